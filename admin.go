@@ -0,0 +1,36 @@
+package main
+
+import (
+	_ "embed"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// adminPage is the single-page admin dashboard. It only calls the management API
+// (/info, /sessions, /specs, /reload, /polling), so it needs no server-side state.
+//
+//go:embed admin/index.html
+var adminPage []byte
+
+// adminUIEnabled reports whether /admin is served; set DISABLE_ADMIN_UI=true to turn it off
+func adminUIEnabled() bool {
+	return strings.ToLower(os.Getenv("DISABLE_ADMIN_UI")) != "true"
+}
+
+// handleAdmin serves the embedded admin dashboard
+func handleAdmin(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/admin" && r.URL.Path != "/admin/" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeErrorResponse(w, "Method not allowed. Use GET.", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Write(adminPage)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/audit"
+	"github.com/ubermorgenland/openapi-mcp/pkg/export"
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+	"github.com/ubermorgenland/openapi-mcp/pkg/openapi2mcp"
+	"github.com/ubermorgenland/openapi-mcp/pkg/repository"
+	"github.com/ubermorgenland/openapi-mcp/pkg/services"
+)
+
+var (
+	// auditLog records every tool call to the sinks of MCP_AUDIT_LOG; nil when it is not set
+	auditLog *audit.Logger
+	// auditRecords reads the audit records written to the database, for GET /audit
+	auditRecords *services.AuditLogService
+)
+
+// startAuditLog starts the audit log configured in MCP_AUDIT_LOG. db is nil without a
+// database. It must run before specs are mounted, so their tools are audited; it runs once.
+func startAuditLog(db *sql.DB) {
+	spec := os.Getenv("MCP_AUDIT_LOG")
+	if spec == "" || auditLog != nil {
+		return
+	}
+	var records *services.AuditLogService
+	var database export.Sink
+	if db != nil {
+		records = services.NewAuditLogService(db)
+		database = records
+	}
+	sinks, err := audit.ParseSinks(spec, database)
+	if err == nil {
+		auditLog, err = audit.New(sinks)
+	}
+	if err != nil {
+		addStartupWarning("Audit log disabled: %v", err)
+		return
+	}
+	for _, sc := range sinks {
+		if sc.Sink != nil {
+			auditRecords = records
+		}
+	}
+	openapi2mcp.SetDefaultCallEventSink(openapi2mcp.CallEventSinks(openapi2mcp.DefaultCallEventSink(), auditLog))
+	log.Printf("Auditing tool calls to: %s", strings.Join(auditLog.Sinks(), ", "))
+}
+
+// stopAuditLog writes the buffered audit records, waiting at most 5 seconds
+func stopAuditLog() {
+	if auditLog == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	auditLog.Close(ctx)
+}
+
+// auditStats returns the counters of the audit sinks for /analytics
+func auditStats() []export.SinkStats {
+	if auditLog == nil {
+		return []export.SinkStats{}
+	}
+	return auditLog.Stats()
+}
+
+// handleAudit serves GET /audit?endpoint=&tool=&session=&since=&limit=100: the most recent
+// audit records written to the database
+func handleAudit(w http.ResponseWriter, r *http.Request) {
+	if auditRecords == nil {
+		writeErrorResponse(w, "Audit log is not written to the database (set MCP_AUDIT_LOG=database in database mode)", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	filter := repository.AuditFilter{Endpoint: query.Get("endpoint"), Tool: query.Get("tool"), SessionID: query.Get("session")}
+	if v := query.Get("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeErrorResponse(w, "Invalid since: use an RFC 3339 time such as 2024-01-02T15:04:05Z", http.StatusBadRequest)
+			return
+		}
+		filter.Since = since.UTC()
+	}
+	limit := 100
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 1000 {
+			writeErrorResponse(w, "Invalid limit: use 1-1000", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	records, err := auditRecords.List(filter, limit)
+	if err != nil {
+		writeErrorResponse(w, "Failed to read the audit log", http.StatusInternalServerError)
+		return
+	}
+	if records == nil {
+		records = []*models.AuditRecord{}
+	}
+	writeSuccessResponse(w, "Audit records retrieved successfully", records)
+}
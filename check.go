@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/services"
+)
+
+// runStartupCheck validates the configuration, database and specs the server would start
+// with, prints a report and returns the exit code: 1 when a check failed. It is run by
+// `openapi-mcp --check`, e.g. as an init container, and serves nothing.
+func runStartupCheck() int {
+	report := services.RunStartupChecks(context.Background(), services.CheckOptions{
+		SpecsDir: "./specs",
+		Addr:     ":8080",
+		Embedded: len(embeddedSpecFiles()),
+	})
+	checkServerConfig(report)
+
+	fmt.Printf("openapi-mcp %s startup check\n\n", version)
+	report.Print(os.Stdout)
+	if report.Failed() {
+		return 1
+	}
+	return 0
+}
+
+// checkServerConfig checks the server settings read from the environment, which the server
+// itself only logs and replaces with defaults when invalid
+func checkServerConfig(report *services.CheckReport) {
+	if v := os.Getenv("POLLING_INTERVAL"); v != "" {
+		if n, err := strconv.Atoi(v); err != nil || n <= 0 {
+			report.Add("POLLING_INTERVAL", services.CheckWarning, fmt.Sprintf("invalid %q, the default of 30 seconds is used", v))
+		}
+	}
+	if v := os.Getenv("MCP_SESSION_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err != nil || d <= 0 {
+			report.Add("MCP_SESSION_TTL", services.CheckWarning, fmt.Sprintf("invalid %q, the default is used", v))
+		}
+	}
+	switch secret := os.Getenv("MCP_SESSION_SECRET"); {
+	case secret == "":
+		report.Add("MCP_SESSION_SECRET", services.CheckWarning, "not set; sessions do not survive restarts")
+	case len(secret) < 32:
+		report.Add("MCP_SESSION_SECRET", services.CheckWarning, "shorter than 32 bytes")
+	default:
+		report.Add("MCP_SESSION_SECRET", services.CheckOK, "set")
+	}
+	if v := os.Getenv("MCP_AUTO_DEACTIVATE"); v != "" {
+		if _, err := strconv.ParseBool(v); err != nil {
+			report.Add("MCP_AUTO_DEACTIVATE", services.CheckWarning, fmt.Sprintf("invalid %q, degraded specs stay active", v))
+		}
+	}
+}
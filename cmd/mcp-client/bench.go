@@ -0,0 +1,441 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// benchClient sends requests to the server under test and returns their responses.
+type benchClient interface {
+	call(message json.RawMessage, id string) (json.RawMessage, error)
+	close()
+}
+
+// benchReport is the outcome of a bench run, printed as JSON with --machine.
+type benchReport struct {
+	Tool        string         `json:"tool"`
+	Concurrency int            `json:"concurrency"`
+	Calls       int            `json:"calls"`
+	Errors      int            `json:"errors"`
+	ErrorRate   float64        `json:"error_rate"`
+	DurationMs  float64        `json:"duration_ms"`
+	CallsPerSec float64        `json:"calls_per_second"`
+	LatencyMs   benchLatency   `json:"latency_ms"`
+	TopErrors   []benchErrorCt `json:"top_errors,omitempty"`
+}
+
+// benchLatency are the latency statistics of all calls, failed ones included.
+type benchLatency struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// benchErrorCt is an error message and how many calls failed with it.
+type benchErrorCt struct {
+	Error string `json:"error"`
+	Count int    `json:"count"`
+}
+
+// benchResult is the outcome of one call.
+type benchResult struct {
+	latency time.Duration
+	err     string
+}
+
+// runBench drives a server with concurrent calls of one tool and reports latency
+// percentiles and the error rate:
+//
+//	mcp-client bench --concurrency N --calls M <tool> <args-json> <endpoint-url | server-command [args...]>
+//
+// Against an HTTP endpoint, every worker has its own session, like separate clients. A
+// server command is started once over stdio and its calls are multiplexed by request ID.
+// It returns the exit code: 1 when a call fails.
+func runBench(flags *cliFlags, args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	concurrency := fs.Int("concurrency", 10, "Number of concurrent callers")
+	calls := fs.Int("calls", 100, "Total number of tool calls")
+	timeout := fs.Duration("timeout", time.Minute, "Timeout of each call")
+	fs.BoolVar(&flags.machine, "machine", flags.machine, "Print the report as JSON")
+	fs.BoolVar(&flags.quiet, "quiet", flags.quiet, "Only print the report")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	rest := fs.Args()
+	if len(rest) < 2 || *concurrency < 1 || *calls < 1 {
+		fmt.Fprintln(os.Stderr, "Usage: mcp-client bench [--concurrency N] [--calls M] [--timeout D] <tool> [args-json] <endpoint-url | server-command [args...]>")
+		return 1
+	}
+	tool, toolArgs, target := rest[0], json.RawMessage(`{}`), rest[1:]
+	if strings.HasPrefix(strings.TrimSpace(rest[1]), "{") {
+		toolArgs, target = json.RawMessage(rest[1]), rest[2:]
+		if !json.Valid(toolArgs) {
+			fmt.Fprintln(os.Stderr, "Tool arguments are not valid JSON:", rest[1])
+			return 1
+		}
+	}
+	if len(target) == 0 {
+		fmt.Fprintln(os.Stderr, "Missing the endpoint URL or server command to benchmark")
+		return 1
+	}
+	if *concurrency > *calls {
+		*concurrency = *calls
+	}
+
+	var nextID atomic.Int64
+	clients, err := benchClients(target, *concurrency, *timeout, &nextID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to connect:", err)
+		return 1
+	}
+	defer func() {
+		for _, c := range clients {
+			c.close()
+		}
+	}()
+	if !flags.quiet && !flags.machine {
+		fmt.Printf("Calling %s %d times with %d concurrent callers...\n", tool, *calls, *concurrency)
+	}
+
+	jobs := make(chan struct{}, *calls)
+	for i := 0; i < *calls; i++ {
+		jobs <- struct{}{}
+	}
+	close(jobs)
+	results := make(chan benchResult, *calls)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := 0; w < *concurrency; w++ {
+		wg.Add(1)
+		go func(client benchClient) {
+			defer wg.Done()
+			for range jobs {
+				id := nextID.Add(1)
+				message, _ := json.Marshal(map[string]any{
+					"jsonrpc": "2.0",
+					"id":      id,
+					"method":  "tools/call",
+					"params":  map[string]any{"name": tool, "arguments": toolArgs},
+				})
+				callStart := time.Now()
+				response, err := client.call(message, fmt.Sprint(id))
+				result := benchResult{latency: time.Since(callStart)}
+				if err != nil {
+					result.err = err.Error()
+				} else {
+					result.err = toolCallError(response)
+				}
+				results <- result
+			}
+		}(clients[w%len(clients)])
+	}
+	wg.Wait()
+	close(results)
+
+	report := summarizeBench(tool, *concurrency, time.Since(start), results)
+	if flags.machine {
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		printBenchReport(report)
+	}
+	if report.Errors > 0 {
+		return 1
+	}
+	return 0
+}
+
+// benchClients connects to the server under test: one session per worker for HTTP
+// endpoints, or one server process shared by all workers.
+func benchClients(target []string, workers int, timeout time.Duration, nextID *atomic.Int64) ([]benchClient, error) {
+	if strings.HasPrefix(target[0], "http://") || strings.HasPrefix(target[0], "https://") {
+		clients := make([]benchClient, 0, workers)
+		for i := 0; i < workers; i++ {
+			c := &httpBenchClient{httpReplayTransport{url: target[0], client: &http.Client{Timeout: timeout}}}
+			if err := benchInitialize(c, nextID); err != nil {
+				for _, opened := range clients {
+					opened.close()
+				}
+				c.close()
+				return nil, err
+			}
+			clients = append(clients, c)
+		}
+		return clients, nil
+	}
+	c, err := startStdioBench(target, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if err := benchInitialize(c, nextID); err != nil {
+		c.close()
+		return nil, err
+	}
+	return []benchClient{c}, nil
+}
+
+// benchInitialize runs the initialize handshake of a client.
+func benchInitialize(c benchClient, nextID *atomic.Int64) error {
+	id := nextID.Add(1)
+	message, _ := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  "initialize",
+		"params": map[string]any{
+			"protocolVersion": "2025-03-26",
+			"capabilities":    map[string]any{},
+			"clientInfo":      map[string]any{"name": "mcp-client-bench", "version": "1.0.0"},
+		},
+	})
+	response, err := c.call(message, fmt.Sprint(id))
+	if err != nil {
+		return err
+	}
+	var msg struct {
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(response, &msg) == nil && msg.Error != nil {
+		return errors.New(msg.Error.Message)
+	}
+	_, err = c.call(json.RawMessage(`{"jsonrpc":"2.0","method":"notifications/initialized"}`), "")
+	return err
+}
+
+// toolCallError returns why a tools/call response failed: a JSON-RPC error or a tool
+// result with isError. It returns "" for successful calls.
+func toolCallError(response json.RawMessage) string {
+	var msg struct {
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+		Result *struct {
+			IsError bool `json:"isError"`
+			Content []struct {
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(response, &msg); err != nil {
+		return "invalid response: " + err.Error()
+	}
+	switch {
+	case msg.Error != nil:
+		return msg.Error.Message
+	case msg.Result == nil:
+		return "response has no result"
+	case msg.Result.IsError:
+		text := "tool returned an error"
+		if len(msg.Result.Content) > 0 && msg.Result.Content[0].Text != "" {
+			text = msg.Result.Content[0].Text
+		}
+		line, _, _ := strings.Cut(text, "\n")
+		if len(line) > 120 {
+			return line[:120] + "..."
+		}
+		return line
+	}
+	return ""
+}
+
+// summarizeBench computes the report of a run from the results of its calls.
+func summarizeBench(tool string, concurrency int, elapsed time.Duration, results <-chan benchResult) benchReport {
+	report := benchReport{Tool: tool, Concurrency: concurrency, DurationMs: ms(elapsed)}
+	var latencies []float64
+	var total float64
+	errorCounts := map[string]int{}
+	for r := range results {
+		report.Calls++
+		latencies = append(latencies, ms(r.latency))
+		total += ms(r.latency)
+		if r.err != "" {
+			report.Errors++
+			errorCounts[r.err]++
+		}
+	}
+	if report.Calls == 0 {
+		return report
+	}
+	sort.Float64s(latencies)
+	report.ErrorRate = float64(report.Errors) / float64(report.Calls)
+	if elapsed > 0 {
+		report.CallsPerSec = float64(report.Calls) / elapsed.Seconds()
+	}
+	report.LatencyMs = benchLatency{
+		Min:  latencies[0],
+		Mean: math.Round(total/float64(len(latencies))*100) / 100,
+		P50:  percentile(latencies, 50),
+		P90:  percentile(latencies, 90),
+		P95:  percentile(latencies, 95),
+		P99:  percentile(latencies, 99),
+		Max:  latencies[len(latencies)-1],
+	}
+	for msg, n := range errorCounts {
+		report.TopErrors = append(report.TopErrors, benchErrorCt{Error: msg, Count: n})
+	}
+	sort.Slice(report.TopErrors, func(i, j int) bool {
+		if report.TopErrors[i].Count != report.TopErrors[j].Count {
+			return report.TopErrors[i].Count > report.TopErrors[j].Count
+		}
+		return report.TopErrors[i].Error < report.TopErrors[j].Error
+	})
+	if len(report.TopErrors) > 5 {
+		report.TopErrors = report.TopErrors[:5]
+	}
+	return report
+}
+
+// percentile returns the nearest-rank percentile p of sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func ms(d time.Duration) float64 {
+	return math.Round(float64(d.Microseconds())/10) / 100
+}
+
+func printBenchReport(r benchReport) {
+	fmt.Printf("\n%s: %d calls with %d concurrent callers in %.0fms (%.1f calls/s)\n", r.Tool, r.Calls, r.Concurrency, r.DurationMs, r.CallsPerSec)
+	fmt.Printf("Errors:  %d (%.1f%%)\n", r.Errors, r.ErrorRate*100)
+	l := r.LatencyMs
+	fmt.Printf("Latency: min %.1fms  mean %.1fms  p50 %.1fms  p90 %.1fms  p95 %.1fms  p99 %.1fms  max %.1fms\n",
+		l.Min, l.Mean, l.P50, l.P90, l.P95, l.P99, l.Max)
+	for _, e := range r.TopErrors {
+		fmt.Printf("  %5d x %s\n", e.Count, e.Error)
+	}
+}
+
+// httpBenchClient is one session on a streamable HTTP endpoint, used by one worker.
+type httpBenchClient struct {
+	t httpReplayTransport
+}
+
+func (c *httpBenchClient) call(message json.RawMessage, id string) (json.RawMessage, error) {
+	return c.t.send(message, id != "")
+}
+
+func (c *httpBenchClient) close() {
+	c.t.close()
+}
+
+// stdioBenchClient shares one server process between workers: requests are written one at
+// a time, and a reader hands each response to the caller waiting for its ID.
+type stdioBenchClient struct {
+	cmd     *exec.Cmd
+	in      io.WriteCloser
+	timeout time.Duration
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	pending map[string]chan json.RawMessage
+	done    chan struct{}
+	err     error
+}
+
+func startStdioBench(args []string, timeout time.Duration) (*stdioBenchClient, error) {
+	cmd := exec.Command(args[0], args[1:]...)
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	c := &stdioBenchClient{cmd: cmd, in: in, timeout: timeout, pending: map[string]chan json.RawMessage{}, done: make(chan struct{})}
+	go c.read(bufio.NewReader(out))
+	return c, nil
+}
+
+// read dispatches the server's responses until it exits; notifications and requests from
+// the server are skipped.
+func (c *stdioBenchClient) read(out *bufio.Reader) {
+	for {
+		line, err := out.ReadBytes('\n')
+		if err != nil {
+			c.mu.Lock()
+			c.err = fmt.Errorf("server closed: %w", err)
+			c.mu.Unlock()
+			close(c.done)
+			return
+		}
+		var msg struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if json.Unmarshal(line, &msg) != nil || msg.Method != "" || len(msg.ID) == 0 {
+			continue
+		}
+		c.mu.Lock()
+		ch := c.pending[string(msg.ID)]
+		delete(c.pending, string(msg.ID))
+		c.mu.Unlock()
+		if ch != nil {
+			ch <- json.RawMessage(bytes.TrimSpace(line))
+		}
+	}
+}
+
+func (c *stdioBenchClient) call(message json.RawMessage, id string) (json.RawMessage, error) {
+	var ch chan json.RawMessage
+	if id != "" {
+		ch = make(chan json.RawMessage, 1)
+		c.mu.Lock()
+		c.pending[id] = ch
+		c.mu.Unlock()
+	}
+	c.writeMu.Lock()
+	_, err := c.in.Write(append(bytes.TrimSpace(message), '\n'))
+	c.writeMu.Unlock()
+	if err != nil || ch == nil {
+		return nil, err
+	}
+	select {
+	case response := <-ch:
+		return response, nil
+	case <-c.done:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return nil, c.err
+	case <-time.After(c.timeout):
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return nil, fmt.Errorf("no response within %s", c.timeout)
+	}
+}
+
+func (c *stdioBenchClient) close() {
+	c.in.Close()
+	if c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+	}
+	c.cmd.Wait()
+}
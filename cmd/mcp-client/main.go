@@ -19,6 +19,8 @@ type cliFlags struct {
 	showHelp bool
 	quiet    bool
 	machine  bool
+	replay   string
+	realtime bool
 	args     []string
 }
 
@@ -29,6 +31,8 @@ func parseFlags() *cliFlags {
 	flag.BoolVar(&flags.showHelp, "help", false, "Show help")
 	flag.BoolVar(&flags.quiet, "quiet", false, "Suppress banners and non-essential output")
 	flag.BoolVar(&flags.machine, "machine", false, "Minimal output: only print raw result")
+	flag.StringVar(&flags.replay, "replay", "", "Replay a recorded session transcript against a server")
+	flag.BoolVar(&flags.realtime, "realtime", false, "With --replay, keep the recorded delays between messages")
 	flag.Parse()
 	flags.args = flag.Args()
 	return &flags
@@ -40,12 +44,28 @@ func printHelp() {
 
 Usage:
   mcp-client <server-command> [args...]
+  mcp-client --replay <transcript> <server-command> [args...]
+  mcp-client --replay <transcript> <endpoint-url>
+  mcp-client bench [--concurrency N] [--calls M] [--timeout D] <tool> [json-args] <endpoint-url | server-command [args...]>
 
 Flags:
   --quiet              Suppress banners and non-essential output
   --machine            Minimal output: only print raw result
+  --replay <file>      Re-send the client messages of a session transcript (recorded with
+                       MCP_TRANSCRIPT_DIR) and compare each response with the recorded one;
+                       exits with 1 when a response differs
+  --realtime           With --replay, keep the recorded delays between messages
   --help, -h           Show help
 
+Bench:
+  Calls one tool M times from N concurrent callers and reports throughput, the error
+  rate and latency percentiles (p50/p90/p95/p99); exits with 1 when a call fails.
+  Against an endpoint URL every caller has its own session; a server command is started
+  once and its calls are multiplexed over stdio.
+  --concurrency <n>    Number of concurrent callers (default 10)
+  --calls <m>          Total number of tool calls (default 100)
+  --timeout <d>        Timeout of each call (default 1m)
+
 By default, output is human-friendly. Use --machine or --quiet for minimal/agent output.
 `)
 	os.Exit(0)
@@ -63,6 +83,14 @@ func main() {
 		os.Exit(0)
 	}
 
+	if flags.replay != "" {
+		os.Exit(runReplay(flags))
+	}
+
+	if len(flags.args) > 0 && flags.args[0] == "bench" {
+		os.Exit(runBench(flags, flags.args[1:]))
+	}
+
 	if len(os.Args) < 2 {
 		fmt.Fprintln(os.Stderr, "Usage: mcp-client <server-command> [args...]")
 		os.Exit(1)
@@ -96,38 +124,50 @@ func main() {
 		toolSchemas = make(map[string]map[string]any)
 	)
 
-	// Fetch tool list and schema at startup only
-	msg := map[string]any{
-		"jsonrpc": "2.0",
-		"id":      id,
-		"method":  "tools/list",
-		"params":  map[string]any{},
-	}
-	id++
-	_ = json.NewEncoder(serverIn).Encode(msg)
+	// Fetch tool list and schema at startup only, following nextCursor through every page
+	cursor := ""
 	for {
-		line, err := serverReader.ReadString('\n')
-		if err != nil {
-			break
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		msg := map[string]any{
+			"jsonrpc": "2.0",
+			"id":      id,
+			"method":  "tools/list",
+			"params":  params,
 		}
-		var obj map[string]any
-		if err := json.Unmarshal([]byte(line), &obj); err == nil {
-			if result, ok := obj["result"]; ok {
-				if tools, ok := result.(map[string]any)["tools"]; ok {
-					if arr, ok := tools.([]any); ok {
-						for _, t := range arr {
-							if tmap, ok := t.(map[string]any); ok {
-								if name, ok := tmap["name"].(string); ok {
-									toolNames = append(toolNames, name)
-									if schema, ok := tmap["inputSchema"].(map[string]any); ok {
-										toolSchemas[name] = schema
+		id++
+		_ = json.NewEncoder(serverIn).Encode(msg)
+		cursor = ""
+		for {
+			line, err := serverReader.ReadString('\n')
+			if err != nil {
+				break
+			}
+			var obj map[string]any
+			if err := json.Unmarshal([]byte(line), &obj); err == nil {
+				if result, ok := obj["result"].(map[string]any); ok {
+					if tools, ok := result["tools"]; ok {
+						if arr, ok := tools.([]any); ok {
+							for _, t := range arr {
+								if tmap, ok := t.(map[string]any); ok {
+									if name, ok := tmap["name"].(string); ok {
+										toolNames = append(toolNames, name)
+										if schema, ok := tmap["inputSchema"].(map[string]any); ok {
+											toolSchemas[name] = schema
+										}
 									}
 								}
 							}
 						}
 					}
+					cursor, _ = result["nextCursor"].(string)
 				}
+				break
 			}
+		}
+		if cursor == "" {
 			break
 		}
 	}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+// replayTransport sends recorded messages to the server under test.
+type replayTransport interface {
+	// send sends a message and, when it is a request, returns the server's response.
+	send(message json.RawMessage, isRequest bool) (json.RawMessage, error)
+	close()
+}
+
+// replayResult is the outcome of one replayed request, printed with --machine.
+type replayResult struct {
+	Index    int             `json:"index"`
+	Method   string          `json:"method"`
+	Tool     string          `json:"tool,omitempty"`
+	Status   string          `json:"status"` // same, differs, new (no recorded response) or failed
+	Recorded json.RawMessage `json:"recorded,omitempty"`
+	Replayed json.RawMessage `json:"replayed,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// runReplay re-sends the client messages of a recorded session transcript (see
+// MCP_TRANSCRIPT_DIR) to a server, a command started over stdio or an HTTP endpoint URL,
+// and compares each response with the recorded one. It returns the exit code: 1 when a
+// response differs or a request fails.
+func runReplay(flags *cliFlags) int {
+	f, err := os.Open(flags.replay)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to open transcript:", err)
+		return 1
+	}
+	entries, err := mcpserver.ReadTranscript(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to read transcript:", err)
+		return 1
+	}
+	if len(flags.args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: mcp-client --replay <transcript> <server-command> [args...] | <endpoint-url>")
+		return 1
+	}
+
+	var transport replayTransport
+	if strings.HasPrefix(flags.args[0], "http://") || strings.HasPrefix(flags.args[0], "https://") {
+		transport = &httpReplayTransport{url: flags.args[0], client: &http.Client{Timeout: 5 * time.Minute}}
+	} else {
+		transport, err = startStdioReplay(flags.args)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to start server:", err)
+			return 1
+		}
+	}
+	defer transport.close()
+
+	var results []replayResult
+	var previous time.Time
+	failed := false
+	for i, entry := range entries {
+		if entry.Direction != mcpserver.TranscriptIn {
+			continue
+		}
+		if flags.realtime && !previous.IsZero() && entry.Time.After(previous) {
+			time.Sleep(entry.Time.Sub(previous))
+		}
+		previous = entry.Time
+
+		var msg struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params struct {
+				Name string `json:"name"`
+			} `json:"params"`
+		}
+		if err := json.Unmarshal(entry.Message, &msg); err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping invalid message %d: %v\n", i+1, err)
+			continue
+		}
+		isRequest := len(msg.ID) > 0 && string(msg.ID) != "null"
+		replayed, err := transport.send(entry.Message, isRequest)
+		if !isRequest {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to send %s: %v\n", msg.Method, err)
+			}
+			continue
+		}
+
+		result := replayResult{Index: i + 1, Method: msg.Method, Tool: msg.Params.Name, Replayed: replayed}
+		result.Recorded = recordedResponse(entries[i+1:], msg.ID)
+		switch {
+		case err != nil:
+			result.Status, result.Error = "failed", err.Error()
+		case result.Recorded == nil:
+			result.Status = "new"
+		case sameResponse(result.Recorded, replayed):
+			result.Status = "same"
+		default:
+			result.Status = "differs"
+		}
+		if result.Status == "failed" || result.Status == "differs" {
+			failed = true
+		}
+		results = append(results, result)
+		if !flags.machine {
+			printReplayResult(result, flags.quiet)
+		}
+	}
+
+	if flags.machine {
+		out, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Println(string(out))
+	} else if !flags.quiet {
+		counts := map[string]int{}
+		for _, r := range results {
+			counts[r.Status]++
+		}
+		fmt.Printf("\nReplayed %d requests: %d same, %d differ, %d failed, %d not recorded\n",
+			len(results), counts["same"], counts["differs"], counts["failed"], counts["new"])
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// recordedResponse finds the recorded response with the given request ID.
+func recordedResponse(entries []mcpserver.TranscriptEntry, id json.RawMessage) json.RawMessage {
+	for _, entry := range entries {
+		if entry.Direction != mcpserver.TranscriptOut {
+			continue
+		}
+		var msg struct {
+			ID json.RawMessage `json:"id"`
+		}
+		if json.Unmarshal(entry.Message, &msg) == nil && bytes.Equal(msg.ID, id) {
+			return entry.Message
+		}
+	}
+	return nil
+}
+
+// sameResponse compares two JSON-RPC messages regardless of formatting and key order.
+func sameResponse(a, b json.RawMessage) bool {
+	return canonicalJSON(a) == canonicalJSON(b)
+}
+
+func canonicalJSON(raw json.RawMessage) string {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return string(raw)
+	}
+	out, _ := json.Marshal(v)
+	return string(out)
+}
+
+func printReplayResult(r replayResult, quiet bool) {
+	name := r.Method
+	if r.Tool != "" {
+		name += " " + r.Tool
+	}
+	fmt.Printf("[%4d] %-40s %s\n", r.Index, name, r.Status)
+	if quiet {
+		return
+	}
+	switch r.Status {
+	case "failed":
+		fmt.Printf("       error: %s\n", r.Error)
+	case "differs":
+		fmt.Printf("       recorded: %s\n       replayed: %s\n", canonicalJSON(r.Recorded), canonicalJSON(r.Replayed))
+	}
+}
+
+// stdioReplayTransport talks to a server command over stdio.
+type stdioReplayTransport struct {
+	cmd *exec.Cmd
+	in  io.WriteCloser
+	out *bufio.Reader
+}
+
+func startStdioReplay(args []string) (*stdioReplayTransport, error) {
+	cmd := exec.Command(args[0], args[1:]...)
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &stdioReplayTransport{cmd: cmd, in: in, out: bufio.NewReader(out)}, nil
+}
+
+func (t *stdioReplayTransport) send(message json.RawMessage, isRequest bool) (json.RawMessage, error) {
+	if _, err := t.in.Write(append(bytes.TrimSpace(message), '\n')); err != nil {
+		return nil, err
+	}
+	if !isRequest {
+		return nil, nil
+	}
+	var id struct {
+		ID json.RawMessage `json:"id"`
+	}
+	_ = json.Unmarshal(message, &id)
+	// Skip notifications and requests from the server until the response arrives
+	for {
+		line, err := t.out.ReadBytes('\n')
+		if err != nil {
+			return nil, fmt.Errorf("server closed: %w", err)
+		}
+		var msg struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if json.Unmarshal(line, &msg) == nil && msg.Method == "" && bytes.Equal(msg.ID, id.ID) {
+			return json.RawMessage(bytes.TrimSpace(line)), nil
+		}
+	}
+}
+
+func (t *stdioReplayTransport) close() {
+	t.in.Close()
+	if t.cmd.Process != nil {
+		t.cmd.Process.Kill()
+	}
+	t.cmd.Wait()
+}
+
+// httpReplayTransport posts messages to a streamable HTTP endpoint, keeping the session ID
+// the server assigns on initialize.
+type httpReplayTransport struct {
+	url       string
+	client    *http.Client
+	sessionID string
+}
+
+func (t *httpReplayTransport) send(message json.RawMessage, isRequest bool) (json.RawMessage, error) {
+	req, err := http.NewRequest(http.MethodPost, t.url, bytes.NewReader(message))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if t.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", t.sessionID)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if id := resp.Header.Get("Mcp-Session-Id"); id != "" {
+		t.sessionID = id
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if !isRequest {
+		return nil, nil
+	}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		// The response is the last data event; earlier ones are notifications
+		var last []byte
+		for _, line := range strings.Split(string(body), "\n") {
+			if data, ok := strings.CutPrefix(line, "data:"); ok {
+				last = []byte(strings.TrimSpace(data))
+			}
+		}
+		return json.RawMessage(last), nil
+	}
+	return json.RawMessage(bytes.TrimSpace(body)), nil
+}
+
+func (t *httpReplayTransport) close() {
+	if t.sessionID == "" {
+		return
+	}
+	req, err := http.NewRequest(http.MethodDelete, t.url, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Mcp-Session-Id", t.sessionID)
+	if resp, err := t.client.Do(req); err == nil {
+		resp.Body.Close()
+	}
+}
@@ -36,6 +36,8 @@ type cliFlags struct {
 	functionListFile   string     // Path to file listing functions to include (for filter command)
 	logFile            string     // Path to file for logging MCP requests and responses
 	noLogTruncation    bool       // Disable truncation in human-readable MCP logs
+	mockUpstream       bool       // Serve tool calls from an example-based mock of each spec
+	allowedMethods     string     // Comma-separated HTTP methods that may become tools (e.g. GET,POST)
 }
 
 type mountFlag struct {
@@ -92,6 +94,8 @@ func parseFlags() *cliFlags {
 	flag.StringVar(&flags.functionListFile, "function-list-file", "", "File with list of function (operationId) names to include (one per line, for filter command)")
 	flag.StringVar(&flags.logFile, "log-file", "", "File path to log all MCP requests and responses for debugging")
 	flag.BoolVar(&flags.noLogTruncation, "no-log-truncation", false, "Disable truncation of long values in human-readable MCP logs")
+	flag.BoolVar(&flags.mockUpstream, "mock-upstream", false, "Send tool calls to an in-process mock API that returns example responses from the spec (no real credentials needed)")
+	flag.StringVar(&flags.allowedMethods, "allowed-methods", "", "Only generate and allow tools for these HTTP methods, comma-separated (e.g. GET,POST)")
 	flag.Parse()
 	flags.args = flag.Args()
 	if flags.extended {
@@ -174,6 +178,11 @@ Examples:
     openapi-mcp --no-confirm-dangerous api.yaml             # Skip confirmations
     openapi-mcp --http-transport=sse --http=:8080 api.yaml  # Use SSE transport
 
+  Demos without API credentials:
+    openapi-mcp --mock-upstream api.yaml                    # Tools call a mock built from spec examples
+    openapi-mcp --http=:8080 --mock-upstream --mount /petstore:petstore.yaml
+    openapi-mcp --allowed-methods GET api.yaml              # Read-only: no POST/PUT/PATCH/DELETE tools
+
 Flags:
   --extended           Enable extended (human-friendly) output (default: minimal/agent)
   --api-key            API key for authenticated endpoints
@@ -200,6 +209,8 @@ Flags:
   --function-list-file   File with list of function (operationId) names to include (one per line, for filter command)
   --log-file           File path to log all MCP requests and responses for debugging
   --no-log-truncation  Disable truncation of long values in human-readable MCP logs
+  --mock-upstream      Send tool calls to an in-process mock API that returns example responses from the spec
+  --allowed-methods    Only generate and allow tools for these HTTP methods, e.g. GET,POST
   --help, -h           Show help
 
 By default, output is minimal and agent-friendly. Use --extended for banners, help, and human-readable output.
@@ -148,6 +148,9 @@ func main() {
 	// Set env vars from flags if provided
 	setEnvFromFlags(flags)
 
+	// Let the runtime manage memory within MCP_MEMORY_LIMIT_MB while specs are registered
+	openapi2mcp.ConfigureMemoryTuning()
+
 	args := flags.args
 
 	// If --mount is used with --http, do not require a positional argument
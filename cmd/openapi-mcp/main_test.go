@@ -46,7 +46,7 @@ func TestRegisterOpenAPITools(t *testing.T) {
 
 	server := mcpserver.NewMCPServer("test", "0.0.1")
 	ops := openapi2mcp.ExtractOpenAPIOperations(doc)
-	openapi2mcp.RegisterOpenAPITools(server, ops, doc, nil)
+	openapi2mcp.RegisterOpenAPITools(server, ops, doc, nil, nil)
 
 	if len(ops) != 2 {
 		t.Fatalf("expected 2 operations, got %d", len(ops))
@@ -170,7 +170,7 @@ func TestHTTPOpenAPIToolHandler(t *testing.T) {
 
 	server := mcpserver.NewMCPServer("test", "0.0.1")
 	ops := openapi2mcp.ExtractOpenAPIOperations(doc)
-	openapi2mcp.RegisterOpenAPITools(server, ops, doc, nil)
+	openapi2mcp.RegisterOpenAPITools(server, ops, doc, nil, nil)
 
 	ctx := context.Background()
 	// Test GET with path and query
@@ -405,7 +405,7 @@ func TestRegisterOpenAPITools_ServerSelection(t *testing.T) {
 
 	server := mcpserver.NewMCPServer("test", "0.0.1")
 	ops := openapi2mcp.ExtractOpenAPIOperations(doc)
-	openapi2mcp.RegisterOpenAPITools(server, ops, doc, nil)
+	openapi2mcp.RegisterOpenAPITools(server, ops, doc, nil, nil)
 
 	ctx := context.Background()
 	for i := 0; i < 20; i++ {
@@ -431,7 +431,7 @@ func TestExternalDocsTool(t *testing.T) {
 	}
 	server := mcpserver.NewMCPServer("test", "0.0.1")
 	ops := openapi2mcp.ExtractOpenAPIOperations(doc)
-	openapi2mcp.RegisterOpenAPITools(server, ops, doc, nil)
+	openapi2mcp.RegisterOpenAPITools(server, ops, doc, nil, nil)
 
 	ctx := context.Background()
 	result := server.HandleMessage(ctx, []byte(`{
@@ -500,7 +500,7 @@ func TestInfoTool(t *testing.T) {
 	}
 	server := mcpserver.NewMCPServer("test", "0.0.1")
 	ops := openapi2mcp.ExtractOpenAPIOperations(doc)
-	openapi2mcp.RegisterOpenAPITools(server, ops, doc, nil)
+	openapi2mcp.RegisterOpenAPITools(server, ops, doc, nil, nil)
 
 	ctx := context.Background()
 	result := server.HandleMessage(ctx, []byte(`{
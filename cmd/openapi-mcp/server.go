@@ -15,8 +15,10 @@ import (
 	"github.com/ubermorgenland/openapi-mcp/pkg/database"
 	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
 	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+	"github.com/ubermorgenland/openapi-mcp/pkg/metrics"
 	"github.com/ubermorgenland/openapi-mcp/pkg/openapi2mcp"
 	"github.com/ubermorgenland/openapi-mcp/pkg/services"
+	"github.com/ubermorgenland/openapi-mcp/pkg/tracing"
 )
 
 // tryLoadFromDatabase attempts to load specs from database if DATABASE_URL is set
@@ -45,9 +47,32 @@ func tryLoadFromDatabase() ([]openapi2mcp.OpenAPIOperation, []*openapi3.T, bool)
 	return ops, docs, true
 }
 
+// applyAllowedMethods records the --allowed-methods policy on doc, so it applies at tool generation and call time.
+func applyAllowedMethods(flags *cliFlags, doc *openapi3.T) {
+	if methods := openapi2mcp.ParseMethodList(flags.allowedMethods); len(methods) > 0 {
+		openapi2mcp.SetAllowedMethods(doc, methods)
+	}
+}
+
+// attachMockUpstream points doc at an example-based mock of itself when --mock-upstream is set.
+// The returned function stops the mock and is always safe to call.
+func attachMockUpstream(flags *cliFlags, doc *openapi3.T) func() {
+	if !flags.mockUpstream || doc == nil {
+		return func() {}
+	}
+	if os.Getenv("OPENAPI_BASE_URL") != "" {
+		fmt.Fprintln(os.Stderr, "[WARN] --mock-upstream ignores --base-url/OPENAPI_BASE_URL.")
+	}
+	mock := openapi2mcp.NewMockUpstream(doc)
+	openapi2mcp.SetMockUpstream(doc, mock.URL)
+	fmt.Fprintf(os.Stderr, "Mock upstream for %q listening on %s\n", doc.Info.Title, mock.URL)
+	return mock.Close
+}
+
 // startServer starts the MCP server in stdio or HTTP mode, based on CLI flags.
 // It registers all OpenAPI operations as MCP tools and starts the server.
 func startServer(flags *cliFlags, ops []openapi2mcp.OpenAPIOperation, doc *openapi3.T) {
+	defer flushTraces()
 	if flags.httpAddr != "" && len(flags.mounts) > 0 {
 		// Check for duplicate base paths
 		basePathCount := make(map[string]int)
@@ -75,6 +100,8 @@ func startServer(flags *cliFlags, ops []openapi2mcp.OpenAPIOperation, doc *opena
 				fmt.Fprintf(os.Stderr, "Failed to load OpenAPI spec for %s: %v\n", m.BasePath, err)
 				os.Exit(1)
 			}
+			applyAllowedMethods(flags, d)
+			defer attachMockUpstream(flags, d)()
 			ops = openapi2mcp.ExtractOpenAPIOperations(d)
 			srv, logFileHandle := createServerWithOptions("openapi-mcp", d.Info.Version, d, ops, flags.logFile, flags.noLogTruncation)
 			if logFileHandle != nil {
@@ -90,8 +117,17 @@ func startServer(flags *cliFlags, ops []openapi2mcp.OpenAPIOperation, doc *opena
 			mux.Handle(m.BasePath, handler) // allow both /base and /base/
 			fmt.Fprintf(os.Stderr, "Mounted %s at %s\n", m.SpecPath, m.BasePath)
 		}
+		if receiver := openapi2mcp.DefaultCallbackReceiver(); receiver != nil {
+			mux.Handle(openapi2mcp.CallbackPathPrefix, receiver)
+		}
+		// Behind a reverse proxy, requests may keep the path prefix of MCP_PUBLIC_URL
+		publicURL, err := openapi2mcp.PublicURL()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] Ignoring MCP_PUBLIC_URL: %v\n", err)
+		}
+		_, prefix, _ := mcpserver.ParsePublicURL(publicURL)
 		fmt.Fprintf(os.Stderr, "Starting multi-mount MCP HTTP server on %s...\n", flags.httpAddr)
-		if err := http.ListenAndServe(flags.httpAddr, mux); err != nil {
+		if err := openapi2mcp.NewHTTPServer(flags.httpAddr, mcpserver.StripPathPrefix(prefix, mux)).ListenAndServe(); err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to start MCP HTTP server: %v\n", err)
 			os.Exit(1)
 		}
@@ -105,6 +141,8 @@ func startServer(flags *cliFlags, ops []openapi2mcp.OpenAPIOperation, doc *opena
 			// Use database specs
 			d := dbDocs[0]
 			ops := dbOps
+			applyAllowedMethods(flags, d)
+			defer attachMockUpstream(flags, d)()
 			srv, logFileHandle := createServerWithOptions("openapi-mcp", d.Info.Version, d, ops, flags.logFile, flags.noLogTruncation)
 			if logFileHandle != nil {
 				defer logFileHandle.Close()
@@ -133,6 +171,8 @@ func startServer(flags *cliFlags, ops []openapi2mcp.OpenAPIOperation, doc *opena
 				fmt.Fprintf(os.Stderr, "Failed to load OpenAPI spec: %v\n", err)
 				os.Exit(1)
 			}
+			applyAllowedMethods(flags, d)
+			defer attachMockUpstream(flags, d)()
 			ops := openapi2mcp.ExtractOpenAPIOperations(d)
 			srv, logFileHandle := createServerWithOptions("openapi-mcp", d.Info.Version, d, ops, flags.logFile, flags.noLogTruncation)
 			if logFileHandle != nil {
@@ -160,6 +200,8 @@ func startServer(flags *cliFlags, ops []openapi2mcp.OpenAPIOperation, doc *opena
 		// Use first doc for server info, combine all operations
 		d := dbDocs[0]
 		ops = dbOps
+		applyAllowedMethods(flags, d)
+		defer attachMockUpstream(flags, d)()
 		srv, logFileHandle := createServerWithOptions("openapi-mcp", d.Info.Version, d, ops, flags.logFile, flags.noLogTruncation)
 		if logFileHandle != nil {
 			defer logFileHandle.Close()
@@ -182,6 +224,8 @@ func startServer(flags *cliFlags, ops []openapi2mcp.OpenAPIOperation, doc *opena
 			fmt.Fprintf(os.Stderr, "Failed to load OpenAPI spec: %v\n", err)
 			os.Exit(1)
 		}
+		applyAllowedMethods(flags, d)
+		defer attachMockUpstream(flags, d)()
 		ops = openapi2mcp.ExtractOpenAPIOperations(d)
 		srv, logFileHandle := createServerWithOptions("openapi-mcp", d.Info.Version, d, ops, flags.logFile, flags.noLogTruncation)
 		if logFileHandle != nil {
@@ -196,6 +240,13 @@ func startServer(flags *cliFlags, ops []openapi2mcp.OpenAPIOperation, doc *opena
 	}
 }
 
+// flushTraces exports the trace spans still buffered when the server stops.
+func flushTraces() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	tracing.Shutdown(ctx)
+}
+
 // makeMCPHandler returns an http.Handler that serves the MCP server at the given basePath.
 func makeMCPHandler(srv *mcpserver.MCPServer, basePath string) http.Handler {
 	return openapi2mcp.HandlerForBasePath(srv, basePath)
@@ -479,7 +530,7 @@ func createLoggingHooks(logFilePath string, noLogTruncation bool) (*mcpserver.Ho
 
 // createServerWithOptions creates a new MCP server with the given operations and optional logging
 func createServerWithOptions(name, version string, doc *openapi3.T, ops []openapi2mcp.OpenAPIOperation, logFile string, noLogTruncation bool) (*mcpserver.MCPServer, *os.File) {
-	var opts []mcpserver.ServerOption
+	opts := openapi2mcp.ServerOptions()
 	var logFileHandle *os.File
 
 	if logFile != "" {
@@ -489,11 +540,11 @@ func createServerWithOptions(name, version string, doc *openapi3.T, ops []openap
 			os.Exit(1)
 		}
 		logFileHandle = fileHandle
-		opts = append(opts, mcpserver.WithHooks(hooks))
+		opts = append(opts, mcpserver.WithHooks(metrics.InstrumentHooks(hooks)))
 		fmt.Fprintf(os.Stderr, "Logging MCP requests and responses to: %s\n", logFile)
 	}
 
 	srv := mcpserver.NewMCPServer(name, version, opts...)
-	openapi2mcp.RegisterOpenAPITools(srv, ops, doc, nil)
+	openapi2mcp.RegisterOpenAPITools(srv, ops, doc, nil, nil)
 	return srv, logFileHandle
 }
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"slices"
+	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/ubermorgenland/openapi-mcp/pkg/openapi2mcp"
@@ -20,8 +22,9 @@ func handleDryRunMode(flags *cliFlags, ops []openapi2mcp.OpenAPIOperation, doc *
 		PrettyPrint:             true,
 		Version:                 doc.Info.Version,
 		ConfirmDangerousActions: !flags.noConfirmDangerous,
+		AllowedMethods:          openapi2mcp.ParseMethodList(flags.allowedMethods),
 	}
-	openapi2mcp.RegisterOpenAPITools(nil, ops, doc, opts)
+	openapi2mcp.RegisterOpenAPITools(nil, ops, doc, opts, nil)
 	if flags.summary {
 		openapi2mcp.PrintToolSummary(ops)
 	}
@@ -50,6 +53,9 @@ func compareWithDiffFile(opts *openapi2mcp.ToolGenOptions, doc *openapi3.T, ops
 				continue
 			}
 		}
+		if len(opts.AllowedMethods) > 0 && !slices.Contains(opts.AllowedMethods, strings.ToUpper(op.Method)) {
+			continue
+		}
 		name := op.OperationID
 		if opts.NameFormat != nil {
 			name = opts.NameFormat(name)
@@ -15,10 +15,11 @@ import (
 
 // SpecConfig defines how each spec should be imported
 type SpecConfig struct {
-	File         string `json:"file" yaml:"file"`
-	Name         string `json:"name" yaml:"name"`
-	EndpointPath string `json:"endpoint_path" yaml:"endpoint_path"`
-	Active       bool   `json:"active" yaml:"active"`
+	File              string   `json:"file" yaml:"file"`
+	Name              string   `json:"name" yaml:"name"`
+	EndpointPath      string   `json:"endpoint_path" yaml:"endpoint_path"`
+	Active            bool     `json:"active" yaml:"active"`
+	ExcludeOperations []string `json:"exclude_operations,omitempty" yaml:"exclude_operations,omitempty"` // patterns of operations to strip, e.g. "DELETE /admin/*"
 }
 
 // SeedConfig defines the seeding configuration
@@ -74,7 +75,7 @@ func seedFromConfig(specLoader *services.SpecLoaderService, configFile string) {
 	imported := 0
 	for _, specConfig := range config.Specs {
 		// Import the spec
-		err := specLoader.ImportSpecFromFile(specConfig.File, specConfig.Name, specConfig.EndpointPath)
+		err := specLoader.ImportSpecFromFileWithOptions(specConfig.File, specConfig.Name, specConfig.EndpointPath, services.ImportOptions{ExcludeOperations: specConfig.ExcludeOperations})
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: Failed to import %s: %v\n", specConfig.File, err)
 			continue
@@ -127,7 +128,7 @@ func autoSeed(specLoader *services.SpecLoaderService) {
 		}
 
 		// Import the spec
-		err := specLoader.ImportSpecFromFile(specConfig.File, specConfig.Name, specConfig.EndpointPath)
+		err := specLoader.ImportSpecFromFileWithOptions(specConfig.File, specConfig.Name, specConfig.EndpointPath, services.ImportOptions{ExcludeOperations: specConfig.ExcludeOperations})
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: Failed to import %s: %v\n", specConfig.File, err)
 			continue
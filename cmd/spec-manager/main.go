@@ -1,13 +1,21 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
 	"github.com/ubermorgenland/openapi-mcp/pkg/database"
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+	"github.com/ubermorgenland/openapi-mcp/pkg/openapi2mcp"
+	"github.com/ubermorgenland/openapi-mcp/pkg/secrets"
 	"github.com/ubermorgenland/openapi-mcp/pkg/services"
 )
 
@@ -19,6 +27,12 @@ func main() {
 
 	command := os.Args[1]
 
+	// doctor checks the database without migrating it
+	if command == "doctor" {
+		handleDoctor()
+		return
+	}
+
 	// Initialize database connection
 	if err := database.InitializeDatabase(); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
@@ -32,16 +46,52 @@ func main() {
 		handleList(specLoader)
 	case "import":
 		handleImport(specLoader)
+	case "split":
+		handleSplit(specLoader)
 	case "activate":
 		handleActivate(specLoader)
 	case "deactivate":
 		handleDeactivate(specLoader)
 	case "delete":
 		handleDelete(specLoader)
+	case "restore":
+		handleRestore(specLoader)
+	case "deleted":
+		handleDeletedList(specLoader)
 	case "active":
 		handleActiveList(specLoader)
 	case "set-token":
 		handleSetToken(specLoader)
+	case "set-tokens":
+		handleSetTokens(specLoader)
+	case "set-flags":
+		handleSetFlags(specLoader)
+	case "set-aliases":
+		handleSetAliases(specLoader)
+	case "require-token":
+		handleRequireToken(specLoader)
+	case "set-rate-limit":
+		handleSetRateLimit(specLoader)
+	case "set-tool-rules":
+		handleSetToolRules(specLoader)
+	case "set-upstream-policy":
+		handleSetUpstreamPolicy(specLoader)
+	case "credentials":
+		handleCredentials(specLoader)
+	case "add-credential":
+		handleAddCredential(specLoader)
+	case "remove-credential":
+		handleRemoveCredential(specLoader)
+	case "set-credential-strategy":
+		handleSetCredentialStrategy(specLoader)
+	case "encrypt-tokens":
+		handleEncryptTokens(specLoader)
+	case "test":
+		handleTest(specLoader)
+	case "mcp-config":
+		handleMCPConfig(specLoader)
+	case "migrate-from-files":
+		handleMigrateFromFiles(specLoader)
 	case "help":
 		printHelp()
 	default:
@@ -57,22 +107,81 @@ func printHelp() {
 	fmt.Println("Commands:")
 	fmt.Println("  list                           List all specs in the database")
 	fmt.Println("  active                         List only active specs")
-	fmt.Println("  import <file> <name> <endpoint> Import a spec file into the database")
+	fmt.Println("  import <file> <name> <endpoint> Import a spec file or URL into the database; a docs page URL")
+	fmt.Println("                                 (Swagger UI, Redoc) imports the spec it shows")
+	fmt.Println("  split <file> <name> <endpoint>  Split a large spec by tag and import each part as its own endpoint")
+	fmt.Println("                                 import and split take --exclude=<patterns>: comma-separated globs on")
+	fmt.Println("                                 operationId or \"METHOD /path\" (or re:<regex>) of operations to drop")
 	fmt.Println("  activate <id>                  Activate a spec by ID")
 	fmt.Println("  deactivate <id>                Deactivate a spec by ID")
-	fmt.Println("  delete <id>                    Delete a spec by ID")
+	fmt.Println("  delete <id>                    Delete a spec by ID; it can be restored until it is purged")
+	fmt.Println("  restore <id>                   Restore a deleted spec by ID")
+	fmt.Println("  deleted                        List deleted specs and when they are purged")
 	fmt.Println("  set-token <id> <token>         Set API key token for a spec")
+	fmt.Println("  set-tokens --from-file <file>  Set the tokens of many specs from a .env file (ENDPOINT=token or")
+	fmt.Println("                                 WEATHER_API_KEY=token) or a CSV file of endpoint,token rows;")
+	fmt.Println("                                 --dry-run only matches the entries to specs")
+	fmt.Println("  set-flags <id> <json>          Set feature flags for a spec (\"\" clears them)")
+	fmt.Println("  set-aliases <id> <paths>       Set comma-separated endpoint aliases for a spec (\"\" clears them)")
+	fmt.Println("  require-token <id> <on|off>    Mount a spec without tools while it has no database token or")
+	fmt.Println("                                 environment variable credentials")
+	fmt.Println("  set-rate-limit <id> <limit>    Limit the requests to a spec's endpoint, in total and per client,")
+	fmt.Println("                                 e.g. \"1000/m, client=60/m\" (\"\" uses MCP_RATE_LIMIT)")
+	fmt.Println("  set-tool-rules <id> <json>     Select the operations of a spec that become tools with include and")
+	fmt.Println("                                 exclude rules on tags, operations and methods (\"\" exposes all)")
+	fmt.Println("  set-upstream-policy <id> <json> Set the timeout, retries and circuit breaker of a spec's upstream")
+	fmt.Println("                                 calls (\"\" uses the defaults)")
+	fmt.Println("  credentials <id>               List the API keys of a spec and how calls are spread over them")
+	fmt.Println("  add-credential <id> <token> [label] Add an API key to a spec, used in turn with its token")
+	fmt.Println("  remove-credential <credential-id> Remove an API key added with add-credential")
+	fmt.Println("  set-credential-strategy <id> <strategy> Spread the calls of a spec over its API keys with")
+	fmt.Println("                                 round-robin or failover (\"\" uses round-robin)")
+	fmt.Println("  encrypt-tokens [--dry-run]     Encrypt the stored tokens that are in plaintext or use a previous key")
+	fmt.Println("                                 with MCP_TOKEN_ENCRYPTION_KEY; --dry-run only counts them")
+	fmt.Println("  test <id>                      Smoke test a spec: call its GET tools against the real API")
+	fmt.Println("  mcp-config <endpoint>          Print the configuration block pointing an MCP client at an endpoint;")
+	fmt.Println("                                 --client=claude|vscode|cursor (default claude), --url=<server URL>")
+	fmt.Println("                                 (default MCP_PUBLIC_URL or http://localhost:8080), --transport=sse")
+	fmt.Println("                                 for servers run with --http-transport=sse, --header=\"Name: value\"")
+	fmt.Println("  migrate-from-files [dir]       Import the specs of a file-mode specs directory (default ./specs),")
+	fmt.Println("                                 keeping their endpoints; --with-tokens stores the tokens of their")
+	fmt.Println("                                 environment variables, --dry-run only checks the files")
+	fmt.Println("  doctor [specs-dir]             Check the database connection, pending migrations, the specs the")
+	fmt.Println("                                 server would mount and their credentials, without changing anything")
 	fmt.Println("  help                           Show this help message")
 	fmt.Println("")
 	fmt.Println("Examples:")
 	fmt.Println("  spec-manager import weather.yaml weather /weather")
+	fmt.Println("  spec-manager import https://api.example.com/docs/ example /example")
+	fmt.Println("  spec-manager split github.yaml github /github")
+	fmt.Println("  spec-manager import stripe.yaml stripe /stripe --exclude='DELETE *,* /v1/admin/*'")
 	fmt.Println("  spec-manager list")
 	fmt.Println("  spec-manager activate 1")
 	fmt.Println("  spec-manager deactivate 1")
+	fmt.Println("  spec-manager restore 1")
 	fmt.Println("  spec-manager set-token 1 \"your_api_token_here\"")
+	fmt.Println("  spec-manager set-tokens --from-file tokens.env --dry-run")
+	fmt.Println("  spec-manager set-flags 1 '{\"experimental-search\": [\"staging\"]}'")
+	fmt.Println("  spec-manager set-aliases 1 /wx,/weather-v1")
+	fmt.Println("  spec-manager require-token 1 on")
+	fmt.Println("  spec-manager set-rate-limit 1 \"1000/m, client=60/m\"")
+	fmt.Println("  spec-manager set-tool-rules 1 '{\"include\": [{\"tags\": [\"pets\"]}], \"exclude\": [{\"methods\": [\"DELETE\"]}]}'")
+	fmt.Println("  spec-manager set-upstream-policy 1 '{\"timeout\": \"60s\", \"max_retries\": 3, \"breaker_threshold\": 10}'")
+	fmt.Println("  spec-manager add-credential 1 \"second_api_token\" team-b")
+	fmt.Println("  spec-manager set-credential-strategy 1 failover")
+	fmt.Println("  spec-manager encrypt-tokens --dry-run")
+	fmt.Println("  spec-manager test 1")
+	fmt.Println("  spec-manager mcp-config /weather --client=vscode --url=https://mcp.example.com")
+	fmt.Println("  spec-manager migrate-from-files ./specs --with-tokens")
+	fmt.Println("  spec-manager doctor")
 	fmt.Println("")
 	fmt.Println("Environment Variables:")
 	fmt.Println("  DATABASE_URL                   PostgreSQL connection string")
+	fmt.Println("  ENVIRONMENT                    Environment feature flags are evaluated against (default: production)")
+	fmt.Println("  SMOKE_TEST_TIMEOUT             Timeout of each smoke test call (default: 30s)")
+	fmt.Println("  MCP_PUBLIC_URL                 Public URL of the server, used by mcp-config (default: http://localhost:8080)")
+	fmt.Println("  MCP_TOKEN_ENCRYPTION_KEY       Key encrypting the stored tokens: 32 bytes in base64 or hex, or a secret reference")
+	fmt.Println("  MCP_DELETED_SPEC_RETENTION     How long deleted specs can be restored before the server purges them (default: 168h)")
 }
 
 func handleList(specLoader *services.SpecLoaderService) {
@@ -124,10 +233,18 @@ func handleList(specLoader *services.SpecLoaderService) {
 		hasToken := "No"
 		if spec.ApiKeyToken != nil && *spec.ApiKeyToken != "" {
 			hasToken = "Yes"
+			if ref, ok := secrets.Default().Parse(*spec.ApiKeyToken); ok {
+				hasToken = "Ref (" + ref.Scheme + ")"
+			}
+		}
+
+		endpoint := spec.EndpointPath
+		if aliases := spec.AliasPaths(); len(aliases) > 0 {
+			endpoint += " (also /" + strings.Join(aliases, ", /") + ")"
 		}
 
 		fmt.Printf("%-4d %-20s %-30s %-10s %-8s %-10s %-12s %s\n",
-			spec.ID, name, title, version, active, format, hasToken, spec.EndpointPath)
+			spec.ID, name, title, version, active, format, hasToken, endpoint)
 	}
 }
 
@@ -175,6 +292,9 @@ func handleActiveList(specLoader *services.SpecLoaderService) {
 		hasToken := "No"
 		if spec.ApiKeyToken != nil && *spec.ApiKeyToken != "" {
 			hasToken = "Yes"
+			if ref, ok := secrets.Default().Parse(*spec.ApiKeyToken); ok {
+				hasToken = "Ref (" + ref.Scheme + ")"
+			}
 		}
 
 		fmt.Printf("%-4d %-20s %-30s %-10s %-10s %-12s %s\n",
@@ -182,17 +302,33 @@ func handleActiveList(specLoader *services.SpecLoaderService) {
 	}
 }
 
+// importArgs returns the positional arguments of an import command and its
+// --exclude=<patterns> options, which may be repeated
+func importArgs(args []string) ([]string, services.ImportOptions) {
+	var positional []string
+	var opts services.ImportOptions
+	for _, arg := range args {
+		if patterns, ok := strings.CutPrefix(arg, "--exclude="); ok {
+			opts.ExcludeOperations = append(opts.ExcludeOperations, openapi2mcp.ParseOperationPatterns(patterns)...)
+			continue
+		}
+		positional = append(positional, arg)
+	}
+	return positional, opts
+}
+
 func handleImport(specLoader *services.SpecLoaderService) {
-	if len(os.Args) < 5 {
-		fmt.Fprintf(os.Stderr, "Usage: spec-manager import <file-path> <name> <endpoint-path>\n")
+	args, opts := importArgs(os.Args[2:])
+	if len(args) < 3 {
+		fmt.Fprintf(os.Stderr, "Usage: spec-manager import <file-path|url> <name> <endpoint-path> [--exclude=<patterns>]\n")
 		os.Exit(1)
 	}
 
-	filePath := os.Args[2]
-	name := os.Args[3]
-	endpointPath := os.Args[4]
+	filePath := args[0]
+	name := args[1]
+	endpointPath := args[2]
 
-	err := specLoader.ImportSpecFromFile(filePath, name, endpointPath)
+	err := specLoader.ImportSpecFromFileWithOptions(filePath, name, endpointPath, opts)
 	if err != nil {
 		log.Fatalf("Failed to import spec: %v", err)
 	}
@@ -200,6 +336,28 @@ func handleImport(specLoader *services.SpecLoaderService) {
 	fmt.Printf("Successfully imported spec '%s' from '%s' with endpoint '%s'\n", name, filePath, endpointPath)
 }
 
+func handleSplit(specLoader *services.SpecLoaderService) {
+	args, opts := importArgs(os.Args[2:])
+	if len(args) < 3 {
+		fmt.Fprintf(os.Stderr, "Usage: spec-manager split <file-path> <name> <endpoint-path> [--exclude=<patterns>]\n")
+		os.Exit(1)
+	}
+
+	filePath := args[0]
+	name := args[1]
+	endpointPath := args[2]
+
+	imported, err := specLoader.ImportSplitSpecFromFile(filePath, name, endpointPath, opts)
+	for _, part := range imported {
+		fmt.Printf("Imported '%s' (tag '%s', %d operations) with endpoint '%s'\n", part.Name, part.Tag, part.Operations, part.EndpointPath)
+	}
+	if err != nil {
+		log.Fatalf("Failed to split spec: %v", err)
+	}
+
+	fmt.Printf("Successfully split '%s' into %d specs\n", filePath, len(imported))
+}
+
 func handleActivate(specLoader *services.SpecLoaderService) {
 	if len(os.Args) < 3 {
 		fmt.Fprintf(os.Stderr, "Usage: spec-manager activate <id>\n")
@@ -254,7 +412,62 @@ func handleDelete(specLoader *services.SpecLoaderService) {
 		log.Fatalf("Failed to delete spec: %v", err)
 	}
 
-	fmt.Printf("Successfully deleted spec with ID %d\n", id)
+	fmt.Printf("Successfully deleted spec with ID %d; restore it with: spec-manager restore %d\n", id, id)
+}
+
+func handleRestore(specLoader *services.SpecLoaderService) {
+	if len(os.Args) < 3 {
+		fmt.Fprintf(os.Stderr, "Usage: spec-manager restore <id>\n")
+		os.Exit(1)
+	}
+
+	id, err := strconv.Atoi(os.Args[2])
+	if err != nil {
+		log.Fatalf("Invalid ID: %v", err)
+	}
+
+	if err := specLoader.RestoreSpec(id); err != nil {
+		log.Fatalf("Failed to restore spec: %v", err)
+	}
+
+	fmt.Printf("Successfully restored spec with ID %d\n", id)
+}
+
+func handleDeletedList(specLoader *services.SpecLoaderService) {
+	specs, err := specLoader.GetDeletedSpecs()
+	if err != nil {
+		log.Fatalf("Failed to get deleted specs: %v", err)
+	}
+
+	if len(specs) == 0 {
+		fmt.Println("No deleted specs found in the database.")
+		return
+	}
+
+	retention := 7 * 24 * time.Hour
+	if v := os.Getenv("MCP_DELETED_SPEC_RETENTION"); v != "" {
+		if retention, err = time.ParseDuration(v); err != nil {
+			log.Fatalf("Invalid MCP_DELETED_SPEC_RETENTION: %v", err)
+		}
+	}
+
+	fmt.Printf("%-4s %-20s %-25s %-20s %s\n", "ID", "Name", "Endpoint", "Deleted", "Purged")
+	fmt.Println(strings.Repeat("-", 95))
+
+	for _, spec := range specs {
+		name := spec.Name
+		if len(name) > 18 {
+			name = name[:18] + "..."
+		}
+		deleted, purged := "", "never"
+		if spec.DeletedAt != nil {
+			deleted = spec.DeletedAt.Format("2006-01-02 15:04")
+			if retention > 0 {
+				purged = spec.DeletedAt.Add(retention).Format("2006-01-02 15:04")
+			}
+		}
+		fmt.Printf("%-4d %-20s %-25s %-20s %s\n", spec.ID, name, spec.EndpointPath, deleted, purged)
+	}
 }
 
 func handleSetToken(specLoader *services.SpecLoaderService) {
@@ -288,3 +501,586 @@ func handleSetToken(specLoader *services.SpecLoaderService) {
 		fmt.Printf("Successfully set API key token for spec with ID %d\n", id)
 	}
 }
+
+func handleSetTokens(specLoader *services.SpecLoaderService) {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, "Usage: spec-manager set-tokens --from-file <tokens.env|tokens.csv> [--dry-run]\n")
+		os.Exit(1)
+	}
+	var file string
+	dryRun := false
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--dry-run":
+			dryRun = true
+		case arg == "--from-file" && i+1 < len(args):
+			i++
+			file = args[i]
+		case strings.HasPrefix(arg, "--from-file="):
+			file = strings.TrimPrefix(arg, "--from-file=")
+		default:
+			usage()
+		}
+	}
+	if file == "" {
+		usage()
+	}
+
+	entries, err := services.ParseTokenFile(file)
+	if err != nil {
+		log.Fatalf("Failed to read tokens: %v", err)
+	}
+	results, err := specLoader.ImportTokens(entries, dryRun)
+	if err != nil {
+		log.Fatalf("Failed to set tokens: %v", err)
+	}
+	if len(results) == 0 {
+		fmt.Printf("No tokens found in %s\n", file)
+		return
+	}
+
+	failed := 0
+	for _, r := range results {
+		target := "no spec with this endpoint"
+		if r.SpecID != 0 {
+			target = fmt.Sprintf("spec %d (%s)", r.SpecID, r.Endpoint)
+		}
+		fmt.Printf("%-9s line %-4d %-30s -> %s\n", strings.ToUpper(r.Status), r.Entry.Line, r.Entry.Key, target)
+		if r.Error != nil {
+			fmt.Printf("          %v\n", r.Error)
+		}
+		if r.Status == services.TokenFailed || r.Status == services.TokenUnknown {
+			failed++
+		}
+	}
+
+	fmt.Println("")
+	if dryRun {
+		fmt.Printf("Dry run: %d entries checked, no token was changed.\n", len(results))
+	}
+	if failed > 0 {
+		fmt.Printf("%d of %d entries could not be set.\n", failed, len(results))
+		os.Exit(1)
+	}
+}
+
+func handleSetFlags(specLoader *services.SpecLoaderService) {
+	if len(os.Args) < 4 {
+		fmt.Fprintf(os.Stderr, "Usage: spec-manager set-flags <id> <json>\n")
+		fmt.Fprintf(os.Stderr, "       spec-manager set-flags <id> \"\"  (to clear flags)\n")
+		os.Exit(1)
+	}
+
+	id, err := strconv.Atoi(os.Args[2])
+	if err != nil {
+		log.Fatalf("Invalid ID: %v", err)
+	}
+
+	flags := os.Args[3]
+	if err := specLoader.UpdateFeatureFlags(id, flags); err != nil {
+		log.Fatalf("Failed to update feature flags: %v", err)
+	}
+
+	if flags == "" {
+		fmt.Printf("Successfully cleared feature flags for spec with ID %d\n", id)
+	} else {
+		fmt.Printf("Successfully set feature flags for spec with ID %d\n", id)
+	}
+}
+
+func handleSetAliases(specLoader *services.SpecLoaderService) {
+	if len(os.Args) < 4 {
+		fmt.Fprintf(os.Stderr, "Usage: spec-manager set-aliases <id> <paths>\n")
+		fmt.Fprintf(os.Stderr, "       spec-manager set-aliases <id> \"\"  (to clear aliases)\n")
+		os.Exit(1)
+	}
+
+	id, err := strconv.Atoi(os.Args[2])
+	if err != nil {
+		log.Fatalf("Invalid ID: %v", err)
+	}
+
+	aliases := os.Args[3]
+	if err := specLoader.UpdateAliases(id, aliases); err != nil {
+		log.Fatalf("Failed to update aliases: %v", err)
+	}
+
+	if strings.TrimSpace(aliases) == "" {
+		fmt.Printf("Successfully cleared aliases for spec with ID %d\n", id)
+	} else {
+		fmt.Printf("Successfully set aliases for spec with ID %d\n", id)
+	}
+}
+
+func handleSetRateLimit(specLoader *services.SpecLoaderService) {
+	if len(os.Args) < 4 {
+		fmt.Fprintf(os.Stderr, "Usage: spec-manager set-rate-limit <id> <limit>\n")
+		fmt.Fprintf(os.Stderr, "       spec-manager set-rate-limit <id> \"\"  (to use the default)\n")
+		os.Exit(1)
+	}
+
+	id, err := strconv.Atoi(os.Args[2])
+	if err != nil {
+		log.Fatalf("Invalid ID: %v", err)
+	}
+
+	rateLimit := os.Args[3]
+	if err := specLoader.UpdateRateLimit(id, rateLimit); err != nil {
+		log.Fatalf("Failed to update rate limit: %v", err)
+	}
+
+	if strings.TrimSpace(rateLimit) == "" {
+		fmt.Printf("Spec with ID %d now uses the default rate limit\n", id)
+	} else {
+		fmt.Printf("Successfully set rate limit for spec with ID %d\n", id)
+	}
+}
+
+func handleSetToolRules(specLoader *services.SpecLoaderService) {
+	if len(os.Args) < 4 {
+		fmt.Fprintf(os.Stderr, "Usage: spec-manager set-tool-rules <id> <json>\n")
+		fmt.Fprintf(os.Stderr, "       spec-manager set-tool-rules <id> \"\"  (to expose all operations)\n")
+		os.Exit(1)
+	}
+
+	id, err := strconv.Atoi(os.Args[2])
+	if err != nil {
+		log.Fatalf("Invalid ID: %v", err)
+	}
+
+	toolRules := os.Args[3]
+	if err := specLoader.UpdateToolRules(id, toolRules); err != nil {
+		log.Fatalf("Failed to update tool rules: %v", err)
+	}
+
+	if strings.TrimSpace(toolRules) == "" {
+		fmt.Printf("Spec with ID %d now exposes all operations\n", id)
+	} else {
+		fmt.Printf("Successfully set tool rules for spec with ID %d\n", id)
+	}
+}
+
+func handleSetUpstreamPolicy(specLoader *services.SpecLoaderService) {
+	if len(os.Args) < 4 {
+		fmt.Fprintf(os.Stderr, "Usage: spec-manager set-upstream-policy <id> <json>\n")
+		fmt.Fprintf(os.Stderr, "       spec-manager set-upstream-policy <id> \"\"  (to use the defaults)\n")
+		os.Exit(1)
+	}
+
+	id, err := strconv.Atoi(os.Args[2])
+	if err != nil {
+		log.Fatalf("Invalid ID: %v", err)
+	}
+
+	upstreamPolicy := os.Args[3]
+	if err := specLoader.UpdateUpstreamPolicy(id, upstreamPolicy); err != nil {
+		log.Fatalf("Failed to update upstream policy: %v", err)
+	}
+
+	if strings.TrimSpace(upstreamPolicy) == "" {
+		fmt.Printf("Spec with ID %d now uses the default upstream policy\n", id)
+	} else {
+		fmt.Printf("Successfully set upstream policy for spec with ID %d\n", id)
+	}
+}
+
+func handleCredentials(specLoader *services.SpecLoaderService) {
+	if len(os.Args) < 3 {
+		fmt.Fprintf(os.Stderr, "Usage: spec-manager credentials <id>\n")
+		os.Exit(1)
+	}
+
+	id, err := strconv.Atoi(os.Args[2])
+	if err != nil {
+		log.Fatalf("Invalid ID: %v", err)
+	}
+
+	specs, err := specLoader.GetAllSpecs()
+	if err != nil {
+		log.Fatalf("Failed to get specs: %v", err)
+	}
+	var spec *models.OpenAPISpec
+	for _, s := range specs {
+		if s.ID == id {
+			spec = s
+		}
+	}
+	if spec == nil {
+		log.Fatalf("Spec with ID %d not found", id)
+	}
+	if spec.Credentials, err = specLoader.ListCredentials(id); err != nil {
+		log.Fatalf("Failed to list credentials: %v", err)
+	}
+
+	creds := auth.SpecCredentials(spec)
+	if len(creds) == 0 {
+		fmt.Printf("Spec '%s' has no API keys in the database.\n", spec.Name)
+		return
+	}
+
+	strategy := auth.CredentialRoundRobin
+	if spec.CredentialStrategy != nil && *spec.CredentialStrategy != "" {
+		strategy = *spec.CredentialStrategy
+	}
+	fmt.Printf("Spec '%s' has %d API key(s), used %s:\n\n", spec.Name, len(creds), strategy)
+	fmt.Printf("%-6s %-24s %s\n", "ID", "Label", "Token")
+	fmt.Println(strings.Repeat("-", 70))
+	for _, cred := range creds {
+		fmt.Printf("%-6s %-24s %s\n", cred.Key, cred.Label, secrets.Describe(cred.Token))
+	}
+}
+
+func handleAddCredential(specLoader *services.SpecLoaderService) {
+	if len(os.Args) < 4 {
+		fmt.Fprintf(os.Stderr, "Usage: spec-manager add-credential <id> <token> [label]\n")
+		os.Exit(1)
+	}
+
+	id, err := strconv.Atoi(os.Args[2])
+	if err != nil {
+		log.Fatalf("Invalid ID: %v", err)
+	}
+
+	label := ""
+	if len(os.Args) > 4 {
+		label = os.Args[4]
+	}
+	cred, err := specLoader.AddCredential(id, os.Args[3], label)
+	if err != nil {
+		log.Fatalf("Failed to add credential: %v", err)
+	}
+
+	fmt.Printf("Successfully added credential %d to spec with ID %d\n", cred.ID, id)
+}
+
+func handleRemoveCredential(specLoader *services.SpecLoaderService) {
+	if len(os.Args) < 3 {
+		fmt.Fprintf(os.Stderr, "Usage: spec-manager remove-credential <credential-id>\n")
+		os.Exit(1)
+	}
+
+	id, err := strconv.Atoi(os.Args[2])
+	if err != nil {
+		log.Fatalf("Invalid ID: %v", err)
+	}
+
+	if err := specLoader.RemoveCredential(id); err != nil {
+		log.Fatalf("Failed to remove credential: %v", err)
+	}
+
+	fmt.Printf("Successfully removed credential %d\n", id)
+}
+
+func handleSetCredentialStrategy(specLoader *services.SpecLoaderService) {
+	if len(os.Args) < 4 {
+		fmt.Fprintf(os.Stderr, "Usage: spec-manager set-credential-strategy <id> <round-robin|failover>\n")
+		fmt.Fprintf(os.Stderr, "       spec-manager set-credential-strategy <id> \"\"  (to use round-robin)\n")
+		os.Exit(1)
+	}
+
+	id, err := strconv.Atoi(os.Args[2])
+	if err != nil {
+		log.Fatalf("Invalid ID: %v", err)
+	}
+
+	strategy := os.Args[3]
+	if err := specLoader.UpdateCredentialStrategy(id, strategy); err != nil {
+		log.Fatalf("Failed to update credential strategy: %v", err)
+	}
+
+	if strings.TrimSpace(strategy) == "" {
+		fmt.Printf("Spec with ID %d now uses its API keys round-robin\n", id)
+	} else {
+		fmt.Printf("Successfully set credential strategy for spec with ID %d\n", id)
+	}
+}
+
+func handleEncryptTokens(specLoader *services.SpecLoaderService) {
+	dryRun := false
+	for _, arg := range os.Args[2:] {
+		if arg != "--dry-run" {
+			fmt.Fprintf(os.Stderr, "Usage: spec-manager encrypt-tokens [--dry-run]\n")
+			os.Exit(1)
+		}
+		dryRun = true
+	}
+
+	result, err := specLoader.EncryptTokens(dryRun)
+	if err != nil {
+		log.Fatalf("Failed to encrypt tokens: %v", err)
+	}
+
+	if dryRun {
+		fmt.Printf("Dry run: %d spec tokens and %d credentials would be encrypted\n", result.SpecTokens, result.CredentialTokens)
+		return
+	}
+	fmt.Printf("Successfully encrypted %d spec tokens and %d credentials\n", result.SpecTokens, result.CredentialTokens)
+}
+
+func handleRequireToken(specLoader *services.SpecLoaderService) {
+	if len(os.Args) < 4 {
+		fmt.Fprintf(os.Stderr, "Usage: spec-manager require-token <id> <on|off>\n")
+		os.Exit(1)
+	}
+
+	id, err := strconv.Atoi(os.Args[2])
+	if err != nil {
+		log.Fatalf("Invalid ID: %v", err)
+	}
+
+	var require bool
+	switch strings.ToLower(os.Args[3]) {
+	case "on", "true", "yes":
+		require = true
+	case "off", "false", "no":
+	default:
+		log.Fatalf("Invalid value %q: use on or off", os.Args[3])
+	}
+
+	if err := specLoader.UpdateRequireToken(id, require); err != nil {
+		log.Fatalf("Failed to update token requirement: %v", err)
+	}
+
+	if require {
+		fmt.Printf("Spec with ID %d is now mounted without tools while it has no credentials\n", id)
+	} else {
+		fmt.Printf("Spec with ID %d is now always mounted with its tools\n", id)
+	}
+}
+
+func handleTest(specLoader *services.SpecLoaderService) {
+	if len(os.Args) < 3 {
+		fmt.Fprintf(os.Stderr, "Usage: spec-manager test <id>\n")
+		os.Exit(1)
+	}
+
+	id, err := strconv.Atoi(os.Args[2])
+	if err != nil {
+		log.Fatalf("Invalid ID: %v", err)
+	}
+
+	timeout := 30 * time.Second
+	if v := os.Getenv("SMOKE_TEST_TIMEOUT"); v != "" {
+		if timeout, err = time.ParseDuration(v); err != nil {
+			log.Fatalf("Invalid SMOKE_TEST_TIMEOUT: %v", err)
+		}
+	}
+
+	loaded, results, err := specLoader.SmokeTestSpec(context.Background(), id, timeout)
+	if err != nil {
+		log.Fatalf("Failed to smoke test spec: %v", err)
+	}
+
+	if len(results) == 0 {
+		fmt.Printf("Spec '%s' has no GET operations to test.\n", loaded.Spec.Name)
+		return
+	}
+
+	fmt.Printf("%-6s %-30s %-8s %s\n", "Result", "Tool", "Time", "Details")
+	fmt.Println(strings.Repeat("-", 80))
+
+	counts := map[string]int{}
+	for _, res := range results {
+		counts[res.Status]++
+		elapsed := ""
+		if res.Status != openapi2mcp.SmokeSkipped {
+			elapsed = res.Duration.Round(time.Millisecond).String()
+		}
+		detail := res.Detail
+		if detail == "" {
+			detail = "GET " + res.Path
+		}
+		fmt.Printf("%-6s %-30s %-8s %s\n", strings.ToUpper(res.Status), res.Tool, elapsed, detail)
+	}
+
+	fmt.Printf("\n%d passed, %d failed, %d skipped for spec '%s' (%s)\n",
+		counts[openapi2mcp.SmokePassed], counts[openapi2mcp.SmokeFailed], counts[openapi2mcp.SmokeSkipped], loaded.Spec.Name, loaded.Spec.EndpointPath)
+	if counts[openapi2mcp.SmokeFailed] > 0 {
+		os.Exit(1)
+	}
+}
+
+func handleMCPConfig(specLoader *services.SpecLoaderService) {
+	usage := "Usage: spec-manager mcp-config <endpoint> [--client=claude|vscode|cursor] [--url=<server URL>] [--transport=streamable|sse] [--header=\"Name: value\"]"
+	opts := services.MCPConfigOptions{Client: services.MCPClientClaude, Headers: map[string]string{}}
+	baseURL := os.Getenv("MCP_PUBLIC_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+	endpoint := ""
+	var headers []string
+	for _, arg := range os.Args[2:] {
+		switch {
+		case strings.HasPrefix(arg, "--client="):
+			opts.Client = strings.TrimPrefix(arg, "--client=")
+		case strings.HasPrefix(arg, "--url="):
+			baseURL = strings.TrimPrefix(arg, "--url=")
+		case strings.HasPrefix(arg, "--transport="):
+			opts.Transport = strings.TrimPrefix(arg, "--transport=")
+		case strings.HasPrefix(arg, "--header="):
+			headers = append(headers, strings.TrimPrefix(arg, "--header="))
+		case strings.HasPrefix(arg, "-") || endpoint != "":
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(1)
+		default:
+			endpoint = strings.Trim(arg, "/")
+		}
+	}
+	if endpoint == "" {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(1)
+	}
+
+	specs, err := specLoader.GetAllSpecs()
+	if err != nil {
+		log.Fatalf("Failed to get specs: %v", err)
+	}
+	var spec *models.OpenAPISpec
+	for _, candidate := range specs {
+		if strings.Trim(candidate.EndpointPath, "/") == endpoint {
+			spec = candidate
+			break
+		}
+		for _, alias := range candidate.AliasPaths() {
+			if strings.Trim(alias, "/") == endpoint {
+				spec = candidate
+			}
+		}
+	}
+	if spec == nil {
+		log.Fatalf("No spec is mounted at /%s; see spec-manager list", endpoint)
+	}
+	if spec.IsActive != nil && !*spec.IsActive {
+		fmt.Fprintf(os.Stderr, "Note: spec %d is inactive; activate it with: spec-manager activate %d\n", spec.ID, spec.ID)
+	}
+
+	// Clients of specs without a stored token send their own credentials
+	if spec.ApiKeyToken == nil || *spec.ApiKeyToken == "" {
+		loaded, err := specLoader.Pipeline().ProcessDBSpec(context.Background(), spec)
+		if err != nil {
+			log.Fatalf("Failed to load spec: %v", err)
+		}
+		opts.Headers = services.CredentialHeaderPlaceholders(loaded.Doc)
+	}
+	for _, header := range headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			log.Fatalf("Invalid header %q: expected \"Name: value\"", header)
+		}
+		opts.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+
+	opts.Name = spec.Name
+	opts.URL = strings.TrimRight(baseURL, "/") + "/" + endpoint
+	config, err := services.MCPClientConfig(opts)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	out, _ := json.MarshalIndent(config, "", "  ")
+	fmt.Println(string(out))
+	for name, value := range opts.Headers {
+		if strings.Contains(value, "<") {
+			fmt.Fprintf(os.Stderr, "Replace the placeholder of the %s header with your credentials.\n", name)
+		}
+	}
+}
+
+func handleMigrateFromFiles(specLoader *services.SpecLoaderService) {
+	dir := "./specs"
+	var opts services.MigrationOptions
+	for _, arg := range os.Args[2:] {
+		switch arg {
+		case "--with-tokens":
+			opts.WithTokens = true
+		case "--dry-run":
+			opts.DryRun = true
+		default:
+			if strings.HasPrefix(arg, "-") {
+				fmt.Fprintf(os.Stderr, "Usage: spec-manager migrate-from-files [dir] [--with-tokens] [--dry-run]\n")
+				os.Exit(1)
+			}
+			dir = arg
+		}
+	}
+
+	results, err := specLoader.MigrateFromFiles(context.Background(), dir, opts)
+	if err != nil {
+		log.Fatalf("Failed to migrate specs: %v", err)
+	}
+	if len(results) == 0 {
+		fmt.Printf("No spec files found in %s\n", dir)
+		return
+	}
+
+	failed := 0
+	var envVars []string
+	for _, r := range results {
+		fmt.Printf("%-9s %-30s -> /%s\n", strings.ToUpper(r.Status), filepath.Base(r.File), r.Endpoint)
+		if r.Error != nil {
+			fmt.Printf("          %v\n", r.Error)
+			failed++
+			continue
+		}
+		switch {
+		case r.TokenCopied:
+			fmt.Printf("          token copied from %s\n", r.EnvVar)
+		case r.EnvVar != "":
+			fmt.Printf("          credentials still come from %s; set them with: spec-manager set-token <id> <token>\n", r.EnvVar)
+		}
+		if r.EnvVar != "" {
+			envVars = append(envVars, r.EnvVar)
+		}
+		if r.Status == services.MigrationDryRun {
+			fmt.Printf("          %d tools\n", len(r.FileTools))
+			continue
+		}
+		if r.Verified() {
+			fmt.Printf("          %d tools, same as in file mode\n", len(r.DatabaseTools))
+			continue
+		}
+		failed++
+		if len(r.MissingTools) > 0 {
+			fmt.Printf("          missing in database mode: %s\n", strings.Join(r.MissingTools, ", "))
+		}
+		if len(r.ExtraTools) > 0 {
+			fmt.Printf("          only in database mode: %s\n", strings.Join(r.ExtraTools, ", "))
+		}
+	}
+
+	fmt.Println("")
+	if opts.DryRun {
+		fmt.Printf("Dry run: %d spec files checked, nothing was imported.\n", len(results))
+		return
+	}
+	if failed > 0 {
+		fmt.Printf("%d of %d specs failed to migrate or mount different tools; fix them before cutting over.\n", failed, len(results))
+		os.Exit(1)
+	}
+	fmt.Println("Cutover checklist:")
+	fmt.Println("  1. Run the server with DATABASE_URL set; it then serves the database specs instead of " + dir)
+	fmt.Println("  2. Check the endpoints: spec-manager active, and GET /health on the server")
+	if len(envVars) > 0 {
+		if opts.WithTokens {
+			fmt.Printf("  3. Remove %s from the server environment once the database tokens work\n", strings.Join(envVars, ", "))
+		} else {
+			fmt.Printf("  3. Keep %s set, or store the tokens with spec-manager set-token\n", strings.Join(envVars, ", "))
+		}
+	} else {
+		fmt.Println("  3. No spec needs credentials from environment variables")
+	}
+	fmt.Println("  4. Point clients at the same endpoints; their paths are unchanged")
+	fmt.Println("  5. Move " + dir + " out of the deployment, so a database outage cannot fall back to stale files")
+}
+
+func handleDoctor() {
+	specsDir := "./specs"
+	if len(os.Args) > 2 {
+		specsDir = os.Args[2]
+	}
+	report := services.RunStartupChecks(context.Background(), services.CheckOptions{SpecsDir: specsDir})
+	report.Print(os.Stdout)
+	if report.Failed() {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/dynamicserver"
+	"github.com/ubermorgenland/openapi-mcp/pkg/services"
+)
+
+// embeddedSpecs holds the specs built into the binary. It is nil unless the binary is built
+// with the embedspecs tag (see embedded_specs.go):
+//
+//	go build -tags embedspecs -o bin/openapimcp .
+var embeddedSpecs fs.FS
+
+// embeddedSpecNames restricts the embedded specs that are served to a comma-separated list of
+// file names, with or without extension, so one build can ship a curated bundle:
+//
+//	go build -tags embedspecs -ldflags "-X main.embeddedSpecNames=weather,perplexity" .
+var embeddedSpecNames = ""
+
+// embeddedSpecFiles lists the embedded spec files to serve, sorted by name.
+func embeddedSpecFiles() []string {
+	if embeddedSpecs == nil {
+		return nil
+	}
+	entries, err := fs.ReadDir(embeddedSpecs, ".")
+	if err != nil {
+		return nil
+	}
+	wanted := map[string]bool{}
+	for _, name := range strings.Split(embeddedSpecNames, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			wanted[name] = true
+		}
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		ext := strings.ToLower(path.Ext(name))
+		if entry.IsDir() || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		if len(wanted) > 0 && !wanted[name] && !wanted[strings.TrimSuffix(name, path.Ext(name))] {
+			continue
+		}
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// mountEmbeddedSpecs mounts the embedded specs on the gateway, records the environment
+// variables they need and returns how many were mounted.
+func mountEmbeddedSpecs(gateway *dynamicserver.Server, requiredEnvVars map[string]string) int {
+	files := embeddedSpecFiles()
+	if len(files) == 0 {
+		return 0
+	}
+	log.Printf("No spec files on disk, serving %d specs embedded in the binary", len(files))
+	mounted := 0
+	for _, name := range files {
+		content, err := fs.ReadFile(embeddedSpecs, name)
+		if err != nil {
+			log.Printf("Failed to read embedded spec %s: %v", name, err)
+			continue
+		}
+		log.Printf("Loading embedded spec: %s -> endpoint: /%s", name, services.EndpointFromPath(name))
+		mount, err := gateway.MountContent(context.Background(), name, content)
+		if err != nil {
+			log.Printf("Failed to load embedded spec %s: %v", name, err)
+			continue
+		}
+		mounted++
+		if envVar, description := services.RequiredEnvVar(mount.Loaded); envVar != "" {
+			requiredEnvVars[envVar] = description
+		}
+	}
+	return mounted
+}
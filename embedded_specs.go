@@ -0,0 +1,22 @@
+//go:build embedspecs
+
+package main
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// bundledSpecs is the specs directory at build time. Put the specs of a demo bundle in
+// ./specs before building with -tags embedspecs.
+//
+//go:embed specs
+var bundledSpecs embed.FS
+
+func init() {
+	sub, err := fs.Sub(bundledSpecs, "specs")
+	if err != nil {
+		panic(err)
+	}
+	embeddedSpecs = sub
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/export"
+	"github.com/ubermorgenland/openapi-mcp/pkg/openapi2mcp"
+)
+
+// exporter sends tool call events to the sinks of MCP_EXPORT_CONFIG; nil when it is not set
+var exporter *export.Exporter
+
+// callEventExporter turns finished tool calls into export events
+type callEventExporter struct {
+	exporter *export.Exporter
+}
+
+func (c callEventExporter) RecordCall(event openapi2mcp.CallEvent) {
+	c.exporter.Emit(export.Event{Type: export.EventToolCall, Time: event.Time, Key: event.Endpoint, Data: event})
+}
+
+// startExporters starts the analytics and audit exporters configured in MCP_EXPORT_CONFIG.
+// It must run before specs are mounted, so their tools report their calls.
+func startExporters() {
+	path := os.Getenv("MCP_EXPORT_CONFIG")
+	if path == "" {
+		return
+	}
+	cfg, err := export.LoadConfig(path)
+	if err == nil {
+		exporter, err = export.New(cfg)
+	}
+	if err != nil {
+		addStartupWarning("Event export disabled: %v", err)
+		return
+	}
+	openapi2mcp.SetDefaultCallEventSink(callEventExporter{exporter})
+	log.Printf("Exporting tool call events to: %s", strings.Join(exporter.Sinks(), ", "))
+}
+
+// stopExporters sends the buffered events, waiting at most 5 seconds
+func stopExporters() {
+	if exporter == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	exporter.Close(ctx)
+}
+
+// exportStats returns the counters of the export sinks for /analytics
+func exportStats() []export.SinkStats {
+	if exporter == nil {
+		return []export.SinkStats{}
+	}
+	return exporter.Stats()
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/dynamicserver"
+	"github.com/ubermorgenland/openapi-mcp/pkg/export"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+	"github.com/ubermorgenland/openapi-mcp/pkg/openapi2mcp"
+)
+
+// Build metadata, set at build time with:
+//
+//	go build -ldflags "-X main.version=v1.2.3 -X main.gitCommit=$(git rev-parse --short HEAD) -X main.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	version   = "dev"
+	gitCommit = "unknown"
+	buildTime = "unknown"
+)
+
+// serverStartTime is used to report uptime in /info
+var serverStartTime = time.Now()
+
+// mountedEndpoint describes an API mounted on the server
+type mountedEndpoint struct {
+	Path     string `json:"path"`
+	Title    string `json:"title"`
+	AuthType string `json:"auth_type,omitempty"`
+	Disabled string `json:"disabled,omitempty"` // why the spec is mounted without tools
+
+	sessions *server.StreamableHTTPServer // session registry for the admin /sessions API
+}
+
+// ServerFeatures lists the optional features enabled on this server
+type ServerFeatures struct {
+	DatabaseMode bool `json:"database_mode"`
+	Polling      bool `json:"polling"`
+	Auth         bool `json:"auth"`
+}
+
+// ServerInfo is the response body of GET /info
+type ServerInfo struct {
+	Version            string            `json:"version"`
+	GitCommit          string            `json:"git_commit"`
+	BuildTime          string            `json:"build_time"`
+	GoVersion          string            `json:"go_version"`
+	Environment        string            `json:"environment"`
+	ProtocolVersions   []string          `json:"protocol_versions"`
+	Features           ServerFeatures    `json:"features"`
+	MountedEndpoints   int               `json:"mounted_endpoints"`
+	Endpoints          []mountedEndpoint `json:"endpoints"`
+	StartedAt          time.Time         `json:"started_at"`
+	UptimeSeconds      int64             `json:"uptime_seconds"`
+	PollingIntervalSec int               `json:"polling_interval_seconds,omitempty"`
+}
+
+var (
+	// gateway serves the mounted specs, in database and file mode alike
+	gateway *dynamicserver.Server
+	// databaseMode is true when specs are served from the database
+	databaseMode bool
+)
+
+// mountedEndpoints returns a snapshot of the endpoints mounted on the gateway
+func mountedEndpoints() []mountedEndpoint {
+	if gateway == nil {
+		return nil
+	}
+	mounts := gateway.Mounts()
+	endpoints := make([]mountedEndpoint, 0, len(mounts))
+	for _, m := range mounts {
+		endpoints = append(endpoints, mountedEndpoint{Path: m.Path(), Title: m.Title, AuthType: m.AuthType, Disabled: m.Disabled, sessions: m.Sessions})
+	}
+	return endpoints
+}
+
+// currentServerInfo builds the server info from the current state
+func currentServerInfo() ServerInfo {
+	endpoints := mountedEndpoints()
+
+	authEnabled := false
+	for _, e := range endpoints {
+		if e.AuthType != "" {
+			authEnabled = true
+			break
+		}
+	}
+
+	var pollScheduler *dynamicserver.PollScheduler
+	if gateway != nil {
+		pollScheduler = gateway.Scheduler()
+	}
+	polling := pollScheduler != nil && pollScheduler.Running()
+
+	info := ServerInfo{
+		Version:          version,
+		GitCommit:        gitCommit,
+		BuildTime:        buildTime,
+		GoVersion:        runtime.Version(),
+		Environment:      openapi2mcp.ServerEnvironment(),
+		ProtocolVersions: mcp.ValidProtocolVersions,
+		Features: ServerFeatures{
+			DatabaseMode: databaseMode,
+			Polling:      polling,
+			Auth:         authEnabled,
+		},
+		MountedEndpoints: len(endpoints),
+		Endpoints:        endpoints,
+		StartedAt:        serverStartTime,
+		UptimeSeconds:    int64(time.Since(serverStartTime).Seconds()),
+	}
+	if polling {
+		info.PollingIntervalSec = int(pollScheduler.Interval() / time.Second)
+	}
+	return info
+}
+
+// handleInfo serves machine-readable server information for inventory and support tooling
+func handleInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(currentServerInfo())
+}
+
+// AnalyticsResponse is the response body of GET /analytics
+type AnalyticsResponse struct {
+	Tools     []openapi2mcp.LatencyStats      `json:"tools"`
+	Upstream  []openapi2mcp.UpstreamCallStats `json:"upstream"`
+	Budgets   []openapi2mcp.BudgetStats       `json:"budgets"`
+	Panics    []openapi2mcp.PanicStats        `json:"panics"`
+	Bandwidth []openapi2mcp.BandwidthStats    `json:"bandwidth"`
+	Sessions  []openapi2mcp.BandwidthStats    `json:"sessions"`
+	Exports   []export.SinkStats              `json:"exports"`
+	Audit     []export.SinkStats              `json:"audit"`
+}
+
+// handleAnalytics serves rolling upstream latency per tool, slowest first, and the
+// upstream HTTP client histograms and slow call counts per spec and tool, and the
+// spend of pay-per-call specs, the panics recovered in tool handlers, and the upstream
+// bytes sent and received per spec and session, and the counters of the event export sinks
+func handleAnalytics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(AnalyticsResponse{
+		Tools:     openapi2mcp.DefaultLatencyTracker().All(),
+		Upstream:  openapi2mcp.DefaultUpstreamMetrics().All(),
+		Budgets:   openapi2mcp.DefaultBudgetTracker().All(),
+		Panics:    openapi2mcp.DefaultPanicTracker().All(),
+		Bandwidth: openapi2mcp.DefaultBandwidthTracker().All(),
+		Sessions:  openapi2mcp.DefaultBandwidthTracker().Sessions(),
+		Exports:   exportStats(),
+		Audit:     auditStats(),
+	})
+}
+
+// saveLatencyStats keeps latency stats across restarts when MCP_LATENCY_FILE is set
+func saveLatencyStats() {
+	path := os.Getenv("MCP_LATENCY_FILE")
+	if path == "" {
+		return
+	}
+	if err := openapi2mcp.DefaultLatencyTracker().SaveFile(path); err != nil {
+		log.Printf("Failed to save latency stats to %s: %v", path, err)
+	}
+}
+
+// logStartupBanner prints a short summary of the build and enabled features
+func logStartupBanner(addr string) {
+	info := currentServerInfo()
+	mode := "file"
+	if info.Features.DatabaseMode {
+		mode = "database"
+	}
+	log.Printf("==================== openapi-mcp ====================")
+	log.Printf("  Version:    %s (commit %s, built %s, %s)", info.Version, info.GitCommit, info.BuildTime, info.GoVersion)
+	log.Printf("  Protocol:   %v", info.ProtocolVersions)
+	log.Printf("  Mode:       %s | polling: %t | auth: %t", mode, info.Features.Polling, info.Features.Auth)
+	log.Printf("  Endpoints:  %d mounted, listening on %s (pid %d)", info.MountedEndpoints, addr, os.Getpid())
+	if publicURL, _ := openapi2mcp.PublicURL(); publicURL != "" {
+		log.Printf("  Public URL: %s", publicURL)
+	}
+	log.Printf("  Info:       GET /info, GET /analytics")
+	log.Printf("=====================================================")
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+	"github.com/ubermorgenland/openapi-mcp/pkg/openapi2mcp"
+	"github.com/ubermorgenland/openapi-mcp/pkg/services"
+)
+
+// callJournal records tool calls in the database when MCP_CALL_JOURNAL is set; nil otherwise
+var callJournal *services.CallJournalService
+
+// startCallJournal turns on the tool call journal and reports the calls a previous run
+// left unfinished. It must run before specs are mounted, so their tools are journaled.
+func startCallJournal(db *sql.DB) {
+	if enabled, _ := strconv.ParseBool(os.Getenv("MCP_CALL_JOURNAL")); !enabled {
+		return
+	}
+	callJournal = services.NewCallJournalService(db)
+	interrupted, err := callJournal.Recover()
+	if err != nil {
+		log.Printf("Failed to recover unfinished tool calls from the journal: %v", err)
+	}
+	logInterruptedCalls("cut off by a previous crash or shutdown", interrupted)
+	if pruned, err := callJournal.Prune(); err != nil {
+		log.Printf("Failed to prune the tool call journal: %v", err)
+	} else if pruned > 0 {
+		log.Printf("Pruned %d finished tool calls from the journal", pruned)
+	}
+	openapi2mcp.SetDefaultCallJournal(callJournal)
+	log.Printf("Tool call journal enabled (GET /journal)")
+}
+
+// stopCallJournal marks the calls still running at shutdown as interrupted
+func stopCallJournal() {
+	if callJournal == nil {
+		return
+	}
+	interrupted, err := callJournal.InterruptInFlight()
+	if err != nil {
+		log.Printf("Failed to journal in-flight tool calls at shutdown: %v", err)
+		return
+	}
+	logInterruptedCalls("still running at shutdown", interrupted)
+}
+
+func logInterruptedCalls(reason string, calls []*models.ToolCall) {
+	if len(calls) == 0 {
+		return
+	}
+	log.Printf("[WARN] %d tool calls were %s:", len(calls), reason)
+	for _, c := range calls {
+		session := "-"
+		if c.SessionID != nil {
+			session = *c.SessionID
+		}
+		log.Printf("  #%d %s on /%s (session %s, accepted %s by %s/%d)", c.ID, c.Tool, c.Endpoint, session, c.AcceptedAt.Format("2006-01-02T15:04:05Z07:00"), c.Host, c.Pid)
+	}
+}
+
+// handleJournal serves GET /journal?status=interrupted&limit=100: the most recent journaled tool calls
+func handleJournal(w http.ResponseWriter, r *http.Request) {
+	if callJournal == nil {
+		writeErrorResponse(w, "Tool call journal is not enabled (set MCP_CALL_JOURNAL=true in database mode)", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", openapi2mcp.JournalAccepted, openapi2mcp.JournalCompleted, openapi2mcp.JournalFailed, openapi2mcp.JournalInterrupted:
+	default:
+		writeErrorResponse(w, "Invalid status: use accepted, completed, failed or interrupted", http.StatusBadRequest)
+		return
+	}
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 1000 {
+			writeErrorResponse(w, "Invalid limit: use 1-1000", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	calls, err := callJournal.List(status, limit)
+	if err != nil {
+		writeErrorResponse(w, "Failed to read the tool call journal", http.StatusInternalServerError)
+		return
+	}
+	if calls == nil {
+		calls = []*models.ToolCall{}
+	}
+	writeSuccessResponse(w, "Tool calls retrieved successfully", calls)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/k8s"
+	"github.com/ubermorgenland/openapi-mcp/pkg/services"
+)
+
+// kubernetesDiscovery imports specs from annotated Services and Ingresses when
+// MCP_KUBERNETES_DISCOVERY is set; nil otherwise
+var kubernetesDiscovery *services.KubernetesDiscoveryService
+
+// startKubernetesDiscovery turns on Kubernetes discovery. It runs a first pass right away, so
+// discovered specs are mounted by the initial load, then syncs every MCP_KUBERNETES_INTERVAL
+// and reloads the gateway after a pass that changed them. It must run before the gateway is
+// created, so the first pass does not reload it.
+func startKubernetesDiscovery(specLoader *services.SpecLoaderService) {
+	if enabled, _ := strconv.ParseBool(os.Getenv("MCP_KUBERNETES_DISCOVERY")); !enabled {
+		return
+	}
+	client, err := k8s.NewInClusterClient()
+	if err != nil {
+		log.Printf("Kubernetes discovery disabled: %v", err)
+		return
+	}
+	interval := 60 * time.Second
+	if v := os.Getenv("MCP_KUBERNETES_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= time.Second {
+			interval = d
+		} else {
+			log.Printf("Invalid MCP_KUBERNETES_INTERVAL '%s', using default %v", v, interval)
+		}
+	}
+	kubernetesDiscovery = services.NewKubernetesDiscoveryService(specLoader, client, interval, func(*services.KubernetesSyncResult) {
+		if gateway == nil {
+			return
+		}
+		if _, err := gateway.Reload(context.Background()); err != nil {
+			log.Printf("Failed to reload specs after Kubernetes discovery: %v", err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), interval)
+	defer cancel()
+	kubernetesDiscovery.SyncAndReport(ctx)
+	kubernetesDiscovery.Start()
+	namespace := client.Namespace
+	if namespace == "" {
+		namespace = "all namespaces"
+	}
+	log.Printf("Kubernetes discovery enabled for %s (every %v)", namespace, interval)
+}
+
+// stopKubernetesDiscovery ends background discovery
+func stopKubernetesDiscovery() {
+	if kubernetesDiscovery != nil {
+		kubernetesDiscovery.Stop()
+	}
+}
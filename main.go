@@ -11,44 +11,22 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
 
-	"github.com/getkin/kin-openapi/openapi3"
-	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
+	"github.com/ubermorgenland/openapi-mcp/pkg/apierrors"
 	"github.com/ubermorgenland/openapi-mcp/pkg/database"
-	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
-	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+	"github.com/ubermorgenland/openapi-mcp/pkg/dynamicserver"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+	"github.com/ubermorgenland/openapi-mcp/pkg/metrics"
 	"github.com/ubermorgenland/openapi-mcp/pkg/openapi2mcp"
+	"github.com/ubermorgenland/openapi-mcp/pkg/secrets"
 	serverPkg "github.com/ubermorgenland/openapi-mcp/pkg/server"
 	"github.com/ubermorgenland/openapi-mcp/pkg/services"
 )
 
-// Spec management request/response types
-type ImportSpecRequest struct {
-	Name         string `json:"name"`
-	EndpointPath string `json:"endpoint_path"`
-	SpecContent  string `json:"spec_content"`
-	FileFormat   string `json:"file_format,omitempty"`   // "json" or "yaml", auto-detected if not provided
-	ApiKeyToken  string `json:"api_key_token,omitempty"` // API key for this specific spec
-	Active       *bool  `json:"active,omitempty"`        // defaults to true if not provided
-}
-
-type UpdateSpecRequest struct {
-	Name         string `json:"name,omitempty"`
-	EndpointPath string `json:"endpoint_path,omitempty"`
-	SpecContent  string `json:"spec_content,omitempty"`
-	FileFormat   string `json:"file_format,omitempty"`
-	ApiKeyToken  string `json:"api_key_token,omitempty"`
-	Active       *bool  `json:"active,omitempty"`
-}
-
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
-	Code    int    `json:"code"`
-}
+// ErrorResponse is the shared error body, also used by the MCP transports
+type ErrorResponse = apierrors.Response
 
 type SuccessResponse struct {
 	Success bool        `json:"success"`
@@ -56,557 +34,9 @@ type SuccessResponse struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
-
-// Global variables for dynamic reloading
-var (
-	// Thread-safe state management
-	authStateManager *auth.StateManager
-
-	// Dynamic reloading state
-	globalMux      *http.ServeMux
-	reloadMux      sync.RWMutex
-	lastSpecHash   string
-	pollingEnabled bool
-	specLoader     *services.SpecLoaderService
-)
-
-// SpecReloadResponse represents the response from reload endpoint
-type SpecReloadResponse struct {
-	Success      bool     `json:"success"`
-	Message      string   `json:"message"`
-	ReloadedAPIs []string `json:"reloaded_apis,omitempty"`
-	Error        string   `json:"error,omitempty"`
-}
-
-// secureAuthContextFunc creates a secure, request-scoped authentication context without global state mutation
-func secureAuthContextFunc(ctx context.Context, r *http.Request, doc *openapi3.T, spec *models.OpenAPISpec) context.Context {
-	// Debug: Log incoming request headers for auth debugging
-	if authHeader := r.Header.Get("Authorization"); authHeader != "" {
-		headerPreview := authHeader
-		if len(headerPreview) > 30 {
-			headerPreview = headerPreview[:30]
-		}
-		log.Printf("DEBUG: secureAuthContextFunc received Authorization header: %s...", headerPreview)
-		
-		// Force Bearer token extraction if not working through normal flow
-		if strings.HasPrefix(authHeader, "Bearer ") {
-			bearerToken := strings.TrimPrefix(authHeader, "Bearer ")
-			tokenPreview := bearerToken
-			if len(tokenPreview) > 20 {
-				tokenPreview = tokenPreview[:20]
-			}
-			log.Printf("DEBUG: Directly extracted Bearer token: %s...", tokenPreview)
-			
-			// Create authentication context and manually set the token
-			authCtx := auth.CreateAuthContext(r, doc, spec)
-			if authCtx.Token == "" {
-				log.Printf("DEBUG: Normal extraction failed, manually setting Bearer token")
-				authCtx.Token = bearerToken
-			}
-			
-			// Add auth context to request context - this is secure and thread-safe
-			ctx = auth.WithAuthContext(ctx, authCtx)
-			return ctx
-		}
-	} else {
-		log.Printf("DEBUG: secureAuthContextFunc - NO Authorization header found in request")
-	}
-	
-	// Create authentication context for this request
-	authCtx := auth.CreateAuthContext(r, doc, spec)
-	
-	// Debug: Log the resulting auth context token
-	if authCtx.Token != "" {
-		tokenPreview := authCtx.Token
-		if len(tokenPreview) > 20 {
-			tokenPreview = tokenPreview[:20]
-		}
-		log.Printf("DEBUG: secureAuthContextFunc extracted token: %s...", tokenPreview)
-	} else {
-		log.Printf("DEBUG: secureAuthContextFunc - NO token extracted from headers")
-	}
-
-	// If no spec provided, try to get it from state manager
-	if spec == nil && authStateManager != nil {
-		endpoint := strings.ToLower(strings.Trim(r.URL.Path, "/"))
-		if strings.Contains(endpoint, "/") {
-			endpoint = strings.Split(endpoint, "/")[0]
-		}
-		if foundSpec, exists := authStateManager.GetSpec(endpoint); exists {
-			// Recreate auth context with the found spec
-			authCtx = auth.CreateAuthContext(r, doc, foundSpec)
-		}
-	}
-
-	// Add auth context to request context - this is secure and thread-safe
-	ctx = auth.WithAuthContext(ctx, authCtx)
-
-	return ctx
-}
-
-// SECURITY FIX: Removed setupLegacyEnvVars function that was mutating global environment variables
-// This was a critical security vulnerability that could cause race conditions in concurrent environments
-// Authentication is now handled securely through request context without global state mutation
-
-
-
-
-// getEndpointFromFilename converts a filename to an endpoint URL path
-func getEndpointFromFilename(filename string) string {
-	// Remove file extension
-	name := strings.TrimSuffix(filename, filepath.Ext(filename))
-	// Replace underscores with hyphens
-	return strings.ReplaceAll(name, "_", "-")
-}
-
-// loadSpecsFromDatabase loads specs from database and returns them with a hash for change detection
-func loadSpecsFromDatabase() ([]*models.OpenAPISpec, string, error) {
-	if specLoader == nil {
-		err := serverPkg.NewError(serverPkg.ErrorTypeInternal, "spec loader not initialized", "")
-		err.LogError()
-		return nil, "", err
-	}
-
-	specs, err := specLoader.GetActiveSpecs()
-	if err != nil {
-		return nil, "", err
-	}
-
-	// Create hash of specs for change detection
-	hash := fmt.Sprintf("%d", len(specs))
-	for _, spec := range specs {
-		hash += fmt.Sprintf("-%d-%s", spec.ID, spec.Name)
-		if spec.ApiKeyToken != nil {
-			hash += fmt.Sprintf("-%d", len(*spec.ApiKeyToken))
-		}
-	}
-
-	return specs, hash, nil
-}
-
-// createSpecEndpoints creates HTTP endpoints for the given specs
-func createSpecEndpoints(specs []*models.OpenAPISpec) ([]string, error) {
-	reloadMux.Lock()
-	defer reloadMux.Unlock()
-
-	// Initialize auth state manager if not already done
-	if authStateManager == nil {
-		authStateManager = auth.NewStateManager()
-	}
-
-	// Create new mux
-	newMux := http.NewServeMux()
-
-	// Add health endpoint
-	newMux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
-
-	// Add reload endpoint
-	newMux.HandleFunc("/reload", handleReload)
-
-	// Add swagger endpoint
-	newMux.HandleFunc("/swagger", handleSwagger)
-
-	// Set up CORS middleware
-	corsMiddleware := func(next http.HandlerFunc) http.HandlerFunc {
-		return func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-			if r.Method == "OPTIONS" {
-				w.WriteHeader(http.StatusOK)
-				return
-			}
-
-			next(w, r)
-		}
-	}
-
-	// Add spec management endpoints
-	newMux.HandleFunc("/specs", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case "GET":
-			handleGetSpecs(w, r)
-		case "POST":
-			handleCreateSpec(w, r)
-		default:
-			writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	}))
-
-	newMux.HandleFunc("/specs/active", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != "GET" {
-			writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-		handleGetActiveSpecs(w, r)
-	}))
-
-	newMux.HandleFunc("/specs/", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		// Extract ID from path
-		path := strings.TrimPrefix(r.URL.Path, "/specs/")
-		if path == "" {
-			writeErrorResponse(w, "Spec ID required", http.StatusBadRequest)
-			return
-		}
-
-		// Handle /specs/{id}/activate, /specs/{id}/deactivate, and /specs/{id}/token
-		parts := strings.Split(path, "/")
-		if len(parts) == 2 {
-			id, err := strconv.Atoi(parts[0])
-			if err != nil {
-				writeErrorResponse(w, "Invalid spec ID", http.StatusBadRequest)
-				return
-			}
-
-			switch parts[1] {
-			case "activate":
-				if r.Method != "POST" {
-					writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
-					return
-				}
-				handleActivateSpec(w, r, id)
-				return
-			case "deactivate":
-				if r.Method != "POST" {
-					writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
-					return
-				}
-				handleDeactivateSpec(w, r, id)
-				return
-			case "token":
-				if r.Method != "PUT" {
-					writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
-					return
-				}
-				handleUpdateApiKeyToken(w, r, id)
-				return
-			}
-		}
-
-		// Handle /specs/{id} operations
-		id, err := strconv.Atoi(parts[0])
-		if err != nil {
-			writeErrorResponse(w, "Invalid spec ID", http.StatusBadRequest)
-			return
-		}
-
-		switch r.Method {
-		case "GET":
-			handleGetSpec(w, r, id)
-		case "PUT":
-			handleUpdateSpec(w, r, id)
-		case "DELETE":
-			handleDeleteSpec(w, r, id)
-		default:
-			writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	}))
-
-	var mountedAPIs []string
-
-	// Process each database spec
-	for _, spec := range specs {
-		endpoint := strings.TrimPrefix(spec.EndpointPath, "/")
-
-		// Store spec in thread-safe state manager
-		// (Will be updated in bulk after processing all specs)
-
-		log.Printf("Loading database spec: %s -> endpoint: /%s", spec.Name, endpoint)
-
-		// Parse spec content to get OpenAPI doc
-		loader := openapi3.NewLoader()
-		doc, err := loader.LoadFromData([]byte(spec.SpecContent))
-		if err != nil {
-			log.Printf("Failed to parse spec content for %s: %v", spec.Name, err)
-			continue
-		}
-
-		// Log the authentication info with proper header casing from raw spec content
-		schemeName, authType, authPath := auth.ExtractAuthSchemeFromSpecWithContent(doc, spec.SpecContent)
-		if authPath != "" {
-			log.Printf("%s API: Found security scheme '%s' with %s authentication: %s", endpoint, schemeName, authType, authPath)
-			// Show database token status and how it will be used
-			if spec.ApiKeyToken != nil && *spec.ApiKeyToken != "" {
-				switch authType {
-				case "bearer":
-					log.Printf("%s API: Will use database token as BEARER TOKEN (length: %d)", endpoint, len(*spec.ApiKeyToken))
-				case "apiKey":
-					log.Printf("%s API: Will use database token as API KEY (length: %d)", endpoint, len(*spec.ApiKeyToken))
-				case "basic":
-					log.Printf("%s API: Will use database token as BASIC AUTH (length: %d)", endpoint, len(*spec.ApiKeyToken))
-				default:
-					log.Printf("%s API: Will use database token as API KEY - default (length: %d)", endpoint, len(*spec.ApiKeyToken))
-				}
-			} else {
-				log.Printf("%s API: No token in database, will use environment variables for %s auth", endpoint, authType)
-			}
-		}
-
-		// Create MCP server - don't set auth env vars here, let the context function handle it
-		// Ensure database connection is healthy before long-running MCP server creation
-		if err := database.EnsureConnection(); err != nil {
-			log.Printf("Failed to ensure database connection before creating MCP server for %s: %v", doc.Info.Title, err)
-			continue
-		}
-		
-		log.Printf("Creating MCP server for %s with database authentication...", doc.Info.Title)
-		srv := openapi2mcp.NewServerWithDatabase(doc.Info.Title, doc.Info.Version, doc, spec)
-		log.Printf("Database-aware MCP server created successfully for %s", doc.Info.Title)
-		
-		// Re-check database connection after long-running operation
-		if err := database.EnsureConnection(); err != nil {
-			log.Printf("Database connection lost after creating MCP server for %s: %v", doc.Info.Title, err)
-		}
-
-		// Create a custom StreamableHTTPServer with database spec-aware auth function
-		streamableServer := server.NewStreamableHTTPServer(srv,
-			server.WithEndpointPath("/"+endpoint),
-			server.WithHTTPContextFunc(func(ctx context.Context, r *http.Request) context.Context {
-				return secureAuthContextFunc(ctx, r, doc, spec)
-			}),
-		)
-
-		// Create a custom SSE Server with database spec-aware auth function
-		sseServer := server.NewSSEServer(srv,
-			server.WithStaticBasePath("/"+endpoint),
-			server.WithSSEEndpoint("/sse"),
-			server.WithMessageEndpoint("/message"),
-			server.WithSSEContextFunc(func(ctx context.Context, r *http.Request) context.Context {
-				return secureAuthContextFunc(ctx, r, doc, spec)
-			}),
-		)
-
-		// Mount the StreamableHTTP server at the main endpoint path
-		newMux.Handle("/"+endpoint, streamableServer)
-		newMux.Handle("/"+endpoint+"/", streamableServer)
-
-		// Mount the SSE server endpoints
-		newMux.Handle("/"+endpoint+"/sse", sseServer.SSEHandler())
-		newMux.Handle("/"+endpoint+"/message", sseServer.MessageHandler())
-
-		log.Printf("Mounted %s API at /%s (StreamableHTTP) and /%s/sse + /%s/message (SSE)", doc.Info.Title, endpoint, endpoint, endpoint)
-		mountedAPIs = append(mountedAPIs, endpoint)
-	}
-
-	// Update specs in thread-safe state manager
-	authStateManager.UpdateSpecs(specs)
-
-	// Replace global mux
-	globalMux = newMux
-
-	return mountedAPIs, nil
-}
-
-// handleSwagger serves the OpenAPI specification for this server
-func handleSwagger(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
-		return
-	}
-
-	if r.Method != "GET" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	// Look for swagger file
-	swaggerPaths := []string{
-		"spec-api-swagger.json",
-		"bin/spec-api-swagger.json",
-		"./spec-api-swagger.json",
-	}
-
-	var swaggerContent []byte
-	var swaggerFound bool
-
-	for _, path := range swaggerPaths {
-		if content, err := os.ReadFile(path); err == nil {
-			swaggerContent = content
-			swaggerFound = true
-			break
-		}
-	}
-
-	if !swaggerFound {
-		// Create a basic swagger spec for the dynamic reloading server
-		basicSwagger := map[string]interface{}{
-			"openapi": "3.0.0",
-			"info": map[string]interface{}{
-				"title":       "OpenAPI MCP Dynamic Server",
-				"version":     "1.0.0",
-				"description": "Dynamic OpenAPI MCP server with database-driven spec loading and intelligent authentication",
-			},
-			"servers": []map[string]interface{}{
-				{"url": "http://localhost:8080", "description": "Local development server"},
-			},
-			"paths": map[string]interface{}{
-				"/health": map[string]interface{}{
-					"get": map[string]interface{}{
-						"summary":     "Health check",
-						"description": "Returns OK if server is running",
-						"responses": map[string]interface{}{
-							"200": map[string]interface{}{
-								"description": "Server is healthy",
-								"content": map[string]interface{}{
-									"text/plain": map[string]interface{}{
-										"schema": map[string]interface{}{
-											"type":    "string",
-											"example": "OK",
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-				"/reload": map[string]interface{}{
-					"post": map[string]interface{}{
-						"summary":     "Reload OpenAPI specs",
-						"description": "Manually trigger reload of OpenAPI specs from database",
-						"responses": map[string]interface{}{
-							"200": map[string]interface{}{
-								"description": "Reload status",
-								"content": map[string]interface{}{
-									"application/json": map[string]interface{}{
-										"schema": map[string]interface{}{
-											"type": "object",
-											"properties": map[string]interface{}{
-												"success": map[string]interface{}{
-													"type": "boolean",
-												},
-												"message": map[string]interface{}{
-													"type": "string",
-												},
-												"reloaded_apis": map[string]interface{}{
-													"type": "array",
-													"items": map[string]interface{}{
-														"type": "string",
-													},
-												},
-											},
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-				"/swagger": map[string]interface{}{
-					"get": map[string]interface{}{
-						"summary":     "Get OpenAPI specification",
-						"description": "Returns the OpenAPI specification for this server",
-						"responses": map[string]interface{}{
-							"200": map[string]interface{}{
-								"description": "OpenAPI specification",
-								"content": map[string]interface{}{
-									"application/json": map[string]interface{}{
-										"schema": map[string]interface{}{
-											"type": "object",
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-			},
-		}
-
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(basicSwagger)
-		return
-	}
-
-	var swaggerSpec map[string]interface{}
-	if err := json.Unmarshal(swaggerContent, &swaggerSpec); err != nil {
-		http.Error(w, "Invalid swagger specification format", http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(swaggerSpec)
-}
-
-// handleReload handles HTTP reload requests
-func handleReload(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
-	if r.Method != "POST" {
-		response := SpecReloadResponse{
-			Success: false,
-			Error:   "Method not allowed. Use POST.",
-		}
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		json.NewEncoder(w).Encode(response)
-		return
-	}
-
-	log.Printf("Reload requested via HTTP endpoint")
-
-	// Load specs from database
-	specs, newHash, err := loadSpecsFromDatabase()
-	if err != nil {
-		response := SpecReloadResponse{
-			Success: false,
-			Error:   fmt.Sprintf("Failed to load specs from database: %v", err),
-		}
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
-		return
-	}
-
-	// Check if specs have changed
-	if newHash == lastSpecHash {
-		response := SpecReloadResponse{
-			Success: true,
-			Message: "No changes detected in database specs",
-		}
-		json.NewEncoder(w).Encode(response)
-		return
-	}
-
-	// Reload endpoints
-	mountedAPIs, err := createSpecEndpoints(specs)
-	if err != nil {
-		response := SpecReloadResponse{
-			Success: false,
-			Error:   fmt.Sprintf("Failed to create spec endpoints: %v", err),
-		}
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
-		return
-	}
-
-	lastSpecHash = newHash
-
-	response := SpecReloadResponse{
-		Success:      true,
-		Message:      fmt.Sprintf("Successfully reloaded %d API specs", len(mountedAPIs)),
-		ReloadedAPIs: mountedAPIs,
-	}
-
-	log.Printf("Successfully reloaded %d API specs: %v", len(mountedAPIs), mountedAPIs)
-	json.NewEncoder(w).Encode(response)
-}
-
-// Spec management handler functions
+// writeErrorResponse and writeSuccessResponse write the JSON bodies of the admin endpoints
 func writeErrorResponse(w http.ResponseWriter, message string, code int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	json.NewEncoder(w).Encode(ErrorResponse{
-		Error:   http.StatusText(code),
-		Message: message,
-		Code:    code,
-	})
+	apierrors.WriteStatus(w, code, message)
 }
 
 func writeSuccessResponse(w http.ResponseWriter, message string, data interface{}) {
@@ -618,244 +48,100 @@ func writeSuccessResponse(w http.ResponseWriter, message string, data interface{
 	})
 }
 
-func handleGetSpecs(w http.ResponseWriter, r *http.Request) {
-	if specLoader == nil {
-		writeErrorResponse(w, "Database not available", http.StatusServiceUnavailable)
-		return
-	}
+// registerAdminRoutes adds the server info, analytics and session admin endpoints to the gateway
+func registerAdminRoutes(gateway *dynamicserver.Server) {
+	// Add server info endpoint
+	gateway.HandleFunc("/info", handleInfo)
 
-	specs, err := specLoader.GetAllSpecs()
-	if err != nil {
-		writeErrorResponse(w, "Failed to get specs", http.StatusInternalServerError)
-		return
-	}
+	// Add per-tool upstream latency endpoint
+	gateway.HandleFunc("/analytics", handleAnalytics)
 
-	writeSuccessResponse(w, "Specs retrieved successfully", specs)
-}
+	// Add session admin endpoints: GET /sessions and DELETE /sessions/{ref}
+	gateway.HandleFunc("/sessions", sessionsHandler)
+	gateway.HandleFunc("/sessions/", sessionsHandler)
 
-func handleGetActiveSpecs(w http.ResponseWriter, r *http.Request) {
-	if specLoader == nil {
-		writeErrorResponse(w, "Database not available", http.StatusServiceUnavailable)
-		return
-	}
+	// Add tool call journal endpoint
+	gateway.HandleFunc("/journal", handleJournal)
 
-	specs, err := specLoader.GetActiveSpecs()
-	if err != nil {
-		writeErrorResponse(w, "Failed to get active specs", http.StatusInternalServerError)
-		return
-	}
+	// Add audit log endpoint
+	gateway.HandleFunc("/audit", handleAudit)
 
-	writeSuccessResponse(w, "Active specs retrieved successfully", specs)
-}
+	// Add Prometheus metrics endpoint
+	metrics.SetActiveSessions(sessionsByTransport)
+	gateway.Handle("/metrics", metrics.Handler())
 
-func handleCreateSpec(w http.ResponseWriter, r *http.Request) {
-	if specLoader == nil {
-		writeErrorResponse(w, "Database not available", http.StatusServiceUnavailable)
-		return
-	}
-
-	// Limit request body size to 10MB to handle large specs gracefully
-	const maxPayloadSize = 10 << 20 // 10MB
-	r.Body = http.MaxBytesReader(w, r.Body, maxPayloadSize)
-
-	var req ImportSpecRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		// Handle different types of errors gracefully
-		switch {
-		case err.Error() == "http: request body too large":
-			writeErrorResponse(w, "Request payload too large (max 10MB)", http.StatusRequestEntityTooLarge)
-		case strings.Contains(err.Error(), "timeout") || strings.Contains(err.Error(), "deadline"):
-			writeErrorResponse(w, "Request timeout while processing large payload", http.StatusRequestTimeout)
-		case strings.Contains(err.Error(), "connection"):
-			writeErrorResponse(w, "Connection error while reading payload", http.StatusBadRequest)
-		default:
-			writeErrorResponse(w, fmt.Sprintf("Invalid JSON payload: %v", err), http.StatusBadRequest)
-		}
-		return
-	}
-
-	// Validate required fields
-	if req.Name == "" {
-		writeErrorResponse(w, "Name is required", http.StatusBadRequest)
-		return
-	}
-	if req.EndpointPath == "" {
-		writeErrorResponse(w, "Endpoint path is required", http.StatusBadRequest)
-		return
-	}
-	if req.SpecContent == "" {
-		writeErrorResponse(w, "Spec content is required", http.StatusBadRequest)
-		return
+	// Add the embedded admin dashboard
+	if adminUIEnabled() {
+		gateway.HandleFunc("/admin", handleAdmin)
+		gateway.HandleFunc("/admin/", handleAdmin)
 	}
+}
 
-	// Auto-detect format if not provided
-	if req.FileFormat == "" {
-		if strings.HasPrefix(strings.TrimSpace(req.SpecContent), "{") {
-			req.FileFormat = "json"
+// sessionSettings reads the secret signing session IDs and their lifetime from
+// MCP_SESSION_SECRET and MCP_SESSION_TTL. Without a secret, a random one is used per process.
+func sessionSettings() ([]byte, time.Duration) {
+	var ttl time.Duration
+	if v := os.Getenv("MCP_SESSION_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Printf("Invalid MCP_SESSION_TTL %q, using the default", v)
 		} else {
-			req.FileFormat = "yaml"
+			ttl = d
 		}
 	}
-
-	// Check for Swagger 2.0 and reject immediately to prevent server hangs
-	if strings.Contains(req.SpecContent, `"swagger":"2.0"`) || strings.Contains(req.SpecContent, `swagger: "2.0"`) ||
-		strings.Contains(req.SpecContent, `"swagger": "2.0"`) || strings.Contains(req.SpecContent, `swagger: '2.0'`) {
-		writeErrorResponse(w, "Swagger 2.0 specifications are not supported. Please convert to OpenAPI 3.x format first.", http.StatusBadRequest)
-		return
-	}
-
-	// Set default active status
-	if req.Active == nil {
-		active := true
-		req.Active = &active
-	}
-
-	// Convert API key token
-	var apiKeyToken *string
-	if req.ApiKeyToken != "" {
-		apiKeyToken = &req.ApiKeyToken
-	}
-
-	// Create spec directly from content
-	if err := specLoader.CreateSpecFromContent(req.Name, req.EndpointPath, req.SpecContent, req.FileFormat, apiKeyToken); err != nil {
-		writeErrorResponse(w, fmt.Sprintf("Failed to create spec: %v", err), http.StatusBadRequest)
-		return
-	}
-
-	// If requested as inactive, deactivate it
-	if !*req.Active {
-		specs, err := specLoader.GetAllSpecs()
-		if err == nil {
-			for _, spec := range specs {
-				if spec.Name == req.Name {
-					specLoader.DeactivateSpec(spec.ID)
-					break
-				}
-			}
-		}
-	}
-
-	writeSuccessResponse(w, "Spec imported successfully", map[string]interface{}{
-		"name":          req.Name,
-		"endpoint_path": req.EndpointPath,
-		"active":        *req.Active,
-		"has_api_token": apiKeyToken != nil,
-	})
-}
-
-func handleGetSpec(w http.ResponseWriter, r *http.Request, id int) {
-	writeErrorResponse(w, "Get spec by ID not implemented yet", http.StatusNotImplemented)
-}
-
-func handleUpdateSpec(w http.ResponseWriter, r *http.Request, id int) {
-	writeErrorResponse(w, "Update spec not implemented yet", http.StatusNotImplemented)
-}
-
-func handleDeleteSpec(w http.ResponseWriter, r *http.Request, id int) {
-	if specLoader == nil {
-		writeErrorResponse(w, "Database not available", http.StatusServiceUnavailable)
-		return
-	}
-
-	if err := specLoader.DeleteSpec(id); err != nil {
-		writeErrorResponse(w, fmt.Sprintf("Failed to delete spec: %v", err), http.StatusBadRequest)
-		return
+	secret := os.Getenv("MCP_SESSION_SECRET")
+	if secret == "" {
+		log.Printf("MCP_SESSION_SECRET not set; session IDs are signed with a random key and do not survive restarts")
+		return nil, ttl
 	}
-
-	writeSuccessResponse(w, "Spec deleted successfully", map[string]int{"id": id})
-}
-
-func handleActivateSpec(w http.ResponseWriter, r *http.Request, id int) {
-	if specLoader == nil {
-		writeErrorResponse(w, "Database not available", http.StatusServiceUnavailable)
-		return
-	}
-
-	if err := specLoader.ActivateSpec(id); err != nil {
-		writeErrorResponse(w, fmt.Sprintf("Failed to activate spec: %v", err), http.StatusBadRequest)
-		return
+	if len(secret) < 32 {
+		log.Printf("⚠️  MCP_SESSION_SECRET is shorter than 32 bytes; use a longer random secret")
 	}
-
-	writeSuccessResponse(w, "Spec activated successfully", map[string]int{"id": id})
+	return []byte(secret), ttl
 }
 
-func handleDeactivateSpec(w http.ResponseWriter, r *http.Request, id int) {
-	if specLoader == nil {
-		writeErrorResponse(w, "Database not available", http.StatusServiceUnavailable)
-		return
+// drainTimeoutSetting returns how long a reloaded endpoint's previous server keeps serving its
+// sessions in progress (MCP_RELOAD_DRAIN_TIMEOUT), or 0 for the default
+func drainTimeoutSetting() time.Duration {
+	v := os.Getenv("MCP_RELOAD_DRAIN_TIMEOUT")
+	if v == "" {
+		return 0
 	}
-
-	if err := specLoader.DeactivateSpec(id); err != nil {
-		writeErrorResponse(w, fmt.Sprintf("Failed to deactivate spec: %v", err), http.StatusBadRequest)
-		return
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid MCP_RELOAD_DRAIN_TIMEOUT %q, using the default", v)
+		return 0
 	}
-
-	writeSuccessResponse(w, "Spec deactivated successfully", map[string]int{"id": id})
+	return d
 }
 
-func handleUpdateApiKeyToken(w http.ResponseWriter, r *http.Request, id int) {
-	if specLoader == nil {
-		writeErrorResponse(w, "Database not available", http.StatusServiceUnavailable)
-		return
-	}
-
-	var req struct {
-		ApiKeyToken *string `json:"api_key_token"`
+// rateLimitSetting returns the default rate limit of the endpoints of specs without their own
+// rate_limit (MCP_RATE_LIMIT, e.g. "1000/m, client=60/m"), or no limit
+func rateLimitSetting() mcpserver.RateLimitConfig {
+	v := os.Getenv("MCP_RATE_LIMIT")
+	if v == "" {
+		return mcpserver.RateLimitConfig{}
 	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeErrorResponse(w, "Invalid JSON payload", http.StatusBadRequest)
-		return
-	}
-
-	if err := specLoader.UpdateApiKeyToken(id, req.ApiKeyToken); err != nil {
-		writeErrorResponse(w, fmt.Sprintf("Failed to update API key token: %v", err), http.StatusBadRequest)
-		return
+	cfg, err := mcpserver.ParseRateLimit(v)
+	if err != nil {
+		log.Printf("Invalid MCP_RATE_LIMIT %q, endpoints are not rate limited: %v", v, err)
+		return mcpserver.RateLimitConfig{}
 	}
-
-	writeSuccessResponse(w, "API key token updated successfully", map[string]interface{}{
-		"id":                    id,
-		"api_key_token_updated": true,
-	})
+	return cfg
 }
 
-// startDatabasePolling starts a goroutine that polls the database for changes
-func startDatabasePolling(intervalSeconds int) {
-	if !pollingEnabled {
-		log.Printf("Database polling disabled")
-		return
-	}
-
-	log.Printf("Starting database polling every %d seconds", intervalSeconds)
-
-	go func() {
-		ticker := time.NewTicker(time.Duration(intervalSeconds) * time.Second)
-		defer ticker.Stop()
-
-		for range ticker.C {
-			// Load specs from database
-			specs, newHash, err := loadSpecsFromDatabase()
-			if err != nil {
-				log.Printf("Database polling error: %v", err)
-				continue
-			}
-
-			// Check if specs have changed
-			if newHash != lastSpecHash {
-				log.Printf("Database changes detected, reloading specs...")
-
-				// Reload endpoints
-				mountedAPIs, err := createSpecEndpoints(specs)
-				if err != nil {
-					log.Printf("Failed to reload specs during polling: %v", err)
-					continue
-				}
-
-				lastSpecHash = newHash
-				log.Printf("Automatically reloaded %d API specs: %v", len(mountedAPIs), mountedAPIs)
-			}
+// specHealthSettings returns whether degraded database specs are deactivated
+// (MCP_AUTO_DEACTIVATE) and the webhook alerted when specs degrade (MCP_ALERT_WEBHOOK)
+func specHealthSettings() (bool, string) {
+	autoDeactivate := false
+	if v := os.Getenv("MCP_AUTO_DEACTIVATE"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Printf("Invalid MCP_AUTO_DEACTIVATE %q, degraded specs stay active", v)
 		}
-	}()
+		autoDeactivate = b
+	}
+	return autoDeactivate, os.Getenv("MCP_ALERT_WEBHOOK")
 }
 
 // startServerWithGracefulShutdown starts the HTTP server with proper graceful shutdown handling
@@ -867,6 +153,12 @@ func startServerWithGracefulShutdown(srv *http.Server) error {
 	// Channel to receive server errors
 	serverErrors := make(chan error, 1)
 
+	// Anonymous usage reports, only when the operator opted in
+	startTelemetry()
+
+	// OpenTelemetry tracing, when configured by OTEL_* variables
+	startTracing()
+
 	// Start server in a goroutine
 	go func() {
 		log.Printf("Starting server on %s", srv.Addr)
@@ -890,23 +182,49 @@ func startServerWithGracefulShutdown(srv *http.Server) error {
 		ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
 		defer cancel()
 
+		// Stop polling first, so no reload starts while requests are drained
+		if pollScheduler := gateway.Scheduler(); pollScheduler != nil {
+			pollScheduler.Stop()
+		}
+		stopKubernetesDiscovery()
+
 		log.Printf("Shutting down server with %v timeout...", 25*time.Second)
 
 		// Attempt graceful shutdown
-		if err := srv.Shutdown(ctx); err != nil {
+		err := srv.Shutdown(ctx)
+		// Calls still running now are cut off; journal them as interrupted
+		stopCallJournal()
+		// Send the events still buffered for the export sinks
+		stopExporters()
+		stopAuditLog()
+		stopTracing()
+		stopTelemetry()
+		if err != nil {
 			shutdownErr := serverPkg.Wrap(err, serverPkg.ErrorTypeInternal, "server shutdown failed")
 			shutdownErr.LogError()
 			return shutdownErr
 		}
 
 		log.Printf("Server shut down gracefully")
+		saveLatencyStats()
 		return nil
 	}
 }
 
 func main() {
-	// Initialize auth state manager
-	authStateManager = auth.NewStateManager()
+	// Report the build version in the upstream User-Agent
+	openapi2mcp.UserAgentVersion = version
+
+	// Validate the configuration, database and specs, then exit without serving
+	if len(os.Args) > 1 && os.Args[1] == "--check" {
+		os.Exit(runStartupCheck())
+	}
+
+	// Let the runtime manage memory within MCP_MEMORY_LIMIT_MB while specs are registered
+	openapi2mcp.ConfigureMemoryTuning()
+
+	// Export tool call events before any spec is mounted, so all of their tools report them
+	startExporters()
 
 	// Check for configuration environment variables
 	pollingInterval := 30 // Default 30 seconds
@@ -919,11 +237,26 @@ func main() {
 	}
 
 	// Enable polling by default if DATABASE_URL is set
-	pollingEnabled = os.Getenv("DATABASE_URL") != ""
+	pollingEnabled := os.Getenv("DATABASE_URL") != ""
 	if os.Getenv("DISABLE_POLLING") == "true" {
 		pollingEnabled = false
 	}
 
+	// Session IDs are JWTs signed with MCP_SESSION_SECRET
+	sessionSecret, sessionTTL := sessionSettings()
+	// Sessions are kept in MCP_SESSION_STORE, e.g. Redis, to survive restarts and be shared by replicas
+	sessionStore, err := openapi2mcp.SessionStore()
+	if err != nil {
+		addStartupWarning("Invalid MCP_SESSION_STORE, sessions are kept in memory: %v", err)
+	}
+	// Behind a reverse proxy, clients are sent URLs under MCP_PUBLIC_URL
+	publicURL, err := openapi2mcp.PublicURL()
+	if err != nil {
+		addStartupWarning("Invalid MCP_PUBLIC_URL, clients are sent URLs of the server itself: %v", err)
+	}
+	// Specs whose upstream calls keep failing are reported degraded, and optionally deactivated
+	autoDeactivate, alertWebhook := specHealthSettings()
+
 	// Track required environment variables
 	requiredEnvVars := make(map[string]string)
 
@@ -932,56 +265,82 @@ func main() {
 		log.Printf("DATABASE_URL found, attempting to load specs from database...")
 
 		if err := database.InitializeDatabase(); err != nil {
-			log.Printf("Failed to initialize database: %v, falling back to file loading", err)
+			addStartupWarning("Failed to initialize database: %v, falling back to file loading", err)
 		} else {
-			specLoader = services.NewSpecLoaderService(database.DB)
-			specs, hash, err := loadSpecsFromDatabase()
+			// Tokens are stored encrypted with MCP_TOKEN_ENCRYPTION_KEY when it is set
+			if _, err := secrets.DefaultTokenCipher(); err != nil {
+				addStartupWarning("Invalid token encryption key, encrypted tokens cannot be read or stored: %v", err)
+			}
+			// Journal tool calls before mounting specs, so all of their tools are covered
+			startCallJournal(database.DB)
+			startAuditLog(database.DB)
+			specLoader := services.NewSpecLoaderService(database.DB)
+			// Deleted specs can be restored until the retention period ends
+			startSpecPurge(specLoader)
+			// Import specs announced in the cluster before the initial load mounts them
+			startKubernetesDiscovery(specLoader)
+			gateway = dynamicserver.New(dynamicserver.Options{
+				SpecLoader:       specLoader,
+				PollInterval:     time.Duration(pollingInterval) * time.Second,
+				EnsureConnection: database.EnsureConnection,
+				SessionSecret:    sessionSecret,
+				SessionTTL:       sessionTTL,
+				AutoDeactivate:   autoDeactivate,
+				AlertWebhook:     alertWebhook,
+				Compression:      openapi2mcp.CompressionConfig(),
+				SessionStore:     sessionStore,
+				DrainTimeout:     drainTimeoutSetting(),
+				RateLimit:        rateLimitSetting(),
+				PublicURL:        publicURL,
+			})
+			registerAdminRoutes(gateway)
+			result, err := gateway.Reload(context.Background())
 			if err != nil {
-				log.Printf("Failed to get active specs from database: %v, falling back to file loading", err)
-			} else if len(specs) > 0 {
-				log.Printf("Successfully loaded %d active specs from database", len(specs))
-
-				// Create initial endpoints
-				mountedAPIs, err := createSpecEndpoints(specs)
-				if err != nil {
-					log.Fatalf("Failed to create spec endpoints: %v", err)
-				}
-
-				lastSpecHash = hash
+				addStartupWarning("Failed to get active specs from database: %v, falling back to file loading", err)
+			} else if result.ActiveSpec > 0 {
+				log.Printf("Successfully loaded %d active specs from database", result.ActiveSpec)
+				mountedAPIs := result.Mounted
+				databaseMode = true
 				log.Printf("Initial load complete. Mounted APIs: %v", mountedAPIs)
+				if result.Report != nil {
+					for _, timing := range result.Report.Specs {
+						if timing.Error != "" {
+							startupWarnings = append(startupWarnings, fmt.Sprintf("Failed to mount spec %s: %s", timing.Name, timing.Error))
+						}
+					}
+				}
 
-				// Start database polling for automatic reload
-				startDatabasePolling(pollingInterval)
+				// Database polling for automatic reload; it can be started, stopped and
+				// re-timed at runtime through /polling
+				if pollingEnabled {
+					gateway.Scheduler().Start()
+				} else {
+					log.Printf("Database polling disabled")
+				}
 
 				// Create HTTP server with dynamic handler
-				srv := &http.Server{
-					Addr: ":8080",
-					Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-						reloadMux.RLock()
-						mux := globalMux
-						reloadMux.RUnlock()
-
-						if mux != nil {
-							mux.ServeHTTP(w, r)
-						} else {
-							http.Error(w, "Server not ready", http.StatusServiceUnavailable)
-						}
-					}),
-					ReadTimeout:  240 * time.Second, // Increased to 4 minutes for very large spec uploads
-					WriteTimeout: 240 * time.Second, // Increased to 4 minutes for large responses
-				}
+				// Phase timeouts: see MCP_SERVER_*_TIMEOUT
+				srv := openapi2mcp.NewHTTPServer(":8080", gateway.Handler())
 
 				log.Printf("Starting dynamic database-driven server on %s", srv.Addr)
 				log.Printf("Available endpoints:")
+				log.Printf("  GET    /admin                   - Admin dashboard")
 				log.Printf("  POST   /reload                  - Reload specs from database")
+				log.Printf("  GET    /reload                  - Recent reloads")
+				log.Printf("  GET    /polling                 - Database polling status")
+				log.Printf("  PUT    /polling                 - Change polling interval or enable/disable it")
+				log.Printf("  POST   /polling/start|stop      - Start or stop database polling")
 				log.Printf("  GET    /health                  - Health check")
+				log.Printf("  GET    /info                    - Server version, features and mounted endpoints")
 				log.Printf("  GET    /swagger                 - OpenAPI specification")
 				log.Printf("  GET    /specs                   - List all specs")
 				log.Printf("  POST   /specs                   - Create new spec")
 				log.Printf("  GET    /specs/active            - List active specs")
+				log.Printf("  GET    /specs/deleted           - List deleted specs")
 				log.Printf("  GET    /specs/{id}              - Get spec by ID")
 				log.Printf("  PUT    /specs/{id}              - Update spec")
-				log.Printf("  DELETE /specs/{id}              - Delete spec")
+				log.Printf("  DELETE /specs/{id}              - Delete spec (restorable until purged)")
+				log.Printf("  POST   /specs/{id}/restore      - Restore deleted spec")
 				log.Printf("  POST   /specs/{id}/activate     - Activate spec")
 				log.Printf("  POST   /specs/{id}/deactivate   - Deactivate spec")
 				log.Printf("  PUT    /specs/{id}/token        - Update API key token")
@@ -993,9 +352,11 @@ func main() {
 					log.Printf("   Set DISABLE_POLLING=true to disable automatic polling")
 				} else {
 					log.Printf("📋 Database polling disabled")
-					log.Printf("   Use POST /reload to manually reload specs")
+					log.Printf("   Use POST /reload to manually reload specs, or POST /polling/start to enable polling")
 				}
 
+				writeStartupReport(srv.Addr)
+				logStartupBanner(srv.Addr)
 				if err := startServerWithGracefulShutdown(srv); err != nil {
 					log.Fatalf("HTTP server error: %v", err)
 				}
@@ -1005,9 +366,26 @@ func main() {
 	}
 
 	log.Printf("No DATABASE_URL or no database specs found, falling back to file loading...")
+	// Discovered specs are only served in database mode
+	stopKubernetesDiscovery()
+
+	// Audit tool calls, unless the database path already started it
+	startAuditLog(database.DB)
 
 	specsDir := "./specs"
-	mux := http.NewServeMux()
+	// File specs are validated strictly, as they are not checked on import like database specs
+	gateway = dynamicserver.New(dynamicserver.Options{
+		Pipeline:      services.NewSpecPipeline(true),
+		SessionSecret: sessionSecret,
+		SessionTTL:    sessionTTL,
+		AlertWebhook:  alertWebhook,
+		Compression:   openapi2mcp.CompressionConfig(),
+		SessionStore:  sessionStore,
+		DrainTimeout:  drainTimeoutSetting(),
+		RateLimit:     rateLimitSetting(),
+		PublicURL:     publicURL,
+	})
+	registerAdminRoutes(gateway)
 
 	// Get all spec files from the specs directory
 	specFiles, err := filepath.Glob(filepath.Join(specsDir, "*"))
@@ -1016,7 +394,10 @@ func main() {
 	}
 
 	if len(specFiles) == 0 {
-		log.Fatalf("No spec files found in %s", specsDir)
+		// A binary built with embedded specs serves them when there is no specs directory
+		if mountEmbeddedSpecs(gateway, requiredEnvVars) == 0 {
+			log.Fatalf("No spec files found in %s", specsDir)
+		}
 	}
 
 	// Process each spec file (fallback mode)
@@ -1028,97 +409,22 @@ func main() {
 
 		// Get the filename for endpoint creation
 		filename := filepath.Base(specFile)
-		endpoint := getEndpointFromFilename(filename)
+		endpoint := services.EndpointFromPath(filename)
 
 		log.Printf("Loading spec: %s -> endpoint: /%s", filename, endpoint)
 
-		// Load OpenAPI spec
-		doc, err := openapi2mcp.LoadOpenAPISpec(specFile)
-		if err != nil {
-			log.Printf("Failed to load spec %s: %v", filename, err)
-			continue
-		}
-
-		// Read raw spec content for dynamic header casing preservation
-		rawContent, err := os.ReadFile(specFile)
+		// Load OpenAPI spec through the shared loading pipeline. The pipeline keeps
+		// the raw content in a synthetic database spec for header casing preservation.
+		mount, err := gateway.MountFile(context.Background(), specFile)
 		if err != nil {
-			log.Printf("Failed to read raw content for %s: %v", filename, err)
+			addStartupWarning("Failed to load spec %s: %v", filename, err)
 			continue
 		}
 
-		// Create a mock database spec with raw content for header casing preservation
-		mockDBSpec := &models.OpenAPISpec{
-			Name:         endpoint,
-			SpecContent:  string(rawContent),
-			EndpointPath: "/" + endpoint,
+		// Add to required environment variables
+		if name, description := services.RequiredEnvVar(mount.Loaded); name != "" {
+			requiredEnvVars[name] = description
 		}
-
-		// Log the authentication scheme extracted from spec with proper header casing
-		schemeName, authType, authPath := auth.ExtractAuthSchemeFromSpecWithContent(doc, string(rawContent))
-		if authPath != "" {
-			log.Printf("%s API: Found security scheme '%s' with %s authentication: %s", endpoint, schemeName, authType, authPath)
-			// Add to required environment variables
-			switch authType {
-			case "apiKey":
-				requiredEnvVars[strings.ToUpper(endpoint)+"_API_KEY"] = "API key for " + doc.Info.Title
-			case "bearer":
-				requiredEnvVars[strings.ToUpper(endpoint)+"_BEARER_TOKEN"] = "Bearer token for " + doc.Info.Title
-			case "basic":
-				requiredEnvVars[strings.ToUpper(endpoint)+"_BASIC_AUTH"] = "Basic auth for " + doc.Info.Title
-			}
-		} else {
-			log.Printf("%s API: No authentication security scheme found in spec", endpoint)
-		}
-
-		// Create MCP server
-		bearerToken := os.Getenv("BEARER_TOKEN")
-		apiKey := os.Getenv("API_KEY")
-
-		log.Printf("Creating MCP server for %s with BEARER_TOKEN=%s, API_KEY=%s",
-			endpoint,
-			func() string {
-				if bearerToken != "" {
-					return bearerToken[:10] + "..."
-				}
-				return "NOT_SET"
-			}(),
-			func() string {
-				if apiKey != "" {
-					return apiKey[:10] + "..."
-				}
-				return "NOT_SET"
-			}())
-		srv := openapi2mcp.NewServerWithDatabase(doc.Info.Title, doc.Info.Version, doc, mockDBSpec)
-
-		// Create a custom StreamableHTTPServer with the package's built-in auth function
-		// For file-based loading, pass mock database spec to preserve header casing
-		streamableServer := server.NewStreamableHTTPServer(srv,
-			server.WithEndpointPath("/"+endpoint),
-			server.WithHTTPContextFunc(func(ctx context.Context, r *http.Request) context.Context {
-				return secureAuthContextFunc(ctx, r, doc, mockDBSpec)
-			}),
-		)
-
-		// Create a custom SSE Server with the package's built-in auth function
-		// For file-based loading, pass mock database spec to preserve header casing
-		sseServer := server.NewSSEServer(srv,
-			server.WithStaticBasePath("/"+endpoint),
-			server.WithSSEEndpoint("/sse"),
-			server.WithMessageEndpoint("/message"),
-			server.WithSSEContextFunc(func(ctx context.Context, r *http.Request) context.Context {
-				return secureAuthContextFunc(ctx, r, doc, mockDBSpec)
-			}),
-		)
-
-		// Mount the StreamableHTTP server at the main endpoint path
-		mux.Handle("/"+endpoint, streamableServer)
-		mux.Handle("/"+endpoint+"/", streamableServer)
-
-		// Mount the SSE server endpoints
-		mux.Handle("/"+endpoint+"/sse", sseServer.SSEHandler())
-		mux.Handle("/"+endpoint+"/message", sseServer.MessageHandler())
-
-		log.Printf("Mounted %s API at /%s (StreamableHTTP) and /%s/sse + /%s/message (SSE)", doc.Info.Title, endpoint, endpoint, endpoint)
 	}
 
 	// Log required environment variables
@@ -1149,13 +455,11 @@ func main() {
 	}
 	log.Printf("=====================================")
 
-	srv := &http.Server{
-		Addr:         ":8080",
-		Handler:      mux,
-		ReadTimeout:  240 * time.Second, // Increased to 4 minutes for very large spec uploads
-		WriteTimeout: 240 * time.Second, // Increased to 4 minutes for large responses
-	}
+	// Phase timeouts: see MCP_SERVER_*_TIMEOUT
+	srv := openapi2mcp.NewHTTPServer(":8080", gateway.Handler())
 
+	writeStartupReport(srv.Addr)
+	logStartupBanner(srv.Addr)
 	if err := startServerWithGracefulShutdown(srv); err != nil {
 		log.Fatalf("HTTP server error: %v", err)
 	}
@@ -0,0 +1,177 @@
+// Package apierrors is the error taxonomy shared by the management API, the MCP
+// transports and the tool handlers, so that clients see one error shape whatever
+// part of the server produced the error.
+package apierrors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"time"
+)
+
+// Type classifies an error. It decides the HTTP status an error is rendered with.
+type Type string
+
+const (
+	TypeValidation  Type = "validation"
+	TypeDatabase    Type = "database"
+	TypeAuth        Type = "authentication"
+	TypeNetwork     Type = "network"
+	TypeInternal    Type = "internal"
+	TypeNotFound    Type = "not_found"
+	TypeConflict    Type = "conflict"
+	TypeUnsupported Type = "unsupported"
+	TypeUnavailable Type = "unavailable"
+)
+
+// Typed is implemented by errors that know their place in the taxonomy.
+type Typed interface {
+	ErrorType() Type
+}
+
+// Error is a structured error with context
+type Error struct {
+	Type       Type   `json:"type"`
+	Message    string `json:"message"`
+	Details    string `json:"details,omitempty"`
+	RequestID  string `json:"request_id,omitempty"`
+	Timestamp  int64  `json:"timestamp"`
+	StackTrace string `json:"stack_trace,omitempty"`
+
+	cause error
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	if e.Details != "" {
+		return fmt.Sprintf("%s: %s (%s)", e.Type, e.Message, e.Details)
+	}
+	return fmt.Sprintf("%s: %s", e.Type, e.Message)
+}
+
+// ErrorType implements Typed
+func (e *Error) ErrorType() Type {
+	return e.Type
+}
+
+// Unwrap returns the wrapped error, if any
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// New creates a new Error
+func New(errType Type, message string, details string) *Error {
+	return &Error{
+		Type:      errType,
+		Message:   message,
+		Details:   details,
+		Timestamp: time.Now().Unix(),
+	}
+}
+
+// NewWithContext creates a new Error carrying the request ID from ctx, if any
+func NewWithContext(ctx context.Context, errType Type, message string, details string) *Error {
+	err := New(errType, message, details)
+	if requestID, ok := ctx.Value("request_id").(string); ok {
+		err.RequestID = requestID
+	}
+	return err
+}
+
+// Wrap wraps err as an Error. The original error stays reachable with errors.Is and errors.As.
+func Wrap(err error, errType Type, message string) *Error {
+	if err == nil {
+		return nil
+	}
+	e := New(errType, message, err.Error())
+	e.cause = err
+	return e
+}
+
+// WrapWithContext wraps err as an Error carrying the request ID from ctx, if any
+func WrapWithContext(ctx context.Context, err error, errType Type, message string) *Error {
+	if err == nil {
+		return nil
+	}
+	e := NewWithContext(ctx, errType, message, err.Error())
+	e.cause = err
+	return e
+}
+
+// WithStackTrace adds stack trace information to the error
+func (e *Error) WithStackTrace() *Error {
+	// Large enough to reach past the runtime frames to the panic site when called from a recover
+	buf := make([]byte, 8192)
+	n := runtime.Stack(buf, false)
+	e.StackTrace = string(buf[:n])
+	return e
+}
+
+// LogError logs the error with appropriate level and context
+func (e *Error) LogError() {
+	switch e.Type {
+	case TypeValidation:
+		log.Printf("VALIDATION ERROR: %s", e.Error())
+	case TypeAuth:
+		log.Printf("AUTH ERROR: %s", e.Error())
+	case TypeDatabase:
+		log.Printf("DATABASE ERROR: %s", e.Error())
+	case TypeNetwork:
+		log.Printf("NETWORK ERROR: %s", e.Error())
+	case TypeNotFound:
+		log.Printf("NOT FOUND: %s", e.Error())
+	case TypeConflict:
+		log.Printf("CONFLICT: %s", e.Error())
+	default:
+		log.Printf("ERROR: %s", e.Error())
+	}
+
+	if e.StackTrace != "" {
+		log.Printf("Stack trace: %s", e.StackTrace)
+	}
+}
+
+// sentinel is a comparable error value with a type, for use with errors.Is
+type sentinel struct {
+	errType Type
+	message string
+}
+
+func (s *sentinel) Error() string   { return s.message }
+func (s *sentinel) ErrorType() Type { return s.errType }
+
+// Sentinel returns a new sentinel error, like errors.New, classified as errType
+func Sentinel(errType Type, message string) error {
+	return &sentinel{errType: errType, message: message}
+}
+
+// TypeOf classifies err: the type of the first Typed error in its chain, or TypeInternal
+func TypeOf(err error) Type {
+	var typed Typed
+	if errors.As(err, &typed) {
+		return typed.ErrorType()
+	}
+	return TypeInternal
+}
+
+// IsType checks whether err is classified as errType
+func IsType(err error, errType Type) bool {
+	return err != nil && TypeOf(err) == errType
+}
+
+// From converts any error into an Error, keeping it as-is if it already is one
+func From(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	wrapped := New(TypeOf(err), err.Error(), "")
+	wrapped.cause = err
+	return wrapped
+}
@@ -0,0 +1,106 @@
+package apierrors
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Response is the JSON body of every HTTP error response
+type Response struct {
+	Error     string `json:"error"`
+	Message   string `json:"message,omitempty"`
+	Code      int    `json:"code"`
+	Type      Type   `json:"type"`
+	Details   string `json:"details,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Details is attached to JSON-RPC errors so MCP clients get the same classification as HTTP clients
+type Details struct {
+	Type      Type   `json:"type"`
+	Details   string `json:"details,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// HTTPStatus returns the HTTP status code an error type is rendered with
+func HTTPStatus(errType Type) int {
+	switch errType {
+	case TypeValidation:
+		return http.StatusBadRequest
+	case TypeAuth:
+		return http.StatusUnauthorized
+	case TypeNotFound:
+		return http.StatusNotFound
+	case TypeConflict:
+		return http.StatusConflict
+	case TypeUnsupported:
+		return http.StatusMethodNotAllowed
+	case TypeNetwork:
+		return http.StatusBadGateway
+	case TypeUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// TypeForStatus classifies an HTTP status code, such as an upstream API response status
+func TypeForStatus(code int) Type {
+	switch code {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusUnsupportedMediaType:
+		return TypeValidation
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return TypeAuth
+	case http.StatusNotFound, http.StatusGone:
+		return TypeNotFound
+	case http.StatusConflict:
+		return TypeConflict
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return TypeUnsupported
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return TypeNetwork
+	case http.StatusServiceUnavailable:
+		return TypeUnavailable
+	default:
+		return TypeInternal
+	}
+}
+
+// DetailsOf returns the JSON-RPC error details for err
+func DetailsOf(err error) *Details {
+	e := From(err)
+	if e == nil {
+		return nil
+	}
+	return &Details{Type: e.Type, Details: e.Details, RequestID: e.RequestID}
+}
+
+// Write renders err as a JSON error response, with the status derived from its type
+func Write(w http.ResponseWriter, err error) {
+	e := From(err)
+	code := HTTPStatus(e.Type)
+	writeResponse(w, code, Response{
+		Error:     http.StatusText(code),
+		Message:   e.Message,
+		Code:      code,
+		Type:      e.Type,
+		Details:   e.Details,
+		RequestID: e.RequestID,
+	})
+}
+
+// WriteStatus renders a JSON error response for an explicit status code
+func WriteStatus(w http.ResponseWriter, code int, message string) {
+	writeResponse(w, code, Response{
+		Error:   http.StatusText(code),
+		Message: message,
+		Code:    code,
+		Type:    TypeForStatus(code),
+	})
+}
+
+func writeResponse(w http.ResponseWriter, code int, body Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,99 @@
+// Package audit records every tool call for compliance review: the tool, spec endpoint,
+// caller session, a hash of the arguments, the duration and the upstream status. Records
+// are written to sinks: stdout as JSON Lines, a database table, or webhooks. Like usage
+// exports, sinks buffer records and write them in batches, so a slow sink never delays
+// tool calls; records a sink cannot keep up with are dropped and counted.
+//
+//	sinks, err := audit.ParseSinks("stdout,https://audit.example.com/ingest", nil)
+//	logger, err := audit.New(sinks)
+//	openapi2mcp.SetDefaultCallEventSink(logger)
+//	defer logger.Close(ctx)
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/export"
+	"github.com/ubermorgenland/openapi-mcp/pkg/openapi2mcp"
+)
+
+// EventType is the export event type of audit records; their data is an openapi2mcp.CallEvent.
+const EventType = "tool_call_audit"
+
+// Logger writes an audit record of each finished tool call to its sinks. It implements
+// openapi2mcp.CallEventSink.
+type Logger struct {
+	exporter *export.Exporter
+}
+
+// ParseSinks reads a comma-separated list of sinks: "stdout", "database" (written to
+// database, which must not be nil then) or webhook URLs, which receive each batch as a JSON
+// array. MCP_AUDIT_WEBHOOK_TOKEN, when set, is sent to webhooks as a bearer token.
+func ParseSinks(spec string, database export.Sink) ([]export.SinkConfig, error) {
+	var sinks []export.SinkConfig
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		switch {
+		case name == "":
+		case name == "stdout":
+			sinks = append(sinks, export.SinkConfig{Name: "audit-stdout", Type: "stdout"})
+		case name == "database" || name == "postgres":
+			if database == nil {
+				return nil, fmt.Errorf("the database audit sink requires DATABASE_URL")
+			}
+			sinks = append(sinks, export.SinkConfig{Name: "audit-database", Type: "database", Sink: database})
+		case strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://"):
+			u, err := url.Parse(name)
+			if err != nil || u.Host == "" {
+				return nil, fmt.Errorf("invalid audit webhook URL %q", name)
+			}
+			sc := export.SinkConfig{Name: "audit-webhook-" + u.Host, Type: "http", URL: name}
+			if token := os.Getenv("MCP_AUDIT_WEBHOOK_TOKEN"); token != "" {
+				sc.Headers = map[string]string{"Authorization": "Bearer " + token}
+			}
+			sinks = append(sinks, sc)
+		default:
+			return nil, fmt.Errorf("unknown audit sink %q: use stdout, database or a webhook URL", name)
+		}
+	}
+	return sinks, nil
+}
+
+// New starts writing audit records to sinks.
+func New(sinks []export.SinkConfig) (*Logger, error) {
+	if len(sinks) == 0 {
+		return nil, fmt.Errorf("no audit sinks configured")
+	}
+	for i := range sinks {
+		sinks[i].Events = []string{EventType}
+	}
+	exporter, err := export.New(&export.Config{Sinks: sinks})
+	if err != nil {
+		return nil, err
+	}
+	return &Logger{exporter: exporter}, nil
+}
+
+// RecordCall queues the audit record of a finished call.
+func (l *Logger) RecordCall(event openapi2mcp.CallEvent) {
+	l.exporter.Emit(export.Event{Type: EventType, Time: event.Time, Key: event.Endpoint, Data: event})
+}
+
+// Close writes the buffered records; those still unwritten when ctx ends are dropped.
+func (l *Logger) Close(ctx context.Context) {
+	l.exporter.Close(ctx)
+}
+
+// Stats returns the counters of each sink.
+func (l *Logger) Stats() []export.SinkStats {
+	return l.exporter.Stats()
+}
+
+// Sinks returns the names of the sinks.
+func (l *Logger) Sinks() []string {
+	return l.exporter.Sinks()
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+	"github.com/ubermorgenland/openapi-mcp/pkg/secrets"
 	"gopkg.in/yaml.v3"
 )
 
@@ -20,6 +21,10 @@ type AuthContext struct {
 	SpecParamName string // OpenAPI spec-defined parameter name for API keys
 	ApiHost       string // API host from OpenAPI spec servers
 	HostHeaders   map[string]string // Host headers extracted from OpenAPI spec parameters
+	QueryParamName string // query parameter API keys are injected in, if any; see authQueryParamName
+	Profile       *GatewayProfile // conventions of the gateway hosting the API, if any; see SpecGatewayProfile
+	SchemeName    string // security scheme the token is for; see ResolveOperationScheme
+	DatabaseToken bool   // Token is an API key of the spec in the database, so calls may rotate over its others; see KeyRotation
 	
 	// Cache for parsed header mappings to avoid re-parsing spec content multiple times per request
 	headerMappingCache map[string]string
@@ -39,6 +44,11 @@ func CreateAuthContext(r *http.Request, doc *openapi3.T, spec *models.OpenAPISpe
 // CreateAuthContextWithToolArgs creates authentication context with support for tool-level arguments
 // Tool arguments take highest priority and can override database/header authentication
 func CreateAuthContextWithToolArgs(r *http.Request, doc *openapi3.T, spec *models.OpenAPISpec, toolArgs map[string]any) *AuthContext {
+	return createAuthContext(r, doc, spec, toolArgs, nil)
+}
+
+// createAuthContext creates the authentication context for scheme, or for the spec's scheme when it is nil
+func createAuthContext(r *http.Request, doc *openapi3.T, spec *models.OpenAPISpec, toolArgs map[string]any, scheme *OperationScheme) *AuthContext {
 	authCtx := &AuthContext{}
 
 	// Extract endpoint from path
@@ -52,8 +62,17 @@ func CreateAuthContextWithToolArgs(r *http.Request, doc *openapi3.T, spec *model
 	}
 	authCtx.Endpoint = endpoint
 
-	// Determine auth type from spec
-	_, authType, _ := ExtractAuthSchemeFromSpec(doc)
+	// Determine auth type from spec, or from the scheme the operation requires
+	schemeName, authType, _ := ExtractAuthSchemeFromSpec(doc)
+	if scheme != nil {
+		schemeName, authType = scheme.Name, scheme.AuthType
+	}
+	authCtx.SchemeName = schemeName
+	// APIs behind a gateway authenticate with the gateway key, even without a security scheme
+	authCtx.Profile = SpecGatewayProfile(doc, spec, endpoint)
+	if authType == "" && authCtx.Profile != nil {
+		authType = "apiKey"
+	}
 	authCtx.AuthType = authType
 	
 	// Parse header mappings once and cache them in the auth context
@@ -70,6 +89,11 @@ func CreateAuthContextWithToolArgs(r *http.Request, doc *openapi3.T, spec *model
 		authCtx.SpecParamName = extractAPIKeyParameterNameWithCache(doc, authCtx.headerMappingCache)
 		authCtx.ApiHost = extractAPIHostFromSpec(doc)
 		authCtx.HostHeaders = extractHostHeadersWithCache(doc, authCtx.headerMappingCache)
+		authCtx.QueryParamName = authQueryParamName(doc, spec, endpoint)
+		if scheme != nil {
+			scheme.apply(authCtx, authQueryParamOverride(doc, spec, endpoint))
+		}
+		applyGatewayProfile(authCtx)
 	}
 
 	// Authentication Priority Hierarchy:
@@ -81,8 +105,12 @@ func CreateAuthContextWithToolArgs(r *http.Request, doc *openapi3.T, spec *model
 
 	token := ""
 
-	// Priority 1: Extract token from tool arguments if available
-	if toolArgs != nil {
+	// Priority 1: Extract token from tool arguments if available; an operation's scheme is
+	// checked under its own parameter name first
+	if scheme != nil {
+		token = scheme.token(r, toolArgs)
+	}
+	if token == "" && toolArgs != nil {
 		token = extractTokenFromToolArgs(toolArgs, authType, doc)
 	}
 
@@ -90,16 +118,25 @@ func CreateAuthContextWithToolArgs(r *http.Request, doc *openapi3.T, spec *model
 	if token == "" {
 		token = extractTokenFromRequestHeadersWithCache(r, authType, doc, authCtx.headerMappingCache)
 	}
+	if token == "" && authType == "apiKey" && authCtx.Profile != nil {
+		token = r.Header.Get(authCtx.Profile.KeyHeader)
+	}
 
-	// Priority 3: Database tokens as fallback
-	if token == "" && spec != nil && spec.ApiKeyToken != nil && *spec.ApiKeyToken != "" {
-		token = *spec.ApiKeyToken
+	// Priority 3: Database tokens as fallback; references like vault://... are resolved through the secret manager
+	if token == "" {
+		if creds := SpecCredentials(spec); len(creds) > 0 {
+			token = resolveDatabaseToken(r.Context(), creds[0].Token)
+			authCtx.DatabaseToken = token != ""
+		}
 	}
 
 	// Priority 4: Environment variables as final fallback
 	if token == "" {
 		token = extractTokenFromEnvironment(authType)
 	}
+	if token == "" && authType == "apiKey" && authCtx.Profile != nil && authCtx.Profile.KeyEnvVar != "" {
+		token = os.Getenv(authCtx.Profile.KeyEnvVar)
+	}
 
 	authCtx.Token = token
 	
@@ -109,6 +146,25 @@ func CreateAuthContextWithToolArgs(r *http.Request, doc *openapi3.T, spec *model
 	return authCtx
 }
 
+// resolveDatabaseToken returns a spec's stored token, fetching it from the secret manager
+// when it is a reference. A reference that cannot be resolved yields no token, so the
+// environment fallback applies.
+func resolveDatabaseToken(ctx context.Context, stored string) string {
+	token, err := secrets.Default().Resolve(ctx, stored)
+	if err != nil {
+		log.Printf("Failed to resolve database token: %v", err)
+		return ""
+	}
+	return token
+}
+
+// WithToken returns a copy of the authentication context with another token.
+func (a *AuthContext) WithToken(token string) *AuthContext {
+	c := *a
+	c.Token = token
+	return &c
+}
+
 func WithAuthContext(ctx context.Context, authCtx *AuthContext) context.Context {
 	return context.WithValue(ctx, authContextKey, authCtx)
 }
@@ -130,8 +186,9 @@ func ExtractAuthSchemeFromSpecWithContent(doc *openapi3.T, rawSpecContent string
 		return "", "", ""
 	}
 
-	// Look for the first security scheme
-	for schemeName, schemeRef := range doc.Components.SecuritySchemes {
+	// Look for the first security scheme, preferring those the spec requires globally
+	for _, schemeName := range schemeOrder(doc) {
+		schemeRef := doc.Components.SecuritySchemes[schemeName]
 		if schemeRef.Value != nil {
 			switch schemeRef.Value.Type {
 			case "apiKey":
@@ -228,7 +285,8 @@ func extractAPIKeyParameterNameFromSpec(doc *openapi3.T) string {
 		return ""
 	}
 
-	for _, schemeRef := range doc.Components.SecuritySchemes {
+	for _, schemeName := range schemeOrder(doc) {
+		schemeRef := doc.Components.SecuritySchemes[schemeName]
 		if schemeRef.Value != nil && schemeRef.Value.Type == "apiKey" {
 			return schemeRef.Value.Name
 		}
@@ -310,7 +368,6 @@ func extractTokenFromRequestHeadersWithSpec(r *http.Request, authType string, do
 			"Authorization",     // Generic auth header (check for non-Bearer/Basic)
 			"X-API-Key",        // Common API key header
 			"Api-Key",          // Alternative API key header
-			"X-RapidAPI-Key",   // RapidAPI specific with correct casing
 		}
 		for _, header := range fallbackHeaders {
 			if value := r.Header.Get(header); value != "" {
@@ -357,7 +414,6 @@ func extractTokenFromRequestHeadersWithCache(r *http.Request, authType string, d
 			"Authorization",     // Generic auth header (check for non-Bearer/Basic)
 			"X-API-Key",        // Common API key header
 			"Api-Key",          // Alternative API key header
-			"X-RapidAPI-Key",   // RapidAPI specific with correct casing
 		}
 		for _, header := range fallbackHeaders {
 			if value := r.Header.Get(header); value != "" {
@@ -383,7 +439,8 @@ func extractAPIKeyHeaderFromSpec(doc *openapi3.T) string {
 	}
 
 	// Look for API key security schemes
-	for _, schemeRef := range doc.Components.SecuritySchemes {
+	for _, schemeName := range schemeOrder(doc) {
+		schemeRef := doc.Components.SecuritySchemes[schemeName]
 		if schemeRef.Value != nil && schemeRef.Value.Type == "apiKey" && schemeRef.Value.In == "header" {
 			return schemeRef.Value.Name
 		}
@@ -450,7 +507,6 @@ func extractTokenFromEnvironment(authType string) string {
 		// Try environment variables in priority order
 		envVars := []string{
 			"API_KEY",           // Generic API key
-			"RAPIDAPI_KEY",      // RapidAPI specific
 			"X_API_KEY",         // X-API-Key variant
 		}
 		for _, envVar := range envVars {
@@ -462,7 +518,7 @@ func extractTokenFromEnvironment(authType string) string {
 	return ""
 }
 // extractAPIHostFromSpec extracts the API host from OpenAPI spec's servers section
-// This is used for APIs like RapidAPI that require a specific host header (x-rapidapi-host)
+// This is used for gateways like RapidAPI that require a host header naming the API (see GatewayProfile)
 func extractAPIHostFromSpec(doc *openapi3.T) string {
 	if doc == nil || doc.Servers == nil || len(doc.Servers) == 0 {
 		return ""
@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+)
+
+// Strategies spreading the calls of a spec over its API keys
+const (
+	// CredentialRoundRobin starts each call with the next key in turn
+	CredentialRoundRobin = "round-robin"
+	// CredentialFailover starts each call with the first key, using the others only while
+	// it is rejected
+	CredentialFailover = "failover"
+)
+
+// ParseCredentialStrategy validates a credential strategy; an empty string is round-robin.
+func ParseCredentialStrategy(s string) (string, error) {
+	switch s = strings.ToLower(strings.TrimSpace(s)); s {
+	case "", CredentialRoundRobin, CredentialFailover:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown credential strategy %q: use %s or %s", s, CredentialRoundRobin, CredentialFailover)
+}
+
+// Credential is an API key of a spec: its api_key_token or one of its spec_credentials.
+type Credential struct {
+	Key   string // "token" for the api_key_token, else the spec_credentials ID
+	Label string
+	Token string // the key, or a secret manager reference
+}
+
+// SpecCredentials returns the API keys of a spec in failover order: its api_key_token, then
+// its spec_credentials in the order they were added.
+func SpecCredentials(spec *models.OpenAPISpec) []Credential {
+	if spec == nil {
+		return nil
+	}
+	var creds []Credential
+	if spec.ApiKeyToken != nil && *spec.ApiKeyToken != "" {
+		creds = append(creds, Credential{Key: "token", Label: "api_key_token", Token: *spec.ApiKeyToken})
+	}
+	for _, c := range spec.Credentials {
+		cred := Credential{Key: strconv.Itoa(c.ID), Label: "credential " + strconv.Itoa(c.ID), Token: c.Token}
+		if c.Label != nil && *c.Label != "" {
+			cred.Label = *c.Label
+		}
+		creds = append(creds, cred)
+	}
+	return creds
+}
+
+// ResolveCredential returns the key of a credential, fetching it from the secret manager when
+// it is a reference; it returns "" when the reference does not resolve.
+func ResolveCredential(ctx context.Context, cred Credential) string {
+	return resolveDatabaseToken(ctx, cred.Token)
+}
+
+// KeyRotation picks the API key each call of a spec starts with, and benches keys the
+// upstream rejected (401) or rate limited (429) for a while.
+type KeyRotation struct {
+	mu      sync.Mutex
+	next    map[int]int          // spec ID -> index of the key the next round-robin call starts with
+	benched map[string]time.Time // spec ID/key -> end of the bench
+}
+
+// NewKeyRotation creates an empty rotation.
+func NewKeyRotation() *KeyRotation {
+	return &KeyRotation{next: map[int]int{}, benched: map[string]time.Time{}}
+}
+
+var (
+	defaultKeyRotation     *KeyRotation
+	defaultKeyRotationOnce sync.Once
+)
+
+// DefaultKeyRotation returns the process-wide key rotation.
+func DefaultKeyRotation() *KeyRotation {
+	defaultKeyRotationOnce.Do(func() {
+		defaultKeyRotation = NewKeyRotation()
+	})
+	return defaultKeyRotation
+}
+
+// Order returns the API keys of a spec in the order a call tries them: starting with the next
+// key in turn for round-robin or the first key for failover, with benched keys last, by the
+// end of their bench. Each call of a round-robin spec advances the turn.
+func (r *KeyRotation) Order(spec *models.OpenAPISpec) []Credential {
+	creds := SpecCredentials(spec)
+	if len(creds) < 2 {
+		return creds
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	start := 0
+	if spec.CredentialStrategy == nil || *spec.CredentialStrategy != CredentialFailover {
+		start = r.next[spec.ID] % len(creds)
+		r.next[spec.ID] = start + 1
+	}
+	now := time.Now()
+	ordered := make([]Credential, 0, len(creds))
+	var benched []Credential
+	for i := range creds {
+		cred := creds[(start+i)%len(creds)]
+		if until, ok := r.benched[benchKey(spec.ID, cred)]; ok && now.Before(until) {
+			benched = append(benched, cred)
+			continue
+		}
+		ordered = append(ordered, cred)
+	}
+	// Once every key is benched, the one available first is tried first
+	for len(benched) > 0 {
+		first := 0
+		for i, cred := range benched {
+			if r.benched[benchKey(spec.ID, cred)].Before(r.benched[benchKey(spec.ID, benched[first])]) {
+				first = i
+			}
+		}
+		ordered = append(ordered, benched[first])
+		benched = append(benched[:first], benched[first+1:]...)
+	}
+	return ordered
+}
+
+// Bench keeps a spec's API key out of the rotation for d, unless no other key is available.
+func (r *KeyRotation) Bench(spec *models.OpenAPISpec, cred Credential, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.benched[benchKey(spec.ID, cred)] = time.Now().Add(d)
+}
+
+func benchKey(specID int, cred Credential) string {
+	return strconv.Itoa(specID) + "/" + cred.Key
+}
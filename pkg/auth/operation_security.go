@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+)
+
+// OperationScheme is the security scheme a tool call authenticates with, resolved from the
+// security requirements of its operation
+type OperationScheme struct {
+	Name      string // name in components.securitySchemes; "" when the operation needs no credentials
+	AuthType  string // "apiKey", "bearer" or "basic"; "" when the operation needs no credentials
+	In        string // "header" or "query", for apiKey schemes
+	ParamName string // header or query parameter the key is sent in, for apiKey schemes
+}
+
+// schemeOrder returns the names of a spec's security schemes, those of its global security
+// requirements first, so the scheme a spec authenticates with does not depend on map order
+func schemeOrder(doc *openapi3.T) []string {
+	if doc == nil || doc.Components == nil {
+		return nil
+	}
+	var names []string
+	seen := map[string]bool{}
+	for _, req := range doc.Security {
+		for _, name := range sortedRequirement(req) {
+			if _, ok := doc.Components.SecuritySchemes[name]; ok && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	var rest []string
+	for name := range doc.Components.SecuritySchemes {
+		if !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	return append(names, rest...)
+}
+
+func sortedRequirement(req openapi3.SecurityRequirement) []string {
+	names := make([]string, 0, len(req))
+	for name := range req {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// schemeFor describes a security scheme of the spec, or returns nil when it is unknown or of
+// a type credentials cannot be injected for (oauth2, openIdConnect, mutualTLS)
+func schemeFor(doc *openapi3.T, name string) *OperationScheme {
+	if doc == nil || doc.Components == nil {
+		return nil
+	}
+	ref := doc.Components.SecuritySchemes[name]
+	if ref == nil || ref.Value == nil {
+		return nil
+	}
+	switch s := ref.Value; {
+	case s.Type == "apiKey" && (s.In == "header" || s.In == "query"):
+		return &OperationScheme{Name: name, AuthType: "apiKey", In: s.In, ParamName: s.Name}
+	case s.Type == "http" && strings.EqualFold(s.Scheme, "bearer"):
+		return &OperationScheme{Name: name, AuthType: "bearer"}
+	case s.Type == "http" && strings.EqualFold(s.Scheme, "basic"):
+		return &OperationScheme{Name: name, AuthType: "basic"}
+	}
+	return nil
+}
+
+// ResolveOperationScheme returns the security scheme an operation authenticates with: the
+// first of its alternative security requirements with a scheme credentials can be injected
+// for. Requirements combining several schemes use the first of them by name, since a call
+// carries one credential. It returns a scheme without AuthType for operations that need no
+// credentials (`security: []`, or only an empty alternative), and nil when the operation
+// declares no requirements the server can satisfy, so the spec's scheme applies.
+func ResolveOperationScheme(doc *openapi3.T, security openapi3.SecurityRequirements) *OperationScheme {
+	if security == nil {
+		return nil
+	}
+	anonymous := len(security) == 0
+	for _, req := range security {
+		if len(req) == 0 {
+			anonymous = true
+			continue
+		}
+		for _, name := range sortedRequirement(req) {
+			if scheme := schemeFor(doc, name); scheme != nil {
+				return scheme
+			}
+		}
+	}
+	if anonymous {
+		return &OperationScheme{}
+	}
+	return nil
+}
+
+// Matches reports whether an auth context already authenticates with the scheme, so a tool
+// call can reuse it as is
+func (s *OperationScheme) Matches(authCtx *AuthContext) bool {
+	return authCtx != nil && authCtx.AuthType == s.AuthType && authCtx.SchemeName == s.Name
+}
+
+// apply points an apiKey auth context at the parameter of the scheme. queryOverride is the
+// query parameter set by x-mcp-auth-query-param, if any.
+func (s *OperationScheme) apply(authCtx *AuthContext, queryOverride string) {
+	if s.AuthType != "apiKey" {
+		return
+	}
+	if s.In == "query" {
+		authCtx.SpecParamName = ""
+		authCtx.QueryParamName = s.ParamName
+		if queryOverride != "" {
+			authCtx.QueryParamName = queryOverride
+		}
+		return
+	}
+	authCtx.SpecParamName = s.ParamName
+	if original, ok := authCtx.headerMappingCache[strings.ToLower(s.ParamName)]; ok {
+		authCtx.SpecParamName = original
+	}
+	authCtx.QueryParamName = ""
+}
+
+// token returns the credential of the scheme given in the tool arguments or the request
+// headers under the scheme's own parameter name
+func (s *OperationScheme) token(r *http.Request, toolArgs map[string]any) string {
+	if s.AuthType != "apiKey" {
+		return ""
+	}
+	if v, ok := toolArgs[s.ParamName].(string); ok && v != "" {
+		return v
+	}
+	if s.In == "header" && r != nil {
+		return r.Header.Get(s.ParamName)
+	}
+	return ""
+}
+
+// CreateOperationAuthContext creates the authentication context of a tool call whose
+// operation requires scheme, with the same token priority as CreateAuthContextWithToolArgs:
+// tool arguments, request headers, the database token, then environment variables. A nil
+// scheme uses the spec's scheme.
+func CreateOperationAuthContext(r *http.Request, doc *openapi3.T, spec *models.OpenAPISpec, toolArgs map[string]any, scheme *OperationScheme) *AuthContext {
+	return createAuthContext(r, doc, spec, toolArgs, scheme)
+}
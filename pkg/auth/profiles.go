@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"log"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+)
+
+// gatewayExtension selects the gateway profile of a spec, e.g. `x-mcp-gateway: rapidapi`.
+// "none" turns off the detection of a profile.
+const gatewayExtension = "x-mcp-gateway"
+
+// GatewayProfile describes the conventions shared by the APIs an API gateway hosts, such as
+// RapidAPI or Azure API Management: the header the gateway key is sent in, the header naming
+// the upstream API, and fixed headers every request needs.
+type GatewayProfile struct {
+	Name string
+	// KeyHeader is the header the gateway key is sent in, e.g. X-RapidAPI-Key. It is used
+	// when the spec declares no apiKey security scheme of its own.
+	KeyHeader string
+	// KeyEnvVar is the environment variable holding the gateway key, e.g. RAPIDAPI_KEY,
+	// checked after the generic API_KEY.
+	KeyEnvVar string
+	// HostHeader, if set, is sent with the host of the spec's first server, e.g. X-RapidAPI-Host.
+	HostHeader string
+	// ExtraHeaders are sent with every request.
+	ExtraHeaders map[string]string
+	// DetectHeaders detect the profile when the spec declares one of them in a security
+	// scheme or parameter. Only headers unique to the gateway belong here.
+	DetectHeaders []string
+	// HostSuffixes detect the profile from the host of the spec's first server.
+	HostSuffixes []string
+}
+
+// headers returns the header names the gateway fills in, lowercased
+func (p *GatewayProfile) headers() []string {
+	names := []string{strings.ToLower(p.KeyHeader)}
+	if p.HostHeader != "" {
+		names = append(names, strings.ToLower(p.HostHeader))
+	}
+	for name := range p.ExtraHeaders {
+		names = append(names, strings.ToLower(name))
+	}
+	return names
+}
+
+var (
+	gatewayProfilesMu sync.RWMutex
+	gatewayProfiles   = map[string]*GatewayProfile{
+		"rapidapi": {
+			Name:          "rapidapi",
+			KeyHeader:     "X-RapidAPI-Key",
+			KeyEnvVar:     "RAPIDAPI_KEY",
+			HostHeader:    "X-RapidAPI-Host",
+			DetectHeaders: []string{"X-RapidAPI-Key", "X-RapidAPI-Host"},
+			HostSuffixes:  []string{".rapidapi.com"},
+		},
+		"azure-apim": {
+			Name:          "azure-apim",
+			KeyHeader:     "Ocp-Apim-Subscription-Key",
+			KeyEnvVar:     "APIM_SUBSCRIPTION_KEY",
+			DetectHeaders: []string{"Ocp-Apim-Subscription-Key"},
+			HostSuffixes:  []string{".azure-api.net"},
+		},
+		"aws-api-gateway": {
+			Name:         "aws-api-gateway",
+			KeyHeader:    "x-api-key",
+			KeyEnvVar:    "AWS_API_GATEWAY_KEY",
+			HostSuffixes: []string{".execute-api.amazonaws.com"},
+		},
+	}
+)
+
+// RegisterGatewayProfile adds a gateway profile, or replaces the built-in profile of the
+// same name, so specs can select it with x-mcp-gateway
+func RegisterGatewayProfile(p *GatewayProfile) {
+	gatewayProfilesMu.Lock()
+	defer gatewayProfilesMu.Unlock()
+	gatewayProfiles[strings.ToLower(p.Name)] = p
+}
+
+// GatewayProfileByName returns the gateway profile registered under name
+func GatewayProfileByName(name string) (*GatewayProfile, bool) {
+	gatewayProfilesMu.RLock()
+	defer gatewayProfilesMu.RUnlock()
+	p, ok := gatewayProfiles[strings.ToLower(strings.TrimSpace(name))]
+	return p, ok
+}
+
+// GatewayProfileNames returns the names of the registered gateway profiles, sorted
+func GatewayProfileNames() []string {
+	gatewayProfilesMu.RLock()
+	defer gatewayProfilesMu.RUnlock()
+	names := make([]string, 0, len(gatewayProfiles))
+	for name := range gatewayProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsGatewayHeader reports whether a header is filled in by a gateway profile, so it is not
+// asked for as a tool argument
+func IsGatewayHeader(name string) bool {
+	name = strings.ToLower(name)
+	gatewayProfilesMu.RLock()
+	defer gatewayProfilesMu.RUnlock()
+	for _, p := range gatewayProfiles {
+		for _, header := range p.headers() {
+			if header == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SpecGatewayProfile returns the gateway profile of a spec: the x-mcp-gateway extension,
+// then <ENDPOINT>_GATEWAY (e.g. WEATHER_GATEWAY=rapidapi), then the profile detected from
+// the headers the spec declares or the host of its first server. It returns nil
+// for specs not hosted by a known gateway.
+func SpecGatewayProfile(doc *openapi3.T, spec *models.OpenAPISpec, endpoint string) *GatewayProfile {
+	name := ""
+	if doc != nil {
+		name, _ = doc.Extensions[gatewayExtension].(string)
+	}
+	if spec != nil && spec.EndpointPath != "" {
+		endpoint = strings.Trim(spec.EndpointPath, "/")
+	}
+	if name == "" && endpoint != "" {
+		name = os.Getenv(strings.ToUpper(strings.ReplaceAll(endpoint, "-", "_")) + "_GATEWAY")
+	}
+	if name = strings.TrimSpace(name); name != "" {
+		if strings.EqualFold(name, "none") {
+			return nil
+		}
+		p, ok := GatewayProfileByName(name)
+		if !ok {
+			log.Printf("[WARN] Unknown gateway profile %q for %s; known profiles: %s", name, endpoint, strings.Join(GatewayProfileNames(), ", "))
+		}
+		return p
+	}
+	return detectGatewayProfile(doc)
+}
+
+// detectGatewayProfile finds the profile whose detection headers a spec declares in its
+// security schemes or parameters, or whose host suffix the spec's first server has
+func detectGatewayProfile(doc *openapi3.T) *GatewayProfile {
+	if doc == nil {
+		return nil
+	}
+	declared := map[string]bool{}
+	if doc.Components != nil {
+		for _, schemeRef := range doc.Components.SecuritySchemes {
+			if schemeRef != nil && schemeRef.Value != nil && schemeRef.Value.In == "header" {
+				declared[strings.ToLower(schemeRef.Value.Name)] = true
+			}
+		}
+		for _, paramRef := range doc.Components.Parameters {
+			if paramRef != nil && paramRef.Value != nil && paramRef.Value.In == "header" {
+				declared[strings.ToLower(paramRef.Value.Name)] = true
+			}
+		}
+	}
+	host := ""
+	if len(doc.Servers) > 0 && doc.Servers[0] != nil {
+		if u, err := url.Parse(doc.Servers[0].URL); err == nil {
+			host = strings.ToLower(u.Hostname())
+		}
+	}
+
+	gatewayProfilesMu.RLock()
+	defer gatewayProfilesMu.RUnlock()
+	names := make([]string, 0, len(gatewayProfiles))
+	for name := range gatewayProfiles {
+		names = append(names, name)
+	}
+	// Check in a fixed order, so a spec matching several profiles always gets the same one
+	sort.Strings(names)
+	for _, name := range names {
+		p := gatewayProfiles[name]
+		for _, header := range p.DetectHeaders {
+			if declared[strings.ToLower(header)] {
+				return p
+			}
+		}
+		for _, suffix := range p.HostSuffixes {
+			if host != "" && strings.HasSuffix(host, strings.ToLower(suffix)) {
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+// applyGatewayProfile fills in the parts of an apiKey auth context the spec leaves to its
+// gateway: the key header, and the host header with the spec's server host
+func applyGatewayProfile(authCtx *AuthContext) {
+	p := authCtx.Profile
+	if p == nil || authCtx.AuthType != "apiKey" {
+		return
+	}
+	if authCtx.SpecParamName == "" && authCtx.QueryParamName == "" {
+		authCtx.SpecParamName = p.KeyHeader
+	}
+	if p.HostHeader == "" || authCtx.ApiHost == "" {
+		return
+	}
+	if authCtx.HostHeaders == nil {
+		authCtx.HostHeaders = map[string]string{}
+	}
+	for name := range authCtx.HostHeaders {
+		if strings.EqualFold(name, p.HostHeader) {
+			return
+		}
+	}
+	authCtx.HostHeaders[p.HostHeader] = authCtx.ApiHost
+}
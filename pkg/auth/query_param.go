@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"os"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+)
+
+// authQueryParamExtension names the query parameter an API key is sent in, for specs whose
+// security scheme does not declare it, e.g. `x-mcp-auth-query-param: appid`
+const authQueryParamExtension = "x-mcp-auth-query-param"
+
+// authQueryParamName returns the only query parameter an API key may be injected in: the
+// x-mcp-auth-query-param extension, then <ENDPOINT>_API_KEY_QUERY_PARAM (e.g.
+// WEATHER_API_KEY_QUERY_PARAM), then the name of an apiKey security scheme declared
+// `in: query`. It returns "" when the key is not sent in the query.
+func authQueryParamName(doc *openapi3.T, spec *models.OpenAPISpec, endpoint string) string {
+	if name := authQueryParamOverride(doc, spec, endpoint); name != "" {
+		return name
+	}
+	if doc == nil || doc.Components == nil {
+		return ""
+	}
+	for _, schemeRef := range doc.Components.SecuritySchemes {
+		if schemeRef != nil && schemeRef.Value != nil && schemeRef.Value.Type == "apiKey" && schemeRef.Value.In == "query" {
+			return schemeRef.Value.Name
+		}
+	}
+	return ""
+}
+
+// authQueryParamOverride returns the query parameter set by the x-mcp-auth-query-param
+// extension or <ENDPOINT>_API_KEY_QUERY_PARAM, or ""
+func authQueryParamOverride(doc *openapi3.T, spec *models.OpenAPISpec, endpoint string) string {
+	if doc != nil {
+		if name, ok := doc.Extensions[authQueryParamExtension].(string); ok && strings.TrimSpace(name) != "" {
+			return strings.TrimSpace(name)
+		}
+	}
+	if spec != nil && spec.EndpointPath != "" {
+		endpoint = strings.Trim(spec.EndpointPath, "/")
+	}
+	if endpoint != "" {
+		envVar := strings.ToUpper(strings.ReplaceAll(endpoint, "-", "_")) + "_API_KEY_QUERY_PARAM"
+		if name := strings.TrimSpace(os.Getenv(envVar)); name != "" {
+			return name
+		}
+	}
+	return ""
+}
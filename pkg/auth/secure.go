@@ -61,7 +61,6 @@ func (p *contextAuthProvider) GetAuthHeaders(ctx context.Context) map[string]str
 			headers["Authorization"] = authCtx.Token
 			headers["X-API-Key"] = authCtx.Token
 			headers["Api-Key"] = authCtx.Token
-			headers["X-RapidAPI-Key"] = authCtx.Token  // Use proper casing for RapidAPI
 		}
 		
 		// Automatically add host headers as defined in the OpenAPI spec
@@ -71,6 +70,13 @@ func (p *contextAuthProvider) GetAuthHeaders(ctx context.Context) map[string]str
 			}
 		}
 	}
+
+	// Fixed headers of the gateway hosting the API
+	if authCtx.Profile != nil {
+		for headerName, headerValue := range authCtx.Profile.ExtraHeaders {
+			headers[headerName] = headerValue
+		}
+	}
 	
 	return headers
 }
@@ -82,21 +88,12 @@ func (p *contextAuthProvider) GetAuthQueryParams(ctx context.Context) map[string
 		return nil
 	}
 
-	params := make(map[string]string)
-	
-	// Prioritize spec-defined parameter name for accuracy
-	if authCtx.SpecParamName != "" {
-		params[authCtx.SpecParamName] = authCtx.Token
-	} else {
-		// Dynamic fallback based on common API patterns
-		// Order matters: most specific to most generic
-		fallbackNames := []string{"key", "api_key", "apikey"}
-		for _, name := range fallbackNames {
-			params[name] = authCtx.Token
-		}
+	// Only the parameter the spec declares (or its override names) is set, since strict
+	// APIs reject requests with unknown query parameters
+	if authCtx.QueryParamName == "" {
+		return nil
 	}
-	
-	return params
+	return map[string]string{authCtx.QueryParamName: authCtx.Token}
 }
 
 // SecureRequestModifier modifies HTTP requests with authentication without using environment variables
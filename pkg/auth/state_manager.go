@@ -27,6 +27,14 @@ func (sm *StateManager) UpdateSpecs(specs []*models.OpenAPISpec) {
 		endpoint := strings.TrimPrefix(spec.EndpointPath, "/")
 		sm.specs[endpoint] = spec
 	}
+	// Aliases resolve to the same spec, unless another spec has that endpoint
+	for _, spec := range specs {
+		for _, alias := range spec.AliasPaths() {
+			if _, exists := sm.specs[alias]; !exists {
+				sm.specs[alias] = spec
+			}
+		}
+	}
 }
 
 func (sm *StateManager) GetSpec(endpoint string) (*models.OpenAPISpec, bool) {
@@ -0,0 +1,113 @@
+package awssig
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Vectors of the AWS Signature Version 4 test suite, which signs for the example credentials
+// below on 2015-08-30 in us-east-1 for a service named "service"
+var suiteSigner = Signer{
+	AccessKeyID:     "AKIDEXAMPLE",
+	SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	Region:          "us-east-1",
+	Service:         "service",
+}
+
+var suiteTime = time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+func TestSign_TestSuite(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		url           string
+		headers       map[string]string
+		body          string
+		signedHeaders string
+		signature     string
+	}{
+		{
+			name:          "get-vanilla",
+			method:        "GET",
+			url:           "https://example.amazonaws.com/",
+			signedHeaders: "host;x-amz-date",
+			signature:     "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:          "post-vanilla",
+			method:        "POST",
+			url:           "https://example.amazonaws.com/",
+			signedHeaders: "host;x-amz-date",
+			signature:     "5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b",
+		},
+		{
+			name:          "get-vanilla-query-order-key-case",
+			method:        "GET",
+			url:           "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			signedHeaders: "host;x-amz-date",
+			signature:     "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			name:          "post-x-www-form-urlencoded",
+			method:        "POST",
+			url:           "https://example.amazonaws.com/",
+			headers:       map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+			body:          "Param1=value1",
+			signedHeaders: "content-type;host;x-amz-date",
+			signature:     "ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			suiteSigner.Sign(req, []byte(tt.body), suiteTime)
+
+			want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=" +
+				tt.signedHeaders + ", Signature=" + tt.signature
+			if got := req.Header.Get("Authorization"); got != want {
+				t.Errorf("got  %s\nwant %s", got, want)
+			}
+			if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+				t.Errorf("expected X-Amz-Date 20150830T123600Z, got %q", got)
+			}
+		})
+	}
+}
+
+func TestSign_Options(t *testing.T) {
+	signer := suiteSigner
+	signer.SessionToken = "session-token"
+	signer.ContentSHA256 = true
+	req, _ := http.NewRequest("PUT", "https://example.amazonaws.com/", strings.NewReader("data"))
+	signer.Sign(req, []byte("data"), suiteTime)
+
+	if req.Header.Get("X-Amz-Security-Token") != "session-token" || req.Header.Get("X-Amz-Content-Sha256") != SHA256Hex([]byte("data")) {
+		t.Errorf("expected the session token and payload hash headers, got %v", req.Header)
+	}
+	if !strings.Contains(req.Header.Get("Authorization"), "SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token,") {
+		t.Errorf("expected the session token and payload hash to be signed, got %s", req.Header.Get("Authorization"))
+	}
+}
+
+func TestCanonicalRequest_Path(t *testing.T) {
+	// Endpoints with a path prefix, e.g. behind a proxy, are signed with their path
+	req, _ := http.NewRequest("POST", "https://proxy.example.com/aws/kms/?b=2&a=x%20y&a=1", nil)
+	canonical, _ := canonicalRequest(req, SHA256Hex(nil))
+	lines := strings.Split(canonical, "\n")
+	if lines[1] != "/aws/kms/" {
+		t.Errorf("expected the canonical URI /aws/kms/, got %q", lines[1])
+	}
+	if lines[2] != "a=1&a=x%20y&b=2" {
+		t.Errorf("expected sorted, AWS-encoded query parameters, got %q", lines[2])
+	}
+
+	req, _ = http.NewRequest("GET", "https://example.amazonaws.com", nil)
+	if canonical, _ := canonicalRequest(req, SHA256Hex(nil)); strings.Split(canonical, "\n")[1] != "/" {
+		t.Errorf("expected an empty path to be signed as /, got %q", strings.Split(canonical, "\n")[1])
+	}
+}
@@ -11,9 +11,15 @@ import (
 	_ "github.com/lib/pq" // PostgreSQL driver
 )
 
+// maxIdleConns is the number of idle connections kept by the pools of Connect and ConnectReplica
+const maxIdleConns = 25
+
 // DB is the global database connection
 var DB *sql.DB
 
+// ReplicaDB is the optional read-only replica connection, set when DATABASE_REPLICA_URL is configured
+var ReplicaDB *sql.DB
+
 // Connect establishes a connection to the PostgreSQL database
 func Connect() (*sql.DB, error) {
 	databaseURL := os.Getenv("DATABASE_URL")
@@ -39,7 +45,7 @@ func Connect() (*sql.DB, error) {
 
 	// Set connection pool settings for long-running operations
 	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(25)
+	db.SetMaxIdleConns(maxIdleConns)
 	db.SetConnMaxLifetime(30 * time.Minute) // Connections live for 30 minutes max
 	db.SetConnMaxIdleTime(10 * time.Minute) // Idle connections timeout after 10 minutes
 
@@ -50,8 +56,46 @@ func Connect() (*sql.DB, error) {
 	return db, nil
 }
 
-// Close closes the database connection
+// ConnectReplica connects to the read-only replica in DATABASE_REPLICA_URL, if set.
+// A replica that cannot be reached is not fatal: reads then go to the primary.
+func ConnectReplica() *sql.DB {
+	replicaURL := os.Getenv("DATABASE_REPLICA_URL")
+	if replicaURL == "" {
+		return nil
+	}
+	if !strings.HasPrefix(replicaURL, "postgresql://") {
+		log.Printf("DATABASE_REPLICA_URL must start with 'postgresql://', reading from primary only")
+		return nil
+	}
+
+	db, err := sql.Open("postgres", replicaURL)
+	if err != nil {
+		log.Printf("Failed to open read replica connection, reading from primary only: %v", err)
+		return nil
+	}
+	if err := db.Ping(); err != nil {
+		log.Printf("Failed to ping read replica, reading from primary only: %v", err)
+		db.Close()
+		return nil
+	}
+
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(maxIdleConns)
+	db.SetConnMaxLifetime(30 * time.Minute)
+	db.SetConnMaxIdleTime(10 * time.Minute)
+
+	log.Printf("Read replica connected successfully: %s", strings.Split(replicaURL, "@")[0]+"@[HIDDEN]")
+
+	ReplicaDB = db
+	return db
+}
+
+// Close closes the database connection and the read replica, if any
 func Close() error {
+	if ReplicaDB != nil {
+		ReplicaDB.Close()
+		ReplicaDB = nil
+	}
 	if DB != nil {
 		return DB.Close()
 	}
@@ -77,18 +121,23 @@ func EnsureConnection() error {
 	// Test connection health
 	if err := DB.Ping(); err != nil {
 		log.Printf("Database connection unhealthy (%v), attempting to reconnect...", err)
-		// Close the stale connection
-		DB.Close()
-		DB = nil
-		
-		// Attempt to reconnect
-		_, err := Connect()
-		return err
+		// Drop the stale connections and let the pool open new ones. The pool itself is kept,
+		// as repositories and services hold it
+		ResetIdleConnections(DB)
+		return DB.Ping()
 	}
 
 	return nil
 }
 
+// ResetIdleConnections closes the idle connections of a pool opened by Connect or
+// ConnectReplica, so the next statements open new ones. Idle connections closed by the
+// server or a firewall while idle would otherwise each fail one statement.
+func ResetIdleConnections(db *sql.DB) {
+	db.SetMaxIdleConns(0)
+	db.SetMaxIdleConns(maxIdleConns)
+}
+
 // InitializeDatabase connects to the database and runs migrations
 func InitializeDatabase() error {
 	db, err := Connect()
@@ -100,5 +149,7 @@ func InitializeDatabase() error {
 		return fmt.Errorf("failed to run migrations: %v", err)
 	}
 
+	ConnectReplica()
+
 	return nil
 }
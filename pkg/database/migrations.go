@@ -18,7 +18,7 @@ func CreateOpenAPISpecsTable(db *sql.DB) error {
 		endpoint_path VARCHAR(255) UNIQUE NOT NULL,
 		file_format VARCHAR(10) DEFAULT 'yaml',
 		file_size INTEGER,
-		api_key_token VARCHAR(500),
+		api_key_token TEXT,
 		is_active BOOLEAN DEFAULT true,
 		created_at TIMESTAMP(6) DEFAULT NOW(),
 		updated_at TIMESTAMP(6) DEFAULT NOW()
@@ -54,12 +54,271 @@ func CreateOpenAPISpecsTable(db *sql.DB) error {
 	return nil
 }
 
+// CreateSpecBlobsTable creates the content-addressed spec_blobs table and points
+// openapi_specs at it. Identical spec content imported under several names is
+// stored once, keyed by the hex SHA-256 of the content, with a reference count.
+func CreateSpecBlobsTable(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS spec_blobs (
+		hash CHAR(64) PRIMARY KEY,
+		content TEXT NOT NULL,
+		size INTEGER,
+		ref_count INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP(6) DEFAULT NOW()
+	);
+
+	ALTER TABLE openapi_specs ADD COLUMN IF NOT EXISTS content_hash CHAR(64) REFERENCES spec_blobs(hash);
+	ALTER TABLE openapi_specs ALTER COLUMN spec_content DROP NOT NULL;
+	CREATE INDEX IF NOT EXISTS idx_openapi_specs_content_hash ON openapi_specs(content_hash);
+	`
+
+	_, err := db.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create spec_blobs table: %v", err)
+	}
+
+	log.Println("Successfully created spec_blobs table")
+	return nil
+}
+
+// MigrateSpecContentToBlobs moves inline spec_content of existing rows into spec_blobs.
+// It only touches rows without a content_hash, so it is safe to run on every start.
+func MigrateSpecContentToBlobs(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to migrate spec content: %v", err)
+	}
+	defer tx.Rollback()
+
+	// The hash must match repository.ContentHash: SHA-256 over the UTF-8 bytes, hex encoded
+	query := `
+	INSERT INTO spec_blobs (hash, content, size, ref_count)
+	SELECT encode(sha256(convert_to(spec_content, 'UTF8')), 'hex'), spec_content, octet_length(spec_content), COUNT(*)
+	FROM openapi_specs
+	WHERE content_hash IS NULL AND spec_content IS NOT NULL
+	GROUP BY spec_content
+	ON CONFLICT (hash) DO UPDATE SET ref_count = spec_blobs.ref_count + EXCLUDED.ref_count;
+
+	UPDATE openapi_specs
+	SET content_hash = encode(sha256(convert_to(spec_content, 'UTF8')), 'hex'), spec_content = NULL
+	WHERE content_hash IS NULL AND spec_content IS NOT NULL;
+	`
+
+	if _, err := tx.Exec(query); err != nil {
+		return fmt.Errorf("failed to migrate spec content: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to migrate spec content: %v", err)
+	}
+
+	log.Println("Successfully migrated spec content to spec_blobs")
+	return nil
+}
+
+// AddFeatureFlagsColumn adds the feature_flags column, a JSON object mapping feature
+// flags to the environments (ENVIRONMENT) in which the operations they gate are exposed.
+func AddFeatureFlagsColumn(db *sql.DB) error {
+	query := `ALTER TABLE openapi_specs ADD COLUMN IF NOT EXISTS feature_flags JSONB;`
+
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to add feature_flags column: %v", err)
+	}
+
+	log.Println("Successfully added feature_flags column")
+	return nil
+}
+
+// AddAliasesColumn adds the aliases column, the comma-separated endpoint paths a spec is
+// also served at, so a renamed endpoint keeps working at its old path.
+func AddAliasesColumn(db *sql.DB) error {
+	query := `ALTER TABLE openapi_specs ADD COLUMN IF NOT EXISTS aliases TEXT;`
+
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to add aliases column: %v", err)
+	}
+
+	log.Println("Successfully added aliases column")
+	return nil
+}
+
+// AddDeletedAtColumn adds the deleted_at column of soft-deleted specs, which are hidden
+// until they are restored or purged after the retention period
+func AddDeletedAtColumn(db *sql.DB) error {
+	query := `
+	ALTER TABLE openapi_specs ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP(6);
+	CREATE INDEX IF NOT EXISTS idx_openapi_specs_deleted_at ON openapi_specs(deleted_at);
+	`
+
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to add deleted_at column: %v", err)
+	}
+
+	log.Println("Successfully added deleted_at column")
+	return nil
+}
+
+// AddRequireTokenColumn adds the require_token_to_activate column of specs that are mounted
+// without tools while they have no credentials
+func AddRequireTokenColumn(db *sql.DB) error {
+	query := `ALTER TABLE openapi_specs ADD COLUMN IF NOT EXISTS require_token_to_activate BOOLEAN NOT NULL DEFAULT FALSE;`
+
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to add require_token_to_activate column: %v", err)
+	}
+
+	log.Println("Successfully added require_token_to_activate column")
+	return nil
+}
+
+// AddRateLimitColumn adds the rate_limit column, the requests allowed to a spec's endpoint
+// in total and per client, e.g. "1000/m, client=60/m"
+func AddRateLimitColumn(db *sql.DB) error {
+	query := `ALTER TABLE openapi_specs ADD COLUMN IF NOT EXISTS rate_limit VARCHAR(255);`
+
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to add rate_limit column: %v", err)
+	}
+
+	log.Println("Successfully added rate_limit column")
+	return nil
+}
+
+// AddToolRulesColumn adds the tool_rules column, a JSON object of include and exclude rules
+// selecting the operations of a spec that become tools
+func AddToolRulesColumn(db *sql.DB) error {
+	query := `ALTER TABLE openapi_specs ADD COLUMN IF NOT EXISTS tool_rules JSONB;`
+
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to add tool_rules column: %v", err)
+	}
+
+	log.Println("Successfully added tool_rules column")
+	return nil
+}
+
+// AddUpstreamPolicyColumn adds the upstream_policy column, a JSON object with the overall
+// timeout, retry policy and circuit breaker of a spec's upstream calls
+func AddUpstreamPolicyColumn(db *sql.DB) error {
+	query := `ALTER TABLE openapi_specs ADD COLUMN IF NOT EXISTS upstream_policy JSONB;`
+
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to add upstream_policy column: %v", err)
+	}
+
+	log.Println("Successfully added upstream_policy column")
+	return nil
+}
+
+// CreateSpecCredentialsTable creates the spec_credentials table, holding the API keys of a
+// spec besides its api_key_token, and adds the credential_strategy column choosing how calls
+// spread over them
+func CreateSpecCredentialsTable(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS spec_credentials (
+		id SERIAL PRIMARY KEY,
+		spec_id INTEGER NOT NULL REFERENCES openapi_specs(id) ON DELETE CASCADE,
+		label VARCHAR(255),
+		token TEXT NOT NULL,
+		created_at TIMESTAMP(6) DEFAULT NOW()
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_spec_credentials_spec_id ON spec_credentials(spec_id);
+	ALTER TABLE openapi_specs ADD COLUMN IF NOT EXISTS credential_strategy VARCHAR(20);
+	`
+
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to create spec_credentials table: %v", err)
+	}
+
+	log.Println("Successfully created spec_credentials table")
+	return nil
+}
+
+// WidenApiKeyTokenColumn changes api_key_token from VARCHAR(500) to TEXT, so it holds the
+// longer ciphertext of tokens encrypted with MCP_TOKEN_ENCRYPTION_KEY
+func WidenApiKeyTokenColumn(db *sql.DB) error {
+	query := `ALTER TABLE openapi_specs ALTER COLUMN api_key_token TYPE TEXT;`
+
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to widen api_key_token column: %v", err)
+	}
+
+	log.Println("Successfully widened api_key_token column")
+	return nil
+}
+
+// CreateToolCallJournalTable creates the tool_call_journal table, where tool calls are
+// recorded when accepted and updated when they finish, so calls cut off by a crash or
+// shutdown can be reported after a restart
+func CreateToolCallJournalTable(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS tool_call_journal (
+		id BIGSERIAL PRIMARY KEY,
+		endpoint VARCHAR(255) NOT NULL,
+		tool VARCHAR(255) NOT NULL,
+		session_id VARCHAR(255),
+		arg_names TEXT,
+		status VARCHAR(20) NOT NULL DEFAULT 'accepted',
+		error TEXT,
+		host VARCHAR(255) NOT NULL,
+		pid INTEGER NOT NULL,
+		accepted_at TIMESTAMP(6) NOT NULL DEFAULT NOW(),
+		finished_at TIMESTAMP(6)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_tool_call_journal_status ON tool_call_journal(status);
+	CREATE INDEX IF NOT EXISTS idx_tool_call_journal_accepted_at ON tool_call_journal(accepted_at);
+	`
+
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to create tool_call_journal table: %v", err)
+	}
+
+	log.Println("Successfully created tool_call_journal table")
+	return nil
+}
+
+// CreateToolCallAuditTable creates the tool_call_audit table, where the audit log records
+// every finished tool call for compliance review
+func CreateToolCallAuditTable(db *sql.DB) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS tool_call_audit (
+		id BIGSERIAL PRIMARY KEY,
+		called_at TIMESTAMP(6) NOT NULL,
+		endpoint VARCHAR(255) NOT NULL,
+		tool VARCHAR(255) NOT NULL,
+		session_id VARCHAR(255),
+		arg_names TEXT,
+		args_hash VARCHAR(64),
+		status VARCHAR(20) NOT NULL,
+		upstream_status INTEGER,
+		error TEXT,
+		duration_ms DOUBLE PRECISION NOT NULL,
+		host VARCHAR(255) NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_tool_call_audit_called_at ON tool_call_audit(called_at);
+	CREATE INDEX IF NOT EXISTS idx_tool_call_audit_session_id ON tool_call_audit(session_id);
+	`
+
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to create tool_call_audit table: %v", err)
+	}
+
+	log.Println("Successfully created tool_call_audit table")
+	return nil
+}
+
 // DropOpenAPISpecsTable drops the openapi_specs table (useful for testing)
 func DropOpenAPISpecsTable(db *sql.DB) error {
 	query := `
 	DROP TRIGGER IF EXISTS update_openapi_specs_updated_at ON openapi_specs;
 	DROP FUNCTION IF EXISTS update_updated_at_column();
+	DROP TABLE IF EXISTS spec_credentials;
 	DROP TABLE IF EXISTS openapi_specs CASCADE;
+	DROP TABLE IF EXISTS spec_blobs CASCADE;
+	DROP TABLE IF EXISTS tool_call_journal;
+	DROP TABLE IF EXISTS tool_call_audit;
 	`
 
 	_, err := db.Exec(query)
@@ -79,6 +338,134 @@ func RunMigrations(db *sql.DB) error {
 		return fmt.Errorf("migration failed: %v", err)
 	}
 
+	if err := CreateSpecBlobsTable(db); err != nil {
+		return fmt.Errorf("migration failed: %v", err)
+	}
+
+	if err := MigrateSpecContentToBlobs(db); err != nil {
+		return fmt.Errorf("migration failed: %v", err)
+	}
+
+	if err := AddFeatureFlagsColumn(db); err != nil {
+		return fmt.Errorf("migration failed: %v", err)
+	}
+
+	if err := CreateToolCallJournalTable(db); err != nil {
+		return fmt.Errorf("migration failed: %v", err)
+	}
+
+	if err := AddAliasesColumn(db); err != nil {
+		return fmt.Errorf("migration failed: %v", err)
+	}
+
+	if err := AddDeletedAtColumn(db); err != nil {
+		return fmt.Errorf("migration failed: %v", err)
+	}
+
+	if err := AddRequireTokenColumn(db); err != nil {
+		return fmt.Errorf("migration failed: %v", err)
+	}
+
+	if err := AddRateLimitColumn(db); err != nil {
+		return fmt.Errorf("migration failed: %v", err)
+	}
+
+	if err := AddToolRulesColumn(db); err != nil {
+		return fmt.Errorf("migration failed: %v", err)
+	}
+
+	if err := AddUpstreamPolicyColumn(db); err != nil {
+		return fmt.Errorf("migration failed: %v", err)
+	}
+
+	if err := CreateToolCallAuditTable(db); err != nil {
+		return fmt.Errorf("migration failed: %v", err)
+	}
+
+	if err := CreateSpecCredentialsTable(db); err != nil {
+		return fmt.Errorf("migration failed: %v", err)
+	}
+
+	if err := WidenApiKeyTokenColumn(db); err != nil {
+		return fmt.Errorf("migration failed: %v", err)
+	}
+
 	log.Println("All migrations completed successfully")
 	return nil
 }
+
+// schemaColumns lists the tables and columns RunMigrations creates
+var schemaColumns = map[string][]string{
+	"openapi_specs": {
+		"id", "name", "title", "version", "spec_content", "endpoint_path", "file_format", "file_size",
+		"api_key_token", "is_active", "created_at", "updated_at", "content_hash", "feature_flags", "aliases", "deleted_at",
+		"require_token_to_activate", "rate_limit", "tool_rules", "upstream_policy", "credential_strategy",
+	},
+	"spec_credentials":  {"id", "spec_id", "label", "token", "created_at"},
+	"spec_blobs":        {"hash", "content", "size", "ref_count", "created_at"},
+	"tool_call_journal": {"id", "endpoint", "tool", "session_id", "arg_names", "status", "error", "host", "pid", "accepted_at", "finished_at"},
+	"tool_call_audit": {
+		"id", "called_at", "endpoint", "tool", "session_id", "arg_names", "args_hash", "status", "upstream_status", "error",
+		"duration_ms", "host",
+	},
+}
+
+// PendingMigrations returns what RunMigrations would still change in the database: missing
+// tables and columns, columns to widen, and spec content not yet moved to spec_blobs. It does not change the
+// database, so the schema can be checked before a deployment.
+func PendingMigrations(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`
+	SELECT table_name, column_name, data_type FROM information_schema.columns
+	WHERE table_schema = current_schema() AND table_name IN ('openapi_specs', 'spec_blobs', 'tool_call_journal', 'tool_call_audit', 'spec_credentials')`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the database schema: %v", err)
+	}
+	defer rows.Close()
+
+	existing := map[string]map[string]bool{}
+	var narrowToken bool
+	for rows.Next() {
+		var table, column, dataType string
+		if err := rows.Scan(&table, &column, &dataType); err != nil {
+			return nil, fmt.Errorf("failed to read the database schema: %v", err)
+		}
+		if existing[table] == nil {
+			existing[table] = map[string]bool{}
+		}
+		existing[table][column] = true
+		if table == "openapi_specs" && column == "api_key_token" && dataType != "text" {
+			narrowToken = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the database schema: %v", err)
+	}
+
+	var pending []string
+	for _, table := range []string{"openapi_specs", "spec_blobs", "tool_call_journal", "tool_call_audit", "spec_credentials"} {
+		if existing[table] == nil {
+			pending = append(pending, "create table "+table)
+			continue
+		}
+		for _, column := range schemaColumns[table] {
+			if !existing[table][column] {
+				pending = append(pending, fmt.Sprintf("add column %s.%s", table, column))
+			}
+		}
+	}
+	if narrowToken {
+		pending = append(pending, "widen column openapi_specs.api_key_token to text")
+	}
+
+	if existing["openapi_specs"]["content_hash"] && existing["openapi_specs"]["spec_content"] {
+		var inline int
+		err := db.QueryRow(`SELECT COUNT(*) FROM openapi_specs WHERE content_hash IS NULL AND spec_content IS NOT NULL`).Scan(&inline)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count inline spec content: %v", err)
+		}
+		if inline > 0 {
+			pending = append(pending, fmt.Sprintf("move the content of %d specs to spec_blobs", inline))
+		}
+	}
+	return pending, nil
+}
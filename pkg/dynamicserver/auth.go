@@ -0,0 +1,75 @@
+package dynamicserver
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+)
+
+// authContextFunc creates a secure, request-scoped authentication context without global state mutation
+func (s *Server) authContextFunc(ctx context.Context, r *http.Request, doc *openapi3.T, spec *models.OpenAPISpec) context.Context {
+	// Debug: Log incoming request headers for auth debugging
+	if authHeader := r.Header.Get("Authorization"); authHeader != "" {
+		headerPreview := authHeader
+		if len(headerPreview) > 30 {
+			headerPreview = headerPreview[:30]
+		}
+		log.Printf("DEBUG: secureAuthContextFunc received Authorization header: %s...", headerPreview)
+
+		// Force Bearer token extraction if not working through normal flow
+		if strings.HasPrefix(authHeader, "Bearer ") {
+			bearerToken := strings.TrimPrefix(authHeader, "Bearer ")
+			tokenPreview := bearerToken
+			if len(tokenPreview) > 20 {
+				tokenPreview = tokenPreview[:20]
+			}
+			log.Printf("DEBUG: Directly extracted Bearer token: %s...", tokenPreview)
+
+			// Create authentication context and manually set the token
+			authCtx := auth.CreateAuthContext(r, doc, spec)
+			if authCtx.Token == "" {
+				log.Printf("DEBUG: Normal extraction failed, manually setting Bearer token")
+				authCtx.Token = bearerToken
+			}
+
+			// Add auth context to request context - this is secure and thread-safe
+			return auth.WithAuthContext(ctx, authCtx)
+		}
+	} else {
+		log.Printf("DEBUG: secureAuthContextFunc - NO Authorization header found in request")
+	}
+
+	// Create authentication context for this request
+	authCtx := auth.CreateAuthContext(r, doc, spec)
+
+	// Debug: Log the resulting auth context token
+	if authCtx.Token != "" {
+		tokenPreview := authCtx.Token
+		if len(tokenPreview) > 20 {
+			tokenPreview = tokenPreview[:20]
+		}
+		log.Printf("DEBUG: secureAuthContextFunc extracted token: %s...", tokenPreview)
+	} else {
+		log.Printf("DEBUG: secureAuthContextFunc - NO token extracted from headers")
+	}
+
+	// If no spec provided, try to get it from state manager
+	if spec == nil {
+		endpoint := strings.ToLower(strings.Trim(r.URL.Path, "/"))
+		if strings.Contains(endpoint, "/") {
+			endpoint = strings.Split(endpoint, "/")[0]
+		}
+		if foundSpec, exists := s.authState.GetSpec(endpoint); exists {
+			// Recreate auth context with the found spec
+			authCtx = auth.CreateAuthContext(r, doc, foundSpec)
+		}
+	}
+
+	// Add auth context to request context - this is secure and thread-safe
+	return auth.WithAuthContext(ctx, authCtx)
+}
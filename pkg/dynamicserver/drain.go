@@ -0,0 +1,127 @@
+package dynamicserver
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+)
+
+// DefaultDrainTimeout is how long a replaced mount serves its sessions in progress when
+// Options.DrainTimeout is not set.
+const DefaultDrainTimeout = 30 * time.Minute
+
+// drainIdleTimeout is how long a streamable-http session of a replaced mount may go without
+// a request before it is considered over; its next request goes to the current mount.
+const drainIdleTimeout = 5 * time.Minute
+
+// drainCheckInterval is how often draining mounts are checked for sessions in progress.
+const drainCheckInterval = 5 * time.Second
+
+// pinned returns a handler serving the requests of sessions in progress on a replaced mount
+// of m's endpoint with that mount's transport, and every other request with m's.
+func (s *Server) pinned(m *Mount, transport func(*Mount) http.Handler) http.Handler {
+	current := transport(m)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if old := s.drainingMount(m.Endpoint, r); old != nil {
+			transport(old).ServeHTTP(w, r)
+			return
+		}
+		current.ServeHTTP(w, r)
+	})
+}
+
+// drainingMount returns the replaced mount of endpoint serving the session of r, if any.
+// Streamable-http requests carry the session in a header, SSE messages in the query.
+func (s *Server) drainingMount(endpoint string, r *http.Request) *Mount {
+	sessionID := r.Header.Get("Mcp-Session-Id")
+	if sessionID == "" {
+		sessionID = r.URL.Query().Get("sessionId")
+	}
+	if sessionID == "" {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, old := range s.draining[endpoint] {
+		if old.Sessions.ServesSession(sessionID, drainIdleTimeout) || old.SSE.HasSession(sessionID) {
+			return old
+		}
+	}
+	return nil
+}
+
+// drainLocked keeps a replaced mount serving the sessions in progress on it, so a reload does
+// not cut them off with tools changing under them, while new sessions go to the new mount.
+// The caller holds s.mu.
+func (s *Server) drainLocked(old *Mount) {
+	sessions := len(old.Sessions.ServedSessions(drainIdleTimeout))
+	if sessions == 0 {
+		go old.retire()
+		return
+	}
+	if s.draining == nil {
+		s.draining = make(map[string][]*Mount)
+	}
+	s.draining[old.Endpoint] = append(s.draining[old.Endpoint], old)
+	log.Printf("Previous /%s server keeps serving %d sessions in progress", old.Endpoint, sessions)
+	go s.watchDrain(old)
+}
+
+// watchDrain retires a draining mount once its sessions are over, or at the drain timeout.
+// Sessions still in progress then move to the current mount: listening clients are handed
+// off, and SSE streams are closed so their clients reconnect.
+func (s *Server) watchDrain(old *Mount) {
+	deadline := time.Now().Add(s.opts.DrainTimeout)
+	ticker := time.NewTicker(drainCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		remaining := len(old.Sessions.ServedSessions(drainIdleTimeout))
+		if remaining > 0 && time.Now().Before(deadline) {
+			continue
+		}
+		s.mu.Lock()
+		s.stopDrainingLocked(old)
+		var current *Mount
+		for _, m := range s.mounts {
+			if m.Endpoint == old.Endpoint {
+				current = m
+			}
+		}
+		if remaining > 0 && current != nil {
+			handOffSessions(old, current)
+		}
+		s.mu.Unlock()
+		if remaining > 0 {
+			old.SSE.CloseSessions()
+			log.Printf("Previous /%s server retired after %s with %d sessions in progress", old.Endpoint, s.opts.DrainTimeout, remaining)
+		} else {
+			log.Printf("Previous /%s server retired, its sessions are over", old.Endpoint)
+		}
+		old.retire()
+		return
+	}
+}
+
+// stopDrainingLocked stops routing sessions to a draining mount. The caller holds s.mu.
+func (s *Server) stopDrainingLocked(old *Mount) {
+	mounts := s.draining[old.Endpoint]
+	for i, m := range mounts {
+		if m == old {
+			mounts = append(mounts[:i:i], mounts[i+1:]...)
+			break
+		}
+	}
+	if len(mounts) == 0 {
+		delete(s.draining, old.Endpoint)
+	} else {
+		s.draining[old.Endpoint] = mounts
+	}
+}
+
+// retire stops the background work of a mount that no longer serves requests.
+func (m *Mount) retire() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	m.Sessions.Shutdown(ctx)
+}
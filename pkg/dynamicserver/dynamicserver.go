@@ -0,0 +1,698 @@
+// Package dynamicserver embeds the dynamic MCP gateway in a Go program: a registry of
+// OpenAPI specs, each mounted as an MCP endpoint (Streamable HTTP at /{endpoint}, SSE at
+// /{endpoint}/sse and /{endpoint}/message), an HTTP handler that swaps its routes atomically
+// when specs are mounted, unmounted or reloaded, database polling, and the spec
+// management API (/reload, /polling, /specs).
+//
+//	ds := dynamicserver.New(dynamicserver.Options{SpecLoader: services.NewSpecLoaderService(db)})
+//	if _, err := ds.Reload(ctx); err != nil {
+//		log.Fatal(err)
+//	}
+//	ds.Scheduler().Start()
+//	http.ListenAndServe(":8080", ds.Handler())
+//
+// Without a SpecLoader, specs are only served once mounted with MountSpec or MountFile.
+package dynamicserver
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+	"github.com/ubermorgenland/openapi-mcp/pkg/metrics"
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+	"github.com/ubermorgenland/openapi-mcp/pkg/openapi2mcp"
+	"github.com/ubermorgenland/openapi-mcp/pkg/secrets"
+	"github.com/ubermorgenland/openapi-mcp/pkg/services"
+)
+
+// DefaultPollInterval is how often the database is polled for spec changes when
+// Options.PollInterval is not set.
+const DefaultPollInterval = 30 * time.Second
+
+// Options configures a Server.
+type Options struct {
+	// SpecLoader is the database-backed spec registry. When set, Reload mounts its active
+	// specs, polling is available and the management API is served.
+	SpecLoader *services.SpecLoaderService
+	// Pipeline loads specs passed to MountSpec and MountFile. Nil uses the SpecLoader's
+	// pipeline, or a non-strict one without a SpecLoader.
+	Pipeline *services.SpecPipeline
+	// PollInterval is the database polling interval; see Scheduler.
+	PollInterval time.Duration
+	// EnsureConnection, if set, is called around the tool generation of database specs,
+	// so a dropped connection is re-established before and after that long-running step.
+	EnsureConnection func() error
+	// SessionSecret signs the JWT session IDs of every endpoint (see
+	// server.JWTSessionIdManager). Servers sharing a secret accept each other's session IDs.
+	// Nil uses a random secret, so session IDs do not survive a restart.
+	SessionSecret []byte
+	// SessionTTL is how long a session ID is valid; 0 uses server.DefaultJWTSessionTTL.
+	SessionTTL time.Duration
+	// AutoDeactivate deactivates database specs that become degraded (see
+	// openapi2mcp.SpecHealth), so agents are no longer offered tools that keep failing.
+	AutoDeactivate bool
+	// AlertWebhook, if set, receives a SpecAlert as a JSON POST when a mounted spec becomes
+	// degraded, is deactivated or recovers.
+	AlertWebhook string
+	// Compression configures the gzip compression of the responses of every endpoint; the
+	// zero value compresses responses of at least server.DefaultCompressionMinSize bytes.
+	Compression server.CompressionConfig
+	// SessionStore keeps the sessions of every endpoint, e.g. a server.RedisSessionStore shared
+	// by replicas; nil keeps them in memory.
+	SessionStore server.SessionStore
+	// DrainTimeout is how long a remounted endpoint's previous server keeps serving the
+	// sessions in progress on it; 0 uses DefaultDrainTimeout.
+	DrainTimeout time.Duration
+	// RateLimit limits the requests of the endpoints of specs without their own rate_limit,
+	// in total and per client; the zero value does not limit them.
+	RateLimit server.RateLimitConfig
+	// PublicURL is the external URL of the server behind a reverse proxy, e.g.
+	// https://example.com/mcp-gateway. The SSE endpoint events and generated SDKs point under
+	// it, and requests are served with or without its path prefix.
+	PublicURL string
+}
+
+// Mount is a spec served as an MCP endpoint.
+type Mount struct {
+	Endpoint string // path without the leading slash
+	Title    string
+	AuthType string   // auth type of a usable security scheme, "" when the spec has none
+	Aliases  []string // other endpoints the spec is served at, without the leading slash
+
+	Spec       *models.OpenAPISpec          // database record, or a synthetic one for file specs
+	Loaded     *services.LoadedSpec         // the spec as loaded by the pipeline
+	MCP        *server.MCPServer            // the endpoint's MCP server and tools
+	Sessions   *server.StreamableHTTPServer // Streamable HTTP transport, which tracks the endpoint's sessions
+	SessionIDs *server.JWTSessionIdManager  // issues the endpoint's session IDs, shared by its mounts
+	SSE        *server.SSEServer            // SSE transport
+
+	Disabled  string                 // why the spec is mounted without tools, "" while it is active
+	RateLimit server.RateLimitConfig // requests allowed to the endpoint, shared with its aliases
+}
+
+// Path returns the endpoint path with its leading slash.
+func (m *Mount) Path() string {
+	return "/" + m.Endpoint
+}
+
+// register adds the transports and SDK route of m to mux at path, its endpoint path or an
+// alias. Requests of sessions that started before m was mounted go to the mount serving them.
+// MCP requests count against the endpoint's rate limit.
+func (s *Server) register(mux *http.ServeMux, m *Mount, path string) {
+	limited := func(h http.Handler) http.Handler {
+		return server.RateLimitHandler(s.rateLimiter, m.Endpoint, m.RateLimit, h)
+	}
+	streamable := limited(s.pinned(m, func(m *Mount) http.Handler { return m.Sessions }))
+	// Streamable HTTP at the main endpoint path
+	mux.Handle(path, streamable)
+	mux.Handle(path+"/", streamable)
+	// SSE endpoints; new streams always open on the current mount
+	mux.Handle(path+"/sse", limited(m.SSE.SSEHandler()))
+	mux.Handle(path+"/message", limited(s.pinned(m, func(m *Mount) http.Handler { return m.SSE.MessageHandler() })))
+	// Generated client SDK for the endpoint's tools
+	mux.HandleFunc(path+"/sdk", sdkHandler(m.MCP, m.Title, m.Endpoint, s.opts.PublicURL))
+}
+
+type route struct {
+	pattern string
+	handler http.Handler
+}
+
+// Server is the dynamic MCP gateway. It is safe for concurrent use.
+type Server struct {
+	opts        Options
+	authState   *auth.StateManager
+	scheduler   *PollScheduler
+	rateLimiter *server.RateLimiter // buckets of every endpoint, kept across reloads
+
+	sessionIDsMu sync.Mutex
+	sessionIDs   map[string]*server.JWTSessionIdManager // endpoint -> its session id manager, kept across reloads
+
+	// reloadMu serializes reloads, so two of them never build mounts at the same time
+	reloadMu sync.Mutex
+	lastHash string
+
+	historyMu sync.Mutex
+	reloads   []ReloadEvent // most recent first, at most maxReloadHistory
+
+	mu       sync.RWMutex
+	mux      *http.ServeMux
+	mounts   []*Mount
+	routes   []route
+	draining map[string][]*Mount // endpoint -> replaced mounts still serving their sessions
+}
+
+// New creates a server with no mounted specs. With a SpecLoader, a stopped poll scheduler
+// is created as well; call Reload for the initial load.
+func New(opts Options) *Server {
+	if opts.Pipeline == nil {
+		if opts.SpecLoader != nil {
+			opts.Pipeline = opts.SpecLoader.Pipeline()
+		} else {
+			opts.Pipeline = services.NewSpecPipeline(false)
+		}
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	if opts.DrainTimeout <= 0 {
+		opts.DrainTimeout = DefaultDrainTimeout
+	}
+	if opts.SessionStore == nil {
+		// Shared by the servers of every mount, so sessions outlive remounts
+		opts.SessionStore = server.NewMemorySessionStore()
+	}
+	if len(opts.SessionSecret) == 0 {
+		opts.SessionSecret = make([]byte, 32)
+		if _, err := rand.Read(opts.SessionSecret); err != nil {
+			panic(fmt.Sprintf("dynamicserver: failed to generate a session secret: %v", err))
+		}
+	}
+	s := &Server{opts: opts, authState: auth.NewStateManager(), rateLimiter: server.NewRateLimiter(),
+		sessionIDs: make(map[string]*server.JWTSessionIdManager)}
+	if opts.SpecLoader != nil {
+		s.scheduler = NewPollScheduler(opts.PollInterval, s.poll)
+	}
+	openapi2mcp.DefaultSpecHealth().Subscribe(s.onSpecHealthChange)
+	s.mu.Lock()
+	s.rebuildLocked()
+	s.mu.Unlock()
+	return s
+}
+
+// Handler returns the HTTP handler serving the mounted specs and the management API.
+// It always dispatches to the current routes, so it can be installed once.
+func (s *Server) Handler() http.Handler {
+	_, prefix, _ := server.ParsePublicURL(s.opts.PublicURL)
+	return server.StripPathPrefix(prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		mux := s.mux
+		s.mu.RUnlock()
+		mux.ServeHTTP(w, r)
+	}))
+}
+
+// Handle adds a route of the host program (e.g. /info) that is kept across reloads.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes = append(s.routes, route{pattern: pattern, handler: handler})
+	s.rebuildLocked()
+}
+
+// HandleFunc adds a route function of the host program that is kept across reloads.
+func (s *Server) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	s.Handle(pattern, http.HandlerFunc(handler))
+}
+
+// Scheduler returns the database poll scheduler, or nil without a SpecLoader.
+func (s *Server) Scheduler() *PollScheduler {
+	return s.scheduler
+}
+
+// Mounts returns the mounted specs in mount order.
+func (s *Server) Mounts() []*Mount {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]*Mount(nil), s.mounts...)
+}
+
+// MountSpec loads a spec record through the pipeline and mounts it at its endpoint path,
+// replacing a spec already mounted there.
+func (s *Server) MountSpec(ctx context.Context, spec *models.OpenAPISpec) (*Mount, error) {
+	m, err := s.loadDatabaseSpec(ctx, spec, &SpecTiming{})
+	if err != nil {
+		return nil, err
+	}
+	s.add(m)
+	return m, nil
+}
+
+// MountFile loads a spec file through the pipeline and mounts it at the endpoint derived
+// from its file name, replacing a spec already mounted there.
+func (s *Server) MountFile(ctx context.Context, path string) (*Mount, error) {
+	loaded, err := s.opts.Pipeline.ProcessFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return s.MountLoaded(loaded), nil
+}
+
+// MountContent loads spec content that has no file on disk, such as a spec embedded in the
+// binary, and mounts it at the endpoint derived from name.
+func (s *Server) MountContent(ctx context.Context, name string, content []byte) (*Mount, error) {
+	loaded, err := s.opts.Pipeline.Process(ctx, services.EndpointFromPath(name), content, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.MountLoaded(loaded), nil
+}
+
+// MountLoaded mounts a spec already loaded by a pipeline, replacing a spec mounted at its endpoint.
+func (s *Server) MountLoaded(loaded *services.LoadedSpec) *Mount {
+	logAuthScheme(loaded)
+	m := s.buildMount(loaded, &SpecTiming{})
+	s.add(m)
+	return m
+}
+
+// Unmount removes the spec mounted at endpoint (with or without a leading slash) and
+// reports whether there was one.
+func (s *Server) Unmount(endpoint string) bool {
+	endpoint = strings.Trim(endpoint, "/")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, m := range s.mounts {
+		if m.Endpoint == endpoint {
+			s.mounts = append(s.mounts[:i:i], s.mounts[i+1:]...)
+			s.rebuildLocked()
+			log.Printf("Unmounted %s API from /%s", m.Title, endpoint)
+			return true
+		}
+	}
+	return false
+}
+
+// add mounts m, replacing a mount at the same endpoint
+func (s *Server) add(m *Mount) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.mounts {
+		if existing.Endpoint == m.Endpoint {
+			s.drainLocked(existing)
+			s.mounts[i] = m
+			s.rebuildLocked()
+			return
+		}
+	}
+	s.mounts = append(s.mounts, m)
+	s.rebuildLocked()
+}
+
+// handOffSessions moves the clients listening on a remounted endpoint to its new MCP server,
+// and tells them to list the tools again when the tools changed, so long-lived connections
+// pick up new and removed tools without reconnecting. It runs when a replaced mount stops
+// draining with sessions still in progress.
+func handOffSessions(old, m *Mount) {
+	adopted := m.MCP.AdoptSessions(context.Background(), old.MCP)
+	if len(adopted) == 0 || toolsFingerprint(old.MCP) == toolsFingerprint(m.MCP) {
+		return
+	}
+	for _, sessionID := range adopted {
+		if err := m.MCP.SendNotificationToSpecificClient(sessionID, mcp.MethodNotificationToolsListChanged, nil); err != nil {
+			log.Printf("Failed to notify session %s of /%s that its tools changed: %v", sessionID, m.Endpoint, err)
+		}
+	}
+	log.Printf("Notified %d sessions of /%s that its tools changed", len(adopted), m.Endpoint)
+}
+
+// toolsFingerprint identifies the tools of a server, so remounts can tell whether they changed
+func toolsFingerprint(srv *server.MCPServer) string {
+	tools := srv.ListTools()
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	data, _ := json.Marshal(tools)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// rebuildLocked replaces the mux with one serving the current routes and mounts.
+// The caller holds s.mu.
+func (s *Server) rebuildLocked() {
+	mux := http.NewServeMux()
+	s.registerBuiltins(mux)
+	for _, r := range s.routes {
+		mux.Handle(r.pattern, r.handler)
+	}
+	specs := make([]*models.OpenAPISpec, 0, len(s.mounts))
+	taken := make(map[string]bool, len(s.mounts))
+	for _, m := range s.mounts {
+		s.register(mux, m, m.Path())
+		taken[m.Endpoint] = true
+		if m.Spec != nil {
+			specs = append(specs, m.Spec)
+		}
+	}
+	// Aliases serve the same MCP server, so sessions work across an endpoint and its aliases
+	for _, m := range s.mounts {
+		for _, alias := range m.Aliases {
+			if taken[alias] {
+				log.Printf("Alias /%s of /%s is already in use, skipping it", alias, m.Endpoint)
+				continue
+			}
+			taken[alias] = true
+			s.register(mux, m, "/"+alias)
+		}
+	}
+	s.authState.UpdateSpecs(specs)
+	s.mux = mux
+}
+
+// ReloadResult reports the outcome of Reload.
+type ReloadResult struct {
+	Changed    bool          // false when the active specs were unchanged since the last reload
+	ActiveSpec int           // number of active specs in the database
+	Mounted    []string      // endpoints mounted by this reload
+	Report     *ReloadReport // per-spec timings; nil when nothing changed
+}
+
+// Reload mounts the active specs of the SpecLoader, replacing all mounted specs, when they
+// changed since the previous reload.
+func (s *Server) Reload(ctx context.Context) (*ReloadResult, error) {
+	if s.opts.SpecLoader == nil {
+		return nil, fmt.Errorf("spec loader not initialized")
+	}
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	specs, hash, err := s.loadActiveSpecs()
+	if err != nil {
+		s.recordReload(ReloadEvent{At: time.Now(), Error: err.Error()})
+		metrics.SpecReloads.Inc("error")
+		return nil, err
+	}
+	result := &ReloadResult{ActiveSpec: len(specs)}
+	if hash == s.lastHash {
+		metrics.SpecReloads.Inc("unchanged")
+		return result, nil
+	}
+	metrics.SpecReloads.Inc("changed")
+	result.Changed = true
+	log.Printf("Database changes detected, reloading specs...")
+	result.Mounted, result.Report = s.replaceSpecs(ctx, specs)
+	s.lastHash = hash
+	s.recordReload(ReloadEvent{At: time.Now(), ActiveSpecs: len(specs), Mounted: result.Mounted, Report: result.Report})
+	return result, nil
+}
+
+// maxReloadHistory is the number of reloads kept for Reloads
+const maxReloadHistory = 20
+
+// ReloadEvent is a reload that changed the mounted specs, or failed to load them.
+type ReloadEvent struct {
+	At          time.Time     `json:"at"`
+	ActiveSpecs int           `json:"active_specs"`
+	Mounted     []string      `json:"mounted,omitempty"`
+	Report      *ReloadReport `json:"timings,omitempty"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// recordReload adds a reload to the history
+func (s *Server) recordReload(e ReloadEvent) {
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+	s.reloads = append([]ReloadEvent{e}, s.reloads...)
+	if len(s.reloads) > maxReloadHistory {
+		s.reloads = s.reloads[:maxReloadHistory]
+	}
+}
+
+// Reloads returns the recent reloads that changed the mounted specs or failed, most recent
+// first. Reloads that found nothing to change are not recorded.
+func (s *Server) Reloads() []ReloadEvent {
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+	reloads := make([]ReloadEvent, len(s.reloads))
+	copy(reloads, s.reloads)
+	return reloads
+}
+
+// loadActiveSpecs returns the active specs with a hash for change detection
+func (s *Server) loadActiveSpecs() ([]*models.OpenAPISpec, string, error) {
+	specs, err := s.opts.SpecLoader.GetActiveSpecs()
+	if err != nil {
+		return nil, "", err
+	}
+	hash := fmt.Sprintf("%d", len(specs))
+	for _, spec := range specs {
+		hash += fmt.Sprintf("-%d-%s", spec.ID, spec.Name)
+		if spec.ApiKeyToken != nil {
+			hash += fmt.Sprintf("-%d", len(*spec.ApiKeyToken))
+		}
+		if spec.Aliases != nil {
+			hash += "-" + *spec.Aliases
+		}
+		if spec.FeatureFlags != nil {
+			hash += "-" + *spec.FeatureFlags
+		}
+		if spec.RequireToken {
+			hash += "-require-token"
+		}
+		if spec.RateLimit != nil {
+			hash += "-" + *spec.RateLimit
+		}
+		if spec.ToolRules != nil {
+			hash += "-" + *spec.ToolRules
+		}
+		if spec.UpstreamPolicy != nil {
+			hash += "-" + *spec.UpstreamPolicy
+		}
+		if spec.CredentialStrategy != nil {
+			hash += "-" + *spec.CredentialStrategy
+		}
+		for _, cred := range spec.Credentials {
+			hash += fmt.Sprintf("-cred%d-%d", cred.ID, len(cred.Token))
+		}
+	}
+	return specs, hash, nil
+}
+
+// replaceSpecs builds mounts for specs and swaps them in at once, so requests see either
+// the old or the new set of endpoints. Specs that fail to load are skipped.
+func (s *Server) replaceSpecs(ctx context.Context, specs []*models.OpenAPISpec) ([]string, *ReloadReport) {
+	reloadStart := time.Now()
+	report := &ReloadReport{}
+	var mounts []*Mount
+	var mounted []string
+	for _, spec := range specs {
+		timing := SpecTiming{Name: spec.Name, Endpoint: strings.TrimPrefix(spec.EndpointPath, "/")}
+		specStart := time.Now()
+		m, err := s.loadDatabaseSpec(ctx, spec, &timing)
+		timing.TotalMs = time.Since(specStart).Milliseconds()
+		if err != nil {
+			timing.Error = err.Error()
+		} else {
+			mounts = append(mounts, m)
+			mounted = append(mounted, m.Endpoint)
+		}
+		report.Specs = append(report.Specs, timing)
+	}
+
+	s.mu.Lock()
+	previous := make(map[string]*Mount, len(s.mounts))
+	for _, m := range s.mounts {
+		previous[m.Endpoint] = m
+	}
+	for _, m := range mounts {
+		if old := previous[m.Endpoint]; old != nil {
+			s.drainLocked(old)
+		}
+	}
+	s.mounts = mounts
+	s.rebuildLocked()
+	s.mu.Unlock()
+
+	report.TotalMs = time.Since(reloadStart).Milliseconds()
+	logReloadReport(report)
+	return mounted, report
+}
+
+// loadDatabaseSpec runs a spec record through the pipeline and builds its mount
+func (s *Server) loadDatabaseSpec(ctx context.Context, spec *models.OpenAPISpec, timing *SpecTiming) (*Mount, error) {
+	endpoint := strings.TrimPrefix(spec.EndpointPath, "/")
+	log.Printf("Loading database spec: %s -> endpoint: /%s", spec.Name, endpoint)
+
+	start := time.Now()
+	loaded, err := s.opts.Pipeline.ProcessDBSpec(ctx, spec)
+	timing.ParseMs = time.Since(start).Milliseconds()
+	if err != nil {
+		log.Printf("Failed to parse spec content for %s: %v", spec.Name, err)
+		return nil, err
+	}
+	logTokenUsage(loaded)
+
+	// Ensure database connection is healthy before long-running MCP server creation
+	if s.opts.EnsureConnection != nil {
+		if err := s.opts.EnsureConnection(); err != nil {
+			log.Printf("Failed to ensure database connection before creating MCP server for %s: %v", loaded.Doc.Info.Title, err)
+			return nil, err
+		}
+	}
+	m := s.buildMount(loaded, timing)
+	// Re-check database connection after long-running operation
+	if s.opts.EnsureConnection != nil {
+		if err := s.opts.EnsureConnection(); err != nil {
+			log.Printf("Database connection lost after creating MCP server for %s: %v", loaded.Doc.Info.Title, err)
+		}
+	}
+	return m, nil
+}
+
+// buildMount creates the MCP server and transports of a loaded spec
+func (s *Server) buildMount(loaded *services.LoadedSpec, timing *SpecTiming) *Mount {
+	doc, spec, endpoint := loaded.Doc, loaded.Spec, loaded.Endpoint
+
+	log.Printf("Creating MCP server for %s...", doc.Info.Title)
+	toolsStart := time.Now()
+	var srv *server.MCPServer
+	disabled := services.InactiveReason(context.Background(), loaded)
+	if disabled != "" {
+		// Agents connecting to a disabled spec see no tools, and the reason in the instructions
+		log.Printf("%s API at /%s is disabled, no tools are registered: it %s", doc.Info.Title, endpoint, disabled)
+		srv = server.NewMCPServer(doc.Info.Title, doc.Info.Version,
+			append(openapi2mcp.ServerOptions(), server.WithInstructions("This API is disabled: it "+disabled+"."))...)
+	} else {
+		srv = openapi2mcp.NewServerWithDatabase(doc.Info.Title, doc.Info.Version, doc, spec)
+	}
+	timing.ToolsMs = time.Since(toolsStart).Milliseconds()
+
+	mountStart := time.Now()
+	contextFunc := func(ctx context.Context, r *http.Request) context.Context {
+		return s.authContextFunc(ctx, r, doc, spec)
+	}
+	sessionIDs := s.sessionIdManager(endpoint)
+	m := &Mount{
+		Endpoint:   endpoint,
+		Title:      doc.Info.Title,
+		AuthType:   endpointAuthType(loaded.AuthType, loaded.AuthPath),
+		Aliases:    spec.AliasPaths(),
+		Spec:       spec,
+		Loaded:     loaded,
+		MCP:        srv,
+		Disabled:   disabled,
+		RateLimit:  s.rateLimit(spec),
+		SessionIDs: sessionIDs,
+		Sessions: server.NewStreamableHTTPServer(srv,
+			server.WithEndpointPath("/"+endpoint),
+			server.WithHTTPContextFunc(contextFunc),
+			server.WithSessionIdManager(sessionIDs),
+			server.WithCompression(s.opts.Compression),
+			server.WithSessionStore(s.opts.SessionStore),
+		),
+		SSE: server.NewSSEServer(srv,
+			server.WithStaticBasePath("/"+endpoint),
+			server.WithSSEEndpoint("/sse"),
+			server.WithMessageEndpoint("/message"),
+			server.WithSSEContextFunc(contextFunc),
+			server.WithSSESessionStore(s.opts.SessionStore),
+			server.WithPublicURL(s.opts.PublicURL),
+		),
+	}
+	timing.MountMs = time.Since(mountStart).Milliseconds()
+	log.Printf("Mounted %s API at /%s (StreamableHTTP) and /%s/sse + /%s/message (SSE)", doc.Info.Title, endpoint, endpoint, endpoint)
+	if len(m.Aliases) > 0 {
+		log.Printf("%s API is also served at /%s", doc.Info.Title, strings.Join(m.Aliases, ", /"))
+	}
+	return m
+}
+
+// sessionIdManager returns the session id manager of an endpoint, created for its first
+// mount and reused by the next ones, so IDs terminated before a remount stay refused
+func (s *Server) sessionIdManager(endpoint string) *server.JWTSessionIdManager {
+	s.sessionIDsMu.Lock()
+	defer s.sessionIDsMu.Unlock()
+	manager, ok := s.sessionIDs[endpoint]
+	if !ok {
+		manager = server.NewJWTSessionIdManager(s.opts.SessionSecret,
+			server.WithJWTSessionEndpoint("/"+endpoint),
+			server.WithJWTSessionTTL(s.opts.SessionTTL),
+		)
+		s.sessionIDs[endpoint] = manager
+	}
+	return manager
+}
+
+// rateLimit returns the rate limit of a spec's endpoint: its rate_limit, or the default
+func (s *Server) rateLimit(spec *models.OpenAPISpec) server.RateLimitConfig {
+	if spec == nil || spec.RateLimit == nil || strings.TrimSpace(*spec.RateLimit) == "" {
+		return s.opts.RateLimit
+	}
+	cfg, err := server.ParseRateLimit(*spec.RateLimit)
+	if err != nil {
+		log.Printf("Invalid rate_limit of spec %s, using the default: %v", spec.Name, err)
+		return s.opts.RateLimit
+	}
+	return cfg
+}
+
+// endpointAuthType returns the auth type only when the spec defines a usable security scheme
+func endpointAuthType(authType, authPath string) string {
+	if authPath == "" {
+		return ""
+	}
+	return authType
+}
+
+// logAuthScheme logs the security scheme of a spec, with header casing from the raw content
+func logAuthScheme(loaded *services.LoadedSpec) {
+	if loaded.AuthPath != "" {
+		log.Printf("%s API: Found security scheme '%s' with %s authentication: %s", loaded.Endpoint, loaded.AuthSchemeName, loaded.AuthType, loaded.AuthPath)
+	} else {
+		log.Printf("%s API: No authentication security scheme found in spec", loaded.Endpoint)
+	}
+}
+
+// logTokenUsage logs how a database spec's token will be used, and resolves secret manager
+// references now, so a misconfigured reference shows up at mount time and the first tool
+// call is served from the cache
+func logTokenUsage(loaded *services.LoadedSpec) {
+	spec, endpoint, authType := loaded.Spec, loaded.Endpoint, loaded.AuthType
+	if loaded.AuthPath != "" {
+		log.Printf("%s API: Found security scheme '%s' with %s authentication: %s", endpoint, loaded.AuthSchemeName, authType, loaded.AuthPath)
+		// Show database token status and how it will be used
+		if spec.ApiKeyToken != nil && *spec.ApiKeyToken != "" {
+			switch authType {
+			case "bearer":
+				log.Printf("%s API: Will use database token as BEARER TOKEN (%s)", endpoint, secrets.Describe(*spec.ApiKeyToken))
+			case "apiKey":
+				log.Printf("%s API: Will use database token as API KEY (%s)", endpoint, secrets.Describe(*spec.ApiKeyToken))
+			case "basic":
+				log.Printf("%s API: Will use database token as BASIC AUTH (%s)", endpoint, secrets.Describe(*spec.ApiKeyToken))
+			default:
+				log.Printf("%s API: Will use database token as API KEY - default (%s)", endpoint, secrets.Describe(*spec.ApiKeyToken))
+			}
+		} else if len(spec.Credentials) == 0 {
+			log.Printf("%s API: No token in database, will use environment variables for %s auth", endpoint, authType)
+		}
+		if creds := auth.SpecCredentials(spec); len(creds) > 1 {
+			strategy := auth.CredentialRoundRobin
+			if spec.CredentialStrategy != nil {
+				strategy = *spec.CredentialStrategy
+			}
+			log.Printf("%s API: Will spread calls over %d database keys (%s)", endpoint, len(creds), strategy)
+		}
+	}
+	for _, cred := range auth.SpecCredentials(spec) {
+		if secrets.Default().IsReference(cred.Token) {
+			if _, err := secrets.Default().Resolve(context.Background(), cred.Token); err != nil {
+				log.Printf("%s API: Failed to resolve token reference of %s: %v", endpoint, cred.Label, err)
+			}
+		}
+	}
+}
+
+// logReloadReport logs the per-spec timings of a reload, slowest first
+func logReloadReport(report *ReloadReport) {
+	specs := append([]SpecTiming(nil), report.Specs...)
+	sort.Slice(specs, func(i, j int) bool { return specs[i].TotalMs > specs[j].TotalMs })
+	log.Printf("Reload took %dms for %d specs", report.TotalMs, len(specs))
+	for _, t := range specs {
+		status := ""
+		if t.Error != "" {
+			status = " (failed: " + t.Error + ")"
+		}
+		log.Printf("  /%s: parse %dms, tools %dms, mount %dms, total %dms%s", t.Endpoint, t.ParseMs, t.ToolsMs, t.MountMs, t.TotalMs, status)
+	}
+}
@@ -0,0 +1,382 @@
+package dynamicserver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/services"
+)
+
+func testSpec(title string) string {
+	return `openapi: 3.0.0
+info:
+  title: ` + title + `
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        '200':
+          description: OK
+`
+}
+
+// writeSpec writes a file-mode spec, which is mounted at the endpoint of its file name
+func writeSpec(t *testing.T, name, title string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(testSpec(title)), 0o644); err != nil {
+		t.Fatalf("failed to write spec: %v", err)
+	}
+	return path
+}
+
+// initialize opens an MCP session on endpoint and returns its status and session ID
+func initialize(t *testing.T, url string) (int, string) {
+	t.Helper()
+	body := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","clientInfo":{"name":"test","version":"1.0.0"}}}`
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp.StatusCode, resp.Header.Get("Mcp-Session-Id")
+}
+
+func hasTool(m *Mount, name string) bool {
+	for _, tool := range m.MCP.ListTools() {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func get(t *testing.T, url string) int {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", url, err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestServer_MountFileAndUnmount(t *testing.T) {
+	ds := New(Options{})
+	ts := httptest.NewServer(ds.Handler())
+	defer ts.Close()
+	ds.HandleFunc("/info", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) })
+
+	if status, _ := initialize(t, ts.URL+"/pets"); status != http.StatusNotFound {
+		t.Fatalf("expected 404 before the spec is mounted, got %d", status)
+	}
+
+	m, err := ds.MountFile(context.Background(), writeSpec(t, "pets.yaml", "Pets"))
+	if err != nil {
+		t.Fatalf("MountFile: %v", err)
+	}
+	if m.Endpoint != "pets" || m.Path() != "/pets" || m.Title != "Pets" || !hasTool(m, "listPets") {
+		t.Errorf("expected the spec mounted at /pets with its tools, got %+v", m)
+	}
+	if mounts := ds.Mounts(); len(mounts) != 1 || mounts[0] != m {
+		t.Errorf("expected the mount to be listed, got %v", mounts)
+	}
+	// The handler installed before the mount serves it
+	if status, sessionID := initialize(t, ts.URL+"/pets"); status != http.StatusOK || sessionID == "" {
+		t.Errorf("expected the mounted endpoint to start a session, got %d %q", status, sessionID)
+	}
+	if status := get(t, ts.URL+"/pets/sdk?lang=python"); status != http.StatusOK {
+		t.Errorf("expected the SDK route of the mount, got %d", status)
+	}
+
+	if !ds.Unmount("/pets") {
+		t.Fatalf("expected Unmount to find the spec")
+	}
+	if ds.Unmount("pets") {
+		t.Errorf("expected a second Unmount to find nothing")
+	}
+	if status, _ := initialize(t, ts.URL+"/pets"); status != http.StatusNotFound {
+		t.Errorf("expected 404 after the spec is unmounted, got %d", status)
+	}
+	if status := get(t, ts.URL+"/info"); status != http.StatusOK {
+		t.Errorf("expected the host route to be kept across mux swaps, got %d", status)
+	}
+}
+
+func TestServer_RemountKeepsSessions(t *testing.T) {
+	ds := New(Options{})
+	ts := httptest.NewServer(ds.Handler())
+	defer ts.Close()
+
+	path := writeSpec(t, "pets.yaml", "Pets")
+	first, err := ds.MountFile(context.Background(), path)
+	if err != nil {
+		t.Fatalf("MountFile: %v", err)
+	}
+	_, sessionID := initialize(t, ts.URL+"/pets")
+
+	second, err := ds.MountContent(context.Background(), "pets.yaml", []byte(testSpec("Pets v2")))
+	if err != nil {
+		t.Fatalf("MountContent: %v", err)
+	}
+	if mounts := ds.Mounts(); len(mounts) != 1 || mounts[0] != second {
+		t.Fatalf("expected the new mount to replace the old one, got %v", mounts)
+	}
+
+	// The session in progress stays on the replaced mount, new sessions go to the new one
+	r, _ := http.NewRequest(http.MethodGet, ts.URL+"/pets", nil)
+	r.Header.Set("Mcp-Session-Id", sessionID)
+	if old := ds.drainingMount("pets", r); old != first {
+		t.Errorf("expected the session to be served by the replaced mount, got %v", old)
+	}
+	ping, _ := http.NewRequest(http.MethodPost, ts.URL+"/pets", strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"ping"}`))
+	ping.Header.Set("Content-Type", "application/json")
+	ping.Header.Set("Mcp-Session-Id", sessionID)
+	resp, err := http.DefaultClient.Do(ping)
+	if err != nil {
+		t.Fatalf("ping failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected the session to survive the remount, got %d", resp.StatusCode)
+	}
+	if status, _ := initialize(t, ts.URL+"/pets"); status != http.StatusOK {
+		t.Errorf("expected a new session on the new mount, got %d", status)
+	}
+}
+
+func TestServer_ReloadWithoutSpecLoader(t *testing.T) {
+	ds := New(Options{})
+	if ds.Scheduler() != nil {
+		t.Errorf("expected no poll scheduler without a SpecLoader")
+	}
+	if _, err := ds.Reload(context.Background()); err == nil {
+		t.Errorf("expected Reload to fail without a SpecLoader")
+	}
+}
+
+func TestServer_Reload(t *testing.T) {
+	fakeSpecs.set(fakeSpecRow{id: 1, name: "pets", endpoint: "/pets", content: testSpec("Pets")})
+	db, err := sql.Open("dynamicserver-fake", "")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	defer db.Close()
+
+	ds := New(Options{SpecLoader: services.NewSpecLoaderService(db)})
+	ts := httptest.NewServer(ds.Handler())
+	defer ts.Close()
+
+	result, err := ds.Reload(context.Background())
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if !result.Changed || result.ActiveSpec != 1 || len(result.Mounted) != 1 || result.Mounted[0] != "pets" {
+		t.Fatalf("expected the active spec to be mounted, got %+v", result)
+	}
+	if status, _ := initialize(t, ts.URL+"/pets"); status != http.StatusOK {
+		t.Errorf("expected the reloaded spec to be served, got %d", status)
+	}
+
+	if result, err := ds.Reload(context.Background()); err != nil || result.Changed {
+		t.Errorf("expected an unchanged database not to reload, got %+v, %v", result, err)
+	}
+
+	fakeSpecs.set(fakeSpecRow{id: 2, name: "store", endpoint: "/store", content: testSpec("Store")})
+	if result, err := ds.Reload(context.Background()); err != nil || !result.Changed {
+		t.Fatalf("expected the changed specs to reload, got %+v, %v", result, err)
+	}
+	if status, _ := initialize(t, ts.URL+"/pets"); status != http.StatusNotFound {
+		t.Errorf("expected the deactivated spec to be unmounted, got %d", status)
+	}
+	if status, _ := initialize(t, ts.URL+"/store"); status != http.StatusOK {
+		t.Errorf("expected the new spec to be served, got %d", status)
+	}
+	if reloads := ds.Reloads(); len(reloads) != 2 || reloads[0].Mounted[0] != "store" {
+		t.Errorf("expected the two reloads that changed specs, most recent first, got %+v", reloads)
+	}
+
+	// GET /reload reports the same history
+	resp, err := http.Get(ts.URL + "/reload")
+	if err != nil {
+		t.Fatalf("GET /reload failed: %v", err)
+	}
+	var history ReloadHistoryResponse
+	json.NewDecoder(resp.Body).Decode(&history)
+	resp.Body.Close()
+	if !history.Success || len(history.Reloads) != 2 {
+		t.Errorf("expected the reload history from GET /reload, got %+v", history)
+	}
+}
+
+func TestServer_ReloadKeepsTerminatedSessions(t *testing.T) {
+	fakeSpecs.set(fakeSpecRow{id: 1, name: "pets", endpoint: "/pets", content: testSpec("Pets")})
+	db, err := sql.Open("dynamicserver-fake", "")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	defer db.Close()
+
+	ds := New(Options{SpecLoader: services.NewSpecLoaderService(db)})
+	ts := httptest.NewServer(ds.Handler())
+	defer ts.Close()
+	if _, err := ds.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	first := ds.Mounts()[0]
+	_, sessionID := initialize(t, ts.URL+"/pets")
+	if err := first.Sessions.TerminateSession(context.Background(), sessionID); err != nil {
+		t.Fatalf("TerminateSession: %v", err)
+	}
+
+	// The spec is imported again at the same endpoint
+	fakeSpecs.set(fakeSpecRow{id: 2, name: "pets", endpoint: "/pets", content: testSpec("Pets v2")})
+	if result, err := ds.Reload(context.Background()); err != nil || !result.Changed {
+		t.Fatalf("expected the changed spec to be remounted, got %+v, %v", result, err)
+	}
+	if second := ds.Mounts()[0]; second == first || second.SessionIDs != first.SessionIDs {
+		t.Errorf("expected the remount to keep the endpoint's session id manager")
+	}
+	if terminated, err := ds.Mounts()[0].SessionIDs.Validate(sessionID); err != nil || !terminated {
+		t.Errorf("expected the terminated session ID to stay refused, got terminated=%v err=%v", terminated, err)
+	}
+
+	ping, _ := http.NewRequest(http.MethodPost, ts.URL+"/pets", strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"ping"}`))
+	ping.Header.Set("Content-Type", "application/json")
+	ping.Header.Set("Mcp-Session-Id", sessionID)
+	resp, err := http.DefaultClient.Do(ping)
+	if err != nil {
+		t.Fatalf("ping failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected the terminated session to be refused after the reload, got %d", resp.StatusCode)
+	}
+}
+
+func TestServer_ReloadFeatureFlags(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "staging")
+	content := testSpec("Pets") + `      x-mcp-feature-flag: list-pets
+`
+	off, on := `{"list-pets": ["production"]}`, `{"list-pets": ["staging"]}`
+	fakeSpecs.set(fakeSpecRow{id: 1, name: "pets", endpoint: "/pets", content: content, featureFlags: &off})
+	db, err := sql.Open("dynamicserver-fake", "")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	defer db.Close()
+
+	ds := New(Options{SpecLoader: services.NewSpecLoaderService(db)})
+	if _, err := ds.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if hasTool(ds.Mounts()[0], "listPets") {
+		t.Fatalf("expected the flagged operation to be hidden in staging")
+	}
+
+	// spec-manager set-flags only changes the feature_flags column
+	fakeSpecs.set(fakeSpecRow{id: 1, name: "pets", endpoint: "/pets", content: content, featureFlags: &on})
+	if result, err := ds.Reload(context.Background()); err != nil || !result.Changed {
+		t.Fatalf("expected the flag change to reload the spec, got %+v, %v", result, err)
+	}
+	if !hasTool(ds.Mounts()[0], "listPets") {
+		t.Errorf("expected the flagged operation to be served once enabled in staging")
+	}
+}
+
+// fakeSpecs are the active specs the dynamicserver-fake database driver returns
+var fakeSpecs = &fakeSpecTable{}
+
+type fakeSpecRow struct {
+	id           int64
+	name         string
+	endpoint     string
+	content      string
+	featureFlags *string
+}
+
+type fakeSpecTable struct {
+	mu   sync.Mutex
+	rows []fakeSpecRow
+}
+
+func (t *fakeSpecTable) set(rows ...fakeSpecRow) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rows = rows
+}
+
+func init() {
+	sql.Register("dynamicserver-fake", fakeDriver{})
+}
+
+// fakeDriver answers the queries of the spec loader: the active specs from fakeSpecs, and
+// no spec credentials
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{query}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return nil, fmt.Errorf("transactions are not supported") }
+
+type fakeStmt struct{ query string }
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, fmt.Errorf("statements are not supported")
+}
+
+var specColumns = []string{"id", "name", "title", "version", "content", "endpoint_path", "file_format", "file_size",
+	"api_key_token", "is_active", "created_at", "updated_at", "content_hash", "feature_flags", "aliases", "deleted_at",
+	"require_token_to_activate", "rate_limit", "tool_rules", "upstream_policy", "credential_strategy"}
+
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	if !strings.Contains(s.query, "FROM openapi_specs") {
+		return &fakeRows{columns: []string{"id", "spec_id", "label", "token", "created_at"}}, nil
+	}
+	fakeSpecs.mu.Lock()
+	defer fakeSpecs.mu.Unlock()
+	rows := &fakeRows{columns: specColumns}
+	now := time.Now()
+	for _, r := range fakeSpecs.rows {
+		rows.values = append(rows.values, []driver.Value{r.id, r.name, nil, nil, r.content, r.endpoint, "yaml", int64(len(r.content)),
+			nil, true, now, now, nil, r.featureFlags, nil, nil, false, nil, nil, nil, nil})
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
@@ -0,0 +1,818 @@
+package dynamicserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/apierrors"
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+	"github.com/ubermorgenland/openapi-mcp/pkg/openapi2mcp"
+	"github.com/ubermorgenland/openapi-mcp/pkg/services"
+)
+
+// Spec management request/response types
+type ImportSpecRequest struct {
+	Name              string   `json:"name"`
+	EndpointPath      string   `json:"endpoint_path"`
+	SpecContent       string   `json:"spec_content"`
+	FileFormat        string   `json:"file_format,omitempty"`        // "json" or "yaml", auto-detected if not provided
+	ApiKeyToken       string   `json:"api_key_token,omitempty"`      // API key for this specific spec
+	Active            *bool    `json:"active,omitempty"`             // defaults to true if not provided
+	ExcludeOperations []string `json:"exclude_operations,omitempty"` // patterns of operations to strip before storage, e.g. "DELETE /admin/*"
+}
+
+type UpdateSpecRequest struct {
+	Name         string `json:"name,omitempty"`
+	EndpointPath string `json:"endpoint_path,omitempty"`
+	SpecContent  string `json:"spec_content,omitempty"`
+	FileFormat   string `json:"file_format,omitempty"`
+	ApiKeyToken  string `json:"api_key_token,omitempty"`
+	Active       *bool  `json:"active,omitempty"`
+}
+
+type SuccessResponse struct {
+	Success bool        `json:"success"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// SpecReloadResponse represents the response from reload endpoint
+type SpecReloadResponse struct {
+	Success      bool          `json:"success"`
+	Message      string        `json:"message"`
+	ReloadedAPIs []string      `json:"reloaded_apis,omitempty"`
+	Timings      *ReloadReport `json:"timings,omitempty"`
+	Error        string        `json:"error,omitempty"`
+}
+
+// ReloadHistoryResponse is the response body of GET /reload
+type ReloadHistoryResponse struct {
+	Success bool          `json:"success"`
+	Reloads []ReloadEvent `json:"reloads"`
+}
+
+// SpecTiming is the time spent on one spec during a reload, in milliseconds
+type SpecTiming struct {
+	Name     string `json:"name"`
+	Endpoint string `json:"endpoint"`
+	ParseMs  int64  `json:"parse_ms"`
+	ToolsMs  int64  `json:"tool_generation_ms"`
+	MountMs  int64  `json:"mount_ms"`
+	TotalMs  int64  `json:"total_ms"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ReloadReport breaks a reload down per spec, so slow specs can be identified
+type ReloadReport struct {
+	TotalMs int64        `json:"total_ms"`
+	Specs   []SpecTiming `json:"specs"`
+}
+
+// registerBuiltins adds the health check, callback receiver and, with a SpecLoader, the
+// reload, polling, swagger and spec management endpoints to mux
+func (s *Server) registerBuiltins(mux *http.ServeMux) {
+	// Add health endpoint, which also reports degraded specs
+	mux.HandleFunc("/health", s.handleHealth)
+
+	// Add callback receiver for OpenAPI callbacks, if MCP_CALLBACK_BASE_URL is set
+	if receiver := openapi2mcp.DefaultCallbackReceiver(); receiver != nil {
+		mux.Handle(openapi2mcp.CallbackPathPrefix, receiver)
+	}
+
+	if s.opts.SpecLoader == nil {
+		return
+	}
+
+	// Add reload endpoint
+	mux.HandleFunc("/reload", s.handleReload)
+
+	// Add database polling management endpoints
+	mux.HandleFunc("/polling", s.handlePolling)
+	mux.HandleFunc("/polling/", s.handlePolling)
+
+	// Add swagger endpoint
+	mux.HandleFunc("/swagger", handleSwagger)
+
+	// Set up CORS middleware
+	corsMiddleware := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+			if r.Method == "OPTIONS" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			next(w, r)
+		}
+	}
+
+	// Add spec management endpoints
+	mux.HandleFunc("/specs", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			s.handleGetSpecs(w, r)
+		case "POST":
+			s.handleCreateSpec(w, r)
+		default:
+			writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+
+	mux.HandleFunc("/specs/active", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.handleGetActiveSpecs(w, r)
+	}))
+
+	mux.HandleFunc("/specs/deleted", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.handleGetDeletedSpecs(w, r)
+	}))
+
+	mux.HandleFunc("/specs/", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		// Extract ID from path
+		path := strings.TrimPrefix(r.URL.Path, "/specs/")
+		if path == "" {
+			writeErrorResponse(w, "Spec ID required", http.StatusBadRequest)
+			return
+		}
+
+		// Handle /specs/{id}/activate, /specs/{id}/deactivate, /specs/{id}/restore, /specs/{id}/token, /specs/{id}/aliases, /specs/{id}/require-token, /specs/{id}/rate-limit, /specs/{id}/tool-rules and /specs/{id}/upstream-policy
+		parts := strings.Split(path, "/")
+		if len(parts) == 2 {
+			id, err := strconv.Atoi(parts[0])
+			if err != nil {
+				writeErrorResponse(w, "Invalid spec ID", http.StatusBadRequest)
+				return
+			}
+
+			switch parts[1] {
+			case "activate":
+				if r.Method != "POST" {
+					writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+				s.handleActivateSpec(w, r, id)
+				return
+			case "deactivate":
+				if r.Method != "POST" {
+					writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+				s.handleDeactivateSpec(w, r, id)
+				return
+			case "restore":
+				if r.Method != "POST" {
+					writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+				s.handleRestoreSpec(w, r, id)
+				return
+			case "token":
+				if r.Method != "PUT" {
+					writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+				s.handleUpdateApiKeyToken(w, r, id)
+				return
+			case "aliases":
+				if r.Method != "PUT" {
+					writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+				s.handleUpdateAliases(w, r, id)
+				return
+			case "require-token":
+				if r.Method != "PUT" {
+					writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+				s.handleUpdateRequireToken(w, r, id)
+				return
+			case "rate-limit":
+				if r.Method != "PUT" {
+					writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+				s.handleUpdateRateLimit(w, r, id)
+				return
+			case "tool-rules":
+				if r.Method != "PUT" {
+					writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+				s.handleUpdateToolRules(w, r, id)
+				return
+			case "upstream-policy":
+				if r.Method != "PUT" {
+					writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+				s.handleUpdateUpstreamPolicy(w, r, id)
+				return
+			}
+		}
+
+		// Handle /specs/{id} operations
+		id, err := strconv.Atoi(parts[0])
+		if err != nil {
+			writeErrorResponse(w, "Invalid spec ID", http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case "GET":
+			s.handleGetSpec(w, r, id)
+		case "PUT":
+			s.handleUpdateSpec(w, r, id)
+		case "DELETE":
+			s.handleDeleteSpec(w, r, id)
+		default:
+			writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+}
+
+// handleSwagger serves the OpenAPI specification for this server
+func handleSwagger(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Look for swagger file
+	swaggerPaths := []string{
+		"spec-api-swagger.json",
+		"bin/spec-api-swagger.json",
+		"./spec-api-swagger.json",
+	}
+
+	var swaggerContent []byte
+	var swaggerFound bool
+
+	for _, path := range swaggerPaths {
+		if content, err := os.ReadFile(path); err == nil {
+			swaggerContent = content
+			swaggerFound = true
+			break
+		}
+	}
+
+	if !swaggerFound {
+		// Create a basic swagger spec for the dynamic reloading server
+		basicSwagger := map[string]interface{}{
+			"openapi": "3.0.0",
+			"info": map[string]interface{}{
+				"title":       "OpenAPI MCP Dynamic Server",
+				"version":     "1.0.0",
+				"description": "Dynamic OpenAPI MCP server with database-driven spec loading and intelligent authentication",
+			},
+			"servers": []map[string]interface{}{
+				{"url": "http://localhost:8080", "description": "Local development server"},
+			},
+			"paths": map[string]interface{}{
+				"/health": map[string]interface{}{
+					"get": map[string]interface{}{
+						"summary":     "Health check",
+						"description": "Returns OK if server is running",
+						"responses": map[string]interface{}{
+							"200": map[string]interface{}{
+								"description": "Server is healthy",
+								"content": map[string]interface{}{
+									"text/plain": map[string]interface{}{
+										"schema": map[string]interface{}{
+											"type":    "string",
+											"example": "OK",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				"/reload": map[string]interface{}{
+					"post": map[string]interface{}{
+						"summary":     "Reload OpenAPI specs",
+						"description": "Manually trigger reload of OpenAPI specs from database",
+						"responses": map[string]interface{}{
+							"200": map[string]interface{}{
+								"description": "Reload status",
+								"content": map[string]interface{}{
+									"application/json": map[string]interface{}{
+										"schema": map[string]interface{}{
+											"type": "object",
+											"properties": map[string]interface{}{
+												"success": map[string]interface{}{
+													"type": "boolean",
+												},
+												"message": map[string]interface{}{
+													"type": "string",
+												},
+												"reloaded_apis": map[string]interface{}{
+													"type": "array",
+													"items": map[string]interface{}{
+														"type": "string",
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				"/swagger": map[string]interface{}{
+					"get": map[string]interface{}{
+						"summary":     "Get OpenAPI specification",
+						"description": "Returns the OpenAPI specification for this server",
+						"responses": map[string]interface{}{
+							"200": map[string]interface{}{
+								"description": "OpenAPI specification",
+								"content": map[string]interface{}{
+									"application/json": map[string]interface{}{
+										"schema": map[string]interface{}{
+											"type": "object",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(basicSwagger)
+		return
+	}
+
+	var swaggerSpec map[string]interface{}
+	if err := json.Unmarshal(swaggerContent, &swaggerSpec); err != nil {
+		http.Error(w, "Invalid swagger specification format", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(swaggerSpec)
+}
+
+// handleReload handles HTTP reload requests: POST reloads, GET lists the recent reloads
+func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == "GET" {
+		json.NewEncoder(w).Encode(ReloadHistoryResponse{Success: true, Reloads: s.Reloads()})
+		return
+	}
+	if r.Method != "POST" {
+		response := SpecReloadResponse{
+			Success: false,
+			Error:   "Method not allowed. Use GET or POST.",
+		}
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	log.Printf("Reload requested via HTTP endpoint")
+
+	result, err := s.Reload(r.Context())
+	if err != nil {
+		response := SpecReloadResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to load specs from database: %v", err),
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	// Check if specs have changed
+	if !result.Changed {
+		response := SpecReloadResponse{
+			Success: true,
+			Message: "No changes detected in database specs",
+		}
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response := SpecReloadResponse{
+		Success:      true,
+		Message:      fmt.Sprintf("Successfully reloaded %d API specs", len(result.Mounted)),
+		ReloadedAPIs: result.Mounted,
+		Timings:      result.Report,
+	}
+
+	log.Printf("Successfully reloaded %d API specs: %v", len(result.Mounted), result.Mounted)
+	json.NewEncoder(w).Encode(response)
+}
+
+// Spec management handler functions
+func writeErrorResponse(w http.ResponseWriter, message string, code int) {
+	apierrors.WriteStatus(w, code, message)
+}
+
+func writeSuccessResponse(w http.ResponseWriter, message string, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SuccessResponse{
+		Success: true,
+		Message: message,
+		Data:    data,
+	})
+}
+
+func (s *Server) handleGetSpecs(w http.ResponseWriter, r *http.Request) {
+	specLoader := s.opts.SpecLoader
+	if specLoader == nil {
+		writeErrorResponse(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	specs, err := specLoader.GetAllSpecs()
+	if err != nil {
+		writeErrorResponse(w, "Failed to get specs", http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, "Specs retrieved successfully", withHealth(specs))
+}
+
+func (s *Server) handleGetActiveSpecs(w http.ResponseWriter, r *http.Request) {
+	specLoader := s.opts.SpecLoader
+	if specLoader == nil {
+		writeErrorResponse(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	specs, err := specLoader.GetActiveSpecs()
+	if err != nil {
+		writeErrorResponse(w, "Failed to get active specs", http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, "Active specs retrieved successfully", withHealth(specs))
+}
+
+func (s *Server) handleCreateSpec(w http.ResponseWriter, r *http.Request) {
+	specLoader := s.opts.SpecLoader
+	if specLoader == nil {
+		writeErrorResponse(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Limit request body size to 10MB to handle large specs gracefully
+	const maxPayloadSize = 10 << 20 // 10MB
+	r.Body = http.MaxBytesReader(w, r.Body, maxPayloadSize)
+
+	var req ImportSpecRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		// Handle different types of errors gracefully
+		switch {
+		case err.Error() == "http: request body too large":
+			writeErrorResponse(w, "Request payload too large (max 10MB)", http.StatusRequestEntityTooLarge)
+		case strings.Contains(err.Error(), "timeout") || strings.Contains(err.Error(), "deadline"):
+			writeErrorResponse(w, "Request timeout while processing large payload", http.StatusRequestTimeout)
+		case strings.Contains(err.Error(), "connection"):
+			writeErrorResponse(w, "Connection error while reading payload", http.StatusBadRequest)
+		default:
+			writeErrorResponse(w, fmt.Sprintf("Invalid JSON payload: %v", err), http.StatusBadRequest)
+		}
+		return
+	}
+
+	// Validate required fields
+	if req.Name == "" {
+		writeErrorResponse(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+	if req.EndpointPath == "" {
+		writeErrorResponse(w, "Endpoint path is required", http.StatusBadRequest)
+		return
+	}
+	if req.SpecContent == "" {
+		writeErrorResponse(w, "Spec content is required", http.StatusBadRequest)
+		return
+	}
+
+	// Auto-detect format if not provided
+	if req.FileFormat == "" {
+		if strings.HasPrefix(strings.TrimSpace(req.SpecContent), "{") {
+			req.FileFormat = "json"
+		} else {
+			req.FileFormat = "yaml"
+		}
+	}
+
+	// Set default active status
+	if req.Active == nil {
+		active := true
+		req.Active = &active
+	}
+
+	// Convert API key token
+	opts := services.ImportOptions{ExcludeOperations: req.ExcludeOperations}
+	if req.ApiKeyToken != "" {
+		opts.ApiKeyToken = &req.ApiKeyToken
+	}
+
+	// Create spec directly from content
+	if err := specLoader.CreateSpecFromContentWithOptions(req.Name, req.EndpointPath, req.SpecContent, req.FileFormat, opts); err != nil {
+		writeErrorResponse(w, fmt.Sprintf("Failed to create spec: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// If requested as inactive, deactivate it
+	if !*req.Active {
+		specs, err := specLoader.GetAllSpecs()
+		if err == nil {
+			for _, spec := range specs {
+				if spec.Name == req.Name {
+					specLoader.DeactivateSpec(spec.ID)
+					break
+				}
+			}
+		}
+	}
+
+	writeSuccessResponse(w, "Spec imported successfully", map[string]interface{}{
+		"name":          req.Name,
+		"endpoint_path": req.EndpointPath,
+		"active":        *req.Active,
+		"has_api_token": opts.ApiKeyToken != nil,
+	})
+}
+
+func (s *Server) handleGetSpec(w http.ResponseWriter, r *http.Request, id int) {
+	writeErrorResponse(w, "Get spec by ID not implemented yet", http.StatusNotImplemented)
+}
+
+func (s *Server) handleUpdateSpec(w http.ResponseWriter, r *http.Request, id int) {
+	writeErrorResponse(w, "Update spec not implemented yet", http.StatusNotImplemented)
+}
+
+func (s *Server) handleDeleteSpec(w http.ResponseWriter, r *http.Request, id int) {
+	specLoader := s.opts.SpecLoader
+	if specLoader == nil {
+		writeErrorResponse(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := specLoader.DeleteSpec(id); err != nil {
+		writeErrorResponse(w, fmt.Sprintf("Failed to delete spec: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	writeSuccessResponse(w, "Spec deleted successfully; restore it with POST /specs/"+strconv.Itoa(id)+"/restore until it is purged", map[string]int{"id": id})
+}
+
+func (s *Server) handleRestoreSpec(w http.ResponseWriter, r *http.Request, id int) {
+	specLoader := s.opts.SpecLoader
+	if specLoader == nil {
+		writeErrorResponse(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := specLoader.RestoreSpec(id); err != nil {
+		writeErrorResponse(w, fmt.Sprintf("Failed to restore spec: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	writeSuccessResponse(w, "Spec restored successfully", map[string]int{"id": id})
+}
+
+func (s *Server) handleGetDeletedSpecs(w http.ResponseWriter, r *http.Request) {
+	specLoader := s.opts.SpecLoader
+	if specLoader == nil {
+		writeErrorResponse(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	specs, err := specLoader.GetDeletedSpecs()
+	if err != nil {
+		writeErrorResponse(w, "Failed to get deleted specs", http.StatusInternalServerError)
+		return
+	}
+	if specs == nil {
+		specs = []*models.OpenAPISpec{}
+	}
+
+	writeSuccessResponse(w, "Deleted specs retrieved successfully", specs)
+}
+
+func (s *Server) handleActivateSpec(w http.ResponseWriter, r *http.Request, id int) {
+	specLoader := s.opts.SpecLoader
+	if specLoader == nil {
+		writeErrorResponse(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := specLoader.ActivateSpec(id); err != nil {
+		writeErrorResponse(w, fmt.Sprintf("Failed to activate spec: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	writeSuccessResponse(w, "Spec activated successfully", map[string]int{"id": id})
+}
+
+func (s *Server) handleDeactivateSpec(w http.ResponseWriter, r *http.Request, id int) {
+	specLoader := s.opts.SpecLoader
+	if specLoader == nil {
+		writeErrorResponse(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := specLoader.DeactivateSpec(id); err != nil {
+		writeErrorResponse(w, fmt.Sprintf("Failed to deactivate spec: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	writeSuccessResponse(w, "Spec deactivated successfully", map[string]int{"id": id})
+}
+
+func (s *Server) handleUpdateApiKeyToken(w http.ResponseWriter, r *http.Request, id int) {
+	specLoader := s.opts.SpecLoader
+	if specLoader == nil {
+		writeErrorResponse(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		ApiKeyToken *string `json:"api_key_token"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := specLoader.UpdateApiKeyToken(id, req.ApiKeyToken); err != nil {
+		writeErrorResponse(w, fmt.Sprintf("Failed to update API key token: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	writeSuccessResponse(w, "API key token updated successfully", map[string]interface{}{
+		"id":                    id,
+		"api_key_token_updated": true,
+	})
+}
+
+func (s *Server) handleUpdateAliases(w http.ResponseWriter, r *http.Request, id int) {
+	specLoader := s.opts.SpecLoader
+	if specLoader == nil {
+		writeErrorResponse(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		Aliases []string `json:"aliases"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := specLoader.UpdateAliases(id, strings.Join(req.Aliases, ",")); err != nil {
+		writeErrorResponse(w, fmt.Sprintf("Failed to update aliases: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	writeSuccessResponse(w, "Aliases updated successfully", map[string]interface{}{
+		"id":      id,
+		"aliases": req.Aliases,
+	})
+}
+
+func (s *Server) handleUpdateRequireToken(w http.ResponseWriter, r *http.Request, id int) {
+	specLoader := s.opts.SpecLoader
+	if specLoader == nil {
+		writeErrorResponse(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		RequireToken *bool `json:"require_token_to_activate"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RequireToken == nil {
+		writeErrorResponse(w, "Invalid JSON payload: require_token_to_activate is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := specLoader.UpdateRequireToken(id, *req.RequireToken); err != nil {
+		writeErrorResponse(w, fmt.Sprintf("Failed to update require_token_to_activate: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	writeSuccessResponse(w, "Token requirement updated successfully", map[string]interface{}{
+		"id":                        id,
+		"require_token_to_activate": *req.RequireToken,
+	})
+}
+
+func (s *Server) handleUpdateRateLimit(w http.ResponseWriter, r *http.Request, id int) {
+	specLoader := s.opts.SpecLoader
+	if specLoader == nil {
+		writeErrorResponse(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		RateLimit *string `json:"rate_limit"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RateLimit == nil {
+		writeErrorResponse(w, "Invalid JSON payload: rate_limit is required (\"\" uses the default)", http.StatusBadRequest)
+		return
+	}
+
+	if err := specLoader.UpdateRateLimit(id, *req.RateLimit); err != nil {
+		writeErrorResponse(w, fmt.Sprintf("Failed to update rate_limit: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	writeSuccessResponse(w, "Rate limit updated successfully", map[string]interface{}{
+		"id":         id,
+		"rate_limit": *req.RateLimit,
+	})
+}
+
+func (s *Server) handleUpdateToolRules(w http.ResponseWriter, r *http.Request, id int) {
+	specLoader := s.opts.SpecLoader
+	if specLoader == nil {
+		writeErrorResponse(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		ToolRules json.RawMessage `json:"tool_rules"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.ToolRules) == 0 {
+		writeErrorResponse(w, "Invalid JSON payload: tool_rules is required (null exposes all operations)", http.StatusBadRequest)
+		return
+	}
+
+	if err := specLoader.UpdateToolRules(id, string(req.ToolRules)); err != nil {
+		writeErrorResponse(w, fmt.Sprintf("Failed to update tool_rules: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	writeSuccessResponse(w, "Tool rules updated successfully", map[string]interface{}{
+		"id":         id,
+		"tool_rules": req.ToolRules,
+	})
+}
+
+func (s *Server) handleUpdateUpstreamPolicy(w http.ResponseWriter, r *http.Request, id int) {
+	specLoader := s.opts.SpecLoader
+	if specLoader == nil {
+		writeErrorResponse(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		UpstreamPolicy json.RawMessage `json:"upstream_policy"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.UpstreamPolicy) == 0 {
+		writeErrorResponse(w, "Invalid JSON payload: upstream_policy is required (null uses the defaults)", http.StatusBadRequest)
+		return
+	}
+
+	if err := specLoader.UpdateUpstreamPolicy(id, string(req.UpstreamPolicy)); err != nil {
+		writeErrorResponse(w, fmt.Sprintf("Failed to update upstream_policy: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	writeSuccessResponse(w, "Upstream policy updated successfully", map[string]interface{}{
+		"id":              id,
+		"upstream_policy": req.UpstreamPolicy,
+	})
+}
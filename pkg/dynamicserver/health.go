@@ -0,0 +1,179 @@
+package dynamicserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+	"github.com/ubermorgenland/openapi-mcp/pkg/openapi2mcp"
+)
+
+// HealthResponse is the response body of GET /health?format=json
+type HealthResponse struct {
+	Status   string                         `json:"status"` // "ok", or "degraded" when a mounted spec is
+	Specs    []openapi2mcp.SpecHealthStatus `json:"specs"`
+	Disabled map[string]string              `json:"disabled,omitempty"` // endpoint path -> why the spec is mounted without tools
+}
+
+// SpecWithHealth is a spec listed by /specs, with the health of its upstream calls
+type SpecWithHealth struct {
+	*models.OpenAPISpec
+	Health openapi2mcp.SpecHealthStatus `json:"health"`
+}
+
+// SpecAlert is the JSON body posted to Options.AlertWebhook
+type SpecAlert struct {
+	Event       string                       `json:"event"` // spec_degraded, spec_recovered or spec_deactivated
+	Spec        string                       `json:"spec"`
+	Endpoint    string                       `json:"endpoint"`
+	Health      openapi2mcp.SpecHealthStatus `json:"health"`
+	At          time.Time                    `json:"at"`
+	Deactivated bool                         `json:"deactivated,omitempty"`
+}
+
+// handleHealth reports "OK", or the degraded specs, with status 200 either way, since the
+// gateway itself is up. ?format=json returns the health of every spec with upstream calls,
+// and the specs that are disabled until they have credentials.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	response := HealthResponse{Status: "ok", Specs: s.mountedHealth()}
+	for _, m := range s.Mounts() {
+		if m.Disabled == "" {
+			continue
+		}
+		if response.Disabled == nil {
+			response.Disabled = map[string]string{}
+		}
+		response.Disabled[m.Path()] = m.Disabled
+	}
+	var degraded []string
+	for _, h := range response.Specs {
+		if h.State == openapi2mcp.SpecDegraded {
+			response.Status = openapi2mcp.SpecDegraded
+			degraded = append(degraded, h.Endpoint)
+		}
+	}
+	if r.URL.Query().Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	if len(degraded) > 0 {
+		w.Write([]byte("DEGRADED: " + strings.Join(degraded, ", ")))
+		return
+	}
+	w.Write([]byte("OK"))
+}
+
+// mountedHealth returns the health of the mounted specs that had upstream calls
+func (s *Server) mountedHealth() []openapi2mcp.SpecHealthStatus {
+	mounted := map[string]bool{}
+	for _, m := range s.Mounts() {
+		mounted[m.healthKey()] = true
+	}
+	var specs []openapi2mcp.SpecHealthStatus
+	for _, h := range openapi2mcp.DefaultSpecHealth().All() {
+		if mounted[h.Endpoint] {
+			specs = append(specs, h)
+		}
+	}
+	return specs
+}
+
+// withHealth adds the health of their upstream calls to specs listed by /specs
+func withHealth(specs []*models.OpenAPISpec) []SpecWithHealth {
+	listed := make([]SpecWithHealth, 0, len(specs))
+	for _, spec := range specs {
+		listed = append(listed, SpecWithHealth{
+			OpenAPISpec: spec,
+			Health:      openapi2mcp.DefaultSpecHealth().Status(openapi2mcp.EndpointName(nil, spec)),
+		})
+	}
+	return listed
+}
+
+// healthKey is the name the mount's upstream calls are tracked under
+func (m *Mount) healthKey() string {
+	if m.Loaded != nil {
+		return openapi2mcp.EndpointName(m.Loaded.Doc, m.Spec)
+	}
+	return openapi2mcp.EndpointName(nil, m.Spec)
+}
+
+// onSpecHealthChange alerts when a mounted spec becomes degraded or recovers and, with
+// AutoDeactivate, deactivates degraded database specs so agents stop seeing their tools
+func (s *Server) onSpecHealthChange(status openapi2mcp.SpecHealthStatus) {
+	var mount *Mount
+	for _, m := range s.Mounts() {
+		if m.healthKey() == status.Endpoint {
+			mount = m
+			break
+		}
+	}
+	if mount == nil {
+		return
+	}
+	alert := SpecAlert{Event: "spec_recovered", Spec: mount.Title, Endpoint: mount.Endpoint, Health: status, At: time.Now().UTC()}
+	if status.State == openapi2mcp.SpecDegraded {
+		alert.Event = "spec_degraded"
+		log.Printf("Spec %s at /%s is degraded: %d auth and %d connection failures in %d recent calls",
+			mount.Title, mount.Endpoint, status.AuthFailures, status.ConnectionFailures, status.RecentCalls)
+	}
+	// Deactivating reloads the specs, so it must not block the tool call that got here
+	go func() {
+		if alert.Event == "spec_degraded" && s.opts.AutoDeactivate {
+			alert.Deactivated = s.deactivateDegraded(mount, status)
+			if alert.Deactivated {
+				alert.Event = "spec_deactivated"
+			}
+		}
+		s.sendAlert(alert)
+	}()
+}
+
+// deactivateDegraded deactivates the database spec of a degraded mount and reloads
+func (s *Server) deactivateDegraded(m *Mount, status openapi2mcp.SpecHealthStatus) bool {
+	if s.opts.SpecLoader == nil || m.Spec == nil || m.Spec.ID == 0 {
+		return false
+	}
+	if err := s.opts.SpecLoader.DeactivateSpec(m.Spec.ID); err != nil {
+		log.Printf("Failed to deactivate degraded spec %s: %v", m.Spec.Name, err)
+		return false
+	}
+	log.Printf("Deactivated degraded spec %s (id %d, /%s)", m.Spec.Name, m.Spec.ID, m.Endpoint)
+	openapi2mcp.DefaultSpecHealth().Reset(status.Endpoint)
+	if _, err := s.Reload(context.Background()); err != nil {
+		log.Printf("Failed to reload after deactivating %s: %v", m.Spec.Name, err)
+	}
+	return true
+}
+
+// sendAlert posts an alert to the AlertWebhook, if there is one
+func (s *Server) sendAlert(alert SpecAlert) {
+	if s.opts.AlertWebhook == "" {
+		return
+	}
+	body, _ := json.Marshal(alert)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.opts.AlertWebhook, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Failed to send %s alert: %v", alert.Event, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("Failed to send %s alert: %v", alert.Event, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("Alert webhook returned HTTP %d for %s", resp.StatusCode, alert.Event)
+	}
+}
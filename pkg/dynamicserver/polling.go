@@ -0,0 +1,252 @@
+package dynamicserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/metrics"
+)
+
+// minPollInterval guards the database against overly aggressive polling
+const minPollInterval = time.Second
+
+// PollFunc runs one poll; it reports whether specs were reloaded
+type PollFunc func() (reloaded bool, err error)
+
+// PollScheduler runs a poll function at a fixed interval and can be started, stopped
+// and re-timed at runtime
+type PollScheduler struct {
+	poll PollFunc
+
+	mu       sync.Mutex
+	interval time.Duration
+	running  bool
+	stop     chan struct{}
+	done     chan struct{}
+	reset    chan time.Duration
+	status   PollStatus
+}
+
+// PollStatus is the response body of GET /polling
+type PollStatus struct {
+	Running         bool       `json:"running"`
+	IntervalSeconds int        `json:"interval_seconds"`
+	Runs            int64      `json:"runs"`
+	Reloads         int64      `json:"reloads"`
+	LastRunAt       *time.Time `json:"last_run_at,omitempty"`
+	LastDurationMs  int64      `json:"last_duration_ms"`
+	LastReloadAt    *time.Time `json:"last_reload_at,omitempty"`
+	LastError       string     `json:"last_error,omitempty"`
+	LastErrorAt     *time.Time `json:"last_error_at,omitempty"`
+	NextRunAt       *time.Time `json:"next_run_at,omitempty"`
+}
+
+// NewPollScheduler creates a stopped scheduler
+func NewPollScheduler(interval time.Duration, poll PollFunc) *PollScheduler {
+	if interval < minPollInterval {
+		interval = minPollInterval
+	}
+	return &PollScheduler{poll: poll, interval: interval}
+}
+
+// Start starts polling; it is a no-op if the scheduler is already running
+func (s *PollScheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return
+	}
+	s.running = true
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	s.reset = make(chan time.Duration, 1)
+	go s.run(s.interval, s.stop, s.done, s.reset)
+	log.Printf("Starting database polling every %v", s.interval)
+}
+
+// Stop stops polling and waits for a poll in progress to finish
+func (s *PollScheduler) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	close(s.stop)
+	done := s.done
+	s.mu.Unlock()
+
+	<-done
+	log.Printf("Database polling stopped")
+}
+
+// SetInterval changes the polling interval; a running scheduler uses it from the next tick
+func (s *PollScheduler) SetInterval(interval time.Duration) error {
+	if interval < minPollInterval {
+		return fmt.Errorf("polling interval must be at least %v", minPollInterval)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interval = interval
+	if s.running {
+		// Replace a pending change rather than block on it
+		select {
+		case <-s.reset:
+		default:
+		}
+		s.reset <- interval
+	}
+	log.Printf("Database polling interval set to %v", interval)
+	return nil
+}
+
+// Running reports whether the scheduler is polling
+func (s *PollScheduler) Running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running
+}
+
+// Interval returns the current polling interval
+func (s *PollScheduler) Interval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.interval
+}
+
+// Status returns a snapshot of the scheduler state
+func (s *PollScheduler) Status() PollStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := s.status
+	status.Running = s.running
+	status.IntervalSeconds = int(s.interval / time.Second)
+	if !s.running {
+		status.NextRunAt = nil
+	}
+	return status
+}
+
+func (s *PollScheduler) run(interval time.Duration, stop, done chan struct{}, reset chan time.Duration) {
+	defer close(done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	s.setNextRun(interval)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case interval = <-reset:
+			ticker.Reset(interval)
+			s.setNextRun(interval)
+		case <-ticker.C:
+			s.runOnce()
+			s.setNextRun(interval)
+		}
+	}
+}
+
+func (s *PollScheduler) setNextRun(interval time.Duration) {
+	next := time.Now().Add(interval)
+	s.mu.Lock()
+	s.status.NextRunAt = &next
+	s.mu.Unlock()
+}
+
+func (s *PollScheduler) runOnce() {
+	start := time.Now()
+	reloaded, err := s.poll()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Runs++
+	s.status.LastRunAt = &start
+	s.status.LastDurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		s.status.LastError = err.Error()
+		s.status.LastErrorAt = &start
+		return
+	}
+	s.status.LastError = ""
+	if reloaded {
+		s.status.Reloads++
+		s.status.LastReloadAt = &start
+	}
+}
+
+// poll reloads the endpoints if the active specs in the database changed
+func (s *Server) poll() (bool, error) {
+	start := time.Now()
+	result, err := s.Reload(context.Background())
+	metrics.DBPollDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		log.Printf("Database polling error: %v", err)
+		return false, err
+	}
+	if !result.Changed {
+		return false, nil
+	}
+	log.Printf("Automatically reloaded %d API specs: %v", len(result.Mounted), result.Mounted)
+	return true, nil
+}
+
+// PollingUpdateRequest is the request body of PUT /polling
+type PollingUpdateRequest struct {
+	IntervalSeconds *int  `json:"interval_seconds,omitempty"`
+	Enabled         *bool `json:"enabled,omitempty"`
+}
+
+// handlePolling serves the polling management API:
+//
+//	GET  /polling        - scheduler status
+//	PUT  /polling        - change interval_seconds and/or enabled
+//	POST /polling/start  - start polling
+//	POST /polling/stop   - stop polling
+func (s *Server) handlePolling(w http.ResponseWriter, r *http.Request) {
+	pollScheduler := s.scheduler
+	if pollScheduler == nil {
+		writeErrorResponse(w, "Database polling is only available in database mode", http.StatusNotFound)
+		return
+	}
+
+	action := strings.Trim(strings.TrimPrefix(r.URL.Path, "/polling"), "/")
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+	case action == "" && r.Method == http.MethodPut:
+		var req PollingUpdateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeErrorResponse(w, "Invalid JSON payload", http.StatusBadRequest)
+			return
+		}
+		if req.IntervalSeconds != nil {
+			if err := pollScheduler.SetInterval(time.Duration(*req.IntervalSeconds) * time.Second); err != nil {
+				writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if req.Enabled != nil {
+			if *req.Enabled {
+				pollScheduler.Start()
+			} else {
+				pollScheduler.Stop()
+			}
+		}
+	case action == "start" && r.Method == http.MethodPost:
+		pollScheduler.Start()
+	case action == "stop" && r.Method == http.MethodPost:
+		pollScheduler.Stop()
+	default:
+		writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pollScheduler.Status())
+}
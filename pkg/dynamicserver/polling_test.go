@@ -0,0 +1,99 @@
+package dynamicserver
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingPoll returns a poll function reporting each run on a channel, and failing with
+// err when it is set
+func countingPoll(err error) (PollFunc, chan struct{}) {
+	runs := make(chan struct{}, 10)
+	return func() (bool, error) {
+		runs <- struct{}{}
+		return err == nil, err
+	}, runs
+}
+
+func waitForRun(t *testing.T, runs chan struct{}, within time.Duration) {
+	t.Helper()
+	select {
+	case <-runs:
+	case <-time.After(within):
+		t.Fatalf("expected a poll within %v", within)
+	}
+}
+
+func TestPollScheduler_StartStop(t *testing.T) {
+	poll, runs := countingPoll(nil)
+	s := NewPollScheduler(time.Millisecond, poll)
+	if s.Interval() != minPollInterval {
+		t.Errorf("expected the interval to be raised to %v, got %v", minPollInterval, s.Interval())
+	}
+	if s.Running() || s.Status().NextRunAt != nil {
+		t.Fatalf("expected a new scheduler to be stopped")
+	}
+
+	s.Start()
+	s.Start() // no-op while running
+	waitForRun(t, runs, 3*time.Second)
+	s.Stop()
+
+	status := s.Status()
+	if s.Running() || status.Running || status.NextRunAt != nil {
+		t.Errorf("expected the scheduler to be stopped, got %+v", status)
+	}
+	if status.Runs < 1 || status.Reloads < 1 || status.LastRunAt == nil || status.LastReloadAt == nil {
+		t.Errorf("expected the run and reload to be counted, got %+v", status)
+	}
+	s.Stop() // no-op while stopped
+
+	select {
+	case <-runs:
+		t.Errorf("expected no polls after Stop")
+	case <-time.After(1500 * time.Millisecond):
+	}
+}
+
+func TestPollScheduler_RecordsErrors(t *testing.T) {
+	poll, runs := countingPoll(errors.New("connection refused"))
+	s := NewPollScheduler(time.Second, poll)
+	s.Start()
+	waitForRun(t, runs, 3*time.Second)
+	s.Stop()
+
+	status := s.Status()
+	if status.LastError != "connection refused" || status.LastErrorAt == nil || status.Reloads != 0 {
+		t.Errorf("expected the failed poll to be reported, got %+v", status)
+	}
+}
+
+func TestPollScheduler_SetInterval(t *testing.T) {
+	var polls atomic.Int32
+	runs := make(chan struct{}, 10)
+	s := NewPollScheduler(time.Hour, func() (bool, error) {
+		polls.Add(1)
+		runs <- struct{}{}
+		return false, nil
+	})
+
+	if err := s.SetInterval(time.Millisecond); err == nil {
+		t.Errorf("expected an interval below %v to be rejected", minPollInterval)
+	}
+	s.Start()
+	defer s.Stop()
+
+	// A running scheduler switches to the new interval without waiting for the old one
+	if err := s.SetInterval(time.Second); err != nil {
+		t.Fatalf("SetInterval: %v", err)
+	}
+	if s.Interval() != time.Second || s.Status().IntervalSeconds != 1 {
+		t.Errorf("expected a 1s interval, got %+v", s.Status())
+	}
+	waitForRun(t, runs, 3*time.Second)
+	if polls.Load() < 1 || s.Status().Reloads != 0 {
+		t.Errorf("expected a poll without a reload, got %+v", s.Status())
+	}
+}
@@ -0,0 +1,57 @@
+package dynamicserver
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+	"github.com/ubermorgenland/openapi-mcp/pkg/openapi2mcp"
+)
+
+// sdkHandler serves GET /{endpoint}/sdk?lang=ts|python: a generated client wrapping the
+// endpoint's MCP tools, for calling them from application code. The client points at
+// publicURL when it is set.
+func sdkHandler(srv *server.MCPServer, title, endpoint, publicURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		lang := r.URL.Query().Get("lang")
+		if lang == "" {
+			lang = "ts"
+		}
+		baseURL := strings.TrimRight(publicURL, "/")
+		if baseURL == "" {
+			baseURL = publicBaseURL(r)
+		}
+		source, err := openapi2mcp.GenerateClientSDK(srv, title, lang, baseURL+"/"+endpoint)
+		if err != nil {
+			writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		filename := "client.ts"
+		if lang == "python" || lang == "py" {
+			filename = "client.py"
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", `inline; filename="`+endpoint+"_"+filename+`"`)
+		w.Write([]byte(source))
+	}
+}
+
+// publicBaseURL is the scheme and host clients used to reach this server, honoring proxy headers
+func publicBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = strings.TrimSpace(strings.Split(proto, ",")[0])
+	}
+	host := r.Host
+	if fwd := r.Header.Get("X-Forwarded-Host"); fwd != "" {
+		host = strings.TrimSpace(strings.Split(fwd, ",")[0])
+	}
+	return scheme + "://" + host
+}
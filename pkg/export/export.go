@@ -0,0 +1,371 @@
+// Package export sends analytics and audit events, such as finished tool calls, to external
+// data platforms: an HTTP collector, a Kafka topic, S3 files or stdout. Each sink buffers events in
+// memory, sends them in batches and retries failed batches with backoff, so a slow or
+// unavailable platform never delays tool calls.
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Event types.
+const (
+	EventToolCall = "tool_call" // a finished tool call; Data is an openapi2mcp.CallEvent
+)
+
+// Defaults of sink settings left out of the configuration.
+const (
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = 5 * time.Second
+	DefaultBufferSize    = 10000
+	DefaultMaxRetries    = 5
+	DefaultTimeout       = 10 * time.Second
+)
+
+// Event is one analytics or audit event.
+type Event struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	Host string    `json:"host,omitempty"` // the server that emitted it
+	Key  string    `json:"-"`              // partition key, e.g. the endpoint; the Kafka record key
+	Data any       `json:"data"`
+}
+
+// Sink sends batches of events to one destination.
+type Sink interface {
+	Send(ctx context.Context, events []Event) error
+}
+
+// permanentError is a failure that retrying cannot fix, such as a rejected request.
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error as not worth retrying: the batch is dropped at once.
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+// Config is the declarative exporter configuration, loaded by LoadConfig.
+type Config struct {
+	Sinks []SinkConfig `json:"sinks" yaml:"sinks"`
+}
+
+// SinkConfig configures one sink. Durations are Go durations such as "5s".
+type SinkConfig struct {
+	Name          string   `json:"name" yaml:"name"`                     // shown in logs and /analytics; defaults to the type
+	Type          string   `json:"type" yaml:"type"`                     // http, kafka, s3 or stdout
+	Events        []string `json:"events" yaml:"events"`                 // event types to send; all when empty
+	BatchSize     int      `json:"batch_size" yaml:"batch_size"`         // events per batch
+	FlushInterval string   `json:"flush_interval" yaml:"flush_interval"` // longest time an event waits for its batch to fill
+	BufferSize    int      `json:"buffer_size" yaml:"buffer_size"`       // events buffered while the sink is slow; newer events are dropped beyond it
+	MaxRetries    int      `json:"max_retries" yaml:"max_retries"`       // retries of a failed batch before it is dropped
+	Timeout       string   `json:"timeout" yaml:"timeout"`               // timeout of each request
+
+	// http and kafka
+	URL     string            `json:"url" yaml:"url"`         // collector URL, or the Kafka REST Proxy base URL
+	Headers map[string]string `json:"headers" yaml:"headers"` // extra request headers, e.g. Authorization
+	Format  string            `json:"format" yaml:"format"`   // http: json (an array, the default) or ndjson
+
+	// kafka
+	Topic string `json:"topic" yaml:"topic"`
+
+	// s3
+	Bucket   string `json:"bucket" yaml:"bucket"`
+	Prefix   string `json:"prefix" yaml:"prefix"`     // key prefix, e.g. "mcp/usage/"
+	Region   string `json:"region" yaml:"region"`     // defaults to AWS_REGION or AWS_DEFAULT_REGION
+	Endpoint string `json:"endpoint" yaml:"endpoint"` // S3-compatible endpoint, e.g. MinIO; uses path-style URLs
+	Gzip     bool   `json:"gzip" yaml:"gzip"`         // compress files (.jsonl.gz)
+
+	// Sink is a sink built by the caller, such as a database table, used instead of the
+	// sink of Type; Type then only names it.
+	Sink Sink `json:"-" yaml:"-"`
+}
+
+// LoadConfig reads the exporter configuration from a YAML or JSON file. ${VAR} references
+// are replaced with environment variables, so credentials need not be in the file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export config: %w", err)
+	}
+	data = []byte(os.ExpandEnv(string(data)))
+	var cfg Config
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = json.Unmarshal(data, &cfg)
+	} else {
+		err = yaml.Unmarshal(data, &cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse export config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// SinkStats are the counters of one sink, shown in /analytics.
+type SinkStats struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Sent      int64  `json:"sent"`
+	Failed    int64  `json:"failed"`  // dropped after their batch failed every retry
+	Dropped   int64  `json:"dropped"` // dropped because the buffer was full
+	Queued    int    `json:"queued"`
+	LastError string `json:"last_error,omitempty"` // the error of the last batch, if it failed
+}
+
+// Exporter fans events out to its sinks.
+type Exporter struct {
+	host   string
+	queues []*sinkQueue
+}
+
+// New creates the sinks of cfg and starts sending events to them. It fails on invalid
+// sink settings, before any sink starts.
+func New(cfg *Config) (*Exporter, error) {
+	host, _ := os.Hostname()
+	e := &Exporter{host: host}
+	for i, sc := range cfg.Sinks {
+		q, err := newSinkQueue(sc)
+		if err != nil {
+			return nil, fmt.Errorf("sink %d (%s): %w", i+1, sc.Type, err)
+		}
+		e.queues = append(e.queues, q)
+	}
+	for _, q := range e.queues {
+		go q.run()
+	}
+	return e, nil
+}
+
+// Emit queues an event for the sinks that accept its type. It never blocks: an event that
+// does not fit a sink's buffer is dropped and counted.
+func (e *Exporter) Emit(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	if event.Host == "" {
+		event.Host = e.host
+	}
+	for _, q := range e.queues {
+		if len(q.events) > 0 && !q.events[event.Type] {
+			continue
+		}
+		select {
+		case q.queue <- event:
+		default:
+			if q.dropped.Add(1)%1000 == 1 {
+				log.Printf("[WARN] Export sink %s is falling behind: dropping events", q.name)
+			}
+		}
+	}
+}
+
+// Close sends the buffered events and stops the sinks. Events still unsent when ctx ends
+// are dropped.
+func (e *Exporter) Close(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, q := range e.queues {
+		wg.Add(1)
+		go func(q *sinkQueue) {
+			defer wg.Done()
+			close(q.stop)
+			select {
+			case <-q.done:
+			case <-ctx.Done():
+				q.cancel()
+				<-q.done
+			}
+		}(q)
+	}
+	wg.Wait()
+}
+
+// Stats returns the counters of each sink.
+func (e *Exporter) Stats() []SinkStats {
+	stats := make([]SinkStats, 0, len(e.queues))
+	for _, q := range e.queues {
+		s := SinkStats{Name: q.name, Type: q.typ, Sent: q.sent.Load(), Failed: q.failed.Load(), Dropped: q.dropped.Load(), Queued: len(q.queue)}
+		if v, ok := q.lastError.Load().(string); ok {
+			s.LastError = v
+		}
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+// Sinks returns the names of the sinks.
+func (e *Exporter) Sinks() []string {
+	names := make([]string, 0, len(e.queues))
+	for _, q := range e.queues {
+		names = append(names, q.name)
+	}
+	return names
+}
+
+// sinkQueue buffers the events of one sink and sends them in batches from its own goroutine.
+type sinkQueue struct {
+	name, typ     string
+	sink          Sink
+	events        map[string]bool
+	batchSize     int
+	flushInterval time.Duration
+	maxRetries    int
+	timeout       time.Duration
+
+	queue  chan Event
+	stop   chan struct{}
+	done   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	sent, failed, dropped atomic.Int64
+	lastError             atomic.Value
+}
+
+func newSinkQueue(sc SinkConfig) (*sinkQueue, error) {
+	q := &sinkQueue{
+		name:          sc.Name,
+		typ:           sc.Type,
+		batchSize:     sc.BatchSize,
+		flushInterval: DefaultFlushInterval,
+		maxRetries:    sc.MaxRetries,
+		timeout:       DefaultTimeout,
+	}
+	if q.name == "" {
+		q.name = sc.Type
+	}
+	if q.batchSize <= 0 {
+		q.batchSize = DefaultBatchSize
+	}
+	if q.maxRetries <= 0 {
+		q.maxRetries = DefaultMaxRetries
+	}
+	bufferSize := sc.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+	var err error
+	if sc.FlushInterval != "" {
+		if q.flushInterval, err = time.ParseDuration(sc.FlushInterval); err != nil || q.flushInterval <= 0 {
+			return nil, fmt.Errorf("invalid flush_interval %q", sc.FlushInterval)
+		}
+	}
+	if sc.Timeout != "" {
+		if q.timeout, err = time.ParseDuration(sc.Timeout); err != nil || q.timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout %q", sc.Timeout)
+		}
+	}
+	if len(sc.Events) > 0 {
+		q.events = map[string]bool{}
+		for _, t := range sc.Events {
+			q.events[t] = true
+		}
+	}
+
+	switch {
+	case sc.Sink != nil:
+		q.sink = sc.Sink
+	case sc.Type == "http":
+		q.sink, err = NewHTTPSink(sc)
+	case sc.Type == "kafka":
+		q.sink, err = NewKafkaSink(sc)
+	case sc.Type == "s3":
+		q.sink, err = NewS3Sink(sc)
+	case sc.Type == "stdout":
+		q.sink = &WriterSink{W: os.Stdout}
+	default:
+		err = fmt.Errorf("unknown sink type %q: use http, kafka, s3 or stdout", sc.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	q.queue = make(chan Event, bufferSize)
+	q.stop = make(chan struct{})
+	q.done = make(chan struct{})
+	q.ctx, q.cancel = context.WithCancel(context.Background())
+	return q, nil
+}
+
+// run collects batches until the queue is stopped, then sends what is left.
+func (q *sinkQueue) run() {
+	defer close(q.done)
+	defer q.cancel()
+	ticker := time.NewTicker(q.flushInterval)
+	defer ticker.Stop()
+	batch := make([]Event, 0, q.batchSize)
+	for {
+		select {
+		case event := <-q.queue:
+			batch = append(batch, event)
+			if len(batch) >= q.batchSize {
+				q.send(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				q.send(batch)
+				batch = batch[:0]
+			}
+		case <-q.stop:
+			for {
+				select {
+				case event := <-q.queue:
+					batch = append(batch, event)
+					if len(batch) >= q.batchSize {
+						q.send(batch)
+						batch = batch[:0]
+					}
+				default:
+					if len(batch) > 0 {
+						q.send(batch)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// send sends a batch, retrying with exponential backoff and jitter. A batch that fails
+// every retry, or fails permanently, is dropped and counted.
+func (q *sinkQueue) send(batch []Event) {
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(q.ctx, q.timeout)
+		err := q.sink.Send(ctx, batch)
+		cancel()
+		if err == nil {
+			q.sent.Add(int64(len(batch)))
+			q.lastError.Store("")
+			return
+		}
+		q.lastError.Store(err.Error())
+		var permanent permanentError
+		if errors.As(err, &permanent) || attempt >= q.maxRetries || q.ctx.Err() != nil {
+			q.failed.Add(int64(len(batch)))
+			log.Printf("[WARN] Export sink %s dropped %d events: %v", q.name, len(batch), err)
+			return
+		}
+		select {
+		case <-time.After(backoff + time.Duration(rand.Int63n(int64(backoff/2)))):
+		case <-q.ctx.Done():
+		}
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
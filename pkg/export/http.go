@@ -0,0 +1,84 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// HTTPSink posts each batch to a collector URL, as a JSON array or as JSON Lines.
+type HTTPSink struct {
+	URL     string
+	Headers map[string]string
+	NDJSON  bool
+	Client  *http.Client
+}
+
+// NewHTTPSink configures an http sink.
+func NewHTTPSink(sc SinkConfig) (*HTTPSink, error) {
+	if sc.URL == "" {
+		return nil, fmt.Errorf("http sink requires a url")
+	}
+	switch sc.Format {
+	case "", "json", "ndjson":
+	default:
+		return nil, fmt.Errorf("invalid format %q: use json or ndjson", sc.Format)
+	}
+	return &HTTPSink{URL: sc.URL, Headers: sc.Headers, NDJSON: sc.Format == "ndjson", Client: &http.Client{}}, nil
+}
+
+// Send posts a batch.
+func (s *HTTPSink) Send(ctx context.Context, events []Event) error {
+	var body []byte
+	contentType := "application/json"
+	if s.NDJSON {
+		body, contentType = jsonLines(events), "application/x-ndjson"
+	} else {
+		var err error
+		if body, err = json.Marshal(events); err != nil {
+			return Permanent(err)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range s.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return statusError(resp)
+}
+
+// jsonLines encodes events as JSON Lines.
+func jsonLines(events []Event) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range events {
+		enc.Encode(e)
+	}
+	return buf.Bytes()
+}
+
+// statusError returns nil for 2xx responses. Timeouts, rate limits and server errors are
+// retried; other client errors are permanent.
+func statusError(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err := fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	if resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return err
+	}
+	return Permanent(err)
+}
@@ -0,0 +1,96 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testEvents = []Event{
+	{Type: "tool_call", Time: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Key: "pets", Data: map[string]any{"tool": "listPets"}},
+	{Type: "tool_call", Time: time.Date(2026, 1, 2, 3, 4, 6, 0, time.UTC), Key: "pets", Data: map[string]any{"tool": "getPet"}},
+}
+
+func TestHTTPSink_Send(t *testing.T) {
+	for _, format := range []string{"json", "ndjson"} {
+		t.Run(format, func(t *testing.T) {
+			var got []map[string]any
+			collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer collector-token" {
+					http.Error(w, "unauthorized", http.StatusUnauthorized)
+					return
+				}
+				body, _ := io.ReadAll(r.Body)
+				if format == "json" {
+					if r.Header.Get("Content-Type") != "application/json" {
+						t.Errorf("expected application/json, got %q", r.Header.Get("Content-Type"))
+					}
+					json.Unmarshal(body, &got)
+					return
+				}
+				if r.Header.Get("Content-Type") != "application/x-ndjson" {
+					t.Errorf("expected application/x-ndjson, got %q", r.Header.Get("Content-Type"))
+				}
+				for _, line := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+					var e map[string]any
+					json.Unmarshal([]byte(line), &e)
+					got = append(got, e)
+				}
+			}))
+			defer collector.Close()
+
+			sink, err := NewHTTPSink(SinkConfig{URL: collector.URL, Format: format, Headers: map[string]string{"Authorization": "Bearer collector-token"}})
+			if err != nil {
+				t.Fatalf("NewHTTPSink: %v", err)
+			}
+			if err := sink.Send(context.Background(), testEvents); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+			if len(got) != 2 || got[1]["data"].(map[string]any)["tool"] != "getPet" {
+				t.Errorf("expected both events, got %v", got)
+			}
+		})
+	}
+}
+
+func TestHTTPSink_StatusErrors(t *testing.T) {
+	tests := []struct {
+		status    int
+		permanent bool
+	}{
+		{http.StatusBadRequest, true},
+		{http.StatusUnauthorized, true},
+		{http.StatusTooManyRequests, false},
+		{http.StatusServiceUnavailable, false},
+	}
+	for _, tt := range tests {
+		collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "rejected", tt.status)
+		}))
+		sink, _ := NewHTTPSink(SinkConfig{URL: collector.URL})
+		err := sink.Send(context.Background(), testEvents)
+		collector.Close()
+
+		if err == nil || !strings.Contains(err.Error(), "rejected") {
+			t.Errorf("HTTP %d: expected an error with the response body, got %v", tt.status, err)
+		}
+		if permanent := errors.As(err, &permanentError{}); permanent != tt.permanent {
+			t.Errorf("HTTP %d: expected permanent %v, got %v", tt.status, tt.permanent, permanent)
+		}
+	}
+}
+
+func TestNewHTTPSink_Invalid(t *testing.T) {
+	if _, err := NewHTTPSink(SinkConfig{}); err == nil {
+		t.Errorf("expected a sink without a url to be rejected")
+	}
+	if _, err := NewHTTPSink(SinkConfig{URL: "http://collector", Format: "xml"}); err == nil {
+		t.Errorf("expected an unknown format to be rejected")
+	}
+}
@@ -0,0 +1,79 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// KafkaSink produces each batch to a Kafka topic through the Confluent REST Proxy (v2 API),
+// so no Kafka client library is needed. Records are keyed by the event key, e.g. the
+// endpoint, which keeps the events of an API in one partition.
+type KafkaSink struct {
+	URL     string // REST Proxy base URL, e.g. http://kafka-rest:8082
+	Topic   string
+	Headers map[string]string
+	Client  *http.Client
+}
+
+// NewKafkaSink configures a kafka sink.
+func NewKafkaSink(sc SinkConfig) (*KafkaSink, error) {
+	if sc.URL == "" || sc.Topic == "" {
+		return nil, fmt.Errorf("kafka sink requires the url of a Kafka REST Proxy and a topic")
+	}
+	return &KafkaSink{URL: strings.TrimRight(sc.URL, "/"), Topic: sc.Topic, Headers: sc.Headers, Client: &http.Client{}}, nil
+}
+
+type kafkaRecord struct {
+	Key   string `json:"key,omitempty"`
+	Value Event  `json:"value"`
+}
+
+// Send produces a batch. A batch some of whose records fail is retried as a whole, so
+// consumers may see duplicates.
+func (s *KafkaSink) Send(ctx context.Context, events []Event) error {
+	records := make([]kafkaRecord, len(events))
+	for i, e := range events {
+		records[i] = kafkaRecord{Key: e.Key, Value: e}
+	}
+	body, err := json.Marshal(map[string]any{"records": records})
+	if err != nil {
+		return Permanent(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL+"/topics/"+url.PathEscape(s.Topic), bytes.NewReader(body))
+	if err != nil {
+		return Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+	for k, v := range s.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return statusError(resp)
+	}
+	var out struct {
+		Offsets []struct {
+			ErrorCode *int   `json:"error_code"`
+			Error     string `json:"error"`
+		} `json:"offsets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return fmt.Errorf("invalid REST Proxy response: %w", err)
+	}
+	for _, o := range out.Offsets {
+		if o.ErrorCode != nil || o.Error != "" {
+			return fmt.Errorf("failed to produce to %s: %s", s.Topic, o.Error)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestKafkaSink_Send(t *testing.T) {
+	var records []map[string]any
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/topics/mcp.usage" {
+			http.Error(w, "unexpected request "+r.Method+" "+r.URL.Path, http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Content-Type") != "application/vnd.kafka.json.v2+json" {
+			t.Errorf("expected the REST Proxy JSON content type, got %q", r.Header.Get("Content-Type"))
+		}
+		var body struct {
+			Records []map[string]any `json:"records"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		records = body.Records
+		w.Write([]byte(`{"offsets":[{"partition":0,"offset":1},{"partition":0,"offset":2}]}`))
+	}))
+	defer proxy.Close()
+
+	sink, err := NewKafkaSink(SinkConfig{URL: proxy.URL + "/", Topic: "mcp.usage"})
+	if err != nil {
+		t.Fatalf("NewKafkaSink: %v", err)
+	}
+	if err := sink.Send(context.Background(), testEvents); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if len(records) != 2 || records[0]["key"] != "pets" || records[0]["value"].(map[string]any)["type"] != "tool_call" {
+		t.Errorf("expected the events keyed by their key, got %v", records)
+	}
+}
+
+func TestKafkaSink_RecordErrors(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"offsets":[{"partition":0,"offset":1},{"error_code":50003,"error":"broker not available"}]}`))
+	}))
+	defer proxy.Close()
+
+	sink, _ := NewKafkaSink(SinkConfig{URL: proxy.URL, Topic: "mcp.usage"})
+	err := sink.Send(context.Background(), testEvents)
+	if err == nil || !strings.Contains(err.Error(), "broker not available") {
+		t.Errorf("expected the failed record to fail the batch, got %v", err)
+	}
+}
+
+func TestNewKafkaSink_Invalid(t *testing.T) {
+	if _, err := NewKafkaSink(SinkConfig{URL: "http://kafka-rest:8082"}); err == nil {
+		t.Errorf("expected a sink without a topic to be rejected")
+	}
+}
@@ -0,0 +1,110 @@
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/awssig"
+)
+
+// S3Sink writes each batch to a new JSON Lines file in an S3 bucket, under
+// <prefix><yyyy>/<mm>/<dd>/, where data platforms can load it. Requests are signed with AWS
+// Signature Version 4; credentials come from the standard AWS_* variables.
+type S3Sink struct {
+	Bucket          string
+	Prefix          string
+	Region          string
+	Endpoint        string // S3-compatible endpoint with path-style URLs; empty for AWS
+	Gzip            bool
+	AccessKeyID     string // AWS_ACCESS_KEY_ID
+	SecretAccessKey string // AWS_SECRET_ACCESS_KEY
+	SessionToken    string // AWS_SESSION_TOKEN, optional
+	Client          *http.Client
+
+	host string
+}
+
+// NewS3Sink configures an s3 sink.
+func NewS3Sink(sc SinkConfig) (*S3Sink, error) {
+	region := sc.Region
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	s := &S3Sink{
+		Bucket:          sc.Bucket,
+		Prefix:          sc.Prefix,
+		Region:          region,
+		Endpoint:        strings.TrimRight(sc.Endpoint, "/"),
+		Gzip:            sc.Gzip,
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		Client:          &http.Client{},
+	}
+	if s.Bucket == "" || s.Region == "" || s.AccessKeyID == "" || s.SecretAccessKey == "" {
+		return nil, fmt.Errorf("s3 sink requires a bucket, a region and AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	s.host, _ = os.Hostname()
+	if s.host == "" {
+		s.host = "openapi-mcp"
+	}
+	return s, nil
+}
+
+// Send uploads a batch as one file. A retried batch keeps its name, so it is not stored twice.
+func (s *S3Sink) Send(ctx context.Context, events []Event) error {
+	body := jsonLines(events)
+	name := s.objectKey(events[0].Time, body)
+	if s.Gzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(body)
+		zw.Close()
+		body, name = buf.Bytes(), name+".gz"
+	}
+
+	url := "https://" + s.Bucket + ".s3." + s.Region + ".amazonaws.com/" + name
+	if s.Endpoint != "" {
+		url = s.Endpoint + "/" + s.Bucket + "/" + name
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	s.sign(req, body, time.Now().UTC())
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return statusError(resp)
+}
+
+// objectKey names the file of a batch by the time of its first event, the host and a hash
+// of its content, so files of several servers sort by time and never collide.
+func (s *S3Sink) objectKey(first time.Time, body []byte) string {
+	first = first.UTC()
+	return fmt.Sprintf("%s%s/%s-%s-%s.jsonl", s.Prefix, first.Format("2006/01/02"), first.Format("20060102T150405Z"), s.host, awssig.SHA256Hex(body)[:12])
+}
+
+// sign adds an AWS Signature Version 4 Authorization header for the s3 service.
+func (s *S3Sink) sign(req *http.Request, payload []byte, now time.Time) {
+	awssig.Signer{
+		AccessKeyID:     s.AccessKeyID,
+		SecretAccessKey: s.SecretAccessKey,
+		SessionToken:    s.SessionToken,
+		Region:          s.Region,
+		Service:         "s3",
+		ContentSHA256:   true,
+	}.Sign(req, payload, now)
+}
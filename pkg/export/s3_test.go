@@ -0,0 +1,81 @@
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestS3Sink_Send(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+
+	objects := map[string][]byte{}
+	bucket := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if r.Method != http.MethodPut || !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") ||
+			!strings.Contains(auth, "/eu-west-1/s3/aws4_request") || r.Header.Get("X-Amz-Content-Sha256") == "" {
+			http.Error(w, "AccessDenied", http.StatusForbidden)
+			return
+		}
+		objects[r.URL.Path], _ = io.ReadAll(r.Body)
+	}))
+	defer bucket.Close()
+
+	for _, gz := range []bool{false, true} {
+		sink, err := NewS3Sink(SinkConfig{Bucket: "analytics", Prefix: "mcp/", Region: "eu-west-1", Endpoint: bucket.URL + "/", Gzip: gz})
+		if err != nil {
+			t.Fatalf("NewS3Sink: %v", err)
+		}
+		sink.host = "host-1"
+		if err := sink.Send(context.Background(), testEvents); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	if len(objects) != 2 {
+		t.Fatalf("expected a plain and a gzipped file, got %v", objects)
+	}
+	for path, body := range objects {
+		if !strings.HasPrefix(path, "/analytics/mcp/2026/01/02/20260102T030405Z-host-1-") {
+			t.Errorf("expected the file under the bucket, prefix and date of the first event, got %s", path)
+		}
+		if strings.HasSuffix(path, ".jsonl.gz") {
+			zr, err := gzip.NewReader(bytes.NewReader(body))
+			if err != nil {
+				t.Fatalf("expected a gzipped file: %v", err)
+			}
+			body, _ = io.ReadAll(zr)
+		} else if !strings.HasSuffix(path, ".jsonl") {
+			t.Errorf("expected a .jsonl file, got %s", path)
+		}
+		if !bytes.Equal(body, jsonLines(testEvents)) {
+			t.Errorf("expected the events as JSON Lines in %s, got %s", path, body)
+		}
+	}
+}
+
+func TestS3Sink_ObjectKeyIsStable(t *testing.T) {
+	sink := &S3Sink{Prefix: "mcp/", host: "host-1"}
+	body := jsonLines(testEvents)
+	if sink.objectKey(testEvents[0].Time, body) != sink.objectKey(testEvents[0].Time, body) {
+		t.Errorf("expected a retried batch to keep its file name")
+	}
+	if sink.objectKey(testEvents[0].Time, body) == sink.objectKey(testEvents[0].Time, jsonLines(testEvents[:1])) {
+		t.Errorf("expected batches with other events to get other file names")
+	}
+}
+
+func TestNewS3Sink_RequiresCredentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	if _, err := NewS3Sink(SinkConfig{Bucket: "analytics", Region: "eu-west-1"}); err == nil {
+		t.Errorf("expected a sink without credentials to be rejected")
+	}
+}
@@ -0,0 +1,21 @@
+package export
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// WriterSink writes each batch to W as JSON Lines, e.g. to stdout for a log collector.
+type WriterSink struct {
+	W  io.Writer
+	mu sync.Mutex
+}
+
+// Send writes a batch.
+func (s *WriterSink) Send(ctx context.Context, events []Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.W.Write(jsonLines(events))
+	return err
+}
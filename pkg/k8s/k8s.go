@@ -0,0 +1,307 @@
+// Package k8s discovers OpenAPI specs published by in-cluster APIs. Services and Ingresses
+// annotated with openapi-mcp.io/spec-url are turned into Targets that the server imports
+// as mounted MCP endpoints. It talks to the Kubernetes API over plain HTTPS with the pod's
+// service account, so it needs no client library.
+package k8s
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Annotations read from Services and Ingresses.
+const (
+	AnnotationSpecURL     = "openapi-mcp.io/spec-url"      // absolute URL, or a path on the Service/Ingress
+	AnnotationEndpoint    = "openapi-mcp.io/endpoint"      // endpoint path; defaults to <namespace>-<name>
+	AnnotationServerURL   = "openapi-mcp.io/server-url"    // base URL for API calls; defaults to the Service/Ingress address
+	AnnotationPort        = "openapi-mcp.io/port"          // Service port name or number; defaults to the first port
+	AnnotationAPIKeyToken = "openapi-mcp.io/api-key-token" // API key token, preferably a vault:// or aws-sm:// reference
+	AnnotationEnabled     = "openapi-mcp.io/enabled"       // "false" keeps the spec imported but inactive
+)
+
+// serviceAccountDir holds the credentials Kubernetes mounts into every pod.
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// Target is an API announced by an annotated Service or Ingress.
+type Target struct {
+	Kind        string // "Service" or "Ingress"
+	Namespace   string
+	Name        string
+	SpecURL     string // resolved URL of the spec
+	Endpoint    string // endpoint path to mount the API at
+	BaseURL     string // address of the Service or Ingress
+	ServerURL   string // base URL for API calls from the server-url annotation; empty if not set
+	APIKeyToken string
+	Enabled     bool
+}
+
+// ID identifies the object a target comes from, e.g. "Service default/petstore".
+func (t Target) ID() string {
+	return t.Kind + " " + t.Namespace + "/" + t.Name
+}
+
+// Client lists annotated objects from the Kubernetes API.
+type Client struct {
+	Host      string // API server URL, e.g. https://10.0.0.1:443
+	TokenFile string // service account token, re-read on every request as it is rotated
+	Namespace string // namespace to watch; empty for all namespaces
+	HTTP      *http.Client
+}
+
+// NewInClusterClient configures a client from the pod's service account, watching
+// MCP_KUBERNETES_NAMESPACE or, when it is not set, all namespaces.
+func NewInClusterClient() (*Client, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a Kubernetes cluster: KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT are not set")
+	}
+	caCert, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the cluster CA certificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("invalid cluster CA certificate in %s/ca.crt", serviceAccountDir)
+	}
+	return &Client{
+		Host:      "https://" + net.JoinHostPort(host, port),
+		TokenFile: serviceAccountDir + "/token",
+		Namespace: os.Getenv("MCP_KUBERNETES_NAMESPACE"),
+		HTTP: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+		},
+	}, nil
+}
+
+// Targets lists the annotated Services and Ingresses, sorted by namespace and name.
+// Objects whose annotations cannot be resolved to a spec URL are skipped with an error in skipped.
+func (c *Client) Targets(ctx context.Context) (targets []Target, skipped []error, err error) {
+	var services objectList[serviceSpec]
+	if err := c.list(ctx, "/api/v1", "services", &services); err != nil {
+		return nil, nil, err
+	}
+	for _, svc := range services.Items {
+		if t, ok, err := serviceTarget(svc); err != nil {
+			skipped = append(skipped, err)
+		} else if ok {
+			targets = append(targets, t)
+		}
+	}
+
+	var ingresses objectList[ingressSpec]
+	if err := c.list(ctx, "/apis/networking.k8s.io/v1", "ingresses", &ingresses); err != nil {
+		return nil, nil, err
+	}
+	for _, ing := range ingresses.Items {
+		if t, ok, err := ingressTarget(ing); err != nil {
+			skipped = append(skipped, err)
+		} else if ok {
+			targets = append(targets, t)
+		}
+	}
+
+	sort.SliceStable(targets, func(i, j int) bool {
+		if targets[i].Namespace != targets[j].Namespace {
+			return targets[i].Namespace < targets[j].Namespace
+		}
+		return targets[i].Name < targets[j].Name
+	})
+	return targets, skipped, nil
+}
+
+func (c *Client) list(ctx context.Context, group, resource string, into any) error {
+	path := group + "/" + resource
+	if c.Namespace != "" {
+		path = group + "/namespaces/" + url.PathEscape(c.Namespace) + "/" + resource
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(c.Host, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	if c.TokenFile != "" {
+		token, err := os.ReadFile(c.TokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read the service account token: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %v", resource, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %v", resource, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("kubernetes API returned HTTP %d listing %s: %s", resp.StatusCode, resource, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, into); err != nil {
+		return fmt.Errorf("invalid %s list from the kubernetes API: %v", resource, err)
+	}
+	return nil
+}
+
+type objectList[T any] struct {
+	Items []object[T] `json:"items"`
+}
+
+type object[T any] struct {
+	Metadata struct {
+		Name        string            `json:"name"`
+		Namespace   string            `json:"namespace"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+	Spec   T `json:"spec"`
+	Status struct {
+		LoadBalancer struct {
+			Ingress []struct {
+				IP       string `json:"ip"`
+				Hostname string `json:"hostname"`
+			} `json:"ingress"`
+		} `json:"loadBalancer"`
+	} `json:"status"`
+}
+
+type serviceSpec struct {
+	Ports []struct {
+		Name string `json:"name"`
+		Port int    `json:"port"`
+	} `json:"ports"`
+}
+
+type ingressSpec struct {
+	TLS []struct {
+		Hosts []string `json:"hosts"`
+	} `json:"tls"`
+	Rules []struct {
+		Host string `json:"host"`
+	} `json:"rules"`
+}
+
+func serviceTarget(svc object[serviceSpec]) (Target, bool, error) {
+	t, ok := newTarget("Service", svc.Metadata.Namespace, svc.Metadata.Name, svc.Metadata.Annotations)
+	if !ok {
+		return t, false, nil
+	}
+	if len(svc.Spec.Ports) == 0 {
+		return t, false, fmt.Errorf("%s has no ports", t.ID())
+	}
+	port := svc.Spec.Ports[0]
+	if want := svc.Metadata.Annotations[AnnotationPort]; want != "" {
+		found := false
+		for _, p := range svc.Spec.Ports {
+			if p.Name == want || strconv.Itoa(p.Port) == want {
+				port, found = p, true
+				break
+			}
+		}
+		if !found {
+			return t, false, fmt.Errorf("%s has no port %s", t.ID(), want)
+		}
+	}
+	scheme := "http"
+	if port.Port == 443 || port.Name == "https" {
+		scheme = "https"
+	}
+	base := fmt.Sprintf("%s://%s.%s.svc:%d", scheme, t.Name, t.Namespace, port.Port)
+	return t, true, t.resolve(base)
+}
+
+func ingressTarget(ing object[ingressSpec]) (Target, bool, error) {
+	t, ok := newTarget("Ingress", ing.Metadata.Namespace, ing.Metadata.Name, ing.Metadata.Annotations)
+	if !ok {
+		return t, false, nil
+	}
+	host := ""
+	for _, rule := range ing.Spec.Rules {
+		if rule.Host != "" && !strings.HasPrefix(rule.Host, "*") {
+			host = rule.Host
+			break
+		}
+	}
+	if host == "" {
+		for _, lb := range ing.Status.LoadBalancer.Ingress {
+			if host = lb.Hostname; host == "" {
+				host = lb.IP
+			}
+			if host != "" {
+				break
+			}
+		}
+	}
+	if host == "" {
+		if isAbsoluteURL(t.SpecURL) && t.ServerURL != "" {
+			return t, true, nil
+		}
+		return t, false, fmt.Errorf("%s has no host and no load balancer address", t.ID())
+	}
+	scheme := "http"
+	for _, tls := range ing.Spec.TLS {
+		for _, h := range tls.Hosts {
+			if h == host {
+				scheme = "https"
+			}
+		}
+	}
+	return t, true, t.resolve(scheme + "://" + host)
+}
+
+// newTarget reads the annotations of an object; ok is false when it announces no spec.
+func newTarget(kind, namespace, name string, annotations map[string]string) (Target, bool) {
+	specURL := strings.TrimSpace(annotations[AnnotationSpecURL])
+	if specURL == "" {
+		return Target{}, false
+	}
+	t := Target{
+		Kind:        kind,
+		Namespace:   namespace,
+		Name:        name,
+		SpecURL:     specURL,
+		Endpoint:    strings.Trim(annotations[AnnotationEndpoint], "/ "),
+		ServerURL:   strings.TrimSpace(annotations[AnnotationServerURL]),
+		APIKeyToken: annotations[AnnotationAPIKeyToken],
+		Enabled:     true,
+	}
+	if t.Endpoint == "" {
+		t.Endpoint = namespace + "-" + name
+	}
+	if v, err := strconv.ParseBool(annotations[AnnotationEnabled]); err == nil {
+		t.Enabled = v
+	}
+	return t, true
+}
+
+// resolve makes the spec and server URLs absolute against base, the object's address.
+func (t *Target) resolve(base string) error {
+	t.BaseURL = base
+	if !isAbsoluteURL(t.SpecURL) {
+		t.SpecURL = base + "/" + strings.TrimLeft(t.SpecURL, "/")
+	}
+	if t.ServerURL != "" && !isAbsoluteURL(t.ServerURL) {
+		t.ServerURL = base + "/" + strings.TrimLeft(t.ServerURL, "/")
+	}
+	if _, err := url.Parse(t.SpecURL); err != nil {
+		return fmt.Errorf("%s has an invalid %s: %v", t.ID(), AnnotationSpecURL, err)
+	}
+	return nil
+}
+
+func isAbsoluteURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
@@ -567,6 +567,8 @@ type ToolAnnotation struct {
 	IdempotentHint *bool `json:"idempotentHint,omitempty"`
 	// If true, tool interacts with external entities
 	OpenWorldHint *bool `json:"openWorldHint,omitempty"`
+	// JSON Schema of the structured result of a successful call, if known
+	OutputSchema map[string]any `json:"outputSchema,omitempty"`
 }
 
 // ToolOption is a function that configures a Tool.
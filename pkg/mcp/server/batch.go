@@ -0,0 +1,69 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+// maxBatchSize bounds the messages of one JSON-RPC batch.
+const maxBatchSize = 100
+
+// isBatch reports whether a JSON-RPC payload is a batch: a JSON array of messages.
+func isBatch(data []byte) bool {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	return len(data) > 0 && data[0] == '['
+}
+
+// handleBatch handles the messages of a JSON-RPC batch and returns the responses to its
+// requests, in the order of the requests, or nil when it has none. Notifications and
+// responses are handled first, in order; the requests then run concurrently, so a slow tool
+// call does not hold up the others. An initialize request must be sent on its own, and
+// entries that are not JSON objects get an Invalid Request error.
+func (s *MCPServer) handleBatch(ctx context.Context, messages []json.RawMessage) []mcp.JSONRPCMessage {
+	responses := make([]mcp.JSONRPCMessage, len(messages))
+	var requests []int
+	for i, message := range messages {
+		if trimmed := bytes.TrimSpace(message); len(trimmed) == 0 || trimmed[0] != '{' {
+			responses[i] = createErrorResponse(nil, mcp.INVALID_REQUEST, "batch entries must be JSON-RPC messages")
+			continue
+		}
+		var base struct {
+			Method mcp.MCPMethod `json:"method"`
+			ID     any           `json:"id,omitempty"`
+		}
+		if err := json.Unmarshal(message, &base); err != nil {
+			responses[i] = createErrorResponse(nil, mcp.PARSE_ERROR, "Failed to parse message")
+			continue
+		}
+		switch {
+		case base.Method == mcp.MethodInitialize:
+			responses[i] = createErrorResponse(base.ID, mcp.INVALID_REQUEST, "initialize must not be part of a batch")
+		case base.ID == nil || base.Method == "":
+			responses[i] = s.handleTranscribed(ctx, message)
+		default:
+			requests = append(requests, i)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, i := range requests {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = s.handleTranscribed(ctx, messages[i])
+		}(i)
+	}
+	wg.Wait()
+
+	var out []mcp.JSONRPCMessage
+	for _, response := range responses {
+		if response != nil {
+			out = append(out, response)
+		}
+	}
+	return out
+}
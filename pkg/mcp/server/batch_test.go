@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+// batchTestServer serves a server whose "meet" tool only returns once two calls of it run at
+// the same time, so a batch of two calls completes only if they run concurrently.
+func batchTestServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	mcpServer := NewMCPServer("test-server", "1.0.0", WithToolCapabilities(true))
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	mcpServer.AddTool(mcp.NewTool("meet"), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		arrived.Done()
+		done := make(chan struct{})
+		go func() { arrived.Wait(); close(done) }()
+		select {
+		case <-done:
+			return &mcp.CallToolResult{Content: []mcp.Content{mcp.NewTextContent("met")}}, nil
+		case <-time.After(2 * time.Second):
+			return &mcp.CallToolResult{Content: []mcp.Content{mcp.NewTextContent("the other call never arrived")}, IsError: true}, nil
+		}
+	})
+	testServer := httptest.NewServer(NewStreamableHTTPServer(mcpServer))
+	t.Cleanup(testServer.Close)
+
+	resp, err := http.Post(testServer.URL, "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","clientInfo":{"name":"test-client","version":"1.0.0"}}}`))
+	if err != nil {
+		t.Fatalf("Failed to send initialize request: %v", err)
+	}
+	resp.Body.Close()
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+	if sessionID == "" {
+		t.Fatal("Expected session ID in response header")
+	}
+	return testServer, sessionID
+}
+
+func postBatch(t *testing.T, url, sessionID, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest("POST", url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Mcp-Session-Id", sessionID)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to send batch: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+type batchResponse struct {
+	ID     any `json:"id"`
+	Result *struct {
+		Tools   []mcp.Tool `json:"tools"`
+		IsError bool       `json:"isError"`
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+	} `json:"result"`
+	Error *struct {
+		Code int `json:"code"`
+	} `json:"error"`
+}
+
+func TestStreamableHTTPServer_Batch(t *testing.T) {
+	testServer, sessionID := batchTestServer(t)
+
+	t.Run("requests are answered with an array in request order", func(t *testing.T) {
+		resp := postBatch(t, testServer.URL, sessionID, `[
+			{"jsonrpc":"2.0","method":"notifications/initialized"},
+			{"jsonrpc":"2.0","id":"a","method":"tools/call","params":{"name":"meet"}},
+			{"jsonrpc":"2.0","id":2,"method":"tools/list"},
+			{"jsonrpc":"2.0","id":"b","method":"tools/call","params":{"name":"meet"}}
+		]`)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		var responses []batchResponse
+		if err := json.NewDecoder(resp.Body).Decode(&responses); err != nil {
+			t.Fatalf("Expected an array of responses: %v", err)
+		}
+		if len(responses) != 3 || responses[0].ID != "a" || responses[1].ID != float64(2) || responses[2].ID != "b" {
+			t.Fatalf("Expected responses to a, 2 and b, got %+v", responses)
+		}
+		for _, i := range []int{0, 2} {
+			if r := responses[i].Result; r == nil || r.IsError || len(r.Content) == 0 || r.Content[0].Text != "met" {
+				t.Errorf("Expected the batched tool calls to run concurrently, got %+v", r)
+			}
+		}
+		if r := responses[1].Result; r == nil || len(r.Tools) != 1 {
+			t.Errorf("Expected tools/list to list the tool, got %+v", r)
+		}
+	})
+
+	t.Run("a batch without requests is accepted", func(t *testing.T) {
+		resp := postBatch(t, testServer.URL, sessionID, `[{"jsonrpc":"2.0","method":"notifications/initialized"}]`)
+		if resp.StatusCode != http.StatusAccepted {
+			t.Errorf("Expected status 202, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("invalid entries and initialize get errors", func(t *testing.T) {
+		resp := postBatch(t, testServer.URL, sessionID, `[1, {"jsonrpc":"2.0","id":3,"method":"initialize","params":{}}, {"jsonrpc":"2.0","id":4,"method":"ping"}]`)
+		var responses []batchResponse
+		if err := json.NewDecoder(resp.Body).Decode(&responses); err != nil {
+			t.Fatalf("Expected an array of responses: %v", err)
+		}
+		if len(responses) != 3 {
+			t.Fatalf("Expected 3 responses, got %+v", responses)
+		}
+		if responses[0].Error == nil || responses[0].Error.Code != mcp.INVALID_REQUEST || responses[0].ID != nil {
+			t.Errorf("Expected an Invalid Request error without ID for the number, got %+v", responses[0])
+		}
+		if responses[1].Error == nil || responses[1].Error.Code != mcp.INVALID_REQUEST || responses[1].ID != float64(3) {
+			t.Errorf("Expected an Invalid Request error for the batched initialize, got %+v", responses[1])
+		}
+		if responses[2].Error != nil || responses[2].ID != float64(4) {
+			t.Errorf("Expected ping to succeed, got %+v", responses[2])
+		}
+	})
+
+	t.Run("an empty batch is an invalid request", func(t *testing.T) {
+		resp := postBatch(t, testServer.URL, sessionID, `[]`)
+		var response batchResponse
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatalf("Expected a single error response: %v", err)
+		}
+		if resp.StatusCode != http.StatusBadRequest || response.Error == nil || response.Error.Code != mcp.INVALID_REQUEST {
+			t.Errorf("Expected 400 with an Invalid Request error, got %d %+v", resp.StatusCode, response)
+		}
+	})
+}
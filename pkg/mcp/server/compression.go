@@ -0,0 +1,212 @@
+package server
+
+import (
+	"bufio"
+	"compress/gzip"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultCompressionMinSize is the size from which responses are compressed when
+// CompressionConfig.MinSize is 0.
+const DefaultCompressionMinSize = 1024
+
+// CompressionConfig configures gzip compression of HTTP responses. The zero value
+// compresses responses of at least DefaultCompressionMinSize bytes at the default level.
+type CompressionConfig struct {
+	Disabled bool // never compress
+	MinSize  int  // smaller responses are sent as is; 0 uses DefaultCompressionMinSize
+	Level    int  // gzip level from 1 (fastest) to 9 (smallest); 0 uses gzip.DefaultCompression
+}
+
+// WithCompression configures the gzip compression of responses; see CompressionConfig.
+func WithCompression(cfg CompressionConfig) StreamableHTTPOption {
+	return func(s *StreamableHTTPServer) {
+		s.compression = cfg
+	}
+}
+
+// CompressHandler gzips the responses of next for clients that accept it, once they reach
+// the minimum size. Small responses keep their Content-Length, and responses that set their
+// own Content-Encoding, SSE streams and responses flushed before reaching the minimum size
+// are passed through uncompressed, so streaming is never delayed.
+func CompressHandler(cfg CompressionConfig, next http.Handler) http.Handler {
+	if cfg.Disabled {
+		return next
+	}
+	if cfg.MinSize <= 0 {
+		cfg.MinSize = DefaultCompressionMinSize
+	}
+	if cfg.Level == 0 || cfg.Level < gzip.HuffmanOnly || cfg.Level > gzip.BestCompression {
+		cfg.Level = gzip.DefaultCompression
+	}
+	pool := &sync.Pool{New: func() any {
+		gz, _ := gzip.NewWriterLevel(nil, cfg.Level)
+		return gz
+	}}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, minSize: cfg.MinSize, pool: pool}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// acceptsGzip reports whether an Accept-Encoding header accepts gzip, with a q-value above 0.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if c := strings.ToLower(strings.TrimSpace(coding)); c != "gzip" && c != "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.ReplaceAll(params, " ", ""), "q="); ok {
+			q, _ = strconv.ParseFloat(v, 64)
+		}
+		if q > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// NoCompression passes the response written to w through uncompressed, when w is compressed
+// by CompressHandler and nothing has been written yet.
+func NoCompression(w http.ResponseWriter) {
+	if cw, ok := w.(*compressWriter); ok && cw.mode == undecided {
+		cw.mode = passthrough
+	}
+}
+
+// Modes of a compressWriter.
+const (
+	undecided   = iota // buffering until the response reaches the minimum size
+	passthrough        // writing to the underlying writer
+	compressing        // writing through gzip
+)
+
+// compressWriter buffers the start of a response until it knows whether to compress it.
+type compressWriter struct {
+	http.ResponseWriter
+	minSize int
+	pool    *sync.Pool
+
+	mode        int
+	status      int
+	wroteHeader bool
+	buf         []byte
+	gz          *gzip.Writer
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+	cw.status = status
+	h := cw.Header()
+	if cw.mode == undecided && (h.Get("Content-Encoding") != "" || status < 200 || status == http.StatusNoContent ||
+		status == http.StatusNotModified || strings.HasPrefix(h.Get("Content-Type"), "text/event-stream")) {
+		cw.mode = passthrough
+	}
+	if cw.mode == passthrough {
+		cw.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.wroteHeader {
+		if cw.Header().Get("Content-Type") == "" {
+			cw.Header().Set("Content-Type", http.DetectContentType(p))
+		}
+		cw.WriteHeader(http.StatusOK)
+	}
+	switch cw.mode {
+	case passthrough:
+		return cw.ResponseWriter.Write(p)
+	case compressing:
+		return cw.gz.Write(p)
+	}
+	cw.buf = append(cw.buf, p...)
+	if len(cw.buf) >= cw.minSize {
+		if err := cw.startGzip(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// startGzip sends the headers of a compressed response and the buffered start of it.
+func (cw *compressWriter) startGzip() error {
+	cw.mode = compressing
+	h := cw.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Encoding", "gzip")
+	h.Add("Vary", "Accept-Encoding")
+	cw.ResponseWriter.WriteHeader(cw.status)
+	cw.gz = cw.pool.Get().(*gzip.Writer)
+	cw.gz.Reset(cw.ResponseWriter)
+	_, err := cw.gz.Write(cw.buf)
+	cw.buf = nil
+	return err
+}
+
+// flushBuffer sends a response that stayed below the minimum size as is.
+func (cw *compressWriter) flushBuffer() {
+	cw.mode = passthrough
+	cw.Header().Add("Vary", "Accept-Encoding")
+	if cw.wroteHeader {
+		cw.ResponseWriter.WriteHeader(cw.status)
+	}
+	if len(cw.buf) > 0 {
+		cw.ResponseWriter.Write(cw.buf)
+		cw.buf = nil
+	}
+}
+
+// Flush sends what was written so far. A response flushed before it reaches the minimum
+// size is streaming, so it is sent uncompressed.
+func (cw *compressWriter) Flush() {
+	switch cw.mode {
+	case undecided:
+		cw.flushBuffer()
+	case compressing:
+		cw.gz.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets handlers that take over the connection do so.
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := cw.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func (cw *compressWriter) close() {
+	switch cw.mode {
+	case undecided:
+		if cw.wroteHeader || len(cw.buf) > 0 {
+			cw.flushBuffer()
+		}
+	case compressing:
+		cw.gz.Close()
+		cw.gz.Reset(nil)
+		cw.pool.Put(cw.gz)
+		cw.gz = nil
+	}
+}
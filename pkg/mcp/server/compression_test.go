@@ -0,0 +1,168 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+func compressedResponse(t *testing.T, cfg CompressionConfig, acceptEncoding string, handler http.HandlerFunc) (*httptest.ResponseRecorder, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	rec := httptest.NewRecorder()
+	CompressHandler(cfg, handler).ServeHTTP(rec, req)
+	body := rec.Body.String()
+	if rec.Header().Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("invalid gzip body: %v", err)
+		}
+		data, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("invalid gzip body: %v", err)
+		}
+		body = string(data)
+	}
+	return rec, body
+}
+
+func writeJSON(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, body[:len(body)/2])
+		io.WriteString(w, body[len(body)/2:])
+	}
+}
+
+func TestCompressHandler(t *testing.T) {
+	large := `{"data":"` + strings.Repeat("x", 2000) + `"}`
+	small := `{"ok":true}`
+
+	t.Run("large responses are compressed", func(t *testing.T) {
+		rec, body := compressedResponse(t, CompressionConfig{}, "br, gzip", writeJSON(large))
+		if rec.Header().Get("Content-Encoding") != "gzip" || rec.Header().Get("Content-Length") != "" || rec.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("expected a gzip response without Content-Length, got headers %v", rec.Header())
+		}
+		if rec.Code != http.StatusCreated || body != large {
+			t.Errorf("expected the status and body to be kept, got %d %q", rec.Code, body[:20])
+		}
+		if rec.Body.Len() >= len(large) {
+			t.Errorf("expected the body to shrink, got %d bytes", rec.Body.Len())
+		}
+	})
+
+	t.Run("small responses keep their Content-Length", func(t *testing.T) {
+		rec, body := compressedResponse(t, CompressionConfig{}, "gzip", writeJSON(small))
+		if rec.Header().Get("Content-Encoding") != "" || rec.Header().Get("Content-Length") != strconv.Itoa(len(small)) || body != small || rec.Code != http.StatusCreated {
+			t.Errorf("expected an uncompressed response, got %d %v %q", rec.Code, rec.Header(), body)
+		}
+	})
+
+	t.Run("the threshold is configurable", func(t *testing.T) {
+		rec, body := compressedResponse(t, CompressionConfig{MinSize: 5, Level: gzip.BestSpeed}, "gzip", writeJSON(small))
+		if rec.Header().Get("Content-Encoding") != "gzip" || body != small {
+			t.Errorf("expected a gzip response above the threshold, got %v %q", rec.Header(), body)
+		}
+	})
+
+	t.Run("clients that do not accept gzip", func(t *testing.T) {
+		for _, accept := range []string{"", "br", "gzip;q=0", "identity"} {
+			rec, body := compressedResponse(t, CompressionConfig{}, accept, writeJSON(large))
+			if rec.Header().Get("Content-Encoding") != "" || body != large {
+				t.Errorf("Accept-Encoding %q: expected an uncompressed response, got %v", accept, rec.Header())
+			}
+		}
+	})
+
+	t.Run("compression can be disabled", func(t *testing.T) {
+		rec, _ := compressedResponse(t, CompressionConfig{Disabled: true}, "gzip", writeJSON(large))
+		if rec.Header().Get("Content-Encoding") != "" {
+			t.Errorf("expected an uncompressed response, got %v", rec.Header())
+		}
+	})
+
+	t.Run("streams are not compressed", func(t *testing.T) {
+		rec, body := compressedResponse(t, CompressionConfig{}, "gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusAccepted)
+			io.WriteString(w, "data: "+large+"\n\n")
+		})
+		if rec.Header().Get("Content-Encoding") != "" || rec.Code != http.StatusAccepted || !strings.HasPrefix(body, "data: ") {
+			t.Errorf("expected an uncompressed SSE stream, got %d %v", rec.Code, rec.Header())
+		}
+	})
+
+	t.Run("responses flushed early are sent as is", func(t *testing.T) {
+		rec, body := compressedResponse(t, CompressionConfig{}, "gzip", func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, small)
+			w.(http.Flusher).Flush()
+			if !flushedToRecorder(w) {
+				t.Error("expected the first chunk to be sent on Flush")
+			}
+			io.WriteString(w, large)
+		})
+		if rec.Header().Get("Content-Encoding") != "" || body != small+large {
+			t.Errorf("expected an uncompressed response, got %v", rec.Header())
+		}
+	})
+
+	t.Run("handlers can opt out", func(t *testing.T) {
+		rec, _ := compressedResponse(t, CompressionConfig{}, "gzip", func(w http.ResponseWriter, r *http.Request) {
+			NoCompression(w)
+			writeJSON(large)(w, r)
+		})
+		if rec.Header().Get("Content-Encoding") != "" || rec.Header().Get("Content-Length") == "" {
+			t.Errorf("expected an uncompressed response, got %v", rec.Header())
+		}
+	})
+}
+
+// flushedToRecorder reports whether w has sent something to the recorder behind it.
+func flushedToRecorder(w http.ResponseWriter) bool {
+	cw, ok := w.(*compressWriter)
+	if !ok {
+		return false
+	}
+	r, ok := cw.ResponseWriter.(*httptest.ResponseRecorder)
+	return ok && r.Body.Len() > 0
+}
+
+func TestStreamableHTTPServerCompression(t *testing.T) {
+	mcpServer := NewMCPServer("test-server", "1.0.0", WithToolCapabilities(true))
+	for i := 0; i < 50; i++ {
+		mcpServer.AddTool(mcp.NewTool("tool"+strconv.Itoa(i)), nil)
+	}
+
+	for _, tt := range []struct {
+		name string
+		opts []StreamableHTTPOption
+		url  string
+		gzip bool
+	}{
+		{"compressed by default", nil, "/mcp/tools", true},
+		{"opt out per request", nil, "/mcp/tools?compressed=false", false},
+		{"disabled", []StreamableHTTPOption{WithCompression(CompressionConfig{Disabled: true})}, "/mcp/tools", false},
+		{"threshold above the response", []StreamableHTTPOption{WithCompression(CompressionConfig{MinSize: 1 << 20})}, "/mcp/tools", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			rec := httptest.NewRecorder()
+			NewStreamableHTTPServer(mcpServer, tt.opts...).ServeHTTP(rec, req)
+			if got := rec.Header().Get("Content-Encoding") == "gzip"; got != tt.gzip || rec.Code != http.StatusOK {
+				t.Errorf("expected gzip %t, got %d %v", tt.gzip, rec.Code, rec.Header())
+			}
+		})
+	}
+}
@@ -1,34 +1,37 @@
 package server
 
 import (
-	"errors"
 	"fmt"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/apierrors"
 )
 
+// Sentinel errors are classified in the shared apierrors taxonomy, so transports
+// and the management API render them with the same HTTP status and error shape.
 var (
 	// Common server errors
-	ErrUnsupported      = errors.New("not supported")
-	ErrResourceNotFound = errors.New("resource not found")
-	ErrPromptNotFound   = errors.New("prompt not found")
-	ErrToolNotFound     = errors.New("tool not found")
+	ErrUnsupported      = apierrors.Sentinel(apierrors.TypeUnsupported, "not supported")
+	ErrResourceNotFound = apierrors.Sentinel(apierrors.TypeNotFound, "resource not found")
+	ErrPromptNotFound   = apierrors.Sentinel(apierrors.TypeNotFound, "prompt not found")
+	ErrToolNotFound     = apierrors.Sentinel(apierrors.TypeNotFound, "tool not found")
 
 	// Session-related errors
-	ErrSessionNotFound              = errors.New("session not found")
-	ErrSessionExists                = errors.New("session already exists")
-	ErrSessionNotInitialized        = errors.New("session not properly initialized")
-	ErrSessionDoesNotSupportTools   = errors.New("session does not support per-session tools")
-	ErrSessionDoesNotSupportLogging = errors.New("session does not support setting logging level")
+	ErrSessionNotFound              = apierrors.Sentinel(apierrors.TypeNotFound, "session not found")
+	ErrSessionExists                = apierrors.Sentinel(apierrors.TypeConflict, "session already exists")
+	ErrSessionNotInitialized        = apierrors.Sentinel(apierrors.TypeValidation, "session not properly initialized")
+	ErrSessionDoesNotSupportTools   = apierrors.Sentinel(apierrors.TypeUnsupported, "session does not support per-session tools")
+	ErrSessionDoesNotSupportLogging = apierrors.Sentinel(apierrors.TypeUnsupported, "session does not support setting logging level")
 
 	// Notification-related errors
-	ErrNotificationNotInitialized = errors.New("notification channel not initialized")
-	ErrNotificationChannelBlocked = errors.New("notification channel full or blocked")
+	ErrNotificationNotInitialized = apierrors.Sentinel(apierrors.TypeInternal, "notification channel not initialized")
+	ErrNotificationChannelBlocked = apierrors.Sentinel(apierrors.TypeUnavailable, "notification channel full or blocked")
 
 	// Authentication-related errors
-	ErrInvalidSessionAuth = errors.New("invalid session authentication")
-	ErrExpiredSessionAuth = errors.New("session authentication expired")
-	ErrMissingAuth        = errors.New("missing authentication credentials")
-	ErrInvalidAuth        = errors.New("invalid authentication credentials")
-	ErrSessionTerminated  = errors.New("session terminated")
+	ErrInvalidSessionAuth = apierrors.Sentinel(apierrors.TypeAuth, "invalid session authentication")
+	ErrExpiredSessionAuth = apierrors.Sentinel(apierrors.TypeAuth, "session authentication expired")
+	ErrMissingAuth        = apierrors.Sentinel(apierrors.TypeAuth, "missing authentication credentials")
+	ErrInvalidAuth        = apierrors.Sentinel(apierrors.TypeAuth, "invalid authentication credentials")
+	ErrSessionTerminated  = apierrors.Sentinel(apierrors.TypeNotFound, "session terminated")
 )
 
 // ErrDynamicPathConfig is returned when attempting to use static path methods with dynamic path configuration
@@ -71,6 +74,16 @@ func (e *AuthError) Unwrap() error {
 	return e.Cause
 }
 
+// ErrorType classifies the error in the shared apierrors taxonomy
+func (e *AuthError) ErrorType() apierrors.Type {
+	switch e.Type {
+	case AuthErrSessionNotFound, AuthErrSessionTerminated:
+		return apierrors.TypeNotFound
+	default:
+		return apierrors.TypeAuth
+	}
+}
+
 // NewAuthError creates a new authentication error
 func NewAuthError(errType AuthErrorType, message string, sessionID string, cause error) *AuthError {
 	return &AuthError{
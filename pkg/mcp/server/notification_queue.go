@@ -0,0 +1,247 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+// NotificationPolicy says what happens to a notification for a session whose queue is full,
+// because its client reads notifications more slowly than the server sends them.
+type NotificationPolicy string
+
+const (
+	// NotificationDropNewest drops the notification being sent. It is the default.
+	NotificationDropNewest NotificationPolicy = "drop-newest"
+	// NotificationDropOldest drops the oldest queued notification to make room.
+	NotificationDropOldest NotificationPolicy = "drop-oldest"
+	// NotificationBlock waits up to BlockTimeout for room, then drops the notification.
+	NotificationBlock NotificationPolicy = "block"
+)
+
+// DefaultNotificationQueueSize is the number of notifications queued per session when
+// neither WithNotificationBackpressure nor MCP_NOTIFICATION_QUEUE_SIZE set one.
+const DefaultNotificationQueueSize = 100
+
+// notificationWarningInterval is the minimum time between two warnings to a client that
+// notifications were dropped.
+const notificationWarningInterval = 30 * time.Second
+
+// NotificationBackpressure configures the notification queue of each session.
+type NotificationBackpressure struct {
+	QueueSize    int                // notifications queued per session; 0 uses DefaultNotificationQueueSize
+	Policy       NotificationPolicy // what to do when the queue is full; "" drops the newest
+	BlockTimeout time.Duration      // how long NotificationBlock waits for room; 0 waits 5s
+}
+
+// ParseNotificationPolicy reads a policy name: drop-newest, drop-oldest or block.
+func ParseNotificationPolicy(s string) (NotificationPolicy, error) {
+	switch p := NotificationPolicy(strings.ToLower(strings.TrimSpace(s))); p {
+	case "":
+		return NotificationDropNewest, nil
+	case NotificationDropNewest, NotificationDropOldest, NotificationBlock:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown notification policy %q: expected drop-newest, drop-oldest or block", s)
+}
+
+// NotificationBackpressureFromEnv reads MCP_NOTIFICATION_QUEUE_SIZE, MCP_NOTIFICATION_POLICY
+// and MCP_NOTIFICATION_BLOCK_TIMEOUT. Invalid values are logged and ignored.
+func NotificationBackpressureFromEnv() NotificationBackpressure {
+	var cfg NotificationBackpressure
+	if v := strings.TrimSpace(os.Getenv("MCP_NOTIFICATION_QUEUE_SIZE")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.QueueSize = n
+		} else {
+			fmt.Fprintf(os.Stderr, "[WARN] Ignoring invalid MCP_NOTIFICATION_QUEUE_SIZE=%q\n", v)
+		}
+	}
+	if v := os.Getenv("MCP_NOTIFICATION_POLICY"); v != "" {
+		if p, err := ParseNotificationPolicy(v); err == nil {
+			cfg.Policy = p
+		} else {
+			fmt.Fprintf(os.Stderr, "[WARN] Ignoring MCP_NOTIFICATION_POLICY: %v\n", err)
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("MCP_NOTIFICATION_BLOCK_TIMEOUT")); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.BlockTimeout = d
+		} else {
+			fmt.Fprintf(os.Stderr, "[WARN] Ignoring invalid MCP_NOTIFICATION_BLOCK_TIMEOUT=%q\n", v)
+		}
+	}
+	return cfg
+}
+
+// WithNotificationBackpressure sets the notification queue size of new sessions and what
+// happens to notifications for sessions whose queue is full. Servers without this option use
+// NotificationBackpressureFromEnv.
+func WithNotificationBackpressure(cfg NotificationBackpressure) ServerOption {
+	return func(s *MCPServer) {
+		s.backpressure = &cfg
+	}
+}
+
+// notificationBackpressure returns the server's configuration with defaults filled in.
+func (s *MCPServer) notificationBackpressure() NotificationBackpressure {
+	var cfg NotificationBackpressure
+	if s.backpressure != nil {
+		cfg = *s.backpressure
+	} else {
+		cfg = NotificationBackpressureFromEnv()
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultNotificationQueueSize
+	}
+	if cfg.Policy == "" {
+		cfg.Policy = NotificationDropNewest
+	}
+	if cfg.BlockTimeout <= 0 {
+		cfg.BlockTimeout = 5 * time.Second
+	}
+	return cfg
+}
+
+// newNotificationChannel returns the notification queue of a new session.
+func (s *MCPServer) newNotificationChannel() chan mcp.JSONRPCNotification {
+	if s == nil {
+		return make(chan mcp.JSONRPCNotification, DefaultNotificationQueueSize)
+	}
+	return make(chan mcp.JSONRPCNotification, s.notificationBackpressure().QueueSize)
+}
+
+// notificationStats counts the notifications dropped for a session.
+type notificationStats struct {
+	dropped     atomic.Int64
+	unreported  atomic.Int64 // dropped since the client was last warned
+	lastWarning atomic.Int64 // UnixNano of the last warning
+}
+
+// sessionWithNotificationQueue is implemented by sessions whose queue the server can drain
+// and whose dropped notifications it counts.
+type sessionWithNotificationQueue interface {
+	notificationQueue() chan mcp.JSONRPCNotification
+	notificationStats() *notificationStats
+}
+
+// NotificationsDropped returns the number of notifications dropped for a session because
+// its queue was full, or 0 when the session does not count them.
+func NotificationsDropped(session ClientSession) int64 {
+	if queued, ok := session.(sessionWithNotificationQueue); ok {
+		return queued.notificationStats().dropped.Load()
+	}
+	return 0
+}
+
+// deliverNotification queues a notification for a session, applying the server's policy
+// when the queue is full. It returns ErrNotificationChannelBlocked when the notification
+// was dropped.
+func (s *MCPServer) deliverNotification(ctx context.Context, session ClientSession, notification mcp.JSONRPCNotification) error {
+	queued, _ := session.(sessionWithNotificationQueue)
+	if queued != nil {
+		s.warnDroppedNotifications(session, queued)
+	}
+	select {
+	case session.NotificationChannel() <- notification:
+		return nil
+	default:
+	}
+
+	cfg := s.notificationBackpressure()
+	switch {
+	case cfg.Policy == NotificationDropOldest && queued != nil:
+		// Make room by dropping the oldest notification, unless the client just read it
+		for {
+			select {
+			case <-queued.notificationQueue():
+				queued.notificationStats().drop()
+			default:
+			}
+			select {
+			case session.NotificationChannel() <- notification:
+				return nil
+			default:
+			}
+		}
+	case cfg.Policy == NotificationBlock:
+		timer := time.NewTimer(cfg.BlockTimeout)
+		defer timer.Stop()
+		select {
+		case session.NotificationChannel() <- notification:
+			return nil
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+	}
+	if queued != nil {
+		queued.notificationStats().drop()
+	}
+	return ErrNotificationChannelBlocked
+}
+
+func (n *notificationStats) drop() {
+	n.dropped.Add(1)
+	n.unreported.Add(1)
+}
+
+// warnDroppedNotifications tells a client, with a warning log message notification, how
+// many notifications were dropped since it was last warned, at most every 30s. The warning
+// is only sent when there is room for it.
+func (s *MCPServer) warnDroppedNotifications(session ClientSession, queued sessionWithNotificationQueue) {
+	stats := queued.notificationStats()
+	if stats.unreported.Load() == 0 {
+		return
+	}
+	now := time.Now().UnixNano()
+	last := stats.lastWarning.Load()
+	if now-last < int64(notificationWarningInterval) || !stats.lastWarning.CompareAndSwap(last, now) {
+		return
+	}
+	dropped := stats.unreported.Swap(0)
+	warning := mcp.JSONRPCNotification{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		Notification: mcp.Notification{
+			Method: "notifications/message",
+			Params: mcp.NotificationParams{
+				AdditionalFields: map[string]any{
+					"level":  mcp.LoggingLevelWarning,
+					"logger": "notifications",
+					"data": map[string]any{
+						"message": fmt.Sprintf("%d notifications were dropped because the client did not read them fast enough", dropped),
+						"dropped": dropped,
+					},
+				},
+			},
+		},
+	}
+	select {
+	case session.NotificationChannel() <- warning:
+	default:
+		// Still full: warn with the next notification
+		stats.unreported.Add(dropped)
+		stats.lastWarning.Store(last)
+	}
+}
+
+// reportBlockedNotification passes a dropped notification to the error hooks, if any.
+func (s *MCPServer) reportBlockedNotification(ctx context.Context, sessionID, method string) {
+	if s.hooks == nil || len(s.hooks.OnError) == 0 {
+		return
+	}
+	err := ErrNotificationChannelBlocked
+	// Copy hooks pointer to local variable to avoid race condition
+	hooks := s.hooks
+	go func(sessionID string, hooks *Hooks) {
+		// Use the error hook to report the blocked channel
+		hooks.onError(ctx, nil, "notification", map[string]any{
+			"method":    method,
+			"sessionID": sessionID,
+		}, fmt.Errorf("notification channel blocked for session %s: %w", sessionID, err))
+	}(sessionID, hooks)
+}
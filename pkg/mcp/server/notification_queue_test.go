@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+func newQueuedTestSession(t *testing.T, server *MCPServer, id string) *sseSession {
+	t.Helper()
+	session := &sseSession{sessionID: id, notificationChannel: server.newNotificationChannel()}
+	session.Initialize()
+	if err := server.RegisterSession(context.Background(), session); err != nil {
+		t.Fatalf("failed to register session: %v", err)
+	}
+	return session
+}
+
+func queuedMethods(session *sseSession) []string {
+	var methods []string
+	for {
+		select {
+		case n := <-session.notificationChannel:
+			methods = append(methods, n.Method)
+		default:
+			return methods
+		}
+	}
+}
+
+func TestNotificationBackpressure(t *testing.T) {
+	t.Run("drop newest", func(t *testing.T) {
+		server := NewMCPServer("test", "1.0.0", WithNotificationBackpressure(NotificationBackpressure{QueueSize: 2}))
+		session := newQueuedTestSession(t, server, "s1")
+		for _, method := range []string{"n1", "n2", "n3"} {
+			err := server.SendNotificationToSpecificClient("s1", method, nil)
+			if method == "n3" && !errors.Is(err, ErrNotificationChannelBlocked) {
+				t.Errorf("expected the third notification to be dropped, got %v", err)
+			}
+		}
+		if got := queuedMethods(session); len(got) != 2 || got[0] != "n1" || got[1] != "n2" {
+			t.Errorf("expected the first notifications to be kept, got %v", got)
+		}
+		if summary := server.ListSessions()[0]; summary.NotificationsDropped != 1 {
+			t.Errorf("expected one dropped notification in the session summary, got %d", summary.NotificationsDropped)
+		}
+
+		// The client is warned once there is room again
+		if err := server.SendNotificationToSpecificClient("s1", "n4", nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		select {
+		case n := <-session.notificationChannel:
+			if n.Method != "notifications/message" || n.Params.AdditionalFields["level"] != mcp.LoggingLevelWarning {
+				t.Errorf("expected a warning log message first, got %+v", n)
+			}
+		default:
+			t.Fatal("expected a warning notification")
+		}
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		server := NewMCPServer("test", "1.0.0", WithNotificationBackpressure(NotificationBackpressure{QueueSize: 2, Policy: NotificationDropOldest}))
+		session := newQueuedTestSession(t, server, "s1")
+		for _, method := range []string{"n1", "n2", "n3"} {
+			if err := server.SendNotificationToSpecificClient("s1", method, nil); err != nil {
+				t.Errorf("expected %s to be queued, got %v", method, err)
+			}
+		}
+		if got := queuedMethods(session); len(got) != 2 || got[0] != "n2" || got[1] != "n3" {
+			t.Errorf("expected the latest notifications to be kept, got %v", got)
+		}
+		if dropped := NotificationsDropped(session); dropped != 1 {
+			t.Errorf("expected one dropped notification, got %d", dropped)
+		}
+	})
+
+	t.Run("block", func(t *testing.T) {
+		server := NewMCPServer("test", "1.0.0", WithNotificationBackpressure(NotificationBackpressure{QueueSize: 1, Policy: NotificationBlock, BlockTimeout: time.Second}))
+		session := newQueuedTestSession(t, server, "s1")
+		server.SendNotificationToSpecificClient("s1", "n1", nil)
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			<-session.notificationChannel
+		}()
+		if err := server.SendNotificationToSpecificClient("s1", "n2", nil); err != nil {
+			t.Errorf("expected the sender to wait for room, got %v", err)
+		}
+
+		server = NewMCPServer("test", "1.0.0", WithNotificationBackpressure(NotificationBackpressure{QueueSize: 1, Policy: NotificationBlock, BlockTimeout: 20 * time.Millisecond}))
+		session = newQueuedTestSession(t, server, "s2")
+		server.SendNotificationToSpecificClient("s2", "n1", nil)
+		if err := server.SendNotificationToSpecificClient("s2", "n2", nil); !errors.Is(err, ErrNotificationChannelBlocked) {
+			t.Errorf("expected the notification to be dropped after the block timeout, got %v", err)
+		}
+		if dropped := NotificationsDropped(session); dropped != 1 {
+			t.Errorf("expected one dropped notification, got %d", dropped)
+		}
+	})
+}
+
+func TestNotificationBackpressureFromEnv(t *testing.T) {
+	t.Setenv("MCP_NOTIFICATION_QUEUE_SIZE", "500")
+	t.Setenv("MCP_NOTIFICATION_POLICY", "Drop-Oldest")
+	cfg := NewMCPServer("test", "1.0.0").notificationBackpressure()
+	if cfg.QueueSize != 500 || cfg.Policy != NotificationDropOldest || cfg.BlockTimeout != 5*time.Second {
+		t.Errorf("unexpected configuration %+v", cfg)
+	}
+	if _, err := ParseNotificationPolicy("drop-all"); err == nil {
+		t.Error("expected an unknown policy to be rejected")
+	}
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ParsePublicURL splits the external URL of a server behind a reverse proxy, e.g.
+// https://example.com/mcp-gateway, into its origin and its path prefix ("" at the root).
+// The URL must be an absolute http or https URL without a query.
+func ParsePublicURL(publicURL string) (origin, prefix string, err error) {
+	u, err := url.Parse(strings.TrimSpace(publicURL))
+	if err != nil {
+		return "", "", fmt.Errorf("invalid public URL %q: %v", publicURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return "", "", fmt.Errorf("invalid public URL %q: expected an http or https URL such as https://example.com/mcp-gateway", publicURL)
+	}
+	prefix = strings.TrimRight(u.Path, "/")
+	if prefix != "" {
+		prefix = normalizeURLPath(prefix)
+	}
+	return u.Scheme + "://" + u.Host, prefix, nil
+}
+
+// StripPathPrefix serves requests for prefix and the paths under it as requests for / and
+// the paths under /, and other requests unchanged. Servers behind a reverse proxy mounting
+// them under prefix thus work whether the proxy strips the prefix or forwards it.
+func StripPathPrefix(prefix string, h http.Handler) http.Handler {
+	prefix = strings.TrimRight(prefix, "/")
+	if prefix == "" {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, ok := strings.CutPrefix(r.URL.Path, prefix)
+		if !ok || rest != "" && rest[0] != '/' {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if rest == "" {
+			rest = "/"
+		}
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = rest
+		if r.URL.RawPath != "" {
+			if rawRest, ok := strings.CutPrefix(r.URL.RawPath, prefix); ok {
+				r2.URL.RawPath = rawRest
+			} else {
+				r2.URL.RawPath = ""
+			}
+		}
+		h.ServeHTTP(w, r2)
+	})
+}
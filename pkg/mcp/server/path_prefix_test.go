@@ -0,0 +1,92 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParsePublicURL(t *testing.T) {
+	tests := []struct {
+		url, origin, prefix string
+	}{
+		{"https://example.com", "https://example.com", ""},
+		{"https://example.com/", "https://example.com", ""},
+		{"http://proxy:8443/mcp-gateway/", "http://proxy:8443", "/mcp-gateway"},
+		{"https://example.com/a/b", "https://example.com", "/a/b"},
+	}
+	for _, tt := range tests {
+		origin, prefix, err := ParsePublicURL(tt.url)
+		if err != nil || origin != tt.origin || prefix != tt.prefix {
+			t.Errorf("%s: expected %q and %q, got %q and %q (%v)", tt.url, tt.origin, tt.prefix, origin, prefix, err)
+		}
+	}
+	for _, invalid := range []string{"/mcp-gateway", "example.com", "ftp://example.com", "https://example.com/?a=b"} {
+		if _, _, err := ParsePublicURL(invalid); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestStripPathPrefix(t *testing.T) {
+	var got string
+	h := StripPathPrefix("/mcp-gateway/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Path
+	}))
+	for path, want := range map[string]string{
+		"/mcp-gateway/weather":     "/weather",
+		"/mcp-gateway":             "/",
+		"/weather":                 "/weather",
+		"/mcp-gateway-2/weather":   "/mcp-gateway-2/weather",
+		"/mcp-gateway/weather/sse": "/weather/sse",
+	} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		if got != want {
+			t.Errorf("%s: expected %s, got %s", path, want, got)
+		}
+	}
+}
+
+func TestSSEServerBehindPathPrefix(t *testing.T) {
+	sseServer := NewSSEServer(NewMCPServer("test-server", "1.0.0"),
+		WithStaticBasePath("/weather"),
+		WithPublicURL("https://example.com/mcp-gateway"),
+	)
+	testServer := httptest.NewServer(StripPathPrefix("/mcp-gateway", sseServer))
+	defer testServer.Close()
+
+	// The proxy may forward the prefix or strip it
+	for _, path := range []string{"/mcp-gateway/weather/sse", "/weather/sse"} {
+		ctx, cancel := context.WithCancel(context.Background())
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, testServer.URL+path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to open SSE stream at %s: %v", path, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected %s to open a stream, got status %d", path, resp.StatusCode)
+		}
+		reader := bufio.NewReader(resp.Body)
+		var endpoint string
+		for endpoint == "" {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("failed to read endpoint event: %v", err)
+			}
+			if strings.HasPrefix(line, "data: ") {
+				endpoint = strings.TrimSpace(strings.TrimPrefix(line, "data: "))
+			}
+		}
+		cancel()
+		resp.Body.Close()
+		if !strings.HasPrefix(endpoint, "https://example.com/mcp-gateway/weather/message?sessionId=") {
+			t.Errorf("expected the message endpoint under the public URL, got %s", endpoint)
+		}
+	}
+	if got, _ := sseServer.CompleteSseEndpoint(); got != "https://example.com/mcp-gateway/weather/sse" {
+		t.Errorf("expected the SSE endpoint under the public URL, got %s", got)
+	}
+}
@@ -0,0 +1,324 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/apierrors"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+// rateLimitPruneInterval is how often client buckets that refilled completely are dropped.
+const rateLimitPruneInterval = time.Minute
+
+// RateLimit allows Requests requests per Period. Requests is also the burst size: a client
+// that was idle for a period may send them all at once. The zero value is unlimited.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// String formats the limit as ParseRateLimit reads it, e.g. "100/1m0s".
+func (l RateLimit) String() string {
+	if l.Requests <= 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+// RateLimitConfig limits the requests of an endpoint. The endpoint limit is shared by all its
+// clients, so one noisy tenant cannot starve the others of the upstream API; the client limit
+// applies to each client, identified by its IP address, or by its verified credential (see
+// WithVerifiedCredentials). Each message of a JSON-RPC batch counts as a request.
+type RateLimitConfig struct {
+	Endpoint RateLimit
+	Client   RateLimit
+}
+
+// Enabled reports whether the config limits anything.
+func (c RateLimitConfig) Enabled() bool {
+	return c.Endpoint.Requests > 0 || c.Client.Requests > 0
+}
+
+// ParseRateLimit reads a rate limit config such as "1000/m, client=60/m": comma-separated
+// "<requests>/<period>" limits, for the whole endpoint when bare or prefixed with "endpoint=",
+// and per client when prefixed with "client=". The period is a unit (s, m, h, d, or second,
+// minute, hour, day) or a duration such as 10s. An empty string is unlimited.
+func ParseRateLimit(s string) (RateLimitConfig, error) {
+	var cfg RateLimitConfig
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		target := &cfg.Endpoint
+		if key, value, ok := strings.Cut(part, "="); ok {
+			switch strings.TrimSpace(strings.ToLower(key)) {
+			case "endpoint":
+			case "client":
+				target = &cfg.Client
+			default:
+				return RateLimitConfig{}, fmt.Errorf("invalid rate limit %q: unknown scope %q, use endpoint or client", part, key)
+			}
+			part = strings.TrimSpace(value)
+		}
+		limit, err := parseLimit(part)
+		if err != nil {
+			return RateLimitConfig{}, fmt.Errorf("invalid rate limit %q: %v", part, err)
+		}
+		*target = limit
+	}
+	return cfg, nil
+}
+
+// parseLimit reads "<requests>/<period>".
+func parseLimit(s string) (RateLimit, error) {
+	count, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("expected <requests>/<period>, e.g. 100/m")
+	}
+	requests, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || requests < 0 {
+		return RateLimit{}, fmt.Errorf("requests must be a non-negative integer")
+	}
+	var d time.Duration
+	switch strings.ToLower(strings.TrimSpace(period)) {
+	case "s", "sec", "second":
+		d = time.Second
+	case "m", "min", "minute":
+		d = time.Minute
+	case "h", "hour":
+		d = time.Hour
+	case "d", "day":
+		d = 24 * time.Hour
+	default:
+		d, err = time.ParseDuration(strings.TrimSpace(period))
+		if err != nil || d <= 0 {
+			return RateLimit{}, fmt.Errorf("invalid period %q", period)
+		}
+	}
+	return RateLimit{Requests: requests, Period: d}, nil
+}
+
+// tokenBucket holds up to limit.Requests tokens, refilled at limit.Requests per limit.Period.
+type tokenBucket struct {
+	limit      RateLimit
+	tokens     float64
+	refilledAt time.Time
+}
+
+func newTokenBucket(limit RateLimit, now time.Time) *tokenBucket {
+	return &tokenBucket{limit: limit, tokens: float64(limit.Requests), refilledAt: now}
+}
+
+// refill adds the tokens earned since the last refill, adapting to a changed limit.
+func (b *tokenBucket) refill(limit RateLimit, now time.Time) {
+	if limit != b.limit {
+		b.tokens = math.Min(b.tokens, float64(limit.Requests))
+		b.limit = limit
+	}
+	if elapsed := now.Sub(b.refilledAt); elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Requests), b.tokens+elapsed.Seconds()/limit.Period.Seconds()*float64(limit.Requests))
+	}
+	b.refilledAt = now
+}
+
+// wait returns how long until the bucket holds n tokens.
+func (b *tokenBucket) wait(n int) time.Duration {
+	missing := float64(n) - b.tokens
+	return time.Duration(missing / float64(b.limit.Requests) * float64(b.limit.Period))
+}
+
+// full reports whether the bucket refilled completely, so dropping it loses nothing.
+func (b *tokenBucket) full(now time.Time) bool {
+	b.refill(b.limit, now)
+	return b.tokens >= float64(b.limit.Requests)
+}
+
+// RateLimiter keeps the token buckets of endpoints and their clients. It outlives the
+// handlers using it, so a remounted endpoint keeps its buckets. It is safe for concurrent use.
+type RateLimiter struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	verify   func(r *http.Request) bool
+	buckets  map[string]*tokenBucket // endpoint, or endpoint + "\x00" + client
+	prunedAt time.Time
+}
+
+// RateLimiterOption configures a RateLimiter.
+type RateLimiterOption func(*RateLimiter)
+
+// WithVerifiedCredentials identifies clients by their credential when verify accepts the
+// credential of their request. Without it, or when verify rejects it, clients are identified
+// by IP address, as anyone could send a made-up credential with each request to get a fresh
+// bucket.
+func WithVerifiedCredentials(verify func(r *http.Request) bool) RateLimiterOption {
+	return func(l *RateLimiter) {
+		l.verify = verify
+	}
+}
+
+// NewRateLimiter creates a rate limiter without buckets.
+func NewRateLimiter(opts ...RateLimiterOption) *RateLimiter {
+	l := &RateLimiter{now: time.Now, buckets: make(map[string]*tokenBucket)}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Allow takes a token from the buckets of endpoint and client. When either is empty,
+// nothing is taken and Allow returns false with how long until a request is allowed.
+func (l *RateLimiter) Allow(endpoint, client string, cfg RateLimitConfig) (bool, time.Duration) {
+	return l.AllowN(endpoint, client, cfg, 1)
+}
+
+// AllowN takes n tokens from the buckets of endpoint and client, for a batch of n requests.
+// When either holds fewer, nothing is taken and AllowN returns false with how long until the
+// batch is allowed, or 0 when n is over the limit and the batch never will be.
+func (l *RateLimiter) AllowN(endpoint, client string, cfg RateLimitConfig, n int) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.pruneLocked(now)
+	var buckets []*tokenBucket
+	if cfg.Endpoint.Requests > 0 {
+		buckets = append(buckets, l.bucketLocked(endpoint, cfg.Endpoint, now))
+	}
+	if cfg.Client.Requests > 0 {
+		buckets = append(buckets, l.bucketLocked(endpoint+"\x00"+client, cfg.Client, now))
+	}
+	var wait time.Duration
+	for _, b := range buckets {
+		if n > b.limit.Requests {
+			return false, 0
+		}
+		if b.tokens < float64(n) {
+			wait = max(wait, b.wait(n))
+		}
+	}
+	if wait > 0 {
+		return false, wait
+	}
+	for _, b := range buckets {
+		b.tokens -= float64(n)
+	}
+	return true, 0
+}
+
+// bucketLocked returns the refilled bucket of key, creating a full one. The caller holds l.mu.
+func (l *RateLimiter) bucketLocked(key string, limit RateLimit, now time.Time) *tokenBucket {
+	b := l.buckets[key]
+	if b == nil {
+		b = newTokenBucket(limit, now)
+		l.buckets[key] = b
+		return b
+	}
+	b.refill(limit, now)
+	return b
+}
+
+// pruneLocked drops the buckets that refilled completely, so clients seen once do not
+// accumulate. The caller holds l.mu.
+func (l *RateLimiter) pruneLocked(now time.Time) {
+	if now.Sub(l.prunedAt) < rateLimitPruneInterval {
+		return
+	}
+	l.prunedAt = now
+	for key, b := range l.buckets {
+		if b.full(now) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Client identifies the client of a request for its rate limit: a hash of its API key or
+// Authorization header when the limiter verifies it, so credentials are not kept in memory,
+// or else its IP address. Forwarding headers are not trusted, as any client could set them.
+func (l *RateLimiter) Client(r *http.Request) string {
+	if l.verify != nil && l.verify(r) {
+		for _, header := range []string{"Authorization", "X-API-Key", "Api-Key"} {
+			if v := r.Header.Get(header); v != "" {
+				sum := sha256.Sum256([]byte(v))
+				return "key:" + hex.EncodeToString(sum[:8])
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// rateLimitCost returns the requests an HTTP request counts as: the messages of a JSON-RPC
+// batch, or 1. The body is read and put back for the next handler.
+func rateLimitCost(r *http.Request) int {
+	if r.Method != http.MethodPost || r.Body == nil {
+		return 1
+	}
+	data, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil || !isBatch(data) {
+		return 1
+	}
+	var batch []json.RawMessage
+	if json.Unmarshal(data, &batch) != nil || len(batch) == 0 {
+		return 1
+	}
+	return len(batch)
+}
+
+// RateLimitHandler serves the requests of next allowed by cfg, counted against endpoint in
+// limiter, a JSON-RPC batch counting as its messages. Other requests are answered with 429
+// Too Many Requests, a Retry-After header unless the batch is over the limit, and a JSON-RPC
+// error of type unavailable. A disabled config returns next unchanged.
+func RateLimitHandler(limiter *RateLimiter, endpoint string, cfg RateLimitConfig, next http.Handler) http.Handler {
+	if limiter == nil || !cfg.Enabled() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cost := rateLimitCost(r)
+		ok, wait := limiter.AllowN(endpoint, limiter.Client(r), cfg, cost)
+		if ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		seconds := int(math.Ceil(wait.Seconds()))
+		message := fmt.Sprintf("Rate limit exceeded, retry in %ds", seconds)
+		if wait == 0 {
+			message = fmt.Sprintf("Rate limit exceeded: a batch of %d messages is over the limit", cost)
+		}
+		response := mcp.JSONRPCError{
+			JSONRPC: mcp.JSONRPC_VERSION,
+			ID:      mcp.NewRequestId(nil),
+			Error: struct {
+				Code    int    `json:"code"`
+				Message string `json:"message"`
+				Details any    `json:"details,omitempty"`
+			}{
+				Code:    mcp.INTERNAL_ERROR,
+				Message: message,
+				Details: apierrors.DetailsOf(apierrors.New(apierrors.TypeUnavailable, message, "rate limit of "+endpoint)),
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(response)
+	})
+}
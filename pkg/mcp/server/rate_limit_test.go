@@ -0,0 +1,158 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		in   string
+		want RateLimitConfig
+	}{
+		{"", RateLimitConfig{}},
+		{"100/m", RateLimitConfig{Endpoint: RateLimit{100, time.Minute}}},
+		{"endpoint=1000/hour, client=5/10s", RateLimitConfig{Endpoint: RateLimit{1000, time.Hour}, Client: RateLimit{5, 10 * time.Second}}},
+		{"client=2/s", RateLimitConfig{Client: RateLimit{2, time.Second}}},
+	}
+	for _, tt := range tests {
+		got, err := ParseRateLimit(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseRateLimit(%q) = %+v, %v; want %+v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"100", "x/m", "-1/m", "10/fortnight", "tenant=10/m"} {
+		if _, err := ParseRateLimit(bad); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func TestRateLimiterBuckets(t *testing.T) {
+	limiter := NewRateLimiter()
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+	cfg := RateLimitConfig{Endpoint: RateLimit{3, time.Minute}, Client: RateLimit{2, time.Minute}}
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := limiter.Allow("/pets", "a", cfg); !ok {
+			t.Fatalf("Expected request %d of client a to be allowed", i+1)
+		}
+	}
+	if ok, wait := limiter.Allow("/pets", "a", cfg); ok || wait != 30*time.Second {
+		t.Errorf("Expected client a to wait 30s for a token, got %v, %s", ok, wait)
+	}
+	if ok, _ := limiter.Allow("/pets", "b", cfg); !ok {
+		t.Error("Expected client b to be allowed")
+	}
+	if ok, _ := limiter.Allow("/pets", "c", cfg); ok {
+		t.Error("Expected the endpoint limit to be shared by all clients")
+	}
+	if ok, _ := limiter.Allow("/other", "c", cfg); !ok {
+		t.Error("Expected other endpoints to have their own limit")
+	}
+
+	now = now.Add(30 * time.Second)
+	if ok, _ := limiter.Allow("/pets", "a", cfg); !ok {
+		t.Error("Expected a token to be refilled after 30s")
+	}
+
+	now = now.Add(2 * rateLimitPruneInterval)
+	limiter.Allow("/pets", "a", cfg)
+	if len(limiter.buckets) != 2 {
+		t.Errorf("Expected the idle buckets to be pruned, got %d buckets", len(limiter.buckets))
+	}
+}
+
+func TestRateLimitHandler(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	// Only key-1 and key-2 are genuine credentials
+	limiter := NewRateLimiter(WithVerifiedCredentials(func(r *http.Request) bool {
+		key := r.Header.Get("X-API-Key")
+		return key == "key-1" || key == "key-2"
+	}))
+	handler := RateLimitHandler(limiter, "/pets", RateLimitConfig{Client: RateLimit{1, time.Minute}}, next)
+
+	request := func(apiKey, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/pets", nil)
+		req.RemoteAddr = remoteAddr
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := request("key-1", "10.0.0.1:1000"); rec.Code != http.StatusOK {
+		t.Fatalf("Expected the first request to be allowed, got %d", rec.Code)
+	}
+	rec := request("key-1", "10.0.0.2:1000")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "60" {
+		t.Fatalf("Expected 429 with Retry-After for the same API key, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+			Details struct {
+				Type string `json:"type"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error.Details.Type != "unavailable" || body.Error.Message != "Rate limit exceeded, retry in 60s" {
+		t.Errorf("Expected a JSON-RPC error of type unavailable, got %s", rec.Body.String())
+	}
+	if rec := request("key-2", "10.0.0.1:1000"); rec.Code != http.StatusOK {
+		t.Errorf("Expected another API key to have its own limit, got %d", rec.Code)
+	}
+	if rec := request("", "10.0.0.1:2000"); rec.Code != http.StatusOK {
+		t.Errorf("Expected a client without a key to be limited by IP, got %d", rec.Code)
+	}
+	if rec := request("", "10.0.0.1:3000"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected the same IP to share its limit, got %d", rec.Code)
+	}
+	// Made-up credentials do not get a bucket of their own
+	if rec := request("bogus-1", "10.0.0.3:1000"); rec.Code != http.StatusOK {
+		t.Errorf("Expected the first request of a new IP to be allowed, got %d", rec.Code)
+	}
+	if rec := request("bogus-2", "10.0.0.3:1000"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected an unverified key to be limited by IP, got %d", rec.Code)
+	}
+}
+
+func TestRateLimitHandlerBatches(t *testing.T) {
+	var served []string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		served = append(served, string(data))
+	})
+	handler := RateLimitHandler(NewRateLimiter(), "/pets", RateLimitConfig{Endpoint: RateLimit{5, time.Minute}}, next)
+
+	request := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/pets", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	ping := `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+	batch := "[" + strings.Repeat(ping+",", 3) + ping + "]"
+
+	if rec := request(batch); rec.Code != http.StatusOK || len(served) != 1 || served[0] != batch {
+		t.Fatalf("Expected the batch to be served with its body, got %d %q", rec.Code, served)
+	}
+	if rec := request(batch); rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "36" {
+		t.Errorf("Expected a batch over the remaining tokens to be refused, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec := request(ping); rec.Code != http.StatusOK {
+		t.Errorf("Expected the last token to serve a single request, got %d", rec.Code)
+	}
+	large := "[" + strings.Repeat(ping+",", 5) + ping + "]"
+	if rec := request(large); rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "" {
+		t.Errorf("Expected a batch over the limit to be refused without Retry-After, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"sort"
 	"sync"
 
+	"github.com/ubermorgenland/openapi-mcp/pkg/apierrors"
 	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
 )
 
@@ -111,7 +112,7 @@ func (e *requestError) ToJSONRPCError() mcp.JSONRPCError {
 		}{
 			Code:    e.code,
 			Message: e.err.Error(),
-			Details: nil,
+			Details: apierrors.DetailsOf(e.err),
 		},
 	}
 }
@@ -148,8 +149,11 @@ type MCPServer struct {
 	notificationHandlers   map[string]NotificationHandlerFunc
 	capabilities           serverCapabilities
 	paginationLimit        *int
+	toolsPageSize          *int
 	sessions               sync.Map
 	hooks                  *Hooks
+	transcripts            *TranscriptRecorder
+	backpressure           *NotificationBackpressure
 }
 
 // WithPaginationLimit sets the pagination limit for the server.
@@ -159,6 +163,28 @@ func WithPaginationLimit(limit int) ServerOption {
 	}
 }
 
+// WithToolsPageSize sets the number of tools per tools/list page, overriding the pagination
+// limit for tools only. Clients fetch the next page with the returned nextCursor.
+func WithToolsPageSize(size int) ServerOption {
+	return func(s *MCPServer) {
+		if size > 0 {
+			s.toolsPageSize = &size
+		}
+	}
+}
+
+// ToolsPageSize returns the number of tools per tools/list page, or 0 when tools are not
+// paginated.
+func (s *MCPServer) ToolsPageSize() int {
+	if s.toolsPageSize != nil {
+		return *s.toolsPageSize
+	}
+	if s.paginationLimit != nil {
+		return *s.paginationLimit
+	}
+	return 0
+}
+
 // serverCapabilities defines the supported features of the MCP server
 type serverCapabilities struct {
 	tools     *toolCapabilities
@@ -217,6 +243,14 @@ func WithToolFilter(
 	}
 }
 
+// AddToolFilter adds a filter function that will be applied to tools before they are returned in list_tools.
+// It is the runtime equivalent of WithToolFilter.
+func (s *MCPServer) AddToolFilter(toolFilter ToolFilterFunc) {
+	s.toolFiltersMu.Lock()
+	s.toolFilters = append(s.toolFilters, toolFilter)
+	s.toolFiltersMu.Unlock()
+}
+
 // WithRecovery adds a middleware that recovers from panics in tool handlers.
 func WithRecovery() ServerOption {
 	return WithToolHandlerMiddleware(func(next ToolHandlerFunc) ToolHandlerFunc {
@@ -556,6 +590,9 @@ func (s *MCPServer) handleInitialize(
 	}
 
 	if session := ClientSessionFromContext(ctx); session != nil {
+		if withClient, ok := session.(SessionWithClientInfo); ok {
+			withClient.SetClientInfo(request.Params.ClientInfo)
+		}
 		session.Initialize()
 	}
 	return &result, nil
@@ -618,11 +655,22 @@ func listByPagination[T mcp.Named](
 	cursor mcp.Cursor,
 	allElements []T,
 ) ([]T, mcp.Cursor, error) {
+	limit := 0
+	if s.paginationLimit != nil {
+		limit = *s.paginationLimit
+	}
+	return paginate(cursor, allElements, limit)
+}
+
+// paginate returns the page of at most limit elements, sorted by name, following the
+// cursor, and the cursor of the next page, or "" on the last page. A limit of 0 returns
+// all the elements following the cursor.
+func paginate[T mcp.Named](cursor mcp.Cursor, allElements []T, limit int) ([]T, mcp.Cursor, error) {
 	startPos := 0
 	if cursor != "" {
 		c, err := base64.StdEncoding.DecodeString(string(cursor))
 		if err != nil {
-			return nil, "", err
+			return nil, "", fmt.Errorf("invalid cursor: %w", err)
 		}
 		cString := string(c)
 		startPos = sort.Search(len(allElements), func(i int) bool {
@@ -630,21 +678,16 @@ func listByPagination[T mcp.Named](
 		})
 	}
 	endPos := len(allElements)
-	if s.paginationLimit != nil {
-		if len(allElements) > startPos+*s.paginationLimit {
-			endPos = startPos + *s.paginationLimit
-		}
+	if limit > 0 && len(allElements) > startPos+limit {
+		endPos = startPos + limit
 	}
 	elementsToReturn := allElements[startPos:endPos]
-	// set the next cursor
-	nextCursor := func() mcp.Cursor {
-		if s.paginationLimit != nil && len(elementsToReturn) >= *s.paginationLimit {
-			nc := elementsToReturn[len(elementsToReturn)-1].GetName()
-			toString := base64.StdEncoding.EncodeToString([]byte(nc))
-			return mcp.Cursor(toString)
-		}
-		return ""
-	}()
+	// set the next cursor, unless this is the last page
+	var nextCursor mcp.Cursor
+	if endPos < len(allElements) {
+		nc := elementsToReturn[len(elementsToReturn)-1].GetName()
+		nextCursor = mcp.Cursor(base64.StdEncoding.EncodeToString([]byte(nc)))
+	}
 	return elementsToReturn, nextCursor, nil
 }
 
@@ -862,6 +905,28 @@ func (s *MCPServer) handleListTools(
 	id any,
 	request mcp.ListToolsRequest,
 ) (*mcp.ListToolsResult, *requestError) {
+	// Apply pagination
+	toolsToReturn, nextCursor, err := paginate(request.Params.Cursor, s.listedTools(ctx), s.ToolsPageSize())
+	if err != nil {
+		return nil, &requestError{
+			id:   id,
+			code: mcp.INVALID_PARAMS,
+			err:  err,
+		}
+	}
+
+	result := mcp.ListToolsResult{
+		Tools: toolsToReturn,
+		PaginatedResult: mcp.PaginatedResult{
+			NextCursor: nextCursor,
+		},
+	}
+	return &result, nil
+}
+
+// listedTools returns the tools listed to the session in the context, sorted by name: the
+// server's tools, overridden by the session's own, passed through the tool filters.
+func (s *MCPServer) listedTools(ctx context.Context) []mcp.Tool {
 	// Get the base tools from the server
 	s.toolsMu.RLock()
 	tools := make([]mcp.Tool, 0, len(s.tools))
@@ -923,28 +988,7 @@ func (s *MCPServer) handleListTools(
 	}
 	s.toolFiltersMu.RUnlock()
 
-	// Apply pagination
-	toolsToReturn, nextCursor, err := listByPagination(
-		ctx,
-		s,
-		request.Params.Cursor,
-		tools,
-	)
-	if err != nil {
-		return nil, &requestError{
-			id:   id,
-			code: mcp.INVALID_PARAMS,
-			err:  err,
-		}
-	}
-
-	result := mcp.ListToolsResult{
-		Tools: toolsToReturn,
-		PaginatedResult: mcp.PaginatedResult{
-			NextCursor: nextCursor,
-		},
-	}
-	return &result, nil
+	return tools
 }
 
 func (s *MCPServer) handleToolCall(
@@ -1076,6 +1120,16 @@ func (s *MCPServer) ListTools() []mcp.Tool {
 	return tools
 }
 
+// CallTool calls a tool the way a tools/call request does, through the session's tools and
+// the tool handler middlewares, for other capabilities that are backed by tools.
+func (s *MCPServer) CallTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	result, reqErr := s.handleToolCall(ctx, nil, request)
+	if reqErr != nil {
+		return nil, reqErr.err
+	}
+	return result, nil
+}
+
 // protocolVersion negotiates the MCP protocol version with the client.
 func (s *MCPServer) protocolVersion(clientVersion string) string {
 	if slices.Contains(mcp.ValidProtocolVersions, clientVersion) {
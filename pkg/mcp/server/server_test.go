@@ -3,8 +3,10 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"testing"
 
+	"github.com/ubermorgenland/openapi-mcp/pkg/apierrors"
 	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
 )
 
@@ -88,3 +90,71 @@ func TestMCPServer_ProtocolNegotiation(t *testing.T) {
 		})
 	}
 }
+
+func TestMCPServer_ToolErrorDetails(t *testing.T) {
+	server := NewMCPServer("test-server", "1.0.0")
+	server.AddTool(mcp.NewTool("failing"), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return nil, apierrors.Wrap(errors.New("connection refused"), apierrors.TypeNetwork, "upstream request failed")
+	})
+
+	resp := server.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"failing","arguments":{}}}`))
+	rpcErr, ok := resp.(mcp.JSONRPCError)
+	if !ok {
+		t.Fatalf("expected JSONRPCError, got %T", resp)
+	}
+	details, ok := rpcErr.Error.Details.(*apierrors.Details)
+	if !ok || details.Type != apierrors.TypeNetwork || details.Details != "connection refused" {
+		t.Errorf("expected network error details, got %#v", rpcErr.Error.Details)
+	}
+}
+
+func TestErrorTaxonomy(t *testing.T) {
+	wrapped := apierrors.Wrap(ErrSessionNotFound, apierrors.TypeOf(ErrSessionNotFound), "lookup failed")
+	if !errors.Is(wrapped, ErrSessionNotFound) {
+		t.Errorf("expected wrapped error to match its sentinel")
+	}
+	if got := apierrors.HTTPStatus(apierrors.TypeOf(wrapped)); got != 404 {
+		t.Errorf("expected 404 for session not found, got %d", got)
+	}
+	if got := apierrors.TypeOf(NewMissingAuthError()); got != apierrors.TypeAuth {
+		t.Errorf("expected authentication type, got %s", got)
+	}
+	if got := apierrors.TypeOf(errors.New("plain")); got != apierrors.TypeInternal {
+		t.Errorf("expected unclassified errors to be internal, got %s", got)
+	}
+}
+
+func TestToolsPagination(t *testing.T) {
+	server := NewMCPServer("test", "1.0.0", WithToolsPageSize(2))
+	for _, name := range []string{"a", "b", "c", "d"} {
+		server.AddTool(mcp.NewTool(name), nil)
+	}
+
+	var names []string
+	var cursor mcp.Cursor
+	pages := 0
+	for {
+		request := mcp.ListToolsRequest{}
+		request.Params.Cursor = cursor
+		result, reqErr := server.handleListTools(context.Background(), 1, request)
+		if reqErr != nil {
+			t.Fatalf("unexpected error: %v", reqErr.err)
+		}
+		pages++
+		for _, tool := range result.Tools {
+			names = append(names, tool.Name)
+		}
+		if cursor = result.NextCursor; cursor == "" {
+			break
+		}
+	}
+	if pages != 2 || len(names) != 4 || names[0] != "a" || names[3] != "d" {
+		t.Errorf("expected all tools in 2 pages without an empty last page, got %d pages of %v", pages, names)
+	}
+
+	request := mcp.ListToolsRequest{}
+	request.Params.Cursor = "not base64!"
+	if _, reqErr := server.handleListTools(context.Background(), 1, request); reqErr == nil || reqErr.code != mcp.INVALID_PARAMS {
+		t.Errorf("expected an invalid cursor to be rejected with invalid params, got %v", reqErr)
+	}
+}
@@ -166,24 +166,8 @@ func (s *MCPServer) SendNotificationToAllClients(
 
 	s.sessions.Range(func(k, v any) bool {
 		if session, ok := v.(ClientSession); ok && session.Initialized() {
-			select {
-			case session.NotificationChannel() <- notification:
-				// Successfully sent notification
-			default:
-				// Channel is blocked, if there's an error hook, use it
-				if s.hooks != nil && len(s.hooks.OnError) > 0 {
-					err := ErrNotificationChannelBlocked
-					// Copy hooks pointer to local variable to avoid race condition
-					hooks := s.hooks
-					go func(sessionID string, hooks *Hooks) {
-						ctx := context.Background()
-						// Use the error hook to report the blocked channel
-						hooks.onError(ctx, nil, "notification", map[string]any{
-							"method":    method,
-							"sessionID": sessionID,
-						}, fmt.Errorf("notification channel blocked for session %s: %w", sessionID, err))
-					}(session.SessionID(), hooks)
-				}
+			if err := s.deliverNotification(context.Background(), session, notification); err != nil {
+				s.reportBlockedNotification(context.Background(), session.SessionID(), method)
 			}
 		}
 		return true
@@ -211,25 +195,11 @@ func (s *MCPServer) SendNotificationToClient(
 		},
 	}
 
-	select {
-	case session.NotificationChannel() <- notification:
-		return nil
-	default:
-		// Channel is blocked, if there's an error hook, use it
-		if s.hooks != nil && len(s.hooks.OnError) > 0 {
-			err := ErrNotificationChannelBlocked
-			// Copy hooks pointer to local variable to avoid race condition
-			hooks := s.hooks
-			go func(sessionID string, hooks *Hooks) {
-				// Use the error hook to report the blocked channel
-				hooks.onError(ctx, nil, "notification", map[string]any{
-					"method":    method,
-					"sessionID": sessionID,
-				}, fmt.Errorf("notification channel blocked for session %s: %w", sessionID, err))
-			}(session.SessionID(), hooks)
-		}
-		return ErrNotificationChannelBlocked
+	if err := s.deliverNotification(ctx, session, notification); err != nil {
+		s.reportBlockedNotification(ctx, session.SessionID(), method)
+		return err
 	}
+	return nil
 }
 
 // SendNotificationToSpecificClient sends a notification to a specific client by session ID
@@ -258,26 +228,11 @@ func (s *MCPServer) SendNotificationToSpecificClient(
 		},
 	}
 
-	select {
-	case session.NotificationChannel() <- notification:
-		return nil
-	default:
-		// Channel is blocked, if there's an error hook, use it
-		if s.hooks != nil && len(s.hooks.OnError) > 0 {
-			err := ErrNotificationChannelBlocked
-			ctx := context.Background()
-			// Copy hooks pointer to local variable to avoid race condition
-			hooks := s.hooks
-			go func(sID string, hooks *Hooks) {
-				// Use the error hook to report the blocked channel
-				hooks.onError(ctx, nil, "notification", map[string]any{
-					"method":    method,
-					"sessionID": sID,
-				}, fmt.Errorf("notification channel blocked for session %s: %w", sID, err))
-			}(sessionID, hooks)
-		}
-		return ErrNotificationChannelBlocked
+	if err := s.deliverNotification(context.Background(), session, notification); err != nil {
+		s.reportBlockedNotification(context.Background(), sessionID, method)
+		return err
 	}
+	return nil
 }
 
 // AddSessionTool adds a tool for a specific session
@@ -0,0 +1,266 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+// SessionSummary describes a session for operators, e.g. in an admin API. The session ID
+// authenticates the requests of the session, so it is not serialized: Ref identifies the
+// session instead.
+type SessionSummary struct {
+	ID         string     `json:"-"`
+	Ref        string     `json:"ref"` // see SessionRef
+	Client     string     `json:"client,omitempty"`
+	Connected  bool       `json:"connected"` // has an open stream or in-flight request
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	// NotificationsDropped counts the notifications dropped because the client did not read
+	// them fast enough; see WithNotificationBackpressure
+	NotificationsDropped int64 `json:"notifications_dropped,omitempty"`
+}
+
+// sessionWithClose is implemented by sessions holding an open stream that can be closed
+// from outside the handler serving it.
+type sessionWithClose interface {
+	Close()
+}
+
+// sessionIdWithExpiry is implemented by session id managers issuing IDs that expire, such
+// as JWTSessionIdManager.
+type sessionIdWithExpiry interface {
+	ExpiresAt(sessionID string) (time.Time, bool)
+}
+
+// ListSessions returns the sessions currently registered with the server, sorted by ID.
+func (s *MCPServer) ListSessions() []SessionSummary {
+	var summaries []SessionSummary
+	s.sessions.Range(func(key, value any) bool {
+		session, ok := value.(ClientSession)
+		if !ok {
+			return true
+		}
+		summaries = append(summaries, summarizeSession(session))
+		return true
+	})
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].ID < summaries[j].ID })
+	return summaries
+}
+
+// TerminateSession unregisters a session and closes its stream, if any.
+// It returns ErrSessionNotFound if no session with that ID is registered.
+func (s *MCPServer) TerminateSession(ctx context.Context, sessionID string) error {
+	value, ok := s.sessions.Load(sessionID)
+	if !ok {
+		return ErrSessionNotFound
+	}
+	s.UnregisterSession(ctx, sessionID)
+	if closer, ok := value.(sessionWithClose); ok {
+		closer.Close()
+	}
+	return nil
+}
+
+// SessionRef returns a reference to a session that can be shown to operators without
+// letting them take the session over: the first 16 hex digits of the SHA-256 of its ID.
+func SessionRef(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return hex.EncodeToString(sum[:8])
+}
+
+func summarizeSession(session ClientSession) SessionSummary {
+	summary := SessionSummary{ID: session.SessionID(), Ref: SessionRef(session.SessionID()), Connected: true, NotificationsDropped: NotificationsDropped(session)}
+	if withClient, ok := session.(SessionWithClientInfo); ok {
+		summary.Client = clientName(withClient.GetClientInfo())
+	}
+	if withCreated, ok := session.(interface{ GetCreatedAt() time.Time }); ok {
+		if created := withCreated.GetCreatedAt(); !created.IsZero() {
+			summary.CreatedAt = &created
+		}
+	}
+	if withExp, ok := session.(SessionWithExpiration); ok {
+		expires := withExp.GetExpiresAt()
+		summary.ExpiresAt = &expires
+	}
+	return summary
+}
+
+// clientName returns the name/version of a client, or "" when unknown.
+func clientName(info mcp.Implementation) string {
+	if info.Name == "" || info.Version == "" {
+		return info.Name
+	}
+	return info.Name + "/" + info.Version
+}
+
+// trackSession records activity on a session ID in the session store, and reports whether
+// the session was terminated, possibly by another server sharing the store. The session is
+// served when the store fails, so a store outage does not take sessions down.
+func (s *StreamableHTTPServer) trackSession(ctx context.Context, sessionID string) (terminated bool) {
+	if sessionID == "" {
+		return false
+	}
+	now := time.Now()
+	record, err := s.sessionStore.Load(ctx, s.endpointPath, sessionID)
+	if err != nil {
+		s.logger.Errorf("Failed to load session %s: %v", sessionID, err)
+	}
+	if record == nil {
+		record = &SessionRecord{Endpoint: s.endpointPath, ID: sessionID, CreatedAt: now}
+	} else if record.Terminated {
+		return true
+	}
+	s.markServed(sessionID)
+	record.LastSeenAt = now
+	record.ExpiresAt = now.Add(DefaultSessionTimeout)
+	if err := s.sessionStore.Save(ctx, *record); err != nil {
+		s.logger.Errorf("Failed to save session %s: %v", sessionID, err)
+	}
+	return false
+}
+
+// markTerminated keeps a terminated session in the store until it would have expired, or
+// until its ID does when the session id manager issues IDs with an expiry, so every server
+// sharing the store refuses its ID, including ones built after this one.
+func (s *StreamableHTTPServer) markTerminated(ctx context.Context, sessionID string, record *SessionRecord) error {
+	now := time.Now()
+	if record == nil {
+		record = &SessionRecord{Endpoint: s.endpointPath, ID: sessionID, CreatedAt: now, LastSeenAt: now}
+	}
+	record.Terminated = true
+	record.ExpiresAt = now.Add(DefaultSessionTimeout)
+	if manager, ok := s.sessionIdManager.(sessionIdWithExpiry); ok {
+		if expiresAt, ok := manager.ExpiresAt(sessionID); ok && expiresAt.After(record.ExpiresAt) {
+			record.ExpiresAt = expiresAt
+		}
+	}
+	return s.sessionStore.Save(ctx, *record)
+}
+
+// Sessions returns the sessions of this endpoint: those in the session store, which are seen
+// by the streamable-http transport within the session timeout, plus any other session
+// registered with the MCP server (e.g. SSE).
+func (s *StreamableHTTPServer) Sessions() []SessionSummary {
+	byID := map[string]SessionSummary{}
+	for _, summary := range s.server.ListSessions() {
+		byID[summary.ID] = summary
+	}
+	records, err := s.sessionStore.List(context.Background(), s.endpointPath)
+	if err != nil {
+		s.logger.Errorf("Failed to list sessions: %v", err)
+	}
+	for _, record := range records {
+		if record.Terminated {
+			continue
+		}
+		summary, ok := byID[record.ID]
+		if !ok {
+			summary = SessionSummary{ID: record.ID, Ref: SessionRef(record.ID), Client: record.Client}
+		}
+		created, lastSeen, expires := record.CreatedAt, record.LastSeenAt, record.ExpiresAt
+		summary.CreatedAt = &created
+		summary.LastSeenAt = &lastSeen
+		summary.ExpiresAt = &expires
+		byID[record.ID] = summary
+	}
+	summaries := make([]SessionSummary, 0, len(byID))
+	for _, summary := range byID {
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].ID < summaries[j].ID })
+	return summaries
+}
+
+// TerminateSession force-terminates a session: the session id manager and the session store
+// mark the ID as terminated so further requests get 404, and any open stream is closed.
+// It returns ErrSessionNotFound if the session is unknown.
+func (s *StreamableHTTPServer) TerminateSession(ctx context.Context, sessionID string) error {
+	record, err := s.sessionStore.Load(ctx, s.endpointPath, sessionID)
+	if err != nil {
+		return err
+	}
+	known := record != nil && !record.Terminated
+	registered := s.server.TerminateSession(ctx, sessionID) == nil
+	if !known && !registered {
+		return ErrSessionNotFound
+	}
+	if _, err := s.sessionIdManager.Terminate(sessionID); err != nil {
+		return err
+	}
+	if err := s.markTerminated(ctx, sessionID, record); err != nil {
+		return err
+	}
+	s.served.Delete(sessionID)
+	s.sessionTools.set(sessionID, nil)
+	return nil
+}
+
+// markServed records that this transport served a request of a session.
+func (s *StreamableHTTPServer) markServed(sessionID string) {
+	s.served.Store(sessionID, time.Now())
+}
+
+// ServesSession reports whether a session is in progress on this transport: it has a request
+// or stream open on the MCP server, or had a request within idle and was not terminated. A
+// server being replaced keeps the sessions in progress on it until they end.
+func (s *StreamableHTTPServer) ServesSession(sessionID string, idle time.Duration) bool {
+	if _, open := s.server.sessions.Load(sessionID); open {
+		return true
+	}
+	value, ok := s.served.Load(sessionID)
+	return ok && time.Since(value.(time.Time)) < idle
+}
+
+// ServedSessions returns the IDs of the sessions in progress on this transport, as defined by
+// ServesSession, including the sessions of other transports of the same MCP server.
+func (s *StreamableHTTPServer) ServedSessions(idle time.Duration) []string {
+	ids := map[string]bool{}
+	s.server.sessions.Range(func(key, value any) bool {
+		ids[key.(string)] = true
+		return true
+	})
+	s.served.Range(func(key, value any) bool {
+		if time.Since(value.(time.Time)) < idle {
+			ids[key.(string)] = true
+		}
+		return true
+	})
+	served := make([]string, 0, len(ids))
+	for id := range ids {
+		served = append(served, id)
+	}
+	sort.Strings(served)
+	return served
+}
+
+// HasSession reports whether the SSE stream of a session is open on this server.
+func (s *SSEServer) HasSession(sessionID string) bool {
+	_, ok := s.sessions.Load(sessionID)
+	return ok
+}
+
+// SessionCount returns the number of open SSE streams.
+func (s *SSEServer) SessionCount() int {
+	count := 0
+	s.sessions.Range(func(key, value any) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// CloseSessions closes every open SSE stream; clients reconnect to start a new session.
+func (s *SSEServer) CloseSessions() {
+	s.sessions.Range(func(key, value any) bool {
+		if session, ok := value.(*sseSession); ok {
+			session.Close()
+		}
+		return true
+	})
+}
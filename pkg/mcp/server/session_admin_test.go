@@ -0,0 +1,145 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func postStreamable(t *testing.T, url, sessionID string, body map[string]any) *http.Response {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(string(data)))
+	req.Header.Set("Content-Type", "application/json")
+	if sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", sessionID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestStreamableHTTP_AdminTerminateSession(t *testing.T) {
+	mcpServer := NewMCPServer("test", "1.0.0")
+	streamable := NewStreamableHTTPServer(mcpServer)
+	testServer := httptest.NewServer(streamable)
+	defer testServer.Close()
+
+	resp := postStreamable(t, testServer.URL, "", map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "initialize",
+		"params":  map[string]any{"protocolVersion": "2025-03-26", "clientInfo": map[string]any{"name": "test-client", "version": "1.0.0"}},
+	})
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+	if sessionID == "" {
+		t.Fatal("Expected session ID in response header")
+	}
+
+	sessions := streamable.Sessions()
+	if len(sessions) != 1 || sessions[0].ID != sessionID || sessions[0].ExpiresAt == nil {
+		t.Fatalf("Expected the initialized session to be listed, got %+v", sessions)
+	}
+	// The listing identifies the session by its ref, never by the ID authenticating it
+	data, _ := json.Marshal(sessions[0])
+	if strings.Contains(string(data), sessionID) || sessions[0].Ref != SessionRef(sessionID) || !strings.Contains(string(data), `"ref":"`+sessions[0].Ref+`"`) {
+		t.Errorf("Expected the session to be serialized with its ref only, got %s", data)
+	}
+
+	if err := streamable.TerminateSession(context.Background(), sessionID); err != nil {
+		t.Fatalf("TerminateSession: %v", err)
+	}
+	if len(streamable.Sessions()) != 0 {
+		t.Errorf("Expected no sessions after termination, got %+v", streamable.Sessions())
+	}
+
+	ping := map[string]any{"jsonrpc": "2.0", "id": 2, "method": "ping"}
+	if resp := postStreamable(t, testServer.URL, sessionID, ping); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for a terminated session, got %d", resp.StatusCode)
+	}
+	if err := streamable.TerminateSession(context.Background(), sessionID); err != ErrSessionNotFound {
+		t.Errorf("Expected ErrSessionNotFound for an unknown session, got %v", err)
+	}
+}
+
+func TestSSEServer_AdminTerminateClosesStream(t *testing.T) {
+	mcpServer := NewMCPServer("test", "1.0.0")
+	sseServer := NewSSEServer(mcpServer)
+	testServer := httptest.NewServer(sseServer)
+	defer testServer.Close()
+
+	resp, err := http.Get(testServer.URL + "/sse")
+	if err != nil {
+		t.Fatalf("Failed to open SSE stream: %v", err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	if _, err := reader.ReadString('\n'); err != nil { // endpoint event
+		t.Fatalf("Failed to read endpoint event: %v", err)
+	}
+
+	sessions := mcpServer.ListSessions()
+	if len(sessions) != 1 || sessions[0].CreatedAt == nil {
+		t.Fatalf("Expected one SSE session with a creation time, got %+v", sessions)
+	}
+	if err := mcpServer.TerminateSession(context.Background(), sessions[0].ID); err != nil {
+		t.Fatalf("TerminateSession: %v", err)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		for {
+			if _, err := reader.ReadString('\n'); err != nil {
+				close(closed)
+				return
+			}
+		}
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the SSE stream to be closed after termination")
+	}
+}
+
+func TestStreamableHTTP_ServesSession(t *testing.T) {
+	mcpServer := NewMCPServer("test", "1.0.0")
+	streamable := NewStreamableHTTPServer(mcpServer)
+	testServer := httptest.NewServer(streamable)
+	defer testServer.Close()
+
+	resp := postStreamable(t, testServer.URL, "", map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "initialize",
+		"params":  map[string]any{"protocolVersion": "2025-03-26", "clientInfo": map[string]any{"name": "test-client", "version": "1.0.0"}},
+	})
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+	if !streamable.ServesSession(sessionID, time.Minute) {
+		t.Fatal("Expected the initialized session to be in progress")
+	}
+	if served := streamable.ServedSessions(time.Minute); len(served) != 1 || served[0] != sessionID {
+		t.Errorf("Expected the session to be served, got %v", served)
+	}
+	if streamable.ServesSession(sessionID, 0) {
+		t.Error("Expected an idle session without open requests to be over")
+	}
+	if streamable.ServesSession("unknown", time.Minute) {
+		t.Error("Expected an unknown session not to be served")
+	}
+
+	if err := streamable.TerminateSession(context.Background(), sessionID); err != nil {
+		t.Fatalf("TerminateSession: %v", err)
+	}
+	if streamable.ServesSession(sessionID, time.Minute) {
+		t.Error("Expected a terminated session to be over")
+	}
+}
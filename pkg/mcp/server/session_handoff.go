@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"sync"
+)
+
+// sessionOwner tracks the server a listening session is registered with. Sessions move to
+// another server with AdoptSessions, so the handler serving the stream must unregister the
+// session from its current owner, not from the server it was opened on.
+type sessionOwner struct {
+	mu           sync.Mutex
+	server       *MCPServer
+	unregistered bool
+}
+
+func (o *sessionOwner) setOwner(s *MCPServer) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.server = s
+}
+
+// unregister removes the session from its current owner; it is not adopted after that.
+func (o *sessionOwner) unregister(ctx context.Context, sessionID string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.unregistered = true
+	if o.server != nil {
+		o.server.UnregisterSession(ctx, sessionID)
+	}
+}
+
+// adoptableSession is implemented by sessions that can move to another server: streamable
+// HTTP sessions with an open GET stream.
+type adoptableSession interface {
+	ClientSession
+	owner() *sessionOwner
+}
+
+// AdoptSessions moves the listening sessions of from, a server being replaced (e.g. when
+// its spec is reloaded), to s, so notifications s sends reach clients connected to from. It
+// returns the IDs of the moved sessions. Sessions that can't move, such as SSE sessions,
+// stay with from.
+func (s *MCPServer) AdoptSessions(ctx context.Context, from *MCPServer) []string {
+	if from == nil || from == s {
+		return nil
+	}
+	var adopted []string
+	from.sessions.Range(func(key, value any) bool {
+		session, ok := value.(adoptableSession)
+		if !ok {
+			return true
+		}
+		o := session.owner()
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		if o.unregistered || o.server != from {
+			return true
+		}
+		if _, exists := s.sessions.LoadOrStore(session.SessionID(), session); exists {
+			return true
+		}
+		from.UnregisterSession(ctx, session.SessionID())
+		s.hooks.RegisterSession(ctx, session)
+		o.server = s
+		adopted = append(adopted, session.SessionID())
+		return true
+	})
+	return adopted
+}
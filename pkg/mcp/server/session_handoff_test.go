@@ -0,0 +1,76 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+func TestAdoptSessionsMovesListeners(t *testing.T) {
+	oldServer := NewMCPServer("test", "1.0.0")
+	testServer := httptest.NewServer(NewStreamableHTTPServer(oldServer))
+	defer testServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, testServer.URL+"/mcp", nil)
+	req.Header.Set(headerKeySessionID, "listener-1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open the listening stream: %v", err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	for { // skip the endpoint event
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read endpoint event: %v", err)
+		}
+		if line == "\n" {
+			break
+		}
+	}
+
+	newServer := NewMCPServer("test", "1.0.0")
+	adopted := newServer.AdoptSessions(context.Background(), oldServer)
+	if len(adopted) != 1 || adopted[0] != "listener-1" {
+		t.Fatalf("Expected the listener to be adopted, got %v", adopted)
+	}
+	if len(oldServer.ListSessions()) != 0 || len(newServer.ListSessions()) != 1 {
+		t.Fatalf("Expected the session to move, old has %v and new has %v", oldServer.ListSessions(), newServer.ListSessions())
+	}
+
+	if err := newServer.SendNotificationToSpecificClient("listener-1", mcp.MethodNotificationToolsListChanged, nil); err != nil {
+		t.Fatalf("Failed to notify the adopted session: %v", err)
+	}
+	var event string
+	for !strings.HasSuffix(event, "\n\n") {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read the notification: %v", err)
+		}
+		event += line
+	}
+	if !strings.Contains(event, mcp.MethodNotificationToolsListChanged) {
+		t.Fatalf("Expected the notification on the original stream, got %q", event)
+	}
+
+	// Closing the stream unregisters the session from the server that adopted it
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(newServer.ListSessions()) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the session to be unregistered from the adopting server, got %v", newServer.ListSessions())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if adopted := NewMCPServer("test", "1.0.0").AdoptSessions(context.Background(), newServer); len(adopted) != 0 {
+		t.Errorf("Expected a closed session not to be adopted, got %v", adopted)
+	}
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultJWTSessionTTL is the lifetime of a JWT session ID when WithJWTSessionTTL is not used.
+// Idle sessions still expire after DefaultSessionTimeout; this bounds how long an active one lives.
+const DefaultJWTSessionTTL = 7 * 24 * time.Hour
+
+// jwtSessionHeader is the encoded JOSE header of every session ID: {"alg":"HS256","typ":"JWT"}
+var jwtSessionHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// jwtSessionClaims are the claims of a session ID.
+type jwtSessionClaims struct {
+	ID        string `json:"jti"`
+	Endpoint  string `json:"aud,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// JWTSessionIdManager issues session IDs that are HS256-signed JWTs carrying the session's
+// expiry and the endpoint it was issued for. IDs are validated from their signature and
+// claims alone, so no session table is needed and an ID cannot be forged, reused after it
+// expires, or replayed against another endpoint. Only terminated IDs are remembered, until
+// they expire: by the manager, and by the session store of the StreamableHTTPServer using
+// it, so a manager created later for the endpoint, e.g. when its server is rebuilt, still
+// refuses them. Instances sharing a secret accept each other's IDs.
+//
+// It is the recommended SessionIdManager for stateful servers:
+//
+//	manager := server.NewJWTSessionIdManager(secret, server.WithJWTSessionEndpoint("/petstore"))
+//	handler := server.NewStreamableHTTPServer(mcpServer, server.WithSessionIdManager(manager))
+type JWTSessionIdManager struct {
+	secret   []byte
+	endpoint string
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu         sync.Mutex
+	terminated map[string]int64 // jti -> expiry (Unix seconds)
+}
+
+// JWTSessionOption configures a JWTSessionIdManager.
+type JWTSessionOption func(*JWTSessionIdManager)
+
+// WithJWTSessionEndpoint binds session IDs to an endpoint path: IDs issued for another
+// endpoint are rejected.
+func WithJWTSessionEndpoint(endpoint string) JWTSessionOption {
+	return func(m *JWTSessionIdManager) {
+		m.endpoint = endpoint
+	}
+}
+
+// WithJWTSessionTTL sets how long a session ID is valid after it is issued.
+func WithJWTSessionTTL(ttl time.Duration) JWTSessionOption {
+	return func(m *JWTSessionIdManager) {
+		if ttl > 0 {
+			m.ttl = ttl
+		}
+	}
+}
+
+// NewJWTSessionIdManager creates a manager signing session IDs with secret, which should be
+// at least 32 random bytes.
+func NewJWTSessionIdManager(secret []byte, opts ...JWTSessionOption) *JWTSessionIdManager {
+	m := &JWTSessionIdManager{
+		secret:     append([]byte(nil), secret...),
+		ttl:        DefaultJWTSessionTTL,
+		now:        time.Now,
+		terminated: make(map[string]int64),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+func (m *JWTSessionIdManager) Generate() string {
+	now := m.now()
+	claims, _ := json.Marshal(jwtSessionClaims{
+		ID:        uuid.New().String(),
+		Endpoint:  m.endpoint,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(m.ttl).Unix(),
+	})
+	unsigned := jwtSessionHeader + "." + base64.RawURLEncoding.EncodeToString(claims)
+	return unsigned + "." + m.sign(unsigned)
+}
+
+func (m *JWTSessionIdManager) Validate(sessionID string) (isTerminated bool, err error) {
+	claims, err := m.parse(sessionID)
+	if err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, terminated := m.terminated[claims.ID]
+	return terminated, nil
+}
+
+func (m *JWTSessionIdManager) Terminate(sessionID string) (isNotAllowed bool, err error) {
+	claims, err := m.parse(sessionID)
+	if err != nil {
+		// Nothing to remember: an invalid or expired ID is rejected anyway
+		return false, nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now().Unix()
+	for id, exp := range m.terminated {
+		if exp <= now {
+			delete(m.terminated, id)
+		}
+	}
+	m.terminated[claims.ID] = claims.ExpiresAt
+	return false, nil
+}
+
+// ExpiresAt returns when a valid session ID expires.
+func (m *JWTSessionIdManager) ExpiresAt(sessionID string) (time.Time, bool) {
+	claims, err := m.parse(sessionID)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.ExpiresAt, 0), true
+}
+
+// parse verifies the signature, expiry and endpoint of a session ID and returns its claims
+func (m *JWTSessionIdManager) parse(sessionID string) (*jwtSessionClaims, error) {
+	parts := strings.Split(sessionID, ".")
+	if len(parts) != 3 || parts[0] != jwtSessionHeader {
+		return nil, errors.New("invalid session id: not a session token")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("invalid session id: malformed signature")
+	}
+	expected, _ := base64.RawURLEncoding.DecodeString(m.sign(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, expected) {
+		return nil, errors.New("invalid session id: bad signature")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.New("invalid session id: malformed claims")
+	}
+	var claims jwtSessionClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.ID == "" {
+		return nil, errors.New("invalid session id: malformed claims")
+	}
+	if m.now().Unix() >= claims.ExpiresAt {
+		return nil, errors.New("invalid session id: expired")
+	}
+	if m.endpoint != "" && claims.Endpoint != m.endpoint {
+		return nil, fmt.Errorf("invalid session id: issued for endpoint %q", claims.Endpoint)
+	}
+	return &claims, nil
+}
+
+func (m *JWTSessionIdManager) sign(unsigned string) string {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJWTSessionIdManager_Validate(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	manager := NewJWTSessionIdManager(secret, WithJWTSessionEndpoint("/petstore"), WithJWTSessionTTL(time.Hour))
+	id := manager.Generate()
+
+	if terminated, err := manager.Validate(id); err != nil || terminated {
+		t.Fatalf("Expected a fresh ID to be valid, got terminated=%v err=%v", terminated, err)
+	}
+	if id == manager.Generate() {
+		t.Error("Expected each generated ID to be unique")
+	}
+
+	// Another server sharing the secret accepts the ID without any shared state
+	other := NewJWTSessionIdManager(secret, WithJWTSessionEndpoint("/petstore"))
+	if _, err := other.Validate(id); err != nil {
+		t.Errorf("Expected an instance sharing the secret to accept the ID, got %v", err)
+	}
+
+	parts := strings.Split(id, ".")
+	forged := parts[0] + "." + parts[1] + "." + strings.Repeat("A", len(parts[2]))
+	invalid := map[string]string{
+		"empty":          "",
+		"uuid":           "mcp-session-8f1c3c3e-0d4a-4c55-9f56-9d3d1f0e8f6a",
+		"forged":         forged,
+		"other secret":   NewJWTSessionIdManager([]byte("another-secret-another-secret-!!"), WithJWTSessionEndpoint("/petstore")).Generate(),
+		"other endpoint": NewJWTSessionIdManager(secret, WithJWTSessionEndpoint("/weather")).Generate(),
+	}
+	for name, sessionID := range invalid {
+		if _, err := manager.Validate(sessionID); err == nil {
+			t.Errorf("Expected the %s ID to be rejected", name)
+		}
+	}
+
+	manager.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := manager.Validate(id); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("Expected an expired ID to be rejected, got %v", err)
+	}
+}
+
+func TestJWTSessionIdManager_Terminate(t *testing.T) {
+	manager := NewJWTSessionIdManager([]byte("0123456789abcdef0123456789abcdef"), WithJWTSessionTTL(time.Hour))
+	id := manager.Generate()
+
+	if notAllowed, err := manager.Terminate(id); err != nil || notAllowed {
+		t.Fatalf("Terminate: notAllowed=%v err=%v", notAllowed, err)
+	}
+	if terminated, err := manager.Validate(id); err != nil || !terminated {
+		t.Errorf("Expected a terminated ID to be reported as terminated, got terminated=%v err=%v", terminated, err)
+	}
+
+	// Invalid IDs are not remembered, and expired terminations are forgotten
+	manager.Terminate("not-a-session")
+	manager.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	manager.Terminate(manager.Generate())
+	if len(manager.terminated) != 1 {
+		t.Errorf("Expected only the live termination to be remembered, got %d", len(manager.terminated))
+	}
+}
+
+func TestStreamableHTTP_JWTSessionIds(t *testing.T) {
+	mcpServer := NewMCPServer("test", "1.0.0")
+	manager := NewJWTSessionIdManager([]byte("0123456789abcdef0123456789abcdef"), WithJWTSessionEndpoint("/mcp"))
+	testServer := NewTestStreamableHTTPServer(mcpServer, WithSessionIdManager(manager))
+	defer testServer.Close()
+
+	resp := postStreamable(t, testServer.URL+"/mcp", "", map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "initialize",
+		"params":  map[string]any{"protocolVersion": "2025-03-26", "clientInfo": map[string]any{"name": "test-client", "version": "1.0.0"}},
+	})
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+	if strings.Count(sessionID, ".") != 2 {
+		t.Fatalf("Expected a JWT session ID, got %q", sessionID)
+	}
+
+	ping := map[string]any{"jsonrpc": "2.0", "id": 2, "method": "ping"}
+	if resp := postStreamable(t, testServer.URL+"/mcp", sessionID, ping); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the signed session ID to be accepted, got %d", resp.StatusCode)
+	}
+	if resp := postStreamable(t, testServer.URL+"/mcp", sessionID+"x", ping); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected a tampered session ID to be rejected, got %d", resp.StatusCode)
+	}
+}
+
+func TestStreamableHTTP_JWTTerminationOutlivesManager(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	store := NewMemorySessionStore()
+	newServer := func() (*StreamableHTTPServer, *httptest.Server) {
+		manager := NewJWTSessionIdManager(secret, WithJWTSessionEndpoint("/mcp"))
+		streamable := NewStreamableHTTPServer(NewMCPServer("test", "1.0.0"),
+			WithEndpointPath("/mcp"), WithSessionIdManager(manager), WithSessionStore(store))
+		testServer := httptest.NewServer(streamable)
+		t.Cleanup(testServer.Close)
+		return streamable, testServer
+	}
+	streamable, testServer := newServer()
+
+	resp := postStreamable(t, testServer.URL+"/mcp", "", map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "initialize",
+		"params":  map[string]any{"protocolVersion": "2025-03-26", "clientInfo": map[string]any{"name": "test-client", "version": "1.0.0"}},
+	})
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+	if err := streamable.TerminateSession(context.Background(), sessionID); err != nil {
+		t.Fatalf("TerminateSession: %v", err)
+	}
+	record, _ := store.Load(context.Background(), "/mcp", sessionID)
+	if record == nil || !record.Terminated || record.ExpiresAt.Before(time.Now().Add(DefaultJWTSessionTTL-time.Minute)) {
+		t.Errorf("Expected the termination to be stored until the ID expires, got %+v", record)
+	}
+
+	// A server rebuilt with a new manager still refuses the ID, whose signature is valid
+	_, rebuilt := newServer()
+	ping := map[string]any{"jsonrpc": "2.0", "id": 2, "method": "ping"}
+	if resp := postStreamable(t, rebuilt.URL+"/mcp", sessionID, ping); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for a session terminated on the previous server, got %d", resp.StatusCode)
+	}
+}
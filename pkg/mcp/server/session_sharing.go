@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+// acquireSession returns the session registered for sessionID, registering a new one when
+// there is none, so the POST requests and the GET stream of a session share one session
+// object. Auth headers sent with the request replace the session's. The caller must call
+// release when its request ends; the session is unregistered when the last request holding
+// it is released.
+func (s *StreamableHTTPServer) acquireSession(ctx context.Context, sessionID string, authHeaders http.Header) (session *streamableHttpSession, release func()) {
+	for {
+		session = newStreamableHttpSessionWithHeaders(sessionID, s.sessionTools, s.server.newNotificationChannel(), authHeaders)
+		session.refs = 1
+		session.owner().setOwner(s.server)
+		err := s.server.RegisterSession(ctx, session)
+		if err == nil {
+			return session, func() { session.releaseRef(ctx) }
+		}
+		existing, _ := s.server.sessions.Load(sessionID)
+		shared, ok := existing.(*streamableHttpSession)
+		if !ok {
+			if existing != nil {
+				// Another transport holds this session ID; serve the request on its own
+				s.logger.Infof("Session %s is registered by another transport; using an unregistered session", sessionID)
+				return session, func() {}
+			}
+			// The session was unregistered in between
+			continue
+		}
+		if shared.retain() {
+			if len(authHeaders) > 0 {
+				shared.mergeAuthHeaders(authHeaders)
+			}
+			return shared, func() { shared.releaseRef(ctx) }
+		}
+		// The last request holding the session is unregistering it; register a new one
+		runtime.Gosched()
+	}
+}
+
+// retain adds a request to the session, unless the session is being unregistered.
+func (s *streamableHttpSession) retain() bool {
+	s.refMu.Lock()
+	defer s.refMu.Unlock()
+	if s.refs == 0 {
+		return false
+	}
+	s.refs++
+	return true
+}
+
+// releaseRef removes a request from the session, and unregisters the session after the
+// last one. It holds refMu while unregistering, so a concurrent retain waits for it.
+func (s *streamableHttpSession) releaseRef(ctx context.Context) {
+	s.refMu.Lock()
+	defer s.refMu.Unlock()
+	if s.refs == 0 {
+		return
+	}
+	s.refs--
+	if s.refs == 0 {
+		s.owner().unregister(context.WithoutCancel(ctx), s.sessionID)
+	}
+}
+
+// mergeAuthHeaders sets the given auth headers on the session, keeping the others.
+func (s *streamableHttpSession) mergeAuthHeaders(headers http.Header) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	merged := s.authHeaders.Clone()
+	if merged == nil {
+		merged = make(http.Header)
+	}
+	for key, values := range headers {
+		merged[key] = values
+	}
+	s.authHeaders = merged
+}
+
+// listen marks the session as having an open GET stream until the returned func is called.
+func (s *streamableHttpSession) listen() func() {
+	s.listeners.Add(1)
+	return func() { s.listeners.Add(-1) }
+}
+
+// listening reports whether a GET stream of the session is open; it then receives the
+// notifications not tied to a request.
+func (s *streamableHttpSession) listening() bool {
+	return s.listeners.Load() > 0
+}
+
+// streamableHttpRequestSession is the session as seen by one POST request: notifications
+// sent with the request's context, such as progress, are streamed in the request's response
+// rather than on the session's GET stream.
+type streamableHttpRequestSession struct {
+	*streamableHttpSession
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *streamableHttpRequestSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+func (s *streamableHttpRequestSession) notificationQueue() chan mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+var _ ClientSession = (*streamableHttpRequestSession)(nil)
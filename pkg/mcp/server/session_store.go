@@ -0,0 +1,161 @@
+package server
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SessionRecord is the state of a session kept between requests: when it was created, last
+// used and expires, and whether it was terminated. Records are kept per endpoint, so servers
+// of several APIs can share a store.
+type SessionRecord struct {
+	Endpoint   string    `json:"endpoint"`
+	ID         string    `json:"id"`
+	Client     string    `json:"client,omitempty"` // name/version of the MCP client, when known
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Terminated bool      `json:"terminated,omitempty"` // kept until it expires, so the ID is refused
+}
+
+// SessionStore keeps session records. Sharing a store, e.g. a RedisSessionStore, lets
+// sessions outlive a restart and be served by any replica behind a load balancer.
+// Session-specific tools hold handler functions, so they stay in the process that set them.
+type SessionStore interface {
+	// Load returns the record of a session, or nil if it is unknown or expired.
+	Load(ctx context.Context, endpoint, id string) (*SessionRecord, error)
+	// Save creates or replaces a record, until its ExpiresAt.
+	Save(ctx context.Context, record SessionRecord) error
+	// Delete removes a record; deleting an unknown record is not an error.
+	Delete(ctx context.Context, endpoint, id string) error
+	// List returns the records of an endpoint that have not expired, in no particular order.
+	List(ctx context.Context, endpoint string) ([]SessionRecord, error)
+}
+
+// WithSessionStore sets where the sessions of the streamable-http transport are kept; the
+// default, also used for a nil store, is a MemorySessionStore.
+func WithSessionStore(store SessionStore) StreamableHTTPOption {
+	return func(s *StreamableHTTPServer) {
+		if store != nil {
+			s.sessionStore = store
+		}
+	}
+}
+
+// WithSSESessionStore records the sessions of the SSE transport in store, so they are
+// listed by every replica sharing it. SSE sessions end with their stream.
+func WithSSESessionStore(store SessionStore) SSEOption {
+	return func(s *SSEServer) {
+		s.sessionStore = store
+	}
+}
+
+// MemorySessionStore keeps session records in memory, for one process.
+type MemorySessionStore struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	records map[string]SessionRecord // endpoint + " " + id
+}
+
+// NewMemorySessionStore creates an empty in-memory store.
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{now: time.Now, records: make(map[string]SessionRecord)}
+}
+
+func memorySessionKey(endpoint, id string) string {
+	return endpoint + " " + id
+}
+
+func (m *MemorySessionStore) Load(ctx context.Context, endpoint, id string) (*SessionRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record, ok := m.records[memorySessionKey(endpoint, id)]
+	if !ok || !record.ExpiresAt.After(m.now()) {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+func (m *MemorySessionStore) Save(ctx context.Context, record SessionRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[memorySessionKey(record.Endpoint, record.ID)] = record
+	return nil
+}
+
+func (m *MemorySessionStore) Delete(ctx context.Context, endpoint, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, memorySessionKey(endpoint, id))
+	return nil
+}
+
+func (m *MemorySessionStore) List(ctx context.Context, endpoint string) ([]SessionRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	var records []SessionRecord
+	for _, record := range m.records {
+		if record.Endpoint == endpoint && record.ExpiresAt.After(now) {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	return records, nil
+}
+
+// DeleteExpired removes the records that expired, so the store does not grow unbounded.
+func (m *MemorySessionStore) DeleteExpired() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	for key, record := range m.records {
+		if !record.ExpiresAt.After(now) {
+			delete(m.records, key)
+		}
+	}
+}
+
+var _ SessionStore = (*MemorySessionStore)(nil)
+
+// recordSSESession saves the record of an open SSE session, when the server has a store.
+func (s *SSEServer) recordSSESession(ctx context.Context, session *sseSession) {
+	if s.sessionStore == nil {
+		return
+	}
+	now := time.Now()
+	record := SessionRecord{
+		Endpoint:   s.basePath,
+		ID:         session.sessionID,
+		Client:     clientName(session.GetClientInfo()),
+		CreatedAt:  session.createdAt,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(DefaultSessionTimeout),
+	}
+	if err := s.sessionStore.Save(ctx, record); err != nil {
+		log.Printf("Failed to save session %s: %v", session.sessionID, err)
+	}
+}
+
+// forgetSSESession removes the record of an SSE session whose stream ended.
+func (s *SSEServer) forgetSSESession(sessionID string) {
+	if s.sessionStore == nil {
+		return
+	}
+	if err := s.sessionStore.Delete(context.Background(), s.basePath, sessionID); err != nil {
+		log.Printf("Failed to delete session %s: %v", sessionID, err)
+	}
+}
+
+// connectedElsewhere reports whether a session unknown to this server has its stream open
+// on another server sharing the store.
+func (s *SSEServer) connectedElsewhere(ctx context.Context, sessionID string) bool {
+	if s.sessionStore == nil {
+		return false
+	}
+	record, err := s.sessionStore.Load(ctx, s.basePath, sessionID)
+	return err == nil && record != nil && !record.Terminated
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRedisSessionPrefix prefixes the keys of a RedisSessionStore when the URL sets none.
+const DefaultRedisSessionPrefix = "mcp:session:"
+
+// RedisSessionStore keeps session records in Redis, one key per session with the record as
+// JSON and a TTL until it expires, so Redis drops expired sessions by itself.
+type RedisSessionStore struct {
+	*RedisClient
+	prefix string
+}
+
+// NewRedisSessionStore creates a store for a Redis URL:
+// redis://[user:password@]host[:port][/db][?prefix=keys:prefix:], or rediss:// for TLS.
+// Connections are opened when needed, so an unreachable Redis is only reported on use.
+func NewRedisSessionStore(rawURL string) (*RedisSessionStore, error) {
+	client, err := NewRedisClient(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	store := &RedisSessionStore{RedisClient: client, prefix: DefaultRedisSessionPrefix}
+	if u, _ := url.Parse(rawURL); u.Query().Get("prefix") != "" {
+		store.prefix = u.Query().Get("prefix")
+	}
+	return store, nil
+}
+
+func (r *RedisSessionStore) key(endpoint, id string) string {
+	return r.prefix + endpoint + ":" + id
+}
+
+func (r *RedisSessionStore) Load(ctx context.Context, endpoint, id string) (*SessionRecord, error) {
+	reply, err := r.Do(ctx, "GET", r.key(endpoint, id))
+	if err != nil || reply == nil {
+		return nil, err
+	}
+	var record SessionRecord
+	if err := json.Unmarshal(reply.([]byte), &record); err != nil {
+		return nil, fmt.Errorf("invalid session record: %w", err)
+	}
+	return &record, nil
+}
+
+func (r *RedisSessionStore) Save(ctx context.Context, record SessionRecord) error {
+	ttl := time.Until(record.ExpiresAt).Milliseconds()
+	if ttl <= 0 {
+		return r.Delete(ctx, record.Endpoint, record.ID)
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = r.Do(ctx, "SET", r.key(record.Endpoint, record.ID), string(data), "PX", strconv.FormatInt(ttl, 10))
+	return err
+}
+
+func (r *RedisSessionStore) Delete(ctx context.Context, endpoint, id string) error {
+	_, err := r.Do(ctx, "DEL", r.key(endpoint, id))
+	return err
+}
+
+func (r *RedisSessionStore) List(ctx context.Context, endpoint string) ([]SessionRecord, error) {
+	pattern := escapeRedisPattern(r.prefix+endpoint+":") + "*"
+	var records []SessionRecord
+	cursor := "0"
+	for {
+		reply, err := r.Do(ctx, "SCAN", cursor, "MATCH", pattern, "COUNT", "100")
+		if err != nil {
+			return nil, err
+		}
+		page, ok := reply.([]any)
+		if !ok || len(page) != 2 {
+			return nil, fmt.Errorf("unexpected SCAN reply %v", reply)
+		}
+		keys, _ := page[1].([]any)
+		if len(keys) > 0 {
+			values, err := r.Do(ctx, "MGET", redisStrings(keys)...)
+			if err != nil {
+				return nil, err
+			}
+			list, _ := values.([]any)
+			for _, value := range list {
+				var record SessionRecord
+				// Keys may expire between SCAN and MGET
+				if data, ok := value.([]byte); ok && json.Unmarshal(data, &record) == nil {
+					records = append(records, record)
+				}
+			}
+		}
+		next, _ := page[0].([]byte)
+		if cursor = string(next); cursor == "0" || cursor == "" {
+			return records, nil
+		}
+	}
+}
+
+var _ SessionStore = (*RedisSessionStore)(nil)
+
+// escapeRedisPattern escapes the glob characters of a SCAN MATCH pattern.
+func escapeRedisPattern(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if strings.ContainsRune(`*?[]\`, c) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func redisStrings(values []any) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if b, ok := v.([]byte); ok {
+			out = append(out, string(b))
+		}
+	}
+	return out
+}
@@ -0,0 +1,235 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStreamableHTTP_SharedSessionStore(t *testing.T) {
+	store := NewMemorySessionStore()
+	first := NewStreamableHTTPServer(NewMCPServer("test", "1.0.0"), WithSessionStore(store))
+	second := NewStreamableHTTPServer(NewMCPServer("test", "1.0.0"), WithSessionStore(store))
+	firstServer, secondServer := httptest.NewServer(first), httptest.NewServer(second)
+	defer firstServer.Close()
+	defer secondServer.Close()
+
+	resp := postStreamable(t, firstServer.URL, "", map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "initialize",
+		"params":  map[string]any{"protocolVersion": "2025-03-26", "clientInfo": map[string]any{"name": "test-client", "version": "1.0.0"}},
+	})
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+	if sessionID == "" {
+		t.Fatal("Expected session ID in response header")
+	}
+
+	ping := map[string]any{"jsonrpc": "2.0", "id": 2, "method": "ping"}
+	if resp := postStreamable(t, secondServer.URL, sessionID, ping); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the other server to serve the session, got %d", resp.StatusCode)
+	}
+	if sessions := second.Sessions(); len(sessions) != 1 || sessions[0].ID != sessionID {
+		t.Fatalf("Expected the other server to list the session, got %+v", sessions)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, firstServer.URL, nil)
+	req.Header.Set("Mcp-Session-Id", sessionID)
+	if resp, err := http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("DELETE failed: %v", err)
+	}
+	if resp := postStreamable(t, secondServer.URL, sessionID, ping); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for a session terminated on the other server, got %d", resp.StatusCode)
+	}
+	if sessions := second.Sessions(); len(sessions) != 0 {
+		t.Errorf("Expected no sessions after termination, got %+v", sessions)
+	}
+}
+
+func TestMemorySessionStoreExpiry(t *testing.T) {
+	store := NewMemorySessionStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+	store.Save(ctx, SessionRecord{Endpoint: "/a", ID: "live", ExpiresAt: now.Add(time.Minute)})
+	store.Save(ctx, SessionRecord{Endpoint: "/a", ID: "expired", ExpiresAt: now})
+	store.Save(ctx, SessionRecord{Endpoint: "/b", ID: "other", ExpiresAt: now.Add(time.Minute)})
+
+	if record, _ := store.Load(ctx, "/a", "expired"); record != nil {
+		t.Errorf("Expected an expired record to be unknown, got %+v", record)
+	}
+	if records, _ := store.List(ctx, "/a"); len(records) != 1 || records[0].ID != "live" {
+		t.Errorf("Expected only the live record of the endpoint, got %+v", records)
+	}
+	store.DeleteExpired()
+	if len(store.records) != 2 {
+		t.Errorf("Expected the expired record to be deleted, got %d records", len(store.records))
+	}
+}
+
+// fakeRedis serves the Redis commands used by RedisSessionStore, keeping keys in memory.
+type fakeRedis struct {
+	mu       sync.Mutex
+	values   map[string]string
+	expires  map[string]time.Time
+	commands []string
+}
+
+func startFakeRedis(t *testing.T) (*fakeRedis, string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	fake := &fakeRedis{values: map[string]string{}, expires: map[string]time.Time{}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go fake.serve(conn)
+		}
+	}()
+	return fake, listener.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		args := make([]string, n)
+		for i := range args {
+			header, _ := r.ReadString('\n')
+			size, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+			data := make([]byte, size+2)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return
+			}
+			args[i] = string(data[:size])
+		}
+		io.WriteString(conn, f.handle(args))
+	}
+}
+
+func bulk(s string) string { return fmt.Sprintf("$%d\r\n%s\r\n", len(s), s) }
+
+func (f *fakeRedis) handle(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commands = append(f.commands, args[0])
+	for key, expires := range f.expires {
+		if time.Now().After(expires) {
+			delete(f.values, key)
+			delete(f.expires, key)
+		}
+	}
+	switch args[0] {
+	case "AUTH", "SELECT":
+		return "+OK\r\n"
+	case "SET":
+		f.values[args[1]] = args[2]
+		ms, _ := strconv.Atoi(args[4])
+		f.expires[args[1]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		return "+OK\r\n"
+	case "GET":
+		if v, ok := f.values[args[1]]; ok {
+			return bulk(v)
+		}
+		return "$-1\r\n"
+	case "DEL":
+		delete(f.values, args[1])
+		return ":1\r\n"
+	case "SCAN":
+		pattern := strings.ReplaceAll(args[3], `\`, "")
+		var keys []string
+		for key := range f.values {
+			if ok, _ := path.Match(pattern, key); ok {
+				keys = append(keys, bulk(key))
+			}
+		}
+		return fmt.Sprintf("*2\r\n%s*%d\r\n%s", bulk("0"), len(keys), strings.Join(keys, ""))
+	case "MGET":
+		out := fmt.Sprintf("*%d\r\n", len(args)-1)
+		for _, key := range args[1:] {
+			if v, ok := f.values[key]; ok {
+				out += bulk(v)
+			} else {
+				out += "$-1\r\n"
+			}
+		}
+		return out
+	}
+	return "-ERR unknown command\r\n"
+}
+
+func TestRedisSessionStore(t *testing.T) {
+	fake, addr := startFakeRedis(t)
+	store, err := NewRedisSessionStore("redis://:secret@" + addr + "/2?prefix=test:")
+	if err != nil {
+		t.Fatalf("NewRedisSessionStore: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	record := SessionRecord{Endpoint: "/pets", ID: "s1", Client: "test-client/1.0.0", CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}
+	if err := store.Save(ctx, record); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	store.Save(ctx, SessionRecord{Endpoint: "/other", ID: "s2", ExpiresAt: now.Add(time.Hour)})
+
+	loaded, err := store.Load(ctx, "/pets", "s1")
+	if err != nil || loaded == nil || loaded.Client != record.Client || !loaded.ExpiresAt.Equal(record.ExpiresAt) {
+		t.Fatalf("Expected the saved record, got %+v, %v", loaded, err)
+	}
+	if _, ok := fake.values["test:/pets:s1"]; !ok {
+		t.Errorf("Expected the record under the prefixed key, got %v", fake.values)
+	}
+	if fake.commands[0] != "AUTH" || fake.commands[1] != "SELECT" {
+		t.Errorf("Expected the connection to authenticate and select the database, got %v", fake.commands)
+	}
+
+	records, err := store.List(ctx, "/pets")
+	if err != nil || len(records) != 1 || records[0].ID != "s1" {
+		t.Errorf("Expected the records of the endpoint, got %+v, %v", records, err)
+	}
+
+	if err := store.Delete(ctx, "/pets", "s1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if loaded, err := store.Load(ctx, "/pets", "s1"); err != nil || loaded != nil {
+		t.Errorf("Expected a deleted record to be unknown, got %+v, %v", loaded, err)
+	}
+}
+
+func TestNewRedisSessionStoreURLs(t *testing.T) {
+	store, err := NewRedisSessionStore("rediss://user:pw@redis.internal")
+	if err != nil {
+		t.Fatalf("NewRedisSessionStore: %v", err)
+	}
+	if store.addr != "redis.internal:6379" || store.tls == nil || store.username != "user" || store.password != "pw" || store.prefix != DefaultRedisSessionPrefix {
+		t.Errorf("unexpected store %+v", store)
+	}
+	for _, bad := range []string{"http://localhost", "redis://", "redis://localhost/db"} {
+		if _, err := NewRedisSessionStore(bad); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
@@ -17,12 +17,16 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/ubermorgenland/openapi-mcp/pkg/apierrors"
 	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	"github.com/ubermorgenland/openapi-mcp/pkg/tracing"
 )
 
 // sseSession represents an active SSE connection.
 type sseSession struct {
 	done                chan struct{}
+	closeOnce           sync.Once
+	createdAt           time.Time
 	eventQueue          chan string // Channel for queuing events
 	sessionID           string
 	requestID           atomic.Int64
@@ -31,6 +35,7 @@ type sseSession struct {
 	loggingLevel        atomic.Value
 	tools               sync.Map     // stores session-specific tools
 	clientInfo          atomic.Value // stores session-specific client info
+	notifications       notificationStats
 }
 
 // SSEContextFunc is a function that takes an existing context and the current
@@ -45,6 +50,16 @@ type SSEContextFunc func(ctx context.Context, r *http.Request) context.Context
 // function should return the base path (e.g., "/mcp/tenant123").
 type DynamicBasePathFunc func(r *http.Request, sessionID string) string
 
+// Close ends the session's event stream. It is safe to call more than once.
+func (s *sseSession) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+// GetCreatedAt returns when the session's stream was opened
+func (s *sseSession) GetCreatedAt() time.Time {
+	return s.createdAt
+}
+
 func (s *sseSession) SessionID() string {
 	return s.sessionID
 }
@@ -53,6 +68,14 @@ func (s *sseSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
 	return s.notificationChannel
 }
 
+func (s *sseSession) notificationQueue() chan mcp.JSONRPCNotification {
+	return s.notificationChannel
+}
+
+func (s *sseSession) notificationStats() *notificationStats {
+	return &s.notifications
+}
+
 func (s *sseSession) Initialize() {
 	// set default logging level
 	s.loggingLevel.Store(mcp.LoggingLevelError)
@@ -125,6 +148,7 @@ type SSEServer struct {
 	server                       *MCPServer
 	baseURL                      string
 	basePath                     string
+	pathPrefix                   string // prefix of a reverse proxy, in the URLs sent to clients only
 	appendQueryToMessageEndpoint bool
 	useFullURLForMessageEndpoint bool
 	messageEndpoint              string
@@ -137,6 +161,8 @@ type SSEServer struct {
 	keepAlive         bool
 	keepAliveInterval time.Duration
 
+	sessionStore SessionStore // records sessions for other replicas, when set
+
 	mu sync.RWMutex
 }
 
@@ -166,6 +192,21 @@ func WithBaseURL(baseURL string) SSEOption {
 	}
 }
 
+// WithPublicURL sets the external URL of a server behind a reverse proxy, e.g.
+// https://example.com/mcp-gateway: the message endpoints sent to clients are absolute URLs
+// under it. Unlike a path in WithBaseURL, its path prefix is not part of the routes, as the
+// proxy may strip it; see StripPathPrefix.
+func WithPublicURL(publicURL string) SSEOption {
+	return func(s *SSEServer) {
+		origin, prefix, err := ParsePublicURL(publicURL)
+		if err != nil {
+			return
+		}
+		WithBaseURL(origin)(s)
+		s.pathPrefix = prefix
+	}
+}
+
 // WithStaticBasePath adds a new option for setting a static base path
 func WithStaticBasePath(basePath string) SSEOption {
 	return func(s *SSEServer) {
@@ -323,7 +364,7 @@ func (s *SSEServer) Shutdown(ctx context.Context) error {
 	if srv != nil {
 		s.sessions.Range(func(key, value any) bool {
 			if session, ok := value.(*sseSession); ok {
-				close(session.done)
+				session.Close()
 			}
 			s.sessions.Delete(key)
 			return true
@@ -341,7 +382,7 @@ func (s *SSEServer) handleSSE(w http.ResponseWriter, r *http.Request) {
 	s.logIncomingRequest(r)
 	
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		apierrors.WriteStatus(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -352,30 +393,35 @@ func (s *SSEServer) handleSSE(w http.ResponseWriter, r *http.Request) {
 
 	flusher, ok := w.(http.Flusher)
 	if !ok {
-		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		apierrors.WriteStatus(w, http.StatusInternalServerError, "Streaming unsupported")
 		return
 	}
+	// The stream outlives the server's write timeout, which is meant for ordinary responses
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
 
 	sessionID := uuid.New().String()
 	session := &sseSession{
 		done:                make(chan struct{}),
+		createdAt:           time.Now(),
 		eventQueue:          make(chan string, 100), // Buffer for events
 		sessionID:           sessionID,
-		notificationChannel: make(chan mcp.JSONRPCNotification, 100),
+		notificationChannel: s.server.newNotificationChannel(),
 	}
 
 	s.sessions.Store(sessionID, session)
 	defer s.sessions.Delete(sessionID)
 
 	if err := s.server.RegisterSession(r.Context(), session); err != nil {
-		http.Error(
+		apierrors.WriteStatus(
 			w,
-			fmt.Sprintf("Session registration failed: %v", err),
 			http.StatusInternalServerError,
+			fmt.Sprintf("Session registration failed: %v", err),
 		)
 		return
 	}
 	defer s.server.UnregisterSession(r.Context(), sessionID)
+	s.recordSSESession(r.Context(), session)
+	defer s.forgetSSESession(sessionID)
 
 	// Start notification handler for this session
 	go func() {
@@ -447,7 +493,7 @@ func (s *SSEServer) handleSSE(w http.ResponseWriter, r *http.Request) {
 			fmt.Fprint(w, event)
 			flusher.Flush()
 		case <-r.Context().Done():
-			close(session.done)
+			session.Close()
 			return
 		case <-session.done:
 			return
@@ -464,7 +510,7 @@ func (s *SSEServer) GetMessageEndpointForClient(r *http.Request, sessionID strin
 		basePath = s.dynamicBasePathFunc(r, sessionID)
 	}
 
-	endpointPath := normalizeURLPath(basePath, s.messageEndpoint)
+	endpointPath := normalizeURLPath(s.pathPrefix, basePath, s.messageEndpoint)
 	if s.useFullURLForMessageEndpoint && s.baseURL != "" {
 		endpointPath = s.baseURL + endpointPath
 	}
@@ -490,6 +536,10 @@ func (s *SSEServer) handleMessage(w http.ResponseWriter, r *http.Request) {
 	}
 	sessionI, ok := s.sessions.Load(sessionID)
 	if !ok {
+		if s.connectedElsewhere(r.Context(), sessionID) {
+			s.writeJSONRPCError(w, nil, mcp.INVALID_PARAMS, "Session is connected to another server instance")
+			return
+		}
 		s.writeJSONRPCError(w, nil, mcp.INVALID_PARAMS, "Invalid session ID")
 		return
 	}
@@ -515,14 +565,23 @@ func (s *SSEServer) handleMessage(w http.ResponseWriter, r *http.Request) {
 	// quick return request, send 202 Accepted with no body, then deal the message and sent response via SSE
 	w.WriteHeader(http.StatusAccepted)
 
-	// Create a new context for handling the message that will be canceled when the message handling is done
+	// Create a new context for handling the message that will be canceled when the message handling is done,
+	// or when the client disconnects from the SSE stream, so in-flight upstream calls are abandoned
 	messageCtx, cancel := context.WithCancel(detachedCtx)
+	go func() {
+		select {
+		case <-session.done:
+			cancel()
+		case <-messageCtx.Done():
+		}
+	}()
 
 	go func(ctx context.Context) {
 		defer cancel()
 		// Use the context that will be canceled when session is done
 		// Process message through MCPServer
-		response := s.server.HandleMessage(ctx, rawMessage)
+		response := s.server.handleTranscribed(ctx, rawMessage)
+		s.recordSSESession(ctx, session)
 		// Only send response if there is one (not for notifications)
 		if response != nil {
 			var message string
@@ -559,10 +618,10 @@ func (s *SSEServer) writeJSONRPCError(
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusBadRequest)
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(
+		apierrors.WriteStatus(
 			w,
-			fmt.Sprintf("Failed to encode response: %v", err),
 			http.StatusInternalServerError,
+			fmt.Sprintf("Failed to encode response: %v", err),
 		)
 		return
 	}
@@ -609,38 +668,36 @@ func (s *SSEServer) CompleteSseEndpoint() (string, error) {
 		return "", &ErrDynamicPathConfig{Method: "CompleteSseEndpoint"}
 	}
 
-	path := normalizeURLPath(s.basePath, s.sseEndpoint)
+	path := normalizeURLPath(s.pathPrefix, s.basePath, s.sseEndpoint)
 	return s.baseURL + path, nil
 }
 
 func (s *SSEServer) CompleteSsePath() string {
-	path, err := s.CompleteSseEndpoint()
-	if err != nil {
-		return normalizeURLPath(s.basePath, s.sseEndpoint)
-	}
-	urlPath, err := s.GetUrlPath(path)
-	if err != nil {
-		return normalizeURLPath(s.basePath, s.sseEndpoint)
-	}
-	return urlPath
+	return s.routePath(s.sseEndpoint)
 }
 
 func (s *SSEServer) CompleteMessageEndpoint() (string, error) {
 	if s.dynamicBasePathFunc != nil {
 		return "", &ErrDynamicPathConfig{Method: "CompleteMessageEndpoint"}
 	}
-	path := normalizeURLPath(s.basePath, s.messageEndpoint)
+	path := normalizeURLPath(s.pathPrefix, s.basePath, s.messageEndpoint)
 	return s.baseURL + path, nil
 }
 
 func (s *SSEServer) CompleteMessagePath() string {
-	path, err := s.CompleteMessageEndpoint()
-	if err != nil {
-		return normalizeURLPath(s.basePath, s.messageEndpoint)
+	return s.routePath(s.messageEndpoint)
+}
+
+// routePath returns the path ServeHTTP serves endpoint at: the path of the base URL, the base
+// path and endpoint, without the path prefix of a public URL.
+func (s *SSEServer) routePath(endpoint string) string {
+	path := normalizeURLPath(s.basePath, endpoint)
+	if s.dynamicBasePathFunc != nil {
+		return path
 	}
-	urlPath, err := s.GetUrlPath(path)
+	urlPath, err := s.GetUrlPath(s.baseURL + path)
 	if err != nil {
-		return normalizeURLPath(s.basePath, s.messageEndpoint)
+		return path
 	}
 	return urlPath
 }
@@ -700,16 +757,16 @@ func (s *SSEServer) SSEHandler() http.Handler {
 //
 // For non-dynamic cases, use ServeHTTP method instead.
 func (s *SSEServer) MessageHandler() http.Handler {
-	return http.HandlerFunc(s.handleMessage)
+	return tracing.Middleware(http.HandlerFunc(s.handleMessage))
 }
 
 // ServeHTTP implements the http.Handler interface.
 func (s *SSEServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if s.dynamicBasePathFunc != nil {
-		http.Error(
+		apierrors.WriteStatus(
 			w,
-			(&ErrDynamicPathConfig{Method: "ServeHTTP"}).Error(),
 			http.StatusInternalServerError,
+			(&ErrDynamicPathConfig{Method: "ServeHTTP"}).Error(),
 		)
 		return
 	}
@@ -722,7 +779,7 @@ func (s *SSEServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	messagePath := s.CompleteMessagePath()
 	if messagePath != "" && path == messagePath {
-		s.handleMessage(w, r)
+		tracing.Middleware(http.HandlerFunc(s.handleMessage)).ServeHTTP(w, r)
 		return
 	}
 
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+func TestSSEServer_DisconnectCancelsToolCall(t *testing.T) {
+	mcpServer := NewMCPServer("test-server", "1.0.0")
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+	mcpServer.AddTool(mcp.NewTool("slow"), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		close(started)
+		select {
+		case <-ctx.Done():
+			close(canceled)
+		case <-time.After(10 * time.Second):
+		}
+		return mcp.NewToolResultText("done", nil, nil, nil, "", nil), nil
+	})
+
+	testServer := httptest.NewServer(NewSSEServer(mcpServer))
+	defer testServer.Close()
+
+	ctx, disconnect := context.WithCancel(context.Background())
+	defer disconnect()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, testServer.URL+"/sse", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to open SSE stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// The first event tells the client where to post messages
+	reader := bufio.NewReader(resp.Body)
+	var endpoint string
+	for endpoint == "" {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed to read endpoint event: %v", err)
+		}
+		if strings.HasPrefix(line, "data: ") {
+			endpoint = strings.TrimSpace(strings.TrimPrefix(line, "data: "))
+		}
+	}
+	if strings.HasPrefix(endpoint, "/") {
+		endpoint = testServer.URL + endpoint
+	}
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"slow","arguments":{}}}`
+	postResp, err := http.Post(endpoint, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to post message: %v", err)
+	}
+	postResp.Body.Close()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("tool call did not start")
+	}
+	disconnect()
+
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the tool call context to be canceled when the SSE client disconnects")
+	}
+}
@@ -54,6 +54,15 @@ type stdioSession struct {
 	notifications chan mcp.JSONRPCNotification
 	initialized   atomic.Bool
 	loggingLevel  atomic.Value
+	stats         notificationStats
+}
+
+func (s *stdioSession) notificationQueue() chan mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+func (s *stdioSession) notificationStats() *notificationStats {
+	return &s.stats
 }
 
 func (s *stdioSession) SessionID() string {
@@ -253,7 +262,7 @@ func (s *StdioServer) processMessage(
 	}
 
 	// Handle the message using the wrapped server
-	response := s.server.HandleMessage(ctx, rawMessage)
+	response := s.server.handleTranscribed(ctx, rawMessage)
 
 	// Only write response if there is one (not for notifications)
 	if response != nil {
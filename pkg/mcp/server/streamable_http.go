@@ -1,7 +1,6 @@
 package server
 
 import (
-	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -11,11 +10,14 @@ import (
 	"net/http/httptest"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/ubermorgenland/openapi-mcp/pkg/apierrors"
 	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
 	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/util"
+	"github.com/ubermorgenland/openapi-mcp/pkg/tracing"
 )
 
 // StreamableHTTPOption defines a function type for configuring StreamableHTTPServer
@@ -46,8 +48,8 @@ func WithStateLess(stateLess bool) StreamableHTTPOption {
 }
 
 // WithSessionIdManager sets a custom session id generator for the server.
-// By default, the server will use SimpleStatefulSessionIdGenerator, which generates
-// session ids with uuid, and it's insecure.
+// By default, the server will use InsecureStatefulSessionIdManager, which generates
+// session ids with uuid, and it's insecure. JWTSessionIdManager is recommended instead.
 // Notice: it will override the WithStateLess option.
 func WithSessionIdManager(manager SessionIdManager) StreamableHTTPOption {
 	return func(s *StreamableHTTPServer) {
@@ -103,8 +105,11 @@ func WithLogger(logger util.Logger) StreamableHTTPOption {
 // not trigger the session registration. So the methods like `SendNotificationToSpecificClient`
 // or `hooks.onRegisterSession` will not be triggered for POST messages.
 //
+// A POST body may be a batch: an array of requests, notifications and responses. The
+// responses to its requests are returned as an array, or as one SSE event once the
+// response is upgraded; an initialize request must be sent on its own.
+//
 // The current implementation does not support the following features from the specification:
-//   - Batching of requests/notifications/responses in arrays.
 //   - Stream Resumability
 type StreamableHTTPServer struct {
 	server       *MCPServer
@@ -118,6 +123,13 @@ type StreamableHTTPServer struct {
 	sessionIdManager        SessionIdManager
 	listenHeartbeatInterval time.Duration
 	logger                  util.Logger
+	compression             CompressionConfig
+	handler                 http.Handler // the routes, behind the gzip middleware
+
+	// sessionStore keeps the sessions seen by this transport between requests
+	sessionStore SessionStore
+	// served tracks when this transport last served each session, see ServesSession
+	served sync.Map // sessionID -> time.Time
 	
 	// Session cleanup
 	cleanupCtx    context.Context
@@ -131,6 +143,7 @@ func NewStreamableHTTPServer(server *MCPServer, opts ...StreamableHTTPOption) *S
 	s := &StreamableHTTPServer{
 		server:           server,
 		sessionTools:     newSessionToolsStore(),
+		sessionStore:     NewMemorySessionStore(),
 		endpointPath:     "/mcp",
 		sessionIdManager: &InsecureStatefulSessionIdManager{},
 		logger:           util.DefaultLogger(),
@@ -143,6 +156,7 @@ func NewStreamableHTTPServer(server *MCPServer, opts ...StreamableHTTPOption) *S
 	for _, opt := range opts {
 		opt(s)
 	}
+	s.handler = tracing.Middleware(CompressHandler(s.compression, http.HandlerFunc(s.route)))
 	
 	// Start cleanup goroutine
 	go s.runSessionCleanup()
@@ -155,6 +169,11 @@ func (s *StreamableHTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request)
 	// Always log incoming requests for debugging
 	// TODO: Make this configurable for production
 	s.logIncomingRequest(r)
+	s.handler.ServeHTTP(w, r)
+}
+
+// route dispatches a request to its handler.
+func (s *StreamableHTTPServer) route(w http.ResponseWriter, r *http.Request) {
 	// Check for optimized API endpoints first
 	if r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/tools") {
 		// Only use optimized API for direct tools endpoint access (not sub-paths like /tools/call)
@@ -258,7 +277,7 @@ func (s *StreamableHTTPServer) handlePost(w http.ResponseWriter, r *http.Request
 	// Check content type
 	contentType := r.Header.Get("Content-Type")
 	if contentType != "application/json" {
-		http.Error(w, "Invalid content type: must be 'application/json'", http.StatusBadRequest)
+		apierrors.WriteStatus(w, http.StatusBadRequest, "Invalid content type: must be 'application/json'")
 		return
 	}
 
@@ -271,45 +290,54 @@ func (s *StreamableHTTPServer) handlePost(w http.ResponseWriter, r *http.Request
 	var baseMessage struct {
 		Method mcp.MCPMethod `json:"method"`
 	}
-	if err := json.Unmarshal(rawData, &baseMessage); err != nil {
+	// A batch is an array of requests, notifications and responses, answered with an array
+	var batch []json.RawMessage
+	if isBatch(rawData) {
+		if err := json.Unmarshal(rawData, &batch); err != nil {
+			s.writeJSONRPCError(w, nil, mcp.PARSE_ERROR, "request body is not valid json")
+			return
+		}
+		if len(batch) == 0 || len(batch) > maxBatchSize {
+			s.writeJSONRPCError(w, nil, mcp.INVALID_REQUEST, fmt.Sprintf("a batch must have 1 to %d messages", maxBatchSize))
+			return
+		}
+	} else if err := json.Unmarshal(rawData, &baseMessage); err != nil {
 		s.writeJSONRPCError(w, nil, mcp.PARSE_ERROR, "request body is not valid json")
 		return
 	}
 	isInitializeRequest := baseMessage.Method == mcp.MethodInitialize
 
 	// Prepare the session for the mcp server
-	// The session is ephemeral. Its life is the same as the request. It's only created
-	// for interaction with the mcp server.
+	// It is shared with the other requests and the GET stream of the session in progress, and
+	// unregistered once none is left.
 	var sessionID string
 	if isInitializeRequest {
 		// generate a new one for initialize request
 		sessionID = s.sessionIdManager.Generate()
+		s.trackSession(r.Context(), sessionID)
 	} else {
 		// Get session ID from header.
 		// Stateful servers need the client to carry the session ID.
 		sessionID = r.Header.Get(headerKeySessionID)
 		isTerminated, err := s.sessionIdManager.Validate(sessionID)
 		if err != nil {
-			http.Error(w, "Invalid session ID", http.StatusBadRequest)
+			apierrors.WriteStatus(w, http.StatusBadRequest, "Invalid session ID")
 			return
 		}
 		if isTerminated {
-			http.Error(w, "Session terminated", http.StatusNotFound)
+			apierrors.WriteStatus(w, http.StatusNotFound, "Session terminated")
 			return
 		}
 		
-		// Touch session to renew its expiration when accessed
-		if sessionID != "" {
-			if err := s.server.TouchSession(sessionID, DefaultSessionTimeout); err != nil {
-				// Log error but don't fail the request - session might not support expiration
-				s.logger.Infof("Failed to touch session %s: %v", sessionID, err)
-			}
+		// Record the activity of the session in the session store
+		if s.trackSession(r.Context(), sessionID) {
+			apierrors.WriteStatus(w, http.StatusNotFound, "Session terminated")
+			return
 		}
 	}
 
 	// Extract authentication headers from the request
 	authHeaders := extractAuthHeaders(r.Header)
-	session := newStreamableHttpSessionWithHeaders(sessionID, s.sessionTools, authHeaders)
 	
 	// Debug: Log extracted headers
 	if len(authHeaders) > 0 {
@@ -318,25 +346,23 @@ func (s *StreamableHTTPServer) handlePost(w http.ResponseWriter, r *http.Request
 		}
 	}
 
-	// Register the session with the MCP server so authentication can find it
-	sessionRegistered := false
+	// Share the session with the other requests and the GET stream of the same session ID, so
+	// authentication can find it; stateless requests get a session of their own
+	var shared *streamableHttpSession
 	if sessionID != "" {
-		if err := s.server.RegisterSession(r.Context(), session); err != nil {
-			// If session already exists, that's fine for streamable HTTP (ephemeral sessions)
-			if err != ErrSessionExists {
-				s.logger.Errorf("Failed to register session %s: %v", sessionID, err)
-			}
-		} else {
-			sessionRegistered = true
-		}
+		var release func()
+		shared, release = s.acquireSession(r.Context(), sessionID, authHeaders)
+		defer release()
+		// Renew the session's expiration when accessed
+		shared.Renew(DefaultSessionTimeout)
+	} else {
+		shared = newStreamableHttpSessionWithHeaders(sessionID, s.sessionTools, s.server.newNotificationChannel(), authHeaders)
+	}
+	// Notifications sent with the request's context are streamed in its response
+	session := &streamableHttpRequestSession{
+		streamableHttpSession: shared,
+		notifications:         s.server.newNotificationChannel(),
 	}
-	
-	// Clean up session when request is done
-	defer func() {
-		if sessionRegistered && sessionID != "" {
-			s.server.UnregisterSession(context.Background(), sessionID)
-		}
-	}()
 
 	// Set the client context before handling the message
 	ctx := s.server.WithContext(r.Context(), session)
@@ -352,44 +378,58 @@ func (s *StreamableHTTPServer) handlePost(w http.ResponseWriter, r *http.Request
 
 	go func() {
 		for {
+			// Notifications not tied to a request go to the GET stream, if one is open
+			var sessionNotifications chan mcp.JSONRPCNotification
+			if !shared.listening() {
+				sessionNotifications = shared.notificationChannel
+			}
+			var nt mcp.JSONRPCNotification
 			select {
-			case nt := <-session.notificationChannel:
-				func() {
-					mu.Lock()
-					defer mu.Unlock()
-					defer func() {
-						flusher, ok := w.(http.Flusher)
-						if ok {
-							flusher.Flush()
-						}
-					}()
-
-					// if there's notifications, upgrade to SSE response
-					if !upgraded {
-						upgraded = true
-						w.Header().Set("Content-Type", "text/event-stream")
-						w.Header().Set("Connection", "keep-alive")
-						w.Header().Set("Cache-Control", "no-cache")
-						w.WriteHeader(http.StatusAccepted)
-					}
-					err := writeSSEEvent(w, nt)
-					if err != nil {
-						s.logger.Errorf("Failed to write SSE event: %v", err)
-						return
-					}
-				}()
+			case nt = <-session.notifications:
+			case nt = <-sessionNotifications:
 			case <-done:
 				return
 			case <-ctx.Done():
 				return
 			}
+			func() {
+				mu.Lock()
+				defer mu.Unlock()
+				defer func() {
+					flusher, ok := w.(http.Flusher)
+					if ok {
+						flusher.Flush()
+					}
+				}()
+
+				// if there's notifications, upgrade to SSE response
+				if !upgraded {
+					upgraded = true
+					w.Header().Set("Content-Type", "text/event-stream")
+					w.Header().Set("Connection", "keep-alive")
+					w.Header().Set("Cache-Control", "no-cache")
+					w.WriteHeader(http.StatusAccepted)
+				}
+				err := writeSSEEvent(w, nt)
+				if err != nil {
+					s.logger.Errorf("Failed to write SSE event: %v", err)
+					return
+				}
+			}()
 		}
 	}()
 
 	// Process message through MCPServer
-	response := s.server.HandleMessage(ctx, rawData)
+	var response any
+	if batch != nil {
+		if responses := s.server.handleBatch(ctx, batch); len(responses) > 0 {
+			response = responses
+		}
+	} else if message := s.server.handleTranscribed(ctx, rawData); message != nil {
+		response = message
+	}
 	if response == nil {
-		// For notifications, just send 202 Accepted with no body
+		// For notifications, and batches without requests, just send 202 Accepted with no body
 		w.WriteHeader(http.StatusAccepted)
 		return
 	}
@@ -405,40 +445,7 @@ func (s *StreamableHTTPServer) handlePost(w http.ResponseWriter, r *http.Request
 			s.logger.Errorf("Failed to write final SSE response event: %v", err)
 		}
 	} else {
-		// Check if compression should be used for large responses
-		shouldCompress := strings.Contains(r.Header.Get("Accept-Encoding"), "gzip")
-		
-		if shouldCompress {
-			// Marshal response to check size
-			responseData, err := json.Marshal(response)
-			if err != nil {
-				s.logger.Errorf("Failed to marshal response: %v", err)
-				http.Error(w, "Internal server error", http.StatusInternalServerError)
-				return
-			}
-			
-			// Apply compression if response is larger than 1KB
-			if len(responseData) > 1024 {
-				w.Header().Set("Content-Type", "application/json")
-				w.Header().Set("Content-Encoding", "gzip")
-				w.Header().Set("Vary", "Accept-Encoding")
-				if isInitializeRequest && sessionID != "" {
-					w.Header().Set(headerKeySessionID, sessionID)
-				}
-				
-				gz := gzip.NewWriter(w)
-				defer gz.Close()
-				
-				w.WriteHeader(http.StatusOK)
-				_, err = gz.Write(responseData)
-				if err != nil {
-					s.logger.Errorf("Compression error: %v", err)
-				}
-				return
-			}
-		}
-		
-		// Fallback to uncompressed response
+		// Large responses are compressed by the gzip middleware
 		w.Header().Set("Content-Type", "application/json")
 		if isInitializeRequest && sessionID != "" {
 			// send the session ID back to the client
@@ -463,14 +470,15 @@ func (s *StreamableHTTPServer) handleGet(w http.ResponseWriter, r *http.Request)
 		// It's a stateless server,
 		// but the MCP server requires a unique ID for registering, so we use a random one
 		sessionID = uuid.New().String()
+	} else {
+		s.markServed(sessionID)
 	}
 
-	session := newStreamableHttpSession(sessionID, s.sessionTools)
-	if err := s.server.RegisterSession(r.Context(), session); err != nil {
-		http.Error(w, fmt.Sprintf("Session registration failed: %v", err), http.StatusBadRequest)
-		return
-	}
-	defer s.server.UnregisterSession(r.Context(), sessionID)
+	// The stream shares the session of the POST requests with the same session ID; the session
+	// may have moved to another server by the time the stream ends (AdoptSessions)
+	session, release := s.acquireSession(r.Context(), sessionID, extractAuthHeaders(r.Header))
+	defer release()
+	defer session.listen()()
 
 	// Set the client context before handling the message
 	w.Header().Set("Content-Type", "text/event-stream")
@@ -480,9 +488,11 @@ func (s *StreamableHTTPServer) handleGet(w http.ResponseWriter, r *http.Request)
 
 	flusher, ok := w.(http.Flusher)
 	if !ok {
-		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		apierrors.WriteStatus(w, http.StatusInternalServerError, "Streaming unsupported")
 		return
 	}
+	// The stream outlives the server's write timeout, which is meant for ordinary responses
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
 	flusher.Flush()
 
 	// Send initial endpoint event with session information
@@ -556,6 +566,9 @@ func (s *StreamableHTTPServer) handleGet(w http.ResponseWriter, r *http.Request)
 			flusher.Flush()
 		case <-r.Context().Done():
 			return
+		case <-session.done:
+			// the session was terminated by an operator
+			return
 		}
 	}
 }
@@ -565,16 +578,26 @@ func (s *StreamableHTTPServer) handleDelete(w http.ResponseWriter, r *http.Reque
 	sessionID := r.Header.Get(headerKeySessionID)
 	notAllowed, err := s.sessionIdManager.Terminate(sessionID)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Session termination failed: %v", err), http.StatusInternalServerError)
+		apierrors.WriteStatus(w, http.StatusInternalServerError, fmt.Sprintf("Session termination failed: %v", err))
 		return
 	}
 	if notAllowed {
-		http.Error(w, "Session termination not allowed", http.StatusMethodNotAllowed)
+		apierrors.WriteStatus(w, http.StatusMethodNotAllowed, "Session termination not allowed")
 		return
 	}
 
 	// remove the session relateddata from the sessionToolsStore
-	s.sessionTools.set(sessionID, nil)
+	s.sessionTools.forget(sessionID)
+	s.served.Delete(sessionID)
+	if sessionID != "" {
+		record, err := s.sessionStore.Load(r.Context(), s.endpointPath, sessionID)
+		if err == nil {
+			err = s.markTerminated(r.Context(), sessionID, record)
+		}
+		if err != nil {
+			s.logger.Errorf("Failed to record the termination of session %s: %v", sessionID, err)
+		}
+	}
 
 	w.WriteHeader(http.StatusOK)
 }
@@ -629,13 +652,15 @@ func (s *StreamableHTTPServer) writeJSONRPCError(
 // --- session ---
 
 type sessionToolsStore struct {
-	mu    sync.RWMutex
-	tools map[string]map[string]ServerTool // sessionID -> toolName -> tool
+	mu      sync.RWMutex
+	tools   map[string]map[string]ServerTool // sessionID -> toolName -> tool
+	clients map[string]mcp.Implementation    // sessionID -> client info sent in initialize
 }
 
 func newSessionToolsStore() *sessionToolsStore {
 	return &sessionToolsStore{
-		tools: make(map[string]map[string]ServerTool),
+		tools:   make(map[string]map[string]ServerTool),
+		clients: make(map[string]mcp.Implementation),
 	}
 }
 
@@ -651,9 +676,29 @@ func (s *sessionToolsStore) set(sessionID string, tools map[string]ServerTool) {
 	s.tools[sessionID] = tools
 }
 
+func (s *sessionToolsStore) getClient(sessionID string) mcp.Implementation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.clients[sessionID]
+}
+
+func (s *sessionToolsStore) setClient(sessionID string, clientInfo mcp.Implementation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients[sessionID] = clientInfo
+}
+
+// forget removes all data kept for a terminated session
+func (s *sessionToolsStore) forget(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tools, sessionID)
+	delete(s.clients, sessionID)
+}
+
 // streamableHttpSession is a session for streamable-http transport
-// When in POST handlers(request/notification), it's ephemeral, and only exists in the life of the request handler.
-// When in GET handlers(listening), it's a real session, and will be registered in the MCP server.
+// It is registered in the MCP server while a POST request or the GET stream (listening) of the
+// session is in progress, and shared by all of them; see acquireSession.
 type streamableHttpSession struct {
 	sessionID           string
 	notificationChannel chan mcp.JSONRPCNotification // server -> client notifications
@@ -661,35 +706,54 @@ type streamableHttpSession struct {
 	authHeaders         http.Header                   // preserve authentication headers from original request
 	createdAt           time.Time                     // when the session was created
 	expiresAt           time.Time                     // when the session expires
+	done                chan struct{}                 // closed when the session is terminated
+	closeOnce           sync.Once
+	listener            sessionOwner                  // server the listening session is registered with
+	notifications       notificationStats             // notifications dropped because the queue was full
+	mu                  sync.Mutex                    // guards authHeaders and expiresAt
+	refMu               sync.Mutex                    // guards refs
+	refs                int                           // requests holding the session; see acquireSession
+	listeners           atomic.Int32                  // open GET streams
 }
 
 // Default session timeout (configurable)
 const DefaultSessionTimeout = 24 * time.Hour
 
-func newStreamableHttpSession(sessionID string, toolStore *sessionToolsStore) *streamableHttpSession {
+func newStreamableHttpSession(sessionID string, toolStore *sessionToolsStore, notifications chan mcp.JSONRPCNotification) *streamableHttpSession {
 	now := time.Now()
 	return &streamableHttpSession{
 		sessionID:           sessionID,
-		notificationChannel: make(chan mcp.JSONRPCNotification, 100),
+		notificationChannel: notifications,
 		tools:               toolStore,
 		authHeaders:         make(http.Header),
 		createdAt:           now,
 		expiresAt:           now.Add(DefaultSessionTimeout),
+		done:                make(chan struct{}),
 	}
 }
 
-func newStreamableHttpSessionWithHeaders(sessionID string, toolStore *sessionToolsStore, authHeaders http.Header) *streamableHttpSession {
+func newStreamableHttpSessionWithHeaders(sessionID string, toolStore *sessionToolsStore, notifications chan mcp.JSONRPCNotification, authHeaders http.Header) *streamableHttpSession {
 	now := time.Now()
 	return &streamableHttpSession{
 		sessionID:           sessionID,
-		notificationChannel: make(chan mcp.JSONRPCNotification, 100),
+		notificationChannel: notifications,
 		tools:               toolStore,
 		authHeaders:         authHeaders,
 		createdAt:           now,
 		expiresAt:           now.Add(DefaultSessionTimeout),
+		done:                make(chan struct{}),
 	}
 }
 
+// Close ends the listening stream of the session, if one is open
+func (s *streamableHttpSession) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+func (s *streamableHttpSession) owner() *sessionOwner {
+	return &s.listener
+}
+
 func (s *streamableHttpSession) SessionID() string {
 	return s.sessionID
 }
@@ -698,18 +762,36 @@ func (s *streamableHttpSession) NotificationChannel() chan<- mcp.JSONRPCNotifica
 	return s.notificationChannel
 }
 
+func (s *streamableHttpSession) notificationQueue() chan mcp.JSONRPCNotification {
+	return s.notificationChannel
+}
+
+func (s *streamableHttpSession) notificationStats() *notificationStats {
+	return &s.notifications
+}
+
 func (s *streamableHttpSession) Initialize() {
 	// do nothing
-	// the session is ephemeral, no real initialized action needed
+	// the initialize request is not tracked per session, no real initialized action needed
 }
 
 func (s *streamableHttpSession) Initialized() bool {
-	// the session is ephemeral, no real initialized action needed
+	// the initialize request is not tracked per session, no real initialized action needed
 	return true
 }
 
 var _ ClientSession = (*streamableHttpSession)(nil)
 
+func (s *streamableHttpSession) GetClientInfo() mcp.Implementation {
+	return s.tools.getClient(s.sessionID)
+}
+
+func (s *streamableHttpSession) SetClientInfo(clientInfo mcp.Implementation) {
+	s.tools.setClient(s.sessionID, clientInfo)
+}
+
+var _ SessionWithClientInfo = (*streamableHttpSession)(nil)
+
 func (s *streamableHttpSession) GetSessionTools() map[string]ServerTool {
 	return s.tools.get(s.sessionID)
 }
@@ -719,10 +801,14 @@ func (s *streamableHttpSession) SetSessionTools(tools map[string]ServerTool) {
 }
 
 func (s *streamableHttpSession) GetAuthHeaders() http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.authHeaders
 }
 
 func (s *streamableHttpSession) SetAuthHeaders(headers http.Header) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.authHeaders = headers
 }
 
@@ -732,14 +818,18 @@ func (s *streamableHttpSession) GetCreatedAt() time.Time {
 }
 
 func (s *streamableHttpSession) GetExpiresAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.expiresAt
 }
 
 func (s *streamableHttpSession) IsExpired() bool {
-	return time.Now().After(s.expiresAt)
+	return time.Now().After(s.GetExpiresAt())
 }
 
 func (s *streamableHttpSession) Renew(duration time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.expiresAt = time.Now().Add(duration)
 }
 
@@ -771,6 +861,7 @@ func (s *StreamableHTTPServer) runSessionCleanup() {
 func (s *StreamableHTTPServer) cleanupExpiredSessions() {
 	var expiredSessions []string
 	var totalSessions, expiringSoon int
+	var droppedNotifications int64
 	
 	// Find expired sessions and collect health info
 	s.server.sessions.Range(func(key, value any) bool {
@@ -780,6 +871,9 @@ func (s *StreamableHTTPServer) cleanupExpiredSessions() {
 		}
 		
 		totalSessions++
+		if session, ok := value.(ClientSession); ok {
+			droppedNotifications += NotificationsDropped(session)
+		}
 		
 		if sessionWithExp, ok := value.(SessionWithExpiration); ok {
 			if sessionWithExp.IsExpired() {
@@ -800,12 +894,27 @@ func (s *StreamableHTTPServer) cleanupExpiredSessions() {
 		s.server.UnregisterSession(context.Background(), sessionID)
 	}
 	
+	// Forget session IDs that have been idle longer than the session timeout; other stores
+	// expire their records themselves
+	if store, ok := s.sessionStore.(*MemorySessionStore); ok {
+		store.DeleteExpired()
+	}
+	s.served.Range(func(key, value any) bool {
+		if time.Since(value.(time.Time)) > DefaultSessionTimeout {
+			s.served.Delete(key)
+		}
+		return true
+	})
+
 	// Log session health status
 	activeSessions := totalSessions - len(expiredSessions)
 	if len(expiredSessions) > 0 || expiringSoon > 0 {
 		s.logger.Infof("Session health: %d active, %d expired (cleaned), %d expiring soon", 
 			activeSessions, len(expiredSessions), expiringSoon)
 	}
+	if droppedNotifications > 0 {
+		s.logger.Infof("Session health: %d notifications dropped across %d sessions", droppedNotifications, totalSessions)
+	}
 }
 
 // GetSessionHealth returns current session health statistics
@@ -872,8 +981,11 @@ func (s *StatelessSessionIdManager) Terminate(sessionID string) (isNotAllowed bo
 
 // InsecureStatefulSessionIdManager generate id with uuid
 // It won't validate the id indeed, so it could be fake.
-// For more secure session id, use a more complex generator, like a JWT.
-type InsecureStatefulSessionIdManager struct{}
+// For more secure session id, use JWTSessionIdManager.
+// Terminated IDs are remembered, so a terminated session cannot be used again.
+type InsecureStatefulSessionIdManager struct {
+	terminated sync.Map // sessionID -> struct{}
+}
 
 const idPrefix = "mcp-session-"
 
@@ -889,10 +1001,19 @@ func (s *InsecureStatefulSessionIdManager) Validate(sessionID string) (isTermina
 	if _, err := uuid.Parse(sessionID[len(idPrefix):]); err != nil {
 		return false, fmt.Errorf("invalid session id: %s", sessionID)
 	}
+	if _, terminated := s.terminated.Load(sessionID); terminated {
+		return true, nil
+	}
 	return false, nil
 }
 
 func (s *InsecureStatefulSessionIdManager) Terminate(sessionID string) (isNotAllowed bool, err error) {
+	// Only remember well-formed IDs, so arbitrary DELETEs cannot grow the set
+	if strings.HasPrefix(sessionID, idPrefix) {
+		if _, err := uuid.Parse(sessionID[len(idPrefix):]); err == nil {
+			s.terminated.Store(sessionID, struct{}{})
+		}
+	}
 	return false, nil
 }
 
@@ -914,45 +1035,44 @@ func (s *StreamableHTTPServer) handleToolsAPI(w http.ResponseWriter, r *http.Req
 	
 	// Create a temporary session for tool listing
 	sessionID := uuid.New().String()
-	session := newStreamableHttpSession(sessionID, s.sessionTools)
+	session := newStreamableHttpSession(sessionID, s.sessionTools, s.server.newNotificationChannel())
 	
 	if err := s.server.RegisterSession(ctx, session); err != nil {
-		http.Error(w, fmt.Sprintf("Session registration failed: %v", err), http.StatusInternalServerError)
+		apierrors.WriteStatus(w, http.StatusInternalServerError, fmt.Sprintf("Session registration failed: %v", err))
 		return
 	}
 	defer s.server.UnregisterSession(ctx, sessionID)
 	
-	// Get tools using MCP protocol
-	toolsRequest := mcp.ListToolsRequest{}
-	
-	result, reqErr := s.server.handleListTools(ctx, "tools-api", toolsRequest)
-	if reqErr != nil {
-		http.Error(w, fmt.Sprintf("Failed to list tools: %v", reqErr.err), http.StatusInternalServerError)
-		return
-	}
-	
 	// Check query parameters for optimization options
 	query := r.URL.Query()
-	// Enable compression by default, allow explicit override
-	compressedParam := query.Get("compressed")
-	compressed := compressedParam == "" || compressedParam == "true" || strings.Contains(r.Header.Get("Accept-Encoding"), "gzip")
+	// Large responses are compressed for clients that accept gzip, unless compressed=false
+	if query.Get("compressed") == "false" {
+		NoCompression(w)
+	}
 	// Use compact mode by default for tools endpoint, allow explicit override
 	compactParam := query.Get("compact")
 	compact := compactParam == "" || compactParam == "true"
-	limit := 0
+	limit := s.server.ToolsPageSize()
 	if limitStr := query.Get("limit"); limitStr != "" {
 		if parsedLimit, err := json.Number(limitStr).Int64(); err == nil && parsedLimit > 0 {
 			limit = int(parsedLimit)
 		}
 	}
 	
-	// Optimize response based on parameters
-	tools := result.Tools
-	if limit > 0 && len(tools) > limit {
-		tools = tools[:limit]
-		// Add pagination info
-		w.Header().Set("X-Total-Tools", fmt.Sprintf("%d", len(result.Tools)))
-		w.Header().Set("X-Returned-Tools", fmt.Sprintf("%d", limit))
+	// Get the tools listed to MCP clients, one page at a time when a limit is set
+	allTools := s.server.listedTools(ctx)
+	tools, nextCursor, err := paginate(mcp.Cursor(query.Get("cursor")), allTools, limit)
+	if err != nil {
+		apierrors.WriteStatus(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if limit > 0 {
+		// Add pagination info; the next page is fetched with ?cursor=<X-Next-Cursor>
+		w.Header().Set("X-Total-Tools", fmt.Sprintf("%d", len(allTools)))
+		w.Header().Set("X-Returned-Tools", fmt.Sprintf("%d", len(tools)))
+		if nextCursor != "" {
+			w.Header().Set("X-Next-Cursor", string(nextCursor))
+		}
 	}
 	
 	// Set appropriate headers
@@ -960,7 +1080,6 @@ func (s *StreamableHTTPServer) handleToolsAPI(w http.ResponseWriter, r *http.Req
 	w.Header().Set("Cache-Control", "public, max-age=300") // 5 minute cache
 	
 	var responseData []byte
-	var err error
 	
 	if compact {
 		// Return compact format with just name and description
@@ -1008,30 +1127,14 @@ func (s *StreamableHTTPServer) handleToolsAPI(w http.ResponseWriter, r *http.Req
 	}
 	
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to serialize tools: %v", err), http.StatusInternalServerError)
+		apierrors.WriteStatus(w, http.StatusInternalServerError, fmt.Sprintf("Failed to serialize tools: %v", err))
 		return
 	}
 	
-	// Apply compression if supported
-	if compressed && len(responseData) > 1024 { // Only compress if > 1KB
-		w.Header().Set("Content-Encoding", "gzip")
-		w.Header().Set("Vary", "Accept-Encoding")
-		
-		gz := gzip.NewWriter(w)
-		defer gz.Close()
-		
-		w.WriteHeader(http.StatusOK)
-		_, err = gz.Write(responseData)
-		if err != nil {
-			// Log error but don't return error to client as headers are already sent
-			fmt.Printf("Compression error: %v\n", err)
-		}
-	} else {
-		w.WriteHeader(http.StatusOK)
-		_, err = w.Write(responseData)
-		if err != nil {
-			fmt.Printf("Write error: %v\n", err)
-		}
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(responseData)
+	if err != nil {
+		fmt.Printf("Write error: %v\n", err)
 	}
 }
 
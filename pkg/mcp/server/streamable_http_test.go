@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
@@ -182,3 +183,113 @@ func TestStreamableHTTPServer_GET(t *testing.T) {
 		}
 	})
 }
+
+func TestStreamableHTTPServer_ToolsAPIPagination(t *testing.T) {
+	mcpServer := NewMCPServer("test-server", "1.0.0")
+	for _, name := range []string{"a", "b", "c"} {
+		mcpServer.AddTool(mcp.NewTool(name), nil)
+	}
+	testServer := httptest.NewServer(NewStreamableHTTPServer(mcpServer))
+	defer testServer.Close()
+
+	get := func(query string) ([]map[string]any, http.Header) {
+		resp, err := http.Get(testServer.URL + "/mcp/tools" + query)
+		if err != nil {
+			t.Fatalf("Failed to list tools: %v", err)
+		}
+		defer resp.Body.Close()
+		var tools []map[string]any
+		if err := json.NewDecoder(resp.Body).Decode(&tools); err != nil {
+			t.Fatalf("Failed to decode tools: %v", err)
+		}
+		return tools, resp.Header
+	}
+
+	tools, header := get("?limit=2")
+	if len(tools) != 2 || header.Get("X-Total-Tools") != "3" || header.Get("X-Next-Cursor") == "" {
+		t.Fatalf("Expected a first page of 2 of 3 tools with a next cursor, got %v %v", tools, header)
+	}
+	tools, header = get("?limit=2&cursor=" + url.QueryEscape(header.Get("X-Next-Cursor")))
+	if len(tools) != 1 || tools[0]["name"] != "c" || header.Get("X-Next-Cursor") != "" {
+		t.Errorf("Expected a last page with tool c, got %v %v", tools, header)
+	}
+}
+
+func TestStreamableHTTPServer_SharedSessionAcrossGETAndPOST(t *testing.T) {
+	mcpServer := NewMCPServer("test-server", "1.0.0")
+	entered := make(chan struct{})
+	proceed := make(chan struct{})
+	mcpServer.AddTool(mcp.NewTool("slow"), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		close(entered)
+		<-proceed
+		return mcp.NewToolResultText("done", nil, nil, nil, "", nil), nil
+	})
+	testServer := httptest.NewServer(NewStreamableHTTPServer(mcpServer))
+	defer testServer.Close()
+
+	post := func(sessionID string, body map[string]any) *http.Response {
+		data, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", testServer.URL, strings.NewReader(string(data)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", "key-from-post")
+		if sessionID != "" {
+			req.Header.Set("Mcp-Session-Id", sessionID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to send POST: %v", err)
+		}
+		return resp
+	}
+	resp := post("", map[string]any{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": map[string]any{"protocolVersion": "2025-03-26"}})
+	resp.Body.Close()
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+
+	// A call in progress holds the session while the client opens its GET stream
+	callDone := make(chan struct{})
+	go func() {
+		defer close(callDone)
+		resp := post(sessionID, map[string]any{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": map[string]any{"name": "slow"}})
+		resp.Body.Close()
+	}()
+	<-entered
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, "GET", testServer.URL, nil)
+	req.Header.Set("Mcp-Session-Id", sessionID)
+	getResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to send GET: %v", err)
+	}
+	defer getResp.Body.Close()
+	if getResp.StatusCode != http.StatusAccepted {
+		t.Fatalf("Expected the GET stream to share the session, got status %d", getResp.StatusCode)
+	}
+	bufio.NewReader(getResp.Body).ReadString('\n')
+
+	registered, ok := mcpServer.sessions.Load(sessionID)
+	if !ok {
+		t.Fatal("Expected the session to be registered")
+	}
+	if got := registered.(*streamableHttpSession).GetAuthHeaders()["X-API-Key"]; len(got) != 1 || got[0] != "key-from-post" {
+		t.Errorf("Expected the shared session to keep the POST auth headers, got %q", got)
+	}
+
+	// The session outlives the call while the stream is open, and ends with it
+	close(proceed)
+	<-callDone
+	if again, ok := mcpServer.sessions.Load(sessionID); !ok || again != registered {
+		t.Error("Expected the GET stream to keep the same session after the call")
+	}
+	cancel()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, ok := mcpServer.sessions.Load(sessionID); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the session to be unregistered once the stream closed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	"github.com/ubermorgenland/openapi-mcp/pkg/tracing"
+)
+
+// startMessageSpan starts the span of a message handled from a transport, named after its
+// method and, for tool calls, the tool: "tools/call getPet". It is a child of the span of
+// the transport's HTTP request, when there is one.
+func startMessageSpan(ctx context.Context, message json.RawMessage) (context.Context, *tracing.Span) {
+	if !tracing.Enabled() {
+		return ctx, nil
+	}
+	var base struct {
+		Method mcp.MCPMethod `json:"method"`
+		ID     any           `json:"id,omitempty"`
+		Params struct {
+			Name string `json:"name"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(message, &base); err != nil || base.Method == "" {
+		// Responses to server requests and unparsable messages are not traced
+		return ctx, nil
+	}
+	name := string(base.Method)
+	if base.Method == mcp.MethodToolsCall && base.Params.Name != "" {
+		name += " " + base.Params.Name
+	}
+	ctx, span := tracing.Start(ctx, name, tracing.KindInternal)
+	span.SetAttribute("mcp.method.name", string(base.Method))
+	if base.ID != nil {
+		span.SetAttribute("jsonrpc.request.id", fmt.Sprint(base.ID))
+	}
+	if base.Method == mcp.MethodToolsCall {
+		span.SetAttribute("gen_ai.tool.name", base.Params.Name)
+	}
+	if session := ClientSessionFromContext(ctx); session != nil && session.SessionID() != "" {
+		span.SetAttribute("mcp.session.id", session.SessionID())
+	}
+	return ctx, span
+}
+
+// endMessageSpan records the outcome of a handled message and ends its span.
+func endMessageSpan(span *tracing.Span, response mcp.JSONRPCMessage) {
+	if span == nil {
+		return
+	}
+	switch r := response.(type) {
+	case mcp.JSONRPCError:
+		span.SetAttribute("rpc.jsonrpc.error_code", r.Error.Code)
+		span.Fail(r.Error.Message)
+	case mcp.JSONRPCResponse:
+		if result, ok := r.Result.(mcp.CallToolResult); ok && result.IsError {
+			span.Fail("tool returned an error result")
+		} else if result, ok := r.Result.(*mcp.CallToolResult); ok && result != nil && result.IsError {
+			span.Fail("tool returned an error result")
+		}
+	}
+	span.End()
+}
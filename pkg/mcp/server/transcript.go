@@ -0,0 +1,158 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+// Directions of transcript entries.
+const (
+	TranscriptIn  = "in"  // a message the client sent
+	TranscriptOut = "out" // the server's response
+)
+
+// TranscriptEntry is one JSON-RPC message of a session transcript.
+type TranscriptEntry struct {
+	Time      time.Time       `json:"time"`
+	Session   string          `json:"session,omitempty"`
+	Direction string          `json:"direction"`
+	Message   json.RawMessage `json:"message"`
+}
+
+// TranscriptRecorder writes the JSON-RPC messages of each session, with timestamps, to a JSON
+// Lines file per session, so a session an agent reported a bug in can be replayed exactly
+// (mcp-client --replay).
+type TranscriptRecorder struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewTranscriptRecorder records transcripts in dir, creating it if needed.
+func NewTranscriptRecorder(dir string) (*TranscriptRecorder, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create transcript directory: %w", err)
+	}
+	return &TranscriptRecorder{dir: dir}, nil
+}
+
+// WithTranscriptRecorder records the messages the server handles through its transports.
+func WithTranscriptRecorder(r *TranscriptRecorder) ServerOption {
+	return func(s *MCPServer) {
+		s.transcripts = r
+	}
+}
+
+var safeSessionFileName = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Path returns the transcript file of a session. Session IDs that can't be used as file
+// names, such as JWTs, are hashed.
+func (r *TranscriptRecorder) Path(sessionID string) string {
+	name := sessionID
+	if !safeSessionFileName.MatchString(name) || name == "." || name == ".." {
+		sum := sha256.Sum256([]byte(sessionID))
+		name = hex.EncodeToString(sum[:8])
+	}
+	return filepath.Join(r.dir, name+".jsonl")
+}
+
+// Record appends a message to a session's transcript. Failures are reported on stderr and
+// do not affect the session.
+func (r *TranscriptRecorder) Record(sessionID, direction string, message []byte) {
+	line, err := json.Marshal(TranscriptEntry{
+		Time:      time.Now().UTC(),
+		Session:   sessionID,
+		Direction: direction,
+		Message:   json.RawMessage(bytes.TrimSpace(message)),
+	})
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, err := os.OpenFile(r.Path(sessionID), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] Failed to record transcript: %v\n", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] Failed to record transcript: %v\n", err)
+	}
+}
+
+// handleTranscribed handles a message from a transport, recording it and its response in
+// the session's transcript when the server records transcripts, and traces it.
+func (s *MCPServer) handleTranscribed(ctx context.Context, message json.RawMessage) (response mcp.JSONRPCMessage) {
+	ctx, span := startMessageSpan(ctx, message)
+	defer func() { endMessageSpan(span, response) }()
+	if s.transcripts == nil {
+		return s.HandleMessage(ctx, message)
+	}
+	sessionID := "no-session"
+	if session := ClientSessionFromContext(ctx); session != nil && session.SessionID() != "" {
+		sessionID = session.SessionID()
+	}
+	s.transcripts.Record(sessionID, TranscriptIn, message)
+	response = s.HandleMessage(ctx, message)
+	if response != nil {
+		if out, err := json.Marshal(response); err == nil {
+			s.transcripts.Record(sessionID, TranscriptOut, out)
+		}
+	}
+	return response
+}
+
+// ReadTranscript reads a transcript written by a TranscriptRecorder (JSON Lines), or a
+// JSON array of entries.
+func ReadTranscript(r io.Reader) ([]TranscriptEntry, error) {
+	reader := bufio.NewReader(r)
+	first, err := firstNonSpace(reader)
+	if err != nil {
+		return nil, err
+	}
+	var entries []TranscriptEntry
+	if first == '[' {
+		if err := json.NewDecoder(reader).Decode(&entries); err != nil {
+			return nil, fmt.Errorf("invalid transcript: %w", err)
+		}
+		return entries, nil
+	}
+	decoder := json.NewDecoder(reader)
+	for {
+		var entry TranscriptEntry
+		if err := decoder.Decode(&entry); err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid transcript entry %d: %w", len(entries)+1, err)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func firstNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				return 0, fmt.Errorf("empty transcript")
+			}
+			return 0, err
+		}
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			return b, r.UnreadByte()
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTranscriptRecordsSessionMessages(t *testing.T) {
+	recorder, err := NewTranscriptRecorder(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create recorder: %v", err)
+	}
+	mcpServer := NewMCPServer("test", "1.0.0", WithTranscriptRecorder(recorder))
+	testServer := httptest.NewServer(NewStreamableHTTPServer(mcpServer))
+	defer testServer.Close()
+
+	post := func(sessionID, body string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, testServer.URL+"/mcp", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if sessionID != "" {
+			req.Header.Set(headerKeySessionID, sessionID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	resp := post("", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	sessionID := resp.Header.Get(headerKeySessionID)
+	post(sessionID, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	post(sessionID, `{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+
+	f, err := os.Open(recorder.Path(sessionID))
+	if err != nil {
+		t.Fatalf("Expected a transcript for the session: %v", err)
+	}
+	defer f.Close()
+	entries, err := ReadTranscript(f)
+	if err != nil {
+		t.Fatalf("Failed to read transcript: %v", err)
+	}
+
+	want := []struct{ direction, contains string }{
+		{TranscriptIn, `"method":"initialize"`},
+		{TranscriptOut, `"serverInfo"`},
+		{TranscriptIn, `"method":"notifications/initialized"`},
+		{TranscriptIn, `"method":"ping"`},
+		{TranscriptOut, `"id":2`},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("Expected %d entries, got %d: %+v", len(want), len(entries), entries)
+	}
+	for i, w := range want {
+		e := entries[i]
+		if e.Direction != w.direction || !strings.Contains(string(e.Message), w.contains) || e.Session != sessionID || e.Time.IsZero() {
+			t.Errorf("Entry %d: expected %s message with %s, got %+v", i, w.direction, w.contains, e)
+		}
+	}
+}
+
+func TestReadTranscriptFormats(t *testing.T) {
+	lines := `{"time":"2024-05-01T00:00:00Z","direction":"in","message":{"jsonrpc":"2.0","id":1,"method":"ping"}}
+{"time":"2024-05-01T00:00:01Z","direction":"out","message":{"jsonrpc":"2.0","id":1,"result":{}}}
+`
+	array := "[" + strings.Replace(strings.TrimSpace(lines), "\n", ",", 1) + "]"
+	for name, content := range map[string]string{"lines": lines, "array": "\n  " + array} {
+		entries, err := ReadTranscript(strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(entries) != 2 || entries[0].Direction != TranscriptIn || entries[1].Direction != TranscriptOut {
+			t.Errorf("%s: unexpected entries %+v", name, entries)
+		}
+	}
+	if _, err := ReadTranscript(strings.NewReader("  ")); err == nil {
+		t.Error("Expected an error for an empty transcript")
+	}
+}
+
+func TestTranscriptPath(t *testing.T) {
+	recorder := &TranscriptRecorder{dir: "/transcripts"}
+	if got := recorder.Path("stdio"); got != "/transcripts/stdio.jsonl" {
+		t.Errorf("Expected a safe session ID to be the file name, got %s", got)
+	}
+	if got := recorder.Path("../../etc/passwd"); !strings.HasPrefix(got, "/transcripts/") || strings.Contains(got, "..") {
+		t.Errorf("Expected an unsafe session ID to be hashed, got %s", got)
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
 )
@@ -105,7 +104,7 @@ func (mesl *MemoryEfficientSpecLoader) OptimizeSpec(spec *openapi3.T) error {
 	
 	// Optimize paths
 	if spec.Paths != nil {
-		for _, pathItem := range spec.Paths {
+		for _, pathItem := range spec.Paths.Map() {
 			if pathItem != nil {
 				mesl.optimizePathItem(pathItem)
 			}
@@ -138,7 +137,7 @@ func (mesl *MemoryEfficientSpecLoader) optimizeSchema(schema *openapi3.Schema) {
 		mesl.optimizeSchema(schema.Items.Value)
 	}
 	
-	if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil && schema.AdditionalProperties.Schema.Value != nil {
+	if schema.AdditionalProperties.Schema != nil && schema.AdditionalProperties.Schema.Value != nil {
 		mesl.optimizeSchema(schema.AdditionalProperties.Schema.Value)
 	}
 }
@@ -177,7 +176,7 @@ func (mesl *MemoryEfficientSpecLoader) optimizeOperation(op *openapi3.Operation)
 	}
 	
 	if op.Responses != nil {
-		for _, responseRef := range op.Responses {
+		for _, responseRef := range op.Responses.Map() {
 			if responseRef.Value != nil && responseRef.Value.Content != nil {
 				for _, contentType := range responseRef.Value.Content {
 					if contentType.Examples != nil {
@@ -210,10 +209,10 @@ func (mesl *MemoryEfficientSpecLoader) CreateSpecSummary(spec *openapi3.T, origi
 	}
 	
 	if spec.Paths != nil {
-		summary.PathCount = len(spec.Paths)
+		summary.PathCount = spec.Paths.Len()
 		
 		// Count methods across all paths
-		for _, pathItem := range spec.Paths {
+		for _, pathItem := range spec.Paths.Map() {
 			if pathItem != nil {
 				if pathItem.Get != nil {
 					summary.MethodCount++
@@ -274,9 +273,6 @@ func CompressSpecForStorage(spec *openapi3.T) ([]byte, error) {
 		return nil, fmt.Errorf("spec cannot be nil")
 	}
 	
-	// First optimize the spec
-	optimizedSpec := *spec // Shallow copy
-	
 	// Create a minimal version for storage
 	minimalSpec := &openapi3.T{
 		OpenAPI: spec.OpenAPI,
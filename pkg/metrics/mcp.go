@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+// The metrics of the MCP server, in the Default registry.
+var (
+	// MCPRequests counts MCP requests by JSON-RPC method and result (success or error).
+	MCPRequests = Default.NewCounter("mcp_requests_total",
+		"MCP requests handled, by JSON-RPC method and result.", "method", "result")
+
+	// ToolCallDuration observes the duration of tool calls by spec endpoint, tool and status
+	// (completed or failed).
+	ToolCallDuration = Default.NewHistogram("mcp_tool_call_duration_seconds",
+		"Duration of tool calls, by spec endpoint, tool and status.", nil, "endpoint", "tool", "status")
+
+	// UpstreamResponses counts upstream API responses by spec endpoint and status code; 0 is
+	// a request that got no response.
+	UpstreamResponses = Default.NewCounter("mcp_upstream_responses_total",
+		"Upstream API responses, by spec endpoint and status code (0 when no response was received).", "endpoint", "status")
+
+	// SpecReloads counts database spec reloads by result: changed, unchanged or error.
+	SpecReloads = Default.NewCounter("mcp_spec_reloads_total",
+		"Spec reloads from the database, by result.", "result")
+
+	// DBPollDuration observes the duration of database polls for spec changes.
+	DBPollDuration = Default.NewHistogram("mcp_db_poll_duration_seconds",
+		"Duration of database polls for spec changes.", nil)
+
+	setActiveSessions = Default.NewFunc("mcp_active_sessions",
+		"Active MCP sessions, by transport.", KindGauge, []string{"transport"}, nil)
+)
+
+// SetActiveSessions sets the function counting active sessions by transport at each scrape.
+func SetActiveSessions(fn func() map[string]int) {
+	if fn == nil {
+		setActiveSessions(nil)
+		return
+	}
+	setActiveSessions(func() []Sample {
+		var samples []Sample
+		for transport, n := range fn() {
+			samples = append(samples, Sample{Labels: []string{transport}, Value: float64(n)})
+		}
+		return samples
+	})
+}
+
+// ObserveToolCall records a finished tool call.
+func ObserveToolCall(endpoint, tool, status string, d time.Duration) {
+	ToolCallDuration.Observe(d.Seconds(), endpoint, tool, status)
+}
+
+// ObserveUpstreamResponse records the status of an upstream API response.
+func ObserveUpstreamResponse(endpoint string, status int) {
+	UpstreamResponses.Inc(endpoint, strconv.Itoa(status))
+}
+
+// InstrumentHooks adds hooks counting MCP requests to hooks, or to new hooks when nil, and
+// returns them, for use with mcpserver.WithHooks.
+func InstrumentHooks(hooks *mcpserver.Hooks) *mcpserver.Hooks {
+	if hooks == nil {
+		hooks = &mcpserver.Hooks{}
+	}
+	hooks.AddOnSuccess(func(ctx context.Context, id any, method mcp.MCPMethod, message any, result any) {
+		MCPRequests.Inc(methodLabel(method), "success")
+	})
+	hooks.AddOnError(func(ctx context.Context, id any, method mcp.MCPMethod, message any, err error) {
+		MCPRequests.Inc(methodLabel(method), "error")
+	})
+	return hooks
+}
+
+// knownMethods are the methods counted by name; clients choose method names, so any other
+// is counted as "other" to keep the number of series bounded.
+var knownMethods = map[mcp.MCPMethod]bool{
+	mcp.MethodInitialize: true, mcp.MethodPing: true, mcp.MethodResourcesList: true,
+	mcp.MethodResourcesTemplatesList: true, mcp.MethodResourcesRead: true, mcp.MethodPromptsList: true,
+	mcp.MethodPromptsGet: true, mcp.MethodToolsList: true, mcp.MethodToolsCall: true, mcp.MethodSetLogLevel: true,
+}
+
+func methodLabel(method mcp.MCPMethod) string {
+	if knownMethods[method] {
+		return string(method)
+	}
+	return "other"
+}
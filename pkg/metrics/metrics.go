@@ -0,0 +1,356 @@
+// Package metrics keeps counters and histograms and serves them in the Prometheus text
+// exposition format, so a Prometheus server can scrape /metrics. Default holds the metrics of
+// the MCP server: MCP requests, tool call latency, upstream status codes, active sessions,
+// spec reloads and database poll durations. Library users serve them with Handler:
+//
+//	srv := mcpserver.NewMCPServer("myapi", "1.0.0", openapi2mcp.ServerOptions()...)
+//	http.Handle("/metrics", metrics.Handler())
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Kind is the Prometheus type of a metric.
+type Kind string
+
+const (
+	KindCounter   Kind = "counter"
+	KindGauge     Kind = "gauge"
+	KindHistogram Kind = "histogram"
+)
+
+// DefBuckets are the default histogram bucket upper bounds, in seconds.
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Sample is one value of a function-backed metric, with its label values.
+type Sample struct {
+	Labels []string
+	Value  float64
+}
+
+// collector writes the series of one metric.
+type collector interface {
+	describe() (name, help string, kind Kind)
+	write(w *bufio.Writer)
+}
+
+// Registry is a set of metrics, written together. It is safe for concurrent use.
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []collector
+	names      map[string]bool
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{names: map[string]bool{}}
+}
+
+// Default is the process-wide registry, served by Handler.
+var Default = NewRegistry()
+
+func (r *Registry) register(c collector) {
+	name, _, _ := c.describe()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[name] {
+		panic("metrics: duplicate metric " + name)
+	}
+	r.names[name] = true
+	r.collectors = append(r.collectors, c)
+}
+
+// Write writes every metric in the Prometheus text format, version 0.0.4.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.RLock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.RUnlock()
+	sort.Slice(collectors, func(i, j int) bool {
+		a, _, _ := collectors[i].describe()
+		b, _, _ := collectors[j].describe()
+		return a < b
+	})
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		name, help, kind := c.describe()
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, kind)
+		c.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler serves the metrics of the registry.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(w)
+	})
+}
+
+// Handler serves the metrics of the Default registry.
+func Handler() http.Handler {
+	return Default.Handler()
+}
+
+// series holds the label values of a series, joined for map keys.
+type series struct {
+	labels []string
+}
+
+func seriesKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// checkLabels panics when a metric is used with the wrong number of label values, a
+// programming error like a wrong metric name.
+func checkLabels(name string, labels, values []string) {
+	if len(labels) != len(values) {
+		panic(fmt.Sprintf("metrics: %s has %d labels, got %d values", name, len(labels), len(values)))
+	}
+}
+
+// Counter is a counter with labels, such as requests by method.
+type Counter struct {
+	name, help string
+	labels     []string
+	mu         sync.Mutex
+	values     map[string]*counterSeries
+}
+
+type counterSeries struct {
+	series
+	value float64
+}
+
+// NewCounter registers a counter with the given label names.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{name: name, help: help, labels: labels, values: map[string]*counterSeries{}}
+	r.register(c)
+	return c
+}
+
+// Inc adds one to the series of the label values.
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds v, which must not be negative, to the series of the label values.
+func (c *Counter) Add(v float64, values ...string) {
+	checkLabels(c.name, c.labels, values)
+	if v < 0 {
+		return
+	}
+	key := seriesKey(values)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.values[key]
+	if s == nil {
+		s = &counterSeries{series: series{labels: append([]string(nil), values...)}}
+		c.values[key] = s
+	}
+	s.value += v
+}
+
+// Value returns the value of the series of the label values.
+func (c *Counter) Value(values ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s := c.values[seriesKey(values)]; s != nil {
+		return s.value
+	}
+	return 0
+}
+
+func (c *Counter) describe() (string, string, Kind) { return c.name, c.help, KindCounter }
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range sortedKeys(c.values) {
+		s := c.values[key]
+		writeSample(w, c.name, c.labels, s.labels, "", "", s.value)
+	}
+}
+
+// Histogram is a histogram with labels, such as call durations by tool.
+type Histogram struct {
+	name, help string
+	labels     []string
+	buckets    []float64
+	mu         sync.Mutex
+	values     map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	series
+	counts []uint64 // per bucket, not cumulative; the last one is +Inf
+	sum    float64
+	count  uint64
+}
+
+// NewHistogram registers a histogram with the given bucket upper bounds (DefBuckets when
+// nil) and label names.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	h := &Histogram{name: name, help: help, labels: labels, buckets: buckets, values: map[string]*histogramSeries{}}
+	r.register(h)
+	return h
+}
+
+// Observe records v in the series of the label values.
+func (h *Histogram) Observe(v float64, values ...string) {
+	checkLabels(h.name, h.labels, values)
+	key := seriesKey(values)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.values[key]
+	if s == nil {
+		s = &histogramSeries{series: series{labels: append([]string(nil), values...)}, counts: make([]uint64, len(h.buckets)+1)}
+		h.values[key] = s
+	}
+	s.counts[sort.SearchFloat64s(h.buckets, v)]++
+	s.sum += v
+	s.count++
+}
+
+// Count returns the number of observations of the series of the label values.
+func (h *Histogram) Count(values ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s := h.values[seriesKey(values)]; s != nil {
+		return s.count
+	}
+	return 0
+}
+
+func (h *Histogram) describe() (string, string, Kind) { return h.name, h.help, KindHistogram }
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.values) {
+		s := h.values[key]
+		var cumulative uint64
+		for i, n := range s.counts {
+			cumulative += n
+			le := "+Inf"
+			if i < len(h.buckets) {
+				le = formatFloat(h.buckets[i])
+			}
+			writeSample(w, h.name+"_bucket", h.labels, s.labels, "le", le, float64(cumulative))
+		}
+		writeSample(w, h.name+"_sum", h.labels, s.labels, "", "", s.sum)
+		writeSample(w, h.name+"_count", h.labels, s.labels, "", "", float64(s.count))
+	}
+}
+
+// funcCollector reads its samples from a function at each scrape, for values kept elsewhere
+// such as session counts.
+type funcCollector struct {
+	name, help string
+	kind       Kind
+	labels     []string
+	mu         sync.RWMutex
+	fn         func() []Sample
+}
+
+// NewFunc registers a counter or gauge whose samples are returned by fn at each scrape.
+// fn may be nil and set later with the returned setter.
+func (r *Registry) NewFunc(name, help string, kind Kind, labels []string, fn func() []Sample) func(func() []Sample) {
+	c := &funcCollector{name: name, help: help, kind: kind, labels: labels, fn: fn}
+	r.register(c)
+	return func(fn func() []Sample) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.fn = fn
+	}
+}
+
+func (c *funcCollector) describe() (string, string, Kind) { return c.name, c.help, c.kind }
+
+func (c *funcCollector) write(w *bufio.Writer) {
+	c.mu.RLock()
+	fn := c.fn
+	c.mu.RUnlock()
+	if fn == nil {
+		return
+	}
+	samples := fn()
+	sort.Slice(samples, func(i, j int) bool { return seriesKey(samples[i].Labels) < seriesKey(samples[j].Labels) })
+	for _, s := range samples {
+		if len(s.Labels) == len(c.labels) {
+			writeSample(w, c.name, c.labels, s.Labels, "", "", s.Value)
+		}
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeSample writes one sample line, with an extra label such as le when extraName is set.
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraName, extraValue string, v float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraName != "" {
+		w.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", l, escapeLabel(values[i]))
+		}
+		if extraName != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", extraName, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
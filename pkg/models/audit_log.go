@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// AuditRecord represents the tool_call_audit table structure: one row per finished tool
+// call, written by the audit log
+type AuditRecord struct {
+	ID             int64     `json:"id" db:"id"`
+	CalledAt       time.Time `json:"called_at" db:"called_at"`
+	Endpoint       string    `json:"endpoint" db:"endpoint"`
+	Tool           string    `json:"tool" db:"tool"`
+	SessionID      *string   `json:"session_id,omitempty" db:"session_id"`
+	ArgNames       *string   `json:"arg_names,omitempty" db:"arg_names"` // comma-separated argument names
+	ArgsHash       *string   `json:"args_hash,omitempty" db:"args_hash"` // SHA-256 of the arguments; values are not logged
+	Status         string    `json:"status" db:"status"`                 // completed or failed
+	UpstreamStatus *int      `json:"upstream_status,omitempty" db:"upstream_status"`
+	Error          *string   `json:"error,omitempty" db:"error"`
+	DurationMs     float64   `json:"duration_ms" db:"duration_ms"`
+	Host           string    `json:"host" db:"host"` // server instance that served the call
+}
+
+// TableName returns the table name for the AuditRecord model
+func (AuditRecord) TableName() string {
+	return "tool_call_audit"
+}
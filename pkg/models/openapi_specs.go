@@ -1,23 +1,34 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
 // OpenAPISpec represents the openapi_specs table structure
 type OpenAPISpec struct {
-	ID           int        `json:"id" db:"id"`
-	Name         string     `json:"name" db:"name"`
-	Title        *string    `json:"title,omitempty" db:"title"`
-	Version      *string    `json:"version,omitempty" db:"version"`
-	SpecContent  string     `json:"spec_content" db:"spec_content"`
-	EndpointPath string     `json:"endpoint_path" db:"endpoint_path"`
-	FileFormat   *string    `json:"file_format,omitempty" db:"file_format"`
-	FileSize     *int       `json:"file_size,omitempty" db:"file_size"`
-	ApiKeyToken  *string    `json:"api_key_token,omitempty" db:"api_key_token"`
-	IsActive     *bool      `json:"is_active,omitempty" db:"is_active"`
-	CreatedAt    *time.Time `json:"created_at,omitempty" db:"created_at"`
-	UpdatedAt    *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+	ID                 int               `json:"id" db:"id"`
+	Name               string            `json:"name" db:"name"`
+	Title              *string           `json:"title,omitempty" db:"title"`
+	Version            *string           `json:"version,omitempty" db:"version"`
+	SpecContent        string            `json:"spec_content" db:"spec_content"`
+	EndpointPath       string            `json:"endpoint_path" db:"endpoint_path"`
+	FileFormat         *string           `json:"file_format,omitempty" db:"file_format"`
+	FileSize           *int              `json:"file_size,omitempty" db:"file_size"`
+	ApiKeyToken        *string           `json:"api_key_token,omitempty" db:"api_key_token"`
+	IsActive           *bool             `json:"is_active,omitempty" db:"is_active"`
+	CreatedAt          *time.Time        `json:"created_at,omitempty" db:"created_at"`
+	UpdatedAt          *time.Time        `json:"updated_at,omitempty" db:"updated_at"`
+	ContentHash        *string           `json:"content_hash,omitempty" db:"content_hash"`                 // SHA-256 key of the content in spec_blobs
+	FeatureFlags       *string           `json:"feature_flags,omitempty" db:"feature_flags"`               // JSON object mapping feature flags to the environments they are enabled in
+	Aliases            *string           `json:"aliases,omitempty" db:"aliases"`                           // comma-separated endpoint paths the spec is also served at, e.g. "/wx"
+	DeletedAt          *time.Time        `json:"deleted_at,omitempty" db:"deleted_at"`                     // set while the spec is soft-deleted, until it is restored or purged
+	RequireToken       bool              `json:"require_token_to_activate" db:"require_token_to_activate"` // mount the spec without tools while it has no credentials
+	RateLimit          *string           `json:"rate_limit,omitempty" db:"rate_limit"`                     // requests allowed to the endpoint, e.g. "1000/m, client=60/m"; nil uses MCP_RATE_LIMIT
+	ToolRules          *string           `json:"tool_rules,omitempty" db:"tool_rules"`                     // JSON include/exclude rules selecting the operations that become tools; nil exposes all
+	UpstreamPolicy     *string           `json:"upstream_policy,omitempty" db:"upstream_policy"`           // JSON timeout, retry and circuit breaker settings of upstream calls; nil uses the defaults
+	CredentialStrategy *string           `json:"credential_strategy,omitempty" db:"credential_strategy"`   // how calls spread over several API keys: round-robin or failover; nil uses round-robin
+	Credentials        []*SpecCredential `json:"credentials,omitempty" db:"-"`                             // API keys used in turn with api_key_token; loaded with the active specs
 }
 
 // TableName returns the table name for the OpenAPISpec model
@@ -41,3 +52,17 @@ func NewOpenAPISpec(name, specContent, endpointPath string) *OpenAPISpec {
 		UpdatedAt:    &now,
 	}
 }
+
+// AliasPaths returns the endpoint aliases of the spec without leading slashes
+func (s *OpenAPISpec) AliasPaths() []string {
+	if s.Aliases == nil {
+		return nil
+	}
+	var aliases []string
+	for _, alias := range strings.Split(*s.Aliases, ",") {
+		if alias = strings.Trim(strings.TrimSpace(alias), "/"); alias != "" {
+			aliases = append(aliases, alias)
+		}
+	}
+	return aliases
+}
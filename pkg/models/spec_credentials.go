@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// SpecCredential represents the spec_credentials table structure: an API key of a spec
+// besides its api_key_token, so calls can spread over several keys with per-key rate limits
+type SpecCredential struct {
+	ID        int        `json:"id" db:"id"`
+	SpecID    int        `json:"spec_id" db:"spec_id"`
+	Label     *string    `json:"label,omitempty" db:"label"` // e.g. the account the key belongs to
+	Token     string     `json:"token" db:"token"`           // the key, or a secret manager reference like vault://...
+	CreatedAt *time.Time `json:"created_at,omitempty" db:"created_at"`
+}
+
+// TableName returns the table name for the SpecCredential model
+func (SpecCredential) TableName() string {
+	return "spec_credentials"
+}
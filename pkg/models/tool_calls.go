@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// ToolCall represents the tool_call_journal table structure: one row per tool call,
+// written when the call is accepted and updated when it finishes
+type ToolCall struct {
+	ID         int64      `json:"id" db:"id"`
+	Endpoint   string     `json:"endpoint" db:"endpoint"`
+	Tool       string     `json:"tool" db:"tool"`
+	SessionID  *string    `json:"session_id,omitempty" db:"session_id"`
+	ArgNames   *string    `json:"arg_names,omitempty" db:"arg_names"` // comma-separated argument names; values are not journaled
+	Status     string     `json:"status" db:"status"`                 // accepted, completed, failed or interrupted
+	Error      *string    `json:"error,omitempty" db:"error"`
+	Host       string     `json:"host" db:"host"` // server instance that accepted the call
+	Pid        int        `json:"pid" db:"pid"`
+	AcceptedAt time.Time  `json:"accepted_at" db:"accepted_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty" db:"finished_at"`
+}
+
+// TableName returns the table name for the ToolCall model
+func (ToolCall) TableName() string {
+	return "tool_call_journal"
+}
@@ -0,0 +1,102 @@
+// accept.go
+package openapi2mcp
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+const (
+	// acceptArgName is the optional tool argument that overrides the upstream Accept header.
+	acceptArgName = "_accept"
+	// acceptExtension is the root-level spec extension that sets a per-spec default Accept header.
+	acceptExtension = "x-mcp-accept"
+	// defaultAccept is sent when neither the call nor the spec asks for a representation.
+	defaultAccept = "application/json, application/vnd.api+json"
+)
+
+// specAccept returns the per-spec Accept header from opts or the x-mcp-accept extension, if any.
+func specAccept(doc *openapi3.T, opts *ToolGenOptions) string {
+	if opts != nil && opts.Accept != "" {
+		return opts.Accept
+	}
+	if doc != nil {
+		if v, ok := doc.Extensions[acceptExtension].(string); ok {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// resolveAccept picks the Accept header for a call: the _accept argument wins over the spec default.
+func resolveAccept(args map[string]any, specDefault string) string {
+	if v, ok := args[acceptArgName].(string); ok && strings.TrimSpace(v) != "" {
+		return strings.TrimSpace(v)
+	}
+	if specDefault != "" {
+		return specDefault
+	}
+	return defaultAccept
+}
+
+// responseMediaTypes lists the media types declared by an operation's 2xx and default responses.
+func responseMediaTypes(doc *openapi3.T, op OpenAPIOperation) []string {
+	if doc == nil || doc.Paths == nil {
+		return nil
+	}
+	item := doc.Paths.Value(op.Path)
+	if item == nil {
+		return nil
+	}
+	operation := item.GetOperation(strings.ToUpper(op.Method))
+	if operation == nil || operation.Responses == nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	for code, ref := range operation.Responses.Map() {
+		if ref == nil || ref.Value == nil || !(strings.HasPrefix(code, "2") || code == "default") {
+			continue
+		}
+		for mediaType := range ref.Value.Content {
+			seen[mediaType] = true
+		}
+	}
+	types := make([]string, 0, len(seen))
+	for mediaType := range seen {
+		types = append(types, mediaType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// addAcceptProperty advertises the _accept argument on operations whose responses
+// come in more than one representation.
+func addAcceptProperty(inputSchema map[string]any, mediaTypes []string) {
+	if len(mediaTypes) < 2 {
+		return
+	}
+	properties, ok := inputSchema["properties"].(map[string]any)
+	if !ok {
+		return
+	}
+	properties[acceptArgName] = map[string]any{
+		"type":        "string",
+		"description": "Optional Accept header for the upstream request, to choose the response representation. Declared types: " + strings.Join(mediaTypes, ", "),
+		"examples":    mediaTypes,
+	}
+}
+
+// withContentMeta records the negotiated representation in the result metadata.
+func withContentMeta(res *mcp.CallToolResult, accept, contentType string) *mcp.CallToolResult {
+	if res.Meta == nil {
+		res.Meta = map[string]any{}
+	}
+	res.Meta["accept"] = accept
+	if contentType != "" {
+		res.Meta["contentType"] = contentType
+	}
+	return res
+}
@@ -0,0 +1,117 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+const acceptSpec = `
+openapi: 3.0.0
+info:
+  title: Repo
+  version: 1.0.0
+paths:
+  /readme:
+    get:
+      operationId: getReadme
+      responses:
+        '200':
+          description: ok
+          content:
+            application/json:
+              schema: {type: object}
+            application/vnd.github.raw:
+              schema: {type: string}
+  /ping:
+    get:
+      operationId: ping
+      responses:
+        '200':
+          description: ok
+          content:
+            application/json:
+              schema: {type: object}
+`
+
+// acceptUpstream records the Accept header of each request in gotAccept, and answers in
+// Markdown when a raw representation is accepted
+func acceptUpstream(gotAccept *string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*gotAccept = r.Header.Get("Accept")
+		if strings.Contains(*gotAccept, "raw") {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Write([]byte("# README"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}
+}
+
+func callToolForTest(t *testing.T, server *mcpserver.MCPServer, name string, args map[string]any) mcp.CallToolResult {
+	t.Helper()
+	req, _ := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]any{"name": name, "arguments": args},
+	})
+	resp, ok := server.HandleMessage(context.Background(), req).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("expected JSONRPCResponse for %s", name)
+	}
+	return resp.Result.(mcp.CallToolResult)
+}
+
+func TestAcceptOverride(t *testing.T) {
+	gotAccept := new(string)
+	server := newTestServer(t, acceptSpec, nil, acceptUpstream(gotAccept))
+
+	tools := map[string]mcp.Tool{}
+	for _, tool := range server.ListTools() {
+		tools[tool.Name] = tool
+	}
+	if !strings.Contains(string(tools["getReadme"].RawInputSchema), `"_accept"`) {
+		t.Errorf("expected _accept property for operation with several representations")
+	}
+	if strings.Contains(string(tools["ping"].RawInputSchema), `"_accept"`) {
+		t.Errorf("did not expect _accept property for single-representation operation")
+	}
+
+	result := callToolForTest(t, server, "getReadme", map[string]any{})
+	if *gotAccept != defaultAccept {
+		t.Errorf("expected default Accept, got %q", *gotAccept)
+	}
+	if result.Meta["contentType"] != "application/json" {
+		t.Errorf("expected JSON content type in metadata, got %v", result.Meta)
+	}
+
+	result = callToolForTest(t, server, "getReadme", map[string]any{"_accept": "application/vnd.github.raw"})
+	if *gotAccept != "application/vnd.github.raw" {
+		t.Errorf("expected overridden Accept, got %q", *gotAccept)
+	}
+	if result.Meta["contentType"] != "text/plain; charset=utf-8" || result.Meta["accept"] != "application/vnd.github.raw" {
+		t.Errorf("expected negotiated representation in metadata, got %v", result.Meta)
+	}
+}
+
+func TestAcceptSpecDefault(t *testing.T) {
+	spec := strings.Replace(acceptSpec, "info:", "x-mcp-accept: application/vnd.github.raw\ninfo:", 1)
+	gotAccept := new(string)
+	server := newTestServer(t, spec, nil, acceptUpstream(gotAccept))
+
+	callToolForTest(t, server, "ping", map[string]any{})
+	if *gotAccept != "application/vnd.github.raw" {
+		t.Errorf("expected spec-level Accept, got %q", *gotAccept)
+	}
+	callToolForTest(t, server, "ping", map[string]any{"_accept": "application/json"})
+	if *gotAccept != "application/json" {
+		t.Errorf("expected _accept to override spec default, got %q", *gotAccept)
+	}
+}
@@ -0,0 +1,187 @@
+package openapi2mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+// apiPromptExtension is the root OpenAPI extension that turns the usage prompt of a spec off
+// (`x-mcp-prompt: false`).
+const apiPromptExtension = "x-mcp-prompt"
+
+// maxPromptTools bounds the tools listed by a usage prompt; large specs point to describe
+// for the rest.
+const maxPromptTools = 150
+
+// APIPromptName returns the name of the usage prompt of an endpoint, e.g. how-to-use-weather.
+func APIPromptName(endpoint string) string {
+	return "how-to-use-" + endpoint
+}
+
+// specAPIPrompt reports whether a usage prompt is registered for the spec: off with
+// opts.NoAPIPrompt, then the x-mcp-prompt extension, then MCP_API_PROMPT. On by default.
+func specAPIPrompt(doc *openapi3.T, opts *ToolGenOptions) bool {
+	if opts != nil && opts.NoAPIPrompt {
+		return false
+	}
+	if doc != nil {
+		if v, ok := doc.Extensions[apiPromptExtension].(bool); ok {
+			return v
+		}
+	}
+	if v := os.Getenv("MCP_API_PROMPT"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		return err != nil || enabled
+	}
+	return true
+}
+
+// promptTool is a tool listed by a usage prompt
+type promptTool struct {
+	name    string
+	summary string
+}
+
+// registerAPIPrompt registers a prompt explaining how to use the spec's API: what it is
+// for, from info.description, and its tools grouped by tag with their summaries. It gives
+// clients usage guidance without calling a docs tool. The optional task argument adds the
+// user's goal, so the prompt can start a conversation about it.
+func registerAPIPrompt(server *mcpserver.MCPServer, ops []OpenAPIOperation, doc *openapi3.T, opts *ToolGenOptions, endpoint string, toolNames []string) {
+	text := apiPromptText(ops, doc, opts, toolNames)
+	title := endpoint
+	if doc.Info != nil && doc.Info.Title != "" {
+		title = doc.Info.Title
+	}
+	prompt := mcp.NewPrompt(APIPromptName(endpoint),
+		mcp.WithPromptDescription(fmt.Sprintf("How to use the %s API: what it does and which tools to call", title)),
+		mcp.WithArgument("task", mcp.ArgumentDescription("What you want to do with the API (optional)")),
+	)
+	server.AddPrompt(prompt, func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		content := text
+		if task := strings.TrimSpace(request.Params.Arguments["task"]); task != "" {
+			content += "\n\nTask: " + task + "\nPick the tools above that accomplish it, and call them."
+		}
+		return &mcp.GetPromptResult{
+			Description: prompt.Description,
+			Messages:    []mcp.PromptMessage{mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(content))},
+		}, nil
+	})
+}
+
+// apiPromptText builds the text of a usage prompt from the spec's info, its tags and the
+// summaries of the operations whose tools are registered.
+func apiPromptText(ops []OpenAPIOperation, doc *openapi3.T, opts *ToolGenOptions, toolNames []string) string {
+	registered := map[string]bool{}
+	for _, name := range toolNames {
+		registered[name] = true
+	}
+
+	var b strings.Builder
+	title, version, description := "this", "", ""
+	if doc.Info != nil {
+		if doc.Info.Title != "" {
+			title = doc.Info.Title
+		}
+		version, description = doc.Info.Version, strings.TrimSpace(doc.Info.Description)
+	}
+	fmt.Fprintf(&b, "You can use the %s API", title)
+	if version != "" {
+		fmt.Fprintf(&b, " (version %s)", version)
+	}
+	b.WriteString(" through the tools of this server.")
+	if description != "" {
+		b.WriteString("\n\n" + truncateText(description, specMaxDescriptionChars(doc, opts)))
+	}
+
+	byTag := map[string][]promptTool{}
+	total := 0
+	for _, op := range ops {
+		name := op.OperationID
+		if opts != nil && opts.NameFormat != nil {
+			name = opts.NameFormat(name)
+		}
+		if !registered[name] {
+			continue
+		}
+		summary := op.Summary
+		if summary == "" {
+			summary = firstLine(op.Description)
+		}
+		if summary == "" {
+			summary = fmt.Sprintf("%s %s", strings.ToUpper(op.Method), op.Path)
+		}
+		tag := ""
+		if len(op.Tags) > 0 {
+			tag = op.Tags[0]
+		}
+		byTag[tag] = append(byTag[tag], promptTool{name: name, summary: summary})
+		total++
+	}
+	if total == 0 {
+		return b.String()
+	}
+
+	b.WriteString("\n\nTools:")
+	listed := 0
+	for _, tag := range promptTagOrder(doc, byTag) {
+		if listed >= maxPromptTools {
+			break
+		}
+		heading := tag
+		if heading == "" {
+			heading = "Other"
+		}
+		b.WriteString("\n\n" + heading)
+		if t := doc.Tags.Get(tag); tag != "" && t != nil && t.Description != "" {
+			b.WriteString(": " + firstLine(t.Description))
+		}
+		for _, tool := range byTag[tag] {
+			if listed >= maxPromptTools {
+				break
+			}
+			fmt.Fprintf(&b, "\n- %s: %s", tool.name, tool.summary)
+			listed++
+		}
+	}
+	if listed < total {
+		fmt.Fprintf(&b, "\n\n...and %d more tools.", total-listed)
+	}
+
+	if registered["describe"] {
+		b.WriteString("\n\nCall describe with {\"tool\": \"<name>\"} for the full parameters and responses of a tool before using it.")
+	}
+	return b.String()
+}
+
+// promptTagOrder returns the tags of byTag in the order the spec declares them, then the
+// undeclared ones by name, and untagged operations last.
+func promptTagOrder(doc *openapi3.T, byTag map[string][]promptTool) []string {
+	var order []string
+	seen := map[string]bool{}
+	for _, t := range doc.Tags {
+		if t != nil && len(byTag[t.Name]) > 0 && !seen[t.Name] {
+			seen[t.Name] = true
+			order = append(order, t.Name)
+		}
+	}
+	var rest []string
+	for tag := range byTag {
+		if tag != "" && !seen[tag] {
+			rest = append(rest, tag)
+		}
+	}
+	sort.Strings(rest)
+	order = append(order, rest...)
+	if len(byTag[""]) > 0 {
+		order = append(order, "")
+	}
+	return order
+}
@@ -0,0 +1,94 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+const taggedSpec = `
+openapi: 3.0.0
+info:
+  title: Weather
+  version: 2.5.0
+  description: |
+    Current conditions and forecasts for any city.
+
+    Data is refreshed every ten minutes.
+tags:
+  - name: forecast
+    description: Forecasts up to five days ahead
+  - name: current
+paths:
+  /weather:
+    get:
+      operationId: getCurrentWeather
+      tags: [current]
+      summary: Current weather of a city
+      responses:
+        '200': {description: ok}
+  /forecast:
+    get:
+      operationId: getForecast
+      tags: [forecast]
+      summary: Five day forecast of a city
+      responses:
+        '200': {description: ok}
+  /status:
+    get:
+      operationId: getStatus
+      description: |
+        Service status.
+        Includes the data freshness.
+      responses:
+        '200': {description: ok}
+`
+
+func getPromptForTest(t *testing.T, server *mcpserver.MCPServer, name string, args map[string]string) mcp.JSONRPCMessage {
+	t.Helper()
+	req, _ := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "prompts/get",
+		"params":  map[string]any{"name": name, "arguments": args},
+	})
+	return server.HandleMessage(context.Background(), req)
+}
+
+func TestAPIPrompt(t *testing.T) {
+	server := newTestServer(t, taggedSpec, nil, nil)
+
+	resp, ok := getPromptForTest(t, server, "how-to-use-weather", map[string]string{"task": "Will it rain in Oslo tomorrow?"}).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("expected the how-to-use-weather prompt, got %+v", getPromptForTest(t, server, "how-to-use-weather", nil))
+	}
+	result := resp.Result.(mcp.GetPromptResult)
+	text := result.Messages[0].Content.(mcp.TextContent).Text
+	for _, want := range []string{
+		"Weather API (version 2.5.0)",
+		"Data is refreshed every ten minutes.",
+		"forecast: Forecasts up to five days ahead\n- getForecast: Five day forecast of a city",
+		"current\n- getCurrentWeather: Current weather of a city",
+		"Other\n- getStatus: Service status.",
+		"Task: Will it rain in Oslo tomorrow?",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected the prompt to contain %q, got:\n%s", want, text)
+		}
+	}
+	// Tags are listed in the order the spec declares them
+	if strings.Index(text, "getForecast") > strings.Index(text, "getCurrentWeather") {
+		t.Errorf("expected the forecast tag first, got:\n%s", text)
+	}
+}
+
+func TestAPIPromptDisabled(t *testing.T) {
+	server := newTestServer(t, taggedSpec, &ToolGenOptions{NoAPIPrompt: true}, nil)
+	if _, ok := getPromptForTest(t, server, "how-to-use-weather", nil).(mcp.JSONRPCResponse); ok {
+		t.Error("expected no prompt with NoAPIPrompt")
+	}
+}
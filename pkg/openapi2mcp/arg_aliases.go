@@ -0,0 +1,92 @@
+package openapi2mcp
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// argAliasesExtension is the root OpenAPI extension that turns argument name aliasing off
+// (`x-mcp-arg-aliases: false`) for a spec.
+const argAliasesExtension = "x-mcp-arg-aliases"
+
+// specArgAliases reports whether a spec's tools accept case variants of argument names:
+// off with opts.DisableArgAliases, then the x-mcp-arg-aliases extension, then
+// MCP_ARG_ALIASES. On by default.
+func specArgAliases(doc *openapi3.T, opts *ToolGenOptions) bool {
+	if opts != nil && opts.DisableArgAliases {
+		return false
+	}
+	if doc != nil {
+		if v, ok := doc.Extensions[argAliasesExtension].(bool); ok {
+			return v
+		}
+	}
+	if v := os.Getenv("MCP_ARG_ALIASES"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		return err != nil || enabled
+	}
+	return true
+}
+
+// normalizeArgName folds the case and word separators of a name, so petId, pet_id, PetID
+// and pet-id are the same.
+func normalizeArgName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if r != '_' && r != '-' && r != '.' && r != ' ' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// argAliasIndex maps the normalized names of a tool's top-level arguments to their
+// declared names. Names that two arguments normalize to are left out, as they are ambiguous.
+func argAliasIndex(schema map[string]any) map[string]string {
+	props, _ := schema["properties"].(map[string]any)
+	index := map[string]string{}
+	ambiguous := map[string]bool{}
+	for name := range props {
+		key := normalizeArgName(name)
+		if _, taken := index[key]; taken {
+			ambiguous[key] = true
+		}
+		index[key] = name
+	}
+	for key := range ambiguous {
+		delete(index, key)
+	}
+	if len(index) == 0 {
+		return nil
+	}
+	return index
+}
+
+// applyArgAliases renames the arguments that are case variants of a declared argument to
+// the declared name, unless the call also has the declared name. It returns the
+// corrections made, as "given→declared".
+func applyArgAliases(args map[string]any, index map[string]string) []string {
+	if len(index) == 0 {
+		return nil
+	}
+	var corrections []string
+	for given, value := range args {
+		declared, ok := index[normalizeArgName(given)]
+		if !ok || declared == given {
+			continue
+		}
+		if _, exists := args[declared]; exists {
+			continue
+		}
+		args[declared] = value
+		delete(args, given)
+		corrections = append(corrections, fmt.Sprintf("%s→%s", given, declared))
+	}
+	sort.Strings(corrections)
+	return corrections
+}
@@ -0,0 +1,76 @@
+package openapi2mcp
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+const aliasSpec = `
+openapi: 3.0.0
+info:
+  title: Aliases
+  version: 1.0.0
+x-mcp-strict-schema: true
+paths:
+  /pets/{pet_id}/visits:
+    get:
+      operationId: listVisits
+      parameters:
+        - name: pet_id
+          in: path
+          required: true
+          schema: {type: integer}
+        - name: pageSize
+          in: query
+          schema: {type: integer}
+      responses:
+        '200': {description: OK}
+`
+
+// uriUpstream records the request URI of each request in gotURI
+func uriUpstream(gotURI *string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*gotURI = r.URL.RequestURI()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}
+}
+
+func TestArgAliasesMapCaseVariants(t *testing.T) {
+	gotURI := new(string)
+	server := newTestServer(t, aliasSpec, nil, uriUpstream(gotURI))
+
+	res := callToolForTest(t, server, "listVisits", map[string]any{"petId": 3, "page_size": 10})
+	if res.IsError {
+		t.Fatalf("expected case variants to be accepted, got %s", res.Content[0].(mcp.TextContent).Text)
+	}
+	if *gotURI != "/pets/3/visits?pageSize=10" {
+		t.Errorf("expected aliased arguments in the upstream request, got %s", *gotURI)
+	}
+}
+
+func TestArgAliasesDisabled(t *testing.T) {
+	server := newTestServer(t, strings.Replace(aliasSpec, "x-mcp-strict-schema: true", "x-mcp-strict-schema: true\nx-mcp-arg-aliases: false", 1), nil, uriUpstream(new(string)))
+
+	res := callToolForTest(t, server, "listVisits", map[string]any{"pet_id": 3, "page_size": 10})
+	if !res.IsError {
+		t.Fatal("expected the variant to be rejected as unknown with aliasing off")
+	}
+}
+
+func TestApplyArgAliases(t *testing.T) {
+	index := argAliasIndex(map[string]any{"properties": map[string]any{
+		"user_id": map[string]any{}, "userID": map[string]any{}, "sort-order": map[string]any{},
+	}})
+	args := map[string]any{"SortOrder": "asc", "userId": 1, "sort-order": nil}
+	if got := applyArgAliases(args, index); len(got) != 0 {
+		t.Errorf("expected no corrections when the declared name is present or ambiguous, got %v", got)
+	}
+	args = map[string]any{"sortOrder": "asc"}
+	if got := applyArgAliases(args, index); strings.Join(got, ",") != "sortOrder→sort-order" || args["sort-order"] != "asc" {
+		t.Errorf("unexpected corrections %v, args %v", got, args)
+	}
+}
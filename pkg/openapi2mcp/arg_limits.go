@@ -0,0 +1,208 @@
+package openapi2mcp
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const (
+	// maxArgsBytesExtension is the root-level spec extension that limits the total size of a
+	// call's arguments, e.g. x-mcp-max-args-bytes: 262144
+	maxArgsBytesExtension = "x-mcp-max-args-bytes"
+	// defaultMaxArgsBytes applies when neither opts, the spec nor MCP_MAX_ARGS_BYTES set a limit.
+	defaultMaxArgsBytes = 1 << 20
+	// maxArgLimitDepth bounds how deep limits are collected from nested schemas.
+	maxArgLimitDepth = 8
+)
+
+// specMaxArgsBytes returns the size limit for the JSON arguments of a call: opts, then the
+// x-mcp-max-args-bytes extension, then MCP_MAX_ARGS_BYTES, then 1 MiB. A binary body in
+// body_base64 is not counted; it has its own limit (see specMaxBinaryBodyBytes).
+func specMaxArgsBytes(doc *openapi3.T, opts *ToolGenOptions) int64 {
+	if opts != nil && opts.MaxArgsBytes > 0 {
+		return opts.MaxArgsBytes
+	}
+	if doc != nil {
+		if n := numberValue(doc.Extensions[maxArgsBytesExtension]); n > 0 {
+			return int64(n)
+		}
+	}
+	if v := os.Getenv("MCP_MAX_ARGS_BYTES"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			return n
+		}
+		fmt.Fprintf(os.Stderr, "[WARN] Ignoring invalid MCP_MAX_ARGS_BYTES=%q\n", v)
+	}
+	return defaultMaxArgsBytes
+}
+
+// argLimits are the maxLength and maxItems of an argument and of the values nested in it.
+type argLimits struct {
+	maxLength  int // for strings; 0 when unlimited
+	maxItems   int // for arrays; 0 when unlimited
+	properties map[string]*argLimits
+	items      *argLimits
+}
+
+// operationArgLimits collects the size limits an operation's schemas declare, keyed by
+// argument name as in the input schema. It returns nil when the operation declares none.
+func operationArgLimits(op OpenAPIOperation) map[string]*argLimits {
+	limits := map[string]*argLimits{}
+	duplicates := duplicateParamNames(op.Parameters)
+	for _, paramRef := range op.Parameters {
+		if paramRef == nil || paramRef.Value == nil {
+			continue
+		}
+		if l := schemaArgLimits(paramRef.Value.Schema, 0); l != nil {
+			limits[paramArgName(paramRef.Value, duplicates)] = l
+		}
+	}
+	if op.RequestBody != nil && op.RequestBody.Value != nil {
+		for _, mt := range op.RequestBody.Value.Content {
+			if mt == nil {
+				continue
+			}
+			if l := schemaArgLimits(mt.Schema, 0); l != nil {
+				limits["requestBody"] = l
+				break
+			}
+		}
+	}
+	if len(limits) == 0 {
+		return nil
+	}
+	return limits
+}
+
+func schemaArgLimits(ref *openapi3.SchemaRef, depth int) *argLimits {
+	if ref == nil || ref.Value == nil || depth > maxArgLimitDepth {
+		return nil
+	}
+	s := ref.Value
+	l := &argLimits{}
+	if s.MaxLength != nil {
+		l.maxLength = int(*s.MaxLength)
+	}
+	if s.MaxItems != nil {
+		l.maxItems = int(*s.MaxItems)
+	}
+	l.items = schemaArgLimits(s.Items, depth+1)
+	addProperties := func(props openapi3.Schemas) {
+		for name, sub := range props {
+			if sl := schemaArgLimits(sub, depth+1); sl != nil {
+				if l.properties == nil {
+					l.properties = map[string]*argLimits{}
+				}
+				l.properties[name] = sl
+			}
+		}
+	}
+	addProperties(s.Properties)
+	for _, sub := range s.AllOf {
+		if sub != nil && sub.Value != nil {
+			addProperties(sub.Value.Properties)
+		}
+	}
+	if l.maxLength == 0 && l.maxItems == 0 && l.items == nil && l.properties == nil {
+		return nil
+	}
+	return l
+}
+
+// checkArgSizes rejects arguments over the limits of the operation's schemas, and arguments
+// whose total JSON size exceeds maxBytes, before they are marshaled or sent upstream. It
+// returns "" when the arguments are within the limits.
+func checkArgSizes(args map[string]any, limits map[string]*argLimits, maxBytes int64) string {
+	var total int64
+	for name, value := range args {
+		if name == binaryBodyArgName {
+			continue
+		}
+		total += int64(len(name)) + 4
+		if total += jsonSizeUpTo(value, maxBytes-total); total > maxBytes {
+			return fmt.Sprintf("Arguments too large: they exceed the limit of %d bytes. Send less data per call, for example by paging or splitting it into several calls.", maxBytes)
+		}
+	}
+	names := make([]string, 0, len(limits))
+	for name := range limits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if value, ok := args[name]; ok {
+			if msg := checkArgLimits(name, value, limits[name]); msg != "" {
+				return msg
+			}
+		}
+	}
+	return ""
+}
+
+func checkArgLimits(path string, value any, l *argLimits) string {
+	switch v := value.(type) {
+	case string:
+		if l.maxLength > 0 {
+			if n := utf8.RuneCountInString(v); n > l.maxLength {
+				return fmt.Sprintf("Argument '%s' is too long: %d characters, the maximum is %d.", path, n, l.maxLength)
+			}
+		}
+	case []any:
+		if l.maxItems > 0 && len(v) > l.maxItems {
+			return fmt.Sprintf("Argument '%s' has too many items: %d, the maximum is %d.", path, len(v), l.maxItems)
+		}
+		if l.items != nil {
+			for i, item := range v {
+				if msg := checkArgLimits(fmt.Sprintf("%s[%d]", path, i), item, l.items); msg != "" {
+					return msg
+				}
+			}
+		}
+	case map[string]any:
+		for name, sub := range l.properties {
+			if item, ok := v[name]; ok {
+				if msg := checkArgLimits(path+"."+name, item, sub); msg != "" {
+					return msg
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// jsonSizeUpTo estimates the JSON encoded size of v, without encoding it. It stops counting
+// once the size exceeds budget.
+func jsonSizeUpTo(v any, budget int64) int64 {
+	switch v := v.(type) {
+	case nil:
+		return 4
+	case string:
+		return int64(len(v)) + 2
+	case bool:
+		return 5
+	case float64:
+		return int64(len(strconv.FormatFloat(v, 'g', -1, 64)))
+	case []any:
+		size := int64(2)
+		for _, item := range v {
+			if size += jsonSizeUpTo(item, budget-size) + 1; size > budget {
+				return size
+			}
+		}
+		return size
+	case map[string]any:
+		size := int64(2)
+		for key, item := range v {
+			if size += int64(len(key)) + 4 + jsonSizeUpTo(item, budget-size); size > budget {
+				return size
+			}
+		}
+		return size
+	default:
+		return int64(len(fmt.Sprint(v)))
+	}
+}
@@ -0,0 +1,100 @@
+package openapi2mcp
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+const argLimitsSpec = `
+openapi: 3.0.0
+info:
+  title: Notes
+  version: 1.0.0
+paths:
+  /notes:
+    post:
+      operationId: createNote
+      parameters:
+        - name: q
+          in: query
+          schema:
+            type: string
+            maxLength: 10
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                title:
+                  type: string
+                  maxLength: 5
+                tags:
+                  type: array
+                  maxItems: 2
+                  items:
+                    type: string
+                    maxLength: 3
+                text:
+                  type: string
+      responses:
+        "200":
+          description: OK
+`
+
+func TestArgSizeLimits(t *testing.T) {
+	calls := 0
+	server := newTestServer(t, argLimitsSpec, &ToolGenOptions{MaxArgsBytes: 200}, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	})
+
+	cases := []struct {
+		args map[string]any
+		want string
+	}{
+		{map[string]any{"q": "much too long"}, "Argument 'q' is too long: 13 characters, the maximum is 10."},
+		{map[string]any{"requestBody": map[string]any{"title": "Grocery list"}}, "Argument 'requestBody.title' is too long"},
+		{map[string]any{"requestBody": map[string]any{"tags": []any{"a", "b", "c"}}}, "Argument 'requestBody.tags' has too many items: 3, the maximum is 2."},
+		{map[string]any{"requestBody": map[string]any{"tags": []any{"a", "long"}}}, "Argument 'requestBody.tags[1]' is too long"},
+		{map[string]any{"requestBody": map[string]any{"text": strings.Repeat("x", 300)}}, "Arguments too large: they exceed the limit of 200 bytes."},
+	}
+	for _, c := range cases {
+		res := callToolForTest(t, server, "createNote", c.args)
+		if !res.IsError {
+			t.Errorf("expected %v to be rejected", c.args)
+			continue
+		}
+		if text := res.Content[0].(mcp.TextContent).Text; !strings.Contains(text, c.want) {
+			t.Errorf("expected %q, got %q", c.want, text)
+		}
+	}
+	if calls != 0 {
+		t.Errorf("expected oversized arguments not to reach the upstream API, got %d calls", calls)
+	}
+
+	res := callToolForTest(t, server, "createNote", map[string]any{
+		"q":           "short",
+		"requestBody": map[string]any{"title": "List", "tags": []any{"a", "b"}, "text": "milk"},
+		"__confirmed": true,
+	})
+	if res.IsError || calls != 1 {
+		t.Errorf("expected arguments within the limits to be sent, got %+v", res)
+	}
+}
+
+func TestJSONSizeUpTo(t *testing.T) {
+	value := map[string]any{"a": []any{"xy", 1.5, true, nil}}
+	// The estimate counts a separator after every value, so it may exceed the encoding a little
+	encoded := int64(len(`{"a":["xy",1.5,true,null]}`))
+	if got := jsonSizeUpTo(value, 1000); got < encoded || got > encoded+3 {
+		t.Errorf("expected about %d bytes, got %d", encoded, got)
+	}
+	if got := jsonSizeUpTo([]any{strings.Repeat("x", 100), strings.Repeat("y", 100)}, 50); got > 110 {
+		t.Errorf("expected counting to stop once the budget is exceeded, got %d", got)
+	}
+}
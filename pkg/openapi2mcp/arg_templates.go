@@ -0,0 +1,262 @@
+// arg_templates.go
+package openapi2mcp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+// argTemplatesExtension is the root-level spec extension mapping tool arguments to
+// values taken from the session, e.g. `x-mcp-arg-templates: {user_id: "{{jwt.sub}}"}`.
+// Templated arguments are hidden from the tool schema and always filled server-side.
+const argTemplatesExtension = "x-mcp-arg-templates"
+
+// argTemplatePlaceholder matches {{source.name}} placeholders in a template.
+var argTemplatePlaceholder = regexp.MustCompile(`\{\{\s*([a-zA-Z]+)\.([^}\s]+)\s*\}\}`)
+
+// SetArgTemplates records argument templates on doc, so they apply wherever the
+// spec is registered. An empty map removes them.
+func SetArgTemplates(doc *openapi3.T, templates map[string]string) {
+	if doc == nil {
+		return
+	}
+	if len(templates) == 0 {
+		delete(doc.Extensions, argTemplatesExtension)
+		return
+	}
+	if doc.Extensions == nil {
+		doc.Extensions = map[string]any{}
+	}
+	values := make(map[string]any, len(templates))
+	for arg, tmpl := range templates {
+		values[arg] = tmpl
+	}
+	doc.Extensions[argTemplatesExtension] = values
+}
+
+// specArgTemplates returns the argument templates from opts or the x-mcp-arg-templates extension.
+func specArgTemplates(doc *openapi3.T, opts *ToolGenOptions) map[string]string {
+	if opts != nil && len(opts.ArgTemplates) > 0 {
+		return opts.ArgTemplates
+	}
+	if doc == nil {
+		return nil
+	}
+	var templates map[string]string
+	switch v := doc.Extensions[argTemplatesExtension].(type) {
+	case map[string]any:
+		templates = make(map[string]string, len(v))
+		for arg, tmpl := range v {
+			if s, ok := tmpl.(string); ok {
+				templates[arg] = s
+			}
+		}
+	case map[string]string:
+		templates = v
+	}
+	if len(templates) == 0 {
+		return nil
+	}
+	return templates
+}
+
+// hideTemplatedArgs removes templated arguments from a tool input schema and
+// returns the names that were hidden, sorted.
+func hideTemplatedArgs(schema map[string]any, templates map[string]string) []string {
+	props, _ := schema["properties"].(map[string]any)
+	if len(templates) == 0 || props == nil {
+		return nil
+	}
+	var hidden []string
+	for arg := range templates {
+		if _, ok := props[arg]; ok {
+			delete(props, arg)
+			hidden = append(hidden, arg)
+		}
+	}
+	if len(hidden) == 0 {
+		return nil
+	}
+	if required, ok := schema["required"].([]string); ok {
+		kept := required[:0]
+		for _, r := range required {
+			if _, ok := templates[r]; !ok {
+				kept = append(kept, r)
+			}
+		}
+		schema["required"] = kept
+	}
+	sort.Strings(hidden)
+	return hidden
+}
+
+// applyArgTemplates fills the hidden arguments of a call from the session context.
+// Values supplied by the caller for hidden arguments are discarded first.
+func applyArgTemplates(ctx context.Context, args map[string]any, hidden []string, templates map[string]string) error {
+	for _, arg := range hidden {
+		delete(args, arg)
+	}
+	for _, arg := range hidden {
+		value, err := renderArgTemplate(ctx, templates[arg])
+		if err != nil {
+			return fmt.Errorf("cannot fill argument '%s': %v", arg, err)
+		}
+		args[arg] = value
+	}
+	return nil
+}
+
+// renderArgTemplate expands the placeholders of a template. Supported sources are
+// jwt.<claim> (verified bearer token claims), header.<Name> (incoming request headers)
+// and session.id (the MCP session ID).
+func renderArgTemplate(ctx context.Context, tmpl string) (string, error) {
+	var firstErr error
+	var claims map[string]any
+	out := argTemplatePlaceholder.ReplaceAllStringFunc(tmpl, func(m string) string {
+		parts := argTemplatePlaceholder.FindStringSubmatch(m)
+		source, name := parts[1], parts[2]
+		var value string
+		var err error
+		switch source {
+		case "jwt":
+			if claims == nil {
+				claims, err = sessionJWTClaims(ctx)
+			}
+			if err == nil {
+				value, err = claimValue(claims, name)
+			}
+		case "header":
+			value = sessionHeaders(ctx).Get(name)
+			if value == "" {
+				err = fmt.Errorf("header %s is not set", name)
+			}
+		case "session":
+			if session := mcpserver.ClientSessionFromContext(ctx); name == "id" && session != nil {
+				value = session.SessionID()
+			} else {
+				err = fmt.Errorf("session.%s is not available", name)
+			}
+		default:
+			err = fmt.Errorf("unknown template source %q", source)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return value
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return out, nil
+}
+
+// sessionHeaders returns the headers of the request that opened the session.
+func sessionHeaders(ctx context.Context) http.Header {
+	headers := http.Header{}
+	if session := mcpserver.ClientSessionFromContext(ctx); session != nil {
+		if withAuth, ok := session.(interface{ GetAuthHeaders() http.Header }); ok {
+			for k, v := range withAuth.GetAuthHeaders() {
+				headers[k] = v
+			}
+		}
+	}
+	if authCtx, ok := auth.FromContext(ctx); ok && authCtx != nil && authCtx.OriginalRequest != nil {
+		for k, v := range authCtx.OriginalRequest.Header {
+			if _, ok := headers[k]; !ok {
+				headers[k] = v
+			}
+		}
+	}
+	return headers
+}
+
+// sessionJWTClaims returns the claims of the session's bearer token after verifying
+// its HS256 signature with MCP_JWT_SECRET. Unverified claims are never used.
+func sessionJWTClaims(ctx context.Context) (map[string]any, error) {
+	secret := os.Getenv("MCP_JWT_SECRET")
+	if secret == "" {
+		return nil, fmt.Errorf("JWT claims require MCP_JWT_SECRET to be set")
+	}
+	token := strings.TrimSpace(sessionHeaders(ctx).Get("Authorization"))
+	if !strings.HasPrefix(strings.ToLower(token), "bearer ") {
+		return nil, fmt.Errorf("no bearer token in the session")
+	}
+	return verifyHS256JWT(strings.TrimSpace(token[len("bearer "):]), []byte(secret), time.Now())
+}
+
+// verifyHS256JWT checks the signature and validity window of a JWT and returns its claims.
+func verifyHS256JWT(token string, secret []byte, now time.Time) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed JWT")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", header.Alg)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(sig, mac.Sum(nil)) {
+		return nil, fmt.Errorf("invalid JWT signature")
+	}
+	var claims map[string]any
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed JWT claims")
+	}
+	if exp, ok := claims["exp"].(float64); ok && now.Unix() >= int64(exp) {
+		return nil, fmt.Errorf("JWT has expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Unix() < int64(nbf) {
+		return nil, fmt.Errorf("JWT is not valid yet")
+	}
+	return claims, nil
+}
+
+func decodeJWTSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// claimValue formats a claim for use in an argument; nested claims use dots, e.g. jwt.org.id.
+func claimValue(claims map[string]any, name string) (string, error) {
+	var v any = claims
+	for _, key := range strings.Split(name, ".") {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("JWT claim %s is not set", name)
+		}
+		if v, ok = obj[key]; !ok {
+			return "", fmt.Errorf("JWT claim %s is not set", name)
+		}
+	}
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), nil
+	default:
+		data, _ := json.Marshal(val)
+		return string(data), nil
+	}
+}
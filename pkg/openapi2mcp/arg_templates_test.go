@@ -0,0 +1,80 @@
+package openapi2mcp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+func signHS256(claims, secret string) string {
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + enc.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + enc.EncodeToString(mac.Sum(nil))
+}
+
+func TestArgTemplatesHideParameters(t *testing.T) {
+	doc, err := LoadOpenAPISpecFromString(mockUpstreamSpec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	SetArgTemplates(doc, map[string]string{"id": "{{header.X-Pet-Id}}"})
+	server := mcpserver.NewMCPServer("test", "0.0.1")
+	RegisterOpenAPITools(server, ExtractOpenAPIOperations(doc), doc, nil, nil)
+	for _, tool := range server.ListTools() {
+		if tool.Name == "getPet" && strings.Contains(string(tool.RawInputSchema), `"id"`) {
+			t.Errorf("expected templated argument to be hidden, got schema %s", tool.RawInputSchema)
+		}
+	}
+}
+
+func TestRenderArgTemplate(t *testing.T) {
+	t.Setenv("MCP_JWT_SECRET", "s3cret")
+	req := httptest.NewRequest("POST", "/pets", nil)
+	req.Header.Set("Authorization", "Bearer "+signHS256(`{"sub":"user-42","org":{"id":7}}`, "s3cret"))
+	req.Header.Set("X-Tenant", "acme")
+	ctx := auth.WithAuthContext(context.Background(), &auth.AuthContext{OriginalRequest: req})
+
+	got, err := renderArgTemplate(ctx, "{{header.X-Tenant}}/{{jwt.sub}}/{{jwt.org.id}}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "acme/user-42/7" {
+		t.Errorf("got %q", got)
+	}
+
+	args := map[string]any{"user_id": "someone-else"}
+	if err := applyArgTemplates(ctx, args, []string{"user_id"}, map[string]string{"user_id": "{{jwt.sub}}"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args["user_id"] != "user-42" {
+		t.Errorf("expected caller value to be replaced, got %v", args["user_id"])
+	}
+
+	req.Header.Set("Authorization", "Bearer "+signHS256(`{"sub":"user-42"}`, "wrong"))
+	if _, err := renderArgTemplate(ctx, "{{jwt.sub}}"); err == nil {
+		t.Errorf("expected an error for a token with an invalid signature")
+	}
+	if _, err := renderArgTemplate(ctx, "{{header.X-Missing}}"); err == nil {
+		t.Errorf("expected an error for a missing header")
+	}
+}
+
+func TestVerifyHS256JWTExpiry(t *testing.T) {
+	token := signHS256(`{"sub":"a","exp":1000}`, "k")
+	if _, err := verifyHS256JWT(token, []byte("k"), time.Unix(999, 0)); err != nil {
+		t.Errorf("unexpected error before expiry: %v", err)
+	}
+	if _, err := verifyHS256JWT(token, []byte("k"), time.Unix(1000, 0)); err == nil {
+		t.Errorf("expected an error for an expired token")
+	}
+}
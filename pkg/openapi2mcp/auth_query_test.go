@@ -0,0 +1,73 @@
+package openapi2mcp
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const authQuerySpec = `
+openapi: 3.0.0
+info:
+  title: Weather
+  version: 1.0.0
+components:
+  securitySchemes:
+    ApiKeyAuth:
+      type: apiKey
+      in: query
+      name: appid
+security:
+  - ApiKeyAuth: []
+paths:
+  /forecast:
+    get:
+      operationId: getForecast
+      responses:
+        '200':
+          description: ok
+`
+
+// upstreamQueryForTest calls getForecast with an API key from API_KEY and returns the
+// query the upstream API received
+func upstreamQueryForTest(t *testing.T, spec string) url.Values {
+	t.Helper()
+	var got url.Values
+	t.Setenv("API_KEY", "secret")
+
+	server := newTestServer(t, spec, nil, func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query()
+		w.Write([]byte(`{}`))
+	})
+	if res := callToolForTest(t, server, "getForecast", map[string]any{}); res.IsError {
+		t.Fatalf("unexpected error: %+v", res)
+	}
+	return got
+}
+
+func TestAuthQueryParamOnlyDeclaredName(t *testing.T) {
+	got := upstreamQueryForTest(t, authQuerySpec)
+	if got.Get("appid") != "secret" {
+		t.Errorf("expected the key in appid, got %v", got)
+	}
+	for _, name := range []string{"key", "api_key", "apikey"} {
+		if got.Has(name) {
+			t.Errorf("expected no %s query parameter, got %v", name, got)
+		}
+	}
+}
+
+func TestAuthQueryParamNotSetForHeaderKeys(t *testing.T) {
+	got := upstreamQueryForTest(t, strings.Replace(authQuerySpec, "in: query\n      name: appid", "in: header\n      name: X-API-Key", 1))
+	if len(got) != 0 {
+		t.Errorf("expected no auth query parameters for a header API key, got %v", got)
+	}
+}
+
+func TestAuthQueryParamOverride(t *testing.T) {
+	got := upstreamQueryForTest(t, strings.Replace(authQuerySpec, "openapi: 3.0.0\n", "openapi: 3.0.0\nx-mcp-auth-query-param: token\n", 1))
+	if got.Get("token") != "secret" || got.Has("appid") {
+		t.Errorf("expected the key only in token, got %v", got)
+	}
+}
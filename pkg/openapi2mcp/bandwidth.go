@@ -0,0 +1,284 @@
+package openapi2mcp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+// bandwidthMetaExtension is the root OpenAPI extension that adds the upstream bytes of a call
+// to its tool result metadata (`x-mcp-bandwidth-meta: true`).
+const bandwidthMetaExtension = "x-mcp-bandwidth-meta"
+
+// maxBandwidthSessions bounds the sessions tracked by a BandwidthTracker; the least recently
+// active session is dropped first.
+const maxBandwidthSessions = 10000
+
+// BandwidthStats are the bytes sent to and received from upstream APIs by one spec, or by
+// one session on one spec. Sizes count the request line, headers and body of every request,
+// and the headers and body read from every response.
+type BandwidthStats struct {
+	Endpoint      string    `json:"endpoint"`
+	Session       string    `json:"session,omitempty"`
+	Calls         int64     `json:"calls"`
+	BytesSent     int64     `json:"bytes_sent"`
+	BytesReceived int64     `json:"bytes_received"`
+	LastCall      time.Time `json:"last_call"`
+}
+
+func (s *BandwidthStats) add(sent, received int64, now time.Time) {
+	s.BytesSent += sent
+	s.BytesReceived += received
+	s.LastCall = now
+}
+
+// BandwidthTracker adds up upstream request and response sizes per spec endpoint and per MCP
+// session, so bandwidth costs can be attributed to the agent deployments behind the sessions.
+type BandwidthTracker struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	specs    map[string]*BandwidthStats
+	sessions map[string]*BandwidthStats // session + "/" + endpoint
+}
+
+// NewBandwidthTracker creates an empty tracker.
+func NewBandwidthTracker() *BandwidthTracker {
+	return &BandwidthTracker{now: time.Now, specs: make(map[string]*BandwidthStats), sessions: make(map[string]*BandwidthStats)}
+}
+
+var (
+	defaultBandwidthTracker     *BandwidthTracker
+	defaultBandwidthTrackerOnce sync.Once
+)
+
+// DefaultBandwidthTracker returns the process-wide tracker, shared by all specs.
+func DefaultBandwidthTracker() *BandwidthTracker {
+	defaultBandwidthTrackerOnce.Do(func() {
+		defaultBandwidthTracker = NewBandwidthTracker()
+	})
+	return defaultBandwidthTracker
+}
+
+// bandwidthTrackerFor returns the tracker of opts, or the default one.
+func bandwidthTrackerFor(opts *ToolGenOptions) *BandwidthTracker {
+	if opts != nil && opts.BandwidthTracker != nil {
+		return opts.BandwidthTracker
+	}
+	return DefaultBandwidthTracker()
+}
+
+// Record adds the sizes of one upstream call. session is empty for calls outside a session,
+// such as stdio; they only count towards the spec.
+func (t *BandwidthTracker) Record(endpoint, session string, sent, received int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	spec := t.specs[endpoint]
+	if spec == nil {
+		spec = &BandwidthStats{Endpoint: endpoint}
+		t.specs[endpoint] = spec
+	}
+	spec.Calls++
+	spec.add(sent, received, now)
+	if session == "" {
+		return
+	}
+	key := session + "/" + endpoint
+	s := t.sessions[key]
+	if s == nil {
+		if len(t.sessions) >= maxBandwidthSessions {
+			t.evictOldestSession()
+		}
+		s = &BandwidthStats{Endpoint: endpoint, Session: session}
+		t.sessions[key] = s
+	}
+	s.Calls++
+	s.add(sent, received, now)
+}
+
+// addReceived adds response bytes read after a call was recorded, such as a body read after
+// the response headers arrived.
+func (t *BandwidthTracker) addReceived(endpoint, session string, received int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	if spec := t.specs[endpoint]; spec != nil {
+		spec.add(0, received, now)
+	}
+	if s := t.sessions[session+"/"+endpoint]; session != "" && s != nil {
+		s.add(0, received, now)
+	}
+}
+
+func (t *BandwidthTracker) evictOldestSession() {
+	oldest := ""
+	for key, s := range t.sessions {
+		if oldest == "" || s.LastCall.Before(t.sessions[oldest].LastCall) {
+			oldest = key
+		}
+	}
+	delete(t.sessions, oldest)
+}
+
+// All returns the totals of every spec, sorted by endpoint.
+func (t *BandwidthTracker) All() []BandwidthStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	all := make([]BandwidthStats, 0, len(t.specs))
+	for _, s := range t.specs {
+		all = append(all, *s)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Endpoint < all[j].Endpoint })
+	return all
+}
+
+// Sessions returns the totals of every session per spec, most bytes first.
+func (t *BandwidthTracker) Sessions() []BandwidthStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	all := make([]BandwidthStats, 0, len(t.sessions))
+	for _, s := range t.sessions {
+		all = append(all, *s)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		ti, tj := all[i].BytesSent+all[i].BytesReceived, all[j].BytesSent+all[j].BytesReceived
+		if ti != tj {
+			return ti > tj
+		}
+		return all[i].Session+"/"+all[i].Endpoint < all[j].Session+"/"+all[j].Endpoint
+	})
+	return all
+}
+
+// specBandwidthMeta reports whether tool results carry the upstream bytes of their call:
+// on with opts.BandwidthMeta, then the x-mcp-bandwidth-meta extension, then
+// MCP_BANDWIDTH_META. Off by default.
+func specBandwidthMeta(doc *openapi3.T, opts *ToolGenOptions) bool {
+	if opts != nil && opts.BandwidthMeta {
+		return true
+	}
+	if doc != nil {
+		if v, ok := doc.Extensions[bandwidthMetaExtension].(bool); ok {
+			return v
+		}
+	}
+	enabled, _ := strconv.ParseBool(os.Getenv("MCP_BANDWIDTH_META"))
+	return enabled
+}
+
+// callBandwidth counts the upstream bytes of one tool call.
+type callBandwidth struct {
+	sent     atomic.Int64
+	received atomic.Int64
+}
+
+type callBandwidthKey struct{}
+
+func callBandwidthFromContext(ctx context.Context) *callBandwidth {
+	c, _ := ctx.Value(callBandwidthKey{}).(*callBandwidth)
+	return c
+}
+
+// bandwidthMetaHandler adds the upstream bytes of each call to its result metadata as
+// upstreamBytes: {"sent": n, "received": n}, when enabled. Calls that made no upstream
+// request, or that shared another call's coalesced request, get none.
+func bandwidthMetaHandler(enabled bool, next mcpserver.ToolHandlerFunc) mcpserver.ToolHandlerFunc {
+	if !enabled {
+		return next
+	}
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		counter := &callBandwidth{}
+		res, err := next(context.WithValue(ctx, callBandwidthKey{}, counter), req)
+		sent, received := counter.sent.Load(), counter.received.Load()
+		if res == nil || sent+received == 0 {
+			return res, err
+		}
+		if res.Meta == nil {
+			res.Meta = map[string]any{}
+		}
+		res.Meta["upstreamBytes"] = map[string]int64{"sent": sent, "received": received}
+		return res, err
+	}
+}
+
+// requestSize returns the size of an upstream request: its request line, headers and body.
+// Bodies of unknown length are not counted.
+func requestSize(req *http.Request) int64 {
+	n := int64(len(req.Method) + len(req.URL.RequestURI()) + len(" HTTP/1.1\r\n"))
+	n += headerSize(req.Header)
+	if req.ContentLength > 0 {
+		n += req.ContentLength
+	}
+	return n
+}
+
+func headerSize(h http.Header) int64 {
+	var n int64
+	for name, values := range h {
+		for _, v := range values {
+			n += int64(len(name) + len(": \r\n") + len(v))
+		}
+	}
+	return n
+}
+
+// countingBody counts the response body bytes read by the tool, and reports them to the
+// tracker when the body is closed.
+type countingBody struct {
+	io.ReadCloser
+	n       int64
+	once    sync.Once
+	onClose func(n int64)
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	b.once.Do(func() { b.onClose(b.n) })
+	return b.ReadCloser.Close()
+}
+
+// observeBandwidth records the request and response header sizes of an upstream call and
+// wraps the response body to count it as it is read.
+func (t *upstreamTransport) observeBandwidth(req *http.Request, resp *http.Response) {
+	if t.bandwidth == nil {
+		return
+	}
+	session := ""
+	if s := mcpserver.ClientSessionFromContext(req.Context()); s != nil {
+		session = s.SessionID()
+	}
+	call := callBandwidthFromContext(req.Context())
+	sent, received := requestSize(req), int64(0)
+	if resp != nil {
+		received = int64(len(resp.Proto)+len(resp.Status)+len(" \r\n\r\n")) + headerSize(resp.Header)
+	}
+	t.bandwidth.Record(t.endpoint, session, sent, received)
+	if call != nil {
+		call.sent.Add(sent)
+		call.received.Add(received)
+	}
+	if resp == nil || resp.Body == nil {
+		return
+	}
+	resp.Body = &countingBody{ReadCloser: resp.Body, onClose: func(n int64) {
+		t.bandwidth.addReceived(t.endpoint, session, n)
+		if call != nil {
+			call.received.Add(n)
+		}
+	}}
+}
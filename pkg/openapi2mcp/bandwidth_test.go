@@ -0,0 +1,45 @@
+package openapi2mcp
+
+import (
+	"testing"
+)
+
+func TestBandwidthTrackerPerSession(t *testing.T) {
+	tracker := NewBandwidthTracker()
+	tracker.Record("pets", "s1", 100, 1000)
+	tracker.Record("pets", "s2", 50, 200)
+	tracker.addReceived("pets", "s2", 5000)
+	tracker.Record("pets", "", 10, 10)
+
+	all := tracker.All()
+	if len(all) != 1 || all[0].Calls != 3 || all[0].BytesSent != 160 || all[0].BytesReceived != 6210 {
+		t.Fatalf("expected the spec totals of every call, got %+v", all)
+	}
+	sessions := tracker.Sessions()
+	if len(sessions) != 2 || sessions[0].Session != "s2" || sessions[0].BytesReceived != 5200 || sessions[1].Session != "s1" {
+		t.Errorf("expected two sessions, most bytes first, got %+v", sessions)
+	}
+}
+
+func TestBandwidthRecordedForUpstreamCalls(t *testing.T) {
+	body := `[{"id": 1, "name": "Rex"}]`
+	tracker := NewBandwidthTracker()
+	server := newTestServer(t, mockUpstreamSpec, &ToolGenOptions{BandwidthTracker: tracker, BandwidthMeta: true}, jsonUpstream(body))
+
+	res := callToolForTest(t, server, "listPets", map[string]any{})
+	all := tracker.All()
+	if len(all) != 1 || all[0].Calls != 1 || all[0].BytesSent == 0 || all[0].BytesReceived <= int64(len(body)) {
+		t.Fatalf("expected the call's request and response bytes, got %+v", all)
+	}
+	meta, ok := res.Meta["upstreamBytes"].(map[string]int64)
+	if !ok || meta["sent"] != all[0].BytesSent || meta["received"] != all[0].BytesReceived {
+		t.Errorf("expected the call's bytes in the result metadata, got %+v", res.Meta)
+	}
+}
+
+func TestBandwidthMetaOffByDefault(t *testing.T) {
+	server := newTestServer(t, mockUpstreamSpec, &ToolGenOptions{BandwidthTracker: NewBandwidthTracker()}, jsonUpstream(`[]`))
+	if res := callToolForTest(t, server, "listPets", map[string]any{}); res.Meta["upstreamBytes"] != nil {
+		t.Errorf("expected no upstream bytes in the metadata by default, got %+v", res.Meta)
+	}
+}
@@ -0,0 +1,209 @@
+// binary_body.go
+package openapi2mcp
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const (
+	// binaryBodyArgName carries a binary request body (image, PDF, ...) as base64 or a data: URL.
+	binaryBodyArgName = "body_base64"
+	// binaryContentTypeArgName selects the Content-Type of a binary request body.
+	binaryContentTypeArgName = "body_content_type"
+	// maxBodyBytesExtension is the root-level spec extension that limits decoded binary bodies.
+	maxBodyBytesExtension = "x-mcp-max-body-bytes"
+	// defaultMaxBinaryBodyBytes applies when neither opts, the spec nor MCP_MAX_BINARY_BODY_BYTES set a limit.
+	defaultMaxBinaryBodyBytes = 10 << 20
+)
+
+// isBinaryMediaType reports whether a request body media type is sent as raw bytes
+// rather than built from JSON, e.g. application/octet-stream or image/png.
+func isBinaryMediaType(mediaType string) bool {
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if idx := strings.IndexByte(mediaType, ';'); idx >= 0 {
+		mediaType = strings.TrimSpace(mediaType[:idx])
+	}
+	switch mediaType {
+	case "application/octet-stream", "application/pdf", "application/zip", "application/gzip", "*/*":
+		return true
+	}
+	for _, prefix := range []string{"image/", "audio/", "video/", "font/"} {
+		if strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// binaryBodyMediaTypes returns the binary media types a request body accepts, sorted.
+func binaryBodyMediaTypes(requestBody *openapi3.RequestBodyRef) []string {
+	if requestBody == nil || requestBody.Value == nil {
+		return nil
+	}
+	var types []string
+	for mediaType := range requestBody.Value.Content {
+		if isBinaryMediaType(mediaType) {
+			types = append(types, mediaType)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// specMaxBinaryBodyBytes returns the decoded size limit for binary request bodies: opts, then the
+// x-mcp-max-body-bytes extension, then MCP_MAX_BINARY_BODY_BYTES, then 10 MiB.
+func specMaxBinaryBodyBytes(doc *openapi3.T, opts *ToolGenOptions) int64 {
+	if opts != nil && opts.MaxBinaryBodyBytes > 0 {
+		return opts.MaxBinaryBodyBytes
+	}
+	if doc != nil {
+		switch v := doc.Extensions[maxBodyBytesExtension].(type) {
+		case float64:
+			if v > 0 {
+				return int64(v)
+			}
+		case string:
+			if n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil && n > 0 {
+				return n
+			}
+		}
+	}
+	if v := os.Getenv("MCP_MAX_BINARY_BODY_BYTES"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			return n
+		}
+		fmt.Fprintf(os.Stderr, "[WARN] Ignoring invalid MCP_MAX_BINARY_BODY_BYTES=%q\n", v)
+	}
+	return defaultMaxBinaryBodyBytes
+}
+
+// addBinaryBodyProperties advertises body_base64 (and body_content_type when there is a choice)
+// on operations that take a binary request body and have no JSON body argument.
+func addBinaryBodyProperties(inputSchema map[string]any, requestBody *openapi3.RequestBodyRef, mediaTypes []string, maxBytes int64) {
+	if len(mediaTypes) == 0 {
+		return
+	}
+	properties, ok := inputSchema["properties"].(map[string]any)
+	if !ok {
+		return
+	}
+	if _, hasJSONBody := properties["requestBody"]; hasJSONBody {
+		return
+	}
+	properties[binaryBodyArgName] = map[string]any{
+		"type":            "string",
+		"contentEncoding": "base64",
+		"description": fmt.Sprintf("The binary request body, base64-encoded or as a data: URL (at most %d bytes once decoded). Accepted types: %s",
+			maxBytes, strings.Join(mediaTypes, ", ")),
+	}
+	if len(mediaTypes) > 1 || strings.Contains(mediaTypes[0], "*") {
+		properties[binaryContentTypeArgName] = map[string]any{
+			"type":        "string",
+			"description": "Content-Type of body_base64, e.g. image/png. Defaults to the data: URL type or is detected from the content.",
+			"examples":    mediaTypes,
+		}
+	}
+	if requestBody.Value.Required {
+		required, _ := inputSchema["required"].([]string)
+		inputSchema["required"] = append(required, binaryBodyArgName)
+	}
+}
+
+// binaryRequestBody decodes the body_base64 argument and picks its Content-Type, which must be
+// one of the declared media types. It returns a nil body when the argument is absent.
+func binaryRequestBody(args map[string]any, mediaTypes []string, maxBytes int64) ([]byte, string, error) {
+	raw, ok := args[binaryBodyArgName].(string)
+	if !ok || raw == "" {
+		return nil, "", nil
+	}
+	contentType, _ := args[binaryContentTypeArgName].(string)
+	contentType = strings.TrimSpace(contentType)
+
+	// data:<type>;base64,<payload>
+	if strings.HasPrefix(raw, "data:") {
+		header, payload, found := strings.Cut(raw[len("data:"):], ",")
+		if !found || !strings.HasSuffix(header, ";base64") {
+			return nil, "", fmt.Errorf("%s: data: URLs must be base64-encoded", binaryBodyArgName)
+		}
+		if contentType == "" {
+			contentType = strings.TrimSuffix(header, ";base64")
+		}
+		raw = payload
+	}
+	// Reject oversized payloads before decoding them
+	// (with some slack for line breaks in wrapped base64)
+	if int64(base64.StdEncoding.DecodedLen(len(raw))) > maxBytes+maxBytes/16+3 {
+		return nil, "", fmt.Errorf("%s exceeds the %d byte limit", binaryBodyArgName, maxBytes)
+	}
+	body, err := decodeBase64(raw)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s is not valid base64: %v", binaryBodyArgName, err)
+	}
+	if int64(len(body)) > maxBytes {
+		return nil, "", fmt.Errorf("%s is %d bytes, over the %d byte limit", binaryBodyArgName, len(body), maxBytes)
+	}
+
+	if contentType == "" {
+		if len(mediaTypes) == 1 && !strings.Contains(mediaTypes[0], "*") {
+			contentType = mediaTypes[0]
+		} else {
+			contentType = http.DetectContentType(body)
+		}
+	}
+	if matched := matchBinaryMediaType(contentType, mediaTypes); matched != "" {
+		return body, contentType, nil
+	}
+	// Sniffing can be less specific than the spec; fall back to a lone declared type
+	if _, hasArg := args[binaryContentTypeArgName]; !hasArg && len(mediaTypes) == 1 {
+		if strings.Contains(mediaTypes[0], "*") {
+			return body, "application/octet-stream", nil
+		}
+		return body, mediaTypes[0], nil
+	}
+	return nil, "", fmt.Errorf("%s %q is not accepted; use one of: %s", binaryContentTypeArgName, contentType, strings.Join(mediaTypes, ", "))
+}
+
+// matchBinaryMediaType returns the declared media type that contentType satisfies,
+// honoring wildcards such as image/* and */*.
+func matchBinaryMediaType(contentType string, mediaTypes []string) string {
+	base, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	for _, declared := range mediaTypes {
+		declaredBase, _, err := mime.ParseMediaType(declared)
+		if err != nil {
+			continue
+		}
+		switch {
+		case declaredBase == base, declaredBase == "*/*":
+			return declared
+		case strings.HasSuffix(declaredBase, "/*") && strings.HasPrefix(base, strings.TrimSuffix(declaredBase, "*")):
+			return declared
+		}
+	}
+	return ""
+}
+
+// decodeBase64 accepts standard and URL-safe base64, with or without padding.
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\r' || r == ' ' || r == '\t' {
+			return -1
+		}
+		return r
+	}, s)
+	if strings.ContainsAny(s, "-_") {
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	}
+	return base64.RawStdEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,168 @@
+package openapi2mcp
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+const binaryBodySpec = `
+openapi: 3.0.0
+info:
+  title: Uploads API
+  version: 1.0.0
+paths:
+  /pets/{id}/photo:
+    post:
+      operationId: uploadPhoto
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      requestBody:
+        required: true
+        content:
+          image/png:
+            schema:
+              type: string
+              format: binary
+          image/jpeg:
+            schema:
+              type: string
+              format: binary
+      responses:
+        '200':
+          description: ok
+  /files:
+    put:
+      operationId: putFile
+      requestBody:
+        content:
+          application/octet-stream:
+            schema:
+              type: string
+              format: binary
+      responses:
+        '200':
+          description: ok
+`
+
+func TestBinaryBodyUpload(t *testing.T) {
+	var gotType string
+	var gotBody []byte
+	server := newTestServer(t, binaryBodySpec, &ToolGenOptions{MaxBinaryBodyBytes: 64}, func(w http.ResponseWriter, r *http.Request) {
+		gotType = r.Header.Get("Content-Type")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok": true}`))
+	})
+
+	tools := map[string]mcp.Tool{}
+	for _, tool := range server.ListTools() {
+		tools[tool.Name] = tool
+	}
+	photoSchema := string(tools["uploadPhoto"].RawInputSchema)
+	if !strings.Contains(photoSchema, `"body_base64"`) || !strings.Contains(photoSchema, `"body_content_type"`) {
+		t.Errorf("expected body_base64 and body_content_type for an image upload, got %s", photoSchema)
+	}
+	fileSchema := string(tools["putFile"].RawInputSchema)
+	if !strings.Contains(fileSchema, `"body_base64"`) || strings.Contains(fileSchema, `"body_content_type"`) {
+		t.Errorf("expected only body_base64 for a single octet-stream type, got %s", fileSchema)
+	}
+
+	png := []byte("\x89PNG\r\n\x1a\nfake image")
+	tests := []struct {
+		name     string
+		tool     string
+		args     map[string]any
+		wantErr  string
+		wantType string
+	}{
+		{
+			name:     "data URL",
+			tool:     "uploadPhoto",
+			args:     map[string]any{"id": 1, "body_base64": "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)},
+			wantType: "image/png",
+		},
+		{
+			name:     "sniffed type",
+			tool:     "uploadPhoto",
+			args:     map[string]any{"id": 1, "body_base64": base64.StdEncoding.EncodeToString(png)},
+			wantType: "image/png",
+		},
+		{
+			name:     "single declared type",
+			tool:     "putFile",
+			args:     map[string]any{"body_base64": base64.RawURLEncoding.EncodeToString(png)},
+			wantType: "application/octet-stream",
+		},
+		{
+			name:    "undeclared type",
+			tool:    "uploadPhoto",
+			args:    map[string]any{"id": 1, "body_base64": base64.StdEncoding.EncodeToString(png), "body_content_type": "application/pdf"},
+			wantErr: "not accepted",
+		},
+		{
+			name:    "too large",
+			tool:    "putFile",
+			args:    map[string]any{"body_base64": base64.StdEncoding.EncodeToString(make([]byte, 200))},
+			wantErr: "byte limit",
+		},
+		{
+			name:    "invalid base64",
+			tool:    "putFile",
+			args:    map[string]any{"body_base64": "not base64!"},
+			wantErr: "not valid base64",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotType, gotBody = "", nil
+			tt.args["__confirmed"] = true
+			res := callToolForTest(t, server, tt.tool, tt.args)
+			text := res.Content[0].(mcp.TextContent).Text
+			if tt.wantErr != "" {
+				if !res.IsError || !strings.Contains(text, tt.wantErr) {
+					t.Errorf("expected an error containing %q, got %q", tt.wantErr, text)
+				}
+				if gotBody != nil {
+					t.Errorf("expected no upstream call, got %d bytes", len(gotBody))
+				}
+				return
+			}
+			if res.IsError {
+				t.Fatalf("expected success, got %q", text)
+			}
+			if gotType != tt.wantType || string(gotBody) != string(png) {
+				t.Errorf("upstream got Content-Type %q and body %q", gotType, gotBody)
+			}
+		})
+	}
+}
+
+func TestSpecMaxBinaryBodyBytes(t *testing.T) {
+	doc, err := LoadOpenAPISpecFromString(binaryBodySpec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	if got := specMaxBinaryBodyBytes(doc, nil); got != defaultMaxBinaryBodyBytes {
+		t.Errorf("expected the default limit, got %d", got)
+	}
+	t.Setenv("MCP_MAX_BINARY_BODY_BYTES", "2048")
+	if got := specMaxBinaryBodyBytes(doc, nil); got != 2048 {
+		t.Errorf("expected the environment limit, got %d", got)
+	}
+	doc.Extensions = map[string]any{maxBodyBytesExtension: float64(1024)}
+	if got := specMaxBinaryBodyBytes(doc, nil); got != 1024 {
+		t.Errorf("expected the extension limit, got %d", got)
+	}
+	if got := specMaxBinaryBodyBytes(doc, &ToolGenOptions{MaxBinaryBodyBytes: 512}); got != 512 {
+		t.Errorf("expected the option limit, got %d", got)
+	}
+}
@@ -0,0 +1,298 @@
+// budget.go
+package openapi2mcp
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const (
+	// budgetExtension is the root-level spec extension that caps a spec's upstream spend, e.g.
+	// x-mcp-budget: {limit: 500, period: 24h, default-cost: 1}
+	budgetExtension = "x-mcp-budget"
+	// costExtension is the operation-level extension with the cost of one call, e.g. x-mcp-cost: 0.5
+	costExtension = "x-mcp-cost"
+	// DefaultBudgetPeriod is the period a budget limit applies to when none is set.
+	DefaultBudgetPeriod = 24 * time.Hour
+)
+
+// BudgetConfig caps the total cost of a spec's upstream calls. Costs are arbitrary units,
+// such as cents or API credits.
+type BudgetConfig struct {
+	Limit       float64       // cost that may be spent per Period; 0 tracks spend without a cap
+	Period      time.Duration // 0 uses DefaultBudgetPeriod
+	DefaultCost float64       // cost of operations without x-mcp-cost; 0 uses 1
+}
+
+func (c BudgetConfig) period() time.Duration {
+	if c.Period <= 0 {
+		return DefaultBudgetPeriod
+	}
+	return c.Period
+}
+
+// BudgetStats is the spend of one spec, shown in /analytics.
+type BudgetStats struct {
+	Endpoint    string    `json:"endpoint"`
+	Limit       float64   `json:"limit,omitempty"` // 0 when spend is tracked without a cap
+	Period      string    `json:"period"`
+	Remaining   float64   `json:"remaining,omitempty"`
+	PeriodSpend float64   `json:"period_spend"` // spent since PeriodStart
+	PeriodStart time.Time `json:"period_start"`
+	TotalSpend  float64   `json:"total_spend"`
+	Calls       int64     `json:"calls"`
+	Rejected    int64     `json:"rejected"` // calls refused because the budget was exhausted
+}
+
+// specBudget is a token bucket holding up to limit, refilled at limit per period.
+type specBudget struct {
+	limit       float64
+	period      time.Duration
+	tokens      float64
+	refilledAt  time.Time
+	periodSpend float64
+	periodStart time.Time
+	totalSpend  float64
+	calls       int64
+	rejected    int64
+}
+
+// BudgetTracker enforces spend caps per spec endpoint and tracks what each spec spent. It
+// outlives reloads, so a remounted spec keeps its remaining budget.
+type BudgetTracker struct {
+	mu    sync.Mutex
+	now   func() time.Time
+	specs map[string]*specBudget
+}
+
+// NewBudgetTracker creates an empty tracker.
+func NewBudgetTracker() *BudgetTracker {
+	return &BudgetTracker{now: time.Now, specs: make(map[string]*specBudget)}
+}
+
+var (
+	defaultBudgetTracker     *BudgetTracker
+	defaultBudgetTrackerOnce sync.Once
+)
+
+// DefaultBudgetTracker returns the process-wide tracker, shared by all specs.
+func DefaultBudgetTracker() *BudgetTracker {
+	defaultBudgetTrackerOnce.Do(func() {
+		defaultBudgetTracker = NewBudgetTracker()
+	})
+	return defaultBudgetTracker
+}
+
+// Configure sets the cap of a spec. A spec that is already tracked keeps its spend; its
+// remaining budget is clamped to the new limit.
+func (t *BudgetTracker) Configure(endpoint string, cfg BudgetConfig) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	period := cfg.period()
+	now := t.now()
+	b := t.specs[endpoint]
+	if b == nil {
+		t.specs[endpoint] = &specBudget{limit: cfg.Limit, period: period, tokens: cfg.Limit, refilledAt: now, periodStart: now}
+		return
+	}
+	b.refill(now)
+	if cfg.Limit > b.limit {
+		b.tokens += cfg.Limit - b.limit
+	}
+	b.limit, b.period = cfg.Limit, period
+	b.tokens = math.Min(b.tokens, b.limit)
+}
+
+// Charge takes cost from a spec's budget. When the budget cannot cover it, nothing is taken
+// and Charge returns false with how long until it can.
+func (t *BudgetTracker) Charge(endpoint string, cost float64) (bool, time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b := t.specs[endpoint]
+	if b == nil {
+		return true, 0
+	}
+	now := t.now()
+	b.refill(now)
+	if b.limit > 0 && cost > b.tokens {
+		b.rejected++
+		if cost > b.limit {
+			return false, -1
+		}
+		wait := time.Duration((cost - b.tokens) / b.limit * float64(b.period))
+		return false, (wait + time.Second - 1).Truncate(time.Second)
+	}
+	if b.limit > 0 {
+		b.tokens -= cost
+	}
+	b.periodSpend += cost
+	b.totalSpend += cost
+	b.calls++
+	return true, 0
+}
+
+// Refund gives back the cost of a call that never reached the upstream API.
+func (t *BudgetTracker) Refund(endpoint string, cost float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b := t.specs[endpoint]
+	if b == nil {
+		return
+	}
+	if b.limit > 0 {
+		b.tokens = math.Min(b.tokens+cost, b.limit)
+	}
+	b.periodSpend = math.Max(b.periodSpend-cost, 0)
+	b.totalSpend = math.Max(b.totalSpend-cost, 0)
+	b.calls--
+}
+
+// Stats returns the spend of a spec, and false if it is not tracked.
+func (t *BudgetTracker) Stats(endpoint string) (BudgetStats, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b := t.specs[endpoint]
+	if b == nil {
+		return BudgetStats{}, false
+	}
+	b.refill(t.now())
+	return b.stats(endpoint), true
+}
+
+// All returns the spend of every tracked spec, sorted by endpoint.
+func (t *BudgetTracker) All() []BudgetStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	all := make([]BudgetStats, 0, len(t.specs))
+	for endpoint, b := range t.specs {
+		b.refill(now)
+		all = append(all, b.stats(endpoint))
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Endpoint < all[j].Endpoint })
+	return all
+}
+
+// refill adds the budget accrued since the last refill and starts a new reporting period
+// once the current one is over.
+func (b *specBudget) refill(now time.Time) {
+	if elapsed := now.Sub(b.refilledAt); elapsed > 0 && b.limit > 0 {
+		b.tokens = math.Min(b.tokens+b.limit*float64(elapsed)/float64(b.period), b.limit)
+	}
+	b.refilledAt = now
+	if now.Sub(b.periodStart) >= b.period {
+		b.periodStart = b.periodStart.Add(now.Sub(b.periodStart) / b.period * b.period)
+		b.periodSpend = 0
+	}
+}
+
+func (b *specBudget) stats(endpoint string) BudgetStats {
+	return BudgetStats{
+		Endpoint:    endpoint,
+		Limit:       b.limit,
+		Period:      b.period.String(),
+		Remaining:   math.Round(b.tokens*1000) / 1000,
+		PeriodSpend: b.periodSpend,
+		PeriodStart: b.periodStart,
+		TotalSpend:  b.totalSpend,
+		Calls:       b.calls,
+		Rejected:    b.rejected,
+	}
+}
+
+// specBudgetConfig returns the budget of a spec: opts.Budget, then the x-mcp-budget extension, then
+// MCP_BUDGET_LIMIT, MCP_BUDGET_PERIOD and MCP_BUDGET_DEFAULT_COST. It returns nil when the
+// spec has no budget and no operation has an x-mcp-cost, so calls are not tracked.
+func specBudgetConfig(doc *openapi3.T, ops []OpenAPIOperation, opts *ToolGenOptions) *BudgetConfig {
+	if opts != nil && opts.Budget != nil {
+		return opts.Budget
+	}
+	if doc != nil {
+		if raw, ok := doc.Extensions[budgetExtension].(map[string]any); ok {
+			cfg := &BudgetConfig{
+				Limit:       math.Max(numberValue(raw["limit"]), 0),
+				DefaultCost: math.Max(numberValue(firstPresent(raw, "default-cost", "default_cost", "defaultCost")), 0),
+			}
+			if v, ok := raw["period"].(string); ok {
+				if d, err := time.ParseDuration(strings.TrimSpace(v)); err == nil && d > 0 {
+					cfg.Period = d
+				} else {
+					fmt.Fprintf(os.Stderr, "[WARN] Ignoring invalid %s period %q\n", budgetExtension, v)
+				}
+			}
+			return cfg
+		}
+	}
+	if v := os.Getenv("MCP_BUDGET_LIMIT"); v != "" {
+		cfg := &BudgetConfig{}
+		if limit, err := strconv.ParseFloat(v, 64); err == nil && limit >= 0 {
+			cfg.Limit = limit
+		} else {
+			fmt.Fprintf(os.Stderr, "[WARN] Ignoring invalid MCP_BUDGET_LIMIT=%q\n", v)
+		}
+		if v := os.Getenv("MCP_BUDGET_PERIOD"); v != "" {
+			if d, err := time.ParseDuration(v); err == nil && d > 0 {
+				cfg.Period = d
+			} else {
+				fmt.Fprintf(os.Stderr, "[WARN] Ignoring invalid MCP_BUDGET_PERIOD=%q\n", v)
+			}
+		}
+		if v := os.Getenv("MCP_BUDGET_DEFAULT_COST"); v != "" {
+			cfg.DefaultCost, _ = strconv.ParseFloat(v, 64)
+		}
+		return cfg
+	}
+	for _, op := range ops {
+		if _, ok := op.Extensions[costExtension]; ok {
+			return &BudgetConfig{}
+		}
+	}
+	return nil
+}
+
+// operationCost is the cost of one call of op: its x-mcp-cost, or the budget's default cost.
+func operationCost(op OpenAPIOperation, cfg *BudgetConfig) float64 {
+	if v, ok := op.Extensions[costExtension]; ok {
+		if cost := numberValue(v); cost >= 0 {
+			return cost
+		}
+	}
+	if cfg.DefaultCost > 0 {
+		return cfg.DefaultCost
+	}
+	return 1
+}
+
+// numberValue returns a number from a spec extension, which may be written as a string,
+// or -1 when v is not a number.
+func numberValue(v any) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int:
+		return float64(n)
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		if err == nil {
+			return f
+		}
+	}
+	return -1
+}
+
+// budgetExceededError describes a call refused by the spec's budget.
+func budgetExceededError(tool string, cost float64, stats BudgetStats, retryAfter time.Duration) string {
+	if retryAfter < 0 {
+		return fmt.Sprintf("Budget exceeded: %s costs %g, more than the whole budget of %g per %s", tool, cost, stats.Limit, stats.Period)
+	}
+	return fmt.Sprintf("Budget exceeded: %s costs %g but only %g of the %g per %s budget is left. Retry in %s.",
+		tool, cost, stats.Remaining, stats.Limit, stats.Period, retryAfter)
+}
@@ -0,0 +1,86 @@
+package openapi2mcp
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+func TestBudgetTrackerTokenBucket(t *testing.T) {
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	tracker := NewBudgetTracker()
+	tracker.now = func() time.Time { return now }
+	tracker.Configure("pets", BudgetConfig{Limit: 10, Period: time.Hour})
+
+	for i := 0; i < 4; i++ {
+		if ok, _ := tracker.Charge("pets", 2.5); !ok {
+			t.Fatalf("charge %d: expected the budget to cover it", i)
+		}
+	}
+	ok, retryAfter := tracker.Charge("pets", 2.5)
+	if ok || retryAfter != 15*time.Minute {
+		t.Fatalf("expected the exhausted budget to refuse with a 15m retry, got %v %v", ok, retryAfter)
+	}
+	if ok, retryAfter := tracker.Charge("pets", 20); ok || retryAfter >= 0 {
+		t.Errorf("expected a call costing more than the limit to never be allowed, got %v %v", ok, retryAfter)
+	}
+
+	// A quarter of the period refills a quarter of the limit
+	now = now.Add(15 * time.Minute)
+	if ok, _ := tracker.Charge("pets", 2.5); !ok {
+		t.Error("expected the refilled budget to cover the call")
+	}
+	tracker.Refund("pets", 2.5)
+
+	stats, _ := tracker.Stats("pets")
+	if stats.TotalSpend != 10 || stats.PeriodSpend != 10 || stats.Calls != 4 || stats.Rejected != 2 || stats.Remaining != 2.5 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	now = now.Add(time.Hour)
+	stats, _ = tracker.Stats("pets")
+	if stats.PeriodSpend != 0 || stats.TotalSpend != 10 || stats.Remaining != 10 || !stats.PeriodStart.Equal(now.Add(-15*time.Minute)) {
+		t.Errorf("expected a new period with a full budget, got %+v", stats)
+	}
+
+	if ok, _ := tracker.Charge("untracked", 100); !ok {
+		t.Error("expected specs without a budget to be allowed")
+	}
+}
+
+func TestBudgetEnforcedOnToolCalls(t *testing.T) {
+	calls := 0
+	spec := strings.Replace(mockUpstreamSpec, "      operationId: getPet\n", "      operationId: getPet\n      x-mcp-cost: 3\n", 1)
+	tracker := NewBudgetTracker()
+	opts := &ToolGenOptions{Budget: &BudgetConfig{Limit: 5, Period: time.Hour}, BudgetTracker: tracker}
+	server := newTestServer(t, spec, opts, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1,"name":"Rex"}`))
+	})
+
+	if res := callToolForTest(t, server, "getPet", map[string]any{"id": 1}); res.IsError {
+		t.Fatalf("expected the first call to be within budget, got %+v", res)
+	}
+	if res := callToolForTest(t, server, "listPets", nil); res.IsError {
+		t.Fatalf("expected a call at the default cost to be within budget, got %+v", res)
+	}
+	res := callToolForTest(t, server, "getPet", map[string]any{"id": 1})
+	if !res.IsError {
+		t.Fatal("expected the call to be refused once the budget is spent")
+	}
+	if text := res.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "Budget exceeded: getPet costs 3 but only 1 of the 5 per 1h0m0s budget is left") {
+		t.Errorf("unexpected error %s", text)
+	}
+	if calls != 2 {
+		t.Errorf("expected the refused call not to reach the upstream API, got %d calls", calls)
+	}
+
+	all := tracker.All()
+	if len(all) != 1 || all[0].TotalSpend != 4 || all[0].Rejected != 1 {
+		t.Errorf("unexpected spend %+v", all)
+	}
+}
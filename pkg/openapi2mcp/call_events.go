@@ -0,0 +1,174 @@
+package openapi2mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+	"github.com/ubermorgenland/openapi-mcp/pkg/metrics"
+)
+
+// CallEvent describes a finished tool call, for usage analytics and audit trails.
+type CallEvent struct {
+	Time       time.Time `json:"time"` // when the call was accepted
+	Endpoint   string    `json:"endpoint"`
+	Tool       string    `json:"tool"`
+	SessionID  string    `json:"session_id,omitempty"`
+	ArgNames   []string  `json:"arg_names,omitempty"` // argument names only; values may hold secrets
+	ArgsHash   string    `json:"args_hash,omitempty"` // SHA-256 of the arguments as JSON with sorted keys
+	Status     string    `json:"status"`              // JournalCompleted or JournalFailed
+	Error      string    `json:"error,omitempty"`
+	DurationMs float64   `json:"duration_ms"`
+
+	// UpstreamStatus is the HTTP status of the call's last upstream response; 0 when it
+	// made no upstream request, or shared the request of a coalesced call.
+	UpstreamStatus int `json:"upstream_status,omitempty"`
+}
+
+// CallEventSink receives an event for each finished tool call. RecordCall runs on the
+// call's goroutine, so sinks must not block: exporters buffer events and send them later.
+type CallEventSink interface {
+	RecordCall(event CallEvent)
+}
+
+var (
+	defaultCallEventSinkMu sync.RWMutex
+	defaultCallEventSink   CallEventSink
+)
+
+// SetDefaultCallEventSink sets the sink used by tools generated without ToolGenOptions.CallEvents.
+// Nil turns call events off, which is the default.
+func SetDefaultCallEventSink(s CallEventSink) {
+	defaultCallEventSinkMu.Lock()
+	defer defaultCallEventSinkMu.Unlock()
+	defaultCallEventSink = s
+}
+
+// DefaultCallEventSink returns the process-wide call event sink, or nil when events are off.
+func DefaultCallEventSink() CallEventSink {
+	defaultCallEventSinkMu.RLock()
+	defer defaultCallEventSinkMu.RUnlock()
+	return defaultCallEventSink
+}
+
+// CallEventSinks returns a sink passing each event to every one of sinks, skipping nil ones.
+func CallEventSinks(sinks ...CallEventSink) CallEventSink {
+	var all multiCallEventSink
+	for _, s := range sinks {
+		if s != nil {
+			all = append(all, s)
+		}
+	}
+	switch len(all) {
+	case 0:
+		return nil
+	case 1:
+		return all[0]
+	}
+	return all
+}
+
+type multiCallEventSink []CallEventSink
+
+func (m multiCallEventSink) RecordCall(event CallEvent) {
+	for _, s := range m {
+		s.RecordCall(event)
+	}
+}
+
+// callUpstreamStatus holds the status of the last upstream response of one tool call.
+type callUpstreamStatus struct {
+	status atomic.Int32
+}
+
+type callUpstreamStatusKey struct{}
+
+// recordUpstreamStatus records the status of an upstream response for the call event of
+// the tool call making the request, if any.
+func recordUpstreamStatus(ctx context.Context, status int) {
+	if c, _ := ctx.Value(callUpstreamStatusKey{}).(*callUpstreamStatus); c != nil && status != 0 {
+		c.status.Store(int32(status))
+	}
+}
+
+// argsHash returns the SHA-256 of the arguments as JSON; map keys are sorted, so equal
+// arguments have equal hashes.
+func argsHash(args map[string]any) string {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// callEventSinkFor returns the sink tools of a spec report their calls to. Calls are always
+// observed by the tool call duration metric as well.
+func callEventSinkFor(opts *ToolGenOptions) CallEventSink {
+	sink := DefaultCallEventSink()
+	if opts != nil && opts.CallEvents != nil {
+		sink = opts.CallEvents
+	}
+	return CallEventSinks(sink, metricsCallEventSink{})
+}
+
+// metricsCallEventSink observes calls in metrics.ToolCallDuration.
+type metricsCallEventSink struct{}
+
+func (metricsCallEventSink) RecordCall(event CallEvent) {
+	metrics.ObserveToolCall(event.Endpoint, event.Tool, event.Status, time.Duration(event.DurationMs*float64(time.Millisecond)))
+}
+
+// eventHandler reports each call of a tool to the sink once it returns, with its outcome
+// and duration. Calls that panic are reported as failed.
+func eventHandler(sink CallEventSink, endpoint, tool string, next mcpserver.ToolHandlerFunc) mcpserver.ToolHandlerFunc {
+	if sink == nil {
+		return next
+	}
+	return func(ctx context.Context, req mcp.CallToolRequest) (res *mcp.CallToolResult, err error) {
+		event := CallEvent{Time: time.Now().UTC(), Endpoint: endpoint, Tool: tool}
+		if session := mcpserver.ClientSessionFromContext(ctx); session != nil {
+			event.SessionID = session.SessionID()
+		}
+		for name := range req.GetArguments() {
+			event.ArgNames = append(event.ArgNames, name)
+		}
+		sort.Strings(event.ArgNames)
+		event.ArgsHash = argsHash(req.GetArguments())
+		upstream := &callUpstreamStatus{}
+		ctx = context.WithValue(ctx, callUpstreamStatusKey{}, upstream)
+
+		defer func() {
+			event.Status = JournalCompleted
+			r := recover()
+			switch {
+			case r != nil:
+				event.Status, event.Error = JournalFailed, fmt.Sprintf("panic: %v", r)
+			case err != nil:
+				event.Status, event.Error = JournalFailed, err.Error()
+			case res != nil && res.IsError:
+				event.Status = JournalFailed
+				event.Error, _ = firstText(res)
+			}
+			const maxEventError = 500
+			if len(event.Error) > maxEventError {
+				event.Error = event.Error[:maxEventError]
+			}
+			event.DurationMs = float64(time.Since(event.Time).Microseconds()) / 1000
+			event.UpstreamStatus = int(upstream.status.Load())
+			sink.RecordCall(event)
+			if r != nil {
+				panic(r)
+			}
+		}()
+		return next(ctx, req)
+	}
+}
@@ -0,0 +1,66 @@
+package openapi2mcp
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type memoryEventSink struct {
+	mu     sync.Mutex
+	events []CallEvent
+}
+
+func (s *memoryEventSink) RecordCall(event CallEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+}
+
+func TestCallEventsReportOutcomes(t *testing.T) {
+	sink := &memoryEventSink{}
+	server := newTestServer(t, mockUpstreamSpec, &ToolGenOptions{CallEvents: sink}, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/404") {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	})
+
+	callToolForTest(t, server, "listPets", map[string]any{"limit": 2, "after": "x"})
+	callToolForTest(t, server, "getPet", map[string]any{"id": 404})
+	callToolForTest(t, server, "describe", map[string]any{})
+
+	if len(sink.events) != 2 {
+		t.Fatalf("expected events for the two API tool calls, got %+v", sink.events)
+	}
+	first, second := sink.events[0], sink.events[1]
+	if first.Endpoint != "pets" || first.Tool != "listPets" || strings.Join(first.ArgNames, ",") != "after,limit" {
+		t.Errorf("unexpected event: %+v", first)
+	}
+	if first.Status != JournalCompleted || first.Error != "" || first.Time.IsZero() || first.DurationMs <= 0 {
+		t.Errorf("expected the successful call to be completed with a duration, got %+v", first)
+	}
+	if first.UpstreamStatus != http.StatusOK || len(first.ArgsHash) != 64 {
+		t.Errorf("expected the upstream status and arguments hash, got %+v", first)
+	}
+	if second.Status != JournalFailed || second.Error == "" || second.UpstreamStatus != http.StatusNotFound {
+		t.Errorf("expected the failed call to be reported with its error and upstream status, got %+v", second)
+	}
+	if first.ArgsHash != argsHash(map[string]any{"after": "x", "limit": 2}) || first.ArgsHash == second.ArgsHash {
+		t.Errorf("expected the hash of the arguments, got %q", first.ArgsHash)
+	}
+}
+
+func TestCallEventSinks(t *testing.T) {
+	a, b := &memoryEventSink{}, &memoryEventSink{}
+	if CallEventSinks(nil, nil) != nil || CallEventSinks(nil, a) != CallEventSink(a) {
+		t.Fatal("expected nil sinks to be skipped")
+	}
+	CallEventSinks(a, nil, b).RecordCall(CallEvent{Tool: "listPets"})
+	if len(a.events) != 1 || len(b.events) != 1 {
+		t.Errorf("expected every sink to get the event, got %d and %d", len(a.events), len(b.events))
+	}
+}
@@ -0,0 +1,267 @@
+package openapi2mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/google/cel-go/cel"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// callPolicyExtension is the root-level spec extension setting the authorization policy of
+// its tool calls: {cel: "<expression>"} or {opa: "<decision URL>"}.
+const callPolicyExtension = "x-mcp-policy"
+
+// DefaultOPATimeout is how long an OPA decision may take before the call is denied.
+const DefaultOPATimeout = 5 * time.Second
+
+// CallPolicyInput is what an authorization policy decides a tool call on.
+type CallPolicyInput struct {
+	Session   string         `json:"session"`  // MCP session ID; empty for stateless calls
+	Endpoint  string         `json:"endpoint"` // spec endpoint, e.g. "weather"
+	Tool      string         `json:"tool"`
+	Method    string         `json:"method"` // HTTP method of the operation
+	Path      string         `json:"path"`   // path template of the operation, e.g. /pets/{id}
+	Arguments map[string]any `json:"arguments"`
+	Claims    map[string]any `json:"claims"` // verified claims of the session's bearer token; empty without one
+}
+
+// CallPolicyDecision is the outcome of an authorization policy for a tool call.
+type CallPolicyDecision struct {
+	Allow     bool           `json:"allow"`
+	Reason    string         `json:"reason,omitempty"`    // shown to the agent when the call is denied
+	Arguments map[string]any `json:"arguments,omitempty"` // set on the arguments of an allowed call; null removes one
+}
+
+// CallPolicy decides whether a tool call may go ahead, and may rewrite its arguments, e.g. to
+// scope a query to the caller's tenant. Calls are denied when Decide returns an error.
+type CallPolicy interface {
+	Decide(ctx context.Context, in CallPolicyInput) (CallPolicyDecision, error)
+}
+
+// celPolicy evaluates a CEL expression over the variables session, endpoint, tool, method,
+// path, args and claims.
+type celPolicy struct {
+	program cel.Program
+}
+
+// NewCELPolicy compiles a CEL expression into a call policy. The expression sees the
+// variables session, endpoint, tool, method, path, args and claims, and returns a bool
+// (allow or deny) or a map with the fields of CallPolicyDecision, e.g.
+//
+//	method == "GET" || "admin" in claims.roles
+//	{"allow": true, "arguments": {"tenant": claims.tenant}}
+func NewCELPolicy(expr string) (CallPolicy, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("session", cel.StringType),
+		cel.Variable("endpoint", cel.StringType),
+		cel.Variable("tool", cel.StringType),
+		cel.Variable("method", cel.StringType),
+		cel.Variable("path", cel.StringType),
+		cel.Variable("args", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("claims", cel.MapType(cel.StringType, cel.DynType)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("invalid CEL policy: %v", issues.Err())
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CEL policy: %v", err)
+	}
+	return &celPolicy{program: program}, nil
+}
+
+func (p *celPolicy) Decide(ctx context.Context, in CallPolicyInput) (CallPolicyDecision, error) {
+	args, claims := in.Arguments, in.Claims
+	if args == nil {
+		args = map[string]any{}
+	}
+	if claims == nil {
+		claims = map[string]any{}
+	}
+	out, _, err := p.program.ContextEval(ctx, map[string]any{
+		"session":  in.Session,
+		"endpoint": in.Endpoint,
+		"tool":     in.Tool,
+		"method":   in.Method,
+		"path":     in.Path,
+		"args":     args,
+		"claims":   claims,
+	})
+	if err != nil {
+		return CallPolicyDecision{}, fmt.Errorf("CEL policy failed: %v", err)
+	}
+	// Round-trip through a protobuf value to turn CEL values into plain Go values
+	native, err := out.ConvertToNative(reflect.TypeOf(&structpb.Value{}))
+	if err != nil {
+		return CallPolicyDecision{}, fmt.Errorf("CEL policy returned %s: expected a bool or a map", out.Type().TypeName())
+	}
+	return decodeDecision(native.(*structpb.Value).AsInterface())
+}
+
+// opaPolicy asks an Open Policy Agent decision endpoint, e.g.
+// http://opa:8181/v1/data/mcp/authz, posting the call as {"input": ...}.
+type opaPolicy struct {
+	url    string
+	client *http.Client
+}
+
+// NewOPAPolicy returns a call policy that asks the OPA decision at url. The decision's result
+// is a bool or an object with the fields of CallPolicyDecision. A nil client uses one with
+// DefaultOPATimeout.
+func NewOPAPolicy(url string, client *http.Client) CallPolicy {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultOPATimeout}
+	}
+	return &opaPolicy{url: url, client: client}
+}
+
+func (p *opaPolicy) Decide(ctx context.Context, in CallPolicyInput) (CallPolicyDecision, error) {
+	body, err := json.Marshal(map[string]any{"input": in})
+	if err != nil {
+		return CallPolicyDecision{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return CallPolicyDecision{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return CallPolicyDecision{}, fmt.Errorf("OPA request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		return CallPolicyDecision{}, fmt.Errorf("OPA returned %s", resp.Status)
+	}
+	var result struct {
+		Result any `json:"result"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return CallPolicyDecision{}, fmt.Errorf("invalid OPA response: %v", err)
+	}
+	if result.Result == nil {
+		// OPA omits the result of undefined decisions
+		return CallPolicyDecision{Reason: "the policy has no decision for this call"}, nil
+	}
+	return decodeDecision(result.Result)
+}
+
+// decodeDecision reads a policy result: a bool, or an object with allow, reason and arguments.
+func decodeDecision(v any) (CallPolicyDecision, error) {
+	switch v := v.(type) {
+	case bool:
+		return CallPolicyDecision{Allow: v}, nil
+	case map[string]any:
+		var d CallPolicyDecision
+		allow, ok := v["allow"].(bool)
+		if !ok {
+			return d, fmt.Errorf("policy decision has no boolean allow field")
+		}
+		d.Allow = allow
+		d.Reason, _ = v["reason"].(string)
+		if args, ok := v["arguments"]; ok {
+			if d.Arguments, ok = args.(map[string]any); !ok {
+				return d, fmt.Errorf("policy decision arguments must be an object")
+			}
+		}
+		return d, nil
+	}
+	return CallPolicyDecision{}, fmt.Errorf("policy returned %T: expected a bool or an object", v)
+}
+
+// specCallPolicy returns the authorization policy of a spec's tool calls: opts.CallPolicy,
+// then the x-mcp-policy extension, then MCP_POLICY_CEL or MCP_POLICY_OPA_URL. It returns nil
+// when there is none. An invalid policy denies every call rather than allowing them all.
+func specCallPolicy(doc *openapi3.T, opts *ToolGenOptions) CallPolicy {
+	if opts != nil && opts.CallPolicy != nil {
+		return opts.CallPolicy
+	}
+	var celExpr, opaURL, source string
+	if doc != nil && doc.Extensions[callPolicyExtension] != nil {
+		ext, ok := doc.Extensions[callPolicyExtension].(map[string]any)
+		if !ok {
+			return denyAllPolicy(fmt.Errorf("%s must be an object with a cel or opa field", callPolicyExtension))
+		}
+		celExpr, _ = ext["cel"].(string)
+		opaURL, _ = ext["opa"].(string)
+		source = callPolicyExtension
+	} else {
+		celExpr, opaURL = os.Getenv("MCP_POLICY_CEL"), os.Getenv("MCP_POLICY_OPA_URL")
+		source = "MCP_POLICY_CEL"
+	}
+	celExpr, opaURL = strings.TrimSpace(celExpr), strings.TrimSpace(opaURL)
+	switch {
+	case celExpr != "" && opaURL != "":
+		return denyAllPolicy(fmt.Errorf("both a CEL and an OPA policy are set"))
+	case celExpr != "":
+		policy, err := NewCELPolicy(celExpr)
+		if err != nil {
+			return denyAllPolicy(fmt.Errorf("%s: %v", source, err))
+		}
+		return policy
+	case opaURL != "":
+		return NewOPAPolicy(opaURL, nil)
+	}
+	return nil
+}
+
+// denyAllPolicy denies every call because of a configuration error, which it logs.
+func denyAllPolicy(err error) CallPolicy {
+	fmt.Fprintf(os.Stderr, "[WARN] Denying all tool calls: %v\n", err)
+	return policyFunc(func(context.Context, CallPolicyInput) (CallPolicyDecision, error) {
+		return CallPolicyDecision{}, err
+	})
+}
+
+type policyFunc func(ctx context.Context, in CallPolicyInput) (CallPolicyDecision, error)
+
+func (f policyFunc) Decide(ctx context.Context, in CallPolicyInput) (CallPolicyDecision, error) {
+	return f(ctx, in)
+}
+
+// callPolicyInput describes a tool call for the policy; claims are only included when the
+// session's bearer token verifies.
+func callPolicyInput(ctx context.Context, endpoint, tool string, op OpenAPIOperation, args map[string]any) CallPolicyInput {
+	in := CallPolicyInput{
+		Endpoint:  endpoint,
+		Tool:      tool,
+		Method:    strings.ToUpper(op.Method),
+		Path:      op.Path,
+		Arguments: args,
+		Claims:    map[string]any{},
+	}
+	if session := mcpserver.ClientSessionFromContext(ctx); session != nil {
+		in.Session = session.SessionID()
+	}
+	if claims, err := sessionJWTClaims(ctx); err == nil {
+		in.Claims = claims
+	}
+	return in
+}
+
+// applyPolicyArguments sets the arguments of a policy decision on args; null values remove them.
+func applyPolicyArguments(args, set map[string]any) {
+	for name, value := range set {
+		if value == nil {
+			delete(args, name)
+		} else {
+			args[name] = value
+		}
+	}
+}
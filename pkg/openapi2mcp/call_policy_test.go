@@ -0,0 +1,119 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+const policySpec = `
+openapi: 3.0.0
+info: {title: Orders, version: "1.0"}
+paths:
+  /orders:
+    get:
+      operationId: listOrders
+      parameters:
+        - {name: tenant, in: query, schema: {type: string}}
+      responses:
+        '200': {description: ok}
+    delete:
+      operationId: deleteOrders
+      responses:
+        '204': {description: deleted}
+`
+
+// policyUpstream records the query of the last request that reached the upstream API
+func policyUpstream(query *string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*query = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}
+}
+
+func callWithClaims(t *testing.T, server *mcpserver.MCPServer, claims, tool string, args map[string]any) mcp.CallToolResult {
+	t.Helper()
+	call, _ := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]any{"name": tool, "arguments": args},
+	})
+	return server.HandleMessage(scopedContext(claims), call).(mcp.JSONRPCResponse).Result.(mcp.CallToolResult)
+}
+
+func TestCELCallPolicy(t *testing.T) {
+	policy, err := NewCELPolicy(`method == "GET" ? {"allow": true, "arguments": {"tenant": claims.tenant}} : {"allow": "admin" in claims.roles, "reason": "only admins may delete orders"}`)
+	if err != nil {
+		t.Fatalf("failed to compile policy: %v", err)
+	}
+	t.Setenv("MCP_JWT_SECRET", "s3cret")
+	query := new(string)
+	server := newTestServer(t, policySpec, &ToolGenOptions{CallPolicy: policy}, policyUpstream(query))
+
+	res := callWithClaims(t, server, `{"tenant":"acme","roles":["viewer"]}`, "listOrders", map[string]any{"tenant": "other"})
+	if res.IsError || *query != "tenant=acme" {
+		t.Errorf("expected the policy to scope the call to the caller's tenant, got query %q, result %+v", *query, res)
+	}
+
+	res = callWithClaims(t, server, `{"tenant":"acme","roles":["viewer"]}`, "deleteOrders", nil)
+	if !res.IsError || !strings.Contains(previewText(res), "only admins may delete orders") {
+		t.Errorf("expected the delete to be denied with the policy's reason, got %+v", res)
+	}
+	res = callWithClaims(t, server, `{"tenant":"acme","roles":["admin"]}`, "deleteOrders", nil)
+	if res.IsError {
+		t.Errorf("expected an admin to delete orders, got %s", previewText(res))
+	}
+
+	if _, err := NewCELPolicy(`method ==`); err == nil {
+		t.Error("expected an invalid expression to be rejected")
+	}
+}
+
+func TestOPACallPolicy(t *testing.T) {
+	var input CallPolicyInput
+	opa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input CallPolicyInput `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		input = body.Input
+		w.Write([]byte(`{"result": {"allow": false, "reason": "outside business hours"}}`))
+	}))
+	defer opa.Close()
+	t.Setenv("MCP_POLICY_OPA_URL", opa.URL+"/v1/data/mcp/authz")
+
+	t.Setenv("MCP_JWT_SECRET", "s3cret")
+	query := new(string)
+	server := newTestServer(t, policySpec, nil, policyUpstream(query))
+	res := callWithClaims(t, server, `{"sub":"alice"}`, "listOrders", map[string]any{"tenant": "acme"})
+	if !res.IsError || !strings.Contains(previewText(res), "outside business hours") || *query != "" {
+		t.Errorf("expected OPA to deny the call before it reaches the upstream, got %+v", res)
+	}
+	if input.Tool != "listOrders" || input.Method != "GET" || input.Path != "/orders" || input.Claims["sub"] != "alice" || input.Arguments["tenant"] != "acme" {
+		t.Errorf("unexpected policy input %+v", input)
+	}
+}
+
+func TestCallPolicyFailsClosed(t *testing.T) {
+	t.Setenv("MCP_POLICY_CEL", `args.missing == "x"`)
+	t.Setenv("MCP_JWT_SECRET", "s3cret")
+	query := new(string)
+	server := newTestServer(t, policySpec, nil, policyUpstream(query))
+	res := callToolForTest(t, server, "listOrders", map[string]any{})
+	if !res.IsError || *query != "" {
+		t.Errorf("expected a failing policy to deny the call, got %+v", res)
+	}
+
+	decision, err := denyAllPolicy(context.Canceled).Decide(context.Background(), CallPolicyInput{})
+	if err == nil || decision.Allow {
+		t.Errorf("expected an invalid policy to deny every call, got %+v", decision)
+	}
+}
@@ -0,0 +1,335 @@
+// callbacks.go
+package openapi2mcp
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/apierrors"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+const (
+	// CallbackPathPrefix is where a CallbackReceiver expects to be mounted.
+	CallbackPathPrefix = "/callbacks/"
+	// CallbackNotification is the MCP notification method used to deliver callback payloads.
+	CallbackNotification = "notifications/callback"
+	// maxCallbackBody caps the size of a callback payload.
+	maxCallbackBody = 1 << 20
+	// callbackTokenTTL is how long the receiver URL of a call accepts callbacks.
+	callbackTokenTTL = 24 * time.Hour
+	// callbackPruneInterval is how often expired callback tokens are dropped.
+	callbackPruneInterval = time.Minute
+)
+
+// CallbackInfo documents one OpenAPI callback of an operation.
+type CallbackInfo struct {
+	Name          string `json:"name"`
+	Expression    string `json:"expression"` // runtime expression for the callback URL, e.g. {$request.body#/callbackUrl}
+	Method        string `json:"method"`
+	Summary       string `json:"summary,omitempty"`
+	PayloadSchema any    `json:"payloadSchema,omitempty"`
+	ReceiverURL   string `json:"receiverUrl,omitempty"` // set when this server receives the callback itself; each call gets its own URL under it
+}
+
+// operationCallbacks lists the callbacks declared by an operation, sorted by name.
+func operationCallbacks(op OpenAPIOperation) []CallbackInfo {
+	var infos []CallbackInfo
+	for name, ref := range op.Callbacks {
+		if ref == nil || ref.Value == nil {
+			continue
+		}
+		for expression, item := range ref.Value.Map() {
+			if item == nil {
+				continue
+			}
+			for method, cbOp := range item.Operations() {
+				info := CallbackInfo{
+					Name:       name,
+					Expression: expression,
+					Method:     strings.ToUpper(method),
+					Summary:    cbOp.Summary,
+				}
+				if cbOp.RequestBody != nil && cbOp.RequestBody.Value != nil {
+					if mt := cbOp.RequestBody.Value.Content.Get("application/json"); mt != nil {
+						info.PayloadSchema = callbackPayloadSchemaRef(mt.Schema)
+					}
+				}
+				infos = append(infos, info)
+			}
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Name != infos[j].Name {
+			return infos[i].Name < infos[j].Name
+		}
+		return infos[i].Method < infos[j].Method
+	})
+	return infos
+}
+
+// callbackDescription is appended to the tool description of operations with callbacks.
+func callbackDescription(callbacks []CallbackInfo, receiverEnabled bool) string {
+	if len(callbacks) == 0 {
+		return ""
+	}
+	var names []string
+	for _, cb := range callbacks {
+		names = append(names, fmt.Sprintf("%s (%s %s)", cb.Name, cb.Method, cb.Expression))
+	}
+	desc := "\n\nCALLBACKS: the API calls back asynchronously: " + strings.Join(names, ", ") + ". See the describe tool for payload schemas."
+	if receiverEnabled {
+		desc += " Leave the callback URL argument empty to receive payloads as " + CallbackNotification + " notifications."
+	}
+	return desc
+}
+
+// CallbackReceiver accepts callback requests from upstream APIs and forwards their
+// payloads as notifications/callback notifications to the MCP session whose tool call
+// asked for them. Each call gets its own receiver URL ending in an unguessable token,
+// so only the upstream API it was sent to can deliver callbacks to that session.
+type CallbackReceiver struct {
+	baseURL  string
+	mu       sync.RWMutex
+	routes   map[string]*mcpserver.MCPServer // {endpoint}/{tool}/{callback} -> server to notify
+	tokens   map[string]callbackTarget       // token -> the call it was issued for
+	prunedAt time.Time
+}
+
+// callbackTarget is the call a callback token was issued for.
+type callbackTarget struct {
+	route     string // {endpoint}/{tool}/{callback}
+	sessionID string // session notified of the callbacks
+	expiresAt time.Time
+}
+
+// NewCallbackReceiver creates a receiver reachable by upstream APIs at baseURL
+// (the public URL of this server, without the /callbacks/ prefix).
+func NewCallbackReceiver(baseURL string) *CallbackReceiver {
+	return &CallbackReceiver{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		routes:  make(map[string]*mcpserver.MCPServer),
+		tokens:  make(map[string]callbackTarget),
+	}
+}
+
+var (
+	defaultCallbackReceiver     *CallbackReceiver
+	defaultCallbackReceiverOnce sync.Once
+)
+
+// DefaultCallbackReceiver returns the process-wide receiver configured by MCP_CALLBACK_BASE_URL,
+// or nil if callback receivers are disabled.
+func DefaultCallbackReceiver() *CallbackReceiver {
+	defaultCallbackReceiverOnce.Do(func() {
+		if baseURL := os.Getenv("MCP_CALLBACK_BASE_URL"); baseURL != "" {
+			defaultCallbackReceiver = NewCallbackReceiver(baseURL)
+		}
+	})
+	return defaultCallbackReceiver
+}
+
+// URL returns the receiver URL prefix for a callback of a tool; the URL of each call is
+// the prefix followed by the token of the call.
+func (r *CallbackReceiver) URL(endpoint, tool, callback string) string {
+	return r.baseURL + CallbackPathPrefix + callbackRoute(endpoint, tool, callback)
+}
+
+func callbackRoute(endpoint, tool, callback string) string {
+	return endpoint + "/" + tool + "/" + callback
+}
+
+// register routes callbacks for a tool to server.
+func (r *CallbackReceiver) register(endpoint, tool, callback string, server *mcpserver.MCPServer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes[callbackRoute(endpoint, tool, callback)] = server
+}
+
+// fillCallbackURLs points the callback URL arguments a call left empty at receiver URLs
+// of their own, delivering the callbacks to sessionID only. The URLs accept callbacks once
+// the returned tokens are issued, when the call is about to be sent upstream.
+func (r *CallbackReceiver) fillCallbackURLs(args map[string]any, endpoint, tool string, callbacks []CallbackInfo, sessionID string) map[string]callbackTarget {
+	tokens := map[string]callbackTarget{}
+	for _, cb := range callbacks {
+		token, err := newCallbackToken()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] Failed to create a callback URL for %s: %v\n", tool, err)
+			return tokens
+		}
+		if fillCallbackURL(args, cb.Expression, r.URL(endpoint, tool, cb.Name)+"/"+token) {
+			tokens[token] = callbackTarget{route: callbackRoute(endpoint, tool, cb.Name), sessionID: sessionID}
+		}
+	}
+	return tokens
+}
+
+// issue makes the receiver URLs of tokens accept callbacks for callbackTokenTTL.
+func (r *CallbackReceiver) issue(tokens map[string]callbackTarget) {
+	if len(tokens) == 0 {
+		return
+	}
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pruneLocked(now)
+	for token, target := range tokens {
+		target.expiresAt = now.Add(callbackTokenTTL)
+		r.tokens[token] = target
+	}
+}
+
+// revoke makes the receiver URLs of tokens refuse callbacks, for a call that failed.
+func (r *CallbackReceiver) revoke(tokens map[string]callbackTarget) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for token := range tokens {
+		delete(r.tokens, token)
+	}
+}
+
+// pruneLocked drops the expired tokens, at most every callbackPruneInterval so calls do not
+// scan every live token. The caller holds r.mu.
+func (r *CallbackReceiver) pruneLocked(now time.Time) {
+	if now.Sub(r.prunedAt) < callbackPruneInterval {
+		return
+	}
+	r.prunedAt = now
+	for token, target := range r.tokens {
+		if now.After(target.expiresAt) {
+			delete(r.tokens, token)
+		}
+	}
+}
+
+// newCallbackToken returns 128 random bits in hex.
+func newCallbackToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ServeHTTP implements http.Handler for requests under CallbackPathPrefix, i.e.
+// {endpoint}/{tool}/{callback}/{token}.
+func (r *CallbackReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(req.URL.Path, CallbackPathPrefix), "/")
+	route, token, _ := cutLast(path, "/")
+	r.mu.RLock()
+	server, ok := r.routes[route]
+	target, issued := r.tokens[token]
+	r.mu.RUnlock()
+	if !ok || !issued || target.route != route || time.Now().After(target.expiresAt) {
+		apierrors.WriteStatus(w, http.StatusNotFound, "unknown callback")
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxCallbackBody))
+	if err != nil {
+		apierrors.WriteStatus(w, http.StatusBadRequest, "failed to read callback payload")
+		return
+	}
+
+	parts := strings.SplitN(route, "/", 3)
+	params := map[string]any{
+		"endpoint":    parts[0],
+		"tool":        parts[1],
+		"callback":    parts[2],
+		"method":      req.Method,
+		"contentType": req.Header.Get("Content-Type"),
+		"receivedAt":  time.Now().UTC().Format(time.RFC3339),
+	}
+	var payload any
+	if json.Unmarshal(body, &payload) == nil {
+		params["payload"] = payload
+	} else {
+		params["payload"] = string(body)
+	}
+	if err := server.SendNotificationToSpecificClient(target.sessionID, CallbackNotification, params); err != nil {
+		// The session that made the call is gone, so later callbacks have nowhere to go either
+		r.mu.Lock()
+		delete(r.tokens, token)
+		r.mu.Unlock()
+		fmt.Fprintf(os.Stderr, "[WARN] Dropped %s callback for %s: %v\n", parts[2], parts[1], err)
+		apierrors.WriteStatus(w, http.StatusGone, "the session of the call has ended")
+		return
+	}
+	fmt.Fprintf(os.Stderr, "[INFO] Received %s callback for %s (%d bytes)\n", parts[2], parts[1], len(body))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// cutLast slices s around the last instance of sep.
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// fillCallbackURL sets the argument referenced by a callback URL expression to url
+// when the caller left it empty. Only {$request.body#/...} and {$request.query.*}
+// expressions can be filled; it reports whether the argument was set.
+func fillCallbackURL(args map[string]any, expression, url string) bool {
+	expr := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(expression), "{"), "}")
+	switch {
+	case strings.HasPrefix(expr, "$request.query."):
+		name := strings.TrimPrefix(expr, "$request.query.")
+		if v, ok := args[name]; ok && v != "" && v != nil {
+			return false
+		}
+		args[name] = url
+		return true
+	case strings.HasPrefix(expr, "$request.body#/"):
+		segments := strings.Split(strings.TrimPrefix(expr, "$request.body#/"), "/")
+		body, _ := args["requestBody"].(map[string]any)
+		if body == nil {
+			body = map[string]any{}
+			args["requestBody"] = body
+		}
+		obj := body
+		for _, seg := range segments[:len(segments)-1] {
+			next, _ := obj[seg].(map[string]any)
+			if next == nil {
+				next = map[string]any{}
+				obj[seg] = next
+			}
+			obj = next
+		}
+		last := segments[len(segments)-1]
+		if v, ok := obj[last]; ok && v != "" && v != nil {
+			return false
+		}
+		obj[last] = url
+		return true
+	}
+	return false
+}
+
+// callbackReceiverFor returns the receiver from opts, or the default one.
+func callbackReceiverFor(opts *ToolGenOptions) *CallbackReceiver {
+	if opts != nil && opts.CallbackReceiver != nil {
+		return opts.CallbackReceiver
+	}
+	return DefaultCallbackReceiver()
+}
+
+// callbackPayloadSchemaRef keeps describe output small for referenced schemas.
+func callbackPayloadSchemaRef(ref *openapi3.SchemaRef) any {
+	if ref == nil {
+		return nil
+	}
+	if ref.Ref != "" {
+		return map[string]any{"$ref": ref.Ref}
+	}
+	return ref.Value
+}
@@ -0,0 +1,222 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+const callbackSpec = `openapi: 3.0.0
+info:
+  title: Webhooks API
+  version: 1.0.0
+paths:
+  /subscriptions:
+    post:
+      operationId: subscribe
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                callbackUrl:
+                  type: string
+      responses:
+        '201':
+          description: Subscribed
+      callbacks:
+        onEvent:
+          '{$request.body#/callbackUrl}':
+            post:
+              summary: Event notification
+              requestBody:
+                content:
+                  application/json:
+                    schema:
+                      type: object
+                      properties:
+                        event:
+                          type: string
+              responses:
+                '204':
+                  description: Received
+`
+
+func TestOperationCallbacks(t *testing.T) {
+	doc, err := LoadOpenAPISpecFromString(callbackSpec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	ops := ExtractOpenAPIOperations(doc)
+	if len(ops) != 1 {
+		t.Fatalf("expected 1 operation, got %d", len(ops))
+	}
+	callbacks := operationCallbacks(ops[0])
+	if len(callbacks) != 1 {
+		t.Fatalf("expected 1 callback, got %d", len(callbacks))
+	}
+	cb := callbacks[0]
+	if cb.Name != "onEvent" || cb.Method != "POST" || cb.Expression != "{$request.body#/callbackUrl}" {
+		t.Errorf("unexpected callback: %+v", cb)
+	}
+	if cb.PayloadSchema == nil {
+		t.Errorf("expected a payload schema")
+	}
+
+	receiver := NewCallbackReceiver("https://mcp.example.com/")
+	server := mcpserver.NewMCPServer("test", "0.0.1")
+	RegisterOpenAPITools(server, ops, doc, &ToolGenOptions{CallbackReceiver: receiver}, nil)
+	for _, tool := range server.ListTools() {
+		if tool.Name == "subscribe" && !strings.Contains(tool.Description, "CALLBACKS:") {
+			t.Errorf("expected callbacks in the tool description, got %q", tool.Description)
+		}
+	}
+
+	// Only the receiver URL of a call is accepted, and its callbacks only reach the calling session
+	caller := &callbackSession{id: "caller", notifications: make(chan mcp.JSONRPCNotification, 10)}
+	other := &callbackSession{id: "other", notifications: make(chan mcp.JSONRPCNotification, 10)}
+	for _, session := range []*callbackSession{caller, other} {
+		if err := server.RegisterSession(context.Background(), session); err != nil {
+			t.Fatalf("failed to register session: %v", err)
+		}
+	}
+	args := map[string]any{}
+	tokens := receiver.fillCallbackURLs(args, "webhooks-api", "subscribe", callbacks, caller.id)
+	callbackURL, _ := args["requestBody"].(map[string]any)["callbackUrl"].(string)
+	prefix := "https://mcp.example.com" + CallbackPathPrefix + "webhooks-api/subscribe/onEvent/"
+	if !strings.HasPrefix(callbackURL, prefix) || len(callbackURL) <= len(prefix) {
+		t.Fatalf("expected a receiver URL with a token, got %q", callbackURL)
+	}
+
+	post := func(path string) int {
+		rec := httptest.NewRecorder()
+		receiver.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"event":"created"}`)))
+		return rec.Code
+	}
+	// The URL only accepts callbacks once the call is sent
+	if code := post(strings.TrimPrefix(callbackURL, "https://mcp.example.com")); code != http.StatusNotFound {
+		t.Errorf("expected 404 before the token is issued, got %d", code)
+	}
+	receiver.issue(tokens)
+	if code := post(strings.TrimPrefix(callbackURL, "https://mcp.example.com")); code != http.StatusNoContent {
+		t.Errorf("expected 204 for the receiver URL of the call, got %d", code)
+	}
+	if len(caller.notifications) != 1 || len(other.notifications) != 0 {
+		t.Errorf("expected the callback to reach the caller only, got %d and %d notifications", len(caller.notifications), len(other.notifications))
+	}
+	for _, path := range []string{
+		CallbackPathPrefix + "webhooks-api/subscribe/onEvent",
+		CallbackPathPrefix + "webhooks-api/subscribe/onEvent/0123456789abcdef0123456789abcdef",
+		CallbackPathPrefix + "unknown/tool/cb/" + strings.TrimPrefix(callbackURL, prefix),
+	} {
+		if code := post(path); code != http.StatusNotFound {
+			t.Errorf("expected 404 for %s, got %d", path, code)
+		}
+	}
+
+	// Callbacks for a session that has ended are refused
+	server.UnregisterSession(context.Background(), caller.id)
+	if code := post(strings.TrimPrefix(callbackURL, "https://mcp.example.com")); code != http.StatusGone {
+		t.Errorf("expected 410 once the session has ended, got %d", code)
+	}
+}
+
+func TestCallbackTokensIssuedWhenSent(t *testing.T) {
+	receiver := NewCallbackReceiver("https://mcp.example.com")
+	upstream := serveUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	doc, err := LoadOpenAPISpecFromString(callbackSpec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	server := mcpserver.NewMCPServer("test", "0.0.1")
+	RegisterOpenAPITools(server, ExtractOpenAPIOperations(doc), doc, &ToolGenOptions{CallbackReceiver: receiver}, nil)
+	session := &callbackSession{id: "caller", notifications: make(chan mcp.JSONRPCNotification, 10)}
+	server.RegisterSession(context.Background(), session)
+	call := func(args map[string]any) {
+		req, _ := json.Marshal(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"method":  "tools/call",
+			"params":  map[string]any{"name": "subscribe", "arguments": args},
+		})
+		server.HandleMessage(server.WithContext(context.Background(), session), req)
+	}
+	issued := func() int {
+		receiver.mu.RLock()
+		defer receiver.mu.RUnlock()
+		return len(receiver.tokens)
+	}
+
+	// Previewed calls are never sent, so their receiver URLs never accept callbacks
+	call(map[string]any{"requestBody": map[string]any{}, dryRunArg: true})
+	if n := issued(); n != 0 {
+		t.Errorf("expected no token for a previewed call, got %d", n)
+	}
+	call(map[string]any{"requestBody": map[string]any{}})
+	if n := issued(); n != 1 {
+		t.Errorf("expected a token for the sent call, got %d", n)
+	}
+	// Calls the upstream API never received are not called back
+	upstream.Close()
+	call(map[string]any{"requestBody": map[string]any{}})
+	if n := issued(); n != 1 {
+		t.Errorf("expected no token for a failed call, got %d tokens", n)
+	}
+}
+
+func TestCallbackReceiverPrunesExpiredTokens(t *testing.T) {
+	receiver := NewCallbackReceiver("https://mcp.example.com")
+	receiver.tokens["expired"] = callbackTarget{route: "a/b/c", expiresAt: time.Now().Add(-time.Minute)}
+	receiver.prunedAt = time.Now()
+	receiver.issue(map[string]callbackTarget{"live": {route: "a/b/c"}})
+	if _, ok := receiver.tokens["expired"]; !ok {
+		t.Errorf("expected tokens to be pruned at most every %s", callbackPruneInterval)
+	}
+	receiver.prunedAt = time.Now().Add(-callbackPruneInterval)
+	receiver.issue(map[string]callbackTarget{"next": {route: "a/b/c"}})
+	if _, ok := receiver.tokens["expired"]; ok || len(receiver.tokens) != 2 {
+		t.Errorf("expected only the expired token to be pruned, got %v", receiver.tokens)
+	}
+}
+
+type callbackSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *callbackSession) Initialize()       {}
+func (s *callbackSession) Initialized() bool { return true }
+func (s *callbackSession) SessionID() string { return s.id }
+func (s *callbackSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+func TestFillCallbackURL(t *testing.T) {
+	args := map[string]any{}
+	if !fillCallbackURL(args, "{$request.body#/subscriber/url}", "https://mcp.example.com/cb") {
+		t.Fatalf("expected body expression to be filled")
+	}
+	body := args["requestBody"].(map[string]any)
+	if body["subscriber"].(map[string]any)["url"] != "https://mcp.example.com/cb" {
+		t.Errorf("unexpected request body: %v", body)
+	}
+
+	args = map[string]any{"callback": "https://caller.example.com"}
+	if fillCallbackURL(args, "{$request.query.callback}", "https://mcp.example.com/cb") {
+		t.Errorf("expected caller-supplied URL to be kept")
+	}
+	if fillCallbackURL(args, "https://static.example.com/hook", "https://mcp.example.com/cb") {
+		t.Errorf("expected static expressions to be left alone")
+	}
+}
@@ -0,0 +1,48 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestToolCallCancellationStopsUpstreamRequest(t *testing.T) {
+	started := make(chan struct{})
+	upstreamCanceled := make(chan struct{})
+	server := newTestServer(t, mockUpstreamSpec, nil, func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		select {
+		case <-r.Context().Done():
+			close(upstreamCanceled)
+		case <-time.After(10 * time.Second):
+			w.Write([]byte(`[]`))
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel() // the MCP client disconnects mid-call
+	}()
+	req, _ := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]any{"name": "listPets", "arguments": map[string]any{}},
+	})
+	done := make(chan any)
+	go func() { done <- server.HandleMessage(ctx, req) }()
+
+	select {
+	case <-upstreamCanceled:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the upstream request to be canceled")
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the tool call to return after cancellation")
+	}
+}
@@ -0,0 +1,150 @@
+package openapi2mcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
+)
+
+// coalesceExtension is the root OpenAPI extension that turns request coalescing off
+// (`x-mcp-coalesce: false`) for a spec.
+const coalesceExtension = "x-mcp-coalesce"
+
+// specCoalesce reports whether identical concurrent GET and HEAD calls of a spec share one
+// upstream request: off with opts.DisableCoalescing, then the x-mcp-coalesce extension, then
+// MCP_COALESCE_REQUESTS. On by default.
+func specCoalesce(doc *openapi3.T, opts *ToolGenOptions) bool {
+	if opts != nil && opts.DisableCoalescing {
+		return false
+	}
+	if doc != nil {
+		if v, ok := doc.Extensions[coalesceExtension].(bool); ok {
+			return v
+		}
+	}
+	if v := os.Getenv("MCP_COALESCE_REQUESTS"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		return err != nil || enabled
+	}
+	return true
+}
+
+// coalescedResponse is an upstream response read in full, so it can be handed to every
+// caller that waited for it.
+type coalescedResponse struct {
+	resp *http.Response
+	body []byte
+}
+
+// response returns a copy of the response with its own body reader and headers.
+func (c *coalescedResponse) response() *http.Response {
+	resp := *c.resp
+	resp.Header = c.resp.Header.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(c.body))
+	return &resp
+}
+
+type inflightCall struct {
+	done chan struct{}
+	res  *coalescedResponse
+	err  error
+}
+
+// requestGroup runs identical concurrent upstream requests once and fans the response out,
+// so a swarm of agents polling the same resource costs the upstream API one request.
+type requestGroup struct {
+	mu    sync.Mutex
+	calls map[string]*inflightCall
+}
+
+func newRequestGroup() *requestGroup {
+	return &requestGroup{calls: make(map[string]*inflightCall)}
+}
+
+// do runs fn for the first caller with key and returns its response to the callers with the
+// same key that arrive while it runs; shared is true for those. A caller whose context ends
+// stops waiting. When the shared request was canceled by the first caller going away, the
+// others run their own request.
+func (g *requestGroup) do(ctx context.Context, key string, fn func() (*http.Response, error)) (resp *http.Response, shared bool, err error) {
+	g.mu.Lock()
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, true, ctx.Err()
+		}
+		if call.err == nil {
+			return call.res.response(), true, nil
+		}
+		if !errors.Is(call.err, context.Canceled) && !errors.Is(call.err, context.DeadlineExceeded) {
+			return nil, true, call.err
+		}
+		r, err := fn()
+		return r, false, err
+	}
+	call := &inflightCall{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(call.done)
+	}()
+	r, err := fn()
+	if err != nil {
+		call.err = err
+		return nil, false, err
+	}
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		call.err = err
+		return nil, false, err
+	}
+	call.res = &coalescedResponse{resp: r, body: body}
+	return call.res.response(), false, nil
+}
+
+// coalesceKey identifies an upstream request by everything that can change its response:
+// method, URL, headers and the credentials the auth provider will add. Only GET and HEAD
+// requests are coalesced; for others it returns "".
+func coalesceKey(req *http.Request, authCtx *auth.AuthContext) string {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return ""
+	}
+	h := sha256.New()
+	io.WriteString(h, req.Method+" "+req.URL.String()+"\n")
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		io.WriteString(h, name+": "+strings.Join(req.Header[name], ", ")+"\n")
+	}
+	if authCtx != nil {
+		io.WriteString(h, "auth: "+authCtx.AuthType+" "+authCtx.Token+"\n")
+		hostHeaders := make([]string, 0, len(authCtx.HostHeaders))
+		for name, value := range authCtx.HostHeaders {
+			hostHeaders = append(hostHeaders, name+": "+value)
+		}
+		sort.Strings(hostHeaders)
+		io.WriteString(h, strings.Join(hostHeaders, "\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
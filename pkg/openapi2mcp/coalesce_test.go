@@ -0,0 +1,104 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+func TestConcurrentGetCallsAreCoalesced(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	server := newTestServer(t, mockUpstreamSpec, nil, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1,"name":"Rex"}`))
+	})
+
+	const callers = 5
+	results := make([]string, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req, _ := json.Marshal(map[string]any{
+				"jsonrpc": "2.0",
+				"id":      i,
+				"method":  "tools/call",
+				"params":  map[string]any{"name": "getPet", "arguments": map[string]any{"id": 1}},
+			})
+			if resp, ok := server.HandleMessage(context.Background(), req).(mcp.JSONRPCResponse); ok {
+				results[i] = resp.Result.(mcp.CallToolResult).Content[0].(mcp.TextContent).Text
+			}
+		}(i)
+	}
+	// Let every caller reach the in-flight request before the upstream answers
+	time.Sleep(200 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("expected identical concurrent calls to share one upstream request, got %d", n)
+	}
+	for i, text := range results {
+		if !strings.Contains(text, `"name":"Rex"`) {
+			t.Errorf("caller %d: expected the shared response, got %q", i, text)
+		}
+	}
+
+	// Calls that don't overlap each reach the upstream API
+	callToolForTest(t, server, "getPet", map[string]any{"id": 1})
+	callToolForTest(t, server, "getPet", map[string]any{"id": 2})
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Errorf("expected sequential calls not to be coalesced, got %d upstream requests", n)
+	}
+}
+
+func TestCoalesceKey(t *testing.T) {
+	get, _ := http.NewRequest(http.MethodGet, "https://api.example.com/pets/1", nil)
+	alice := &auth.AuthContext{AuthType: "bearer", Token: "alice"}
+	bob := &auth.AuthContext{AuthType: "bearer", Token: "bob"}
+
+	if coalesceKey(get, alice) != coalesceKey(get.Clone(context.Background()), alice) {
+		t.Error("expected identical requests to have the same key")
+	}
+	if coalesceKey(get, alice) == coalesceKey(get, bob) {
+		t.Error("expected requests with different credentials not to be coalesced")
+	}
+	withHeader := get.Clone(context.Background())
+	withHeader.Header.Set("Accept-Language", "de")
+	if coalesceKey(get, alice) == coalesceKey(withHeader, alice) {
+		t.Error("expected requests with different headers not to be coalesced")
+	}
+	post, _ := http.NewRequest(http.MethodPost, "https://api.example.com/pets", nil)
+	if coalesceKey(post, alice) != "" {
+		t.Error("expected POST requests not to be coalesced")
+	}
+}
+
+func TestSpecCoalesce(t *testing.T) {
+	if !specCoalesce(nil, nil) {
+		t.Error("expected coalescing to be on by default")
+	}
+	if specCoalesce(nil, &ToolGenOptions{DisableCoalescing: true}) {
+		t.Error("expected DisableCoalescing to turn coalescing off")
+	}
+	doc, _ := LoadOpenAPISpecFromString(strings.Replace(mockUpstreamSpec, "openapi: 3.0.0\n", "openapi: 3.0.0\nx-mcp-coalesce: false\n", 1))
+	if specCoalesce(doc, nil) {
+		t.Error("expected x-mcp-coalesce: false to turn coalescing off")
+	}
+	t.Setenv("MCP_COALESCE_REQUESTS", "false")
+	if specCoalesce(nil, nil) {
+		t.Error("expected MCP_COALESCE_REQUESTS=false to turn coalescing off")
+	}
+}
@@ -0,0 +1,87 @@
+// Package convert upgrades Swagger 2.0 documents to OpenAPI 3, so specs only published in
+// the older format can be imported and served like any other.
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"gopkg.in/yaml.v3"
+)
+
+// IsSwagger2 reports whether content is a JSON or YAML document with a top-level
+// `swagger: "2.x"` version.
+func IsSwagger2(content []byte) bool {
+	var root struct {
+		Swagger any `json:"swagger" yaml:"swagger"`
+	}
+	if err := decode(content, &root); err != nil {
+		return false
+	}
+	return strings.HasPrefix(fmt.Sprint(root.Swagger), "2")
+}
+
+// Swagger2ToOpenAPI3 converts a Swagger 2.0 document, in JSON or YAML, to an OpenAPI 3
+// document in JSON. Host, basePath and schemes become servers, body and formData
+// parameters become request bodies, and definitions and securityDefinitions move to
+// components. Vendor extensions are kept.
+func Swagger2ToOpenAPI3(content []byte) ([]byte, error) {
+	var doc2 openapi2.T
+	if err := decode(content, &doc2); err != nil {
+		return nil, fmt.Errorf("failed to parse Swagger 2.0 spec: %v", err)
+	}
+	doc3, err := openapi2conv.ToV3(&doc2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert Swagger 2.0 spec to OpenAPI 3: %v", err)
+	}
+	converted, err := json.MarshalIndent(doc3, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert Swagger 2.0 spec to OpenAPI 3: %v", err)
+	}
+	return converted, nil
+}
+
+// decode unmarshals a JSON document, or a YAML one through its JSON form, so the JSON
+// unmarshalers of the kin-openapi types apply
+func decode(content []byte, v any) error {
+	trimmed := bytes.TrimSpace(content)
+	if !bytes.HasPrefix(trimmed, []byte("{")) {
+		var doc any
+		if err := yaml.Unmarshal(content, &doc); err != nil {
+			return err
+		}
+		var err error
+		if trimmed, err = json.Marshal(jsonCompatible(doc)); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(trimmed, v)
+}
+
+// jsonCompatible converts the maps YAML decodes with non-string keys, such as the status
+// codes of responses, to maps with string keys
+func jsonCompatible(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			v[key] = jsonCompatible(value)
+		}
+		return v
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = jsonCompatible(value)
+		}
+		return m
+	case []any:
+		for i, value := range v {
+			v[i] = jsonCompatible(value)
+		}
+		return v
+	}
+	return v
+}
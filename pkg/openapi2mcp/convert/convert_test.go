@@ -0,0 +1,81 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const petstoreSwagger2 = `
+swagger: "2.0"
+info:
+  title: Petstore
+  version: "1.0"
+host: api.example.com
+basePath: /v1
+schemes: [https]
+securityDefinitions:
+  api_key:
+    type: apiKey
+    in: header
+    name: X-API-Key
+paths:
+  /pets:
+    post:
+      operationId: addPet
+      parameters:
+        - in: body
+          name: pet
+          required: true
+          schema:
+            $ref: '#/definitions/Pet'
+      responses:
+        200:
+          description: The created pet
+          schema:
+            $ref: '#/definitions/Pet'
+definitions:
+  Pet:
+    type: object
+    properties:
+      name:
+        type: string
+`
+
+func TestIsSwagger2(t *testing.T) {
+	if !IsSwagger2([]byte(petstoreSwagger2)) || !IsSwagger2([]byte(`{"swagger": "2.0", "info": {}}`)) {
+		t.Error("expected Swagger 2.0 documents to be detected")
+	}
+	if IsSwagger2([]byte("openapi: 3.0.0\ninfo: {}\n")) || IsSwagger2([]byte("not: [valid")) {
+		t.Error("expected OpenAPI 3 and invalid documents not to be detected")
+	}
+}
+
+func TestSwagger2ToOpenAPI3(t *testing.T) {
+	converted, err := Swagger2ToOpenAPI3([]byte(petstoreSwagger2))
+	if err != nil {
+		t.Fatalf("failed to convert: %v", err)
+	}
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(converted)
+	if err != nil {
+		t.Fatalf("failed to load the converted spec: %v", err)
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		t.Fatalf("expected the converted spec to be valid: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("expected an OpenAPI 3 document, got %q", doc.OpenAPI)
+	}
+	if len(doc.Servers) != 1 || doc.Servers[0].URL != "https://api.example.com/v1" {
+		t.Errorf("expected host, basePath and schemes as the server, got %+v", doc.Servers)
+	}
+	op := doc.Paths.Find("/pets").Post
+	if op == nil || op.RequestBody == nil || op.RequestBody.Value.Content.Get("application/json") == nil {
+		t.Fatalf("expected the body parameter as a JSON request body, got %+v", op)
+	}
+	if doc.Components.Schemas["Pet"] == nil || doc.Components.SecuritySchemes["api_key"] == nil {
+		t.Errorf("expected definitions and securityDefinitions in components, got %+v", doc.Components)
+	}
+}
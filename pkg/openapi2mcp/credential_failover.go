@@ -0,0 +1,69 @@
+package openapi2mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+)
+
+const (
+	// rateLimitedKeyBench is how long an API key answered with 429 and no Retry-After is
+	// kept out of the rotation
+	rateLimitedKeyBench = time.Minute
+	// rejectedKeyBench is how long an API key answered with 401 is kept out of the rotation
+	rejectedKeyBench = 10 * time.Minute
+)
+
+// keyFailoverKey marks the context of upstream calls that fail over to another API key
+// on 429, so retryTransport does not retry them with the rate limited key.
+type keyFailoverKey struct{}
+
+// credentialRejected reports whether the upstream rejected the API key of a call (401) or
+// rate limited it (429), and how long to bench the key: a 429's Retry-After, when it has one.
+func credentialRejected(resp *http.Response) (time.Duration, bool) {
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return rejectedKeyBench, true
+	case http.StatusTooManyRequests:
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok && wait > 0 {
+			return wait, true
+		}
+		return rateLimitedKeyBench, true
+	}
+	return 0, false
+}
+
+// sendWithCredentials sends req with the first of a spec's API keys in creds, and while the
+// upstream rejects or rate limits a key, benches it and sends req again with the next one.
+// req must have been built with creds[0] in its auth context.
+func sendWithCredentials(send func(*http.Request) (*http.Response, error), req *http.Request, spec *models.OpenAPISpec, creds []auth.Credential, rotation *auth.KeyRotation) (*http.Response, error) {
+	req = req.WithContext(context.WithValue(req.Context(), keyFailoverKey{}, true))
+	resp, err := send(req)
+	for i := 0; err == nil && i < len(creds); i++ {
+		bench, rejected := credentialRejected(resp)
+		if !rejected {
+			break
+		}
+		rotation.Bench(spec, creds[i], bench)
+		if i+1 == len(creds) || req.Body != nil && req.GetBody == nil {
+			break
+		}
+		fmt.Fprintf(os.Stderr, "[WARN] %s answered %d to the API key %s of %s; retrying with %s\n",
+			req.URL.Host, resp.StatusCode, creds[i].Label, spec.EndpointPath, creds[i+1].Label)
+		resp.Body.Close()
+		authCtx, _ := auth.FromContext(req.Context())
+		retry := req.WithContext(auth.WithAuthContext(req.Context(), authCtx.WithToken(auth.ResolveCredential(req.Context(), creds[i+1]))))
+		if req.GetBody != nil {
+			if retry.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		resp, err = send(retry)
+	}
+	return resp, err
+}
@@ -0,0 +1,106 @@
+package openapi2mcp
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+)
+
+const keyedSpec = `
+openapi: 3.0.0
+info: {title: Keyed, version: "1.0"}
+components:
+  securitySchemes:
+    key: {type: apiKey, in: header, name: X-API-Key}
+security:
+  - key: []
+paths:
+  /items:
+    get:
+      operationId: listItems
+      responses:
+        '200': {description: ok}
+`
+
+// keyedServer registers keyedSpec for a spec whose api_key_token is key-a, with key-b and
+// key-c as spec_credentials, against an upstream answering rejected keys with their status.
+func keyedServer(t *testing.T, strategy string, rejected map[string]int) (*mcpserver.MCPServer, func() []string) {
+	var mu sync.Mutex
+	var keys []string
+	serveUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-API-Key")
+		mu.Lock()
+		keys = append(keys, key)
+		mu.Unlock()
+		if status := rejected[key]; status != 0 {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	})
+
+	doc, err := LoadOpenAPISpecFromString(keyedSpec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	token, labelB := "key-a", "team-b"
+	dbSpec := &models.OpenAPISpec{ID: 7, Name: "keyed", EndpointPath: "/keyed", ApiKeyToken: &token,
+		Credentials: []*models.SpecCredential{{ID: 1, Label: &labelB, Token: "key-b"}, {ID: 2, Token: "key-c"}}}
+	if strategy != "" {
+		dbSpec.CredentialStrategy = &strategy
+	}
+	server := mcpserver.NewMCPServer("test", "0.0.1")
+	opts := &ToolGenOptions{KeyRotation: auth.NewKeyRotation()}
+	RegisterOpenAPITools(server, ExtractOpenAPIOperations(doc), doc, opts, dbSpec)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		seen := keys
+		keys = nil
+		return seen
+	}
+}
+
+func TestCredentialRoundRobin(t *testing.T) {
+	server, seen := keyedServer(t, "", nil)
+	for i := 0; i < 4; i++ {
+		if res := callToolForTest(t, server, "listItems", nil); res.IsError {
+			t.Fatalf("call %d failed: %s", i, previewText(res))
+		}
+	}
+	if got := strings.Join(seen(), ","); got != "key-a,key-b,key-c,key-a" {
+		t.Errorf("expected the calls to take the keys in turn, got %s", got)
+	}
+}
+
+func TestCredentialFailoverOnRateLimit(t *testing.T) {
+	server, seen := keyedServer(t, auth.CredentialFailover, map[string]int{"key-a": http.StatusTooManyRequests})
+	if res := callToolForTest(t, server, "listItems", nil); res.IsError {
+		t.Fatalf("expected the call to succeed with the next key, got %s", previewText(res))
+	}
+	if got := strings.Join(seen(), ","); got != "key-a,key-b" {
+		t.Errorf("expected one attempt with the rate limited key, then the next key, got %s", got)
+	}
+	// The rate limited key stays benched, so the next call starts with the second key
+	callToolForTest(t, server, "listItems", nil)
+	if got := strings.Join(seen(), ","); got != "key-b" {
+		t.Errorf("expected the benched key to be skipped, got %s", got)
+	}
+}
+
+func TestCredentialFailoverAllRejected(t *testing.T) {
+	server, seen := keyedServer(t, "", map[string]int{"key-a": 401, "key-b": 401, "key-c": 401})
+	res := callToolForTest(t, server, "listItems", nil)
+	if !res.IsError {
+		t.Fatalf("expected the call to fail when every key is rejected")
+	}
+	if got := strings.Join(seen(), ","); got != "key-a,key-b,key-c" {
+		t.Errorf("expected each key to be tried once, got %s", got)
+	}
+}
@@ -0,0 +1,149 @@
+package openapi2mcp
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// dateTimeLayouts are the date-time variants agents send instead of RFC 3339; values without
+// an offset are read as UTC.
+var dateTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	time.RFC1123,
+	time.RFC1123Z,
+	"2006-01-02",
+	"2006/01/02",
+	"20060102",
+}
+
+// epochMillisThreshold is the epoch value above which a timestamp is read as milliseconds:
+// 1e12 seconds is in the year 33658, 1e12 milliseconds in 2001.
+const epochMillisThreshold = 1e12
+
+// normalizeDateArgs rewrites the values of date and date-time parameters that are not in the
+// format their schema declares (epoch seconds, a date-time for a date, a space instead of the
+// T, ...) into that format, so the upstream doesn't reject them. Values already in the format
+// are left as they are. It returns an error for the first value it cannot read.
+func normalizeDateArgs(args map[string]any, params openapi3.Parameters, duplicates map[string]bool) error {
+	for _, paramRef := range params {
+		if paramRef == nil || paramRef.Value == nil || paramRef.Value.Schema == nil || paramRef.Value.Schema.Value == nil {
+			continue
+		}
+		p := paramRef.Value
+		schema := p.Schema.Value
+		format := schema.Format
+		var isArray bool
+		if schema.Type != nil && schema.Type.Is("array") && schema.Items != nil && schema.Items.Value != nil {
+			schema, isArray = schema.Items.Value, true
+			format = schema.Format
+		}
+		if format != "date" && format != "date-time" || len(schema.Type.Slice()) > 0 && !schema.Type.Includes("string") {
+			continue
+		}
+		argName := paramArgName(p, duplicates)
+		if _, ok := args[argName]; !ok && !duplicates[escapeParameterName(p.Name)] {
+			argName = p.Name // unescaped name, see getParameterValue
+		}
+		val, ok := args[argName]
+		if !ok || val == nil {
+			continue
+		}
+		if !isArray {
+			normalized, err := normalizeDateValue(val, format)
+			if err != nil {
+				return fmt.Errorf("invalid %s for '%s': %v", format, argName, err)
+			}
+			args[argName] = normalized
+			continue
+		}
+		items, ok := val.([]any)
+		if !ok {
+			continue // reported by schema validation
+		}
+		for i, item := range items {
+			normalized, err := normalizeDateValue(item, format)
+			if err != nil {
+				return fmt.Errorf("invalid %s for '%s[%d]': %v", format, argName, i, err)
+			}
+			items[i] = normalized
+		}
+	}
+	return nil
+}
+
+// normalizeDateValue returns a date or date-time value in the format of the schema: RFC 3339
+// full-date (2024-01-02) or date-time (2024-01-02T15:04:05Z).
+func normalizeDateValue(val any, format string) (any, error) {
+	var t time.Time
+	switch v := val.(type) {
+	case float64:
+		t = epochTime(v)
+	case int:
+		t = epochTime(float64(v))
+	case int64:
+		t = epochTime(float64(v))
+	case string:
+		s := strings.TrimSpace(v)
+		if format == "date" {
+			if _, err := time.Parse(time.DateOnly, s); err == nil {
+				return s, nil
+			}
+		} else if _, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return s, nil
+		}
+		// Shorter numbers are dates like 20240102, not timestamps before 1973
+		if epoch, err := strconv.ParseFloat(s, 64); err == nil && len(s) >= 9 {
+			t = epochTime(epoch)
+			break
+		}
+		parsed, ok := parseDateTime(s)
+		if !ok {
+			example := "2024-01-02T15:04:05Z"
+			if format == "date" {
+				example = "2024-01-02"
+			}
+			return nil, fmt.Errorf("%q is not a date; use RFC 3339 like %s, or epoch seconds", v, example)
+		}
+		t = parsed
+	default:
+		return val, nil // reported by schema validation
+	}
+	if format == "date" {
+		return t.Format(time.DateOnly), nil
+	}
+	return t.Format(time.RFC3339Nano), nil
+}
+
+// parseDateTime reads s with the first of dateTimeLayouts that matches, accepting the
+// lowercase t and z RFC 3339 allows.
+func parseDateTime(s string) (time.Time, bool) {
+	if len(s) >= 10 && s[4] == '-' {
+		s = strings.ToUpper(s)
+	}
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// epochTime returns the UTC time of epoch seconds, or milliseconds above epochMillisThreshold.
+func epochTime(epoch float64) time.Time {
+	if math.Abs(epoch) >= epochMillisThreshold {
+		epoch /= 1000
+	}
+	sec, frac := math.Modf(epoch)
+	return time.Unix(int64(sec), int64(math.Round(frac*1e9))).UTC()
+}
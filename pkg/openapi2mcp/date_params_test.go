@@ -0,0 +1,72 @@
+package openapi2mcp
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeDateValue(t *testing.T) {
+	tests := []struct {
+		val    any
+		format string
+		want   any
+	}{
+		{"2024-01-02T15:04:05+02:00", "date-time", "2024-01-02T15:04:05+02:00"},
+		{"2024-01-02 15:04:05", "date-time", "2024-01-02T15:04:05Z"},
+		{"2024-01-02t15:04:05.5z", "date-time", "2024-01-02T15:04:05.5Z"},
+		{"2024-01-02T15:04:05+0200", "date-time", "2024-01-02T15:04:05+02:00"},
+		{"2024-01-02", "date-time", "2024-01-02T00:00:00Z"},
+		{float64(1704207845), "date-time", "2024-01-02T15:04:05Z"},
+		{"1704207845000", "date-time", "2024-01-02T15:04:05Z"},
+		{"2024-01-02", "date", "2024-01-02"},
+		{"2024-01-02T23:30:00-05:00", "date", "2024-01-02"},
+		{"2024/01/02", "date", "2024-01-02"},
+		{"20240102", "date", "2024-01-02"},
+		{1704207845, "date", "2024-01-02"},
+		{true, "date", true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeDateValue(tt.val, tt.format)
+		if err != nil || got != tt.want {
+			t.Errorf("%v as %s: expected %v, got %v (%v)", tt.val, tt.format, tt.want, got, err)
+		}
+	}
+	if _, err := normalizeDateValue("next tuesday", "date"); err == nil || !strings.Contains(err.Error(), "2024-01-02") {
+		t.Errorf("expected an error with an example date, got %v", err)
+	}
+}
+
+const dateParamsSpec = `
+openapi: 3.0.0
+info: {title: Events, version: "1.0"}
+paths:
+  /events:
+    get:
+      operationId: listEvents
+      parameters:
+        - {name: since, in: query, schema: {type: string, format: date-time}}
+        - {name: day, in: query, schema: {type: string, format: date}}
+      responses:
+        '200': {description: ok}
+`
+
+func TestDateParameters(t *testing.T) {
+	var query string
+	server := newTestServer(t, dateParamsSpec, nil, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	})
+
+	res := callToolForTest(t, server, "listEvents", map[string]any{"since": 1704207845, "day": "2024-01-02T10:00:00Z"})
+	if res.IsError || query != "day=2024-01-02&since=2024-01-02T15%3A04%3A05Z" {
+		t.Errorf("expected the dates in the spec's formats, got query %q, result %s", query, previewText(res))
+	}
+
+	query = ""
+	res = callToolForTest(t, server, "listEvents", map[string]any{"day": "soon"})
+	if !res.IsError || query != "" || !strings.Contains(previewText(res), "invalid date for 'day'") {
+		t.Errorf("expected an unreadable date to be rejected before the upstream call, got %s", previewText(res))
+	}
+}
@@ -0,0 +1,121 @@
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+// describeSchema is the input schema of the describe tool. Without arguments it describes
+// every tool; large endpoints can ask for one tool or a group to save tokens.
+var describeSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"tool": map[string]any{
+			"type":        "string",
+			"description": "Only describe the tools whose name matches: a name, a glob such as 'list*' or 're:<regexp>', ignoring case. Separate several patterns with commas.",
+		},
+		"tag": map[string]any{
+			"type":        "string",
+			"description": "Only describe the tools of operations with this OpenAPI tag, ignoring case.",
+		},
+		"include_examples": map[string]any{
+			"type":        "boolean",
+			"description": "Include example calls and the examples of input schemas (default true).",
+			"default":     true,
+		},
+	},
+}
+
+// describeFilter selects the tools reported by the describe tool.
+type describeFilter struct {
+	patterns []*regexp.Regexp
+	tag      string
+	examples bool
+}
+
+// parseDescribeFilter reads the arguments of a describe call.
+func parseDescribeFilter(args map[string]any) (describeFilter, error) {
+	f := describeFilter{examples: true}
+	if s, ok := args["tool"].(string); ok {
+		patterns, err := compileOperationPatterns(ParseOperationPatterns(s))
+		if err != nil {
+			return f, err
+		}
+		f.patterns = patterns
+	}
+	if s, ok := args["tag"].(string); ok {
+		f.tag = strings.TrimSpace(s)
+	}
+	switch v := args["include_examples"].(type) {
+	case bool:
+		f.examples = v
+	case nil:
+	default:
+		return f, fmt.Errorf("include_examples must be a boolean, got %v", v)
+	}
+	return f, nil
+}
+
+// matches reports whether a tool with the given operation tags is selected.
+func (f describeFilter) matches(name string, tags []string) bool {
+	if len(f.patterns) > 0 && !operationMatches(f.patterns, name, "") {
+		return false
+	}
+	if f.tag == "" {
+		return true
+	}
+	for _, tag := range tags {
+		if strings.EqualFold(tag, f.tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// describeInputSchema returns the input schema of a tool as described, without "example"
+// and "examples" keywords unless examples are requested.
+func describeInputSchema(tool mcp.Tool, examples bool) any {
+	var schema any = tool.InputSchema
+	if len(tool.RawInputSchema) > 0 {
+		schema = tool.RawInputSchema
+		if !examples {
+			var decoded any
+			if err := json.Unmarshal(tool.RawInputSchema, &decoded); err == nil {
+				schema = stripSchemaExamples(decoded)
+			}
+		}
+	}
+	return schema
+}
+
+// stripSchemaExamples removes the "example" and "examples" keywords of a decoded schema.
+// Properties named "example" are kept.
+func stripSchemaExamples(v any) any {
+	switch s := v.(type) {
+	case map[string]any:
+		for key, value := range s {
+			if key == "properties" {
+				if props, ok := value.(map[string]any); ok {
+					for name, prop := range props {
+						props[name] = stripSchemaExamples(prop)
+					}
+					continue
+				}
+			}
+			if key == "example" || key == "examples" {
+				delete(s, key)
+				continue
+			}
+			s[key] = stripSchemaExamples(value)
+		}
+	case []any:
+		for i, item := range s {
+			s[i] = stripSchemaExamples(item)
+		}
+	}
+	return v
+}
@@ -0,0 +1,109 @@
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+const describeSpec = `
+openapi: 3.0.0
+info:
+  title: Store
+  version: "1.0"
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      tags: [pets]
+      parameters:
+        - name: limit
+          in: query
+          schema: {type: integer, example: 10}
+      responses:
+        "200": {description: ok}
+  /pets/{id}:
+    get:
+      operationId: getPet
+      tags: [Pets]
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema: {type: string}
+      responses:
+        "200": {description: ok}
+  /orders:
+    get:
+      operationId: listOrders
+      tags: [orders]
+      responses:
+        "200": {description: ok}
+`
+
+func describeForTest(t *testing.T, server *mcpserver.MCPServer, args map[string]any) (names []string, described map[string]any) {
+	t.Helper()
+	res := callToolForTest(t, server, "describe", args)
+	if res.IsError {
+		t.Fatalf("describe failed: %s", res.Content[0].(mcp.TextContent).Text)
+	}
+	if err := json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &described); err != nil {
+		t.Fatalf("invalid describe output: %v", err)
+	}
+	for _, tool := range described["tools"].([]any) {
+		names = append(names, tool.(map[string]any)["name"].(string))
+	}
+	return names, described
+}
+
+func TestDescribeFilters(t *testing.T) {
+	server := newTestServer(t, describeSpec, &ToolGenOptions{}, nil)
+
+	names, described := describeForTest(t, server, map[string]any{})
+	if len(names) != len(server.ListTools()) || described["total"] != nil {
+		t.Errorf("expected every tool without a filter, got %v", names)
+	}
+
+	names, described = describeForTest(t, server, map[string]any{"tool": "GETPET"})
+	if strings.Join(names, ",") != "getPet" || described["total"] != float64(len(server.ListTools())) {
+		t.Errorf("expected only getPet and the total, got %v, %v", names, described["total"])
+	}
+	tool := described["tools"].([]any)[0].(map[string]any)
+	if props, _ := tool["inputSchema"].(map[string]any)["properties"].(map[string]any); props["id"] == nil {
+		t.Errorf("expected the input schema of getPet, got %v", tool["inputSchema"])
+	}
+	if tool["example_call"] == nil || tool["tags"] == nil {
+		t.Errorf("expected the example call and tags, got %v", tool)
+	}
+
+	_, described = describeForTest(t, server, map[string]any{"tool": "listPets"})
+	tool = described["tools"].([]any)[0].(map[string]any)
+	if limit := tool["inputSchema"].(map[string]any)["properties"].(map[string]any)["limit"].(map[string]any); limit["example"] != float64(10) {
+		t.Errorf("expected the schema example by default, got %v", limit)
+	}
+
+	if names, _ = describeForTest(t, server, map[string]any{"tool": "list*"}); strings.Join(names, ",") != "listPets,listOrders" && strings.Join(names, ",") != "listOrders,listPets" {
+		t.Errorf("expected the list tools, got %v", names)
+	}
+	if names, _ = describeForTest(t, server, map[string]any{"tag": "pets"}); len(names) != 2 {
+		t.Errorf("expected the tools tagged pets in any case, got %v", names)
+	}
+	if names, _ = describeForTest(t, server, map[string]any{"tag": "pets", "tool": "get*"}); strings.Join(names, ",") != "getPet" {
+		t.Errorf("expected filters to combine, got %v", names)
+	}
+
+	_, described = describeForTest(t, server, map[string]any{"tool": "listPets", "include_examples": false})
+	tool = described["tools"].([]any)[0].(map[string]any)
+	limit := tool["inputSchema"].(map[string]any)["properties"].(map[string]any)["limit"].(map[string]any)
+	if tool["example_call"] != nil || limit["example"] != nil || limit["type"] != "integer" {
+		t.Errorf("expected no examples, got %v", tool)
+	}
+
+	res := callToolForTest(t, server, "describe", map[string]any{"tool": "re:("})
+	if !res.IsError || res.Meta["error"] == nil {
+		t.Errorf("expected a validation error for an invalid pattern, got %+v", res)
+	}
+}
@@ -0,0 +1,168 @@
+package openapi2mcp
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// maxDescriptionExtension is the root OpenAPI extension that sets the length, in characters,
+// above which operation descriptions are shortened (`x-mcp-max-description-chars: 1500`).
+const maxDescriptionExtension = "x-mcp-max-description-chars"
+
+// defaultMaxDescriptionChars is the description length kept when none is configured.
+const defaultMaxDescriptionChars = 2000
+
+// DescSummarizer shortens an operation description to at most maxChars characters,
+// for example with an LLM. It is only called for descriptions longer than maxChars. When it
+// returns an error, an empty string or a text that is still too long, the rule-based
+// summary is used instead.
+type DescSummarizer func(toolName, description string, maxChars int) (string, error)
+
+// specMaxDescriptionChars returns the description length limit of a spec:
+// opts.MaxDescriptionChars, then the x-mcp-max-description-chars extension, then
+// MCP_MAX_DESCRIPTION_CHARS, then 2000.
+func specMaxDescriptionChars(doc *openapi3.T, opts *ToolGenOptions) int {
+	if opts != nil && opts.MaxDescriptionChars > 0 {
+		return opts.MaxDescriptionChars
+	}
+	if doc != nil {
+		// Fractions below 1 would truncate to no characters at all
+		if n := int(numberValue(doc.Extensions[maxDescriptionExtension])); n >= 1 {
+			return n
+		}
+	}
+	if v := os.Getenv("MCP_MAX_DESCRIPTION_CHARS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+		fmt.Fprintf(os.Stderr, "[WARN] Ignoring invalid MCP_MAX_DESCRIPTION_CHARS=%q\n", v)
+	}
+	return defaultMaxDescriptionChars
+}
+
+// shortenDescription returns the description of op shortened to about maxChars characters,
+// and whether it was shortened. The summarizer is tried first when there is one.
+func shortenDescription(name string, op OpenAPIOperation, inputSchema map[string]any, maxChars int, summarizer DescSummarizer) (string, bool) {
+	length := utf8.RuneCountInString(op.Description)
+	if length <= maxChars {
+		return op.Description, false
+	}
+	if summarizer != nil {
+		summary, err := summarizer(name, op.Description, maxChars)
+		switch {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "[WARN] Failed to summarize the description of %s: %v\n", name, err)
+		case strings.TrimSpace(summary) != "" && utf8.RuneCountInString(summary) <= maxChars:
+			return summary + shortenedDescriptionNote(length), true
+		}
+	}
+	var params []string
+	if properties, ok := inputSchema["properties"].(map[string]any); ok {
+		for param := range properties {
+			params = append(params, param)
+		}
+		sort.Strings(params)
+	}
+	return summarizeDescription(op.Description, params, maxChars) + shortenedDescriptionNote(length), true
+}
+
+func shortenedDescriptionNote(length int) string {
+	return fmt.Sprintf("\n\n[Description shortened from %d characters; the describe tool returns the full text.]", length)
+}
+
+// summarizeDescription shortens a description without changing its wording: it keeps the
+// first paragraph, then the lines of later paragraphs that mention one of params, while
+// they fit in maxChars characters. Paragraphs too long to fit are cut at the end of a
+// sentence, in any script, or else at a word boundary.
+func summarizeDescription(text string, params []string, maxChars int) string {
+	text = strings.ReplaceAll(strings.TrimSpace(text), "\r\n", "\n")
+	paragraphs := strings.Split(text, "\n\n")
+	summary := truncateText(strings.TrimSpace(paragraphs[0]), maxChars)
+	used := utf8.RuneCountInString(summary)
+
+	var notes []string
+	for _, paragraph := range paragraphs[1:] {
+		for _, line := range strings.Split(paragraph, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || !mentionsAny(line, params) {
+				continue
+			}
+			n := utf8.RuneCountInString(line) + 1
+			if used+n > maxChars {
+				continue
+			}
+			notes = append(notes, line)
+			used += n
+		}
+	}
+	if len(notes) > 0 {
+		summary += "\n\n" + strings.Join(notes, "\n")
+	}
+	return summary
+}
+
+// truncateText cuts text to at most maxChars characters, at the last sentence end in the
+// second half of the limit, or else at the last space, adding an ellipsis.
+func truncateText(text string, maxChars int) string {
+	runes := []rune(text)
+	if len(runes) <= maxChars {
+		return text
+	}
+	cut := runes[:maxChars-1]
+	for i := len(cut) - 1; i >= len(cut)/2; i-- {
+		if isSentenceEnd(runes, i) {
+			return string(cut[:i+1])
+		}
+	}
+	for i := len(cut) - 1; i >= len(cut)/2; i-- {
+		if unicode.IsSpace(cut[i]) {
+			return strings.TrimRightFunc(string(cut[:i]), unicode.IsSpace) + "…"
+		}
+	}
+	return string(cut) + "…"
+}
+
+// isSentenceEnd reports whether runes[i] ends a sentence: a full stop, question or
+// exclamation mark followed by a space, or one of the CJK ones, which need none.
+func isSentenceEnd(runes []rune, i int) bool {
+	switch runes[i] {
+	case '。', '！', '？', '｡':
+		return true
+	case '.', '!', '?':
+		return i+1 < len(runes) && unicode.IsSpace(runes[i+1])
+	}
+	return false
+}
+
+// mentionsAny reports whether line mentions one of names as a whole word, ignoring case.
+func mentionsAny(line string, names []string) bool {
+	lower := strings.ToLower(line)
+	for _, name := range names {
+		name = strings.ToLower(name)
+		for start := 0; name != ""; {
+			i := strings.Index(lower[start:], name)
+			if i < 0 {
+				break
+			}
+			i += start
+			before, _ := utf8.DecodeLastRuneInString(lower[:i])
+			after, _ := utf8.DecodeRuneInString(lower[i+len(name):])
+			if !isWordRune(before) && !isWordRune(after) {
+				return true
+			}
+			start = i + len(name)
+		}
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,133 @@
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+func TestSummarizeDescriptionKeepsFirstParagraphAndParameterNotes(t *testing.T) {
+	text := "Search the catalog for products.\n\n" +
+		strings.Repeat("Background on the ranking algorithm. ", 40) + "\n\n" +
+		"- limit: at most 100 results.\n- Results are cached.\n- Use `category` to narrow the search."
+	got := summarizeDescription(text, []string{"category", "limit"}, 200)
+	want := "Search the catalog for products.\n\n- limit: at most 100 results.\n- Use `category` to narrow the search."
+	if got != want {
+		t.Errorf("unexpected summary:\n%s", got)
+	}
+}
+
+func TestTruncateTextAtSentenceEnd(t *testing.T) {
+	cases := []struct {
+		text string
+		max  int
+		want string
+	}{
+		{"First sentence. Second sentence is longer.", 30, "First sentence."},
+		{"天気を取得します。詳細な説明がここに続きます。さらに続きます。", 25, "天気を取得します。詳細な説明がここに続きます。"},
+		{"one two three four five six seven", 20, "one two three four…"},
+	}
+	for _, c := range cases {
+		got := truncateText(c.text, c.max)
+		if got != c.want || utf8.RuneCountInString(got) > c.max {
+			t.Errorf("truncateText(%q, %d) = %q, want %q", c.text, c.max, got, c.want)
+		}
+	}
+}
+
+func TestSpecMaxDescriptionCharsIgnoresFractions(t *testing.T) {
+	t.Setenv("MCP_MAX_DESCRIPTION_CHARS", "")
+	for _, value := range []any{0.5, 0.0, -3.0} {
+		doc := &openapi3.T{Extensions: map[string]any{maxDescriptionExtension: value}}
+		if got := specMaxDescriptionChars(doc, nil); got != defaultMaxDescriptionChars {
+			t.Errorf("%s: %v = %d, want the default %d", maxDescriptionExtension, value, got, defaultMaxDescriptionChars)
+		}
+	}
+	doc := &openapi3.T{Extensions: map[string]any{maxDescriptionExtension: 1.5}}
+	if got := specMaxDescriptionChars(doc, nil); got != 1 {
+		t.Errorf("%s: 1.5 = %d, want 1", maxDescriptionExtension, got)
+	}
+	if got := truncateText("Some description.", 1); got != "…" {
+		t.Errorf("truncateText to 1 character = %q", got)
+	}
+}
+
+func TestMentionsAnyWholeWords(t *testing.T) {
+	if !mentionsAny("Set Limit to 10", []string{"limit"}) {
+		t.Error("expected a case-insensitive match")
+	}
+	if mentionsAny("Unlimited results", []string{"limit"}) || mentionsAny("limits apply", []string{"limit"}) {
+		t.Error("expected no match inside a word")
+	}
+}
+
+func TestLongDescriptionsShortenedWithFullTextInDescribe(t *testing.T) {
+	long := "Get a pet.\n\n" + strings.Repeat("Lorem ipsum dolor sit amet. ", 100)
+	spec := `
+openapi: 3.0.0
+info:
+  title: Pets
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      description: |
+        ` + strings.ReplaceAll(long, "\n", "\n        ") + `
+      responses:
+        '200':
+          description: ok
+`
+	doc, err := LoadOpenAPISpecFromString(spec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	full := doc.Paths.Find("/pets").Get.Description
+
+	var summarized string
+	summarizer := func(toolName, description string, maxChars int) (string, error) {
+		summarized = toolName
+		return "", errors.New("unavailable")
+	}
+	server := mcpserver.NewMCPServer("test", "0.0.1")
+	RegisterOpenAPITools(server, ExtractOpenAPIOperations(doc), doc, &ToolGenOptions{MaxDescriptionChars: 500, SummarizeDescription: summarizer}, nil)
+	if summarized != "listPets" {
+		t.Errorf("expected the summarizer to be called for listPets, got %q", summarized)
+	}
+	for _, tool := range server.ListTools() {
+		if tool.Name == "listPets" && (!strings.HasPrefix(tool.Description, "Get a pet.\n\n[Description shortened from") || strings.Contains(tool.Description, "Lorem")) {
+			t.Errorf("expected a shortened description, got %q", tool.Description)
+		}
+	}
+
+	res := callToolForTest(t, server, "describe", map[string]any{})
+	var described struct {
+		Tools []map[string]any `json:"tools"`
+	}
+	if err := json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &described); err != nil {
+		t.Fatalf("invalid describe output: %v", err)
+	}
+	for _, tool := range described.Tools {
+		if tool["name"] == "listPets" && tool["full_description"] != full {
+			t.Errorf("expected describe to return the full description, got %v", tool["full_description"])
+		}
+	}
+
+	// A summarizer's summary is used when it fits
+	server = mcpserver.NewMCPServer("test", "0.0.1")
+	summarizer = func(toolName, description string, maxChars int) (string, error) {
+		return "Lists pets.", nil
+	}
+	RegisterOpenAPITools(server, ExtractOpenAPIOperations(doc), doc, &ToolGenOptions{MaxDescriptionChars: 500, SummarizeDescription: summarizer}, nil)
+	for _, tool := range server.ListTools() {
+		if tool.Name == "listPets" && !strings.HasPrefix(tool.Description, "Lists pets.\n\n[Description shortened") {
+			t.Errorf("expected the summarizer's summary, got %q", tool.Description)
+		}
+	}
+}
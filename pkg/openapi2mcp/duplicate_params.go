@@ -0,0 +1,92 @@
+// duplicate_params.go
+package openapi2mcp
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// duplicateParamNames returns the argument names of an operation's parameters that are declared
+// in more than one location, e.g. `id` in both the path and the query. The flat input schema
+// cannot hold them under one name, so each gets its location appended (see paramArgName).
+func duplicateParamNames(params openapi3.Parameters) map[string]bool {
+	locations := map[string]map[string]bool{}
+	for _, paramRef := range params {
+		if paramRef == nil || paramRef.Value == nil {
+			continue
+		}
+		name := escapeParameterName(paramRef.Value.Name)
+		if locations[name] == nil {
+			locations[name] = map[string]bool{}
+		}
+		locations[name][paramRef.Value.In] = true
+	}
+	var duplicates map[string]bool
+	for name, in := range locations {
+		if len(in) > 1 {
+			if duplicates == nil {
+				duplicates = map[string]bool{}
+			}
+			duplicates[name] = true
+		}
+	}
+	return duplicates
+}
+
+// paramArgName returns the argument name of a parameter in the input schema: its escaped
+// name, with the location appended (id_path, id_query) when the name is declared in more
+// than one location.
+func paramArgName(p *openapi3.Parameter, duplicates map[string]bool) string {
+	name := escapeParameterName(p.Name)
+	if duplicates[name] {
+		return name + "_" + p.In
+	}
+	return name
+}
+
+// parameterValue retrieves the value of a parameter from the arguments of a call. A parameter
+// whose name is declared in several locations is only read from its disambiguated name.
+func parameterValue(args map[string]any, p *openapi3.Parameter, paramNameMapping map[string]string, duplicates map[string]bool) (any, bool) {
+	if duplicates[escapeParameterName(p.Name)] {
+		val, ok := args[paramArgName(p, duplicates)]
+		return val, ok
+	}
+	return getParameterValue(args, p.Name, paramNameMapping)
+}
+
+// duplicateParamsNote documents in a tool description how the disambiguated arguments of
+// an operation map back to its parameters, and warns about them once at registration.
+func duplicateParamsNote(op OpenAPIOperation) string {
+	duplicates := duplicateParamNames(op.Parameters)
+	if len(duplicates) == 0 {
+		return ""
+	}
+	byName := map[string][]string{}
+	for _, paramRef := range op.Parameters {
+		if paramRef == nil || paramRef.Value == nil {
+			continue
+		}
+		p := paramRef.Value
+		if duplicates[escapeParameterName(p.Name)] {
+			byName[p.Name] = append(byName[p.Name], fmt.Sprintf("`%s` (%s)", paramArgName(p, duplicates), p.In))
+		}
+	}
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var note strings.Builder
+	note.WriteString("\n\nDUPLICATE PARAMETER NAMES:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "[WARN] Operation %s declares parameter '%s' in several locations; it is exposed as %s\n",
+			op.OperationID, name, strings.Join(byName[name], ", "))
+		fmt.Fprintf(&note, "\n- `%s` is declared in several locations; pass it as %s", name, strings.Join(byName[name], " or "))
+	}
+	return note.String()
+}
@@ -0,0 +1,81 @@
+package openapi2mcp
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const duplicateParamsSpec = `
+openapi: 3.0.0
+info:
+  title: Items
+  version: 1.0.0
+paths:
+  /items/{id}:
+    get:
+      operationId: getItem
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: string
+        - name: id
+          in: query
+          description: Revision to fetch.
+          schema:
+            type: string
+        - name: verbose
+          in: query
+          schema:
+            type: boolean
+      responses:
+        '200':
+          description: ok
+`
+
+func TestDuplicateParamNamesDisambiguated(t *testing.T) {
+	doc, err := LoadOpenAPISpecFromString(duplicateParamsSpec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	op := ExtractOpenAPIOperations(doc)[0]
+	schema := BuildInputSchemaWithContext(op.Parameters, op.RequestBody, doc)
+	props := schema["properties"].(map[string]any)
+	for _, name := range []string{"id_path", "id_query", "verbose"} {
+		if _, ok := props[name]; !ok {
+			t.Errorf("expected property %s, got %v", name, props)
+		}
+	}
+	if _, ok := props["id"]; ok {
+		t.Error("expected no collapsed id property")
+	}
+	if desc := props["id_query"].(map[string]any)["description"]; desc != "The query parameter 'id'. Revision to fetch." {
+		t.Errorf("unexpected description %q", desc)
+	}
+	if required := schema["required"].([]string); len(required) != 1 || required[0] != "id_path" {
+		t.Errorf("expected id_path to be required, got %v", required)
+	}
+}
+
+func TestDuplicateParamNamesMappedAtCallTime(t *testing.T) {
+	var gotPath, gotQuery string
+	server := newTestServer(t, duplicateParamsSpec, nil, func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery = r.URL.Path, r.URL.RawQuery
+		w.Write([]byte(`{}`))
+	})
+	for _, tool := range server.ListTools() {
+		if tool.Name == "getItem" && !strings.Contains(tool.Description, "`id` is declared in several locations; pass it as `id_path` (path) or `id_query` (query)") {
+			t.Errorf("expected the description to document the mapping, got %q", tool.Description)
+		}
+	}
+
+	res := callToolForTest(t, server, "getItem", map[string]any{"id_path": "42", "id_query": "7", "verbose": true})
+	if res.IsError {
+		t.Fatalf("unexpected error: %+v", res)
+	}
+	if gotPath != "/items/42" || gotQuery != "id=7&verbose=true" {
+		t.Errorf("expected /items/42?id=7&verbose=true, got %s?%s", gotPath, gotQuery)
+	}
+}
@@ -0,0 +1,53 @@
+// empty_response.go
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+// isEmptyResponse reports whether a successful upstream response has no body to show,
+// such as 204 No Content or a 202 Accepted with an empty body.
+func isEmptyResponse(statusCode int, body []byte) bool {
+	return statusCode == http.StatusNoContent || statusCode == http.StatusResetContent || len(body) == 0
+}
+
+// emptyResponseResult is the structured success result for a response without a body.
+// The HTTP status is also set in the result metadata as "httpStatus".
+func emptyResponseResult(op OpenAPIOperation, method, fullURL string, resp *http.Response) *mcp.CallToolResult {
+	resultObj := map[string]any{
+		"type":        "api_response",
+		"success":     true,
+		"http_status": resp.StatusCode,
+		"status_text": http.StatusText(resp.StatusCode),
+		"body":        nil,
+		"message":     fmt.Sprintf("%s %s succeeded with HTTP %d %s and returned no content.", method, fullURL, resp.StatusCode, http.StatusText(resp.StatusCode)),
+		"operation": map[string]any{
+			"id":      op.OperationID,
+			"summary": op.Summary,
+		},
+	}
+	// A created or accepted resource may only be identified by its Location
+	if location := resp.Header.Get("Location"); location != "" {
+		resultObj["location"] = location
+	}
+	resultJSON, _ := json.MarshalIndent(resultObj, "", "  ")
+	res := &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: string(resultJSON),
+			},
+		},
+		OutputFormat: "structured",
+		OutputType:   "json",
+	}
+	res.Meta = map[string]any{
+		"httpStatus": resp.StatusCode,
+		"empty":      true,
+	}
+	return res
+}
@@ -0,0 +1,55 @@
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+func TestEmptyResponsesReturnStructuredSuccess(t *testing.T) {
+	server := newTestServer(t, mockUpstreamSpec, nil, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Location", "/pets/7")
+			w.WriteHeader(http.StatusAccepted)
+		}
+	})
+
+	tests := []struct {
+		tool     string
+		args     map[string]any
+		status   int
+		location string
+	}{
+		{tool: "deletePet", args: map[string]any{"id": 7}, status: http.StatusNoContent},
+		{tool: "getPet", args: map[string]any{"id": 7}, status: http.StatusAccepted, location: "/pets/7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tool, func(t *testing.T) {
+			res := callToolForTest(t, server, tt.tool, tt.args)
+			if res.IsError {
+				t.Fatalf("expected a success result, got error: %+v", res.Content)
+			}
+			if got, _ := res.Meta["httpStatus"].(int); got != tt.status {
+				t.Errorf("expected httpStatus %d in metadata, got %v", tt.status, res.Meta["httpStatus"])
+			}
+			if res.Meta["empty"] != true {
+				t.Errorf("expected empty=true in metadata, got %v", res.Meta["empty"])
+			}
+			var body map[string]any
+			if err := json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &body); err != nil {
+				t.Fatalf("expected JSON result text: %v", err)
+			}
+			if body["http_status"] != float64(tt.status) || body["body"] != nil {
+				t.Errorf("unexpected result body: %v", body)
+			}
+			if tt.location != "" && body["location"] != tt.location {
+				t.Errorf("expected location %q, got %v", tt.location, body["location"])
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// enumErrorMessage describes an argument that is not one of the values its schema allows:
+// it lists the allowed values and, when one is close to the given value, suggests it
+// ("Did you mean 'metric'?"). It returns "" when the schema of field has no enum.
+func enumErrorMessage(schema map[string]any, field string, value any) string {
+	obj := schemaAtField(schema, field)
+	allowed, _ := obj["enum"].([]any)
+	if len(allowed) == 0 {
+		return ""
+	}
+	names := make([]string, len(allowed))
+	for i, v := range allowed {
+		names[i] = enumValueString(v)
+	}
+	msg := fmt.Sprintf("Invalid value %s for '%s'. Allowed values: %s.", quoteEnumValue(value), field, strings.Join(names, ", "))
+	if given, ok := value.(string); ok {
+		if match := closestEnumValue(given, names); match != "" {
+			msg += " Did you mean '" + match + "'?"
+		}
+	}
+	return msg
+}
+
+// schemaAtField returns the schema of a gojsonschema field path such as "requestBody.units"
+// or "tags.0", or nil when the path leaves the schema.
+func schemaAtField(schema map[string]any, field string) map[string]any {
+	obj := schema
+	if field == "" || field == "(root)" {
+		return obj
+	}
+	for _, part := range strings.Split(field, ".") {
+		if obj == nil {
+			return nil
+		}
+		if _, err := strconv.Atoi(part); err == nil {
+			if items, ok := obj["items"].(map[string]any); ok {
+				obj = items
+				continue
+			}
+		}
+		props, _ := obj["properties"].(map[string]any)
+		obj, _ = props[part].(map[string]any)
+	}
+	return obj
+}
+
+// closestEnumValue returns the allowed value closest to given: one that only differs in case
+// or separators, or else the one with the smallest edit distance, if that distance is at most
+// a third of its length. It returns "" when no value is close.
+func closestEnumValue(given string, allowed []string) string {
+	norm := normalizeArgName(given)
+	for _, a := range allowed {
+		if normalizeArgName(a) == norm {
+			return a
+		}
+	}
+	best, bestDist := "", -1
+	for _, a := range allowed {
+		d := levenshtein(strings.ToLower(given), strings.ToLower(a))
+		if d*3 > utf8.RuneCountInString(a) {
+			continue
+		}
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = a, d
+		}
+	}
+	if best == "" {
+		// A prefix of a single allowed value: "celsi" for "celsius"
+		for _, a := range allowed {
+			if len(norm) >= 3 && strings.HasPrefix(normalizeArgName(a), norm) {
+				if best != "" {
+					return ""
+				}
+				best = a
+			}
+		}
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between a and b, in runes.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func enumValueString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	out, _ := json.Marshal(v)
+	return string(out)
+}
+
+func quoteEnumValue(v any) string {
+	if s, ok := v.(string); ok {
+		return "'" + s + "'"
+	}
+	out, _ := json.Marshal(v)
+	return string(out)
+}
@@ -0,0 +1,67 @@
+package openapi2mcp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+const enumSpec = `
+openapi: 3.0.0
+info:
+  title: Weather
+  version: 1.0.0
+paths:
+  /forecast:
+    get:
+      operationId: getForecast
+      parameters:
+        - name: units
+          in: query
+          schema:
+            type: string
+            enum: [metric, imperial, standard]
+        - name: days
+          in: query
+          schema:
+            type: integer
+            enum: [1, 3, 7]
+      responses:
+        '200':
+          description: ok
+`
+
+func TestClosestEnumValue(t *testing.T) {
+	allowed := []string{"metric", "imperial", "standard", "in_progress"}
+	cases := map[string]string{
+		"metirc":     "metric",
+		"Metric":     "metric",
+		"inProgress": "in_progress",
+		"imperal":    "imperial",
+		"stand":      "standard",
+		"kelvin":     "",
+		"x":          "",
+	}
+	for given, want := range cases {
+		if got := closestEnumValue(given, allowed); got != want {
+			t.Errorf("closestEnumValue(%q) = %q, want %q", given, got, want)
+		}
+	}
+}
+
+func TestEnumErrorSuggestsClosestValue(t *testing.T) {
+	server := newTestServer(t, enumSpec, nil, nil)
+
+	res := callToolForTest(t, server, "getForecast", map[string]any{"units": "metirc"})
+	text := res.Content[0].(mcp.TextContent).Text
+	if !res.IsError || !strings.Contains(text, "Allowed values: metric, imperial, standard.") || !strings.Contains(text, "Did you mean 'metric'?") {
+		t.Errorf("expected the allowed values and a suggestion, got %q", text)
+	}
+
+	res = callToolForTest(t, server, "getForecast", map[string]any{"days": 5})
+	text = res.Content[0].(mcp.TextContent).Text
+	if !res.IsError || !strings.Contains(text, "Invalid value 5 for 'days'. Allowed values: 1, 3, 7.") || strings.Contains(text, "Did you mean") {
+		t.Errorf("expected the allowed values without a suggestion, got %q", text)
+	}
+}
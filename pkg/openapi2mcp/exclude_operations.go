@@ -0,0 +1,105 @@
+package openapi2mcp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// excludeOperationsExtension is the root OpenAPI extension listing patterns of operations to
+// strip when the spec is loaded (`x-mcp-exclude-operations: ["DELETE /admin/*", "re:^internal"]`).
+const excludeOperationsExtension = "x-mcp-exclude-operations"
+
+// ParseOperationPatterns splits a comma or newline separated list of operation patterns, as
+// given on the command line, dropping empty entries.
+func ParseOperationPatterns(s string) []string {
+	var patterns []string
+	for _, p := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// SpecExcludedOperations returns the operation patterns of a spec's x-mcp-exclude-operations
+// extension, a list or a comma-separated string.
+func SpecExcludedOperations(doc *openapi3.T) []string {
+	if doc == nil {
+		return nil
+	}
+	switch v := doc.Extensions[excludeOperationsExtension].(type) {
+	case string:
+		return ParseOperationPatterns(v)
+	case []any:
+		var patterns []string
+		for _, p := range v {
+			if s, ok := p.(string); ok && strings.TrimSpace(s) != "" {
+				patterns = append(patterns, strings.TrimSpace(s))
+			}
+		}
+		return patterns
+	}
+	return nil
+}
+
+// compileOperationPatterns compiles operation patterns to case-insensitive regular
+// expressions. A pattern prefixed with "re:" is a regular expression; any other pattern is
+// a glob, where * matches any characters, slashes included, and ? matches one.
+func compileOperationPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		expr := ""
+		if re, ok := strings.CutPrefix(p, "re:"); ok {
+			expr = "(?i)" + re
+		} else {
+			expr = "(?i)^" + strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(regexp.QuoteMeta(p)) + "$"
+		}
+		r, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid operation pattern %q: %v", p, err)
+		}
+		compiled = append(compiled, r)
+	}
+	return compiled, nil
+}
+
+// ExcludeOperations removes the operations matching any of patterns from doc and returns the
+// number removed. Patterns are matched against the operationId and against the method and
+// path, e.g. "deleteUser", "DELETE /admin/*" or "re:^internal", ignoring case; see
+// ParseOperationPatterns. Paths left without operations are removed too.
+func ExcludeOperations(doc *openapi3.T, patterns []string) (int, error) {
+	if doc == nil || doc.Paths == nil || len(patterns) == 0 {
+		return 0, nil
+	}
+	compiled, err := compileOperationPatterns(patterns)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for path, item := range doc.Paths.Map() {
+		matched := 0
+		for method, op := range item.Operations() {
+			if operationMatches(compiled, op.OperationID, method+" "+path) {
+				item.SetOperation(method, nil)
+				matched++
+			}
+		}
+		if matched > 0 && len(item.Operations()) == 0 {
+			doc.Paths.Delete(path)
+		}
+		removed += matched
+	}
+	return removed, nil
+}
+
+func operationMatches(patterns []*regexp.Regexp, operationID, methodPath string) bool {
+	for _, p := range patterns {
+		if (operationID != "" && p.MatchString(operationID)) || p.MatchString(methodPath) {
+			return true
+		}
+	}
+	return false
+}
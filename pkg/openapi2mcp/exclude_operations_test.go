@@ -0,0 +1,129 @@
+package openapi2mcp
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const adminSpec = `
+openapi: 3.0.0
+info:
+  title: Shop
+  version: 1.0.0
+paths:
+  /products:
+    get:
+      operationId: listProducts
+      responses:
+        '200': {description: ok}
+    delete:
+      operationId: purgeProducts
+      responses:
+        '204': {description: deleted}
+  /admin/users/{id}:
+    get:
+      operationId: adminGetUser
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: string}}
+      responses:
+        '200': {description: ok}
+  /internal/metrics:
+    get:
+      operationId: InternalMetrics
+      responses:
+        '200': {description: ok}
+`
+
+func operationIDs(t *testing.T, spec string, patterns []string) ([]string, int) {
+	t.Helper()
+	doc, err := LoadOpenAPISpecFromString(spec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	n, err := ExcludeOperations(doc, patterns)
+	if err != nil {
+		t.Fatalf("ExcludeOperations: %v", err)
+	}
+	var ids []string
+	for _, op := range ExtractOpenAPIOperations(doc) {
+		ids = append(ids, op.OperationID)
+	}
+	sort.Strings(ids)
+	return ids, n
+}
+
+func TestExcludeOperations(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+	}{
+		{"operationId glob", []string{"admin*"}, []string{"InternalMetrics", "listProducts", "purgeProducts"}},
+		{"method and path glob across segments", []string{"get /admin/*"}, []string{"InternalMetrics", "listProducts", "purgeProducts"}},
+		{"method of every path", []string{"DELETE *"}, []string{"InternalMetrics", "adminGetUser", "listProducts"}},
+		{"regular expression", []string{"re:^internal"}, []string{"adminGetUser", "listProducts", "purgeProducts"}},
+		{"several patterns", []string{"admin*", "* /internal/*"}, []string{"listProducts", "purgeProducts"}},
+		{"no match", []string{"deleteEverything"}, []string{"InternalMetrics", "adminGetUser", "listProducts", "purgeProducts"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, n := operationIDs(t, adminSpec, tt.patterns)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+			if n != 4-len(tt.want) {
+				t.Errorf("expected %d operations removed, got %d", 4-len(tt.want), n)
+			}
+		})
+	}
+}
+
+func TestExcludeOperationsRemovesEmptyPaths(t *testing.T) {
+	doc, err := LoadOpenAPISpecFromString(adminSpec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	if _, err := ExcludeOperations(doc, []string{"adminGetUser", "purgeProducts"}); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Paths.Value("/admin/users/{id}") != nil {
+		t.Error("expected the path without operations to be removed")
+	}
+	if item := doc.Paths.Value("/products"); item == nil || item.Get == nil || item.Delete != nil {
+		t.Errorf("expected /products to keep only its GET, got %+v", item)
+	}
+}
+
+func TestExcludeOperationsInvalidRegexp(t *testing.T) {
+	doc, err := LoadOpenAPISpecFromString(adminSpec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	if _, err := ExcludeOperations(doc, []string{"re:admin("}); err == nil || !strings.Contains(err.Error(), "re:admin(") {
+		t.Errorf("expected an error naming the invalid pattern, got %v", err)
+	}
+}
+
+func TestSpecExcludedOperations(t *testing.T) {
+	for _, ext := range []string{
+		"x-mcp-exclude-operations: ['admin*', '* /internal/*']\n",
+		"x-mcp-exclude-operations: 'admin*, * /internal/*'\n",
+	} {
+		doc, err := LoadOpenAPISpecFromString(strings.Replace(adminSpec, "openapi: 3.0.0\n", "openapi: 3.0.0\n"+ext, 1))
+		if err != nil {
+			t.Fatalf("failed to load spec: %v", err)
+		}
+		if got := SpecExcludedOperations(doc); !reflect.DeepEqual(got, []string{"admin*", "* /internal/*"}) {
+			t.Errorf("%s: expected both patterns, got %v", strings.TrimSpace(ext), got)
+		}
+	}
+}
+
+func TestParseOperationPatterns(t *testing.T) {
+	got := ParseOperationPatterns(" DELETE *, ,re:^admin\nlistUsers ")
+	if want := []string{"DELETE *", "re:^admin", "listUsers"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,150 @@
+// feature_flags.go
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+)
+
+const (
+	// featureFlagExtension marks an operation as gated by one or more flags,
+	// e.g. `x-mcp-feature-flag: experimental-search`.
+	featureFlagExtension = "x-mcp-feature-flag"
+	// featureFlagsExtension is the root-level spec extension mapping flags to the environments
+	// they are enabled in, for specs loaded from files. Database specs use the feature_flags column.
+	featureFlagsExtension = "x-mcp-feature-flags"
+	// defaultEnvironment is assumed when ENVIRONMENT is not set.
+	defaultEnvironment = "production"
+)
+
+// FeatureFlags maps a flag name to the environments it is enabled in. "*" enables a flag everywhere.
+//
+//	{"experimental-search": ["staging", "development"], "bulk-export": ["*"]}
+type FeatureFlags map[string][]string
+
+// ServerEnvironment returns the environment feature flags are evaluated against,
+// from ENVIRONMENT (default "production").
+func ServerEnvironment() string {
+	if env := strings.ToLower(strings.TrimSpace(os.Getenv("ENVIRONMENT"))); env != "" {
+		return env
+	}
+	return defaultEnvironment
+}
+
+// ParseFeatureFlags parses the JSON stored in the feature_flags column. A flag may also be
+// set to true or false to enable or disable it in every environment.
+func ParseFeatureFlags(data string) (FeatureFlags, error) {
+	if strings.TrimSpace(data) == "" || strings.TrimSpace(data) == "null" {
+		return nil, nil
+	}
+	var raw map[string]any
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		return nil, fmt.Errorf("feature flags must be a JSON object mapping flags to environments: %v", err)
+	}
+	return featureFlagsFromMap(raw)
+}
+
+func featureFlagsFromMap(raw map[string]any) (FeatureFlags, error) {
+	flags := FeatureFlags{}
+	for flag, v := range raw {
+		switch val := v.(type) {
+		case bool:
+			if val {
+				flags[flag] = []string{"*"}
+			} else {
+				flags[flag] = nil
+			}
+		case string:
+			flags[flag] = []string{val}
+		case []any:
+			for _, item := range val {
+				env, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("feature flag %q: environments must be strings", flag)
+				}
+				flags[flag] = append(flags[flag], env)
+			}
+		case []string:
+			flags[flag] = val
+		default:
+			return nil, fmt.Errorf("feature flag %q: expected a list of environments or a boolean", flag)
+		}
+	}
+	return flags, nil
+}
+
+// Enabled reports whether flag is enabled in env. Unknown flags are disabled, so gated
+// operations stay hidden until a flag is explicitly turned on.
+func (f FeatureFlags) Enabled(flag, env string) bool {
+	for _, e := range f[flag] {
+		if e == "*" || strings.EqualFold(strings.TrimSpace(e), env) {
+			return true
+		}
+	}
+	return false
+}
+
+// specFeatureFlags returns the flags of a spec: the database row's feature_flags if set,
+// otherwise the x-mcp-feature-flags extension.
+func specFeatureFlags(doc *openapi3.T, dbSpec *models.OpenAPISpec) FeatureFlags {
+	if dbSpec != nil && dbSpec.FeatureFlags != nil {
+		flags, err := ParseFeatureFlags(*dbSpec.FeatureFlags)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] Ignoring feature flags of spec %s: %v\n", dbSpec.Name, err)
+			return nil
+		}
+		return flags
+	}
+	if doc != nil {
+		if raw, ok := doc.Extensions[featureFlagsExtension].(map[string]any); ok {
+			flags, err := featureFlagsFromMap(raw)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[WARN] Ignoring %s: %v\n", featureFlagsExtension, err)
+				return nil
+			}
+			return flags
+		}
+	}
+	return nil
+}
+
+// operationFeatureFlags returns the flags gating an operation, sorted.
+func operationFeatureFlags(op OpenAPIOperation) []string {
+	var names []string
+	switch v := op.Extensions[featureFlagExtension].(type) {
+	case string:
+		names = []string{v}
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				names = append(names, s)
+			}
+		}
+	case []string:
+		names = v
+	}
+	var flags []string
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			flags = append(flags, name)
+		}
+	}
+	sort.Strings(flags)
+	return flags
+}
+
+// operationEnabled reports whether every flag gating op is enabled in env.
+func operationEnabled(op OpenAPIOperation, flags FeatureFlags, env string) bool {
+	for _, flag := range operationFeatureFlags(op) {
+		if !flags.Enabled(flag, env) {
+			return false
+		}
+	}
+	return true
+}
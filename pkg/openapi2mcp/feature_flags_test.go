@@ -0,0 +1,62 @@
+package openapi2mcp
+
+import (
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+func TestParseFeatureFlags(t *testing.T) {
+	flags, err := ParseFeatureFlags(`{"search": ["staging", "Development"], "export": true, "legacy": false}`)
+	if err != nil {
+		t.Fatalf("ParseFeatureFlags: %v", err)
+	}
+	tests := []struct {
+		flag, env string
+		want      bool
+	}{
+		{"search", "staging", true},
+		{"search", "development", true},
+		{"search", "production", false},
+		{"export", "production", true},
+		{"legacy", "staging", false},
+		{"unknown", "staging", false},
+	}
+	for _, tt := range tests {
+		if got := flags.Enabled(tt.flag, tt.env); got != tt.want {
+			t.Errorf("Enabled(%q, %q) = %v, want %v", tt.flag, tt.env, got, tt.want)
+		}
+	}
+	if _, err := ParseFeatureFlags(`{"search": 1}`); err == nil {
+		t.Errorf("expected an error for a non-list flag value")
+	}
+}
+
+func TestFeatureFlagsHideOperationsByEnvironment(t *testing.T) {
+	doc, err := LoadOpenAPISpecFromString(mockUpstreamSpec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	doc.Paths.Value("/pets/{id}").Delete.Extensions = map[string]any{featureFlagExtension: "pet-deletion"}
+	flags := `{"pet-deletion": ["staging"]}`
+	dbSpec := &models.OpenAPISpec{Name: "pets", EndpointPath: "/pets", FeatureFlags: &flags}
+
+	for env, wantDelete := range map[string]bool{"staging": true, "production": false, "": false} {
+		t.Run("env="+env, func(t *testing.T) {
+			t.Setenv("ENVIRONMENT", env)
+			server := mcpserver.NewMCPServer("test", "0.0.1")
+			names := RegisterOpenAPITools(server, ExtractOpenAPIOperations(doc), doc, nil, dbSpec)
+			registered := map[string]bool{}
+			for _, name := range names {
+				registered[name] = true
+			}
+			if registered["deletePet"] != wantDelete {
+				t.Errorf("deletePet registered = %v, want %v", registered["deletePet"], wantDelete)
+			}
+			if !registered["listPets"] {
+				t.Errorf("ungated operations must always be registered")
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package openapi2mcp
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
+)
+
+const gatewaySpec = `
+openapi: 3.0.0
+x-mcp-gateway: rapidapi
+info:
+  title: Jokes
+  version: 1.0.0
+servers:
+  - url: https://jokes.p.rapidapi.com
+paths:
+  /random:
+    get:
+      operationId: getRandomJoke
+      parameters:
+        - name: X-RapidAPI-Host
+          in: header
+          required: true
+          schema:
+            type: string
+      responses:
+        '200':
+          description: ok
+`
+
+func TestGatewayProfileHeaders(t *testing.T) {
+	var got http.Header
+	t.Setenv("RAPIDAPI_KEY", "gateway-key")
+
+	server := newTestServer(t, gatewaySpec, nil, func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+		w.Write([]byte(`{}`))
+	})
+	for _, tool := range server.ListTools() {
+		if _, ok := tool.InputSchema.Properties["X-RapidAPI-Host"]; ok {
+			t.Error("expected the gateway host header not to be a tool argument")
+		}
+	}
+	if res := callToolForTest(t, server, "getRandomJoke", map[string]any{}); res.IsError {
+		t.Fatalf("unexpected error: %+v", res)
+	}
+	if got.Get("X-RapidAPI-Key") != "gateway-key" || got.Get("X-RapidAPI-Host") != "jokes.p.rapidapi.com" {
+		t.Errorf("expected the RapidAPI key and host headers, got %v", got)
+	}
+}
+
+func TestSpecGatewayProfileDetection(t *testing.T) {
+	doc, _ := LoadOpenAPISpecFromString(`
+openapi: 3.0.0
+info:
+  title: Orders
+  version: 1.0.0
+servers:
+  - url: https://contoso.azure-api.net/orders
+paths: {}
+`)
+	if p := auth.SpecGatewayProfile(doc, nil, "orders"); p == nil || p.Name != "azure-apim" {
+		t.Fatalf("expected the Azure APIM profile from the server host, got %+v", p)
+	}
+	t.Setenv("ORDERS_GATEWAY", "none")
+	if p := auth.SpecGatewayProfile(doc, nil, "orders"); p != nil {
+		t.Errorf("expected no profile with ORDERS_GATEWAY=none, got %+v", p)
+	}
+
+	auth.RegisterGatewayProfile(&auth.GatewayProfile{Name: "acme-gateway", KeyHeader: "X-Acme-Key"})
+	t.Setenv("ORDERS_GATEWAY", "acme-gateway")
+	if p := auth.SpecGatewayProfile(doc, nil, "orders"); p == nil || p.KeyHeader != "X-Acme-Key" {
+		t.Errorf("expected the registered profile, got %+v", p)
+	}
+}
@@ -183,5 +183,5 @@ func ServeHTTPLint(addr string, detailedSuggestions bool) error {
 
 	log.Printf("Starting OpenAPI validation/linting HTTP server on %s (validate & lint endpoints available)", addr)
 
-	return http.ListenAndServe(addr, mux)
+	return NewHTTPServer(addr, mux).ListenAndServe()
 }
@@ -0,0 +1,128 @@
+package openapi2mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+// Outcomes of journaled tool calls.
+const (
+	JournalAccepted    = "accepted"    // the call is running, or the server stopped before it finished
+	JournalCompleted   = "completed"   // the tool returned a result
+	JournalFailed      = "failed"      // the tool returned an error result, or failed
+	JournalInterrupted = "interrupted" // the server stopped while the call was running
+)
+
+// JournalEntry describes a tool call when it is accepted.
+type JournalEntry struct {
+	Endpoint  string
+	Tool      string
+	SessionID string
+	ArgNames  []string // argument names only; values may hold secrets
+}
+
+// CallJournal durably records tool calls that were accepted but have not finished, so a
+// restarted server can report calls that a crash or shutdown cut off instead of losing them.
+type CallJournal interface {
+	// Accept records a call before it runs and returns its journal ID.
+	Accept(entry JournalEntry) (int64, error)
+	// Finish records the outcome of an accepted call: JournalCompleted or JournalFailed.
+	Finish(id int64, status, errMsg string) error
+}
+
+var (
+	defaultCallJournalMu sync.RWMutex
+	defaultCallJournal   CallJournal
+)
+
+// SetDefaultCallJournal sets the journal used by tools generated without ToolGenOptions.CallJournal.
+// Nil turns journaling off, which is the default.
+func SetDefaultCallJournal(j CallJournal) {
+	defaultCallJournalMu.Lock()
+	defer defaultCallJournalMu.Unlock()
+	defaultCallJournal = j
+}
+
+// DefaultCallJournal returns the process-wide journal, or nil when journaling is off.
+func DefaultCallJournal() CallJournal {
+	defaultCallJournalMu.RLock()
+	defer defaultCallJournalMu.RUnlock()
+	return defaultCallJournal
+}
+
+// callJournalFor returns the journal tools of a spec record their calls in, or nil.
+func callJournalFor(opts *ToolGenOptions) CallJournal {
+	if opts != nil && opts.CallJournal != nil {
+		return opts.CallJournal
+	}
+	return DefaultCallJournal()
+}
+
+// journaledHandler records each call of a tool in the journal: accepted before it runs,
+// and completed or failed once it returns. A journal that fails to record a call is
+// logged, but does not fail the call.
+func journaledHandler(journal CallJournal, endpoint, tool string, next mcpserver.ToolHandlerFunc) mcpserver.ToolHandlerFunc {
+	if journal == nil {
+		return next
+	}
+	return func(ctx context.Context, req mcp.CallToolRequest) (res *mcp.CallToolResult, err error) {
+		entry := JournalEntry{Endpoint: endpoint, Tool: tool}
+		if session := mcpserver.ClientSessionFromContext(ctx); session != nil {
+			entry.SessionID = session.SessionID()
+		}
+		for name := range req.GetArguments() {
+			entry.ArgNames = append(entry.ArgNames, name)
+		}
+		sort.Strings(entry.ArgNames)
+
+		id, jerr := journal.Accept(entry)
+		if jerr != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] Failed to journal call of %s: %v\n", tool, jerr)
+			return next(ctx, req)
+		}
+		defer func() {
+			status, msg := JournalCompleted, ""
+			if r := recover(); r != nil {
+				journalFinish(journal, id, tool, JournalFailed, fmt.Sprintf("panic: %v", r))
+				panic(r)
+			}
+			switch {
+			case err != nil:
+				status, msg = JournalFailed, err.Error()
+			case res != nil && res.IsError:
+				status = JournalFailed
+				if text, ok := firstText(res); ok {
+					msg = text
+				}
+			}
+			journalFinish(journal, id, tool, status, msg)
+		}()
+		return next(ctx, req)
+	}
+}
+
+func journalFinish(journal CallJournal, id int64, tool, status, msg string) {
+	const maxJournalError = 500
+	if len(msg) > maxJournalError {
+		msg = msg[:maxJournalError]
+	}
+	if err := journal.Finish(id, status, msg); err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] Failed to journal outcome of %s call %d: %v\n", tool, id, err)
+	}
+}
+
+// firstText returns the first text content of a result.
+func firstText(res *mcp.CallToolResult) (string, bool) {
+	for _, c := range res.Content {
+		if text, ok := c.(mcp.TextContent); ok {
+			return text.Text, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,62 @@
+package openapi2mcp
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type memoryJournal struct {
+	mu       sync.Mutex
+	entries  []JournalEntry
+	statuses map[int64]string
+	errors   map[int64]string
+}
+
+func (j *memoryJournal) Accept(entry JournalEntry) (int64, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries = append(j.entries, entry)
+	id := int64(len(j.entries))
+	j.statuses[id] = JournalAccepted
+	return id, nil
+}
+
+func (j *memoryJournal) Finish(id int64, status, errMsg string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.statuses[id] = status
+	j.errors[id] = errMsg
+	return nil
+}
+
+func TestCallJournalRecordsOutcomes(t *testing.T) {
+	journal := &memoryJournal{statuses: map[int64]string{}, errors: map[int64]string{}}
+	server := newTestServer(t, mockUpstreamSpec, &ToolGenOptions{CallJournal: journal}, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/404") {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	})
+
+	callToolForTest(t, server, "listPets", map[string]any{"limit": 2, "after": "x"})
+	callToolForTest(t, server, "getPet", map[string]any{"id": "404"})
+	callToolForTest(t, server, "describe", map[string]any{})
+
+	if len(journal.entries) != 2 {
+		t.Fatalf("expected the two API tool calls to be journaled, got %+v", journal.entries)
+	}
+	first := journal.entries[0]
+	if first.Endpoint != "pets" || first.Tool != "listPets" || strings.Join(first.ArgNames, ",") != "after,limit" {
+		t.Errorf("unexpected journal entry: %+v", first)
+	}
+	if journal.statuses[1] != JournalCompleted {
+		t.Errorf("expected the successful call to be completed, got %q", journal.statuses[1])
+	}
+	if journal.statuses[2] != JournalFailed || journal.errors[2] == "" {
+		t.Errorf("expected the failed call to be journaled with its error, got %q %q", journal.statuses[2], journal.errors[2])
+	}
+}
@@ -0,0 +1,203 @@
+// latency.go
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyWindow is the number of recent upstream calls latency stats are computed over.
+const DefaultLatencyWindow = 100
+
+// LatencyStats summarizes the recent upstream latency of one tool.
+type LatencyStats struct {
+	Endpoint  string    `json:"endpoint"`
+	Tool      string    `json:"tool"`
+	Calls     int64     `json:"calls"`
+	LastMs    int64     `json:"last_ms"`
+	P50Ms     int64     `json:"p50_ms"`
+	P95Ms     int64     `json:"p95_ms"`
+	MaxMs     int64     `json:"max_ms"`
+	Hint      string    `json:"hint"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// toolLatency is a ring buffer of the most recent call durations of a tool.
+type toolLatency struct {
+	Samples   []int64   `json:"samples"`
+	Next      int       `json:"next"`
+	Calls     int64     `json:"calls"`
+	LastMs    int64     `json:"last_ms"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LatencyTracker keeps rolling upstream latency stats per endpoint and tool. It outlives
+// reloads, so stats are kept when a spec is remounted.
+type LatencyTracker struct {
+	window int
+	mu     sync.RWMutex
+	tools  map[string]*toolLatency // endpoint + "/" + tool
+}
+
+// NewLatencyTracker creates a tracker computing stats over the last window calls of each tool.
+func NewLatencyTracker(window int) *LatencyTracker {
+	if window <= 0 {
+		window = DefaultLatencyWindow
+	}
+	return &LatencyTracker{window: window, tools: make(map[string]*toolLatency)}
+}
+
+var (
+	defaultLatencyTracker     *LatencyTracker
+	defaultLatencyTrackerOnce sync.Once
+)
+
+// DefaultLatencyTracker returns the process-wide tracker. If MCP_LATENCY_FILE is set,
+// stats saved there by a previous run are loaded, so hints are available right after a restart.
+func DefaultLatencyTracker() *LatencyTracker {
+	defaultLatencyTrackerOnce.Do(func() {
+		defaultLatencyTracker = NewLatencyTracker(DefaultLatencyWindow)
+		if path := os.Getenv("MCP_LATENCY_FILE"); path != "" {
+			if err := defaultLatencyTracker.LoadFile(path); err != nil && !os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "[WARN] Failed to load latency stats from %s: %v\n", path, err)
+			}
+		}
+	})
+	return defaultLatencyTracker
+}
+
+func latencyKey(endpoint, tool string) string {
+	return endpoint + "/" + tool
+}
+
+// Record adds the duration of one upstream call.
+func (t *LatencyTracker) Record(endpoint, tool string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := latencyKey(endpoint, tool)
+	tl := t.tools[key]
+	if tl == nil {
+		tl = &toolLatency{}
+		t.tools[key] = tl
+	}
+	ms := d.Milliseconds()
+	if len(tl.Samples) < t.window {
+		tl.Samples = append(tl.Samples, ms)
+	} else {
+		tl.Samples[tl.Next%len(tl.Samples)] = ms
+	}
+	tl.Next = (tl.Next + 1) % t.window
+	tl.Calls++
+	tl.LastMs = ms
+	tl.UpdatedAt = time.Now()
+}
+
+// Stats returns the stats of a tool, and false if it has not been called yet.
+func (t *LatencyTracker) Stats(endpoint, tool string) (LatencyStats, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	tl := t.tools[latencyKey(endpoint, tool)]
+	if tl == nil || len(tl.Samples) == 0 {
+		return LatencyStats{}, false
+	}
+	return tl.stats(endpoint, tool), true
+}
+
+// All returns the stats of every tool, slowest (by p95) first.
+func (t *LatencyTracker) All() []LatencyStats {
+	t.mu.RLock()
+	all := make([]LatencyStats, 0, len(t.tools))
+	for key, tl := range t.tools {
+		if len(tl.Samples) == 0 {
+			continue
+		}
+		endpoint, tool := splitLatencyKey(key)
+		all = append(all, tl.stats(endpoint, tool))
+	}
+	t.mu.RUnlock()
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].P95Ms != all[j].P95Ms {
+			return all[i].P95Ms > all[j].P95Ms
+		}
+		return latencyKey(all[i].Endpoint, all[i].Tool) < latencyKey(all[j].Endpoint, all[j].Tool)
+	})
+	return all
+}
+
+func splitLatencyKey(key string) (string, string) {
+	i := strings.LastIndexByte(key, '/')
+	return key[:i], key[i+1:]
+}
+
+func (tl *toolLatency) stats(endpoint, tool string) LatencyStats {
+	sorted := append([]int64(nil), tl.Samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	p50 := percentile(sorted, 50)
+	return LatencyStats{
+		Endpoint:  endpoint,
+		Tool:      tool,
+		Calls:     tl.Calls,
+		LastMs:    tl.LastMs,
+		P50Ms:     p50,
+		P95Ms:     percentile(sorted, 95),
+		MaxMs:     sorted[len(sorted)-1],
+		Hint:      "typically ~" + formatLatency(p50),
+		UpdatedAt: tl.UpdatedAt,
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted samples.
+func percentile(sorted []int64, p int) int64 {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// formatLatency renders a duration in milliseconds for humans, e.g. "350ms" or "2.1s".
+func formatLatency(ms int64) string {
+	if ms < 1000 {
+		return strconv.FormatInt(ms, 10) + "ms"
+	}
+	return strconv.FormatFloat(float64(ms)/1000, 'f', 1, 64) + "s"
+}
+
+// SaveFile writes the tracker's samples to path as JSON.
+func (t *LatencyTracker) SaveFile(path string) error {
+	t.mu.RLock()
+	data, err := json.Marshal(t.tools)
+	t.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// LoadFile restores samples saved with SaveFile.
+func (t *LatencyTracker) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	tools := map[string]*toolLatency{}
+	if err := json.Unmarshal(data, &tools); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, tl := range tools {
+		if len(tl.Samples) > t.window {
+			tl.Samples = tl.Samples[len(tl.Samples)-t.window:]
+		}
+		tl.Next %= t.window
+		t.tools[key] = tl
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package openapi2mcp
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLatencyTracker(t *testing.T) {
+	tracker := NewLatencyTracker(10)
+	if _, ok := tracker.Stats("pets", "getPet"); ok {
+		t.Fatalf("expected no stats before the first call")
+	}
+	for i := 1; i <= 20; i++ {
+		tracker.Record("pets", "getPet", time.Duration(i*100)*time.Millisecond)
+	}
+	tracker.Record("pets", "listPets", 50*time.Millisecond)
+
+	stats, ok := tracker.Stats("pets", "getPet")
+	if !ok {
+		t.Fatalf("expected stats for getPet")
+	}
+	// Only the last 10 calls (1.1s..2s) are in the window
+	if stats.Calls != 20 || stats.LastMs != 2000 || stats.P50Ms != 1500 || stats.P95Ms != 2000 || stats.MaxMs != 2000 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if stats.Hint != "typically ~1.5s" {
+		t.Errorf("unexpected hint %q", stats.Hint)
+	}
+
+	all := tracker.All()
+	if len(all) != 2 || all[0].Tool != "getPet" || all[1].Hint != "typically ~50ms" {
+		t.Errorf("expected slowest tool first, got %+v", all)
+	}
+
+	path := filepath.Join(t.TempDir(), "latency.json")
+	if err := tracker.SaveFile(path); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+	restored := NewLatencyTracker(10)
+	if err := restored.LoadFile(path); err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	if got, _ := restored.Stats("pets", "getPet"); got.P50Ms != stats.P50Ms || got.Calls != stats.Calls {
+		t.Errorf("expected restored stats to match, got %+v", got)
+	}
+}
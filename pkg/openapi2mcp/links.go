@@ -0,0 +1,316 @@
+// links.go
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+// operationLink is an OpenAPI response link resolved to the tool it points at.
+type operationLink struct {
+	Name        string
+	Status      string // response code the link is declared on: "201", "2XX" or "default"
+	Tool        string
+	Description string
+	Parameters  map[string]any // target argument name -> literal or runtime expression
+	RequestBody any
+}
+
+// operationLinks collects the links declared on the responses of op, with each target
+// resolved to its tool name. Links whose target cannot be found in the spec are skipped.
+func operationLinks(doc *openapi3.T, op OpenAPIOperation, opts *ToolGenOptions) []operationLink {
+	if doc == nil || doc.Paths == nil {
+		return nil
+	}
+	pathItem := doc.Paths.Value(op.Path)
+	if pathItem == nil {
+		return nil
+	}
+	specOp := pathItem.GetOperation(strings.ToUpper(op.Method))
+	if specOp == nil || specOp.Responses == nil {
+		return nil
+	}
+	var links []operationLink
+	for status, respRef := range specOp.Responses.Map() {
+		if respRef == nil || respRef.Value == nil {
+			continue
+		}
+		for linkName, linkRef := range respRef.Value.Links {
+			if linkRef == nil || linkRef.Value == nil {
+				continue
+			}
+			tool := linkTargetTool(doc, linkRef.Value)
+			if tool == "" {
+				fmt.Fprintf(os.Stderr, "[WARN] Link %q on %s %s response %s has no resolvable target operation; skipping\n", linkName, op.Method, op.Path, status)
+				continue
+			}
+			if opts != nil && opts.NameFormat != nil {
+				tool = opts.NameFormat(tool)
+			}
+			links = append(links, operationLink{
+				Name:        linkName,
+				Status:      strings.ToUpper(status),
+				Tool:        tool,
+				Description: linkRef.Value.Description,
+				Parameters:  linkRef.Value.Parameters,
+				RequestBody: linkRef.Value.RequestBody,
+			})
+		}
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].Status != links[j].Status {
+			return links[i].Status < links[j].Status
+		}
+		return links[i].Name < links[j].Name
+	})
+	return links
+}
+
+// linkTargetTool returns the operationId a link points at, either directly or through a
+// local operationRef such as "#/paths/~1pets~1{id}/get".
+func linkTargetTool(doc *openapi3.T, link *openapi3.Link) string {
+	if link.OperationID != "" {
+		return link.OperationID
+	}
+	ref := link.OperationRef
+	if !strings.HasPrefix(ref, "#/paths/") {
+		return ""
+	}
+	rest := strings.TrimPrefix(ref, "#/paths/")
+	idx := strings.LastIndex(rest, "/")
+	if idx < 0 {
+		return ""
+	}
+	path := unescapePointerToken(rest[:idx])
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		path = unescaped
+	}
+	pathItem := doc.Paths.Value(path)
+	if pathItem == nil {
+		return ""
+	}
+	target := pathItem.GetOperation(strings.ToUpper(rest[idx+1:]))
+	if target == nil {
+		return ""
+	}
+	return target.OperationID
+}
+
+// linksForStatus picks the links that apply to an actual response status. An exact code
+// wins over a range such as "2XX", which wins over "default".
+func linksForStatus(links []operationLink, statusCode int) []operationLink {
+	exact := strconv.Itoa(statusCode)
+	rangeCode := fmt.Sprintf("%dXX", statusCode/100)
+	for _, status := range []string{exact, rangeCode, "DEFAULT"} {
+		var matched []operationLink
+		for _, l := range links {
+			if l.Status == status {
+				matched = append(matched, l)
+			}
+		}
+		if len(matched) > 0 {
+			return matched
+		}
+	}
+	return nil
+}
+
+// linkContext holds what runtime expressions in a link can refer to.
+type linkContext struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Args       map[string]any
+	Header     http.Header
+	Body       any // decoded JSON response body, nil if not JSON
+}
+
+// resolveLinks evaluates the links that apply to a response and returns them in the
+// shape reported under the "_links" result metadata.
+func resolveLinks(links []operationLink, lc linkContext) []map[string]any {
+	var out []map[string]any
+	for _, l := range linksForStatus(links, lc.StatusCode) {
+		arguments := map[string]any{}
+		var unresolved []string
+		for param, expr := range l.Parameters {
+			value, ok := evalLinkValue(expr, lc)
+			if !ok {
+				unresolved = append(unresolved, param)
+				continue
+			}
+			arguments[linkArgName(param)] = value
+		}
+		if l.RequestBody != nil {
+			if value, ok := evalLinkValue(l.RequestBody, lc); ok {
+				arguments["requestBody"] = value
+			} else {
+				unresolved = append(unresolved, "requestBody")
+			}
+		}
+		entry := map[string]any{
+			"name":      l.Name,
+			"tool":      l.Tool,
+			"arguments": arguments,
+		}
+		if l.Description != "" {
+			entry["description"] = l.Description
+		}
+		if len(unresolved) > 0 {
+			sort.Strings(unresolved)
+			entry["unresolved"] = unresolved
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+// linkArgName strips the optional location qualifier from a link parameter name,
+// e.g. "path.id" becomes "id".
+func linkArgName(param string) string {
+	for _, prefix := range []string{"path.", "query.", "header.", "cookie."} {
+		if strings.HasPrefix(param, prefix) {
+			return strings.TrimPrefix(param, prefix)
+		}
+	}
+	return param
+}
+
+// evalLinkValue evaluates a link parameter: a whole runtime expression keeps the type of
+// the value it selects, a string with embedded {$expr} parts is interpolated, and any
+// other value is a literal.
+func evalLinkValue(v any, lc linkContext) (any, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return v, true
+	}
+	if strings.HasPrefix(s, "$") {
+		return evalRuntimeExpression(s, lc)
+	}
+	if !strings.Contains(s, "{$") {
+		return s, true
+	}
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "{$")
+		if start < 0 {
+			b.WriteString(s)
+			break
+		}
+		end := strings.Index(s[start:], "}")
+		if end < 0 {
+			return nil, false
+		}
+		b.WriteString(s[:start])
+		value, ok := evalRuntimeExpression(s[start+1:start+end], lc)
+		if !ok {
+			return nil, false
+		}
+		b.WriteString(fmt.Sprintf("%v", value))
+		s = s[start+end+1:]
+	}
+	return b.String(), true
+}
+
+// evalRuntimeExpression evaluates an OpenAPI runtime expression such as
+// "$response.body#/id", "$response.header.Location" or "$request.path.petId".
+func evalRuntimeExpression(expr string, lc linkContext) (any, bool) {
+	switch {
+	case expr == "$url":
+		return lc.URL, true
+	case expr == "$method":
+		return lc.Method, true
+	case expr == "$statusCode":
+		return lc.StatusCode, true
+	case strings.HasPrefix(expr, "$response.header."):
+		value := lc.Header.Get(strings.TrimPrefix(expr, "$response.header."))
+		return value, value != ""
+	case expr == "$response.body" || strings.HasPrefix(expr, "$response.body#"):
+		if lc.Body == nil {
+			return nil, false
+		}
+		return jsonPointerGet(lc.Body, strings.TrimPrefix(strings.TrimPrefix(expr, "$response.body"), "#"))
+	case expr == "$request.body" || strings.HasPrefix(expr, "$request.body#"):
+		body, ok := lc.Args["requestBody"]
+		if !ok {
+			return nil, false
+		}
+		return jsonPointerGet(body, strings.TrimPrefix(strings.TrimPrefix(expr, "$request.body"), "#"))
+	case strings.HasPrefix(expr, "$request."):
+		// $request.path.x, $request.query.x and $request.header.x all map onto tool arguments
+		rest := strings.TrimPrefix(expr, "$request.")
+		idx := strings.Index(rest, ".")
+		if idx < 0 {
+			return nil, false
+		}
+		value, ok := lc.Args[rest[idx+1:]]
+		return value, ok
+	}
+	return nil, false
+}
+
+// jsonPointerGet selects the value at an RFC 6901 JSON pointer within decoded JSON.
+func jsonPointerGet(doc any, pointer string) (any, bool) {
+	if pointer == "" {
+		return doc, true
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, false
+	}
+	cur := doc
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = unescapePointerToken(token)
+		switch node := cur.(type) {
+		case map[string]any:
+			next, ok := node[token]
+			if !ok {
+				return nil, false
+			}
+			cur = next
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			cur = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func unescapePointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
+
+// withLinks records resolved links in the "_links" result metadata and suggests each
+// linked call as a next step.
+func withLinks(res *mcp.CallToolResult, links []operationLink, lc linkContext) *mcp.CallToolResult {
+	if len(links) == 0 || lc.StatusCode < 200 || lc.StatusCode >= 300 {
+		return res
+	}
+	resolved := resolveLinks(links, lc)
+	if len(resolved) == 0 {
+		return res
+	}
+	if res.Meta == nil {
+		res.Meta = map[string]any{}
+	}
+	res.Meta["_links"] = resolved
+	var steps []string
+	for _, l := range resolved {
+		argsJSON, _ := json.Marshal(l["arguments"])
+		steps = append(steps, fmt.Sprintf("call %s %s", l["tool"], argsJSON))
+	}
+	res.NextSteps = append(steps, res.NextSteps...)
+	return res
+}
@@ -0,0 +1,173 @@
+package openapi2mcp
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const linksSpec = `
+openapi: 3.0.0
+info:
+  title: Links API
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+        - name: kind
+          in: query
+          schema:
+            type: string
+      responses:
+        '200':
+          description: ok
+    post:
+      operationId: createPet
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                kind:
+                  type: string
+      responses:
+        '201':
+          description: created
+          links:
+            GetCreatedPet:
+              operationId: getPet
+              description: Fetch the pet that was just created
+              parameters:
+                id: $response.body#/id
+            ListSameKind:
+              operationRef: '#/paths/~1pets/get'
+              parameters:
+                query.kind: $request.body#/kind
+            GetOwner:
+              operationId: getPet
+              parameters:
+                id: $response.body#/owner/id
+        default:
+          description: error
+          links:
+            ListPets:
+              operationId: listPets
+  /pets/{id}:
+    get:
+      operationId: getPet
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: ok
+`
+
+func TestOperationLinksResolveTargets(t *testing.T) {
+	doc, err := LoadOpenAPISpecFromString(linksSpec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	var createPet OpenAPIOperation
+	for _, op := range ExtractOpenAPIOperations(doc) {
+		if op.OperationID == "createPet" {
+			createPet = op
+		}
+	}
+	links := operationLinks(doc, createPet, &ToolGenOptions{NameFormat: strings.ToLower})
+	if len(links) != 4 {
+		t.Fatalf("expected 4 links, got %d: %+v", len(links), links)
+	}
+	tools := map[string]string{}
+	for _, l := range links {
+		tools[l.Name] = l.Tool
+	}
+	if tools["GetCreatedPet"] != "getpet" || tools["ListSameKind"] != "listpets" {
+		t.Errorf("unexpected link targets: %v", tools)
+	}
+	if got := linksForStatus(links, 201); len(got) != 3 {
+		t.Errorf("expected the 201 links for a 201 response, got %+v", got)
+	}
+	if got := linksForStatus(links, 200); len(got) != 1 || got[0].Name != "ListPets" {
+		t.Errorf("expected the default link for a 200 response, got %+v", got)
+	}
+}
+
+func TestLinksInToolResult(t *testing.T) {
+	server := newTestServer(t, linksSpec, nil, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": 42, "name": "Rex"}`))
+	})
+
+	res := callToolForTest(t, server, "createPet", map[string]any{
+		"requestBody": map[string]any{"kind": "dog"},
+		"__confirmed": true,
+	})
+	if res.IsError {
+		t.Fatalf("expected a success result, got error: %+v", res.Content)
+	}
+	links, ok := res.Meta["_links"].([]map[string]any)
+	if !ok || len(links) != 3 {
+		t.Fatalf("expected 3 links in _links metadata, got %#v", res.Meta["_links"])
+	}
+	byName := map[string]map[string]any{}
+	for _, l := range links {
+		byName[l["name"].(string)] = l
+	}
+
+	created := byName["GetCreatedPet"]
+	if created["tool"] != "getPet" || created["description"] != "Fetch the pet that was just created" {
+		t.Errorf("unexpected GetCreatedPet link: %v", created)
+	}
+	if args := created["arguments"].(map[string]any); args["id"] != float64(42) {
+		t.Errorf("expected id 42 from the response body, got %v", args)
+	}
+	if args := byName["ListSameKind"]["arguments"].(map[string]any); args["kind"] != "dog" {
+		t.Errorf("expected kind from the request body, got %v", args)
+	}
+	if unresolved, _ := byName["GetOwner"]["unresolved"].([]string); len(unresolved) != 1 || unresolved[0] != "id" {
+		t.Errorf("expected id to be reported unresolved, got %v", byName["GetOwner"])
+	}
+	if len(res.NextSteps) == 0 || !strings.HasPrefix(res.NextSteps[0], "call getPet ") {
+		t.Errorf("expected linked calls in next steps, got %v", res.NextSteps)
+	}
+}
+
+func TestEvalLinkValue(t *testing.T) {
+	lc := linkContext{
+		Method:     "POST",
+		URL:        "http://api.test/pets",
+		StatusCode: 201,
+		Args:       map[string]any{"id": 3},
+		Header:     http.Header{"Location": []string{"/pets/3"}},
+		Body:       map[string]any{"items": []any{map[string]any{"a/b": "x"}}},
+	}
+	tests := []struct {
+		expr any
+		want any
+		ok   bool
+	}{
+		{expr: "$response.body#/items/0/a~1b", want: "x", ok: true},
+		{expr: "$response.header.Location", want: "/pets/3", ok: true},
+		{expr: "$request.path.id", want: 3, ok: true},
+		{expr: "$statusCode", want: 201, ok: true},
+		{expr: "pets/{$request.path.id}/toys", want: "pets/3/toys", ok: true},
+		{expr: "literal", want: "literal", ok: true},
+		{expr: 5, want: 5, ok: true},
+		{expr: "$response.body#/missing", ok: false},
+		{expr: "$request.query.missing", ok: false},
+	}
+	for _, tt := range tests {
+		got, ok := evalLinkValue(tt.expr, lc)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("evalLinkValue(%v) = %v, %v; want %v, %v", tt.expr, got, ok, tt.want, tt.ok)
+		}
+	}
+}
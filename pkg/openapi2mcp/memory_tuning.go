@@ -0,0 +1,70 @@
+// memory_tuning.go
+package openapi2mcp
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/memory"
+)
+
+// defaultMemoryLimitMB is the soft memory limit applied when neither GOMEMLIMIT
+// nor MCP_MEMORY_LIMIT_MB is set. It matches the old "high usage" cleanup threshold.
+const defaultMemoryLimitMB = 4500
+
+var (
+	memoryTuningOnce    sync.Once
+	registrationLimiter atomic.Pointer[memory.MemoryLimiter]
+)
+
+// ConfigureMemoryTuning lets the Go runtime manage memory instead of forcing GC cycles: it
+// sets the process-wide soft memory limit and GC target, and has RegisterOpenAPITools check
+// memory usage against the limit. It changes the runtime settings of the whole process, so
+// the library never calls it; servers opt in at startup. Only the first call takes effect.
+//
+// Environment variables:
+//   - GOMEMLIMIT / GOGC: standard runtime settings, always respected when set
+//   - MCP_MEMORY_LIMIT_MB: soft memory limit in MB when GOMEMLIMIT is unset (default 4500)
+//   - MCP_GOGC: GC target percentage when GOGC is unset
+func ConfigureMemoryTuning() {
+	memoryTuningOnce.Do(func() {
+		limitMB := int64(defaultMemoryLimitMB)
+		if val := os.Getenv("MCP_MEMORY_LIMIT_MB"); val != "" {
+			if n, err := strconv.ParseInt(val, 10, 64); err == nil && n > 0 {
+				limitMB = n
+			} else {
+				fmt.Fprintf(os.Stderr, "[WARN] Invalid MCP_MEMORY_LIMIT_MB %q, using %dMB\n", val, limitMB)
+			}
+		}
+
+		if os.Getenv("GOMEMLIMIT") == "" {
+			debug.SetMemoryLimit(limitMB * 1024 * 1024)
+		} else {
+			// Keep the limiter in line with the runtime's own limit.
+			limitMB = debug.SetMemoryLimit(-1) / (1024 * 1024)
+		}
+
+		if val := os.Getenv("MCP_GOGC"); val != "" && os.Getenv("GOGC") == "" {
+			if n, err := strconv.Atoi(val); err == nil {
+				debug.SetGCPercent(n)
+			} else {
+				fmt.Fprintf(os.Stderr, "[WARN] Invalid MCP_GOGC %q, ignoring\n", val)
+			}
+		}
+
+		registrationLimiter.Store(memory.NewMemoryLimiter(limitMB))
+		fmt.Fprintf(os.Stderr, "[INFO] Memory tuning: soft limit %dMB\n", limitMB)
+	})
+}
+
+// registrationMemoryOK reports whether registration may continue. The limiter only
+// samples memory periodically and collects garbage only when the limit is exceeded.
+// Without ConfigureMemoryTuning, memory is left to the runtime.
+func registrationMemoryOK() bool {
+	limiter := registrationLimiter.Load()
+	return limiter == nil || limiter.CheckMemoryUsage()
+}
@@ -0,0 +1,141 @@
+package openapi2mcp
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+func TestRegistrationLeavesRuntimeAlone(t *testing.T) {
+	limit, gcPercent := debug.SetMemoryLimit(-1), debug.SetGCPercent(-1)
+	debug.SetGCPercent(gcPercent)
+
+	doc, err := LoadOpenAPISpecFromString(mockUpstreamSpec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	RegisterOpenAPITools(mcpserver.NewMCPServer("test", "0.0.1"), ExtractOpenAPIOperations(doc), doc, nil, nil)
+
+	if got := debug.SetMemoryLimit(-1); got != limit {
+		t.Errorf("expected registration to keep the memory limit %d, got %d", limit, got)
+	}
+	if got := debug.SetGCPercent(gcPercent); got != gcPercent {
+		t.Errorf("expected registration to keep GOGC %d, got %d", gcPercent, got)
+	}
+}
+
+// benchmarkSpecs builds n small specs with a handful of operations each.
+func benchmarkSpecs(b *testing.B, n, opsPerSpec int) []*openapi3.T {
+	b.Helper()
+	docs := make([]*openapi3.T, 0, n)
+	for i := 0; i < n; i++ {
+		var paths strings.Builder
+		for j := 0; j < opsPerSpec; j++ {
+			fmt.Fprintf(&paths, `
+  /items%d/{id}:
+    get:
+      operationId: getItem%d
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema: {type: string}
+        - name: expand
+          in: query
+          schema: {type: boolean}
+      responses:
+        '200': {description: ok}
+    put:
+      operationId: putItem%d
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema: {type: string}
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                name: {type: string}
+                tags: {type: array, items: {type: string}}
+      responses:
+        '200': {description: ok}`, j, j, j)
+		}
+		spec := fmt.Sprintf("openapi: 3.0.0\ninfo:\n  title: Spec %d\n  version: 1.0.0\npaths:%s\n", i, paths.String())
+		doc, err := LoadOpenAPISpecFromString(spec)
+		if err != nil {
+			b.Fatalf("failed to load spec %d: %v", i, err)
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+// silenceStderr discards registration logging for the duration of a benchmark.
+func silenceStderr(b *testing.B) {
+	b.Helper()
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	orig := os.Stderr
+	os.Stderr = devNull
+	b.Cleanup(func() {
+		os.Stderr = orig
+		devNull.Close()
+	})
+}
+
+// reloadSpecs rebuilds a server for every spec, the way a reload does, and reports the peak
+// heap in use after any spec was registered. forceGC adds the runtime.GC calls that
+// registration made before memory tuning.
+func reloadSpecs(b *testing.B, docs []*openapi3.T, forceGC bool) {
+	b.Helper()
+	var peak uint64
+	var stats runtime.MemStats
+	for i := 0; i < b.N; i++ {
+		for _, doc := range docs {
+			if forceGC {
+				ops := ExtractOpenAPIOperations(doc)
+				srv := mcpserver.NewMCPServer("bench", doc.Info.Version)
+				runtime.GC()
+				RegisterOpenAPITools(srv, ops, doc, nil, nil)
+				runtime.GC()
+			} else {
+				NewServer("bench", doc.Info.Version, doc)
+			}
+			b.StopTimer()
+			runtime.ReadMemStats(&stats)
+			peak = max(peak, stats.HeapInuse)
+			b.StartTimer()
+		}
+	}
+	b.ReportMetric(float64(peak)/(1024*1024), "peak-heap-MB")
+}
+
+// BenchmarkReload50Specs compares rebuilding 50 spec servers with the previous
+// forced runtime.GC calls against the runtime-tuned path the servers opt into with
+// ConfigureMemoryTuning, for both reload latency and peak heap.
+func BenchmarkReload50Specs(b *testing.B) {
+	docs := benchmarkSpecs(b, 50, 10)
+	silenceStderr(b)
+
+	b.Run("forced-gc", func(b *testing.B) {
+		reloadSpecs(b, docs, true)
+	})
+
+	b.Run("tuned", func(b *testing.B) {
+		// Tuning changes the settings of the whole process, and benchmarks run after the tests
+		ConfigureMemoryTuning()
+		b.ResetTimer()
+		reloadSpecs(b, docs, false)
+	})
+}
@@ -0,0 +1,112 @@
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/apierrors"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+// metadataOperationsExtension is the root OpenAPI extension that exposes HEAD and OPTIONS
+// operations as metadata-only tools (`x-mcp-metadata-operations: true`).
+const metadataOperationsExtension = "x-mcp-metadata-operations"
+
+// metadataOnlyDescription is appended to the descriptions of HEAD and OPTIONS tools.
+const metadataOnlyDescription = "\n\nMetadata only: returns the HTTP status and response headers (and the allowed methods for OPTIONS), without a body."
+
+// isMetadataMethod reports whether method only returns metadata: HEAD and OPTIONS.
+func isMetadataMethod(method string) bool {
+	method = strings.ToUpper(method)
+	return method == http.MethodHead || method == http.MethodOptions
+}
+
+// specMetadataOperations reports whether a spec's HEAD and OPTIONS operations become tools:
+// opts.MetadataOperations, then the x-mcp-metadata-operations extension, then
+// MCP_METADATA_OPERATIONS. Off by default, as they rarely help an agent.
+func specMetadataOperations(doc *openapi3.T, opts *ToolGenOptions) bool {
+	if opts != nil && opts.MetadataOperations {
+		return true
+	}
+	if doc != nil {
+		if v, ok := doc.Extensions[metadataOperationsExtension].(bool); ok {
+			return v
+		}
+	}
+	enabled, _ := strconv.ParseBool(os.Getenv("MCP_METADATA_OPERATIONS"))
+	return enabled
+}
+
+// metadataOperationIncluded reports whether op becomes a tool. HEAD and OPTIONS operations
+// only do when metadata operations are included, or the allowed methods policy names them.
+func metadataOperationIncluded(op OpenAPIOperation, include bool, allowed map[string]bool) bool {
+	if !isMetadataMethod(op.Method) || include {
+		return true
+	}
+	return allowed != nil && allowed[strings.ToUpper(op.Method)]
+}
+
+// metadataResult is the result of a HEAD or OPTIONS tool: the status and headers of the
+// response, and the methods an OPTIONS response allows. Set-Cookie is left out.
+func metadataResult(op OpenAPIOperation, fullURL string, resp *http.Response) *mcp.CallToolResult {
+	method := strings.ToUpper(op.Method)
+	headers := map[string]string{}
+	for name, values := range resp.Header {
+		if strings.EqualFold(name, "Set-Cookie") {
+			continue
+		}
+		headers[name] = strings.Join(values, ", ")
+	}
+	resultObj := map[string]any{
+		"type":        "metadata",
+		"success":     resp.StatusCode < 400,
+		"http_status": resp.StatusCode,
+		"status_text": http.StatusText(resp.StatusCode),
+		"message":     fmt.Sprintf("%s %s returned HTTP %d %s.", method, fullURL, resp.StatusCode, http.StatusText(resp.StatusCode)),
+		"headers":     headers,
+		"operation": map[string]any{
+			"id":      op.OperationID,
+			"summary": op.Summary,
+		},
+	}
+	if method == http.MethodOptions {
+		if allowed := allowedMethodsHeader(resp.Header); len(allowed) > 0 {
+			resultObj["allowed_methods"] = allowed
+		}
+	}
+	resultJSON, _ := json.MarshalIndent(resultObj, "", "  ")
+	res := &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: string(resultJSON),
+			},
+		},
+		IsError:      resp.StatusCode >= 400,
+		OutputFormat: "structured",
+		OutputType:   "json",
+	}
+	res.Meta = map[string]any{"httpStatus": resp.StatusCode}
+	if res.IsError {
+		return withErrorMeta(res, apierrors.TypeForStatus(resp.StatusCode), fmt.Sprintf("upstream HTTP %d", resp.StatusCode))
+	}
+	return res
+}
+
+// allowedMethodsHeader returns the methods of the Allow header, or of the CORS
+// Access-Control-Allow-Methods header when there is no Allow header, sorted.
+func allowedMethodsHeader(h http.Header) []string {
+	value := strings.Join(h.Values("Allow"), ",")
+	if value == "" {
+		value = strings.Join(h.Values("Access-Control-Allow-Methods"), ",")
+	}
+	methods := ParseMethodList(value)
+	sort.Strings(methods)
+	return methods
+}
@@ -0,0 +1,104 @@
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+const metadataOpsSpec = `
+openapi: 3.0.0
+info:
+  title: Files
+  version: 1.0.0
+paths:
+  /files/{name}:
+    parameters:
+      - name: name
+        in: path
+        required: true
+        schema:
+          type: string
+    get:
+      operationId: getFile
+      responses:
+        "200":
+          description: OK
+    head:
+      operationId: headFile
+      summary: Check that a file exists
+      responses:
+        "200":
+          description: OK
+    options:
+      operationId: fileOptions
+      responses:
+        "204":
+          description: No Content
+`
+
+func TestMetadataOperationsSkippedByDefault(t *testing.T) {
+	names := registeredToolNames(newTestServer(t, metadataOpsSpec, nil, nil))
+	if !names["getFile"] || names["headFile"] || names["fileOptions"] {
+		t.Errorf("expected only the GET operation to be a tool, got %v", names)
+	}
+
+	// An allowed methods policy that names HEAD includes HEAD operations
+	names = registeredToolNames(newTestServer(t, metadataOpsSpec, &ToolGenOptions{AllowedMethods: []string{"GET", "HEAD"}}, nil))
+	if !names["headFile"] || names["fileOptions"] {
+		t.Errorf("expected the HEAD operation named by the policy to be a tool, got %v", names)
+	}
+}
+
+func TestMetadataOperationTools(t *testing.T) {
+	server := newTestServer(t, metadataOpsSpec, &ToolGenOptions{MetadataOperations: true}, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodHead:
+			if r.URL.Path == "/files/missing" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Header().Set("ETag", `"abc"`)
+			w.Header().Set("Set-Cookie", "session=secret")
+			w.Header().Set("Content-Length", "1234")
+		case http.MethodOptions:
+			w.Header().Set("Allow", "OPTIONS, GET, HEAD")
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	for _, tool := range server.ListTools() {
+		if tool.Name == "headFile" && !strings.Contains(tool.Description, "Metadata only") {
+			t.Errorf("expected the HEAD tool to say it returns metadata only, got %q", tool.Description)
+		}
+	}
+
+	var head map[string]any
+	res := callToolForTest(t, server, "headFile", map[string]any{"name": "report.pdf"})
+	if err := json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &head); err != nil || res.IsError {
+		t.Fatalf("expected a metadata result, got %+v", res)
+	}
+	headers, _ := head["headers"].(map[string]any)
+	if head["type"] != "metadata" || head["http_status"] != float64(200) || headers["Etag"] != `"abc"` || headers["Content-Length"] != "1234" {
+		t.Errorf("unexpected HEAD result %v", head)
+	}
+	if _, ok := headers["Set-Cookie"]; ok {
+		t.Error("expected Set-Cookie to be left out")
+	}
+
+	var options map[string]any
+	res = callToolForTest(t, server, "fileOptions", map[string]any{"name": "report.pdf"})
+	_ = json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &options)
+	allowed, _ := json.Marshal(options["allowed_methods"])
+	if string(allowed) != `["GET","HEAD","OPTIONS"]` {
+		t.Errorf("expected the allowed methods of the OPTIONS response, got %v", options)
+	}
+
+	res = callToolForTest(t, server, "headFile", map[string]any{"name": "missing"})
+	if !res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, `"http_status": 404`) {
+		t.Errorf("expected a 404 metadata error, got %+v", res)
+	}
+}
@@ -0,0 +1,95 @@
+// methods_policy.go
+package openapi2mcp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// allowedMethodsExtension is the root-level spec extension listing the HTTP methods
+// that may be exposed as tools, e.g. `x-mcp-allowed-methods: [GET, POST]`.
+const allowedMethodsExtension = "x-mcp-allowed-methods"
+
+// SetAllowedMethods records an allowed HTTP methods policy on doc, so it applies
+// wherever the spec is registered. An empty list removes the policy.
+func SetAllowedMethods(doc *openapi3.T, methods []string) {
+	if doc == nil {
+		return
+	}
+	if len(methods) == 0 {
+		delete(doc.Extensions, allowedMethodsExtension)
+		return
+	}
+	if doc.Extensions == nil {
+		doc.Extensions = map[string]any{}
+	}
+	values := make([]any, len(methods))
+	for i, m := range methods {
+		values[i] = m
+	}
+	doc.Extensions[allowedMethodsExtension] = values
+}
+
+// ParseMethodList splits a comma-separated list of HTTP methods, e.g. "GET,POST".
+func ParseMethodList(s string) []string {
+	var methods []string
+	for _, m := range strings.Split(s, ",") {
+		if m = strings.ToUpper(strings.TrimSpace(m)); m != "" {
+			methods = append(methods, m)
+		}
+	}
+	return methods
+}
+
+// specAllowedMethods returns the set of allowed HTTP methods from opts or the
+// x-mcp-allowed-methods extension. nil means every method is allowed.
+func specAllowedMethods(doc *openapi3.T, opts *ToolGenOptions) map[string]bool {
+	var methods []string
+	if opts != nil && len(opts.AllowedMethods) > 0 {
+		methods = opts.AllowedMethods
+	} else if doc != nil {
+		switch v := doc.Extensions[allowedMethodsExtension].(type) {
+		case string:
+			methods = ParseMethodList(v)
+		case []any:
+			for _, item := range v {
+				if s, ok := item.(string); ok {
+					methods = append(methods, ParseMethodList(s)...)
+				}
+			}
+		case []string:
+			methods = v
+		}
+	}
+	if len(methods) == 0 {
+		return nil
+	}
+	allowed := make(map[string]bool, len(methods))
+	for _, m := range methods {
+		allowed[strings.ToUpper(strings.TrimSpace(m))] = true
+	}
+	return allowed
+}
+
+// methodAllowed reports whether the policy allows method. A nil policy allows everything.
+func methodAllowed(allowed map[string]bool, method string) bool {
+	return allowed == nil || allowed[strings.ToUpper(method)]
+}
+
+// describeAllowedMethods formats a policy for log and error messages.
+func describeAllowedMethods(allowed map[string]bool) string {
+	methods := make([]string, 0, len(allowed))
+	for m := range allowed {
+		methods = append(methods, m)
+	}
+	sort.Strings(methods)
+	return strings.Join(methods, ", ")
+}
+
+// methodNotAllowedError is the call-time error for an operation the policy forbids.
+func methodNotAllowedError(method string, allowed map[string]bool) string {
+	return fmt.Sprintf("HTTP method %s is not allowed for this API (allowed: %s)", strings.ToUpper(method), describeAllowedMethods(allowed))
+}
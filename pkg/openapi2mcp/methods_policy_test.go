@@ -0,0 +1,91 @@
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+func registeredToolNames(server *mcpserver.MCPServer) map[string]bool {
+	names := map[string]bool{}
+	for _, tool := range server.ListTools() {
+		names[tool.Name] = true
+	}
+	return names
+}
+
+func TestAllowedMethodsPolicy(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		opts *ToolGenOptions
+	}{
+		{
+			name: "extension",
+			spec: strings.Replace(mockUpstreamSpec, "info:", "x-mcp-allowed-methods: [GET]\ninfo:", 1),
+		},
+		{
+			name: "comma-separated extension",
+			spec: strings.Replace(mockUpstreamSpec, "info:", "x-mcp-allowed-methods: get, post\ninfo:", 1),
+		},
+		{
+			name: "option",
+			spec: mockUpstreamSpec,
+			opts: &ToolGenOptions{AllowedMethods: []string{"GET"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, tt.spec, tt.opts, nil)
+
+			names := registeredToolNames(server)
+			if !names["listPets"] || !names["getPet"] {
+				t.Errorf("expected GET tools to be registered, got %v", names)
+			}
+			if names["deletePet"] {
+				t.Errorf("expected DELETE tool to be excluded by the policy")
+			}
+		})
+	}
+}
+
+func TestSetAllowedMethods(t *testing.T) {
+	doc, err := LoadOpenAPISpecFromString(mockUpstreamSpec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	SetAllowedMethods(doc, ParseMethodList("get, delete"))
+	allowed := specAllowedMethods(doc, nil)
+	if !methodAllowed(allowed, "delete") || methodAllowed(allowed, "PUT") {
+		t.Errorf("unexpected policy %v", allowed)
+	}
+	SetAllowedMethods(doc, nil)
+	if specAllowedMethods(doc, nil) != nil {
+		t.Errorf("expected policy to be removed")
+	}
+}
+
+func TestAllowedMethodsPolicyEnforcedAtCallTime(t *testing.T) {
+	doc, err := LoadOpenAPISpecFromString(mockUpstreamSpec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	server := mcpserver.NewMCPServer("test", "0.0.1")
+	RegisterOpenAPITools(server, ExtractOpenAPIOperations(doc), doc, nil, nil)
+	if !registeredToolNames(server)["deletePet"] {
+		t.Fatalf("expected deletePet to be registered without a policy")
+	}
+
+	// Tighten the policy after registration; the already registered tool must refuse to run
+	SetAllowedMethods(doc, []string{"GET"})
+	result := callToolForTest(t, server, "deletePet", map[string]any{"id": 1})
+	if !result.IsError {
+		t.Fatalf("expected deletePet to be rejected by the policy")
+	}
+	text, _ := json.Marshal(result.Content)
+	if !strings.Contains(string(text), "not allowed") {
+		t.Errorf("expected a method not allowed error, got %s", text)
+	}
+}
@@ -0,0 +1,66 @@
+package openapi2mcp
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+	"github.com/ubermorgenland/openapi-mcp/pkg/metrics"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	serveUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/404") {
+			http.Error(w, `{"error": "not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 1, "name": "Rex"}`))
+	})
+
+	doc, err := LoadOpenAPISpecFromString(mockUpstreamSpec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	server := mcpserver.NewMCPServer("test", "0.0.1", ServerOptions()...)
+	RegisterOpenAPITools(server, ExtractOpenAPIOperations(doc), doc, &ToolGenOptions{}, nil)
+	endpoint := resultEndpointName(doc, nil)
+
+	calls := metrics.MCPRequests.Value("tools/call", "success")
+	completed := metrics.ToolCallDuration.Count(endpoint, "getPet", JournalCompleted)
+	failed := metrics.ToolCallDuration.Count(endpoint, "getPet", JournalFailed)
+	notFound := metrics.UpstreamResponses.Value(endpoint, "404")
+
+	callToolForTest(t, server, "getPet", map[string]any{"id": 1})
+	callToolForTest(t, server, "getPet", map[string]any{"id": 404})
+
+	if got := metrics.MCPRequests.Value("tools/call", "success") - calls; got != 2 {
+		t.Errorf("expected 2 counted tools/call requests, got %v", got)
+	}
+	if got := metrics.ToolCallDuration.Count(endpoint, "getPet", JournalCompleted) - completed; got != 1 {
+		t.Errorf("expected 1 completed call observed, got %d", got)
+	}
+	if got := metrics.ToolCallDuration.Count(endpoint, "getPet", JournalFailed) - failed; got != 1 {
+		t.Errorf("expected 1 failed call observed, got %d", got)
+	}
+	if got := metrics.UpstreamResponses.Value(endpoint, "404") - notFound; got != 1 {
+		t.Errorf("expected 1 upstream 404 counted, got %v", got)
+	}
+
+	var buf bytes.Buffer
+	if err := metrics.Default.Write(&buf); err != nil {
+		t.Fatalf("failed to write metrics: %v", err)
+	}
+	for _, want := range []string{
+		"# TYPE mcp_tool_call_duration_seconds histogram",
+		`mcp_tool_call_duration_seconds_bucket{endpoint="` + endpoint + `",tool="getPet",status="completed",le="+Inf"}`,
+		`mcp_requests_total{method="tools/call",result="success"}`,
+		`mcp_upstream_responses_total{endpoint="` + endpoint + `",status="404"}`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %q in the metrics, got:\n%s", want, buf.String())
+		}
+	}
+}
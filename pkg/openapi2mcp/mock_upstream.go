@@ -0,0 +1,287 @@
+// mock_upstream.go
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// mockUpstreamExtension is the root-level spec extension holding the URL of a mock upstream
+// that serves the spec's tool calls, set by SetMockUpstream.
+const mockUpstreamExtension = "x-mcp-mock-upstream"
+
+// SetMockUpstream sends the tool calls of doc to a mock upstream at url, ahead of
+// OPENAPI_BASE_URL and the spec's servers. An empty url removes it.
+func SetMockUpstream(doc *openapi3.T, url string) {
+	if doc == nil {
+		return
+	}
+	if url == "" {
+		delete(doc.Extensions, mockUpstreamExtension)
+		return
+	}
+	if doc.Extensions == nil {
+		doc.Extensions = map[string]any{}
+	}
+	doc.Extensions[mockUpstreamExtension] = url
+}
+
+// mockUpstreamURL returns the mock upstream URL set on doc, if any.
+func mockUpstreamURL(doc *openapi3.T) string {
+	if doc == nil {
+		return ""
+	}
+	url, _ := doc.Extensions[mockUpstreamExtension].(string)
+	return url
+}
+
+// mockRoute is a single operation served by the mock upstream.
+type mockRoute struct {
+	method  string
+	pattern *regexp.Regexp
+	op      *openapi3.Operation
+}
+
+// NewMockUpstream starts an httptest server that answers every operation in doc with
+// an example-based response. Examples are taken from the first 2xx (or default) response,
+// preferring media type examples, then schema examples, then a value synthesized from the schema.
+// Point tools at the returned server's URL to demo a spec without real API credentials.
+// The caller is responsible for calling Close on the returned server.
+func NewMockUpstream(doc *openapi3.T) *httptest.Server {
+	routes := buildMockRoutes(doc)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pathMatched := false
+		for _, route := range routes {
+			if !route.pattern.MatchString(r.URL.Path) {
+				continue
+			}
+			pathMatched = true
+			if route.method != r.Method {
+				continue
+			}
+			writeMockResponse(w, route.op)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if pathMatched {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]any{"error": "method not allowed", "method": r.Method, "path": r.URL.Path})
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]any{"error": "no mock operation for path", "path": r.URL.Path})
+	}))
+}
+
+// buildMockRoutes compiles a route for every operation in doc. Templated segments
+// such as {id} match any single path segment. Literal paths sort before templated
+// ones so /pets/mine wins over /pets/{id}.
+func buildMockRoutes(doc *openapi3.T) []mockRoute {
+	var routes []mockRoute
+	if doc == nil || doc.Paths == nil {
+		return routes
+	}
+	paths := doc.Paths.InMatchingOrder()
+	for _, path := range paths {
+		item := doc.Paths.Value(path)
+		if item == nil {
+			continue
+		}
+		pattern := regexp.MustCompile("^" + pathTemplateRegex(path) + "/?$")
+		methods := make([]string, 0, len(item.Operations()))
+		for method := range item.Operations() {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			routes = append(routes, mockRoute{
+				method:  strings.ToUpper(method),
+				pattern: pattern,
+				op:      item.Operations()[method],
+			})
+		}
+	}
+	return routes
+}
+
+// pathTemplateRegex converts an OpenAPI path template into a regular expression.
+func pathTemplateRegex(path string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(path, "{")
+		if start < 0 {
+			b.WriteString(regexp.QuoteMeta(path))
+			break
+		}
+		end := strings.Index(path[start:], "}")
+		if end < 0 {
+			b.WriteString(regexp.QuoteMeta(path))
+			break
+		}
+		b.WriteString(regexp.QuoteMeta(path[:start]))
+		b.WriteString("[^/]+")
+		path = path[start+end+1:]
+	}
+	return b.String()
+}
+
+// writeMockResponse writes the example response for op.
+func writeMockResponse(w http.ResponseWriter, op *openapi3.Operation) {
+	status, resp := mockResponseFor(op)
+	if resp == nil || len(resp.Content) == 0 {
+		w.WriteHeader(status)
+		return
+	}
+
+	contentType, media := pickMockMediaType(resp.Content)
+	example := mockExampleFor(media)
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+
+	if s, ok := example.(string); ok && !strings.Contains(contentType, "json") {
+		w.Write([]byte(s))
+		return
+	}
+	json.NewEncoder(w).Encode(example)
+}
+
+// mockResponseFor returns the lowest 2xx response of op, falling back to default.
+func mockResponseFor(op *openapi3.Operation) (int, *openapi3.Response) {
+	if op == nil || op.Responses == nil {
+		return http.StatusOK, nil
+	}
+	codes := make([]int, 0)
+	for code := range op.Responses.Map() {
+		if n, err := strconv.Atoi(code); err == nil && n >= 200 && n < 300 {
+			codes = append(codes, n)
+		}
+	}
+	sort.Ints(codes)
+	if len(codes) > 0 {
+		if ref := op.Responses.Status(codes[0]); ref != nil {
+			return codes[0], ref.Value
+		}
+	}
+	if ref := op.Responses.Default(); ref != nil {
+		return http.StatusOK, ref.Value
+	}
+	return http.StatusOK, nil
+}
+
+// pickMockMediaType prefers a JSON media type and otherwise takes the first one alphabetically.
+func pickMockMediaType(content openapi3.Content) (string, *openapi3.MediaType) {
+	keys := make([]string, 0, len(content))
+	for k := range content {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if strings.Contains(k, "json") {
+			return k, content[k]
+		}
+	}
+	return keys[0], content[keys[0]]
+}
+
+// mockExampleFor picks the best available example for a media type.
+func mockExampleFor(media *openapi3.MediaType) any {
+	if media == nil {
+		return nil
+	}
+	if media.Example != nil {
+		return media.Example
+	}
+	if len(media.Examples) > 0 {
+		names := make([]string, 0, len(media.Examples))
+		for name := range media.Examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if ex := media.Examples[name]; ex != nil && ex.Value != nil && ex.Value.Value != nil {
+				return ex.Value.Value
+			}
+		}
+	}
+	if media.Schema != nil {
+		return mockValueFromSchema(media.Schema.Value, 0)
+	}
+	return nil
+}
+
+// mockValueFromSchema synthesizes a value for schema, honouring examples, defaults and enums.
+func mockValueFromSchema(schema *openapi3.Schema, depth int) any {
+	if schema == nil || depth > 8 {
+		return nil
+	}
+	if schema.Example != nil {
+		return schema.Example
+	}
+	if schema.Default != nil {
+		return schema.Default
+	}
+	if len(schema.Enum) > 0 {
+		return schema.Enum[0]
+	}
+	if len(schema.AllOf) > 0 {
+		merged := map[string]any{}
+		for _, ref := range schema.AllOf {
+			if m, ok := mockValueFromSchema(ref.Value, depth+1).(map[string]any); ok {
+				for k, v := range m {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	}
+	if len(schema.OneOf) > 0 {
+		return mockValueFromSchema(schema.OneOf[0].Value, depth+1)
+	}
+	if len(schema.AnyOf) > 0 {
+		return mockValueFromSchema(schema.AnyOf[0].Value, depth+1)
+	}
+
+	switch {
+	case schema.Type.Is("object") || len(schema.Properties) > 0:
+		obj := map[string]any{}
+		for name, prop := range schema.Properties {
+			if prop != nil {
+				obj[name] = mockValueFromSchema(prop.Value, depth+1)
+			}
+		}
+		return obj
+	case schema.Type.Is("array"):
+		if schema.Items == nil {
+			return []any{}
+		}
+		return []any{mockValueFromSchema(schema.Items.Value, depth+1)}
+	case schema.Type.Is("integer"):
+		return 1
+	case schema.Type.Is("number"):
+		return 1.5
+	case schema.Type.Is("boolean"):
+		return true
+	case schema.Type.Is("string"):
+		switch schema.Format {
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		case "date":
+			return "2024-01-01"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		case "email":
+			return "user@example.com"
+		case "uri", "url":
+			return "https://example.com"
+		}
+		return "string"
+	}
+	return nil
+}
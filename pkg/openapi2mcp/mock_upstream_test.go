@@ -0,0 +1,155 @@
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+const mockUpstreamSpec = `
+openapi: 3.0.0
+info:
+  title: Pets
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        '200':
+          description: ok
+          content:
+            application/json:
+              example: [{"id": 1, "name": "Rex"}]
+  /pets/{id}:
+    get:
+      operationId: getPet
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  id:
+                    type: integer
+                  name:
+                    type: string
+                    example: Rex
+    delete:
+      operationId: deletePet
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        '204':
+          description: deleted
+`
+
+func TestNewMockUpstream(t *testing.T) {
+	doc, err := LoadOpenAPISpecFromString(mockUpstreamSpec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	mock := NewMockUpstream(doc)
+	defer mock.Close()
+
+	t.Run("media type example", func(t *testing.T) {
+		resp, err := http.Get(mock.URL + "/pets")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var got []map[string]any
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK || len(got) != 1 || got[0]["name"] != "Rex" {
+			t.Errorf("unexpected response %d: %v", resp.StatusCode, got)
+		}
+	})
+
+	t.Run("synthesized from schema", func(t *testing.T) {
+		resp, err := http.Get(mock.URL + "/pets/42")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var got map[string]any
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got["name"] != "Rex" || got["id"] != float64(1) {
+			t.Errorf("unexpected body: %v", got)
+		}
+	})
+
+	t.Run("no content", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, mock.URL+"/pets/42", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent {
+			t.Errorf("expected 204, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("unknown routes", func(t *testing.T) {
+		resp, err := http.Get(mock.URL + "/owners")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", resp.StatusCode)
+		}
+		req, _ := http.NewRequest(http.MethodPut, mock.URL+"/pets", nil)
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("expected 405, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestMockUpstreamTakesPrecedence(t *testing.T) {
+	serveUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("expected no call to OPENAPI_BASE_URL, got %s %s", r.Method, r.URL)
+	})
+	doc, err := LoadOpenAPISpecFromString(mockUpstreamSpec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	mock := NewMockUpstream(doc)
+	defer mock.Close()
+	SetMockUpstream(doc, mock.URL)
+
+	server := mcpserver.NewMCPServer("test", "0.0.1")
+	RegisterOpenAPITools(server, ExtractOpenAPIOperations(doc), doc, nil, nil)
+	if res := callToolForTest(t, server, "listPets", map[string]any{}); res.IsError || !strings.Contains(previewText(res), "Rex") {
+		t.Errorf("expected the mock's example response, got %+v", res)
+	}
+
+	SetMockUpstream(doc, "")
+	if mockUpstreamURL(doc) != "" {
+		t.Error("expected an empty URL to remove the mock upstream")
+	}
+}
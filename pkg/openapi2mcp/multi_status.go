@@ -0,0 +1,304 @@
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/apierrors"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+// maxMultiStatusErrors bounds the failed items listed in a multi-status summary.
+const maxMultiStatusErrors = 5
+
+// MultiStatus summarizes an upstream response reporting one result per item: a 207
+// Multi-Status response, or a batch endpoint's response mixing succeeded and failed items.
+// Results carry it as _meta.multiStatus, so agents can retry only the failed items.
+type MultiStatus struct {
+	Total     int                    `json:"total"`
+	Succeeded int                    `json:"succeeded"`
+	Failed    int                    `json:"failed"`
+	Errors    []MultiStatusItemError `json:"errors,omitempty"` // the first failed items
+}
+
+// MultiStatusItemError is a failed item of a multi-status response.
+type MultiStatusItemError struct {
+	Index  int    `json:"index"`            // position of the item in the response, from 0
+	ID     string `json:"id,omitempty"`     // id, key or href of the item, when it has one
+	Status int    `json:"status,omitempty"` // HTTP status of the item, when it has one
+	Error  string `json:"error,omitempty"`
+}
+
+// itemOutcome is the result of one item of a multi-status response.
+type itemOutcome struct {
+	known  bool // the item reports whether it succeeded
+	failed bool
+	id     string
+	status int
+	err    string
+}
+
+// batchItemKeys are the fields of a JSON object response that commonly hold per-item results.
+var batchItemKeys = []string{"results", "items", "responses", "operations", "data", "statuses"}
+
+// parseMultiStatus summarizes a response reporting per-item results. It understands JSON
+// arrays of items, at the root or in a field such as "results" or "items", whose items carry
+// a status ("status", "statusCode", "code"), a success flag ("success", "ok") or an "error",
+// also wrapped in a single-key object as in Elasticsearch bulk responses, and WebDAV XML
+// multistatus bodies. Other 2xx responses are only summarized when every item reports its
+// outcome and some failed. It returns nil for any other response.
+func parseMultiStatus(statusCode int, contentType string, body []byte) *MultiStatus {
+	if statusCode != http.StatusMultiStatus && (statusCode < 200 || statusCode >= 300) {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	var outcomes []itemOutcome
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		outcomes = jsonItemOutcomes(body, statusCode == http.StatusMultiStatus)
+	case statusCode == http.StatusMultiStatus && (mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")):
+		outcomes = davItemOutcomes(body)
+	}
+	if len(outcomes) == 0 {
+		return nil
+	}
+
+	ms := &MultiStatus{Total: len(outcomes)}
+	known := 0
+	for i, o := range outcomes {
+		if !o.known {
+			continue
+		}
+		known++
+		if !o.failed {
+			ms.Succeeded++
+			continue
+		}
+		ms.Failed++
+		if len(ms.Errors) < maxMultiStatusErrors {
+			ms.Errors = append(ms.Errors, MultiStatusItemError{Index: i, ID: o.id, Status: o.status, Error: o.err})
+		}
+	}
+	if statusCode == http.StatusMultiStatus {
+		if known == 0 {
+			return nil
+		}
+		return ms
+	}
+	if known < len(outcomes) || ms.Failed == 0 {
+		return nil
+	}
+	return ms
+}
+
+// jsonItemOutcomes returns the outcomes of the items of a JSON batch response. Status words
+// such as "failed" are only outcomes in multi-status responses, as lists of records often
+// have such a status field.
+func jsonItemOutcomes(body []byte, statusWords bool) []itemOutcome {
+	var root any
+	if err := json.Unmarshal(body, &root); err != nil {
+		return nil
+	}
+	items, ok := root.([]any)
+	if obj, isObj := root.(map[string]any); isObj {
+		for _, key := range batchItemKeys {
+			if items, ok = obj[key].([]any); ok && len(items) > 0 {
+				break
+			}
+		}
+	}
+	if !ok || len(items) == 0 {
+		return nil
+	}
+	outcomes := make([]itemOutcome, len(items))
+	for i, item := range items {
+		if obj, ok := item.(map[string]any); ok {
+			outcomes[i] = jsonItemOutcome(obj, statusWords)
+		}
+	}
+	return outcomes
+}
+
+// jsonItemOutcome returns the outcome of one item of a JSON batch response.
+func jsonItemOutcome(item map[string]any, statusWords bool) itemOutcome {
+	o := itemOutcome{status: itemStatus(item)}
+	if o.status == 0 && len(item) == 1 {
+		// {"index": {"_id": "1", "status": 201}}
+		for _, v := range item {
+			if inner, ok := v.(map[string]any); ok {
+				return jsonItemOutcome(inner, statusWords)
+			}
+		}
+	}
+	o.id = firstString(item, "id", "_id", "key", "href", "name")
+	o.err = itemError(item["error"])
+	if o.err == "" && item["error"] != nil && item["error"] != false {
+		o.err = "error"
+	}
+	switch success := firstPresent(item, "success", "ok", "succeeded").(type) {
+	case bool:
+		o.known, o.failed = true, !success
+	default:
+		switch {
+		case o.status != 0:
+			o.known, o.failed = true, o.status >= 400
+		case o.err != "":
+			o.known, o.failed = true, true
+		case statusWords:
+			switch strings.ToLower(firstString(item, "status", "result", "state")) {
+			case "success", "succeeded", "ok", "created", "updated", "deleted", "done", "completed":
+				o.known = true
+			case "error", "failed", "failure", "rejected", "invalid":
+				o.known, o.failed = true, true
+			}
+		}
+	}
+	if o.failed && o.err == "" {
+		o.err = firstString(item, "message", "detail", "reason", "error_message", "errorMessage", "description")
+	}
+	return o
+}
+
+// itemStatus returns the HTTP status of a batch item, or 0 when it has none.
+func itemStatus(item map[string]any) int {
+	for _, key := range []string{"status", "statusCode", "status_code", "httpStatus", "http_status", "code"} {
+		var status int
+		switch v := item[key].(type) {
+		case float64:
+			status = int(v)
+		case string:
+			status = parseStatusLine(v)
+		}
+		if status >= 100 && status <= 599 {
+			return status
+		}
+	}
+	return 0
+}
+
+// parseStatusLine returns the status of "404", "HTTP/1.1 404 Not Found" or "404 Not Found".
+func parseStatusLine(s string) int {
+	fields := strings.Fields(s)
+	if len(fields) > 1 && strings.HasPrefix(fields[0], "HTTP/") {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return 0
+	}
+	n, _ := strconv.Atoi(fields[0])
+	return n
+}
+
+// itemError returns the message of an item's error: a string, or an object with a message.
+func itemError(v any) string {
+	switch e := v.(type) {
+	case string:
+		return e
+	case map[string]any:
+		msg := firstString(e, "message", "reason", "detail", "description", "title")
+		if kind := firstString(e, "type", "code"); kind != "" && msg != "" {
+			return kind + ": " + msg
+		} else if msg == "" {
+			return kind
+		}
+		return msg
+	}
+	return ""
+}
+
+// davMultistatus is a WebDAV multistatus body (RFC 4918); tags match in any namespace.
+type davMultistatus struct {
+	Responses []struct {
+		Href     []string `xml:"href"`
+		Status   string   `xml:"status"`
+		Propstat []struct {
+			Status string `xml:"status"`
+		} `xml:"propstat"`
+		Description string `xml:"responsedescription"`
+	} `xml:"response"`
+}
+
+// davItemOutcomes returns the outcomes of the responses of a WebDAV multistatus body. A
+// response with property statuses failed when any of them failed.
+func davItemOutcomes(body []byte) []itemOutcome {
+	var ms davMultistatus
+	if err := xml.Unmarshal(body, &ms); err != nil {
+		return nil
+	}
+	outcomes := make([]itemOutcome, len(ms.Responses))
+	for i, r := range ms.Responses {
+		o := &outcomes[i]
+		if len(r.Href) > 0 {
+			o.id = strings.TrimSpace(r.Href[0])
+		}
+		o.status = parseStatusLine(r.Status)
+		for _, ps := range r.Propstat {
+			if status := parseStatusLine(ps.Status); status > o.status {
+				o.status = status
+			}
+		}
+		o.known = o.status != 0
+		o.failed = o.status >= 400
+		if o.failed {
+			o.err = strings.TrimSpace(r.Description)
+		}
+	}
+	return outcomes
+}
+
+// Text is the summary shown before the response body, e.g.
+// "Multi-status: 3 of 5 items succeeded, 2 failed" followed by the first failed items.
+func (ms *MultiStatus) Text() string {
+	text := fmt.Sprintf("Multi-status: %d of %d items succeeded, %d failed", ms.Succeeded, ms.Total, ms.Failed)
+	if unknown := ms.Total - ms.Succeeded - ms.Failed; unknown > 0 {
+		text += fmt.Sprintf(", %d without a status", unknown)
+	}
+	if len(ms.Errors) == 0 {
+		return text
+	}
+	text += "\nFailed items:"
+	for _, e := range ms.Errors {
+		line := fmt.Sprintf("\n- item %d", e.Index)
+		if e.ID != "" {
+			line += " (" + e.ID + ")"
+		}
+		var reasons []string
+		if e.Status != 0 {
+			reasons = append(reasons, fmt.Sprintf("HTTP %d", e.Status))
+		}
+		if e.Error != "" {
+			reasons = append(reasons, e.Error)
+		}
+		if len(reasons) > 0 {
+			line += ": " + strings.Join(reasons, " ")
+		}
+		text += line
+	}
+	if more := ms.Failed - len(ms.Errors); more > 0 {
+		text += fmt.Sprintf("\n- and %d more failed items", more)
+	}
+	return text
+}
+
+// withMultiStatus adds the summary of a multi-status response to a result as
+// _meta.multiStatus. A response whose items all failed is an error result.
+func withMultiStatus(res *mcp.CallToolResult, ms *MultiStatus) *mcp.CallToolResult {
+	if ms == nil {
+		return res
+	}
+	if res.Meta == nil {
+		res.Meta = map[string]any{}
+	}
+	res.Meta["multiStatus"] = ms
+	if ms.Succeeded == 0 && ms.Failed > 0 {
+		res.IsError = true
+		status := ms.Errors[0].Status
+		withErrorMeta(res, apierrors.TypeForStatus(status), fmt.Sprintf("all %d items failed", ms.Failed))
+	}
+	return res
+}
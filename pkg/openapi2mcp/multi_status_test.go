@@ -0,0 +1,140 @@
+package openapi2mcp
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+func TestParseMultiStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		want        *MultiStatus
+	}{
+		{
+			name:        "207 with a results array",
+			status:      207,
+			contentType: "application/json",
+			body:        `{"results":[{"id":"a","status":201},{"id":"b","status":404,"error":{"code":"not_found","message":"no such user"}},{"id":"c","status":"HTTP/1.1 409 Conflict","message":"duplicate"}]}`,
+			want: &MultiStatus{Total: 3, Succeeded: 1, Failed: 2, Errors: []MultiStatusItemError{
+				{Index: 1, ID: "b", Status: 404, Error: "not_found: no such user"},
+				{Index: 2, ID: "c", Status: 409, Error: "duplicate"},
+			}},
+		},
+		{
+			name:        "Elasticsearch bulk response",
+			status:      200,
+			contentType: "application/json; charset=utf-8",
+			body:        `{"took":3,"errors":true,"items":[{"index":{"_id":"1","status":201}},{"index":{"_id":"2","status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse"}}}]}`,
+			want: &MultiStatus{Total: 2, Succeeded: 1, Failed: 1, Errors: []MultiStatusItemError{
+				{Index: 1, ID: "2", Status: 400, Error: "mapper_parsing_exception: failed to parse"},
+			}},
+		},
+		{
+			name:        "success flags in a root array",
+			status:      200,
+			contentType: "application/json",
+			body:        `[{"key":"x","success":true},{"key":"y","success":false,"message":"quota exceeded"}]`,
+			want: &MultiStatus{Total: 2, Succeeded: 1, Failed: 1, Errors: []MultiStatusItemError{
+				{Index: 1, ID: "y", Error: "quota exceeded"},
+			}},
+		},
+		{
+			name:        "status words in a 207 response",
+			status:      207,
+			contentType: "application/json",
+			body:        `[{"id":1,"status":"ok"},{"id":2,"status":"failed","reason":"locked"}]`,
+			want: &MultiStatus{Total: 2, Succeeded: 1, Failed: 1, Errors: []MultiStatusItemError{
+				{Index: 1, ID: "2", Error: "locked"},
+			}},
+		},
+		{
+			name:        "WebDAV multistatus",
+			status:      207,
+			contentType: "application/xml; charset=utf-8",
+			body: `<?xml version="1.0"?><d:multistatus xmlns:d="DAV:">
+				<d:response><d:href>/files/a.txt</d:href><d:status>HTTP/1.1 200 OK</d:status></d:response>
+				<d:response><d:href>/files/b.txt</d:href><d:propstat><d:status>HTTP/1.1 200 OK</d:status></d:propstat><d:propstat><d:status>HTTP/1.1 403 Forbidden</d:status></d:propstat><d:responsedescription>read only</d:responsedescription></d:response>
+			</d:multistatus>`,
+			want: &MultiStatus{Total: 2, Succeeded: 1, Failed: 1, Errors: []MultiStatusItemError{
+				{Index: 1, ID: "/files/b.txt", Status: 403, Error: "read only"},
+			}},
+		},
+		{
+			name:        "batch where every item succeeded",
+			status:      200,
+			contentType: "application/json",
+			body:        `{"results":[{"id":"a","status":200},{"id":"b","status":201}]}`,
+		},
+		{
+			name:        "list of records with status words",
+			status:      200,
+			contentType: "application/json",
+			body:        `{"data":[{"id":1,"status":"completed"},{"id":2,"status":"failed"}]}`,
+		},
+		{
+			name:        "list without outcomes",
+			status:      200,
+			contentType: "application/json",
+			body:        `[{"id":1,"name":"rex"},{"id":2,"name":"tom","status":404}]`,
+		},
+		{
+			name:        "error response",
+			status:      400,
+			contentType: "application/json",
+			body:        `[{"id":"a","status":400}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseMultiStatus(tt.status, tt.contentType, []byte(tt.body))
+			if (got == nil) != (tt.want == nil) {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+			if got == nil {
+				return
+			}
+			if got.Total != tt.want.Total || got.Succeeded != tt.want.Succeeded || got.Failed != tt.want.Failed || len(got.Errors) != len(tt.want.Errors) {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+			for i := range got.Errors {
+				if got.Errors[i] != tt.want.Errors[i] {
+					t.Errorf("error %d: expected %+v, got %+v", i, tt.want.Errors[i], got.Errors[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMultiStatusResults(t *testing.T) {
+	body := `{"results":[{"id":"1","status":200},{"id":"2","status":404,"error":"not found"}]}`
+	server := newTestServer(t, mockUpstreamSpec, &ToolGenOptions{}, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/all-failed") {
+			w.WriteHeader(http.StatusMultiStatus)
+			w.Write([]byte(`[{"id":"1","status":404},{"id":"2","status":404}]`))
+			return
+		}
+		w.WriteHeader(http.StatusMultiStatus)
+		w.Write([]byte(body))
+	})
+
+	result := callToolForTest(t, server, "listPets", map[string]any{})
+	text := result.Content[0].(mcp.TextContent).Text
+	if result.IsError || !strings.Contains(text, "Multi-status: 1 of 2 items succeeded, 1 failed\nFailed items:\n- item 1 (2): HTTP 404 not found\nResponse:\n"+body) {
+		t.Errorf("expected a summary before the body, got %q", text)
+	}
+	if ms, ok := result.Meta["multiStatus"].(*MultiStatus); !ok || ms.Failed != 1 {
+		t.Errorf("expected _meta.multiStatus, got %v", result.Meta)
+	}
+
+	result = callToolForTest(t, server, "getPet", map[string]any{"id": "all-failed"})
+	if !result.IsError || result.Meta["error"] == nil {
+		t.Errorf("expected an error result when every item failed, got %+v", result)
+	}
+}
@@ -61,7 +61,10 @@
 package openapi2mcp
 
 import (
+	"time"
+
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
 )
 
 // OpenAPIOperation describes a single OpenAPI operation to be mapped to an MCP tool.
@@ -76,6 +79,8 @@ type OpenAPIOperation struct {
 	RequestBody *openapi3.RequestBodyRef
 	Tags        []string
 	Security    openapi3.SecurityRequirements
+	Callbacks   openapi3.Callbacks
+	Extensions  map[string]any // operation-level x- extensions, e.g. x-mcp-feature-flag
 }
 
 // ToolGenOptions controls tool generation and output for OpenAPI-MCP conversion.
@@ -96,5 +101,50 @@ type ToolGenOptions struct {
 	PrettyPrint             bool
 	Version                 string
 	PostProcessSchema       func(toolName string, schema map[string]any) map[string]any
-	ConfirmDangerousActions bool // if true, add confirmation prompt for dangerous actions
+	ConfirmDangerousActions bool              // if true, add confirmation prompt for dangerous actions
+	ResultStore             *ResultStore      // store for result://{endpoint}/{callId} resources; nil uses DefaultResultStore
+	Accept                  string            // default upstream Accept header for this spec; overrides the x-mcp-accept extension
+	AllowedMethods          []string          // HTTP methods that may become tools (e.g. GET, POST); overrides the x-mcp-allowed-methods extension
+	ToolRules               *ToolRules        // include/exclude rules selecting the operations that become tools; overrides the tool_rules column and the x-mcp-tool-rules extension
+	CallbackReceiver        *CallbackReceiver // receives OpenAPI callbacks as MCP notifications; nil uses DefaultCallbackReceiver
+	ArgTemplates            map[string]string // arguments filled from the session, e.g. {"user_id": "{{jwt.sub}}"}; overrides the x-mcp-arg-templates extension
+	LatencyTracker          *LatencyTracker   // rolling upstream latency per tool, shown by describe; nil uses DefaultLatencyTracker
+	UpstreamMetrics         *UpstreamMetrics  // upstream call counts and duration histograms; nil uses DefaultUpstreamMetrics
+	SpecHealth              *SpecHealth       // auth and connection failure tracking that marks failing specs degraded; nil uses DefaultSpecHealth
+	SlowCallThreshold       time.Duration     // upstream calls at least this slow are logged; overrides the x-mcp-slow-call-threshold extension
+	UserAgent               string            // upstream User-Agent for this spec; overrides the x-mcp-user-agent extension
+	UpstreamHost            string            // Host header of upstream requests, for shared load balancers; overrides the x-mcp-upstream-host extension
+	UpstreamSNI             string            // TLS server name of upstream connections; overrides the x-mcp-upstream-sni extension
+	UpstreamTimeouts        *UpstreamTimeouts // connect, TLS, response-header and idle timeouts of upstream calls; overrides the x-mcp-upstream-timeouts extension
+	UpstreamPolicy          *UpstreamPolicy   // overall timeout, retries and circuit breaker of upstream calls; overrides the upstream_policy column and the x-mcp-upstream-policy extension
+	CircuitBreakers         *CircuitBreakers  // circuit breakers of upstream hosts; nil uses DefaultCircuitBreakers
+	CallPolicy              CallPolicy        // authorization policy deciding each tool call; overrides the x-mcp-policy extension
+	KeyRotation             *auth.KeyRotation // turns and benched keys of specs with several API keys; nil uses auth.DefaultKeyRotation
+	AttributionHeaders      bool              // send X-Forwarded-For and X-MCP-Session-Id upstream; see MCP_ATTRIBUTION_HEADERS
+	PassthroughHeaders      []string          // client headers forwarded upstream (e.g. Accept-Language); overrides the x-mcp-passthrough-headers extension
+	MaxBinaryBodyBytes      int64             // decoded size limit for body_base64 request bodies; overrides the x-mcp-max-body-bytes extension
+	MaxArgsBytes            int64             // size limit for the JSON arguments of a call; overrides the x-mcp-max-args-bytes extension
+	MetadataOperations      bool              // expose HEAD and OPTIONS operations as metadata-only tools; see the x-mcp-metadata-operations extension and MCP_METADATA_OPERATIONS
+	CallJournal             CallJournal       // records accepted and finished calls for crash recovery; nil uses DefaultCallJournal
+	CallEvents              CallEventSink     // receives an event per finished call for analytics and audit exporters; nil uses DefaultCallEventSink
+	PanicTracker            *PanicTracker     // panics recovered in tool handlers per tool, shown in /analytics; nil uses DefaultPanicTracker
+	StrictSchema            bool              // reject arguments not in the tool's input schema; see the x-mcp-strict-schema extension and MCP_STRICT_SCHEMA
+	DisableArgAliases       bool              // don't map case variants of argument names (petId for pet_id) to the declared names; see the x-mcp-arg-aliases extension and MCP_ARG_ALIASES
+	EnforceScopes           bool              // limit tools to sessions whose bearer token grants their OAuth scopes; see the x-mcp-enforce-scopes extension and MCP_ENFORCE_SCOPES
+	Budget                  *BudgetConfig     // cap on the total cost of upstream calls; overrides the x-mcp-budget extension and MCP_BUDGET_LIMIT
+	BudgetTracker           *BudgetTracker    // spend per spec, shown in /analytics; nil uses DefaultBudgetTracker
+	DisableCoalescing       bool              // don't share one upstream request between identical concurrent GET calls; see the x-mcp-coalesce extension and MCP_COALESCE_REQUESTS
+	ResponseCache           ResponseCache     // cache of GET tool responses; nil uses DefaultResponseCache
+	ResponseCacheTTL        time.Duration     // how long GET tool responses are cached without upstream Cache-Control; negative disables; overrides the x-mcp-response-cache extension
+	NoResourceTemplates     bool              // don't expose GET operations with only path parameters as api:// resource templates; see the x-mcp-resource-templates extension and MCP_RESOURCE_TEMPLATES
+	APIResources            bool              // also expose GET operations with query or no parameters as api:// resources; see RegisterOpenAPIResources, the x-mcp-resources extension and MCP_RESOURCES
+	NoAPIPrompt             bool              // don't register the how-to-use-{endpoint} prompt describing the API and its tools; see the x-mcp-prompt extension and MCP_API_PROMPT
+	MaxDescriptionChars     int               // length above which operation descriptions are shortened; overrides the x-mcp-max-description-chars extension
+	SummarizeDescription    DescSummarizer    // optional hook, e.g. LLM-backed, that shortens long descriptions before the rule-based summary
+	BandwidthTracker        *BandwidthTracker // upstream bytes sent and received per spec and session, shown in /analytics; nil uses DefaultBandwidthTracker
+	BandwidthMeta           bool              // add the upstream bytes of each call to its result metadata; see the x-mcp-bandwidth-meta extension and MCP_BANDWIDTH_META
+	ResponseTransforms      []ValueTransform  // conversions of JSON response fields, e.g. epoch times to ISO 8601; overrides the x-mcp-response-transforms extension
+	SchemaDefs              []string          // client name patterns, e.g. "claude*" or "*", whose tool schemas share repeated models under $defs; overrides the x-mcp-schema-defs extension and MCP_SCHEMA_DEFS
+	NoOutputSchema          bool              // don't add the JSON schema of successful responses to tool annotations; see the x-mcp-output-schema extension and MCP_OUTPUT_SCHEMA
+	PreviewWrites           bool              // POST, PUT, PATCH and DELETE calls return the request they would send without sending it; see the x-mcp-preview-writes extension and MCP_PREVIEW_WRITES
 }
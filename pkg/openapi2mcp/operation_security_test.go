@@ -0,0 +1,73 @@
+package openapi2mcp
+
+import (
+	"net/http"
+	"testing"
+)
+
+const mixedSecuritySpec = `
+openapi: 3.0.0
+info:
+  title: Mixed
+  version: 1.0.0
+components:
+  securitySchemes:
+    ApiKeyAuth:
+      type: apiKey
+      in: header
+      name: X-API-Key
+    BearerAuth:
+      type: http
+      scheme: bearer
+security:
+  - ApiKeyAuth: []
+paths:
+  /reports:
+    get:
+      operationId: listReports
+      responses:
+        '200':
+          description: ok
+  /admin/users:
+    get:
+      operationId: listUsers
+      security:
+        - BearerAuth: []
+      responses:
+        '200':
+          description: ok
+  /status:
+    get:
+      operationId: getStatus
+      security: []
+      responses:
+        '200':
+          description: ok
+`
+
+func TestPerOperationSecuritySchemes(t *testing.T) {
+	got := map[string]http.Header{}
+	t.Setenv("API_KEY", "the-key")
+	t.Setenv("BEARER_TOKEN", "the-token")
+
+	server := newTestServer(t, mixedSecuritySpec, nil, func(w http.ResponseWriter, r *http.Request) {
+		got[r.URL.Path] = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	})
+	for _, tool := range []string{"listReports", "listUsers", "getStatus"} {
+		if res := callToolForTest(t, server, tool, map[string]any{}); res.IsError {
+			t.Fatalf("unexpected error from %s: %+v", tool, res)
+		}
+	}
+
+	if h := got["/reports"]; h.Get("X-API-Key") != "the-key" || h.Get("Authorization") != "" {
+		t.Errorf("expected only the API key for listReports, got %v", h)
+	}
+	if h := got["/admin/users"]; h.Get("Authorization") != "Bearer the-token" || h.Get("X-API-Key") != "" {
+		t.Errorf("expected only the bearer token for listUsers, got %v", h)
+	}
+	if h := got["/status"]; h.Get("Authorization") != "" || h.Get("X-API-Key") != "" {
+		t.Errorf("expected no credentials for getStatus, got %v", h)
+	}
+}
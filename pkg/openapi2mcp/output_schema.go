@@ -0,0 +1,91 @@
+package openapi2mcp
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// outputSchemaExtension is the root OpenAPI extension that turns response schemas in tool
+// annotations on or off for a spec.
+const outputSchemaExtension = "x-mcp-output-schema"
+
+// specOutputSchema reports whether tools carry the JSON schema of their successful response
+// in their annotations: off with opts.NoOutputSchema, then the x-mcp-output-schema extension,
+// then MCP_OUTPUT_SCHEMA. On by default.
+func specOutputSchema(doc *openapi3.T, opts *ToolGenOptions) bool {
+	if opts != nil && opts.NoOutputSchema {
+		return false
+	}
+	if doc != nil {
+		if v, ok := doc.Extensions[outputSchemaExtension].(bool); ok {
+			return v
+		}
+	}
+	if v := os.Getenv("MCP_OUTPUT_SCHEMA"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		return err != nil || enabled
+	}
+	return true
+}
+
+// operationOutputSchema returns the JSON schema of an operation's successful JSON response:
+// the first 2xx response by status code, then 2XX, with an application/json (or +json)
+// schema. It returns nil when the operation declares none.
+func operationOutputSchema(doc *openapi3.T, op OpenAPIOperation) map[string]any {
+	if doc == nil || doc.Paths == nil {
+		return nil
+	}
+	item := doc.Paths.Value(op.Path)
+	if item == nil {
+		return nil
+	}
+	operation := item.GetOperation(strings.ToUpper(op.Method))
+	if operation == nil || operation.Responses == nil {
+		return nil
+	}
+	var codes []string
+	for code := range operation.Responses.Map() {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	// "200" sorts before "201" and "2XX"
+	sort.Strings(codes)
+	for _, code := range codes {
+		ref := operation.Responses.Value(code)
+		if ref == nil || ref.Value == nil {
+			continue
+		}
+		if schema := jsonResponseSchema(ref.Value.Content, doc); schema != nil {
+			return schema
+		}
+	}
+	return nil
+}
+
+// jsonResponseSchema returns the schema of the JSON media type of a response, preferring
+// application/json over vendor types such as application/vnd.api+json.
+func jsonResponseSchema(content openapi3.Content, doc *openapi3.T) map[string]any {
+	if mt := content.Get("application/json"); mt != nil && mt.Schema != nil {
+		return extractPropertyWithContext(mt.Schema, doc)
+	}
+	mediaTypes := make([]string, 0, len(content))
+	for mediaType := range content {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+	for _, mediaType := range mediaTypes {
+		base, _, _ := strings.Cut(mediaType, ";")
+		if !strings.HasSuffix(strings.TrimSpace(base), "+json") && strings.TrimSpace(base) != "application/json" {
+			continue
+		}
+		if mt := content[mediaType]; mt != nil && mt.Schema != nil {
+			return extractPropertyWithContext(mt.Schema, doc)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package openapi2mcp
+
+import (
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+const outputSchemaSpec = `
+openapi: 3.0.0
+info:
+  title: Pets
+  version: 1.0.0
+paths:
+  /pets/{id}:
+    get:
+      operationId: getPet
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema: {type: string}
+      responses:
+        '201':
+          description: never sent
+          content:
+            application/json:
+              schema: {type: string}
+        '200':
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+        '404':
+          description: not found
+          content:
+            application/json:
+              schema: {type: object}
+    delete:
+      operationId: deletePet
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema: {type: string}
+      responses:
+        '204':
+          description: deleted
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name: {type: string}
+        age: {type: integer}
+`
+
+func TestOutputSchemaAnnotations(t *testing.T) {
+	server := newTestServer(t, outputSchemaSpec, nil, nil)
+
+	tools := map[string]mcp.Tool{}
+	for _, tool := range server.ListTools() {
+		tools[tool.Name] = tool
+	}
+	schema := tools["getPet"].Annotations.OutputSchema
+	props, _ := schema["properties"].(map[string]any)
+	if schema["type"] != "object" || props["name"] == nil || props["age"] == nil {
+		t.Errorf("expected the 200 response schema with its resolved properties, got %v", schema)
+	}
+	if schema := tools["deletePet"].Annotations.OutputSchema; schema != nil {
+		t.Errorf("expected no output schema for a response without content, got %v", schema)
+	}
+
+	server = newTestServer(t, outputSchemaSpec, &ToolGenOptions{NoOutputSchema: true}, nil)
+	for _, tool := range server.ListTools() {
+		if tool.Annotations.OutputSchema != nil {
+			t.Errorf("expected no output schema with NoOutputSchema, got %v for %s", tool.Annotations.OutputSchema, tool.Name)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package openapi2mcp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/apierrors"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+// PanicStats counts the panics of one tool.
+type PanicStats struct {
+	Endpoint  string    `json:"endpoint"`
+	Tool      string    `json:"tool"`
+	Panics    int64     `json:"panics"`
+	LastPanic string    `json:"last_panic"`
+	LastAt    time.Time `json:"last_at"`
+}
+
+// PanicTracker counts panics recovered in tool handlers per spec endpoint and tool.
+type PanicTracker struct {
+	mu     sync.Mutex
+	panics map[string]*PanicStats // endpoint + "/" + tool
+}
+
+// NewPanicTracker creates an empty panic tracker.
+func NewPanicTracker() *PanicTracker {
+	return &PanicTracker{panics: make(map[string]*PanicStats)}
+}
+
+var (
+	defaultPanicTracker     *PanicTracker
+	defaultPanicTrackerOnce sync.Once
+)
+
+// DefaultPanicTracker returns the process-wide panic tracker, shared by all specs.
+func DefaultPanicTracker() *PanicTracker {
+	defaultPanicTrackerOnce.Do(func() {
+		defaultPanicTracker = NewPanicTracker()
+	})
+	return defaultPanicTracker
+}
+
+// Record counts a panic of a tool.
+func (t *PanicTracker) Record(endpoint, tool, message string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := latencyKey(endpoint, tool)
+	p := t.panics[key]
+	if p == nil {
+		p = &PanicStats{Endpoint: endpoint, Tool: tool}
+		t.panics[key] = p
+	}
+	p.Panics++
+	p.LastPanic = message
+	p.LastAt = time.Now().UTC()
+}
+
+// Count returns the number of panics of all tools of a spec.
+func (t *PanicTracker) Count(endpoint string) int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var n int64
+	for _, p := range t.panics {
+		if p.Endpoint == endpoint {
+			n += p.Panics
+		}
+	}
+	return n
+}
+
+// All returns the panic counts of every tool that panicked, sorted by endpoint and tool.
+func (t *PanicTracker) All() []PanicStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	all := make([]PanicStats, 0, len(t.panics))
+	for _, p := range t.panics {
+		all = append(all, *p)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return latencyKey(all[i].Endpoint, all[i].Tool) < latencyKey(all[j].Endpoint, all[j].Tool)
+	})
+	return all
+}
+
+// panicTrackerFor returns the tracker panics of a spec's tools are counted in.
+func panicTrackerFor(opts *ToolGenOptions) *PanicTracker {
+	if opts != nil && opts.PanicTracker != nil {
+		return opts.PanicTracker
+	}
+	return DefaultPanicTracker()
+}
+
+// recoveredHandler turns a panic in a tool handler into an internal error result, so a bug
+// triggered by one spec's operation fails that call only, instead of the whole request.
+// The panic is logged with its stack trace and counted per spec and tool.
+func recoveredHandler(panics *PanicTracker, endpoint, tool string, next mcpserver.ToolHandlerFunc) mcpserver.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (res *mcp.CallToolResult, err error) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			apiErr := apierrors.NewWithContext(ctx, apierrors.TypeInternal,
+				fmt.Sprintf("tool %s of spec %s panicked", tool, endpoint), fmt.Sprint(r)).WithStackTrace()
+			apiErr.LogError()
+			panics.Record(endpoint, tool, apiErr.Details)
+			res = withErrorMeta(mcp.NewToolResultError(
+				fmt.Sprintf("Internal error in tool %s; the call was aborted.", tool),
+				nil, req.GetArguments(), nil, "", []string{"describe"},
+			), apierrors.TypeInternal, apiErr.Details)
+			err = nil
+		}()
+		return next(ctx, req)
+	}
+}
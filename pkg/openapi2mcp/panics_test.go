@@ -0,0 +1,55 @@
+package openapi2mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/apierrors"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+func TestRecoveredHandlerTurnsPanicIntoInternalError(t *testing.T) {
+	panics := NewPanicTracker()
+	handler := recoveredHandler(panics, "pets", "getPet", func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var m map[string]int
+		m["boom"]++
+		return nil, nil
+	})
+
+	for i := 0; i < 2; i++ {
+		res, err := handler(context.Background(), mcp.CallToolRequest{})
+		if err != nil {
+			t.Fatalf("expected the panic to become a tool result, got error %v", err)
+		}
+		if !res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "Internal error in tool getPet") {
+			t.Fatalf("expected an internal error result, got %+v", res)
+		}
+		meta, ok := res.Meta["error"].(apierrors.Details)
+		if !ok || meta.Type != apierrors.TypeInternal || !strings.Contains(meta.Details, "nil map") {
+			t.Errorf("expected internal error metadata with the panic value, got %+v", res.Meta)
+		}
+	}
+
+	if n := panics.Count("pets"); n != 2 {
+		t.Errorf("expected 2 panics for the spec, got %d", n)
+	}
+	all := panics.All()
+	if len(all) != 1 || all[0].Tool != "getPet" || all[0].Panics != 2 || all[0].LastAt.IsZero() {
+		t.Errorf("unexpected panic stats %+v", all)
+	}
+}
+
+func TestRecoveredHandlerPassesThroughResults(t *testing.T) {
+	panics := NewPanicTracker()
+	want := mcp.NewToolResultText("ok", nil, nil, nil, "", nil)
+	handler := recoveredHandler(panics, "pets", "listPets", func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return want, nil
+	})
+	if res, err := handler(context.Background(), mcp.CallToolRequest{}); res != want || err != nil {
+		t.Errorf("expected the handler's result, got %+v, %v", res, err)
+	}
+	if len(panics.All()) != 0 {
+		t.Error("expected no panics to be recorded")
+	}
+}
@@ -0,0 +1,161 @@
+// param_style.go
+package openapi2mcp
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Serialization of query and header parameters following the OpenAPI style/explode rules.
+// Query parameters default to style=form, explode=true (tags=a&tags=b); headers use
+// style=simple, explode=false (a,b).
+
+// paramStyle returns the effective style and explode of a parameter.
+func paramStyle(p *openapi3.Parameter) (string, bool) {
+	style := p.Style
+	if style == "" {
+		if p.In == "query" || p.In == "cookie" {
+			style = "form"
+		} else {
+			style = "simple"
+		}
+	}
+	explode := style == "form"
+	if p.Explode != nil {
+		explode = *p.Explode
+	}
+	return style, explode
+}
+
+// paramItemIsInteger reports whether the values (or array items) of a parameter are integers.
+func paramItemIsInteger(p *openapi3.Parameter) bool {
+	if p.Schema == nil || p.Schema.Value == nil {
+		return false
+	}
+	s := p.Schema.Value
+	if s.Type != nil && s.Type.Is("array") && s.Items != nil && s.Items.Value != nil {
+		s = s.Items.Value
+	}
+	return s.Type != nil && s.Type.Is("integer")
+}
+
+// paramIsArray reports whether a parameter is declared as an array.
+func paramIsArray(p *openapi3.Parameter) bool {
+	return p.Schema != nil && p.Schema.Value != nil && p.Schema.Value.Type != nil && p.Schema.Value.Type.Is("array")
+}
+
+// paramValues normalizes a tool argument into a list of formatted values. A single value
+// given for an array parameter is treated as a one-element array.
+func paramValues(val any, isInteger bool) []string {
+	switch v := val.(type) {
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			out = append(out, formatParameterValue(item, isInteger))
+		}
+		return out
+	case []string:
+		return v
+	default:
+		return []string{formatParameterValue(val, isInteger)}
+	}
+}
+
+// sortedObjectKeys returns the keys of an object argument in a stable order.
+func sortedObjectKeys(obj map[string]any) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// addQueryParam serializes a query parameter value into query.
+func addQueryParam(query url.Values, p *openapi3.Parameter, val any) {
+	style, explode := paramStyle(p)
+	isInteger := paramItemIsInteger(p)
+
+	if obj, ok := val.(map[string]any); ok {
+		keys := sortedObjectKeys(obj)
+		switch {
+		case style == "deepObject":
+			for _, k := range keys {
+				query.Add(fmt.Sprintf("%s[%s]", p.Name, k), formatParameterValue(obj[k], false))
+			}
+		case explode:
+			for _, k := range keys {
+				query.Add(k, formatParameterValue(obj[k], false))
+			}
+		default:
+			var parts []string
+			for _, k := range keys {
+				parts = append(parts, k, formatParameterValue(obj[k], false))
+			}
+			query.Set(p.Name, strings.Join(parts, ","))
+		}
+		return
+	}
+
+	_, isList := val.([]any)
+	if !isList && !paramIsArray(p) {
+		query.Set(p.Name, formatParameterValue(val, isInteger))
+		return
+	}
+	values := paramValues(val, isInteger)
+	switch {
+	case style == "spaceDelimited" && !explode:
+		query.Set(p.Name, strings.Join(values, " "))
+	case style == "pipeDelimited" && !explode:
+		query.Set(p.Name, strings.Join(values, "|"))
+	case explode:
+		for _, v := range values {
+			query.Add(p.Name, v)
+		}
+	default:
+		query.Set(p.Name, strings.Join(values, ","))
+	}
+}
+
+// headerParamValue serializes a header parameter value (style=simple).
+func headerParamValue(p *openapi3.Parameter, val any) string {
+	_, explode := paramStyle(p)
+	if obj, ok := val.(map[string]any); ok {
+		var parts []string
+		for _, k := range sortedObjectKeys(obj) {
+			if explode {
+				parts = append(parts, k+"="+formatParameterValue(obj[k], false))
+			} else {
+				parts = append(parts, k, formatParameterValue(obj[k], false))
+			}
+		}
+		return strings.Join(parts, ",")
+	}
+	return strings.Join(paramValues(val, paramItemIsInteger(p)), ",")
+}
+
+// arrayParamHint tells the model how an array query or header parameter is sent.
+func arrayParamHint(p *openapi3.Parameter) string {
+	if !paramIsArray(p) || (p.In != "query" && p.In != "header") {
+		return ""
+	}
+	style, explode := paramStyle(p)
+	var example string
+	switch {
+	case p.In == "header":
+		example = p.Name + ": a,b"
+	case style == "spaceDelimited" && !explode:
+		example = p.Name + "=a%20b"
+	case style == "pipeDelimited" && !explode:
+		example = p.Name + "=a|b"
+	case explode:
+		example = p.Name + "=a&" + p.Name + "=b"
+	default:
+		example = p.Name + "=a,b"
+	}
+	return fmt.Sprintf("Pass a list of values; sent as %s.", example)
+}
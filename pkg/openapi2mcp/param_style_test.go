@@ -0,0 +1,91 @@
+package openapi2mcp
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func arrayParam(in, style string, explode *bool, itemType string) *openapi3.Parameter {
+	return &openapi3.Parameter{
+		Name:    "tags",
+		In:      in,
+		Style:   style,
+		Explode: explode,
+		Schema: openapi3.NewSchemaRef("", &openapi3.Schema{
+			Type:  &openapi3.Types{"array"},
+			Items: openapi3.NewSchemaRef("", &openapi3.Schema{Type: &openapi3.Types{itemType}}),
+		}),
+	}
+}
+
+func TestAddQueryParam(t *testing.T) {
+	no := false
+	tests := []struct {
+		name  string
+		param *openapi3.Parameter
+		val   any
+		want  string
+	}{
+		{"form explode", arrayParam("query", "", nil, "string"), []any{"a", "b"}, "tags=a&tags=b"},
+		{"form no explode", arrayParam("query", "form", &no, "string"), []any{"a", "b"}, "tags=a%2Cb"},
+		{"pipe delimited", arrayParam("query", "pipeDelimited", &no, "string"), []any{"a", "b"}, "tags=a%7Cb"},
+		{"integer items", arrayParam("query", "", nil, "integer"), []any{float64(1), float64(2)}, "tags=1&tags=2"},
+		{"single value for array", arrayParam("query", "", nil, "string"), "a", "tags=a"},
+		{"deep object", &openapi3.Parameter{Name: "filter", In: "query", Style: "deepObject"}, map[string]any{"status": "open", "owner": "me"}, "filter%5Bowner%5D=me&filter%5Bstatus%5D=open"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := url.Values{}
+			addQueryParam(query, tt.param, tt.val)
+			if got := query.Encode(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeaderParamValue(t *testing.T) {
+	if got := headerParamValue(arrayParam("header", "", nil, "string"), []any{"a", "b"}); got != "a,b" {
+		t.Errorf("got %q, want a,b", got)
+	}
+	yes := true
+	obj := map[string]any{"role": "admin", "id": float64(5)}
+	if got := headerParamValue(&openapi3.Parameter{Name: "X-Filter", In: "header", Explode: &yes}, obj); got != "id=5,role=admin" {
+		t.Errorf("got %q, want id=5,role=admin", got)
+	}
+}
+
+func TestRepeatedQueryParameterCall(t *testing.T) {
+	var gotQuery string
+	spec := strings.Replace(mockUpstreamSpec, "      operationId: listPets\n", `      operationId: listPets
+      parameters:
+        - name: tags
+          in: query
+          schema:
+            type: array
+            items:
+              type: string
+`, 1)
+	server := newTestServer(t, spec, nil, func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	})
+	for _, tool := range server.ListTools() {
+		if tool.Name == "listPets" && !strings.Contains(string(tool.RawInputSchema), `"type":"array"`) {
+			t.Errorf("expected tags to be surfaced as an array, got %s", tool.RawInputSchema)
+		}
+	}
+
+	res := callToolForTest(t, server, "listPets", map[string]any{"tags": []any{"dog", "cat"}})
+	if res.IsError {
+		t.Fatalf("unexpected error result: %+v", res)
+	}
+	if gotQuery != "tags=dog&tags=cat" {
+		t.Errorf("got query %q, want tags=dog&tags=cat", gotQuery)
+	}
+}
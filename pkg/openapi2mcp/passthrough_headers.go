@@ -0,0 +1,83 @@
+// passthrough_headers.go
+package openapi2mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// passthroughHeadersExtension is the root-level spec extension listing client headers that are
+// forwarded upstream as-is, e.g. `x-mcp-passthrough-headers: [Accept-Language, X-Tenant-Id]`.
+const passthroughHeadersExtension = "x-mcp-passthrough-headers"
+
+// nonPassthroughHeaders are connection-level headers that never make sense to forward.
+var nonPassthroughHeaders = map[string]bool{
+	"Connection":          true,
+	"Content-Length":      true,
+	"Content-Type":        true,
+	"Host":                true,
+	"Keep-Alive":          true,
+	"Proxy-Authorization": true,
+	"Te":                  true,
+	"Trailer":             true,
+	"Transfer-Encoding":   true,
+	"Upgrade":             true,
+}
+
+// specPassthroughHeaders returns the canonical names of the headers to forward, from opts or
+// the x-mcp-passthrough-headers extension. Names that must not be forwarded are dropped.
+func specPassthroughHeaders(doc *openapi3.T, opts *ToolGenOptions) []string {
+	var names []string
+	if opts != nil && len(opts.PassthroughHeaders) > 0 {
+		names = opts.PassthroughHeaders
+	} else if doc != nil {
+		switch v := doc.Extensions[passthroughHeadersExtension].(type) {
+		case string:
+			names = strings.Split(v, ",")
+		case []any:
+			for _, item := range v {
+				if s, ok := item.(string); ok {
+					names = append(names, s)
+				}
+			}
+		case []string:
+			names = v
+		}
+	}
+	var allowed []string
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		if nonPassthroughHeaders[name] {
+			fmt.Fprintf(os.Stderr, "[WARN] Header %s cannot be passed through and is ignored\n", name)
+			continue
+		}
+		seen[name] = true
+		allowed = append(allowed, name)
+	}
+	return allowed
+}
+
+// copyPassthroughHeaders copies the allowlisted headers of the client's request to an upstream request.
+func copyPassthroughHeaders(ctx context.Context, req *http.Request, allowed []string) {
+	if len(allowed) == 0 {
+		return
+	}
+	incoming := sessionHeaders(ctx)
+	for _, name := range allowed {
+		if values := incoming.Values(name); len(values) > 0 {
+			req.Header.Del(name)
+			for _, v := range values {
+				req.Header.Add(name, v)
+			}
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package openapi2mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
+)
+
+func TestSpecPassthroughHeaders(t *testing.T) {
+	doc := &openapi3.T{Extensions: map[string]any{
+		passthroughHeadersExtension: []any{"accept-language", "X-Tenant-Id", "Host", "x-tenant-id"},
+	}}
+	want := []string{"Accept-Language", "X-Tenant-Id"}
+	if got := specPassthroughHeaders(doc, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	opts := &ToolGenOptions{PassthroughHeaders: []string{"X-Request-Id"}}
+	if got := specPassthroughHeaders(doc, opts); !reflect.DeepEqual(got, []string{"X-Request-Id"}) {
+		t.Errorf("expected options to override the extension, got %v", got)
+	}
+}
+
+func TestCopyPassthroughHeaders(t *testing.T) {
+	incoming := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	incoming.Header.Set("Accept-Language", "de-CH")
+	incoming.Header.Set("X-Tenant-Id", "acme")
+	incoming.Header.Set("X-Internal-Secret", "nope")
+	ctx := auth.WithAuthContext(context.Background(), &auth.AuthContext{OriginalRequest: incoming})
+
+	req := httptest.NewRequest(http.MethodGet, "http://upstream/pets", nil)
+	copyPassthroughHeaders(ctx, req, []string{"Accept-Language", "X-Tenant-Id", "X-Missing"})
+	if req.Header.Get("Accept-Language") != "de-CH" || req.Header.Get("X-Tenant-Id") != "acme" {
+		t.Errorf("expected allowlisted headers to be copied, got %v", req.Header)
+	}
+	if req.Header.Get("X-Internal-Secret") != "" || len(req.Header.Values("X-Missing")) != 0 {
+		t.Errorf("expected only allowlisted headers present on the client request, got %v", req.Header)
+	}
+}
@@ -0,0 +1,168 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+// previewWritesExtension is the root OpenAPI extension that makes a spec's write operations
+// return the request they would send instead of sending it (`x-mcp-preview-writes: true`).
+const previewWritesExtension = "x-mcp-preview-writes"
+
+// dryRunArg is the argument asking a single write call for a preview.
+const dryRunArg = "__dry_run"
+
+// redacted replaces the values of credentials in previews.
+const redacted = "[REDACTED]"
+
+// specPreviewWrites reports whether a spec's write operations only preview their request:
+// on with opts.PreviewWrites, then the x-mcp-preview-writes extension, then MCP_PREVIEW_WRITES.
+// Off by default.
+func specPreviewWrites(doc *openapi3.T, opts *ToolGenOptions) bool {
+	if opts != nil && opts.PreviewWrites {
+		return true
+	}
+	if doc != nil {
+		if v, ok := doc.Extensions[previewWritesExtension].(bool); ok {
+			return v
+		}
+	}
+	enabled, _ := strconv.ParseBool(os.Getenv("MCP_PREVIEW_WRITES"))
+	return enabled
+}
+
+// isWriteMethod reports whether method changes the upstream state: POST, PUT, PATCH or DELETE.
+func isWriteMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// addDryRunProperty advertises the __dry_run argument on write operations.
+func addDryRunProperty(schema map[string]any) {
+	props, ok := schema["properties"].(map[string]any)
+	if !ok {
+		props = map[string]any{}
+		schema["properties"] = props
+	}
+	if _, taken := props[dryRunArg]; taken {
+		return
+	}
+	props[dryRunArg] = map[string]any{
+		"type":        "boolean",
+		"description": "If true, return the HTTP request this call would send (method, URL, headers with credentials masked, body) without sending it.",
+	}
+}
+
+// takeDryRunArg removes the __dry_run argument and reports whether it asked for a preview.
+func takeDryRunArg(args map[string]any) bool {
+	v, ok := args[dryRunArg]
+	if !ok {
+		return false
+	}
+	delete(args, dryRunArg)
+	switch v := v.(type) {
+	case bool:
+		return v
+	case string:
+		b, _ := strconv.ParseBool(v)
+		return b
+	}
+	return false
+}
+
+// isSensitiveHeader reports whether a header carries credentials.
+func isSensitiveHeader(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range []string{"auth", "key", "token", "secret", "cookie", "signature", "password"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// previewResult describes the request a write call would send, with the authentication the
+// secure client would add and credentials masked, as a tool result. Nothing is sent upstream.
+func previewResult(ctx context.Context, op OpenAPIOperation, req *http.Request, body []byte, authProvider auth.SecureAuthProvider) *mcp.CallToolResult {
+	headers := map[string]string{}
+	for name, values := range req.Header {
+		headers[name] = strings.Join(values, ", ")
+	}
+	for name, value := range authProvider.GetAuthHeaders(ctx) {
+		headers[name] = value
+	}
+	for name := range headers {
+		if isSensitiveHeader(name) {
+			headers[name] = redacted
+		}
+	}
+
+	u := *req.URL
+	query := u.Query()
+	for name := range query {
+		if isSensitiveHeader(name) {
+			query.Set(name, redacted)
+		}
+	}
+	authParams := authProvider.GetAuthQueryParams(ctx)
+	names := make([]string, 0, len(authParams))
+	for name := range authParams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		query.Set(name, redacted)
+	}
+	u.RawQuery = query.Encode()
+
+	resultObj := map[string]any{
+		"type":    "dry_run",
+		"message": fmt.Sprintf("Dry run: %s %s was not sent.", req.Method, u.Path),
+		"request": map[string]any{
+			"method":  req.Method,
+			"url":     u.String(),
+			"headers": headers,
+		},
+		"operation": map[string]any{
+			"id":      op.OperationID,
+			"summary": op.Summary,
+		},
+	}
+	if len(body) > 0 {
+		var parsed any
+		if json.Unmarshal(body, &parsed) == nil {
+			resultObj["request"].(map[string]any)["body"] = parsed
+		} else if utf8.Valid(body) {
+			resultObj["request"].(map[string]any)["body"] = string(body)
+		} else {
+			resultObj["request"].(map[string]any)["body_bytes"] = len(body)
+		}
+	}
+	resultJSON, _ := json.MarshalIndent(resultObj, "", "  ")
+	res := &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: string(resultJSON),
+			},
+		},
+		OutputFormat: "structured",
+		OutputType:   "json",
+	}
+	res.Meta = map[string]any{"dryRun": true}
+	return res
+}
@@ -0,0 +1,109 @@
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+const previewSpec = `
+openapi: 3.0.0
+info:
+  title: Pets
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        '200':
+          description: ok
+    post:
+      operationId: createPet
+      parameters:
+        - name: X-Request-Id
+          in: header
+          schema: {type: string}
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                name: {type: string}
+      responses:
+        '201':
+          description: created
+`
+
+// previewUpstream counts the requests that reach the upstream API in calls
+func previewUpstream(calls *int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1}`))
+	}
+}
+
+func previewText(res mcp.CallToolResult) string {
+	if len(res.Content) == 0 {
+		return ""
+	}
+	text, _ := res.Content[0].(mcp.TextContent)
+	return text.Text
+}
+
+func TestDryRunArgument(t *testing.T) {
+	t.Setenv("BEARER_TOKEN", "s3cret-token")
+	calls := new(int)
+	server := newTestServer(t, previewSpec, &ToolGenOptions{}, previewUpstream(calls))
+
+	res := callToolForTest(t, server, "createPet", map[string]any{
+		"__dry_run":    true,
+		"X-Request-Id": "abc",
+		"requestBody":  map[string]any{"name": "Rex"},
+	})
+	if *calls != 0 {
+		t.Fatalf("expected no upstream call for a dry run, got %d", *calls)
+	}
+	var preview struct {
+		Type    string `json:"type"`
+		Request struct {
+			Method  string            `json:"method"`
+			URL     string            `json:"url"`
+			Headers map[string]string `json:"headers"`
+			Body    map[string]any    `json:"body"`
+		} `json:"request"`
+	}
+	if err := json.Unmarshal([]byte(previewText(res)), &preview); err != nil {
+		t.Fatalf("expected a JSON preview, got %q: %v", previewText(res), err)
+	}
+	if preview.Type != "dry_run" || preview.Request.Method != "POST" || !strings.HasSuffix(preview.Request.URL, "/pets") {
+		t.Errorf("unexpected preview %+v", preview)
+	}
+	if preview.Request.Body["name"] != "Rex" || preview.Request.Headers["X-Request-Id"] != "abc" {
+		t.Errorf("expected the body and header parameters in the preview, got %+v", preview.Request)
+	}
+	if strings.Contains(previewText(res), "s3cret-token") {
+		t.Errorf("expected credentials to be masked, got %s", previewText(res))
+	}
+
+	// Without the argument, and for reads, the request is sent
+	callToolForTest(t, server, "createPet", map[string]any{"requestBody": map[string]any{"name": "Rex"}})
+	callToolForTest(t, server, "listPets", map[string]any{"__dry_run": true})
+	if *calls != 2 {
+		t.Errorf("expected 2 upstream calls, got %d", *calls)
+	}
+}
+
+func TestPreviewWrites(t *testing.T) {
+	calls := new(int)
+	server := newTestServer(t, previewSpec, &ToolGenOptions{PreviewWrites: true}, previewUpstream(calls))
+	res := callToolForTest(t, server, "createPet", map[string]any{"requestBody": map[string]any{"name": "Rex"}})
+	if *calls != 0 || !strings.Contains(previewText(res), `"dry_run"`) {
+		t.Errorf("expected a preview without an upstream call, got %d calls and %s", *calls, previewText(res))
+	}
+}
@@ -0,0 +1,206 @@
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// ProblemDetails is the failure reason of an upstream error response, parsed from RFC 7807
+// problem+json or a common error envelope. Error results carry it as _meta.problem.
+type ProblemDetails struct {
+	Type     string   `json:"type,omitempty"`     // problem type URI
+	Title    string   `json:"title,omitempty"`    // short summary of the problem
+	Detail   string   `json:"detail,omitempty"`   // explanation specific to this occurrence
+	Code     string   `json:"code,omitempty"`     // API-specific error code
+	Status   int      `json:"status,omitempty"`   // HTTP status reported in the body
+	Instance string   `json:"instance,omitempty"` // URI of this occurrence
+	Errors   []string `json:"errors,omitempty"`   // individual errors, e.g. per invalid field
+}
+
+// parseProblemDetails extracts the failure reason from an upstream error body. It
+// understands RFC 7807/9457 problem details and these envelopes:
+//
+//	{"error": {"code": ..., "message": ..., "details": [...]}}
+//	{"error": "invalid_grant", "error_description": "..."}
+//	{"errors": [{"code": ..., "message"|"detail"|"title": ..., "field"|"source": ...}]}
+//	{"message": ..., "code": ...}
+//
+// It returns nil when the body is not JSON or has none of these shapes.
+func parseProblemDetails(contentType string, body []byte) *ProblemDetails {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "" && mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return nil
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return nil
+	}
+
+	p := &ProblemDetails{}
+	if mediaType == "application/problem+json" || hasAny(obj, "title", "detail") {
+		p.Type = stringField(obj, "type")
+		p.Title = stringField(obj, "title")
+		p.Detail = stringField(obj, "detail")
+		p.Instance = stringField(obj, "instance")
+		p.Code = stringField(obj, "code")
+		p.Status = intField(obj, "status")
+		p.Errors = errorList(obj["errors"])
+		if p.Errors == nil {
+			p.Errors = errorList(obj["invalid-params"])
+		}
+		return p.orNil()
+	}
+
+	switch e := obj["error"].(type) {
+	case map[string]any:
+		p.Code = firstString(e, "code", "type", "status")
+		p.Title = stringField(e, "title")
+		p.Detail = firstString(e, "message", "detail", "description")
+		p.Status = intField(e, "status")
+		p.Errors = errorList(firstPresent(e, "details", "errors"))
+		return p.orNil()
+	case string:
+		p.Code = e
+		p.Detail = firstString(obj, "error_description", "message", "detail")
+		return p.orNil()
+	}
+
+	if errs := errorList(obj["errors"]); len(errs) > 0 {
+		p.Detail = firstString(obj, "message", "detail")
+		p.Errors = errs
+		if list, ok := obj["errors"].([]any); ok && len(list) == 1 {
+			if first, ok := list[0].(map[string]any); ok {
+				p.Code = firstString(first, "code", "type")
+			}
+		}
+		return p.orNil()
+	}
+
+	p.Detail = firstString(obj, "message", "error_message", "errorMessage")
+	p.Code = firstString(obj, "code", "error_code", "errorCode")
+	if p.Detail == "" {
+		return nil
+	}
+	return p
+}
+
+func (p *ProblemDetails) orNil() *ProblemDetails {
+	if p.Title == "" && p.Detail == "" && p.Code == "" && len(p.Errors) == 0 {
+		return nil
+	}
+	return p
+}
+
+// Summary is a one-line description of the problem, e.g. "Out of credit: Your balance is 30 (code insufficient_funds)".
+func (p *ProblemDetails) Summary() string {
+	var parts []string
+	if p.Title != "" {
+		parts = append(parts, p.Title)
+	}
+	if p.Detail != "" && p.Detail != p.Title {
+		parts = append(parts, p.Detail)
+	}
+	summary := strings.Join(parts, ": ")
+	if p.Code != "" {
+		if summary == "" {
+			return "code " + p.Code
+		}
+		summary += " (code " + p.Code + ")"
+	}
+	return summary
+}
+
+// errorList formats a list of errors: strings, or objects with a message and the field it concerns.
+func errorList(v any) []string {
+	list, ok := v.([]any)
+	if !ok {
+		return nil
+	}
+	var out []string
+	for _, item := range list {
+		switch e := item.(type) {
+		case string:
+			out = append(out, e)
+		case map[string]any:
+			msg := firstString(e, "message", "detail", "reason", "title", "description")
+			field := firstString(e, "field", "name", "param", "path", "location")
+			if field == "" {
+				if src, ok := e["source"].(map[string]any); ok {
+					field = firstString(src, "pointer", "parameter", "header")
+				}
+			}
+			switch {
+			case msg != "" && field != "":
+				out = append(out, field+": "+msg)
+			case msg != "":
+				out = append(out, msg)
+			}
+		}
+	}
+	return out
+}
+
+func hasAny(obj map[string]any, keys ...string) bool {
+	for _, k := range keys {
+		if _, ok := obj[k].(string); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func firstPresent(obj map[string]any, keys ...string) any {
+	for _, k := range keys {
+		if v, ok := obj[k]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+func firstString(obj map[string]any, keys ...string) string {
+	for _, k := range keys {
+		if s := stringField(obj, k); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// stringField returns a string or number field as a string.
+func stringField(obj map[string]any, key string) string {
+	switch v := obj[key].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+func intField(obj map[string]any, key string) int {
+	switch v := obj[key].(type) {
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
+
+// problemText is the text of an error result with problem details: the summary and the
+// individual errors instead of the raw body.
+func problemText(p *ProblemDetails) string {
+	text := "Details:"
+	if summary := p.Summary(); summary != "" {
+		text += " " + summary
+	}
+	for _, e := range p.Errors {
+		text += fmt.Sprintf("\n  - %s", e)
+	}
+	return text
+}
@@ -0,0 +1,73 @@
+package openapi2mcp
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+func TestParseProblemDetails(t *testing.T) {
+	cases := []struct {
+		name, contentType, body string
+		want                    string // Summary, then the errors
+	}{
+		{"rfc7807", "application/problem+json",
+			`{"type":"https://example.com/probs/out-of-credit","title":"You do not have enough credit.","detail":"Your current balance is 30, but that costs 50.","status":403}`,
+			"You do not have enough credit.: Your current balance is 30, but that costs 50."},
+		{"rfc7807 invalid params", "application/problem+json",
+			`{"title":"Your request parameters didn't validate.","invalid-params":[{"name":"age","reason":"must be a positive integer"}]}`,
+			"Your request parameters didn't validate.|age: must be a positive integer"},
+		{"error object", "application/json",
+			`{"error":{"code":"rate_limited","message":"Too many requests","details":["retry in 30s"]}}`,
+			"Too many requests (code rate_limited)|retry in 30s"},
+		{"oauth error", "application/json; charset=utf-8",
+			`{"error":"invalid_grant","error_description":"The refresh token has expired"}`,
+			"The refresh token has expired (code invalid_grant)"},
+		{"errors array", "application/vnd.api+json",
+			`{"errors":[{"code":"required","detail":"can't be blank","source":{"pointer":"/data/attributes/name"}}]}`,
+			"code required|/data/attributes/name: can't be blank"},
+		{"message", "application/json", `{"message":"Not Found","code":404}`, "Not Found (code 404)"},
+	}
+	for _, tc := range cases {
+		p := parseProblemDetails(tc.contentType, []byte(tc.body))
+		if p == nil {
+			t.Errorf("%s: expected problem details", tc.name)
+			continue
+		}
+		if got := strings.Join(append([]string{p.Summary()}, p.Errors...), "|"); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	for _, body := range []string{`<html>Bad Gateway</html>`, `{"id":1}`, `[1,2]`} {
+		if p := parseProblemDetails("application/json", []byte(body)); p != nil {
+			t.Errorf("expected no problem details for %s, got %+v", body, p)
+		}
+	}
+	if p := parseProblemDetails("text/html", []byte(`{"message":"x"}`)); p != nil {
+		t.Errorf("expected non-JSON responses to be left alone, got %+v", p)
+	}
+}
+
+func TestProblemDetailsInErrorResult(t *testing.T) {
+	server := newTestServer(t, mockUpstreamSpec, nil, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"title":"Pet already adopted","detail":"Pet 1 was adopted on 2024-05-01","code":"adopted"}`))
+	})
+
+	res := callToolForTest(t, server, "getPet", map[string]any{"id": 1})
+	if !res.IsError {
+		t.Fatal("expected an error result")
+	}
+	text := res.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "Details: Pet already adopted: Pet 1 was adopted on 2024-05-01 (code adopted)") || strings.Contains(text, `"title"`) {
+		t.Errorf("expected the problem summary instead of the raw body, got %s", text)
+	}
+	problem, ok := res.Meta["problem"].(*ProblemDetails)
+	if !ok || problem.Title != "Pet already adopted" || problem.Code != "adopted" {
+		t.Errorf("expected structured problem details in _meta, got %#v", res.Meta["problem"])
+	}
+}
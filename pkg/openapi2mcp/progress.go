@@ -0,0 +1,133 @@
+package openapi2mcp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+// progressInterval is the minimum time between two download progress notifications.
+const progressInterval = 250 * time.Millisecond
+
+// Progress of an upstream call before its response body is read: connecting to the upstream
+// API and receiving the response headers count as one unit each, and each byte of the body
+// as one more, so progress always increases.
+const (
+	progressConnected       = 1
+	progressHeadersReceived = 2
+)
+
+// progressReporter sends the notifications/progress of a tool call to the client that asked
+// for them with a progressToken in the _meta of its tools/call request.
+type progressReporter struct {
+	ctx    context.Context
+	server *mcpserver.MCPServer
+	token  mcp.ProgressToken
+
+	mu       sync.Mutex
+	progress float64
+	lastSent time.Time
+	now      func() time.Time
+}
+
+// newProgressReporter returns the reporter of a tool call, or nil when the client did not
+// ask for progress or the call is not served by an MCP server.
+func newProgressReporter(ctx context.Context, req mcp.CallToolRequest) *progressReporter {
+	if req.Params.Meta == nil || req.Params.Meta.ProgressToken == nil {
+		return nil
+	}
+	server := mcpserver.ServerFromContext(ctx)
+	if server == nil {
+		return nil
+	}
+	return &progressReporter{ctx: ctx, server: server, token: req.Params.Meta.ProgressToken, now: time.Now}
+}
+
+// report sends a notification unless progress does not increase. total is 0 when unknown.
+func (p *progressReporter) report(progress, total float64, message string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if progress <= p.progress {
+		return
+	}
+	p.progress = progress
+	p.lastSent = p.now()
+	params := map[string]any{"progressToken": p.token, "progress": progress}
+	if total > 0 {
+		params["total"] = total
+	}
+	if message != "" {
+		params["message"] = message
+	}
+	// Progress is best effort: a client that stopped listening does not fail the call
+	_ = p.server.SendNotificationToClient(p.ctx, "notifications/progress", params)
+}
+
+// reportDownload reports the body bytes read so far, at most every progressInterval unless
+// the body is complete.
+func (p *progressReporter) reportDownload(n, length int64, done bool) {
+	p.mu.Lock()
+	throttled := !done && p.now().Sub(p.lastSent) < progressInterval
+	p.mu.Unlock()
+	if throttled {
+		return
+	}
+	total := float64(0)
+	message := fmt.Sprintf("downloaded %d bytes", n)
+	if length >= 0 {
+		total = progressHeadersReceived + float64(length)
+		message = fmt.Sprintf("downloaded %d of %d bytes", n, length)
+	}
+	p.report(progressHeadersReceived+float64(n), total, message)
+}
+
+// trace returns ctx with an HTTP client trace that reports when the upstream connection is
+// established and when the response headers arrive. It returns ctx as is for a nil reporter.
+func (p *progressReporter) trace(ctx context.Context) context.Context {
+	if p == nil {
+		return ctx
+	}
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) {
+			p.report(progressConnected, 0, "connected to the upstream API")
+		},
+		GotFirstResponseByte: func() {
+			p.report(progressHeadersReceived, 0, "received the response headers")
+		},
+	})
+}
+
+// body wraps the body of resp to report the bytes downloaded as it is read. It returns the
+// body as is for a nil reporter.
+func (p *progressReporter) body(resp *http.Response) io.ReadCloser {
+	if p == nil {
+		return resp.Body
+	}
+	// Headers may come from a coalesced call, whose trace was another call's
+	p.report(progressHeadersReceived, 0, "received the response headers")
+	return &progressBody{ReadCloser: resp.Body, reporter: p, length: resp.ContentLength}
+}
+
+// progressBody reports the bytes read from an upstream response body.
+type progressBody struct {
+	io.ReadCloser
+	reporter *progressReporter
+	length   int64 // -1 when unknown
+	n        int64
+}
+
+func (b *progressBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if n > 0 || err == io.EOF {
+		b.reporter.reportDownload(b.n, b.length, err == io.EOF)
+	}
+	return n, err
+}
@@ -0,0 +1,81 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+type notificationSession struct {
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *notificationSession) Initialize()       {}
+func (s *notificationSession) Initialized() bool { return true }
+func (s *notificationSession) SessionID() string { return "progress-session" }
+func (s *notificationSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+func callToolWithMeta(t *testing.T, server *mcpserver.MCPServer, session mcpserver.ClientSession, name string, meta map[string]any) {
+	t.Helper()
+	params := map[string]any{"name": name, "arguments": map[string]any{}}
+	if meta != nil {
+		params["_meta"] = meta
+	}
+	req, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": params})
+	ctx := server.WithContext(context.Background(), session)
+	if _, ok := server.HandleMessage(ctx, req).(mcp.JSONRPCResponse); !ok {
+		t.Fatalf("expected JSONRPCResponse for %s", name)
+	}
+}
+
+func TestProgressNotifications(t *testing.T) {
+	body := `[` + strings.Repeat(`{"id":1,"name":"rex"},`, 200) + `{"id":2}]`
+	server := newTestServer(t, mockUpstreamSpec, &ToolGenOptions{}, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Write([]byte(body))
+	})
+
+	t.Run("calls with a progressToken report their progress", func(t *testing.T) {
+		session := &notificationSession{notifications: make(chan mcp.JSONRPCNotification, 100)}
+		callToolWithMeta(t, server, session, "listPets", map[string]any{"progressToken": "tok-1"})
+		close(session.notifications)
+
+		var messages []string
+		last := 0.0
+		for n := range session.notifications {
+			if n.Method != "notifications/progress" || n.Params.AdditionalFields["progressToken"] != "tok-1" {
+				t.Fatalf("unexpected notification: %+v", n)
+			}
+			progress := n.Params.AdditionalFields["progress"].(float64)
+			if progress <= last {
+				t.Errorf("expected progress to increase, got %v after %v", progress, last)
+			}
+			last = progress
+			messages = append(messages, n.Params.AdditionalFields["message"].(string))
+		}
+		if len(messages) < 3 || messages[0] != "connected to the upstream API" || messages[1] != "received the response headers" {
+			t.Fatalf("expected the connection, headers and download to be reported, got %q", messages)
+		}
+		want := "downloaded " + strconv.Itoa(len(body)) + " of " + strconv.Itoa(len(body)) + " bytes"
+		if messages[len(messages)-1] != want || last != float64(progressHeadersReceived+len(body)) {
+			t.Errorf("expected the last notification to report the whole body, got %q at %v", messages[len(messages)-1], last)
+		}
+	})
+
+	t.Run("calls without a progressToken send nothing", func(t *testing.T) {
+		session := &notificationSession{notifications: make(chan mcp.JSONRPCNotification, 100)}
+		callToolWithMeta(t, server, session, "listPets", nil)
+		if len(session.notifications) != 0 {
+			t.Errorf("expected no notifications, got %d", len(session.notifications))
+		}
+	})
+}
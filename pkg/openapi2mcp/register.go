@@ -6,6 +6,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -14,11 +15,11 @@ import (
 	"net/url"
 	"os"
 	"runtime"
-	"runtime/debug"
 	"strings"
 	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/apierrors"
 	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
 	"github.com/ubermorgenland/openapi-mcp/pkg/database"
 	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
@@ -57,7 +58,9 @@ func NewToolRegistrar(server *mcpserver.MCPServer, doc *openapi3.T, opts *ToolGe
 func (tr *ToolRegistrar) setupConfiguration() {
 	// Setup base URLs
 	tr.baseURLs = []string{}
-	if os.Getenv("OPENAPI_BASE_URL") != "" {
+	if url := mockUpstreamURL(tr.doc); url != "" {
+		tr.baseURLs = append(tr.baseURLs, url)
+	} else if os.Getenv("OPENAPI_BASE_URL") != "" {
 		tr.baseURLs = append(tr.baseURLs, os.Getenv("OPENAPI_BASE_URL"))
 	} else if tr.doc.Servers != nil && len(tr.doc.Servers) > 0 {
 		for _, s := range tr.doc.Servers {
@@ -114,11 +117,10 @@ func (tr *ToolRegistrar) processOperations(ops []OpenAPIOperation) []string {
 			continue
 		}
 
-		// Memory management and logging
-		if processedCount > 0 && processedCount%10 == 0 {
-			runtime.GC()
-			debug.FreeOSMemory()
-			log.Printf("🧹 Memory cleanup after processing %d/%d operations", processedCount, actualOpsCount)
+		// Memory management: the runtime's soft limit drives GC, the limiter is the backstop
+		if !registrationMemoryOK() {
+			log.Printf("🛑 Memory limit exceeded after %d/%d operations, stopping registration", processedCount, actualOpsCount)
+			break
 		}
 
 		log.Printf("🔄 Processing operation %d/%d: %s", processedCount+1, actualOpsCount, op.OperationID)
@@ -1118,7 +1120,9 @@ func RegisterOpenAPIToolsRefactored(server *mcpserver.MCPServer, ops []OpenAPIOp
 // Returns the list of tool names registered.
 func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, doc *openapi3.T, opts *ToolGenOptions, dbSpec *models.OpenAPISpec) []string {
 	baseURLs := []string{}
-	if os.Getenv("OPENAPI_BASE_URL") != "" {
+	if url := mockUpstreamURL(doc); url != "" {
+		baseURLs = append(baseURLs, url)
+	} else if os.Getenv("OPENAPI_BASE_URL") != "" {
 		baseURLs = append(baseURLs, os.Getenv("OPENAPI_BASE_URL"))
 	} else if doc.Servers != nil && len(doc.Servers) > 0 {
 		for _, s := range doc.Servers {
@@ -1130,6 +1134,119 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 		baseURLs = append(baseURLs, "http://localhost:8080")
 	}
 
+	// Recent results are kept so agents can re-read them as result://{endpoint}/{callId} resources
+	resultStore := DefaultResultStore()
+	if opts != nil && opts.ResultStore != nil {
+		resultStore = opts.ResultStore
+	}
+	resultEndpoint := resultEndpointName(doc, dbSpec)
+	acceptDefault := specAccept(doc, opts)
+	allowedMethods := specAllowedMethods(doc, opts)
+	callbackReceiver := callbackReceiverFor(opts)
+	argTemplates := specArgTemplates(doc, opts)
+	responseTransforms := specResponseTransforms(doc, opts)
+	outputSchema := specOutputSchema(doc, opts)
+	// Clients matching these get input schemas sharing repeated models under $defs
+	schemaDefsClients := specSchemaDefsClients(doc, opts)
+	var componentNames map[string]string
+	if schemaDefsClients != nil {
+		componentNames = componentSchemaNames(doc)
+	}
+	sharedSchemas := map[string][]byte{}
+	inlineBytes, sharedBytes := 0, 0
+	userAgent := specUserAgent(doc, opts, resultEndpoint)
+	attributionHeaders := specAttributionHeaders(doc, opts)
+	passthroughHeaders := specPassthroughHeaders(doc, opts)
+	maxBinaryBody := specMaxBinaryBodyBytes(doc, opts)
+	strictSchema := specStrictSchema(doc, opts)
+	previewWrites := specPreviewWrites(doc, opts)
+	argAliases := specArgAliases(doc, opts)
+	callJournal := callJournalFor(opts)
+	callEvents := callEventSinkFor(opts)
+	toolPanics := panicTrackerFor(opts)
+	enforceScopes := specEnforceScopes(doc, opts)
+	// Centralized authorization decides each call, and may rewrite its arguments
+	callPolicy := specCallPolicy(doc, opts)
+	toolScopes := map[string][][]string{}
+	featureFlags := specFeatureFlags(doc, dbSpec)
+	environment := ServerEnvironment()
+	// Include/exclude rules keep large specs to the operations agents need
+	toolRules := specToolRules(doc, opts, dbSpec)
+	if len(passthroughHeaders) > 0 {
+		fmt.Fprintf(os.Stderr, "[INFO] Passing client headers through to upstream: %s\n", strings.Join(passthroughHeaders, ", "))
+	}
+	if strictSchema {
+		fmt.Fprintf(os.Stderr, "[INFO] Strict input schemas: unknown arguments are rejected\n")
+	}
+	latency := DefaultLatencyTracker()
+	if opts != nil && opts.LatencyTracker != nil {
+		latency = opts.LatencyTracker
+	}
+	// Each spec gets its own HTTP client, which measures its calls and logs the slow ones
+	upstreamMetrics := DefaultUpstreamMetrics()
+	if opts != nil && opts.UpstreamMetrics != nil {
+		upstreamMetrics = opts.UpstreamMetrics
+	}
+	specHealth := DefaultSpecHealth()
+	if opts != nil && opts.SpecHealth != nil {
+		specHealth = opts.SpecHealth
+	}
+	upstreamHost, upstreamSNI := specUpstreamHost(doc, opts)
+	circuitBreakers := DefaultCircuitBreakers()
+	if opts != nil && opts.CircuitBreakers != nil {
+		circuitBreakers = opts.CircuitBreakers
+	}
+	upstreamClient := newUpstreamClient(resultEndpoint, upstreamMetrics, specHealth, bandwidthTrackerFor(opts), specSlowCallThreshold(doc, opts), specUpstreamTimeouts(doc, opts), specUpstreamPolicy(doc, opts, dbSpec), circuitBreakers, upstreamHost, upstreamSNI)
+	// Upstream bytes are tracked per spec and session; results only carry them when asked to
+	bandwidthMeta := specBandwidthMeta(doc, opts)
+	// Pay-per-call specs are charged per upstream call, and refused once their budget is spent
+	budget := specBudgetConfig(doc, ops, opts)
+	budgets := DefaultBudgetTracker()
+	if opts != nil && opts.BudgetTracker != nil {
+		budgets = opts.BudgetTracker
+	}
+	if budget != nil {
+		budgets.Configure(resultEndpoint, *budget)
+		if budget.Limit > 0 {
+			fmt.Fprintf(os.Stderr, "[INFO] Upstream budget: %g per %s\n", budget.Limit, budget.period())
+		}
+	}
+	// HEAD and OPTIONS operations are skipped unless they are asked for
+	metadataOps := specMetadataOperations(doc, opts)
+	// Oversized arguments are rejected before they are validated or sent upstream
+	maxArgsBytes := specMaxArgsBytes(doc, opts)
+	// Gigantic descriptions are shortened; describe returns the full text
+	maxDescChars := specMaxDescriptionChars(doc, opts)
+	var summarizer DescSummarizer
+	if opts != nil {
+		summarizer = opts.SummarizeDescription
+	}
+	fullDescriptions := map[string]string{}
+	// Identical concurrent GET calls share one upstream request
+	var coalescer *requestGroup
+	if specCoalesce(doc, opts) {
+		coalescer = newRequestGroup()
+	}
+	// Calls of specs with several API keys spread over them
+	keyRotation := auth.DefaultKeyRotation()
+	if opts != nil && opts.KeyRotation != nil {
+		keyRotation = opts.KeyRotation
+	}
+	// GET tools answer repeated calls from a cache of upstream responses
+	responseCacheTTL := specResponseCacheTTL(doc, opts)
+	var responseCache ResponseCache
+	if responseCacheTTL > 0 {
+		if opts != nil && opts.ResponseCache != nil {
+			responseCache = opts.ResponseCache
+		} else {
+			responseCache = DefaultResponseCache()
+		}
+		fmt.Fprintf(os.Stderr, "[INFO] Caching GET tool responses for %s\n", responseCacheTTL)
+	}
+	toolCallbacks := map[string][]CallbackInfo{}
+	toolCosts := map[string]float64{}
+	toolTags := map[string][]string{}
+
 	// Extract API key header name from securitySchemes
 	apiKeyHeader := "Fastly-Key" // default fallback
 	if doc.Components != nil && doc.Components.SecuritySchemes != nil {
@@ -1166,79 +1283,68 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 	
 	// Count operations that will actually be processed
 	actualOpsCount := 0
-	for _, op := range ops {
-		if filterByTag(op) {
-			actualOpsCount++
+	deniedByMethod := 0
+	disabledByFlag := 0
+	skippedMetadata := 0
+	excludedByRules := 0
+	included := make([]bool, len(ops))
+	for i, op := range ops {
+		if !filterByTag(op) {
+			continue
 		}
+		if !toolRules.exposes(op) {
+			excludedByRules++
+			continue
+		}
+		if !methodAllowed(allowedMethods, op.Method) {
+			deniedByMethod++
+			continue
+		}
+		if !metadataOperationIncluded(op, metadataOps, allowedMethods) {
+			skippedMetadata++
+			continue
+		}
+		if !operationEnabled(op, featureFlags, environment) {
+			disabledByFlag++
+			continue
+		}
+		included[i] = true
+		actualOpsCount++
+	}
+	if deniedByMethod > 0 {
+		fmt.Fprintf(os.Stderr, "[INFO] Allowed methods policy (%s) excludes %d operations\n", describeAllowedMethods(allowedMethods), deniedByMethod)
+	}
+	if disabledByFlag > 0 {
+		fmt.Fprintf(os.Stderr, "[INFO] Feature flags hide %d operations in environment %s\n", disabledByFlag, environment)
+	}
+	if excludedByRules > 0 {
+		fmt.Fprintf(os.Stderr, "[INFO] Tool rules exclude %d operations\n", excludedByRules)
+	}
+	if skippedMetadata > 0 {
+		fmt.Fprintf(os.Stderr, "[INFO] Skipping %d HEAD/OPTIONS operations; set %s: true to expose them as metadata-only tools\n", skippedMetadata, metadataOperationsExtension)
 	}
 	
 	fmt.Fprintf(os.Stderr, "[INFO] Will process %d/%d operations in batches of %d\n", actualOpsCount, totalOps, batchSize)
 	
 	for i, op := range ops {
-		if !filterByTag(op) {
+		if !included[i] {
 			continue
 		}
 		
-		// PRE-OPERATION memory check to prevent processing when already at limit
-		var preM runtime.MemStats
-		runtime.ReadMemStats(&preM)
-		if preM.Sys > uint64(5000 * 1024 * 1024) { // 5GB pre-check limit
-			fmt.Fprintf(os.Stderr, "[ERROR] Pre-operation memory too high: %.1fMB sys, aborting before operation %d\n", float64(preM.Sys)/1024/1024, processedCount+1)
-			fmt.Fprintf(os.Stderr, "[INFO] Successfully processed %d/%d operations before hitting pre-operation memory limit\n", processedCount, actualOpsCount)
+		// Let the runtime's soft memory limit drive GC; the limiter only intervenes
+		// (and aborts registration) when usage stays above the limit after a collection.
+		if !registrationMemoryOK() {
+			fmt.Fprintf(os.Stderr, "[ERROR] Memory limit exceeded, aborting before operation %d\n", processedCount+1)
+			fmt.Fprintf(os.Stderr, "[INFO] Successfully processed %d/%d operations before hitting memory limit\n", processedCount, actualOpsCount)
 			break
 		}
-		
+
 		processedCount++
 		fmt.Fprintf(os.Stderr, "[INFO] Processing operation %d/%d: %s (index %d)\n", processedCount, actualOpsCount, op.OperationID, i+1)
-		
-		// Emergency memory management - force multiple GC cycles after every operation
-		var m runtime.MemStats
-		runtime.ReadMemStats(&m)
-		
-		// Ultra-aggressive memory management with lower thresholds to prevent OOM kills
-		memThresholdCritical := uint64(5500 * 1024 * 1024) // 5.5GB critical threshold (reduced)
-		memThresholdHigh := uint64(4500 * 1024 * 1024)     // 4.5GB high threshold (reduced) 
-		memThresholdMedium := uint64(3500 * 1024 * 1024)   // 3.5GB medium threshold (reduced)
-		memThresholdLow := uint64(2500 * 1024 * 1024)      // 2.5GB low threshold (new)
-		
-		if m.Sys > memThresholdCritical {
-			fmt.Fprintf(os.Stderr, "[ERROR] Critical memory usage: %.1fMB sys, aborting to prevent OOM\n", float64(m.Sys)/1024/1024)
-			fmt.Fprintf(os.Stderr, "[INFO] Successfully processed %d/%d operations before hitting memory limit\n", processedCount, actualOpsCount)
-			break // Stop processing to prevent OOMKill
-		} else if m.Sys > memThresholdHigh {
-			// Aggressive cleanup for high memory usage
-			fmt.Fprintf(os.Stderr, "[WARN] High memory usage detected: %.1fMB sys, performing aggressive cleanup\n", float64(m.Sys)/1024/1024)
-			
-			// Force memory return to OS
-			for i := 0; i < 15; i++ {
-				runtime.GC()
-			}
-			
-			// Additional cleanup strategies
-			debug.FreeOSMemory()
-			runtime.ReadMemStats(&m)
-			fmt.Fprintf(os.Stderr, "[INFO] After aggressive cleanup: %.1fMB sys\n", float64(m.Sys)/1024/1024)
-			
-		} else if m.Sys > memThresholdMedium {
-			// Moderate cleanup for medium memory usage
-			fmt.Fprintf(os.Stderr, "[WARN] Medium memory usage: %.1fMB sys, performing moderate cleanup\n", float64(m.Sys)/1024/1024)
-			runtime.GC()
-			runtime.GC() 
-			debug.FreeOSMemory()
-		} else if m.Sys > memThresholdLow {
-			// Early cleanup to prevent spikes
-			fmt.Fprintf(os.Stderr, "[INFO] Low threshold reached: %.1fMB sys, performing preventive cleanup\n", float64(m.Sys)/1024/1024)
-			runtime.GC()
-			debug.FreeOSMemory()
-		} else {
-			// Light cleanup for normal usage
-			if processedCount%3 == 0 { // GC every 3 operations (increased frequency)
-				runtime.GC()
-			}
-		}
-		
+
 		// Memory monitoring and database health check every 10 operations
 		if processedCount%10 == 0 {
+			var m runtime.MemStats
 			runtime.ReadMemStats(&m)
 			fmt.Fprintf(os.Stderr, "[INFO] ✅ Progress %d/%d (%.1f%%), Memory: %.1fMB heap, %.1fMB sys\n", 
 				processedCount, actualOpsCount,
@@ -1274,37 +1380,68 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 				}
 			}()
 			
-			// For very memory-constrained situations, use simplified schema building
-			if m.Sys > memThresholdMedium {
-				// Use simplified schema for operations under memory pressure
-				inputSchema = map[string]any{
-					"type": "object", 
-					"properties": map[string]any{
-						"body": map[string]any{
-							"type": "object",
-							"description": fmt.Sprintf("Parameters for %s operation", op.OperationID),
-						},
-					},
-				}
-			} else {
-				inputSchema = BuildInputSchemaWithContext(op.Parameters, op.RequestBody, doc)
-			}
+			inputSchema = BuildInputSchemaWithContext(op.Parameters, op.RequestBody, doc)
 		}()
+		addAcceptProperty(inputSchema, responseMediaTypes(doc, op))
+		// Binary request bodies (images, PDFs, ...) are uploaded as base64
+		binaryTypes := binaryBodyMediaTypes(op.RequestBody)
+		addBinaryBodyProperties(inputSchema, op.RequestBody, binaryTypes, maxBinaryBody)
+		// Single write calls can be previewed, unless the whole spec already is
+		if isWriteMethod(op.Method) && !previewWrites {
+			addDryRunProperty(inputSchema)
+		}
+		// Strict schemas reject arguments the operation does not declare
+		var strictExempt []string
+		if strictSchema {
+			strictExempt = applyStrictSchema(inputSchema)
+		}
 		if opts != nil && opts.PostProcessSchema != nil {
 			inputSchema = opts.PostProcessSchema(op.OperationID, inputSchema)
 		}
+		// Arguments filled from the session are not shown to the model
+		hiddenArgs := hideTemplatedArgs(inputSchema, argTemplates)
+		// Case variants of argument names (petId for pet_id) are mapped to the declared names
+		var aliasIndex map[string]string
+		if argAliases {
+			aliasIndex = argAliasIndex(inputSchema)
+		}
 		// Use more memory-efficient JSON marshaling
 		inputSchemaJSON, _ := json.Marshal(inputSchema)
-		// Generate AI-friendly description
-		desc := generateAIFriendlyDescription(op, inputSchema, apiKeyHeader)
 		name := op.OperationID
+		// Generate AI-friendly description, from a shortened operation description if it is gigantic
+		shortDesc, shortened := shortenDescription(name, op, inputSchema, maxDescChars, summarizer)
+		descOp := op
+		descOp.Description = shortDesc
+		desc := generateAIFriendlyDescription(descOp, inputSchema, apiKeyHeader)
+		if isMetadataMethod(op.Method) {
+			desc += metadataOnlyDescription
+		}
+		desc += duplicateParamsNote(op)
 		
-		// Clear large objects immediately and force GC
+		// Drop the schema map early; only the marshaled JSON is kept
 		inputSchema = nil
-		runtime.GC() // Force GC after clearing schema
 		if opts != nil && opts.NameFormat != nil {
 			name = opts.NameFormat(name)
 		}
+		if shortened {
+			fullDescriptions[name] = op.Description
+		}
+		// OpenAPI response links become follow-up hints on successful results
+		opLinks := operationLinks(doc, op, opts)
+		// Response fields are converted, e.g. epoch times to ISO 8601, so agents need not convert them
+		opTransforms := operationTransforms(responseTransforms, op)
+		desc += transformDescription(opTransforms)
+		// OpenAPI callbacks: document them and, with a receiver, route them back as notifications
+		opCallbacks := operationCallbacks(op)
+		if len(opCallbacks) > 0 {
+			if callbackReceiver != nil {
+				for i := range opCallbacks {
+					opCallbacks[i].ReceiverURL = callbackReceiver.URL(resultEndpoint, name, opCallbacks[i].Name)
+				}
+			}
+			desc += callbackDescription(opCallbacks, callbackReceiver != nil)
+			toolCallbacks[name] = opCallbacks
+		}
 		annotations := mcp.ToolAnnotation{}
 		var titleParts []string
 		if opts != nil && opts.Version != "" {
@@ -1316,24 +1453,59 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 		if len(titleParts) > 0 {
 			annotations.Title = strings.Join(titleParts, " | ")
 		}
+		// Clients can validate and reason about results with the schema of the JSON response
+		if outputSchema {
+			annotations.OutputSchema = operationOutputSchema(doc, op)
+		}
 		tool := mcp.NewToolWithRawSchema(name, desc, inputSchemaJSON)
 		tool.Annotations = annotations
 		toolSchemas[name] = inputSchemaJSON
+		if schemaDefsClients != nil {
+			if shared, ok := shareSchemaDefs(inputSchemaJSON, componentNames); ok {
+				sharedSchemas[name] = shared
+				inlineBytes, sharedBytes = inlineBytes+len(inputSchemaJSON), sharedBytes+len(shared)
+			}
+		}
+		toolTags[name] = op.Tags
 		opCopy := op
 		if opts != nil && opts.DryRun {
 			// For dry run, collect summary info
-			toolSummaries = append(toolSummaries, map[string]any{
+			summary := map[string]any{
 				"name":        name,
 				"description": desc,
 				"tags":        op.Tags,
 				"inputSchema": inputSchema,
-			})
+			}
+			if annotations.OutputSchema != nil {
+				summary["outputSchema"] = annotations.OutputSchema
+			}
+			if len(opCallbacks) > 0 {
+				summary["callbacks"] = opCallbacks
+			}
+			toolSummaries = append(toolSummaries, summary)
 			toolNames = append(toolNames, name)
 			continue
 		}
+		if callbackReceiver != nil {
+			for _, cb := range opCallbacks {
+				callbackReceiver.register(resultEndpoint, name, cb.Name, server)
+			}
+		}
+		requiredScopes := operationScopes(op)
+		argSizeLimits := operationArgLimits(op)
+		// Operations of specs mixing security schemes authenticate with the one they require
+		opScheme := auth.ResolveOperationScheme(doc, op.Security)
+		var callCost float64
+		if budget != nil {
+			callCost = operationCost(op, budget)
+			toolCosts[name] = callCost
+		}
+		if enforceScopes && len(requiredScopes) > 0 {
+			toolScopes[name] = requiredScopes
+		}
 		// Register the tool with the MCP server
 
-		server.AddTool(tool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		server.AddTool(tool, recoveredHandler(toolPanics, resultEndpoint, name, bandwidthMetaHandler(bandwidthMeta, eventHandler(callEvents, resultEndpoint, name, journaledHandler(callJournal, resultEndpoint, name, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			// Execute the OpenAPI operation
 
 			args := req.GetArguments()
@@ -1341,17 +1513,88 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 				args = map[string]any{}
 			}
 
+			// Enforce the allowed methods policy again at call time, in case it changed after
+			// registration or the tool was reached some other way
+			if allowed := specAllowedMethods(doc, opts); !methodAllowed(allowed, opCopy.Method) {
+				return withErrorMeta(mcp.NewToolResultError(
+					methodNotAllowedError(opCopy.Method, allowed),
+					nil, args, nil, "", []string{"list"},
+				), apierrors.TypeUnsupported, ""), nil
+			}
+
+			// Only sessions holding the operation's OAuth scopes may call it
+			if enforceScopes && !scopesSatisfied(requiredScopes, sessionScopes(ctx)) {
+				return withErrorMeta(mcp.NewToolResultError(
+					insufficientScopeError(name, requiredScopes),
+					nil, args, nil, "", []string{"list"},
+				), apierrors.TypeAuth, ""), nil
+			}
+
+			// Point callbacks at this server's receiver unless the caller supplied its own URL;
+			// each call gets its own URL, and its callbacks only go to the calling session
+			var callbackTokens map[string]callbackTarget
+			if callbackReceiver != nil {
+				if session := mcpserver.ClientSessionFromContext(ctx); session != nil {
+					callbackTokens = callbackReceiver.fillCallbackURLs(args, resultEndpoint, name, opCallbacks, session.SessionID())
+				}
+			}
+
+			// Hidden arguments can only come from the session, never from the caller
+			for _, arg := range hiddenArgs {
+				delete(args, arg)
+			}
+			previewCall := takeDryRunArg(args)
+
+			// Accept petId for pet_id, and other case variants, before validation
+			if corrections := applyArgAliases(args, aliasIndex); len(corrections) > 0 {
+				fmt.Fprintf(os.Stderr, "[INFO] Corrected argument names of %s: %s\n", name, strings.Join(corrections, ", "))
+			}
+
+			if callPolicy != nil {
+				decision, err := callPolicy.Decide(ctx, callPolicyInput(ctx, resultEndpoint, name, opCopy, args))
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "[WARN] Denied %s: authorization policy failed: %v\n", name, err)
+					decision = CallPolicyDecision{Reason: "the authorization policy could not be evaluated"}
+				}
+				if !decision.Allow {
+					fmt.Fprintf(os.Stderr, "[INFO] Authorization policy denied %s on %s: %s\n", name, resultEndpoint, decision.Reason)
+					msg := fmt.Sprintf("Tool call denied by the authorization policy: %s.", name)
+					if decision.Reason != "" {
+						msg = fmt.Sprintf("Tool call denied by the authorization policy: %s (%s).", name, decision.Reason)
+					}
+					return withErrorMeta(mcp.NewToolResultError(msg, nil, nil, nil, "", nil), apierrors.TypeAuth, decision.Reason), nil
+				}
+				// Rewritten arguments are validated like the caller's
+				applyPolicyArguments(args, decision.Arguments)
+			}
+
+			// Reject absurdly large arguments before they are marshaled; they are not echoed back
+			if msg := checkArgSizes(args, argSizeLimits, maxArgsBytes); msg != "" {
+				return withErrorMeta(mcp.NewToolResultError(
+					msg, nil, nil, nil, "", []string{"schema <tool>"},
+				), apierrors.TypeValidation, ""), nil
+			}
+
 			// Build parameter name mapping for escaped parameter names
 			paramNameMapping := buildParameterNameMapping(opCopy.Parameters)
+			// Names declared in several locations are passed as id_path, id_query, ...
+			duplicateParams := duplicateParamNames(opCopy.Parameters)
+
+			// Date parameters sent as epoch seconds or another date layout get the spec's format
+			if err := normalizeDateArgs(args, opCopy.Parameters, duplicateParams); err != nil {
+				return withErrorMeta(mcp.NewToolResultError(
+					err.Error(), nil, args, nil, "", []string{"schema <tool>"},
+				), apierrors.TypeValidation, ""), nil
+			}
 
 			// Validate arguments against inputSchema
 			inputSchemaJSON := toolSchemas[name]
-			argsJSON, _ := json.Marshal(args)
+			argsJSON, _ := json.Marshal(withoutArgs(args, strictExempt))
 			schemaLoader := gojsonschema.NewBytesLoader(inputSchemaJSON)
 			argsLoader := gojsonschema.NewBytesLoader(argsJSON)
 			result, err := gojsonschema.Validate(schemaLoader, argsLoader)
 			if err != nil {
-				return &mcp.CallToolResult{
+				return withErrorMeta(&mcp.CallToolResult{
 					Content: []mcp.Content{
 						mcp.TextContent{
 							Type: "text",
@@ -1359,7 +1602,7 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 						},
 					},
 					IsError: true,
-				}, nil
+				}, apierrors.TypeValidation, err.Error()), nil
 			}
 			if !result.Valid() {
 				var missingFields []string
@@ -1405,8 +1648,15 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 						// Convert "Invalid type. Expected: string, given: integer" to plain text
 						errMsg = verr.String()
 					case "enum":
-						// Convert enum validation errors to plain text
-						errMsg = verr.String()
+						// List the allowed values and suggest the closest one
+						errMsg = enumErrorMessage(schemaObj, verr.Field(), verr.Value())
+						if errMsg == "" {
+							errMsg = verr.String()
+						}
+					case "additional_property_not_allowed":
+						// Rejected by a strict schema: name the valid arguments instead
+						arg, _ := verr.Details()["property"].(string)
+						errMsg = unknownArgMessage(schemaObj, verr.Field(), arg)
 					case "invalid_union", "one_of", "any_of":
 						// Convert union/oneOf/anyOf errors to plain text
 						errMsg = "Invalid value. " + verr.String()
@@ -1455,14 +1705,21 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 					errorText += "\n\n" + strings.Join(suggestions, "\n")
 				}
 
-				return mcp.NewToolResultError(
+				return withErrorMeta(mcp.NewToolResultError(
 					errorText,
 					inputSchema,
 					args,
 					[]any{args},
 					"call <tool> <json-args>",
 					[]string{"list", "schema <tool>"},
-				), nil
+				), apierrors.TypeValidation, ""), nil
+			}
+
+			// Fill hidden arguments from the session (JWT claims, headers, session ID)
+			if err := applyArgTemplates(ctx, args, hiddenArgs, argTemplates); err != nil {
+				return withErrorMeta(mcp.NewToolResultError(
+					err.Error(), nil, nil, nil, "", nil,
+				), apierrors.TypeAuth, ""), nil
 			}
 
 			// Build URL path with path parameters
@@ -1473,7 +1730,7 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 				}
 				p := paramRef.Value
 				if p.In == "path" {
-					if val, ok := getParameterValue(args, p.Name, paramNameMapping); ok {
+					if val, ok := parameterValue(args, p, paramNameMapping, duplicateParams); ok {
 						// Check if parameter is integer type
 						isInteger := false
 						if p.Schema != nil && p.Schema.Value != nil && p.Schema.Value.Type != nil {
@@ -1491,13 +1748,9 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 				}
 				p := paramRef.Value
 				if p.In == "query" {
-					if val, ok := getParameterValue(args, p.Name, paramNameMapping); ok {
-						// Check if parameter is integer type
-						isInteger := false
-						if p.Schema != nil && p.Schema.Value != nil && p.Schema.Value.Type != nil {
-							isInteger = p.Schema.Value.Type.Is("integer")
-						}
-						query.Set(p.Name, formatParameterValue(val, isInteger))
+					if val, ok := parameterValue(args, p, paramNameMapping, duplicateParams); ok {
+						// Arrays and objects are serialized per the parameter's style/explode
+						addQueryParam(query, p, val)
 					}
 				}
 			}
@@ -1528,17 +1781,40 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 					}
 				}
 			}
+			if body == nil && len(binaryTypes) > 0 {
+				binaryBody, binaryType, err := binaryRequestBody(args, binaryTypes, maxBinaryBody)
+				if err != nil {
+					return withErrorMeta(&mcp.CallToolResult{
+						Content: []mcp.Content{
+							mcp.TextContent{
+								Type: "text",
+								Text: "Validation error: " + err.Error(),
+							},
+						},
+						IsError: true,
+					}, apierrors.TypeValidation, err.Error()), nil
+				}
+				body, requestContentType = binaryBody, binaryType
+			}
 			// Build HTTP request
 			method := strings.ToUpper(opCopy.Method)
 			httpReq, err := http.NewRequestWithContext(ctx, method, fullURL, bytes.NewReader(body))
 			if err != nil {
-				return nil, err
+				return nil, apierrors.Wrap(err, apierrors.TypeValidation, "failed to build upstream request")
 			}
 			if len(body) > 0 && requestContentType != "" {
 				httpReq.Header.Set("Content-Type", requestContentType)
 			}
-			// Set Accept header to accept both JSON and JSON:API responses
-			httpReq.Header.Set("Accept", "application/json, application/vnd.api+json")
+			// Accept JSON and JSON:API by default; _accept or the spec's x-mcp-accept can ask for another representation
+			accept := resolveAccept(args, acceptDefault)
+			httpReq.Header.Set("Accept", accept)
+			// Identify the traffic; header parameters declared by the spec may still override it
+			httpReq.Header.Set("User-Agent", userAgent)
+			if attributionHeaders {
+				setAttributionHeaders(ctx, httpReq)
+			}
+			// Forward allowlisted client headers (locale, tenant id, ...); spec parameters and auth still take precedence
+			copyPassthroughHeaders(ctx, httpReq, passthroughHeaders)
 			// --- SECURE AUTH HANDLING: Use context-based authentication ---
 			// Apply authentication from secure auth context (headers/database/environment priority)
 			// Add header parameters
@@ -1548,13 +1824,8 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 				}
 				p := paramRef.Value
 				if p.In == "header" {
-					if val, ok := getParameterValue(args, p.Name, paramNameMapping); ok {
-						// Check if parameter is integer type
-						isInteger := false
-						if p.Schema != nil && p.Schema.Value != nil && p.Schema.Value.Type != nil {
-							isInteger = p.Schema.Value.Type.Is("integer")
-						}
-						httpReq.Header.Set(p.Name, formatParameterValue(val, isInteger))
+					if val, ok := parameterValue(args, p, paramNameMapping, duplicateParams); ok {
+						httpReq.Header.Set(p.Name, headerParamValue(p, val))
 					}
 				}
 			}
@@ -1643,7 +1914,7 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 				}
 				p := paramRef.Value
 				if p.In == "cookie" {
-					if val, ok := getParameterValue(args, p.Name, paramNameMapping); ok {
+					if val, ok := parameterValue(args, p, paramNameMapping, duplicateParams); ok {
 						// Check if parameter is integer type
 						isInteger := false
 						if p.Schema != nil && p.Schema.Value != nil && p.Schema.Value.Type != nil {
@@ -1735,6 +2006,9 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 								SpecParamName:     existingAuthCtx.SpecParamName,
 								ApiHost:           existingAuthCtx.ApiHost,
 								HostHeaders:       existingAuthCtx.HostHeaders,
+								QueryParamName:    existingAuthCtx.QueryParamName,
+								Profile:           existingAuthCtx.Profile,
+								SchemeName:        existingAuthCtx.SchemeName,
 							}
 						}
 					} else {
@@ -1746,32 +2020,133 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 				log.Printf("DEBUG: No session auth context found, creating new context with tool args")
 				finalAuthCtx = auth.CreateAuthContextWithToolArgs(httpReq, doc, dbSpec, args)
 			}
-			ctxWithAuth := auth.WithAuthContext(ctx, finalAuthCtx)
+			if opScheme != nil && !opScheme.Matches(finalAuthCtx) {
+				// The session authenticates with another scheme than this operation requires
+				credentialsReq := httpReq
+				if finalAuthCtx != nil && finalAuthCtx.OriginalRequest != nil {
+					credentialsReq = finalAuthCtx.OriginalRequest
+				}
+				finalAuthCtx = auth.CreateOperationAuthContext(credentialsReq, doc, dbSpec, args, opScheme)
+			}
+			// Specs with several API keys start each call with the next key, and fail over on 401 and 429
+			var credentials []auth.Credential
+			cacheAuthCtx := finalAuthCtx // the keys of a spec share its cached responses
+			if finalAuthCtx != nil && finalAuthCtx.DatabaseToken {
+				if credentials = keyRotation.Order(dbSpec); len(credentials) > 1 {
+					finalAuthCtx = finalAuthCtx.WithToken(auth.ResolveCredential(ctx, credentials[0]))
+				} else {
+					credentials = nil
+				}
+			}
+			// Report the progress of the upstream call to clients that sent a progressToken
+			progress := newProgressReporter(ctx, req)
+			ctxWithAuth := progress.trace(withUpstreamTool(auth.WithAuthContext(ctx, finalAuthCtx), name))
 			httpReqWithAuth := httpReq.WithContext(ctxWithAuth)
 
 			// Use secure HTTP client with context-based authentication
 			authProvider := auth.NewSecureAuthProvider()
-			secureClient := auth.NewSecureHTTPClientWrapper(http.DefaultClient, authProvider)
+			secureClient := auth.NewSecureHTTPClientWrapper(upstreamClient, authProvider)
 			
 			// Log final request with authentication headers if logging is enabled
 			if os.Getenv("MCP_LOG_HTTP") != "" || os.Getenv("DEBUG") != "" {
 				logAuthenticatedHTTPRequest(httpReqWithAuth, authProvider)
 			}
 			
-			resp, err := secureClient.Do(httpReqWithAuth)
+			// Previewed write calls return the request they would send, without sending it
+			if isWriteMethod(method) && (previewWrites || previewCall) {
+				return previewResult(ctxWithAuth, opCopy, httpReqWithAuth, body, authProvider), nil
+			}
+			// A cached response of a GET call is neither charged nor sent upstream
+			var cacheKey string
+			var cached *CachedResponse
+			if responseCache != nil && method == http.MethodGet {
+				cacheKey = responseCacheKey(resultEndpoint, name, httpReqWithAuth, cacheAuthCtx)
+				cached, _ = responseCache.Get(ctx, cacheKey)
+			}
+			if budget != nil && cached == nil {
+				if ok, retryAfter := budgets.Charge(resultEndpoint, callCost); !ok {
+					stats, _ := budgets.Stats(resultEndpoint)
+					return withErrorMeta(mcp.NewToolResultError(
+						budgetExceededError(name, callCost, stats, retryAfter),
+						nil, args, nil, "", nil,
+					), apierrors.TypeUnavailable, ""), nil
+				}
+			}
+			if cached == nil && len(callbackTokens) > 0 {
+				// The receiver URLs of the call accept callbacks from now on, as the upstream API
+				// may call back before it answers
+				callbackReceiver.issue(callbackTokens)
+			}
+			upstreamStart := time.Now()
+			var resp *http.Response
+			coalesced := false
+			send := func() (*http.Response, error) {
+				if credentials == nil {
+					return secureClient.Do(httpReqWithAuth)
+				}
+				return sendWithCredentials(secureClient.Do, httpReqWithAuth, dbSpec, credentials, keyRotation)
+			}
+			switch key := coalesceKey(httpReqWithAuth, finalAuthCtx); {
+			case cached != nil:
+				resp = cached.response()
+			case coalescer != nil && key != "":
+				resp, coalesced, err = coalescer.do(ctx, key, send)
+			default:
+				resp, err = send()
+			}
+			if coalesced && budget != nil {
+				// The call shared another call's upstream request, which was charged
+				budgets.Refund(resultEndpoint, callCost)
+			}
 			if err != nil {
-				return nil, err
+				// The upstream API never answered, so the call is not charged and will not be called back
+				if budget != nil && !coalesced {
+					budgets.Refund(resultEndpoint, callCost)
+				}
+				if cached == nil && len(callbackTokens) > 0 {
+					callbackReceiver.revoke(callbackTokens)
+				}
+				if ctx.Err() != nil {
+					// The MCP client went away (or canceled the call); the upstream request was abandoned
+					fmt.Fprintf(os.Stderr, "[INFO] Canceled upstream call for %s: %v\n", name, ctx.Err())
+					return nil, apierrors.Wrap(ctx.Err(), apierrors.TypeUnavailable, "tool call canceled")
+				}
+				var circuitOpen *CircuitOpenError
+				if errors.As(err, &circuitOpen) {
+					// The upstream host keeps failing; the call was not sent
+					return nil, apierrors.Wrap(err, apierrors.TypeUnavailable, "upstream unavailable")
+				}
+				return nil, apierrors.Wrap(err, apierrors.TypeNetwork, "upstream request failed")
+			}
+			if !coalesced && cached == nil {
+				latency.Record(resultEndpoint, name, time.Since(upstreamStart))
 			}
 			defer resp.Body.Close()
-			respBody, _ := io.ReadAll(resp.Body)
+			respBody, _ := io.ReadAll(progress.body(resp))
+			if cacheKey != "" && cached == nil && len(respBody) <= maxCachedResponseBytes {
+				if ttl := cacheableFor(resp, responseCacheTTL); ttl > 0 {
+					responseCache.Set(ctx, cacheKey, &CachedResponse{
+						Status:   resp.StatusCode,
+						Header:   resp.Header.Clone(),
+						Body:     respBody,
+						StoredAt: time.Now(),
+					}, ttl)
+				}
+			}
 
 			// Log HTTP response if logging is enabled
 			if os.Getenv("MCP_LOG_HTTP") != "" || os.Getenv("DEBUG") != "" {
 				logHTTPResponse(resp, respBody)
 			}
 
+			// HEAD and OPTIONS tools report the status and headers of the response
+			if isMetadataMethod(opCopy.Method) {
+				return metadataResult(opCopy, fullURL, resp), nil
+			}
+
 			contentType := resp.Header.Get("Content-Type")
-			isJSON := strings.HasPrefix(contentType, "application/json") || strings.HasPrefix(contentType, "application/vnd.api+json")
+			isJSON := strings.HasPrefix(contentType, "application/json") || strings.HasPrefix(contentType, "application/vnd.api+json") ||
+				strings.HasSuffix(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]), "+json") // e.g. application/problem+json
 			isText := strings.HasPrefix(contentType, "text/")
 			isBinary := !isJSON && !isText
 
@@ -1820,7 +2195,7 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 						},
 					}
 					errorJSON, _ := json.MarshalIndent(errorObj, "", "  ")
-					return &mcp.CallToolResult{
+					return withErrorMeta(&mcp.CallToolResult{
 						Content: []mcp.Content{
 							mcp.TextContent{
 								Type: "json",
@@ -1835,11 +2210,15 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 						NextSteps:    []string{"list", "schema <tool>"},
 						OutputFormat: "structured",
 						OutputType:   "file",
-					}, nil
+					}, apierrors.TypeForStatus(resp.StatusCode), fmt.Sprintf("upstream HTTP %d", resp.StatusCode)), nil
 				}
 				// Create a simple text error message
 				errorText := fmt.Sprintf("HTTP %s %s\nError: %s (HTTP %d)", opCopy.Method, fullURL, http.StatusText(resp.StatusCode), resp.StatusCode)
-				if len(respBody) > 0 {
+				// Problem details and common error envelopes are reported field by field
+				problem := parseProblemDetails(contentType, respBody)
+				if problem != nil {
+					errorText += "\n" + problemText(problem)
+				} else if len(respBody) > 0 {
 					errorText += "\nDetails: " + string(respBody)
 				}
 				if suggestion != "" {
@@ -1847,14 +2226,23 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 				}
 				errorText += fmt.Sprintf("\nOperation: %s (%s)", opCopy.OperationID, opSummary)
 
-				return mcp.NewToolResultError(
+				errResult := withErrorMeta(mcp.NewToolResultError(
 					errorText,
 					inputSchema,
 					args,
 					[]any{args},
 					"call <tool> <json-args>",
 					[]string{"list", "schema <tool>"},
-				), nil
+				), apierrors.TypeForStatus(resp.StatusCode), fmt.Sprintf("upstream HTTP %d", resp.StatusCode))
+				if problem != nil {
+					errResult.Meta["problem"] = problem
+				}
+				return errResult, nil
+			}
+
+			// 204 No Content and other empty success responses get an explicit structured result
+			if isEmptyResponse(resp.StatusCode, respBody) {
+				return emptyResponseResult(opCopy, opCopy.Method, fullURL, resp), nil
 			}
 
 			// Handle binary/file responses for success
@@ -1879,7 +2267,7 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 					},
 				}
 				resultJSON, _ := json.MarshalIndent(resultObj, "", "  ")
-				return &mcp.CallToolResult{
+				return resultStore.attach(ctx, withContentMeta(&mcp.CallToolResult{
 					Content: []mcp.Content{
 						mcp.TextContent{
 							Type: "json",
@@ -1893,13 +2281,28 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 					NextSteps:    []string{"list", "schema <tool>"},
 					OutputFormat: "structured",
 					OutputType:   "file",
-				}, nil
+				}, accept, contentType), resultEndpoint, name, "application/json", string(resultJSON)), nil
+			}
+
+			// Links are evaluated against the decoded JSON body, the response headers and the call arguments
+			linkCtx := linkContext{Method: opCopy.Method, URL: fullURL, StatusCode: resp.StatusCode, Args: args, Header: resp.Header}
+			if isJSON && len(opLinks) > 0 {
+				_ = json.Unmarshal(respBody, &linkCtx.Body)
+			}
+			// Links keep the upstream values; the agent gets the converted ones
+			if isJSON {
+				respBody = transformResponse(respBody, opTransforms)
 			}
 
 			// Always format the response as: HTTP <METHOD> <URL>\nStatus: <status>\nResponse:\n<respBody>
 			respText := fmt.Sprintf("HTTP %s %s\nStatus: %d\nResponse:\n%s", opCopy.Method, fullURL, resp.StatusCode, string(respBody))
+			// Per-item results of multi-status and batch responses are summarized before the body
+			multiStatus := parseMultiStatus(resp.StatusCode, contentType, respBody)
+			if multiStatus != nil {
+				respText = fmt.Sprintf("HTTP %s %s\nStatus: %d\n%s\nResponse:\n%s", opCopy.Method, fullURL, resp.StatusCode, multiStatus.Text(), string(respBody))
+			}
 			if args["stream"] == true {
-				return &mcp.CallToolResult{
+				return withMultiStatus(withContentMeta(&mcp.CallToolResult{
 					Content: []mcp.Content{
 						mcp.TextContent{
 							Type: "text",
@@ -1915,7 +2318,7 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 					ResumeToken:  "stream-" + fmt.Sprintf("%d", rand.Intn(1000)),
 					OutputFormat: "unstructured",
 					OutputType:   "text",
-				}, nil
+				}, accept, contentType), multiStatus), nil
 			}
 			if args["resume_token"] != "" {
 				var resumeToken string
@@ -1924,7 +2327,7 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 				} else {
 					resumeToken = fmt.Sprintf("%v", args["resume_token"])
 				}
-				return &mcp.CallToolResult{
+				return resultStore.attach(ctx, withLinks(withMultiStatus(withContentMeta(&mcp.CallToolResult{
 					Content: []mcp.Content{
 						mcp.TextContent{
 							Type: "text",
@@ -1940,7 +2343,7 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 					ResumeToken:  resumeToken,
 					OutputFormat: "unstructured",
 					OutputType:   "text",
-				}, nil
+				}, accept, contentType), multiStatus), opLinks, linkCtx), resultEndpoint, name, storedResultType(contentType), string(respBody)), nil
 			}
 			if (opts == nil || opts.ConfirmDangerousActions) && (method == "PUT" || method == "POST" || method == "DELETE") {
 				if _, confirmed := args["__confirmed"]; !confirmed {
@@ -1957,7 +2360,7 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 					}, nil
 				}
 			}
-			return &mcp.CallToolResult{
+			return resultStore.attach(ctx, withLinks(withMultiStatus(withContentMeta(&mcp.CallToolResult{
 				Content: []mcp.Content{
 					mcp.TextContent{
 						Type: "text",
@@ -1971,19 +2374,25 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 				NextSteps:    []string{"list", "schema <tool>"},
 				OutputFormat: "unstructured",
 				OutputType:   "text",
-			}, nil
-		})
+			}, accept, contentType), multiStatus), opLinks, linkCtx), resultEndpoint, name, storedResultType(contentType), string(respBody)), nil
+		})))))
 		toolNames = append(toolNames, name)
 	}
 
-	// Final batch completion check
-	if processedCount%batchSize != 0 {
-		runtime.GC()
-		runtime.GC() // Double GC for final cleanup
-	}
-	
 	fmt.Fprintf(os.Stderr, "[INFO] ✅ Successfully completed processing all %d operations! Registration complete.\n", processedCount)
 
+	// Hide the tools whose OAuth scopes the session does not hold
+	if len(toolScopes) > 0 {
+		server.AddToolFilter(scopeToolFilter(toolScopes))
+		fmt.Fprintf(os.Stderr, "[INFO] Enforcing OAuth scopes: %d tools require scopes granted by the session's bearer token\n", len(toolScopes))
+	}
+
+	// Give the clients supporting $defs the smaller input schemas
+	if len(sharedSchemas) > 0 {
+		server.AddToolFilter(schemaDefsToolFilter(schemaDefsClients, sharedSchemas))
+		fmt.Fprintf(os.Stderr, "[INFO] Shared $defs shrink the input schemas of %d tools from %d to %d bytes\n", len(sharedSchemas), inlineBytes, sharedBytes)
+	}
+
 	// Add a tool for externalDocs if present
 	if doc.ExternalDocs != nil && doc.ExternalDocs.URL != "" && (opts == nil || !opts.DryRun) {
 		desc := "Show the OpenAPI external documentation URL and description."
@@ -2065,24 +2474,53 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 
 	// After registering all OpenAPI tools, add a `describe` tool that returns the full schema and metadata for all tools.
 	if opts == nil || !opts.DryRun {
-		describeSchema := map[string]any{
-			"type":       "object",
-			"properties": map[string]any{},
-		}
 		describeSchemaJSON, _ := json.MarshalIndent(describeSchema, "", "  ")
-		describeTool := mcp.NewToolWithRawSchema("describe", "Describe all available tools and their schemas in machine-readable form.", describeSchemaJSON)
+		describeTool := mcp.NewToolWithRawSchema("describe", "Describe the available tools and their schemas in machine-readable form. Filter by tool name pattern or tag to describe only some of them.", describeSchemaJSON)
 		describeTool.Annotations = mcp.ToolAnnotation{Title: "Agent-Friendly Documentation"}
 		server.AddTool(describeTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			// Gather all tools and their schemas
+			filter, err := parseDescribeFilter(req.GetArguments())
+			if err != nil {
+				return withErrorMeta(&mcp.CallToolResult{
+					Content: []mcp.Content{
+						mcp.TextContent{
+							Type: "text",
+							Text: "Invalid describe arguments: " + err.Error(),
+						},
+					},
+					IsError: true,
+				}, apierrors.TypeValidation, err.Error()), nil
+			}
+			// Gather the selected tools and their schemas
 			tools := []map[string]any{}
-			for _, tool := range server.ListTools() {
+			allTools := server.ListTools()
+			for _, tool := range allTools {
+				if !filter.matches(tool.Name, toolTags[tool.Name]) {
+					continue
+				}
 				toolInfo := map[string]any{
-					"name":         tool.Name,
-					"description":  tool.Description,
-					"inputSchema":  tool.InputSchema,
-					"annotations":  tool.Annotations,
-					"output_type":  "text", // default, can be improved if richer info is available
-					"example_call": map[string]any{"name": tool.Name, "arguments": map[string]any{}},
+					"name":        tool.Name,
+					"description": tool.Description,
+					"inputSchema": describeInputSchema(tool, filter.examples),
+					"annotations": tool.Annotations,
+					"output_type": "text", // default, can be improved if richer info is available
+				}
+				if filter.examples {
+					toolInfo["example_call"] = map[string]any{"name": tool.Name, "arguments": map[string]any{}}
+				}
+				if tags := toolTags[tool.Name]; len(tags) > 0 {
+					toolInfo["tags"] = tags
+				}
+				if full, ok := fullDescriptions[tool.Name]; ok {
+					toolInfo["full_description"] = full
+				}
+				if callbacks, ok := toolCallbacks[tool.Name]; ok {
+					toolInfo["callbacks"] = callbacks
+				}
+				if stats, ok := latency.Stats(resultEndpoint, tool.Name); ok {
+					toolInfo["latency"] = stats
+				}
+				if cost, ok := toolCosts[tool.Name]; ok {
+					toolInfo["cost"] = cost
 				}
 				tools = append(tools, toolInfo)
 			}
@@ -2090,6 +2528,9 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 				"type":  "tool_descriptions",
 				"tools": tools,
 			}
+			if len(tools) < len(allTools) {
+				response["total"] = len(allTools)
+			}
 			jsonOut, _ := json.MarshalIndent(response, "", "  ")
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
@@ -2115,6 +2556,20 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 		}
 	}
 
+	if opts == nil || !opts.DryRun {
+		registerResultResources(server, resultStore, resultEndpoint)
+		// GET operations like users/{id} can also be read as resources
+		if specAPIResources(doc, opts) {
+			RegisterOpenAPIResources(server, ops, doc, opts, dbSpec)
+		} else if specResourceTemplates(doc, opts) {
+			registerResourceTemplates(server, ops, doc, opts, resultEndpoint, toolNames)
+		}
+		// A prompt per API tells clients what it is for and which tools to call
+		if specAPIPrompt(doc, opts) {
+			registerAPIPrompt(server, ops, doc, opts, resultEndpoint, toolNames)
+		}
+	}
+
 	// Check if any operations use date/time parameters
 	hasTimeRelatedOps := false
 	for _, op := range ops {
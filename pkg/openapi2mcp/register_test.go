@@ -58,7 +58,7 @@ func TestRegisterOpenAPITools_Basic(t *testing.T) {
 	srv := server.NewMCPServer("test", "1.0.0")
 	ops := ExtractOpenAPIOperations(doc)
 	opts := &ToolGenOptions{}
-	names := RegisterOpenAPITools(srv, ops, doc, opts)
+	names := RegisterOpenAPITools(srv, ops, doc, opts, nil)
 	expected := []string{"getFoo", "info", "describe"}
 	if !toolSetEqual(names, expected) {
 		t.Fatalf("expected tools %v, got: %v", expected, names)
@@ -76,7 +76,7 @@ func TestRegisterOpenAPITools_TagFilter(t *testing.T) {
 	opts := &ToolGenOptions{
 		TagFilter: []string{"baz"}, // should filter out
 	}
-	names := RegisterOpenAPITools(srv, ops, doc, opts)
+	names := RegisterOpenAPITools(srv, ops, doc, opts, nil)
 	expected := []string{"info", "describe"}
 	if !toolSetEqual(names, expected) {
 		t.Fatalf("expected only meta tools %v, got: %v", expected, names)
@@ -88,7 +88,7 @@ func TestSelfTestOpenAPIMCP_Pass(t *testing.T) {
 	srv := server.NewMCPServer("test", "1.0.0")
 	ops := ExtractOpenAPIOperations(doc)
 	opts := &ToolGenOptions{}
-	RegisterOpenAPITools(srv, ops, doc, opts)
+	RegisterOpenAPITools(srv, ops, doc, opts, nil)
 	toolNames := make([]string, 0)
 	for _, tool := range srv.ListTools() {
 		toolNames = append(toolNames, tool.Name)
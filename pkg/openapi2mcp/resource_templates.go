@@ -0,0 +1,275 @@
+package openapi2mcp
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+	"github.com/yosida95/uritemplate/v3"
+)
+
+// resourceTemplatesExtension is the root OpenAPI extension that turns the resource templates
+// of GET operations off (`x-mcp-resource-templates: false`) for a spec.
+const resourceTemplatesExtension = "x-mcp-resource-templates"
+
+// apiResourcesExtension is the root OpenAPI extension that exposes all idempotent GET
+// operations of a spec as resources (`x-mcp-resources: true`), see RegisterOpenAPIResources.
+const apiResourcesExtension = "x-mcp-resources"
+
+// templateVarName matches the path parameter names a URI template variable can have.
+var templateVarName = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// OperationURI returns the URI of an operation's resource, e.g. api://petstore/pets/{petId}.
+func OperationURI(endpoint, path string) string {
+	return fmt.Sprintf("api://%s/%s", endpoint, strings.TrimPrefix(path, "/"))
+}
+
+// specResourceTemplates reports whether GET operations with only path parameters are also
+// exposed as resource templates: off with opts.NoResourceTemplates, then the
+// x-mcp-resource-templates extension, then MCP_RESOURCE_TEMPLATES. On by default.
+func specResourceTemplates(doc *openapi3.T, opts *ToolGenOptions) bool {
+	if opts != nil && opts.NoResourceTemplates {
+		return false
+	}
+	if doc != nil {
+		if v, ok := doc.Extensions[resourceTemplatesExtension].(bool); ok {
+			return v
+		}
+	}
+	if v := os.Getenv("MCP_RESOURCE_TEMPLATES"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		return err != nil || enabled
+	}
+	return true
+}
+
+// specAPIResources reports whether all idempotent GET operations are exposed as resources,
+// see RegisterOpenAPIResources: on with opts.APIResources, then the x-mcp-resources
+// extension, then MCP_RESOURCES. Off by default.
+func specAPIResources(doc *openapi3.T, opts *ToolGenOptions) bool {
+	if opts != nil && opts.APIResources {
+		return true
+	}
+	if doc != nil {
+		if v, ok := doc.Extensions[apiResourcesExtension].(bool); ok {
+			return v
+		}
+	}
+	enabled, _ := strconv.ParseBool(os.Getenv("MCP_RESOURCES"))
+	return enabled
+}
+
+// resourceParams returns the path and query parameters of a GET operation that can be read
+// as a resource: one with no request body and no parameters elsewhere. Auth headers are
+// filled in by the server, so they don't count. Optional query parameters with array or
+// object schemas are left out, since a URI variable holds a single value.
+func resourceParams(op OpenAPIOperation, doc *openapi3.T) (path, query []*openapi3.Parameter, ok bool) {
+	if !strings.EqualFold(op.Method, "get") || (op.RequestBody != nil && op.RequestBody.Value != nil) {
+		return nil, nil, false
+	}
+	for _, paramRef := range op.Parameters {
+		if paramRef == nil || paramRef.Value == nil {
+			continue
+		}
+		p := paramRef.Value
+		switch {
+		case p.In == "path" && templateVarName.MatchString(p.Name):
+			path = append(path, p)
+		case p.In == "query" && templateVarName.MatchString(p.Name) && scalarParam(p):
+			query = append(query, p)
+		case p.In == "query" && !p.Required:
+		case isAuthenticationHeader(p, doc):
+		default:
+			return nil, nil, false
+		}
+	}
+	return path, query, true
+}
+
+// scalarParam reports whether a parameter takes a single value
+func scalarParam(p *openapi3.Parameter) bool {
+	if p.Schema == nil || p.Schema.Value == nil || p.Schema.Value.Type == nil {
+		return true
+	}
+	return !p.Schema.Value.Type.Is("array") && !p.Schema.Value.Type.Is("object")
+}
+
+// registerResourceTemplates exposes the GET operations with only path parameters as resource
+// templates at api://{endpoint}/{path}. Reading one calls the operation's tool, so it shares
+// the tool's execution path, auth and middlewares.
+func registerResourceTemplates(server *mcpserver.MCPServer, ops []OpenAPIOperation, doc *openapi3.T, opts *ToolGenOptions, endpoint string, toolNames []string) {
+	registered := map[string]bool{}
+	for _, name := range toolNames {
+		registered[name] = true
+	}
+	registerOperationResources(server, ops, doc, opts, endpoint, registered, false)
+}
+
+// RegisterOpenAPIResources exposes the idempotent GET operations of a spec as MCP resources
+// at api://{endpoint}/{path}, alongside the tools RegisterOpenAPITools registered on server,
+// so clients that prefer resource reads over tool calls can browse the API's data.
+// Operations without parameters become resources, and those with path or query parameters
+// resource templates, e.g. api://petstore/pets{?limit,status}. Reading one calls the
+// operation's tool. Operations with a request body or header parameters, and those whose
+// tool is not registered, are skipped. It returns the number of operations exposed.
+func RegisterOpenAPIResources(server *mcpserver.MCPServer, ops []OpenAPIOperation, doc *openapi3.T, opts *ToolGenOptions, dbSpec *models.OpenAPISpec) int {
+	registered := map[string]bool{}
+	for _, tool := range server.ListTools() {
+		registered[tool.Name] = true
+	}
+	return registerOperationResources(server, ops, doc, opts, resultEndpointName(doc, dbSpec), registered, true)
+}
+
+// registerOperationResources exposes the GET operations whose tools are registered as
+// resources: all of them, or only those with path parameters alone.
+func registerOperationResources(server *mcpserver.MCPServer, ops []OpenAPIOperation, doc *openapi3.T, opts *ToolGenOptions, endpoint string, registered map[string]bool, all bool) int {
+	exposed := 0
+	for _, op := range ops {
+		path, query, ok := resourceParams(op, doc)
+		if !ok || (!all && (len(path) == 0 || len(query) > 0)) {
+			continue
+		}
+		name := op.OperationID
+		if opts != nil && opts.NameFormat != nil {
+			name = opts.NameFormat(name)
+		}
+		if !registered[name] {
+			continue
+		}
+		description := op.Summary
+		if description == "" {
+			description = fmt.Sprintf("%s %s", strings.ToUpper(op.Method), op.Path)
+		}
+		description += fmt.Sprintf(" Same as calling the %s tool.", name)
+		uri := OperationURI(endpoint, op.Path)
+		if len(path) == 0 && len(query) == 0 {
+			resource := mcp.NewResource(uri, name,
+				mcp.WithResourceDescription(description),
+				mcp.WithMIMEType("application/json"),
+			)
+			server.AddResource(resource, mcpserver.ResourceHandlerFunc(operationResourceHandler(server, name, nil, nil, nil)))
+			exposed++
+			continue
+		}
+		var pathTemplate *uritemplate.Template
+		if len(query) > 0 {
+			pathTemplate = uritemplate.MustNew(uri)
+			names := make([]string, len(query))
+			for i, p := range query {
+				names[i] = p.Name
+			}
+			uri += "{?" + strings.Join(names, ",") + "}"
+		}
+		template := mcp.NewResourceTemplate(
+			uri,
+			name,
+			mcp.WithTemplateDescription(description),
+			mcp.WithTemplateMIMEType("application/json"),
+		)
+		server.AddResourceTemplate(template, operationResourceHandler(server, name, path, query, pathTemplate))
+		exposed++
+	}
+	return exposed
+}
+
+// resourceURIVars returns the variables of a resource URI with a query string. The template
+// only matches query parameters given in its own order, so the URI is parsed instead:
+// its path against pathTemplate, and its query string as is.
+func resourceURIVars(uri string, pathTemplate *uritemplate.Template) map[string]any {
+	path, rawQuery, _ := strings.Cut(uri, "?")
+	vars := map[string]any{}
+	for name, value := range pathTemplate.Match(path) {
+		vars[name] = value.V
+	}
+	query, _ := url.ParseQuery(rawQuery)
+	for name, values := range query {
+		vars[name] = values
+	}
+	return vars
+}
+
+// operationResourceHandler reads an operation resource by calling its tool with the
+// URI variables as arguments. pathTemplate is the template of the URI without its query
+// parameters, for operations that have some; nil otherwise.
+func operationResourceHandler(server *mcpserver.MCPServer, tool string, path, query []*openapi3.Parameter, pathTemplate *uritemplate.Template) mcpserver.ResourceTemplateHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		vars := request.Params.Arguments
+		if pathTemplate != nil {
+			vars = resourceURIVars(request.Params.URI, pathTemplate)
+		}
+		args := map[string]any{}
+		for _, p := range path {
+			value, ok := vars[p.Name]
+			if !ok {
+				return nil, fmt.Errorf("resource URI %s has no %s", request.Params.URI, p.Name)
+			}
+			args[p.Name] = templateArgValue(p, value)
+		}
+		for _, p := range query {
+			if value, ok := vars[p.Name]; ok && value != "" {
+				args[p.Name] = templateArgValue(p, value)
+			}
+		}
+		var call mcp.CallToolRequest
+		call.Params.Name = tool
+		call.Params.Arguments = args
+		res, err := server.CallTool(ctx, call)
+		if err != nil {
+			return nil, err
+		}
+		text, _ := firstText(res)
+		if res.IsError {
+			return nil, fmt.Errorf("%s", text)
+		}
+		mimeType := "application/json"
+		if contentType, ok := res.Meta["contentType"].(string); ok && contentType != "" {
+			mimeType = contentType
+		}
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: mimeType,
+				Text:     text,
+			},
+		}, nil
+	}
+}
+
+// templateArgValue converts a template variable to the type of its parameter's schema, since
+// URI variables are strings and the tool validates its arguments against the schema.
+func templateArgValue(p *openapi3.Parameter, value any) any {
+	s, ok := value.(string)
+	if !ok {
+		if values, isList := value.([]string); isList && len(values) > 0 {
+			s = values[0]
+		} else {
+			return value
+		}
+	}
+	if p.Schema == nil || p.Schema.Value == nil || p.Schema.Value.Type == nil {
+		return s
+	}
+	switch {
+	case p.Schema.Value.Type.Is("integer"):
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+	case p.Schema.Value.Type.Is("number"):
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	case p.Schema.Value.Type.Is("boolean"):
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+	return s
+}
@@ -0,0 +1,174 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+func readResourceForTest(t *testing.T, server *mcpserver.MCPServer, uri string) mcp.JSONRPCMessage {
+	t.Helper()
+	req, _ := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "resources/read",
+		"params":  map[string]any{"uri": uri},
+	})
+	return server.HandleMessage(context.Background(), req)
+}
+
+func TestResourceTemplatesForPathOnlyGETOperations(t *testing.T) {
+	var gotPath string
+	server := newTestServer(t, mockUpstreamSpec, nil, func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 42, "name": "Rex"}`))
+	})
+
+	req, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "resources/templates/list"})
+	resp, ok := server.HandleMessage(context.Background(), req).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatal("expected a templates list")
+	}
+	var templates []string
+	for _, tmpl := range resp.Result.(mcp.ListResourceTemplatesResult).ResourceTemplates {
+		templates = append(templates, tmpl.URITemplate.Raw())
+	}
+	// listPets has no path parameters and deletePet is not a GET
+	if !strings.Contains(strings.Join(templates, " "), "api://pets/pets/{id}") || strings.Count(strings.Join(templates, " "), "api://") != 1 {
+		t.Errorf("expected one api:// template for getPet, got %v", templates)
+	}
+
+	read, ok := readResourceForTest(t, server, "api://pets/pets/42").(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("expected the resource to be read, got %+v", readResourceForTest(t, server, "api://pets/pets/42"))
+	}
+	contents := read.Result.(mcp.ReadResourceResult).Contents
+	text, ok := contents[0].(mcp.TextResourceContents)
+	if !ok || !strings.Contains(text.Text, `"Rex"`) || text.URI != "api://pets/pets/42" || gotPath != "/pets/42" {
+		t.Errorf("expected getPet's result for /pets/42, got %+v (upstream path %s)", contents, gotPath)
+	}
+}
+
+func TestResourceTemplatesDisabled(t *testing.T) {
+	server := newTestServer(t, strings.Replace(mockUpstreamSpec, "openapi: 3.0.0\n", "openapi: 3.0.0\nx-mcp-resource-templates: false\n", 1), nil, nil)
+	if _, ok := readResourceForTest(t, server, "api://pets/pets/42").(mcp.JSONRPCResponse); ok {
+		t.Error("expected no resource templates with x-mcp-resource-templates: false")
+	}
+}
+
+func TestTemplateArgValue(t *testing.T) {
+	integer := &openapi3.Parameter{In: "path", Name: "id", Schema: openapi3.NewIntegerSchema().NewRef()}
+	if v := templateArgValue(integer, "42"); v != int64(42) {
+		t.Errorf("expected 42 as an integer, got %#v", v)
+	}
+	if v := templateArgValue(integer, "abc"); v != "abc" {
+		t.Errorf("expected an invalid integer to be left for validation, got %#v", v)
+	}
+}
+
+const browsableSpec = `
+openapi: 3.0.0
+info:
+  title: Pets
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+        - {name: limit, in: query, schema: {type: integer}}
+        - {name: status, in: query, schema: {type: string}}
+        - {name: tags, in: query, schema: {type: array, items: {type: string}}}
+      responses:
+        '200': {description: ok}
+  /pets/{id}:
+    get:
+      operationId: getPet
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: integer}}
+      responses:
+        '200': {description: ok}
+  /owners:
+    get:
+      operationId: listOwners
+      responses:
+        '200': {description: ok}
+  /audit:
+    get:
+      operationId: getAudit
+      parameters:
+        - {name: X-Trace-Id, in: header, required: true, schema: {type: string}}
+      responses:
+        '200': {description: ok}
+`
+
+func TestRegisterOpenAPIResources(t *testing.T) {
+	var gotURL string
+	server := newTestServer(t, browsableSpec, &ToolGenOptions{APIResources: true}, func(w http.ResponseWriter, r *http.Request) {
+		gotURL = r.URL.String()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": 1}]`))
+	})
+
+	req, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "resources/list"})
+	resp, ok := server.HandleMessage(context.Background(), req).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatal("expected a resources list")
+	}
+	var resources []string
+	for _, r := range resp.Result.(mcp.ListResourcesResult).Resources {
+		resources = append(resources, r.URI)
+	}
+	if !strings.Contains(strings.Join(resources, " "), "api://pets/owners") {
+		t.Errorf("expected listOwners as a resource, got %v", resources)
+	}
+
+	req, _ = json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "resources/templates/list"})
+	resp = server.HandleMessage(context.Background(), req).(mcp.JSONRPCResponse)
+	var templates []string
+	for _, tmpl := range resp.Result.(mcp.ListResourceTemplatesResult).ResourceTemplates {
+		templates = append(templates, tmpl.URITemplate.Raw())
+	}
+	joined := strings.Join(templates, " ")
+	if !strings.Contains(joined, "api://pets/pets{?limit,status}") || !strings.Contains(joined, "api://pets/pets/{id}") || strings.Contains(joined, "audit") {
+		t.Errorf("expected templates for listPets and getPet only, got %v", templates)
+	}
+
+	// Query parameters are read in any order
+	read, ok := readResourceForTest(t, server, "api://pets/pets?status=sold&limit=2").(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("expected the resource to be read, got %+v", readResourceForTest(t, server, "api://pets/pets?status=sold&limit=2"))
+	}
+	if text := read.Result.(mcp.ReadResourceResult).Contents[0].(mcp.TextResourceContents); !strings.Contains(text.Text, `"id"`) {
+		t.Errorf("expected listPets' result, got %+v", text)
+	}
+	if !strings.Contains(gotURL, "limit=2") || !strings.Contains(gotURL, "status=sold") {
+		t.Errorf("expected the query parameters upstream, got %s", gotURL)
+	}
+
+	if _, ok := readResourceForTest(t, server, "api://pets/owners").(mcp.JSONRPCResponse); !ok || gotURL != "/owners" {
+		t.Errorf("expected api://pets/owners to call listOwners, got upstream %s", gotURL)
+	}
+}
+
+func TestRegisterOpenAPIResourcesSkipsUnregisteredTools(t *testing.T) {
+	doc, err := LoadOpenAPISpecFromString(browsableSpec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	server := mcpserver.NewMCPServer("test", "0.0.1")
+	if n := RegisterOpenAPIResources(server, ExtractOpenAPIOperations(doc), doc, nil, nil); n != 0 {
+		t.Errorf("expected no resources without registered tools, got %d", n)
+	}
+	RegisterOpenAPITools(server, ExtractOpenAPIOperations(doc), doc, &ToolGenOptions{NoResourceTemplates: true}, nil)
+	if n := RegisterOpenAPIResources(server, ExtractOpenAPIOperations(doc), doc, nil, nil); n != 3 {
+		t.Errorf("expected listPets, getPet and listOwners, got %d", n)
+	}
+}
@@ -0,0 +1,294 @@
+package openapi2mcp
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+// responseCacheExtension is the root OpenAPI extension setting how long the responses of a
+// spec's GET tools are cached when the upstream doesn't say (`x-mcp-response-cache: 5m`), or
+// turning caching off (`false`).
+const responseCacheExtension = "x-mcp-response-cache"
+
+const (
+	// DefaultResponseCacheSize is the number of responses the in-memory cache keeps when
+	// MCP_RESPONSE_CACHE_SIZE is unset.
+	DefaultResponseCacheSize = 1000
+	// DefaultRedisCachePrefix prefixes the keys of a RedisResponseCache when the URL sets none.
+	DefaultRedisCachePrefix = "mcp:cache:"
+	// maxCachedResponseBytes is the size above which responses are not cached.
+	maxCachedResponseBytes = 1 << 20
+)
+
+// CachedResponse is an upstream response kept by a ResponseCache.
+type CachedResponse struct {
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+	StoredAt time.Time   `json:"stored_at"`
+}
+
+// response returns the cached response as an HTTP response, with an Age header.
+func (c *CachedResponse) response() *http.Response {
+	header := c.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Age", strconv.Itoa(int(time.Since(c.StoredAt).Seconds())))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", c.Status, http.StatusText(c.Status)),
+		StatusCode:    c.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+	}
+}
+
+// ResponseCache keeps the upstream responses of GET tool calls, so an agent repeating a
+// lookup doesn't cost an upstream request. Errors are not reported: a failing cache misses.
+type ResponseCache interface {
+	Get(ctx context.Context, key string) (*CachedResponse, bool)
+	Set(ctx context.Context, key string, resp *CachedResponse, ttl time.Duration)
+}
+
+type memoryCacheEntry struct {
+	key       string
+	resp      *CachedResponse
+	expiresAt time.Time
+}
+
+// MemoryResponseCache is an in-memory ResponseCache that evicts the least recently used
+// response beyond its size, and expired responses when they are read.
+type MemoryResponseCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	lru     *list.List // most recently used first
+}
+
+// NewMemoryResponseCache creates an in-memory cache holding at most size responses.
+func NewMemoryResponseCache(size int) *MemoryResponseCache {
+	return &MemoryResponseCache{size: size, entries: make(map[string]*list.Element), lru: list.New()}
+}
+
+func (c *MemoryResponseCache) Get(ctx context.Context, key string) (*CachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*memoryCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return entry.resp, true
+}
+
+func (c *MemoryResponseCache) Set(ctx context.Context, key string, resp *CachedResponse, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &memoryCacheEntry{key: key, resp: resp, expiresAt: time.Now().Add(ttl)}
+	if el, ok := c.entries[key]; ok {
+		el.Value = entry
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+// Len returns the number of responses in the cache, including expired ones not read since.
+func (c *MemoryResponseCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// RedisResponseCache keeps responses in Redis, one key per response with a TTL, so replicas
+// share their cache and Redis drops expired responses by itself.
+type RedisResponseCache struct {
+	client *mcpserver.RedisClient
+	prefix string
+}
+
+// NewRedisResponseCache creates a cache for a Redis URL:
+// redis://[user:password@]host[:port][/db][?prefix=keys:prefix:], or rediss:// for TLS.
+func NewRedisResponseCache(rawURL string) (*RedisResponseCache, error) {
+	client, err := mcpserver.NewRedisClient(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	cache := &RedisResponseCache{client: client, prefix: DefaultRedisCachePrefix}
+	if u, _ := url.Parse(rawURL); u.Query().Get("prefix") != "" {
+		cache.prefix = u.Query().Get("prefix")
+	}
+	return cache, nil
+}
+
+func (c *RedisResponseCache) Get(ctx context.Context, key string) (*CachedResponse, bool) {
+	reply, err := c.client.Do(ctx, "GET", c.prefix+key)
+	data, ok := reply.([]byte)
+	if err != nil || !ok {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] Response cache read failed: %v\n", err)
+		}
+		return nil, false
+	}
+	var resp CachedResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, false
+	}
+	return &resp, true
+}
+
+func (c *RedisResponseCache) Set(ctx context.Context, key string, resp *CachedResponse, ttl time.Duration) {
+	data, err := json.Marshal(resp)
+	if err == nil {
+		_, err = c.client.Do(ctx, "SET", c.prefix+key, string(data), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] Response cache write failed: %v\n", err)
+	}
+}
+
+// Close closes the idle connections to Redis.
+func (c *RedisResponseCache) Close() error {
+	return c.client.Close()
+}
+
+// ResponseCacheFromEnv returns the cache set by MCP_RESPONSE_CACHE: a redis:// or rediss://
+// URL keeps responses in Redis, shared by replicas; otherwise, or when the URL is invalid,
+// they are kept in memory, up to MCP_RESPONSE_CACHE_SIZE responses.
+func ResponseCacheFromEnv() ResponseCache {
+	if v := os.Getenv("MCP_RESPONSE_CACHE"); v != "" && v != "memory" {
+		cache, err := NewRedisResponseCache(v)
+		if err == nil {
+			return cache
+		}
+		fmt.Fprintf(os.Stderr, "[WARN] Responses are cached in memory: MCP_RESPONSE_CACHE: %v\n", err)
+	}
+	size := DefaultResponseCacheSize
+	if v := os.Getenv("MCP_RESPONSE_CACHE_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			size = n
+		} else {
+			fmt.Fprintf(os.Stderr, "[WARN] Invalid MCP_RESPONSE_CACHE_SIZE %q, using %d\n", v, size)
+		}
+	}
+	return NewMemoryResponseCache(size)
+}
+
+var (
+	defaultResponseCache     ResponseCache
+	defaultResponseCacheOnce sync.Once
+)
+
+// DefaultResponseCache returns the process-wide response cache of ResponseCacheFromEnv,
+// shared by all specs.
+func DefaultResponseCache() ResponseCache {
+	defaultResponseCacheOnce.Do(func() {
+		defaultResponseCache = ResponseCacheFromEnv()
+	})
+	return defaultResponseCache
+}
+
+// specResponseCacheTTL returns how long the responses of a spec's GET tools are cached when
+// the upstream sends no freshness information: opts.ResponseCacheTTL, then the
+// x-mcp-response-cache extension, then MCP_RESPONSE_CACHE_TTL. Zero, the default, turns the
+// cache off.
+func specResponseCacheTTL(doc *openapi3.T, opts *ToolGenOptions) time.Duration {
+	if opts != nil && opts.ResponseCacheTTL != 0 {
+		return max(opts.ResponseCacheTTL, 0)
+	}
+	if doc != nil && doc.Extensions[responseCacheExtension] != nil {
+		switch v := doc.Extensions[responseCacheExtension].(type) {
+		case bool:
+			if !v {
+				return 0
+			}
+		case string:
+			if d, err := time.ParseDuration(strings.TrimSpace(v)); err == nil && d >= 0 {
+				return d
+			}
+			fmt.Fprintf(os.Stderr, "[WARN] Ignoring invalid %s %q\n", responseCacheExtension, v)
+		default:
+			fmt.Fprintf(os.Stderr, "[WARN] Ignoring %s: expected a duration or false\n", responseCacheExtension)
+		}
+	}
+	var ttl time.Duration
+	envTimeout("MCP_RESPONSE_CACHE_TTL", &ttl)
+	return ttl
+}
+
+// responseCacheKey identifies a GET tool call by the request it sends upstream: its URL,
+// every header it carries, whether set from arguments or passed through from the client,
+// and the credentials it is made with, so callers never see the responses of other callers.
+func responseCacheKey(endpoint, tool string, req *http.Request, authCtx *auth.AuthContext) string {
+	return endpoint + ":" + tool + ":" + coalesceKey(req, authCtx)
+}
+
+// cacheableFor returns how long a response may be cached, honoring its Cache-Control:
+// never with no-store, no-cache or private, for s-maxage or max-age when set, and otherwise
+// for defaultTTL. Only successful responses are cached. Responses varying on request headers
+// may be cached, since responseCacheKey covers every header of the request, but not Vary: *.
+func cacheableFor(resp *http.Response, defaultTTL time.Duration) time.Duration {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 || resp.StatusCode == http.StatusPartialContent {
+		return 0
+	}
+	for _, vary := range resp.Header.Values("Vary") {
+		for _, name := range strings.Split(vary, ",") {
+			if strings.TrimSpace(name) == "*" {
+				return 0
+			}
+		}
+	}
+	maxAge, sharedMaxAge := -1, -1
+	for _, directive := range strings.Split(resp.Header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store", "no-cache", "private":
+			return 0
+		case "max-age":
+			if n, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil {
+				maxAge = n
+			}
+		case "s-maxage":
+			if n, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil {
+				sharedMaxAge = n
+			}
+		}
+	}
+	switch {
+	case sharedMaxAge >= 0:
+		return time.Duration(sharedMaxAge) * time.Second
+	case maxAge >= 0:
+		return time.Duration(maxAge) * time.Second
+	}
+	return defaultTTL
+}
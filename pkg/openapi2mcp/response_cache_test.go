@@ -0,0 +1,162 @@
+package openapi2mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
+)
+
+const cacheSpec = `
+openapi: 3.0.0
+info: {title: Catalog, version: "1.0"}
+paths:
+  /items:
+    get:
+      operationId: listItems
+      parameters:
+        - {name: q, in: query, schema: {type: string}}
+        - {name: cache, in: query, schema: {type: string}}
+        - {name: X-Tenant, in: header, schema: {type: string}}
+      responses:
+        '200': {description: ok}
+    post:
+      operationId: createItem
+      responses:
+        '201': {description: created}
+`
+
+func TestResponseCacheToolCalls(t *testing.T) {
+	var calls atomic.Int32
+	opts := &ToolGenOptions{ResponseCache: NewMemoryResponseCache(10), ResponseCacheTTL: time.Minute}
+	server := newTestServer(t, cacheSpec, opts, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if cc := r.URL.Query().Get("cache"); cc != "" {
+			w.Header().Set("Cache-Control", cc)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id":1}]`))
+	})
+
+	for i := 0; i < 2; i++ {
+		if res := callToolForTest(t, server, "listItems", map[string]any{"q": "a"}); res.IsError {
+			t.Fatalf("call failed: %s", previewText(res))
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected the repeated call to be answered from the cache, got %d upstream calls", calls.Load())
+	}
+	callToolForTest(t, server, "listItems", map[string]any{"q": "b"})
+	if calls.Load() != 2 {
+		t.Errorf("expected other arguments to miss the cache, got %d upstream calls", calls.Load())
+	}
+
+	calls.Store(0)
+	for i := 0; i < 2; i++ {
+		callToolForTest(t, server, "listItems", map[string]any{"cache": "no-store"})
+		callToolForTest(t, server, "listItems", map[string]any{"cache": "private, max-age=60"})
+		callToolForTest(t, server, "createItem", map[string]any{})
+	}
+	if calls.Load() != 6 {
+		t.Errorf("expected no-store and private responses and POST calls not to be cached, got %d upstream calls", calls.Load())
+	}
+}
+
+func TestResponseCacheHeaderParams(t *testing.T) {
+	var calls atomic.Int32
+	opts := &ToolGenOptions{ResponseCache: NewMemoryResponseCache(10), ResponseCacheTTL: time.Minute}
+	server := newTestServer(t, cacheSpec, opts, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Vary", "X-Tenant")
+		w.Write([]byte(`{"tenant":"` + r.Header.Get("X-Tenant") + `"}`))
+	})
+
+	// The calls differ only by a header parameter, which is not in the URL
+	for _, tenant := range []string{"alice", "bob", "alice", "bob"} {
+		res := callToolForTest(t, server, "listItems", map[string]any{"X-Tenant": tenant})
+		if text := previewText(res); !strings.Contains(text, tenant) {
+			t.Errorf("expected the response for %s, got %s", tenant, text)
+		}
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected one upstream call per tenant, got %d", calls.Load())
+	}
+}
+
+func TestMemoryResponseCache(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryResponseCache(2)
+	resp := &CachedResponse{Status: http.StatusOK, Body: []byte("ok")}
+	cache.Set(ctx, "a", resp, time.Minute)
+	cache.Set(ctx, "b", resp, time.Minute)
+	cache.Get(ctx, "a")
+	cache.Set(ctx, "c", resp, time.Minute)
+	if _, ok := cache.Get(ctx, "b"); ok || cache.Len() != 2 {
+		t.Errorf("expected the least recently used response to be evicted, %d left", cache.Len())
+	}
+	if _, ok := cache.Get(ctx, "a"); !ok {
+		t.Error("expected a recently read response to be kept")
+	}
+
+	cache.Set(ctx, "d", resp, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := cache.Get(ctx, "d"); ok {
+		t.Error("expected an expired response to miss")
+	}
+}
+
+func TestCacheableFor(t *testing.T) {
+	tests := []struct {
+		status       int
+		cacheControl string
+		want         time.Duration
+	}{
+		{http.StatusOK, "", time.Minute},
+		{http.StatusOK, "public, max-age=30", 30 * time.Second},
+		{http.StatusOK, "max-age=30, s-maxage=300", 5 * time.Minute},
+		{http.StatusOK, "max-age=0", 0},
+		{http.StatusOK, "no-store", 0},
+		{http.StatusOK, "private, no-cache", 0},
+		{http.StatusOK, "private, max-age=60", 0},
+		{http.StatusNotFound, "max-age=60", 0},
+		{http.StatusPartialContent, "", 0},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+		if tt.cacheControl != "" {
+			resp.Header.Set("Cache-Control", tt.cacheControl)
+		}
+		if got := cacheableFor(resp, time.Minute); got != tt.want {
+			t.Errorf("status %d, Cache-Control %q: expected %s, got %s", tt.status, tt.cacheControl, tt.want, got)
+		}
+	}
+
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Vary": {"Accept-Language, *"}}}
+	if got := cacheableFor(resp, time.Minute); got != 0 {
+		t.Errorf("expected a response varying on * not to be cached, got %s", got)
+	}
+}
+
+func TestResponseCacheKey(t *testing.T) {
+	newRequest := func(tenant string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "https://api.example.com/items?q=a", nil)
+		req.Header.Set("X-Tenant", tenant)
+		return req
+	}
+	a := responseCacheKey("shop", "listItems", newRequest("alice"), nil)
+	if a != responseCacheKey("shop", "listItems", newRequest("alice"), nil) {
+		t.Error("expected the same request to have the same key")
+	}
+	if a == responseCacheKey("shop", "listItems", newRequest("bob"), nil) {
+		t.Error("expected requests with other headers to have another key")
+	}
+	if a == responseCacheKey("shop", "listItems", newRequest("alice"), &auth.AuthContext{Token: "secret"}) {
+		t.Error("expected calls with other credentials to have another key")
+	}
+}
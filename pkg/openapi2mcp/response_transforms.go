@@ -0,0 +1,345 @@
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// responseTransformsExtension is the root-level spec extension listing the conversions
+// applied to JSON response fields before they reach the agent, e.g.
+//
+//	x-mcp-response-transforms:
+//	  - {field: "*_at", convert: epoch-to-iso8601}
+//	  - {field: distance, convert: unit, from: mi, to: km, rename: distance_km}
+const responseTransformsExtension = "x-mcp-response-transforms"
+
+// Response transform conversions.
+const (
+	ConvertEpoch   = "epoch-to-iso8601"    // Unix seconds to an RFC 3339 UTC time
+	ConvertEpochMs = "epoch-ms-to-iso8601" // Unix milliseconds to an RFC 3339 UTC time
+	ConvertDate    = "date-to-iso8601"     // a date in the Go layout From to RFC 3339, or YYYY-MM-DD without a time of day
+	ConvertUnit    = "unit"                // a number in unit From to unit To, e.g. mi to km or f to c
+	ConvertNumber  = "number"              // a localized number string such as "1.234,56" to a number; From is the decimal separator
+)
+
+// ValueTransform converts the values of matching JSON response fields.
+type ValueTransform struct {
+	Field      string   `json:"field" yaml:"field"`                               // field name at any depth: a name, a glob such as "*_at" or "re:<regexp>", ignoring case
+	Convert    string   `json:"convert" yaml:"convert"`                           // one of the Convert constants
+	From       string   `json:"from,omitempty" yaml:"from,omitempty"`             // date layout, source unit or decimal separator
+	To         string   `json:"to,omitempty" yaml:"to,omitempty"`                 // target unit
+	Rename     string   `json:"rename,omitempty" yaml:"rename,omitempty"`         // new name of converted fields, e.g. to carry the new unit
+	Operations []string `json:"operations,omitempty" yaml:"operations,omitempty"` // operation patterns as in ParseOperationPatterns; all operations when empty
+}
+
+// unitFactors maps units to their factor to the base unit of their dimension.
+var unitFactors = map[string]struct {
+	dimension string
+	factor    float64
+}{
+	"mm": {"length", 0.001}, "cm": {"length", 0.01}, "m": {"length", 1}, "km": {"length", 1000},
+	"in": {"length", 0.0254}, "ft": {"length", 0.3048}, "yd": {"length", 0.9144}, "mi": {"length", 1609.344},
+	"mg": {"mass", 0.000001}, "g": {"mass", 0.001}, "kg": {"mass", 1}, "t": {"mass", 1000},
+	"oz": {"mass", 0.028349523125}, "lb": {"mass", 0.45359237},
+	"ml": {"volume", 0.001}, "l": {"volume", 1}, "gal": {"volume", 3.785411784}, "floz": {"volume", 0.0295735295625},
+	"m/s": {"speed", 1}, "km/h": {"speed", 1 / 3.6}, "mph": {"speed", 0.44704}, "kn": {"speed", 1852.0 / 3600},
+	"c": {"temperature", 0}, "f": {"temperature", 0}, "k": {"temperature", 0},
+}
+
+// compiledTransform is a validated ValueTransform.
+type compiledTransform struct {
+	ValueTransform
+	field      *regexp.Regexp
+	operations []*regexp.Regexp
+}
+
+// specResponseTransforms returns the response transforms from opts or the
+// x-mcp-response-transforms extension. Invalid transforms are logged and skipped.
+func specResponseTransforms(doc *openapi3.T, opts *ToolGenOptions) []compiledTransform {
+	transforms := []ValueTransform(nil)
+	if opts != nil && len(opts.ResponseTransforms) > 0 {
+		transforms = opts.ResponseTransforms
+	} else if doc != nil && doc.Extensions[responseTransformsExtension] != nil {
+		data, err := json.Marshal(doc.Extensions[responseTransformsExtension])
+		if err == nil {
+			err = json.Unmarshal(data, &transforms)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] Ignoring invalid %s: %v\n", responseTransformsExtension, err)
+			return nil
+		}
+	}
+	var compiled []compiledTransform
+	for _, t := range transforms {
+		c, err := compileTransform(t)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] Ignoring response transform of %q: %v\n", t.Field, err)
+			continue
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled
+}
+
+// compileTransform validates a transform.
+func compileTransform(t ValueTransform) (compiledTransform, error) {
+	t.Convert = strings.ToLower(strings.TrimSpace(t.Convert))
+	t.From, t.To = strings.TrimSpace(t.From), strings.TrimSpace(t.To)
+	if strings.TrimSpace(t.Field) == "" {
+		return compiledTransform{}, fmt.Errorf("field is required")
+	}
+	switch t.Convert {
+	case ConvertEpoch, ConvertEpochMs:
+	case ConvertDate:
+		if t.From == "" {
+			return compiledTransform{}, fmt.Errorf("%s requires the date layout in from, e.g. 02/01/2006", ConvertDate)
+		}
+	case ConvertUnit:
+		t.From, t.To = strings.ToLower(t.From), strings.ToLower(t.To)
+		from, ok1 := unitFactors[t.From]
+		to, ok2 := unitFactors[t.To]
+		if !ok1 || !ok2 {
+			return compiledTransform{}, fmt.Errorf("unknown unit in %q to %q", t.From, t.To)
+		}
+		if from.dimension != to.dimension {
+			return compiledTransform{}, fmt.Errorf("cannot convert %s to %s", t.From, t.To)
+		}
+	case ConvertNumber:
+		if t.From == "" {
+			t.From = "."
+		}
+		if t.From != "." && t.From != "," {
+			return compiledTransform{}, fmt.Errorf("the decimal separator in from must be . or ,")
+		}
+	default:
+		return compiledTransform{}, fmt.Errorf("unknown conversion %q: use %s, %s, %s, %s or %s", t.Convert, ConvertEpoch, ConvertEpochMs, ConvertDate, ConvertUnit, ConvertNumber)
+	}
+	fields, err := compileOperationPatterns([]string{strings.TrimSpace(t.Field)})
+	if err != nil {
+		return compiledTransform{}, err
+	}
+	operations, err := compileOperationPatterns(t.Operations)
+	if err != nil {
+		return compiledTransform{}, err
+	}
+	return compiledTransform{ValueTransform: t, field: fields[0], operations: operations}, nil
+}
+
+// operationTransforms returns the transforms applying to an operation.
+func operationTransforms(transforms []compiledTransform, op OpenAPIOperation) []compiledTransform {
+	var selected []compiledTransform
+	for _, t := range transforms {
+		if len(t.operations) == 0 || operationMatches(t.operations, op.OperationID, strings.ToUpper(op.Method)+" "+op.Path) {
+			selected = append(selected, t)
+		}
+	}
+	return selected
+}
+
+// transformDescription tells agents which response fields are converted, as the response
+// schema still describes the upstream values.
+func transformDescription(transforms []compiledTransform) string {
+	if len(transforms) == 0 {
+		return ""
+	}
+	var notes []string
+	for _, t := range transforms {
+		note := t.Field + ": "
+		switch t.Convert {
+		case ConvertEpoch, ConvertEpochMs:
+			note += "ISO 8601 time"
+		case ConvertDate:
+			note += "ISO 8601 date"
+		case ConvertUnit:
+			note += "in " + t.To
+		case ConvertNumber:
+			note += "number"
+		}
+		if t.Rename != "" {
+			note += ", renamed " + t.Rename
+		}
+		notes = append(notes, note)
+	}
+	return "\n\nCONVERTED RESPONSE FIELDS: " + strings.Join(notes, "; ") + "."
+}
+
+// transformResponse applies transforms to a JSON response body. The body is returned
+// unchanged when it is not JSON or no field was converted.
+func transformResponse(body []byte, transforms []compiledTransform) []byte {
+	if len(transforms) == 0 {
+		return body
+	}
+	var decoded any
+	dec := json.NewDecoder(strings.NewReader(string(body)))
+	dec.UseNumber()
+	if err := dec.Decode(&decoded); err != nil {
+		return body
+	}
+	if !transformValue(decoded, transforms) {
+		return body
+	}
+	out, err := json.MarshalIndent(decoded, "", "  ")
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// transformValue converts the matching fields of objects at any depth, reporting whether any was.
+func transformValue(v any, transforms []compiledTransform) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]any:
+		// Renamed fields are added while iterating, so the keys are taken first
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		for _, key := range keys {
+			value := v[key]
+			if transformValue(value, transforms) {
+				changed = true
+			}
+			for _, t := range transforms {
+				if !t.field.MatchString(key) {
+					continue
+				}
+				converted, ok := t.convert(value)
+				if !ok {
+					continue
+				}
+				if t.Rename != "" && t.Rename != key {
+					delete(v, key)
+					v[t.Rename] = converted
+				} else {
+					v[key] = converted
+				}
+				changed = true
+				break
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if transformValue(item, transforms) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// convert converts one value; values of the wrong type are left as they are.
+func (t compiledTransform) convert(v any) (any, bool) {
+	switch t.Convert {
+	case ConvertEpoch, ConvertEpochMs:
+		n, ok := jsonNumberValue(v)
+		if !ok {
+			return nil, false
+		}
+		if t.Convert == ConvertEpochMs {
+			return time.UnixMilli(int64(n)).UTC().Format(time.RFC3339Nano), true
+		}
+		sec, frac := math.Modf(n)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC().Format(time.RFC3339Nano), true
+	case ConvertDate:
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		parsed, err := time.Parse(t.From, strings.TrimSpace(s))
+		if err != nil {
+			return nil, false
+		}
+		if !layoutHasClock(t.From) {
+			return parsed.Format("2006-01-02"), true
+		}
+		return parsed.Format(time.RFC3339Nano), true
+	case ConvertUnit:
+		n, ok := jsonNumberValue(v)
+		if !ok {
+			return nil, false
+		}
+		return convertUnit(n, t.From, t.To), true
+	case ConvertNumber:
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		return parseLocalizedNumber(s, t.From)
+	}
+	return nil, false
+}
+
+// jsonNumberValue reads a decoded JSON number, or a string holding one.
+func jsonNumberValue(v any) (float64, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// layoutHasClock reports whether a Go time layout has a time of day.
+func layoutHasClock(layout string) bool {
+	for _, element := range []string{"15", "03", "3:", ":04", ":4"} {
+		if strings.Contains(layout, element) {
+			return true
+		}
+	}
+	return false
+}
+
+// convertUnit converts n between two units of the same dimension, rounded to 6 decimals.
+func convertUnit(n float64, from, to string) float64 {
+	var result float64
+	if unitFactors[from].dimension == "temperature" {
+		celsius := n
+		switch from {
+		case "f":
+			celsius = (n - 32) * 5 / 9
+		case "k":
+			celsius = n - 273.15
+		}
+		switch to {
+		case "c":
+			result = celsius
+		case "f":
+			result = celsius*9/5 + 32
+		case "k":
+			result = celsius + 273.15
+		}
+	} else {
+		result = n * unitFactors[from].factor / unitFactors[to].factor
+	}
+	return math.Round(result*1e6) / 1e6
+}
+
+// parseLocalizedNumber reads a number written with the given decimal separator and any
+// grouping separators, e.g. "1.234,56" or "1 234,56" with ",".
+func parseLocalizedNumber(s, decimal string) (any, bool) {
+	group := ","
+	if decimal == "," {
+		group = "."
+	}
+	s = strings.NewReplacer(group, "", " ", "", " ", "", " ", "", "'", "").Replace(strings.TrimSpace(s))
+	s = strings.Replace(s, decimal, ".", 1)
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, false
+	}
+	return f, true
+}
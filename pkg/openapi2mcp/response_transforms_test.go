@@ -0,0 +1,97 @@
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+func TestTransformResponse(t *testing.T) {
+	var transforms []compiledTransform
+	for _, vt := range []ValueTransform{
+		{Field: "*_at", Convert: ConvertEpoch},
+		{Field: "updated", Convert: ConvertEpochMs},
+		{Field: "born", Convert: ConvertDate, From: "02/01/2006"},
+		{Field: "distance", Convert: ConvertUnit, From: "mi", To: "km", Rename: "distance_km"},
+		{Field: "temp", Convert: ConvertUnit, From: "F", To: "C"},
+		{Field: "price", Convert: ConvertNumber, From: ","},
+	} {
+		c, err := compileTransform(vt)
+		if err != nil {
+			t.Fatalf("compileTransform(%+v): %v", vt, err)
+		}
+		transforms = append(transforms, c)
+	}
+
+	body := `{"items": [{"created_at": 1700000000, "updated": 1700000000123, "born": "24/12/2019", "distance": 10, "temp": 212, "price": "1.234,50"}], "id": 12345678901234567}`
+	var got map[string]any
+	dec := json.NewDecoder(strings.NewReader(string(transformResponse([]byte(body), transforms))))
+	dec.UseNumber()
+	if err := dec.Decode(&got); err != nil {
+		t.Fatalf("invalid transformed body: %v", err)
+	}
+	item := got["items"].([]any)[0].(map[string]any)
+	want := map[string]string{
+		"created_at":  "2023-11-14T22:13:20Z",
+		"updated":     "2023-11-14T22:13:20.123Z",
+		"born":        "2019-12-24",
+		"distance_km": "16.09344",
+		"temp":        "100",
+		"price":       "1234.5",
+	}
+	for field, value := range want {
+		if s := strings.Trim(toJSONString(item[field]), `"`); s != value {
+			t.Errorf("%s = %s, want %s", field, s, value)
+		}
+	}
+	if _, ok := item["distance"]; ok {
+		t.Error("expected distance to be renamed")
+	}
+	if got["id"].(json.Number) != "12345678901234567" {
+		t.Errorf("expected untouched numbers to keep their precision, got %v", got["id"])
+	}
+
+	unchanged := `{"name": "Rex"}`
+	if string(transformResponse([]byte(unchanged), transforms)) != unchanged {
+		t.Error("expected a body without matching fields to be returned as is")
+	}
+
+	for _, bad := range []ValueTransform{
+		{Field: "x", Convert: "rot13"},
+		{Field: "x", Convert: ConvertUnit, From: "km", To: "kg"},
+		{Field: "x", Convert: ConvertDate},
+		{Convert: ConvertEpoch},
+	} {
+		if _, err := compileTransform(bad); err == nil {
+			t.Errorf("expected an error for %+v", bad)
+		}
+	}
+}
+
+func toJSONString(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func TestResponseTransformsExtension(t *testing.T) {
+	spec := strings.Replace(mockUpstreamSpec, "openapi: 3.0.0\n",
+		"openapi: 3.0.0\nx-mcp-response-transforms:\n  - {field: created_at, convert: epoch-to-iso8601, operations: [getPet]}\n", 1)
+	server := newTestServer(t, spec, &ToolGenOptions{}, jsonUpstream(`{"id": 1, "name": "Rex", "created_at": 0}`))
+
+	for _, tool := range server.ListTools() {
+		if strings.Contains(tool.Description, "CONVERTED RESPONSE FIELDS") != (tool.Name == "getPet") {
+			t.Errorf("expected only getPet to document its converted fields, got %q for %s", tool.Description, tool.Name)
+		}
+	}
+
+	res := callToolForTest(t, server, "getPet", map[string]any{"id": 1})
+	if text := res.Content[0].(mcp.TextContent).Text; !strings.Contains(text, `"created_at": "1970-01-01T00:00:00Z"`) {
+		t.Errorf("expected the converted time in the result, got %s", text)
+	}
+	res = callToolForTest(t, server, "listPets", map[string]any{})
+	if text := res.Content[0].(mcp.TextContent).Text; !strings.Contains(text, `"created_at": 0`) {
+		t.Errorf("expected other operations to be left alone, got %s", text)
+	}
+}
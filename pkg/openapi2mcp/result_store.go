@@ -0,0 +1,284 @@
+// result_store.go
+package openapi2mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/google/uuid"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+)
+
+const (
+	// DefaultResultStoreSize is the number of tool results kept when MCP_RESULT_STORE_SIZE is unset.
+	DefaultResultStoreSize = 100
+	// DefaultResultStoreBytes is the total size of the tool results kept when MCP_RESULT_STORE_BYTES is unset.
+	DefaultResultStoreBytes = 32 << 20
+	// DefaultResultRetention is how long tool results are kept when MCP_RESULT_RETENTION is unset.
+	DefaultResultRetention = time.Hour
+)
+
+// StoredResult is a tool call result kept for later reads as an MCP resource.
+type StoredResult struct {
+	CallID    string
+	SessionID string // session that made the call; only it can read the result
+	Endpoint  string
+	Tool      string
+	MIMEType  string
+	Text      string
+	CreatedAt time.Time
+}
+
+// URI returns the resource URI of the stored result: result://{endpoint}/{callId}.
+func (r StoredResult) URI() string {
+	return ResultURI(r.Endpoint, r.CallID)
+}
+
+// ResultURI builds the resource URI for a stored tool call result.
+func ResultURI(endpoint, callID string) string {
+	return fmt.Sprintf("result://%s/%s", endpoint, callID)
+}
+
+// ResultStore keeps recent tool call results in memory, bounded by entry count, total size
+// and age.
+type ResultStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int
+	retention  time.Duration
+	results    map[string]StoredResult
+	order      []string // call IDs, oldest first
+	size       int      // total length of the stored texts
+}
+
+// NewResultStore creates a result store holding at most maxEntries results, of
+// DefaultResultStoreBytes in total, for up to retention. A retention of 0 keeps results
+// until they are evicted by newer ones.
+func NewResultStore(maxEntries int, retention time.Duration) *ResultStore {
+	return &ResultStore{
+		maxEntries: maxEntries,
+		maxBytes:   DefaultResultStoreBytes,
+		retention:  retention,
+		results:    make(map[string]StoredResult),
+	}
+}
+
+// WithMaxBytes limits the total size of the stored results; results larger than the limit
+// are not stored. 0 removes the limit.
+func (s *ResultStore) WithMaxBytes(maxBytes int) *ResultStore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxBytes = maxBytes
+	s.evictLocked()
+	return s
+}
+
+var (
+	defaultResultStore     *ResultStore
+	defaultResultStoreOnce sync.Once
+)
+
+// DefaultResultStore returns the process-wide store of NewResultStoreFromEnv, shared by all
+// mounted specs so its limits bound the memory of the whole server. It is nil when result
+// storage is disabled.
+func DefaultResultStore() *ResultStore {
+	defaultResultStoreOnce.Do(func() {
+		defaultResultStore = NewResultStoreFromEnv()
+	})
+	return defaultResultStore
+}
+
+// NewResultStoreFromEnv creates a result store configured by MCP_RESULT_STORE_SIZE,
+// MCP_RESULT_STORE_BYTES and MCP_RESULT_RETENTION (a Go duration such as "30m"). It returns
+// nil if the size is 0, which disables result storage.
+func NewResultStoreFromEnv() *ResultStore {
+	size := DefaultResultStoreSize
+	if val := os.Getenv("MCP_RESULT_STORE_SIZE"); val != "" {
+		if n, err := strconv.Atoi(val); err == nil && n >= 0 {
+			size = n
+		} else {
+			fmt.Fprintf(os.Stderr, "[WARN] Invalid MCP_RESULT_STORE_SIZE %q, using %d\n", val, size)
+		}
+	}
+	if size == 0 {
+		return nil
+	}
+	retention := DefaultResultRetention
+	if val := os.Getenv("MCP_RESULT_RETENTION"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d >= 0 {
+			retention = d
+		} else {
+			fmt.Fprintf(os.Stderr, "[WARN] Invalid MCP_RESULT_RETENTION %q, using %s\n", val, retention)
+		}
+	}
+	maxBytes := DefaultResultStoreBytes
+	if val := os.Getenv("MCP_RESULT_STORE_BYTES"); val != "" {
+		if n, err := strconv.Atoi(val); err == nil && n >= 0 {
+			maxBytes = n
+		} else {
+			fmt.Fprintf(os.Stderr, "[WARN] Invalid MCP_RESULT_STORE_BYTES %q, using %d\n", val, maxBytes)
+		}
+	}
+	return NewResultStore(size, retention).WithMaxBytes(maxBytes)
+}
+
+// Put stores a result of a call made by sessionID and returns it with its generated call ID.
+// It reports false, storing nothing, when the result alone exceeds the size limit.
+func (s *ResultStore) Put(sessionID, endpoint, tool, mimeType, text string) (StoredResult, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maxBytes > 0 && len(text) > s.maxBytes {
+		return StoredResult{}, false
+	}
+	s.pruneLocked(time.Now())
+	result := StoredResult{
+		CallID:    uuid.NewString(),
+		SessionID: sessionID,
+		Endpoint:  endpoint,
+		Tool:      tool,
+		MIMEType:  mimeType,
+		Text:      text,
+		CreatedAt: time.Now(),
+	}
+	s.results[result.CallID] = result
+	s.order = append(s.order, result.CallID)
+	s.size += len(text)
+	s.evictLocked()
+	return result, true
+}
+
+// evictLocked drops the oldest results until the entry count and total size are within limits.
+func (s *ResultStore) evictLocked() {
+	for len(s.order) > 0 && (s.maxEntries > 0 && len(s.order) > s.maxEntries || s.maxBytes > 0 && s.size > s.maxBytes) {
+		s.size -= len(s.results[s.order[0]].Text)
+		delete(s.results, s.order[0])
+		s.order = s.order[1:]
+	}
+}
+
+// Get returns the stored result for callID if it has not expired.
+func (s *ResultStore) Get(callID string) (StoredResult, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneLocked(time.Now())
+	result, ok := s.results[callID]
+	return result, ok
+}
+
+// Len returns the number of results currently stored.
+func (s *ResultStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneLocked(time.Now())
+	return len(s.order)
+}
+
+// pruneLocked drops expired results. Results are appended in time order, so it stops at the first live one.
+func (s *ResultStore) pruneLocked(now time.Time) {
+	if s.retention <= 0 {
+		return
+	}
+	i := 0
+	for ; i < len(s.order); i++ {
+		if now.Sub(s.results[s.order[i]].CreatedAt) < s.retention {
+			break
+		}
+		s.size -= len(s.results[s.order[i]].Text)
+		delete(s.results, s.order[i])
+	}
+	s.order = s.order[i:]
+}
+
+// attach stores the result text for the session of ctx and records its resource URI in the
+// result metadata. It is a no-op on a nil store so callers don't need to check whether
+// storage is enabled, and for results too large to store.
+func (s *ResultStore) attach(ctx context.Context, res *mcp.CallToolResult, endpoint, tool, mimeType, text string) *mcp.CallToolResult {
+	if s == nil || res == nil {
+		return res
+	}
+	stored, ok := s.Put(resultSessionID(ctx), endpoint, tool, mimeType, text)
+	if !ok {
+		return res
+	}
+	if res.Meta == nil {
+		res.Meta = map[string]any{}
+	}
+	res.Meta["callId"] = stored.CallID
+	res.Meta["resultUri"] = stored.URI()
+	res.NextSteps = append(res.NextSteps, "read "+stored.URI())
+	return res
+}
+
+// resultSessionID returns the ID of the session of ctx, or "" outside of a session.
+func resultSessionID(ctx context.Context) string {
+	if session := mcpserver.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
+
+// storedResultType returns the MIME type recorded for a stored upstream body.
+func storedResultType(contentType string) string {
+	if contentType == "" {
+		return "text/plain"
+	}
+	return contentType
+}
+
+var nonEndpointChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// resultEndpointName derives the {endpoint} segment of result URIs for a spec:
+// the database endpoint path when known, otherwise a slug of the API title.
+func resultEndpointName(doc *openapi3.T, dbSpec *models.OpenAPISpec) string {
+	if dbSpec != nil {
+		if name := strings.Trim(dbSpec.EndpointPath, "/"); name != "" {
+			return nonEndpointChars.ReplaceAllString(strings.ToLower(name), "-")
+		}
+	}
+	if doc != nil && doc.Info != nil && doc.Info.Title != "" {
+		if name := strings.Trim(nonEndpointChars.ReplaceAllString(strings.ToLower(doc.Info.Title), "-"), "-"); name != "" {
+			return name
+		}
+	}
+	return "default"
+}
+
+// registerResultResources exposes stored results as result://{endpoint}/{callId} resources.
+func registerResultResources(server *mcpserver.MCPServer, store *ResultStore, endpoint string) {
+	if store == nil {
+		return
+	}
+	template := mcp.NewResourceTemplate(
+		ResultURI(endpoint, "{callId}"),
+		"Stored tool result",
+		mcp.WithTemplateDescription(fmt.Sprintf("Result of an earlier %s tool call, readable without calling the upstream API again. The URI is returned in the tool result's _meta.resultUri.", endpoint)),
+	)
+	server.AddResourceTemplate(template, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		callID := strings.TrimPrefix(request.Params.URI, ResultURI(endpoint, ""))
+		result, ok := store.Get(callID)
+		// Results may hold data fetched with the caller's own credentials, so only the
+		// session that made the call can read them
+		if !ok || result.Endpoint != endpoint || result.SessionID != resultSessionID(ctx) {
+			return nil, fmt.Errorf("result %q not found or expired (results are kept for %s, up to %d entries)", callID, store.retention, store.maxEntries)
+		}
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      result.URI(),
+				MIMEType: result.MIMEType,
+				Text:     result.Text,
+			},
+		}, nil
+	})
+}
@@ -0,0 +1,119 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+func TestResultStore_Eviction(t *testing.T) {
+	store := NewResultStore(2, 0)
+	first, _ := store.Put("s1", "pets", "listPets", "application/json", "1")
+	store.Put("s1", "pets", "listPets", "application/json", "2")
+	third, _ := store.Put("s1", "pets", "listPets", "application/json", "3")
+
+	if _, ok := store.Get(first.CallID); ok {
+		t.Errorf("expected oldest result to be evicted")
+	}
+	if got, ok := store.Get(third.CallID); !ok || got.Text != "3" {
+		t.Errorf("expected newest result to be kept, got %+v", got)
+	}
+	if store.Len() != 2 {
+		t.Errorf("expected 2 results, got %d", store.Len())
+	}
+}
+
+func TestResultStore_MaxBytes(t *testing.T) {
+	store := NewResultStore(10, 0).WithMaxBytes(10)
+	first, _ := store.Put("s1", "pets", "listPets", "text/plain", "12345")
+	second, _ := store.Put("s1", "pets", "listPets", "text/plain", "67890")
+	store.Put("s1", "pets", "listPets", "text/plain", "abc")
+
+	if _, ok := store.Get(first.CallID); ok {
+		t.Errorf("expected the oldest result to be evicted to stay within 10 bytes")
+	}
+	if _, ok := store.Get(second.CallID); !ok || store.Len() != 2 {
+		t.Errorf("expected the two newest results to be kept, got %d", store.Len())
+	}
+	if _, ok := store.Put("s1", "pets", "listPets", "text/plain", "larger than the limit"); ok {
+		t.Errorf("expected a result larger than the limit not to be stored")
+	}
+	if store.Len() != 2 {
+		t.Errorf("expected an oversized result to leave the store alone, got %d results", store.Len())
+	}
+}
+
+func TestResultStore_Retention(t *testing.T) {
+	store := NewResultStore(10, time.Minute)
+	stale, _ := store.Put("s1", "pets", "listPets", "application/json", "old")
+	store.mu.Lock()
+	r := store.results[stale.CallID]
+	r.CreatedAt = time.Now().Add(-2 * time.Minute)
+	store.results[stale.CallID] = r
+	store.mu.Unlock()
+
+	if _, ok := store.Get(stale.CallID); ok {
+		t.Errorf("expected expired result to be dropped")
+	}
+}
+
+func TestResultStore_ReadAsResource(t *testing.T) {
+	doc, err := LoadOpenAPISpecFromString(mockUpstreamSpec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	mock := NewMockUpstream(doc)
+	defer mock.Close()
+	t.Setenv("OPENAPI_BASE_URL", mock.URL)
+
+	server := mcpserver.NewMCPServer("test", "0.0.1")
+	RegisterOpenAPITools(server, ExtractOpenAPIOperations(doc), doc, nil, nil)
+
+	ctx := context.Background()
+	resp := server.HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"listPets","arguments":{}}}`))
+	callResp, ok := resp.(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("expected JSONRPCResponse, got %T", resp)
+	}
+	toolResult := callResp.Result.(mcp.CallToolResult)
+	uri, _ := toolResult.Meta["resultUri"].(string)
+	if !strings.HasPrefix(uri, "result://pets/") {
+		t.Fatalf("expected result URI in metadata, got %v", toolResult.Meta)
+	}
+
+	resp = server.HandleMessage(ctx, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":2,"method":"resources/read","params":{"uri":%q}}`, uri)))
+	readResp, ok := resp.(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("expected JSONRPCResponse, got %#v", resp)
+	}
+	contents := readResp.Result.(mcp.ReadResourceResult).Contents
+	if len(contents) != 1 {
+		t.Fatalf("expected one resource content, got %d", len(contents))
+	}
+	text := contents[0].(mcp.TextResourceContents)
+	var pets []map[string]any
+	if err := json.Unmarshal([]byte(text.Text), &pets); err != nil || len(pets) != 1 {
+		t.Errorf("expected stored upstream body, got %q", text.Text)
+	}
+	if text.MIMEType != "application/json" {
+		t.Errorf("expected application/json, got %q", text.MIMEType)
+	}
+
+	resp = server.HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","id":3,"method":"resources/read","params":{"uri":"result://pets/unknown"}}`))
+	if _, ok := resp.(mcp.JSONRPCError); !ok {
+		t.Errorf("expected error for unknown call ID, got %T", resp)
+	}
+
+	// Another session cannot read the result
+	other := &callbackSession{id: "other-session"}
+	resp = server.HandleMessage(server.WithContext(ctx, other), []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":4,"method":"resources/read","params":{"uri":%q}}`, uri)))
+	if _, ok := resp.(mcp.JSONRPCError); !ok {
+		t.Errorf("expected another session to be refused the result, got %T", resp)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
 )
 
 // escapeParameterName converts parameter names with brackets to MCP-compatible names.
@@ -68,8 +69,6 @@ func isAuthenticationHeader(param *openapi3.Parameter, doc *openapi3.T) bool {
 		"authorization",
 		"x-api-key",
 		"api-key",
-		"x-rapidapi-key",
-		"x-rapidapi-host",
 	}
 	
 	for _, authHeader := range commonAuthHeaders {
@@ -78,7 +77,8 @@ func isAuthenticationHeader(param *openapi3.Parameter, doc *openapi3.T) bool {
 		}
 	}
 	
-	return false
+	// Headers filled in by a gateway profile, e.g. X-RapidAPI-Key
+	return auth.IsGatewayHeader(paramName)
 }
 
 // isMessageArrayPattern checks if the oneOf schema represents a common message array pattern
@@ -381,6 +381,12 @@ func extractPropertyWithContextAndVisited(s *openapi3.SchemaRef, doc *openapi3.T
 			prop["required"] = val.Required
 		}
 	}
+	// Maps declared with additionalProperties stay open, also under strict schemas
+	if val.AdditionalProperties.Schema != nil {
+		prop["additionalProperties"] = extractPropertyWithContextAndVisited(val.AdditionalProperties.Schema, doc, visited)
+	} else if val.AdditionalProperties.Has != nil && *val.AdditionalProperties.Has {
+		prop["additionalProperties"] = true
+	}
 	// Array items
 	if val.Type != nil && val.Type.Is("array") && val.Items != nil {
 		prop["items"] = extractPropertyWithContextAndVisited(val.Items, doc, visited)
@@ -409,6 +415,7 @@ func BuildInputSchemaWithContext(params openapi3.Parameters, requestBody *openap
 	}
 	properties := schema["properties"].(map[string]any)
 	var required []string
+	duplicates := duplicateParamNames(params)
 
 	// Parameters (query, path, header, cookie)
 	for _, paramRef := range params {
@@ -424,8 +431,16 @@ func BuildInputSchemaWithContext(params openapi3.Parameters, requestBody *openap
 			if p.Description != "" {
 				prop["description"] = p.Description
 			}
-			// Use escaped parameter name for MCP schema compatibility
-			escapedName := escapeParameterName(p.Name)
+			if hint := arrayParamHint(p); hint != "" {
+				desc, _ := prop["description"].(string)
+				prop["description"] = strings.TrimSpace(desc + " " + hint)
+			}
+			// Use escaped parameter name for MCP schema compatibility, disambiguated by location if needed
+			escapedName := paramArgName(p, duplicates)
+			if duplicates[escapeParameterName(p.Name)] {
+				desc, _ := prop["description"].(string)
+				prop["description"] = strings.TrimSpace(fmt.Sprintf("The %s parameter '%s'. %s", p.In, p.Name, desc))
+			}
 			properties[escapedName] = prop
 			if p.Required && !isAuthenticationHeader(p, doc) {
 				required = append(required, escapedName)
@@ -463,7 +478,7 @@ func BuildInputSchemaWithContext(params openapi3.Parameters, requestBody *openap
 				}
 			}
 			
-			if !isSupported {
+			if !isSupported && !isBinaryMediaType(baseMT) {
 				fmt.Fprintf(os.Stderr, "[WARN] Request body uses media type '%s'. Supported types: %v\n", mtName, supportedTypes)
 			}
 		}
@@ -0,0 +1,294 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+// schemaDefsExtension is the root-level spec extension listing the MCP clients, by the name
+// they send in initialize, whose tools/list schemas share repeated models under $defs:
+// true for all clients, or a list of patterns such as ["claude*", "cursor"].
+const schemaDefsExtension = "x-mcp-schema-defs"
+
+// minSchemaDefBytes is the size below which a repeated subschema is left inline, as a
+// $ref pointer would save little or nothing.
+const minSchemaDefBytes = 80
+
+// specSchemaDefsClients returns the patterns of the clients receiving schemas with $defs,
+// from opts, the x-mcp-schema-defs extension or MCP_SCHEMA_DEFS ("true" or "*" for all
+// clients, or comma-separated patterns). Nil turns $defs off, which is the default.
+func specSchemaDefsClients(doc *openapi3.T, opts *ToolGenOptions) []*regexp.Regexp {
+	var patterns []string
+	switch {
+	case opts != nil && len(opts.SchemaDefs) > 0:
+		patterns = opts.SchemaDefs
+	case doc != nil && doc.Extensions[schemaDefsExtension] != nil:
+		switch v := doc.Extensions[schemaDefsExtension].(type) {
+		case bool:
+			if v {
+				patterns = []string{"*"}
+			}
+		case string:
+			patterns = schemaDefsPatterns(v)
+		case []any:
+			for _, item := range v {
+				if s, ok := item.(string); ok {
+					patterns = append(patterns, s)
+				}
+			}
+		}
+	default:
+		patterns = schemaDefsPatterns(os.Getenv("MCP_SCHEMA_DEFS"))
+	}
+	var cleaned []string
+	for _, p := range patterns {
+		if p = strings.TrimSpace(p); p != "" {
+			cleaned = append(cleaned, p)
+		}
+	}
+	compiled, err := compileOperationPatterns(cleaned)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] Ignoring %s: %v\n", schemaDefsExtension, err)
+		return nil
+	}
+	if len(compiled) == 0 {
+		return nil
+	}
+	return compiled
+}
+
+// schemaDefsPatterns reads a setting that is a boolean or comma-separated client patterns.
+func schemaDefsPatterns(v string) []string {
+	if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+		if b {
+			return []string{"*"}
+		}
+		return nil
+	}
+	return strings.Split(v, ",")
+}
+
+// componentSchemaNames maps the generated schema of each component schema, without its
+// description, to the component name, so that shared definitions keep the spec's names.
+func componentSchemaNames(doc *openapi3.T) map[string]string {
+	names := map[string]string{}
+	if doc == nil || doc.Components == nil {
+		return names
+	}
+	componentNames := make([]string, 0, len(doc.Components.Schemas))
+	for name := range doc.Components.Schemas {
+		componentNames = append(componentNames, name)
+	}
+	sort.Strings(componentNames)
+	for _, name := range componentNames {
+		prop := extractPropertyWithContext(doc.Components.Schemas[name], doc)
+		if prop == nil {
+			continue
+		}
+		// Round-trip through JSON, so the key matches the schemas decoded from tool JSON
+		data, err := json.Marshal(prop)
+		if err != nil {
+			continue
+		}
+		var decoded map[string]any
+		if json.Unmarshal(data, &decoded) != nil {
+			continue
+		}
+		if key := schemaKey(decoded); key != "" {
+			if _, taken := names[key]; !taken {
+				names[key] = name
+			}
+		}
+	}
+	return names
+}
+
+// schemaKey identifies an object subschema by its JSON without the description, which
+// differs between the places a model is used. It is empty for other subschemas.
+func schemaKey(schema map[string]any) string {
+	if props, ok := schema["properties"].(map[string]any); !ok || len(props) == 0 {
+		return ""
+	}
+	stripped := make(map[string]any, len(schema))
+	for k, v := range schema {
+		if k != "description" {
+			stripped[k] = v
+		}
+	}
+	data, err := json.Marshal(stripped)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// schemaChildren calls fn with each direct subschema of schema and a setter replacing it,
+// along with a name hint taken from the property name.
+func schemaChildren(schema map[string]any, fn func(child map[string]any, hint string, set func(any))) {
+	if props, ok := schema["properties"].(map[string]any); ok {
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		// Sorted, so definition names do not depend on map order
+		sort.Strings(names)
+		for _, name := range names {
+			if child, ok := props[name].(map[string]any); ok {
+				name := name
+				fn(child, name, func(v any) { props[name] = v })
+			}
+		}
+	}
+	for _, key := range []string{"items", "additionalProperties", "not"} {
+		if child, ok := schema[key].(map[string]any); ok {
+			key := key
+			fn(child, key, func(v any) { schema[key] = v })
+		}
+	}
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		if list, ok := schema[key].([]any); ok {
+			for i := range list {
+				if child, ok := list[i].(map[string]any); ok {
+					i := i
+					fn(child, key, func(v any) { list[i] = v })
+				}
+			}
+		}
+	}
+}
+
+// shareSchemaDefs moves the object subschemas used more than once in a tool's input schema
+// under $defs, replacing them by $ref pointers, and returns the smaller schema. It returns
+// false when nothing is repeated or the schema would not shrink.
+func shareSchemaDefs(schemaJSON []byte, componentNames map[string]string) ([]byte, bool) {
+	var root map[string]any
+	if err := json.Unmarshal(schemaJSON, &root); err != nil {
+		return nil, false
+	}
+	if _, ok := root["$defs"]; ok {
+		return nil, false
+	}
+
+	counts := map[string]int{}
+	var count func(schema map[string]any)
+	count = func(schema map[string]any) {
+		schemaChildren(schema, func(child map[string]any, _ string, _ func(any)) {
+			if key := schemaKey(child); len(key) >= minSchemaDefBytes {
+				counts[key]++
+			}
+			count(child)
+		})
+	}
+	count(root)
+
+	defs := map[string]any{}
+	defNames := map[string]string{} // schema key to definition name
+	var share func(schema map[string]any)
+	share = func(schema map[string]any) {
+		schemaChildren(schema, func(child map[string]any, hint string, set func(any)) {
+			key := schemaKey(child)
+			if counts[key] < 2 {
+				share(child)
+				return
+			}
+			name, defined := defNames[key]
+			if !defined {
+				name = uniqueDefName(componentNames[key], hint, defs)
+				defNames[key] = name
+				def := make(map[string]any, len(child))
+				for k, v := range child {
+					def[k] = v
+				}
+				delete(def, "description")
+				defs[name] = def
+				share(def)
+			}
+			ref := map[string]any{"$ref": "#/$defs/" + name}
+			if desc, ok := child["description"]; ok {
+				ref["description"] = desc
+			}
+			set(ref)
+		})
+	}
+	share(root)
+	if len(defs) == 0 {
+		return nil, false
+	}
+	root["$defs"] = defs
+	out, err := json.Marshal(root)
+	if err != nil || len(out) >= len(schemaJSON) {
+		return nil, false
+	}
+	return out, true
+}
+
+// uniqueDefName names a shared definition after its component schema, or else after the
+// property it was found in, e.g. ShippingAddress.
+func uniqueDefName(component, hint string, defs map[string]any) string {
+	name := component
+	if name == "" {
+		runes := []rune(hint)
+		if len(runes) > 0 {
+			runes[0] = unicode.ToUpper(runes[0])
+		}
+		name = string(runes)
+		if name == "" || name == "Items" || name == "AllOf" || name == "AnyOf" || name == "OneOf" {
+			name = "Schema" + name
+		}
+	}
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.' {
+			return r
+		}
+		return '_'
+	}, name)
+	unique := name
+	for i := 2; defs[unique] != nil; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	return unique
+}
+
+// schemaDefsToolFilter gives the clients matching patterns the input schemas with $defs;
+// other clients keep the inline schemas.
+func schemaDefsToolFilter(patterns []*regexp.Regexp, schemas map[string][]byte) mcpserver.ToolFilterFunc {
+	return func(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+		if !clientMatches(ctx, patterns) {
+			return tools
+		}
+		filtered := make([]mcp.Tool, len(tools))
+		for i, tool := range tools {
+			if schema, ok := schemas[tool.Name]; ok {
+				tool.RawInputSchema = schema
+			}
+			filtered[i] = tool
+		}
+		return filtered
+	}
+}
+
+// clientMatches reports whether the session's client name, as sent in initialize, matches
+// any of patterns. A "*" pattern also matches clients that sent no name.
+func clientMatches(ctx context.Context, patterns []*regexp.Regexp) bool {
+	name := ""
+	if session, ok := mcpserver.ClientSessionFromContext(ctx).(mcpserver.SessionWithClientInfo); ok {
+		name = session.GetClientInfo().Name
+	}
+	for _, p := range patterns {
+		if p.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
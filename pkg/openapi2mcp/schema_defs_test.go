@@ -0,0 +1,128 @@
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const sharedModelsSpec = `
+openapi: 3.0.0
+info: {title: Shop, version: "1.0"}
+paths:
+  /orders:
+    post:
+      operationId: createOrder
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                billingAddress: {$ref: '#/components/schemas/Address'}
+                shippingAddress: {$ref: '#/components/schemas/Address'}
+                note: {type: string}
+      responses:
+        '200': {description: ok}
+  /ping:
+    get:
+      operationId: ping
+      responses:
+        '200': {description: ok}
+components:
+  schemas:
+    Address:
+      type: object
+      description: A postal address.
+      required: [street, city]
+      properties:
+        street: {type: string, description: Street and house number}
+        city: {type: string, description: City name}
+        postalCode: {type: string, description: Postal or ZIP code}
+        country: {type: string, description: ISO 3166-1 alpha-2 country code}
+`
+
+func TestShareSchemaDefs(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(sharedModelsSpec))
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	var op OpenAPIOperation
+	for _, candidate := range ExtractOpenAPIOperations(doc) {
+		if candidate.OperationID == "createOrder" {
+			op = candidate
+		}
+	}
+	inline, _ := json.Marshal(BuildInputSchemaWithContext(op.Parameters, op.RequestBody, doc))
+
+	shared, ok := shareSchemaDefs(inline, componentSchemaNames(doc))
+	if !ok {
+		t.Fatalf("expected the repeated Address model to be shared, got %s", inline)
+	}
+	if len(shared) >= len(inline) {
+		t.Errorf("expected a smaller schema, got %d bytes instead of %d", len(shared), len(inline))
+	}
+	var schema struct {
+		Defs       map[string]map[string]any `json:"$defs"`
+		Properties struct {
+			RequestBody struct {
+				Properties map[string]map[string]any `json:"properties"`
+			} `json:"requestBody"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(shared, &schema); err != nil {
+		t.Fatalf("invalid schema: %v", err)
+	}
+	if schema.Defs["Address"] == nil {
+		t.Fatalf("expected an Address definition named after the component, got %v", schema.Defs)
+	}
+	for _, field := range []string{"billingAddress", "shippingAddress"} {
+		if ref := schema.Properties.RequestBody.Properties[field]["$ref"]; ref != "#/$defs/Address" {
+			t.Errorf("expected %s to point to the shared definition, got %v", field, ref)
+		}
+	}
+
+	if _, ok := shareSchemaDefs([]byte(`{"type":"object","properties":{"id":{"type":"integer"}}}`), nil); ok {
+		t.Error("expected a schema without repeated models to be left alone")
+	}
+}
+
+func TestSchemaDefsPerClient(t *testing.T) {
+	server := newTestServer(t, sharedModelsSpec, &ToolGenOptions{SchemaDefs: []string{"claude*"}}, nil)
+	ts := httptest.NewServer(HandlerForStreamableHTTP(server, "/mcp"))
+	defer ts.Close()
+
+	post := func(sessionID, body string) (*http.Response, string) {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/mcp", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		if sessionID != "" {
+			req.Header.Set("Mcp-Session-Id", sessionID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp, string(data)
+	}
+	listTools := func(client string) string {
+		resp, _ := post("", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"`+client+`","version":"1"}}}`)
+		_, body := post(resp.Header.Get("Mcp-Session-Id"), `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+		return body
+	}
+
+	if body := listTools("claude-code"); !strings.Contains(body, `$defs`) || !strings.Contains(body, `#/$defs/Address`) {
+		t.Errorf("expected a matching client to get shared definitions, got %s", body)
+	}
+	if body := listTools("other-client"); strings.Contains(body, `$defs`) {
+		t.Errorf("expected other clients to get inline schemas, got %s", body)
+	}
+}
@@ -0,0 +1,130 @@
+package openapi2mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+// enforceScopesExtension is the root OpenAPI extension that turns on scope enforcement for a spec.
+const enforceScopesExtension = "x-mcp-enforce-scopes"
+
+// specEnforceScopes reports whether a spec's tools are limited to sessions holding the
+// scopes their operations declare: opts.EnforceScopes, then the x-mcp-enforce-scopes
+// extension, then MCP_ENFORCE_SCOPES. Off by default.
+func specEnforceScopes(doc *openapi3.T, opts *ToolGenOptions) bool {
+	if opts != nil && opts.EnforceScopes {
+		return true
+	}
+	if doc != nil {
+		if v, ok := doc.Extensions[enforceScopesExtension].(bool); ok {
+			return v
+		}
+	}
+	enabled, _ := strconv.ParseBool(os.Getenv("MCP_ENFORCE_SCOPES"))
+	return enabled
+}
+
+// operationScopes returns the scope sets that allow calling an operation, one per security
+// requirement: the scopes of all its schemes together. A session needs every scope of at
+// least one set. It returns nil when the operation needs no scope, including when one of
+// its alternatives (or the empty requirement {}) declares none.
+func operationScopes(op OpenAPIOperation) [][]string {
+	var sets [][]string
+	for _, req := range op.Security {
+		var set []string
+		for _, scopes := range req {
+			set = append(set, scopes...)
+		}
+		if len(set) == 0 {
+			return nil
+		}
+		sort.Strings(set)
+		sets = append(sets, set)
+	}
+	return sets
+}
+
+// sessionScopes returns the scopes granted to the session: the space-separated "scope"
+// claim, or the "scp" claim (list or string), of its bearer token as verified with
+// MCP_JWT_SECRET. A session without a valid token has no scopes.
+func sessionScopes(ctx context.Context) map[string]bool {
+	claims, err := sessionJWTClaims(ctx)
+	if err != nil {
+		return nil
+	}
+	granted := map[string]bool{}
+	for _, claim := range []string{"scope", "scp"} {
+		switch v := claims[claim].(type) {
+		case string:
+			for _, s := range strings.Fields(v) {
+				granted[s] = true
+			}
+		case []any:
+			for _, s := range v {
+				if str, ok := s.(string); ok {
+					granted[str] = true
+				}
+			}
+		}
+	}
+	return granted
+}
+
+// scopesSatisfied reports whether granted holds every scope of at least one set.
+func scopesSatisfied(required [][]string, granted map[string]bool) bool {
+	if len(required) == 0 {
+		return true
+	}
+	for _, set := range required {
+		ok := true
+		for _, scope := range set {
+			if !granted[scope] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// insufficientScopeError describes the scopes a tool needs.
+func insufficientScopeError(tool string, required [][]string) string {
+	alternatives := make([]string, len(required))
+	for i, set := range required {
+		alternatives[i] = strings.Join(set, " ")
+	}
+	return fmt.Sprintf("Insufficient scope: %s requires a bearer token granting %s", tool, strings.Join(alternatives, ", or "))
+}
+
+// scopeToolFilter hides the tools whose scopes the session does not hold from tools/list.
+// Tools that are not in required are left alone, as they belong to another spec or need no scope.
+func scopeToolFilter(required map[string][][]string) mcpserver.ToolFilterFunc {
+	return func(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+		var granted map[string]bool
+		checked := false
+		filtered := tools[:0:0]
+		for _, tool := range tools {
+			if sets, ok := required[tool.Name]; ok {
+				if !checked {
+					granted, checked = sessionScopes(ctx), true
+				}
+				if !scopesSatisfied(sets, granted) {
+					continue
+				}
+			}
+			filtered = append(filtered, tool)
+		}
+		return filtered
+	}
+}
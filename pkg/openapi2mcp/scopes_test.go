@@ -0,0 +1,122 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+const scopedSpec = `
+openapi: 3.0.0
+info:
+  title: Scoped Pets
+  version: 1.0.0
+x-mcp-enforce-scopes: true
+components:
+  securitySchemes:
+    oauth:
+      type: oauth2
+      flows:
+        clientCredentials:
+          tokenUrl: https://auth.example.com/token
+          scopes:
+            pets:read: Read pets
+            pets:write: Change pets
+            admin: Everything
+security:
+  - oauth: [pets:read]
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        '200': {description: OK}
+    post:
+      operationId: createPet
+      security:
+        - oauth: [pets:read, pets:write]
+        - oauth: [admin]
+      responses:
+        '200': {description: OK}
+  /status:
+    get:
+      operationId: getStatus
+      security: []
+      responses:
+        '200': {description: OK}
+`
+
+func scopedContext(claims string) context.Context {
+	req := httptest.NewRequest("POST", "/scoped-pets", nil)
+	if claims != "" {
+		req.Header.Set("Authorization", "Bearer "+signHS256(claims, "s3cret"))
+	}
+	return auth.WithAuthContext(context.Background(), &auth.AuthContext{OriginalRequest: req})
+}
+
+func listToolNames(t *testing.T, server *mcpserver.MCPServer, ctx context.Context) []string {
+	t.Helper()
+	req, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "tools/list"})
+	resp, ok := server.HandleMessage(ctx, req).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatal("expected JSONRPCResponse for tools/list")
+	}
+	var names []string
+	for _, tool := range resp.Result.(mcp.ListToolsResult).Tools {
+		if tool.Name != "info" && tool.Name != "describe" {
+			names = append(names, tool.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestScopeEnforcement(t *testing.T) {
+	t.Setenv("MCP_JWT_SECRET", "s3cret")
+
+	server := newTestServer(t, scopedSpec, nil, jsonUpstream(`{}`))
+
+	cases := map[string]struct {
+		claims string
+		want   string
+	}{
+		"no token":      {"", "getStatus"},
+		"read scope":    {`{"scope":"pets:read"}`, "getStatus,listPets"},
+		"both scopes":   {`{"scope":"pets:read pets:write"}`, "createPet,getStatus,listPets"},
+		"admin via scp": {`{"scp":["admin"]}`, "createPet,getStatus"},
+	}
+	for name, tc := range cases {
+		if got := strings.Join(listToolNames(t, server, scopedContext(tc.claims)), ","); got != tc.want {
+			t.Errorf("%s: expected tools %s, got %s", name, tc.want, got)
+		}
+	}
+
+	call, _ := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      2,
+		"method":  "tools/call",
+		"params":  map[string]any{"name": "listPets", "arguments": map[string]any{}},
+	})
+	res := server.HandleMessage(scopedContext(`{"scope":"pets:write"}`), call).(mcp.JSONRPCResponse).Result.(mcp.CallToolResult)
+	if !res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "Insufficient scope") {
+		t.Errorf("expected the call to be refused without pets:read, got %+v", res)
+	}
+	res = server.HandleMessage(scopedContext(`{"scope":"pets:read"}`), call).(mcp.JSONRPCResponse).Result.(mcp.CallToolResult)
+	if res.IsError {
+		t.Errorf("expected the call to succeed with pets:read, got %+v", res)
+	}
+}
+
+func TestScopesNotEnforcedByDefault(t *testing.T) {
+	server := newTestServer(t, strings.Replace(scopedSpec, "x-mcp-enforce-scopes: true", "", 1), nil, nil)
+	if got := strings.Join(listToolNames(t, server, scopedContext("")), ","); got != "createPet,getStatus,listPets" {
+		t.Errorf("expected every tool without enforcement, got %s", got)
+	}
+}
@@ -0,0 +1,432 @@
+// sdk.go
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+// SDKLanguages lists the languages GenerateClientSDK supports.
+var SDKLanguages = []string{"ts", "python"}
+
+// sdkTool is the part of a tool a generated client needs.
+type sdkTool struct {
+	Name        string
+	Description string
+	Properties  map[string]map[string]any
+	Required    map[string]bool
+}
+
+// sdkArg is one argument of a generated method, in declaration order.
+type sdkArg struct {
+	Name     string
+	Type     string
+	Required bool
+	Doc      string
+}
+
+var identifierChars = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// GenerateClientSDK emits a thin client for the tools registered on server. The client
+// talks to the MCP StreamableHTTP endpoint at endpointURL and exposes one typed method per tool.
+func GenerateClientSDK(server *mcpserver.MCPServer, title, lang, endpointURL string) (string, error) {
+	tools := sdkTools(server.ListTools())
+	switch lang {
+	case "ts", "typescript":
+		return generateTypeScriptSDK(title, endpointURL, tools), nil
+	case "python", "py":
+		return generatePythonSDK(title, endpointURL, tools), nil
+	default:
+		return "", fmt.Errorf("unsupported SDK language %q (supported: %s)", lang, strings.Join(SDKLanguages, ", "))
+	}
+}
+
+func sdkTools(tools []mcp.Tool) []sdkTool {
+	out := make([]sdkTool, 0, len(tools))
+	for _, tool := range tools {
+		var schema struct {
+			Properties map[string]map[string]any `json:"properties"`
+			Required   []string                  `json:"required"`
+		}
+		if raw, err := json.Marshal(tool); err == nil {
+			var wrapper struct {
+				InputSchema json.RawMessage `json:"inputSchema"`
+			}
+			if json.Unmarshal(raw, &wrapper) == nil {
+				_ = json.Unmarshal(wrapper.InputSchema, &schema)
+			}
+		}
+		required := map[string]bool{}
+		for _, r := range schema.Required {
+			required[r] = true
+		}
+		out = append(out, sdkTool{
+			Name:        tool.Name,
+			Description: sdkDescription(tool.Description),
+			Properties:  schema.Properties,
+			Required:    required,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// args lists a tool's arguments, required ones first, with types for lang.
+func (t sdkTool) args(typeOf func(map[string]any) string) []sdkArg {
+	args := make([]sdkArg, 0, len(t.Properties))
+	for name, prop := range t.Properties {
+		doc, _ := prop["description"].(string)
+		args = append(args, sdkArg{Name: name, Type: typeOf(prop), Required: t.Required[name], Doc: firstLine(doc)})
+	}
+	sort.Slice(args, func(i, j int) bool {
+		if args[i].Required != args[j].Required {
+			return args[i].Required
+		}
+		return args[i].Name < args[j].Name
+	})
+	return args
+}
+
+// sectionHeading matches the headings of generated tool descriptions, e.g. "PARAMETERS:".
+var sectionHeading = regexp.MustCompile(`^[A-Z][A-Z _-]*:`)
+
+// sdkDescription is the summary line of a tool description, if it has one.
+func sdkDescription(desc string) string {
+	line := firstLine(desc)
+	if sectionHeading.MatchString(line) {
+		return ""
+	}
+	return line
+}
+
+// sdkTitle is a spec title on one line, for the header comments of generated clients: the
+// title comes from the spec, which may come from a docs page or a cluster annotation, and
+// must not end a // comment early.
+func sdkTitle(title string) string {
+	return strings.Join(strings.Fields(title), " ")
+}
+
+// pyDocstring escapes text for a """ docstring, so it cannot close it.
+func pyDocstring(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	return s
+}
+
+// identifierParts splits a tool name such as "get-pet_by.id" into words.
+func identifierParts(name string) []string {
+	var parts []string
+	for _, p := range identifierChars.Split(name, -1) {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) == 0 {
+		parts = []string{"tool"}
+	}
+	if unicode.IsDigit(rune(parts[0][0])) {
+		parts[0] = "_" + parts[0]
+	}
+	return parts
+}
+
+func camelIdentifier(name string, upperFirst bool) string {
+	var b strings.Builder
+	for i, p := range identifierParts(name) {
+		if i > 0 || upperFirst {
+			p = strings.ToUpper(p[:1]) + p[1:]
+		}
+		b.WriteString(p)
+	}
+	return b.String()
+}
+
+// pythonReserved are names a generated Python parameter or method must not use.
+var pythonReserved = map[string]bool{
+	"and": true, "as": true, "assert": true, "async": true, "await": true, "break": true, "class": true,
+	"continue": true, "def": true, "del": true, "elif": true, "else": true, "except": true, "false": true,
+	"finally": true, "for": true, "from": true, "global": true, "if": true, "import": true, "in": true,
+	"is": true, "lambda": true, "none": true, "nonlocal": true, "not": true, "or": true, "pass": true,
+	"raise": true, "return": true, "self": true, "true": true, "try": true, "while": true, "with": true,
+	"yield": true, "args": true, "call_tool": true,
+}
+
+func pythonIdentifier(name string) string {
+	id := snakeIdentifier(name)
+	if pythonReserved[id] {
+		id += "_"
+	}
+	return id
+}
+
+func snakeIdentifier(name string) string {
+	var words []string
+	for _, p := range identifierParts(name) {
+		var b strings.Builder
+		for i, r := range p {
+			if unicode.IsUpper(r) && i > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		}
+		words = append(words, b.String())
+	}
+	return strings.Join(words, "_")
+}
+
+func schemaTypeName(prop map[string]any) string {
+	t, _ := prop["type"].(string)
+	return t
+}
+
+func tsType(prop map[string]any) string {
+	if enum, ok := prop["enum"].([]any); ok && len(enum) > 0 {
+		values := make([]string, 0, len(enum))
+		for _, v := range enum {
+			data, _ := json.Marshal(v)
+			values = append(values, string(data))
+		}
+		return strings.Join(values, " | ")
+	}
+	switch schemaTypeName(prop) {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		if items, ok := prop["items"].(map[string]any); ok {
+			if it := tsType(items); !strings.Contains(it, "|") {
+				return it + "[]"
+			}
+		}
+		return "unknown[]"
+	case "object":
+		return "Record<string, unknown>"
+	default:
+		return "unknown"
+	}
+}
+
+func pythonType(prop map[string]any) string {
+	switch schemaTypeName(prop) {
+	case "string":
+		return "str"
+	case "integer":
+		return "int"
+	case "number":
+		return "float"
+	case "boolean":
+		return "bool"
+	case "array":
+		if items, ok := prop["items"].(map[string]any); ok {
+			return "List[" + pythonType(items) + "]"
+		}
+		return "List[Any]"
+	case "object":
+		return "Dict[str, Any]"
+	default:
+		return "Any"
+	}
+}
+
+// tsClassMembers are names a generated TypeScript method must not use.
+var tsClassMembers = map[string]bool{
+	"constructor": true, "rpc": true, "callTool": true, "url": true, "headers": true, "sessionId": true, "nextId": true,
+}
+
+func tsMethodName(name string) string {
+	id := camelIdentifier(name, false)
+	if tsClassMembers[id] {
+		id += "_"
+	}
+	return id
+}
+
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func generateTypeScriptSDK(title, endpointURL string, tools []sdkTool) string {
+	className := camelIdentifier(title, true) + "Client"
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Client for the %s MCP endpoint, generated by openapi-mcp. Do not edit.\n\n", sdkTitle(title))
+	b.WriteString(`export interface ToolResult {
+  content: Array<{ type: string; text?: string; [key: string]: unknown }>;
+  isError?: boolean;
+  [key: string]: unknown;
+}
+
+`)
+	for _, tool := range tools {
+		fmt.Fprintf(&b, "export interface %sArgs {\n", camelIdentifier(tool.Name, true))
+		for _, arg := range tool.args(tsType) {
+			key := arg.Name
+			if !tsIdentifier.MatchString(key) {
+				key = fmt.Sprintf("%q", key)
+			}
+			if arg.Doc != "" {
+				fmt.Fprintf(&b, "  /** %s */\n", strings.ReplaceAll(arg.Doc, "*/", "* /"))
+			}
+			optional := "?"
+			if arg.Required {
+				optional = ""
+			}
+			fmt.Fprintf(&b, "  %s%s: %s;\n", key, optional, arg.Type)
+		}
+		b.WriteString("}\n\n")
+	}
+	fmt.Fprintf(&b, `export class %s {
+  private sessionId?: string;
+  private nextId = 1;
+
+  constructor(
+    private readonly url: string = %q,
+    private readonly headers: Record<string, string> = {},
+  ) {}
+
+  private async rpc(method: string, params: unknown): Promise<any> {
+    const headers: Record<string, string> = {
+      "Content-Type": "application/json",
+      Accept: "application/json, text/event-stream",
+      ...this.headers,
+    };
+    if (this.sessionId) headers["Mcp-Session-Id"] = this.sessionId;
+    const res = await fetch(this.url, {
+      method: "POST",
+      headers,
+      body: JSON.stringify({ jsonrpc: "2.0", id: this.nextId++, method, params }),
+    });
+    const sessionId = res.headers.get("Mcp-Session-Id");
+    if (sessionId) this.sessionId = sessionId;
+    const text = await res.text();
+    let message: any;
+    if ((res.headers.get("Content-Type") ?? "").includes("text/event-stream")) {
+      const data = text.split("\n").filter((l) => l.startsWith("data:"));
+      message = JSON.parse(data[data.length - 1].slice(5));
+    } else {
+      message = JSON.parse(text);
+    }
+    if (message.error) throw new Error(message.error.message);
+    return message.result;
+  }
+
+  /** Calls any tool by name. */
+  async callTool(name: string, args: object = {}): Promise<ToolResult> {
+    if (!this.sessionId) {
+      await this.rpc("initialize", {
+        protocolVersion: %q,
+        capabilities: {},
+        clientInfo: { name: %q, version: "1.0.0" },
+      });
+    }
+    return this.rpc("tools/call", { name, arguments: args });
+  }
+`, className, endpointURL, mcp.LATEST_PROTOCOL_VERSION, className)
+	for _, tool := range tools {
+		typeName := camelIdentifier(tool.Name, true) + "Args"
+		hasRequired := len(tool.Required) > 0
+		b.WriteString("\n")
+		if tool.Description != "" {
+			fmt.Fprintf(&b, "  /** %s */\n", strings.ReplaceAll(tool.Description, "*/", "* /"))
+		}
+		param := "args: " + typeName + " = {}"
+		if hasRequired {
+			param = "args: " + typeName
+		}
+		fmt.Fprintf(&b, "  %s(%s): Promise<ToolResult> {\n    return this.callTool(%q, args);\n  }\n",
+			tsMethodName(tool.Name), param, tool.Name)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func generatePythonSDK(title, endpointURL string, tools []sdkTool) string {
+	className := camelIdentifier(title, true) + "Client"
+	var b strings.Builder
+	fmt.Fprintf(&b, "\"\"\"Client for the %s MCP endpoint, generated by openapi-mcp. Do not edit.\"\"\"\n\n", pyDocstring(sdkTitle(title)))
+	fmt.Fprintf(&b, `import json
+import urllib.request
+from typing import Any, Dict, List, Optional
+
+
+class %s:
+    def __init__(self, url: str = %q, headers: Optional[Dict[str, str]] = None):
+        self.url = url
+        self.headers = headers or {}
+        self.session_id: Optional[str] = None
+        self._next_id = 1
+
+    def _rpc(self, method: str, params: Any) -> Any:
+        headers = {"Content-Type": "application/json", "Accept": "application/json, text/event-stream"}
+        headers.update(self.headers)
+        if self.session_id:
+            headers["Mcp-Session-Id"] = self.session_id
+        body = json.dumps({"jsonrpc": "2.0", "id": self._next_id, "method": method, "params": params}).encode()
+        self._next_id += 1
+        req = urllib.request.Request(self.url, data=body, headers=headers, method="POST")
+        with urllib.request.urlopen(req) as res:
+            self.session_id = res.headers.get("Mcp-Session-Id") or self.session_id
+            text = res.read().decode()
+            if "text/event-stream" in (res.headers.get("Content-Type") or ""):
+                text = [l[5:] for l in text.splitlines() if l.startswith("data:")][-1]
+        message = json.loads(text)
+        if "error" in message:
+            raise RuntimeError(message["error"].get("message"))
+        return message["result"]
+
+    def call_tool(self, name: str, arguments: Optional[Dict[str, Any]] = None) -> Dict[str, Any]:
+        """Calls any tool by name."""
+        if not self.session_id:
+            self._rpc("initialize", {
+                "protocolVersion": %q,
+                "capabilities": {},
+                "clientInfo": {"name": %q, "version": "1.0.0"},
+            })
+        return self._rpc("tools/call", {"name": name, "arguments": arguments or {}})
+`, className, endpointURL, mcp.LATEST_PROTOCOL_VERSION, className)
+	for _, tool := range tools {
+		var params, docs, assigns []string
+		params = append(params, "self")
+		for _, arg := range tool.args(pythonType) {
+			pyName := pythonIdentifier(arg.Name)
+			if arg.Required {
+				params = append(params, fmt.Sprintf("%s: %s", pyName, arg.Type))
+				assigns = append(assigns, fmt.Sprintf("        args[%q] = %s", arg.Name, pyName))
+			} else {
+				params = append(params, fmt.Sprintf("%s: Optional[%s] = None", pyName, arg.Type))
+				assigns = append(assigns, fmt.Sprintf("        if %s is not None:\n            args[%q] = %s", pyName, arg.Name, pyName))
+			}
+			if arg.Doc != "" {
+				docs = append(docs, fmt.Sprintf("            %s: %s", pyName, arg.Doc))
+			}
+		}
+		fmt.Fprintf(&b, "\n    def %s(%s) -> Dict[str, Any]:\n", pythonIdentifier(tool.Name), strings.Join(params, ", "))
+		doc := pyDocstring(tool.Description)
+		if doc == "" {
+			doc = "Calls the " + tool.Name + " tool."
+		}
+		if len(docs) > 0 {
+			doc += "\n\n        Args:\n" + pyDocstring(strings.Join(docs, "\n")) + "\n        "
+		}
+		fmt.Fprintf(&b, "        \"\"\"%s\"\"\"\n", doc)
+		b.WriteString("        args: Dict[str, Any] = {}\n")
+		for _, a := range assigns {
+			b.WriteString(a + "\n")
+		}
+		fmt.Fprintf(&b, "        return self.call_tool(%q, args)\n", tool.Name)
+	}
+	return b.String()
+}
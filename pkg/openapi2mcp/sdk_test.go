@@ -0,0 +1,65 @@
+package openapi2mcp
+
+import (
+	"strings"
+	"testing"
+
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+func TestGenerateClientSDK(t *testing.T) {
+	doc, err := LoadOpenAPISpecFromString(mockUpstreamSpec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	server := mcpserver.NewMCPServer("test", "0.0.1")
+	RegisterOpenAPITools(server, ExtractOpenAPIOperations(doc), doc, nil, nil)
+
+	tests := []struct {
+		lang string
+		want []string
+	}{
+		{"ts", []string{
+			"export class PetsClient",
+			"export interface GetPetArgs {\n  id: number;",
+			"getPet(args: GetPetArgs): Promise<ToolResult>",
+			`"http://localhost:8080/pets"`,
+		}},
+		{"python", []string{
+			"class PetsClient:",
+			"def get_pet(self, id: int) -> Dict[str, Any]:",
+			`return self.call_tool("getPet", args)`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			source, err := GenerateClientSDK(server, doc.Info.Title, tt.lang, "http://localhost:8080/pets")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(source, want) {
+					t.Errorf("expected generated %s client to contain %q\n%s", tt.lang, want, source)
+				}
+			}
+		})
+	}
+
+	if _, err := GenerateClientSDK(server, doc.Info.Title, "cobol", ""); err == nil {
+		t.Errorf("expected an error for an unsupported language")
+	}
+}
+
+func TestGenerateClientSDKEscapesTitle(t *testing.T) {
+	server := mcpserver.NewMCPServer("test", "0.0.1")
+	title := "Pets\nimport os; os.system('id') \"\"\" \\"
+	ts, _ := GenerateClientSDK(server, title, "ts", "")
+	if !strings.HasPrefix(ts, "// Client for the Pets import os; os.system('id') \"\"\" \\ MCP endpoint") {
+		t.Errorf("expected the title on the comment line, got %q", strings.SplitN(ts, "\n", 2)[0])
+	}
+	py, _ := GenerateClientSDK(server, title, "python", "")
+	header := strings.SplitN(py, "\n", 2)[0]
+	if header != `"""Client for the Pets import os; os.system('id') \"\"\" \\ MCP endpoint, generated by openapi-mcp. Do not edit."""` {
+		t.Errorf("expected the title escaped in the module docstring, got %q", header)
+	}
+}
@@ -6,11 +6,14 @@ import (
 	"fmt"
 	"net/http"
 	"os"
-	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+	"github.com/ubermorgenland/openapi-mcp/pkg/metrics"
 	"github.com/ubermorgenland/openapi-mcp/pkg/models"
 )
 
@@ -37,16 +40,10 @@ func authContextFunc(ctx context.Context, r *http.Request) context.Context {
 //	openapi2mcp.ServeHTTP(srv, ":8080")
 func NewServer(name, version string, doc *openapi3.T) *mcpserver.MCPServer {
 	ops := ExtractOpenAPIOperations(doc)
-	srv := mcpserver.NewMCPServer(name, version)
+	srv := mcpserver.NewMCPServer(name, version, ServerOptions()...)
 	fmt.Fprintf(os.Stderr, "[INFO] Registering %d operations for %s (memory optimized)\n", len(ops), name)
-	
-	// Force initial GC before processing large operations
-	runtime.GC()
-	
+
 	RegisterOpenAPITools(srv, ops, doc, nil, nil)
-	
-	// Final cleanup
-	runtime.GC()
 	fmt.Fprintf(os.Stderr, "[INFO] Server creation complete for %s\n", name)
 	return srv
 }
@@ -59,7 +56,7 @@ func NewServer(name, version string, doc *openapi3.T) *mcpserver.MCPServer {
 //	srv := openapi2mcp.NewServerWithOps("petstore", doc.Info.Version, doc, ops)
 //	openapi2mcp.ServeHTTP(srv, ":8080")
 func NewServerWithOps(name, version string, doc *openapi3.T, ops []OpenAPIOperation) *mcpserver.MCPServer {
-	srv := mcpserver.NewMCPServer(name, version)
+	srv := mcpserver.NewMCPServer(name, version, ServerOptions()...)
 	RegisterOpenAPITools(srv, ops, doc, nil, nil)
 	return srv
 }
@@ -70,16 +67,10 @@ func NewServerWithOps(name, version string, doc *openapi3.T, ops []OpenAPIOperat
 //	srv := openapi2mcp.NewServerWithDatabase("weather", doc.Info.Version, doc, dbSpec)
 func NewServerWithDatabase(name, version string, doc *openapi3.T, dbSpec *models.OpenAPISpec) *mcpserver.MCPServer {
 	ops := ExtractOpenAPIOperations(doc)
-	srv := mcpserver.NewMCPServer(name, version)
+	srv := mcpserver.NewMCPServer(name, version, ServerOptions()...)
 	fmt.Fprintf(os.Stderr, "[INFO] Registering %d operations for %s with database auth (memory optimized)\n", len(ops), name)
-	
-	// Force initial GC before processing large operations
-	runtime.GC()
-	
+
 	RegisterOpenAPITools(srv, ops, doc, nil, dbSpec)
-	
-	// Final cleanup
-	runtime.GC()
 	fmt.Fprintf(os.Stderr, "[INFO] Database-aware server creation complete for %s\n", name)
 	return srv
 }
@@ -111,16 +102,46 @@ func ServeHTTP(server *mcpserver.MCPServer, addr string, basePath string) error
 		basePath = "/mcp"
 	}
 
+	publicURL, _ := PublicURL()
+	_, prefix, _ := mcpserver.ParsePublicURL(publicURL)
+	httpServer := NewHTTPServer(addr, nil)
 	sseServer := mcpserver.NewSSEServer(server,
 		mcpserver.WithSSEContextFunc(sseAuthContextFunc),
 		mcpserver.WithStaticBasePath(basePath),
 		mcpserver.WithSSEEndpoint("/sse"),
-		mcpserver.WithMessageEndpoint("/message"))
+		mcpserver.WithMessageEndpoint("/message"),
+		mcpserver.WithPublicURL(publicURL),
+		mcpserver.WithHTTPServer(httpServer))
+	httpServer.Handler = mcpserver.StripPathPrefix(prefix, sseServer)
 	return sseServer.Start(addr)
 }
 
+// PublicURL returns the external URL clients reach the server at through a reverse proxy,
+// MCP_PUBLIC_URL (e.g. https://example.com/mcp-gateway), without a trailing slash, or "" when
+// it is not set. Its path prefix is added to the URLs sent to clients, and requests are
+// served with or without it, so proxies may strip it or not.
+func PublicURL() (string, error) {
+	publicURL := strings.TrimRight(strings.TrimSpace(os.Getenv("MCP_PUBLIC_URL")), "/")
+	if publicURL == "" {
+		return "", nil
+	}
+	if _, _, err := mcpserver.ParsePublicURL(publicURL); err != nil {
+		return "", fmt.Errorf("MCP_PUBLIC_URL: %w", err)
+	}
+	return publicURL, nil
+}
+
+// serverURL returns the URL of a server listening on addr: MCP_PUBLIC_URL when it is set.
+func serverURL(addr string) string {
+	if publicURL, _ := PublicURL(); publicURL != "" {
+		return publicURL
+	}
+	return "http://" + normalizeAddrToHost(addr)
+}
+
 // GetSSEURL returns the URL for establishing an SSE connection to the MCP server.
-// addr is the address the server is listening on (e.g., ":8080", "0.0.0.0:8080", "localhost:8080").
+// addr is the address the server is listening on (e.g., ":8080", "0.0.0.0:8080", "localhost:8080");
+// it is not used when MCP_PUBLIC_URL is set.
 // basePath is the base HTTP path (e.g., "/mcp").
 // Example usage:
 //
@@ -130,12 +151,12 @@ func GetSSEURL(addr, basePath string) string {
 	if basePath == "" {
 		basePath = "/mcp"
 	}
-	host := normalizeAddrToHost(addr)
-	return "http://" + host + basePath + "/sse"
+	return serverURL(addr) + basePath + "/sse"
 }
 
 // GetMessageURL returns the URL for sending JSON-RPC requests to the MCP server.
-// addr is the address the server is listening on (e.g., ":8080", "0.0.0.0:8080", "localhost:8080").
+// addr is the address the server is listening on (e.g., ":8080", "0.0.0.0:8080", "localhost:8080");
+// it is not used when MCP_PUBLIC_URL is set.
 // basePath is the base HTTP path (e.g., "/mcp").
 // sessionID should be the session ID received from the SSE endpoint event.
 // Example usage:
@@ -146,12 +167,12 @@ func GetMessageURL(addr, basePath, sessionID string) string {
 	if basePath == "" {
 		basePath = "/mcp"
 	}
-	host := normalizeAddrToHost(addr)
-	return fmt.Sprintf("http://%s%s/message?sessionId=%s", host, basePath, sessionID)
+	return fmt.Sprintf("%s%s/message?sessionId=%s", serverURL(addr), basePath, sessionID)
 }
 
 // GetStreamableHTTPURL returns the URL for the Streamable HTTP endpoint of the MCP server.
-// addr is the address the server is listening on (e.g., ":8080", "0.0.0.0:8080", "localhost:8080").
+// addr is the address the server is listening on (e.g., ":8080", "0.0.0.0:8080", "localhost:8080");
+// it is not used when MCP_PUBLIC_URL is set.
 // basePath is the base HTTP path (e.g., "/mcp").
 // Example usage:
 //
@@ -161,8 +182,7 @@ func GetStreamableHTTPURL(addr, basePath string) string {
 	if basePath == "" {
 		basePath = "/mcp"
 	}
-	host := normalizeAddrToHost(addr)
-	return "http://" + host + basePath
+	return serverURL(addr) + basePath
 }
 
 // normalizeAddrToHost converts an addr (as used by net/http) to a host:port string suitable for URLs.
@@ -180,6 +200,7 @@ func normalizeAddrToHost(addr string) string {
 
 // HandlerForBasePath returns an http.Handler that serves the given MCP server at the specified basePath.
 // This is useful for multi-mount HTTP servers, where you want to serve multiple OpenAPI schemas at different URL paths.
+// Behind a reverse proxy set by MCP_PUBLIC_URL, serve the mux through mcpserver.StripPathPrefix.
 // Example usage:
 //
 //	handler := openapi2mcp.HandlerForBasePath(srv, "/petstore")
@@ -191,11 +212,13 @@ func HandlerForBasePath(server *mcpserver.MCPServer, basePath string) http.Handl
 	if basePath == "" {
 		basePath = "/mcp"
 	}
+	publicURL, _ := PublicURL()
 	sseServer := mcpserver.NewSSEServer(server,
 		mcpserver.WithSSEContextFunc(sseAuthContextFunc),
 		mcpserver.WithStaticBasePath(basePath),
 		mcpserver.WithSSEEndpoint("/sse"),
 		mcpserver.WithMessageEndpoint("/message"),
+		mcpserver.WithPublicURL(publicURL),
 	)
 	return sseServer
 }
@@ -220,8 +243,15 @@ func ServeStreamableHTTP(server *mcpserver.MCPServer, addr string, basePath stri
 	streamableServer := mcpserver.NewStreamableHTTPServer(server,
 		mcpserver.WithHTTPContextFunc(streamableAuthContextFunc),
 		mcpserver.WithEndpointPath(basePath),
+		sessionIdManagerOption(basePath),
+		mcpserver.WithCompression(CompressionConfig()),
+		mcpserver.WithSessionStore(sharedSessionStore()),
 	)
-	return streamableServer.Start(addr)
+	mux := http.NewServeMux()
+	mux.Handle(basePath, streamableServer)
+	publicURL, _ := PublicURL()
+	_, prefix, _ := mcpserver.ParsePublicURL(publicURL)
+	return NewHTTPServer(addr, mcpserver.StripPathPrefix(prefix, mux)).ListenAndServe()
 }
 
 // HandlerForStreamableHTTP returns an http.Handler that serves the given MCP server at the specified basePath using StreamableHTTP.
@@ -240,6 +270,120 @@ func HandlerForStreamableHTTP(server *mcpserver.MCPServer, basePath string) http
 	streamableServer := mcpserver.NewStreamableHTTPServer(server,
 		mcpserver.WithHTTPContextFunc(streamableAuthContextFunc),
 		mcpserver.WithEndpointPath(basePath),
+		sessionIdManagerOption(basePath),
+		mcpserver.WithCompression(CompressionConfig()),
+		mcpserver.WithSessionStore(sharedSessionStore()),
 	)
 	return streamableServer
 }
+
+// sessionIdManagerOption signs session IDs as JWTs bound to basePath when MCP_SESSION_SECRET
+// is set, and keeps the default UUID session IDs otherwise.
+func sessionIdManagerOption(basePath string) mcpserver.StreamableHTTPOption {
+	secret := os.Getenv("MCP_SESSION_SECRET")
+	if secret == "" {
+		return func(*mcpserver.StreamableHTTPServer) {}
+	}
+	ttl, _ := time.ParseDuration(os.Getenv("MCP_SESSION_TTL"))
+	return mcpserver.WithSessionIdManager(mcpserver.NewJWTSessionIdManager([]byte(secret),
+		mcpserver.WithJWTSessionEndpoint(basePath),
+		mcpserver.WithJWTSessionTTL(ttl),
+	))
+}
+
+// SessionStore returns the session store set by MCP_SESSION_STORE: a redis:// or rediss://
+// URL keeps sessions in Redis, so they survive restarts and are shared by replicas behind a
+// load balancer. It returns nil when it is not set or "memory", to keep them in memory.
+func SessionStore() (mcpserver.SessionStore, error) {
+	v := os.Getenv("MCP_SESSION_STORE")
+	if v == "" || v == "memory" {
+		return nil, nil
+	}
+	store, err := mcpserver.NewRedisSessionStore(v)
+	if err != nil {
+		return nil, fmt.Errorf("MCP_SESSION_STORE: %w", err)
+	}
+	return store, nil
+}
+
+var (
+	sessionStore     mcpserver.SessionStore
+	sessionStoreOnce sync.Once
+)
+
+// sharedSessionStore returns the store of MCP_SESSION_STORE, shared by every endpoint served
+// by this process, or nil to keep sessions in memory.
+func sharedSessionStore() mcpserver.SessionStore {
+	sessionStoreOnce.Do(func() {
+		store, err := SessionStore()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] Sessions are kept in memory: %v\n", err)
+		}
+		sessionStore = store
+	})
+	return sessionStore
+}
+
+// CompressionConfig returns the gzip compression of HTTP responses set by the environment:
+// MCP_GZIP=false turns it off, MCP_GZIP_MIN_SIZE is the size in bytes from which responses
+// are compressed (default 1024) and MCP_GZIP_LEVEL the gzip level, from 1 (fastest) to 9.
+func CompressionConfig() mcpserver.CompressionConfig {
+	var cfg mcpserver.CompressionConfig
+	if v := os.Getenv("MCP_GZIP"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		cfg.Disabled = err == nil && !enabled
+	}
+	if v := os.Getenv("MCP_GZIP_MIN_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.MinSize = n
+		} else {
+			fmt.Fprintf(os.Stderr, "[WARN] Invalid MCP_GZIP_MIN_SIZE %q, using %d bytes\n", v, mcpserver.DefaultCompressionMinSize)
+		}
+	}
+	if v := os.Getenv("MCP_GZIP_LEVEL"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 1 && n <= 9 {
+			cfg.Level = n
+		} else {
+			fmt.Fprintf(os.Stderr, "[WARN] Invalid MCP_GZIP_LEVEL %q, using the default level\n", v)
+		}
+	}
+	return cfg
+}
+
+var (
+	transcriptRecorder     *mcpserver.TranscriptRecorder
+	transcriptRecorderOnce sync.Once
+)
+
+// ServerOptions returns the MCP server options set by the environment: with MCP_TRANSCRIPT_DIR,
+// each session's JSON-RPC messages are recorded there, to be replayed with mcp-client --replay.
+// MCP requests are counted in metrics.MCPRequests; servers replacing the hooks with
+// mcpserver.WithHooks should pass them through metrics.InstrumentHooks to keep counting.
+// With MCP_TOOLS_PAGE_SIZE, tools/list returns that many tools per page, with a nextCursor.
+func ServerOptions() []mcpserver.ServerOption {
+	transcriptRecorderOnce.Do(func() {
+		dir := os.Getenv("MCP_TRANSCRIPT_DIR")
+		if dir == "" {
+			return
+		}
+		recorder, err := mcpserver.NewTranscriptRecorder(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] Session transcripts disabled: %v\n", err)
+			return
+		}
+		transcriptRecorder = recorder
+		fmt.Fprintf(os.Stderr, "[INFO] Recording session transcripts in %s\n", dir)
+	})
+	opts := []mcpserver.ServerOption{mcpserver.WithHooks(metrics.InstrumentHooks(nil))}
+	if transcriptRecorder != nil {
+		opts = append(opts, mcpserver.WithTranscriptRecorder(transcriptRecorder))
+	}
+	if v := os.Getenv("MCP_TOOLS_PAGE_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			opts = append(opts, mcpserver.WithToolsPageSize(n))
+		} else {
+			fmt.Fprintf(os.Stderr, "[WARN] Invalid MCP_TOOLS_PAGE_SIZE %q, listing all tools at once\n", v)
+		}
+	}
+	return opts
+}
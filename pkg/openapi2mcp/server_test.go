@@ -94,3 +94,24 @@ func TestGetMessageURL(t *testing.T) {
 		})
 	}
 }
+
+func TestPublicURL(t *testing.T) {
+	t.Setenv("MCP_PUBLIC_URL", "https://example.com/mcp-gateway/")
+	if got := GetSSEURL(":8080", "/weather"); got != "https://example.com/mcp-gateway/weather/sse" {
+		t.Errorf("expected the SSE URL under the public URL, got %q", got)
+	}
+	if got := GetMessageURL(":8080", "/weather", "s1"); got != "https://example.com/mcp-gateway/weather/message?sessionId=s1" {
+		t.Errorf("expected the message URL under the public URL, got %q", got)
+	}
+	if got := GetStreamableHTTPURL(":8080", "/weather"); got != "https://example.com/mcp-gateway/weather" {
+		t.Errorf("expected the Streamable HTTP URL under the public URL, got %q", got)
+	}
+
+	t.Setenv("MCP_PUBLIC_URL", "/mcp-gateway")
+	if _, err := PublicURL(); err == nil {
+		t.Errorf("expected a public URL without a host to be rejected")
+	}
+	if got := GetSSEURL(":8080", "/weather"); got != "http://localhost:8080/weather/sse" {
+		t.Errorf("expected an invalid public URL to be ignored, got %q", got)
+	}
+}
@@ -0,0 +1,154 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+// Outcomes of a smoke-tested tool.
+const (
+	SmokePassed  = "pass"
+	SmokeFailed  = "fail"
+	SmokeSkipped = "skip"
+)
+
+// SmokeResult is the outcome of calling one tool in a smoke test.
+type SmokeResult struct {
+	Tool     string
+	Path     string
+	Status   string // SmokePassed, SmokeFailed or SmokeSkipped
+	Detail   string // why the call failed or was skipped
+	Duration time.Duration
+}
+
+// SmokeTest calls every GET tool of server that can be called without inventing values:
+// operations without required parameters, or whose required parameters have an example,
+// default or enum value in the spec. The calls are real and go through the tool handlers,
+// so they use ctx's authentication like any MCP client would. Each call gets timeout.
+// Tools that are not registered (filtered by tag, method policy or feature flag) are skipped.
+func SmokeTest(ctx context.Context, server *mcpserver.MCPServer, ops []OpenAPIOperation, timeout time.Duration) []SmokeResult {
+	registered := map[string]bool{}
+	for _, tool := range server.ListTools() {
+		registered[tool.Name] = true
+	}
+	var results []SmokeResult
+	for _, op := range ops {
+		if !strings.EqualFold(op.Method, "GET") {
+			continue
+		}
+		res := SmokeResult{Tool: op.OperationID, Path: op.Path}
+		args, err := smokeArguments(op)
+		switch {
+		case !registered[op.OperationID]:
+			res.Status, res.Detail = SmokeSkipped, "tool is not registered"
+		case err != nil:
+			res.Status, res.Detail = SmokeSkipped, err.Error()
+		default:
+			res.Status, res.Detail, res.Duration = smokeCall(ctx, server, op.OperationID, args, timeout)
+		}
+		results = append(results, res)
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Tool < results[j].Tool })
+	return results
+}
+
+func smokeCall(ctx context.Context, server *mcpserver.MCPServer, tool string, args map[string]any, timeout time.Duration) (string, string, time.Duration) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	req, _ := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]any{"name": tool, "arguments": args},
+	})
+	start := time.Now()
+	msg := server.HandleMessage(ctx, req)
+	elapsed := time.Since(start)
+	switch resp := msg.(type) {
+	case mcp.JSONRPCResponse:
+		res, ok := resp.Result.(mcp.CallToolResult)
+		if !ok {
+			return SmokeFailed, fmt.Sprintf("unexpected result %T", resp.Result), elapsed
+		}
+		if res.IsError {
+			text, _ := firstText(&res)
+			return SmokeFailed, smokeDetail(text), elapsed
+		}
+		return SmokePassed, "", elapsed
+	case mcp.JSONRPCError:
+		return SmokeFailed, resp.Error.Message, elapsed
+	default:
+		return SmokeFailed, fmt.Sprintf("unexpected response %T", msg), elapsed
+	}
+}
+
+// smokeArguments fills the required parameters of an operation from the examples of the spec.
+func smokeArguments(op OpenAPIOperation) (map[string]any, error) {
+	if op.RequestBody != nil && op.RequestBody.Value != nil && op.RequestBody.Value.Required {
+		return nil, fmt.Errorf("operation requires a request body")
+	}
+	args := map[string]any{}
+	duplicates := duplicateParamNames(op.Parameters)
+	for _, ref := range op.Parameters {
+		if ref == nil || ref.Value == nil || !ref.Value.Required {
+			continue
+		}
+		value, ok := parameterExample(ref.Value)
+		if !ok {
+			return nil, fmt.Errorf("required parameter %s has no example", ref.Value.Name)
+		}
+		args[paramArgName(ref.Value, duplicates)] = value
+	}
+	return args, nil
+}
+
+// parameterExample returns the example of a parameter, or of its schema, or the schema's
+// default or first enum value.
+func parameterExample(p *openapi3.Parameter) (any, bool) {
+	if p.Example != nil {
+		return p.Example, true
+	}
+	names := make([]string, 0, len(p.Examples))
+	for name := range p.Examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if ex := p.Examples[name]; ex != nil && ex.Value != nil && ex.Value.Value != nil {
+			return ex.Value.Value, true
+		}
+	}
+	if p.Schema != nil && p.Schema.Value != nil {
+		s := p.Schema.Value
+		switch {
+		case s.Example != nil:
+			return s.Example, true
+		case s.Default != nil:
+			return s.Default, true
+		case len(s.Enum) > 0:
+			return s.Enum[0], true
+		}
+	}
+	return nil, false
+}
+
+// smokeDetail is the first line of an error result, truncated for a one-line report.
+func smokeDetail(text string) string {
+	const maxDetail = 200
+	line := firstLine(text)
+	if len(line) > maxDetail {
+		line = line[:maxDetail] + "..."
+	}
+	return line
+}
@@ -0,0 +1,103 @@
+package openapi2mcp
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+const smokeSpec = `
+openapi: 3.0.0
+info:
+  title: Smoke
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        '200': {description: OK}
+    post:
+      operationId: createPet
+      responses:
+        '200': {description: OK}
+  /pets/{id}:
+    get:
+      operationId: getPet
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema: {type: integer, example: 7}
+      responses:
+        '200': {description: OK}
+  /owners/{id}:
+    get:
+      operationId: getOwner
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema: {type: integer}
+      responses:
+        '200': {description: OK}
+  /broken:
+    get:
+      operationId: getBroken
+      parameters:
+        - name: mode
+          in: query
+          required: true
+          schema: {type: string, enum: [fast, slow]}
+      responses:
+        '200': {description: OK}
+`
+
+func TestSmokeTest(t *testing.T) {
+	var paths []string
+	serveUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.RequestURI())
+		if r.URL.Path == "/broken" {
+			http.Error(w, `{"error":"boom"}`, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	})
+
+	doc, err := LoadOpenAPISpecFromString(smokeSpec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	ops := ExtractOpenAPIOperations(doc)
+	server := mcpserver.NewMCPServer("test", "0.0.1")
+	RegisterOpenAPITools(server, ops, doc, nil, nil)
+
+	results := SmokeTest(context.Background(), server, ops, 5*time.Second)
+	want := map[string]string{
+		"getBroken": SmokeFailed,
+		"getOwner":  SmokeSkipped,
+		"getPet":    SmokePassed,
+		"listPets":  SmokePassed,
+	}
+	if len(results) != len(want) {
+		t.Fatalf("expected only the GET tools to be tested, got %+v", results)
+	}
+	for _, res := range results {
+		if res.Status != want[res.Tool] {
+			t.Errorf("%s: expected %s, got %s (%s)", res.Tool, want[res.Tool], res.Status, res.Detail)
+		}
+		if res.Status != SmokePassed && res.Detail == "" {
+			t.Errorf("%s: expected a reason for %s", res.Tool, res.Status)
+		}
+	}
+	expected := map[string]bool{"/pets": true, "/pets/7": true, "/broken?mode=fast": true}
+	for _, p := range paths {
+		if !expected[p] {
+			t.Errorf("unexpected upstream call %s", p)
+		}
+	}
+}
@@ -187,6 +187,8 @@ func ExtractOpenAPIOperations(doc *openapi3.T) []OpenAPIOperation {
 				RequestBody: op.RequestBody,
 				Tags:        tags,
 				Security:    security,
+				Callbacks:   op.Callbacks,
+				Extensions:  op.Extensions,
 			})
 		}
 	}
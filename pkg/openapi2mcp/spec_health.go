@@ -0,0 +1,283 @@
+package openapi2mcp
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+)
+
+// States of a spec's health.
+const (
+	SpecHealthy  = "healthy"
+	SpecFailing  = "failing"  // failing above the threshold, but not for long enough to be degraded
+	SpecDegraded = "degraded" // failing above the threshold for the sustained period
+)
+
+// SpecHealthPolicy decides when a spec whose upstream calls fail is degraded.
+type SpecHealthPolicy struct {
+	FailureRate float64       // share of auth and connection failures among recent calls
+	MinCalls    int           // recent calls needed before the failure rate counts
+	Window      time.Duration // how far back calls are recent
+	SustainFor  time.Duration // how long the failure rate must stay at or above FailureRate
+}
+
+// DefaultSpecHealthPolicy marks a spec degraded when at least 80% of its calls in the last
+// 5 minutes, and at least 5 calls, failed with auth or connection errors for 10 minutes.
+var DefaultSpecHealthPolicy = SpecHealthPolicy{
+	FailureRate: 0.8,
+	MinCalls:    5,
+	Window:      5 * time.Minute,
+	SustainFor:  10 * time.Minute,
+}
+
+// SpecHealthStatus is the health of one spec, from its recent upstream calls.
+type SpecHealthStatus struct {
+	Endpoint           string     `json:"endpoint"`
+	State              string     `json:"state"`
+	RecentCalls        int        `json:"recent_calls"`
+	AuthFailures       int        `json:"auth_failures"`       // 401 and 403 responses
+	ConnectionFailures int        `json:"connection_failures"` // transport errors and 502, 503 and 504 responses
+	FailureRate        float64    `json:"failure_rate"`
+	FailingSince       *time.Time `json:"failing_since,omitempty"`
+	DegradedSince      *time.Time `json:"degraded_since,omitempty"`
+	LastFailure        string     `json:"last_failure,omitempty"`
+}
+
+type healthOutcome int
+
+const (
+	outcomeOK healthOutcome = iota
+	outcomeAuthFailure
+	outcomeConnectionFailure
+)
+
+// maxHealthCalls caps the recent calls kept per spec.
+const maxHealthCalls = 1000
+
+type healthCall struct {
+	at      time.Time
+	outcome healthOutcome
+}
+
+type specHealthState struct {
+	calls         []healthCall
+	failingSince  time.Time
+	degradedSince time.Time
+	lastFailure   string
+}
+
+// SpecHealth tracks the auth and connection failures of each spec's upstream calls and marks
+// a spec degraded when they stay above the policy's threshold, so gateways can report it and
+// take its tools out of service.
+type SpecHealth struct {
+	policy SpecHealthPolicy
+	now    func() time.Time
+
+	mu          sync.Mutex
+	specs       map[string]*specHealthState
+	subscribers []func(SpecHealthStatus)
+}
+
+// NewSpecHealth creates a health tracker with the given policy.
+func NewSpecHealth(policy SpecHealthPolicy) *SpecHealth {
+	return &SpecHealth{policy: policy, now: time.Now, specs: map[string]*specHealthState{}}
+}
+
+var (
+	defaultSpecHealth     *SpecHealth
+	defaultSpecHealthOnce sync.Once
+)
+
+// DefaultSpecHealth returns the process-wide health tracker, with DefaultSpecHealthPolicy
+// adjusted by MCP_DEGRADED_FAILURE_RATE (e.g. 0.5) and MCP_DEGRADED_AFTER (e.g. 30m).
+func DefaultSpecHealth() *SpecHealth {
+	defaultSpecHealthOnce.Do(func() {
+		policy := DefaultSpecHealthPolicy
+		if v := os.Getenv("MCP_DEGRADED_FAILURE_RATE"); v != "" {
+			if rate, err := strconv.ParseFloat(v, 64); err == nil && rate > 0 && rate <= 1 {
+				policy.FailureRate = rate
+			} else {
+				fmt.Fprintf(os.Stderr, "[WARN] Ignoring invalid MCP_DEGRADED_FAILURE_RATE=%q\n", v)
+			}
+		}
+		if v := os.Getenv("MCP_DEGRADED_AFTER"); v != "" {
+			if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+				policy.SustainFor = d
+			} else {
+				fmt.Fprintf(os.Stderr, "[WARN] Ignoring invalid MCP_DEGRADED_AFTER=%q\n", v)
+			}
+		}
+		defaultSpecHealth = NewSpecHealth(policy)
+	})
+	return defaultSpecHealth
+}
+
+// EndpointName returns the name a spec's upstream metrics, budget and health are reported
+// under: its database endpoint path, or else its title, lowercased.
+func EndpointName(doc *openapi3.T, dbSpec *models.OpenAPISpec) string {
+	return resultEndpointName(doc, dbSpec)
+}
+
+// Subscribe calls fn, outside the tracker's lock, whenever a spec becomes degraded or
+// recovers from being degraded.
+func (h *SpecHealth) Subscribe(fn func(SpecHealthStatus)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subscribers = append(h.subscribers, fn)
+}
+
+// Observe records an upstream call of a spec. status is 0 when the call failed before a
+// response, with err.
+func (h *SpecHealth) Observe(endpoint string, status int, err error) {
+	outcome := outcomeOK
+	failure := ""
+	switch {
+	case status == 0:
+		outcome = outcomeConnectionFailure
+		failure = "connection failed"
+		if err != nil {
+			failure += ": " + err.Error()
+		}
+	case status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout:
+		outcome = outcomeConnectionFailure
+		failure = fmt.Sprintf("HTTP %d", status)
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		outcome = outcomeAuthFailure
+		failure = fmt.Sprintf("HTTP %d", status)
+	}
+
+	h.mu.Lock()
+	now := h.now()
+	s := h.specs[endpoint]
+	if s == nil {
+		s = &specHealthState{}
+		h.specs[endpoint] = s
+	}
+	s.calls = append(s.calls, healthCall{at: now, outcome: outcome})
+	if failure != "" {
+		s.lastFailure = failure
+	}
+	wasDegraded := !s.degradedSince.IsZero()
+	st := h.updateLocked(endpoint, s, now)
+	changed := wasDegraded != !s.degradedSince.IsZero()
+	subscribers := h.subscribers
+	h.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if st.State == SpecDegraded {
+		fmt.Fprintf(os.Stderr, "[WARN] Spec %s is degraded: %d of %d recent upstream calls failed (last: %s)\n",
+			endpoint, st.AuthFailures+st.ConnectionFailures, st.RecentCalls, st.LastFailure)
+	} else {
+		fmt.Fprintf(os.Stderr, "[INFO] Spec %s recovered\n", endpoint)
+	}
+	for _, fn := range subscribers {
+		fn(st)
+	}
+}
+
+// updateLocked drops calls that are no longer recent and updates the failing and degraded
+// times of a spec. The caller holds h.mu.
+func (h *SpecHealth) updateLocked(endpoint string, s *specHealthState, now time.Time) SpecHealthStatus {
+	cutoff := now.Add(-h.policy.Window)
+	drop := 0
+	for drop < len(s.calls) && (s.calls[drop].at.Before(cutoff) || len(s.calls)-drop > maxHealthCalls) {
+		drop++
+	}
+	s.calls = s.calls[drop:]
+
+	status := SpecHealthStatus{Endpoint: endpoint, State: SpecHealthy, RecentCalls: len(s.calls), LastFailure: s.lastFailure}
+	for _, c := range s.calls {
+		switch c.outcome {
+		case outcomeAuthFailure:
+			status.AuthFailures++
+		case outcomeConnectionFailure:
+			status.ConnectionFailures++
+		}
+	}
+	if status.RecentCalls > 0 {
+		status.FailureRate = float64(status.AuthFailures+status.ConnectionFailures) / float64(status.RecentCalls)
+	}
+
+	switch {
+	case status.RecentCalls >= h.policy.MinCalls && status.FailureRate >= h.policy.FailureRate:
+		if s.failingSince.IsZero() {
+			s.failingSince = now
+		}
+		if s.degradedSince.IsZero() && now.Sub(s.failingSince) >= h.policy.SustainFor {
+			s.degradedSince = now
+		}
+	case status.FailureRate < h.policy.FailureRate:
+		// Only a lower failure rate ends a failure; too few calls say nothing
+		s.failingSince, s.degradedSince = time.Time{}, time.Time{}
+	}
+
+	if !s.failingSince.IsZero() {
+		since := s.failingSince
+		status.FailingSince = &since
+		status.State = SpecFailing
+	}
+	if !s.degradedSince.IsZero() {
+		since := s.degradedSince
+		status.DegradedSince = &since
+		status.State = SpecDegraded
+	}
+	return status
+}
+
+// Status returns the health of a spec; a spec without calls is healthy.
+func (h *SpecHealth) Status(endpoint string) SpecHealthStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.specs[endpoint]
+	if s == nil {
+		return SpecHealthStatus{Endpoint: endpoint, State: SpecHealthy}
+	}
+	return h.statusLocked(endpoint, s)
+}
+
+// statusLocked reports a spec's health without changing its state, so a spec stays
+// degraded until calls show it recovered. The caller holds h.mu.
+func (h *SpecHealth) statusLocked(endpoint string, s *specHealthState) SpecHealthStatus {
+	failingSince, degradedSince := s.failingSince, s.degradedSince
+	status := h.updateLocked(endpoint, s, h.now())
+	s.failingSince, s.degradedSince = failingSince, degradedSince
+	status.State = SpecHealthy
+	status.FailingSince, status.DegradedSince = nil, nil
+	if !failingSince.IsZero() {
+		status.State = SpecFailing
+		status.FailingSince = &failingSince
+	}
+	if !degradedSince.IsZero() {
+		status.State = SpecDegraded
+		status.DegradedSince = &degradedSince
+	}
+	return status
+}
+
+// All returns the health of every spec with upstream calls, sorted by endpoint.
+func (h *SpecHealth) All() []SpecHealthStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	all := make([]SpecHealthStatus, 0, len(h.specs))
+	for endpoint, s := range h.specs {
+		all = append(all, h.statusLocked(endpoint, s))
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Endpoint < all[j].Endpoint })
+	return all
+}
+
+// Reset forgets the calls of a spec, e.g. after it was deactivated.
+func (h *SpecHealth) Reset(endpoint string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.specs, endpoint)
+}
@@ -0,0 +1,86 @@
+package openapi2mcp
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSpecHealthDegradesAfterSustainedFailures(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	h := NewSpecHealth(SpecHealthPolicy{FailureRate: 0.5, MinCalls: 3, Window: 5 * time.Minute, SustainFor: 10 * time.Minute})
+	h.now = func() time.Time { return now }
+	var changes []SpecHealthStatus
+	h.Subscribe(func(s SpecHealthStatus) { changes = append(changes, s) })
+
+	// Too few calls to count
+	h.Observe("weather", http.StatusUnauthorized, nil)
+	h.Observe("weather", 0, errors.New("connection refused"))
+	if got := h.Status("weather").State; got != SpecHealthy {
+		t.Fatalf("expected healthy with too few calls, got %s", got)
+	}
+
+	h.Observe("weather", http.StatusOK, nil)
+	h.Observe("weather", http.StatusServiceUnavailable, nil)
+	status := h.Status("weather")
+	if status.State != SpecFailing || status.AuthFailures != 1 || status.ConnectionFailures != 2 || status.FailureRate != 0.75 {
+		t.Fatalf("expected a failing spec, got %+v", status)
+	}
+
+	// Still failing 10 minutes later: degraded
+	for i := 0; i < 4; i++ {
+		now = now.Add(3 * time.Minute)
+		h.Observe("weather", http.StatusForbidden, nil)
+		h.Observe("weather", http.StatusForbidden, nil)
+	}
+	status = h.Status("weather")
+	if status.State != SpecDegraded || status.LastFailure != "HTTP 403" {
+		t.Fatalf("expected a degraded spec, got %+v", status)
+	}
+	if len(changes) != 1 || changes[0].State != SpecDegraded {
+		t.Fatalf("expected one degraded notification, got %+v", changes)
+	}
+
+	// Calls that succeed again recover the spec
+	for i := 0; i < 10; i++ {
+		h.Observe("weather", http.StatusOK, nil)
+	}
+	if got := h.Status("weather").State; got != SpecHealthy {
+		t.Errorf("expected the spec to recover, got %s", got)
+	}
+	if len(changes) != 2 || changes[1].State != SpecHealthy {
+		t.Errorf("expected a recovery notification, got %+v", changes)
+	}
+	if all := h.All(); len(all) != 1 || all[0].Endpoint != "weather" {
+		t.Errorf("unexpected specs %+v", all)
+	}
+	h.Reset("weather")
+	if len(h.All()) != 0 {
+		t.Error("expected Reset to forget the spec")
+	}
+}
+
+func TestSpecHealthIgnoresOtherErrors(t *testing.T) {
+	h := NewSpecHealth(SpecHealthPolicy{FailureRate: 0.5, MinCalls: 1, Window: time.Minute})
+	for _, status := range []int{http.StatusNotFound, http.StatusBadRequest, http.StatusInternalServerError} {
+		h.Observe("pets", status, nil)
+	}
+	if status := h.Status("pets"); status.State != SpecHealthy || status.FailureRate != 0 {
+		t.Errorf("expected client and server errors not to count, got %+v", status)
+	}
+}
+
+func TestSpecHealthObservesUpstreamCalls(t *testing.T) {
+	h := NewSpecHealth(SpecHealthPolicy{FailureRate: 0.5, MinCalls: 2, Window: time.Minute})
+	server := newTestServer(t, mockUpstreamSpec, &ToolGenOptions{SpecHealth: h}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	callToolForTest(t, server, "listPets", map[string]any{})
+	callToolForTest(t, server, "listPets", map[string]any{})
+
+	status := h.Status("pets")
+	if status.State != SpecDegraded || status.AuthFailures != 2 {
+		t.Errorf("expected the spec to be degraded by auth failures, got %+v", status)
+	}
+}
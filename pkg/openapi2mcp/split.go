@@ -0,0 +1,133 @@
+// split.go
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// UntaggedSplitPart is the tag used for operations without tags when splitting a spec.
+const UntaggedSplitPart = "untagged"
+
+// SpecPart is one of the smaller specs produced by SplitSpecByTag.
+type SpecPart struct {
+	Tag        string      // tag whose operations the part contains
+	Slug       string      // tag normalized for names and endpoint paths, e.g. "pet-store"
+	Doc        *openapi3.T // standalone spec with the part's operations and all shared components
+	Operations int         // number of operations in the part
+}
+
+// JSON returns the part as a JSON OpenAPI document.
+func (p SpecPart) JSON() ([]byte, error) {
+	return json.MarshalIndent(p.Doc, "", "  ")
+}
+
+// SplitSpecByTag splits a spec into one spec per tag, to keep each mounted endpoint under the
+// tool-count limits of LLM clients. An operation with several tags goes to its first tag, so
+// every operation appears in exactly one part. Servers, security, extensions and all components
+// are copied into every part, so each one is a complete document on its own.
+// Parts are returned sorted by tag.
+func SplitSpecByTag(doc *openapi3.T) ([]SpecPart, error) {
+	if doc == nil || doc.Paths == nil || doc.Paths.Len() == 0 {
+		return nil, fmt.Errorf("spec has no operations to split")
+	}
+
+	parts := map[string]*SpecPart{}
+	partFor := func(tag string) *SpecPart {
+		if p, ok := parts[tag]; ok {
+			return p
+		}
+		p := &SpecPart{Tag: tag, Slug: splitSlug(tag), Doc: splitPartDoc(doc, tag)}
+		parts[tag] = p
+		return p
+	}
+
+	for _, path := range doc.Paths.InMatchingOrder() {
+		item := doc.Paths.Value(path)
+		if item == nil {
+			continue
+		}
+		for method, op := range item.Operations() {
+			if op == nil {
+				continue
+			}
+			tag := UntaggedSplitPart
+			if len(op.Tags) > 0 && strings.TrimSpace(op.Tags[0]) != "" {
+				tag = op.Tags[0]
+			}
+			part := partFor(tag)
+			partItem := part.Doc.Paths.Value(path)
+			if partItem == nil {
+				partItem = &openapi3.PathItem{
+					Extensions:  item.Extensions,
+					Ref:         item.Ref,
+					Summary:     item.Summary,
+					Description: item.Description,
+					Servers:     item.Servers,
+					Parameters:  item.Parameters,
+				}
+				part.Doc.Paths.Set(path, partItem)
+			}
+			partItem.SetOperation(method, op)
+			part.Operations++
+		}
+	}
+
+	// Two tags may normalize to the same slug; keep slugs unique so endpoints don't collide
+	tags := make([]string, 0, len(parts))
+	for tag := range parts {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	result := make([]SpecPart, 0, len(tags))
+	usedSlugs := map[string]int{}
+	for _, tag := range tags {
+		part := parts[tag]
+		if n := usedSlugs[part.Slug]; n > 0 {
+			usedSlugs[part.Slug]++
+			part.Slug = fmt.Sprintf("%s-%d", part.Slug, n+1)
+		} else {
+			usedSlugs[part.Slug] = 1
+		}
+		result = append(result, *part)
+	}
+	return result, nil
+}
+
+// splitPartDoc returns an empty copy of doc for the operations of one tag.
+func splitPartDoc(doc *openapi3.T, tag string) *openapi3.T {
+	part := &openapi3.T{
+		Extensions:   doc.Extensions,
+		OpenAPI:      doc.OpenAPI,
+		Components:   doc.Components,
+		Security:     doc.Security,
+		Servers:      doc.Servers,
+		ExternalDocs: doc.ExternalDocs,
+		Paths:        openapi3.NewPaths(),
+	}
+	info := openapi3.Info{Title: tag, Version: "1.0.0"}
+	if doc.Info != nil {
+		info = *doc.Info
+		info.Title = fmt.Sprintf("%s (%s)", doc.Info.Title, tag)
+	}
+	part.Info = &info
+	for _, t := range doc.Tags {
+		if t != nil && t.Name == tag {
+			part.Tags = openapi3.Tags{t}
+		}
+	}
+	return part
+}
+
+// splitSlug normalizes a tag for use in spec names and endpoint paths.
+func splitSlug(tag string) string {
+	slug := strings.Trim(nonEndpointChars.ReplaceAllString(strings.ToLower(tag), "-"), "-.")
+	if slug == "" {
+		return UntaggedSplitPart
+	}
+	return slug
+}
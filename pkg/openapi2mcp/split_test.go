@@ -0,0 +1,108 @@
+package openapi2mcp
+
+import (
+	"testing"
+)
+
+const splitTestSpec = `
+openapi: 3.0.0
+info:
+  title: Store
+  version: "2.0"
+x-mcp-accept: application/json
+tags:
+  - name: Pet Store
+  - name: orders
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      tags: [Pet Store]
+      responses:
+        '200':
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Pet'
+    post:
+      operationId: createPet
+      tags: [Pet Store, orders]
+      responses:
+        '201':
+          description: created
+  /orders:
+    get:
+      operationId: listOrders
+      tags: [orders]
+      responses:
+        '200':
+          description: ok
+  /health:
+    get:
+      operationId: health
+      responses:
+        '200':
+          description: ok
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name:
+          type: string
+`
+
+func TestSplitSpecByTag(t *testing.T) {
+	doc, err := LoadOpenAPISpecFromString(splitTestSpec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	parts, err := SplitSpecByTag(doc)
+	if err != nil {
+		t.Fatalf("SplitSpecByTag: %v", err)
+	}
+
+	want := map[string][]string{
+		"pet-store": {"createPet", "listPets"},
+		"orders":    {"listOrders"},
+		"untagged":  {"health"},
+	}
+	if len(parts) != len(want) {
+		t.Fatalf("expected %d parts, got %d", len(want), len(parts))
+	}
+	for _, part := range parts {
+		ops := ExtractOpenAPIOperations(part.Doc)
+		var ids []string
+		for _, op := range ops {
+			ids = append(ids, op.OperationID)
+		}
+		if len(ids) != len(want[part.Slug]) || part.Operations != len(ids) {
+			t.Errorf("part %s: expected operations %v, got %v", part.Slug, want[part.Slug], ids)
+		}
+
+		// Every part must load on its own, with shared components and spec extensions
+		data, err := part.JSON()
+		if err != nil {
+			t.Fatalf("part %s: JSON: %v", part.Slug, err)
+		}
+		reloaded, err := LoadOpenAPISpecFromBytes(data)
+		if err != nil {
+			t.Fatalf("part %s does not load on its own: %v", part.Slug, err)
+		}
+		if reloaded.Components == nil || reloaded.Components.Schemas["Pet"] == nil {
+			t.Errorf("part %s: expected shared components to be copied", part.Slug)
+		}
+		if reloaded.Extensions[acceptExtension] != "application/json" {
+			t.Errorf("part %s: expected spec extensions to be copied", part.Slug)
+		}
+	}
+	if parts[0].Doc.Info.Title != "Store (Pet Store)" {
+		t.Errorf("unexpected part title %q", parts[0].Doc.Info.Title)
+	}
+	if doc.Paths.Value("/pets").Get == nil {
+		t.Errorf("splitting must not modify the original spec")
+	}
+}
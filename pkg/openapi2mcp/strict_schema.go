@@ -0,0 +1,115 @@
+package openapi2mcp
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// strictSchemaExtension is the root OpenAPI extension that turns on strict input schemas for a spec.
+const strictSchemaExtension = "x-mcp-strict-schema"
+
+// strictControlArgs are arguments the tool handler understands itself. Strict schemas let
+// them through, unless the operation has a parameter of the same name.
+var strictControlArgs = []string{"__confirmed", "resume_token", "stream"}
+
+// specStrictSchema reports whether a spec's tools reject unknown arguments: opts.StrictSchema,
+// then the x-mcp-strict-schema extension, then MCP_STRICT_SCHEMA. Off by default.
+func specStrictSchema(doc *openapi3.T, opts *ToolGenOptions) bool {
+	if opts != nil && opts.StrictSchema {
+		return true
+	}
+	if doc != nil {
+		if v, ok := doc.Extensions[strictSchemaExtension].(bool); ok {
+			return v
+		}
+	}
+	enabled, _ := strconv.ParseBool(os.Getenv("MCP_STRICT_SCHEMA"))
+	return enabled
+}
+
+// applyStrictSchema sets additionalProperties:false on the input schema and on every nested
+// object schema that declares its properties. Free-form objects stay open: objects without
+// declared properties, objects whose spec declares additionalProperties, and anyOf branches.
+// It returns the control arguments to exempt from validation.
+func applyStrictSchema(schema map[string]any) []string {
+	strictenObject(schema)
+	props, _ := schema["properties"].(map[string]any)
+	var exempt []string
+	for _, arg := range strictControlArgs {
+		if _, ok := props[arg]; !ok {
+			exempt = append(exempt, arg)
+		}
+	}
+	return exempt
+}
+
+func strictenObject(schema map[string]any) {
+	if props, ok := schema["properties"].(map[string]any); ok {
+		if _, declared := schema["additionalProperties"]; !declared {
+			schema["additionalProperties"] = false
+		}
+		for _, p := range props {
+			if sub, ok := p.(map[string]any); ok {
+				strictenObject(sub)
+			}
+		}
+	}
+	if items, ok := schema["items"].(map[string]any); ok {
+		strictenObject(items)
+	}
+}
+
+// withoutArgs returns args without the given names, copying only when one is present.
+func withoutArgs(args map[string]any, names []string) map[string]any {
+	out := args
+	for _, name := range names {
+		if _, ok := args[name]; !ok {
+			continue
+		}
+		if len(out) == len(args) {
+			out = make(map[string]any, len(args))
+			for k, v := range args {
+				out[k] = v
+			}
+		}
+		delete(out, name)
+	}
+	return out
+}
+
+// unknownArgMessage explains an argument rejected by a strict schema, listing the valid
+// names of the object it was passed in. field is the object's path as reported by the
+// validator: "(root)" for the tool arguments, or e.g. "requestBody.owner".
+func unknownArgMessage(schema map[string]any, field, arg string) string {
+	obj := schema
+	where := ""
+	if field != "" && field != "(root)" {
+		where = " in '" + field + "'"
+		for _, part := range strings.Split(field, ".") {
+			if obj == nil {
+				break
+			}
+			if _, err := strconv.Atoi(part); err == nil {
+				obj, _ = obj["items"].(map[string]any)
+				continue
+			}
+			props, _ := obj["properties"].(map[string]any)
+			obj, _ = props[part].(map[string]any)
+		}
+	}
+	msg := "Unknown argument '" + arg + "'" + where + "."
+	props, _ := obj["properties"].(map[string]any)
+	if len(props) == 0 {
+		return msg
+	}
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return msg + " Valid arguments: " + strings.Join(names, ", ")
+}
@@ -0,0 +1,97 @@
+package openapi2mcp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+const strictSchemaSpec = `
+openapi: 3.0.0
+info:
+  title: Strict API
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: ok
+    post:
+      operationId: createPet
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                name:
+                  type: string
+                labels:
+                  type: object
+                  additionalProperties:
+                    type: string
+                extra:
+                  type: object
+      responses:
+        '200':
+          description: ok
+`
+
+func TestStrictSchemaRejectsUnknownArgs(t *testing.T) {
+	server := newTestServer(t, strictSchemaSpec, &ToolGenOptions{StrictSchema: true}, jsonUpstream(`{}`))
+
+	for _, tool := range server.ListTools() {
+		if tool.Name == "listPets" && !strings.Contains(string(tool.RawInputSchema), `"additionalProperties":false`) {
+			t.Errorf("expected additionalProperties:false in strict schema, got %s", tool.RawInputSchema)
+		}
+	}
+
+	res := callToolForTest(t, server, "listPets", map[string]any{"limt": 5})
+	text := res.Content[0].(mcp.TextContent).Text
+	if !res.IsError || !strings.Contains(text, "Unknown argument 'limt'. Valid arguments: limit") {
+		t.Errorf("expected an unknown argument error listing valid names, got %q", text)
+	}
+
+	res = callToolForTest(t, server, "createPet", map[string]any{
+		"__confirmed": true,
+		"requestBody": map[string]any{"name": "Rex", "color": "brown"},
+	})
+	text = res.Content[0].(mcp.TextContent).Text
+	if !res.IsError || !strings.Contains(text, "Unknown argument 'color' in 'requestBody'. Valid arguments: extra, labels, name") {
+		t.Errorf("expected a nested unknown argument error, got %q", text)
+	}
+
+	// Control arguments and free-form objects are let through
+	res = callToolForTest(t, server, "createPet", map[string]any{
+		"__confirmed": true,
+		"requestBody": map[string]any{
+			"name":   "Rex",
+			"labels": map[string]any{"team": "blue"},
+			"extra":  map[string]any{"anything": 1},
+		},
+	})
+	if res.IsError {
+		t.Errorf("expected free-form objects to be accepted, got %q", res.Content[0].(mcp.TextContent).Text)
+	}
+}
+
+func TestStrictSchemaOffByDefault(t *testing.T) {
+	server := newTestServer(t, strictSchemaSpec, nil, jsonUpstream(`{}`))
+	res := callToolForTest(t, server, "listPets", map[string]any{"limt": 5})
+	if res.IsError {
+		t.Errorf("expected unknown arguments to be ignored without strict mode, got %q", res.Content[0].(mcp.TextContent).Text)
+	}
+	t.Setenv("MCP_STRICT_SCHEMA", "true")
+	if !specStrictSchema(nil, nil) {
+		t.Errorf("expected MCP_STRICT_SCHEMA to enable strict schemas")
+	}
+}
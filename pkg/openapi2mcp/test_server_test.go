@@ -0,0 +1,44 @@
+package openapi2mcp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+// serveUpstream starts an upstream API served by handler and points the tool calls of the
+// test at it through OPENAPI_BASE_URL.
+func serveUpstream(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	upstream := httptest.NewServer(handler)
+	t.Cleanup(upstream.Close)
+	t.Setenv("OPENAPI_BASE_URL", upstream.URL)
+	return upstream
+}
+
+// newTestServer registers the tools of spec with opts on a new MCP server whose calls go to
+// an upstream served by upstream. A nil upstream leaves the base URL as it is, for tests
+// that only look at the registered tools.
+func newTestServer(t *testing.T, spec string, opts *ToolGenOptions, upstream http.HandlerFunc) *mcpserver.MCPServer {
+	t.Helper()
+	if upstream != nil {
+		serveUpstream(t, upstream)
+	}
+	doc, err := LoadOpenAPISpecFromString(spec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	server := mcpserver.NewMCPServer("test", "0.0.1")
+	RegisterOpenAPITools(server, ExtractOpenAPIOperations(doc), doc, opts, nil)
+	return server
+}
+
+// jsonUpstream answers every request with body as JSON
+func jsonUpstream(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}
+}
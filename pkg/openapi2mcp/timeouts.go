@@ -0,0 +1,157 @@
+package openapi2mcp
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// upstreamTimeoutsExtension is the root-level spec extension setting the phase timeouts of
+// upstream calls, e.g. {connect: 5s, tls: 5s, response-header: 2m, idle: 90s}.
+const upstreamTimeoutsExtension = "x-mcp-upstream-timeouts"
+
+// ServerTimeouts are the phase timeouts of the inbound HTTP server. Zero means no limit.
+type ServerTimeouts struct {
+	ReadHeader time.Duration // reading the request headers, which stops slow-loris clients
+	Read       time.Duration // reading the whole request, including large spec uploads
+	Write      time.Duration // writing the response; SSE streams are not limited
+	Idle       time.Duration // keeping an idle keep-alive connection open
+}
+
+// UpstreamTimeouts are the phase timeouts of upstream calls. Zero fields in ToolGenOptions
+// fall back to the spec, the environment and the defaults.
+type UpstreamTimeouts struct {
+	Connect        time.Duration // establishing the TCP connection
+	TLSHandshake   time.Duration // the TLS handshake
+	ResponseHeader time.Duration // waiting for the response headers once the request is sent
+	Idle           time.Duration // keeping an idle pooled connection open
+}
+
+var (
+	// DefaultServerTimeouts apply when the MCP_SERVER_*_TIMEOUT variables are not set.
+	DefaultServerTimeouts = ServerTimeouts{
+		ReadHeader: 10 * time.Second,
+		Read:       240 * time.Second,
+		Write:      240 * time.Second,
+		Idle:       120 * time.Second,
+	}
+	// DefaultUpstreamTimeouts apply when neither opts, the spec nor the
+	// MCP_UPSTREAM_*_TIMEOUT variables set a phase.
+	DefaultUpstreamTimeouts = UpstreamTimeouts{
+		Connect:        30 * time.Second,
+		TLSHandshake:   10 * time.Second,
+		ResponseHeader: 120 * time.Second,
+		Idle:           90 * time.Second,
+	}
+)
+
+// ServerTimeoutsFromEnv returns DefaultServerTimeouts overridden by MCP_SERVER_READ_HEADER_TIMEOUT,
+// MCP_SERVER_READ_TIMEOUT, MCP_SERVER_WRITE_TIMEOUT and MCP_SERVER_IDLE_TIMEOUT ("0" for no limit).
+func ServerTimeoutsFromEnv() ServerTimeouts {
+	t := DefaultServerTimeouts
+	envTimeout("MCP_SERVER_READ_HEADER_TIMEOUT", &t.ReadHeader)
+	envTimeout("MCP_SERVER_READ_TIMEOUT", &t.Read)
+	envTimeout("MCP_SERVER_WRITE_TIMEOUT", &t.Write)
+	envTimeout("MCP_SERVER_IDLE_TIMEOUT", &t.Idle)
+	return t
+}
+
+// NewHTTPServer returns an HTTP server for addr and handler with the phase timeouts of
+// ServerTimeoutsFromEnv.
+func NewHTTPServer(addr string, handler http.Handler) *http.Server {
+	t := ServerTimeoutsFromEnv()
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: t.ReadHeader,
+		ReadTimeout:       t.Read,
+		WriteTimeout:      t.Write,
+		IdleTimeout:       t.Idle,
+	}
+}
+
+// specUpstreamTimeouts returns the phase timeouts of a spec's upstream calls, phase by phase:
+// opts, then the x-mcp-upstream-timeouts extension, then MCP_UPSTREAM_CONNECT_TIMEOUT,
+// MCP_UPSTREAM_TLS_TIMEOUT, MCP_UPSTREAM_RESPONSE_HEADER_TIMEOUT and MCP_UPSTREAM_IDLE_TIMEOUT,
+// then DefaultUpstreamTimeouts.
+func specUpstreamTimeouts(doc *openapi3.T, opts *ToolGenOptions) UpstreamTimeouts {
+	t := DefaultUpstreamTimeouts
+	envTimeout("MCP_UPSTREAM_CONNECT_TIMEOUT", &t.Connect)
+	envTimeout("MCP_UPSTREAM_TLS_TIMEOUT", &t.TLSHandshake)
+	envTimeout("MCP_UPSTREAM_RESPONSE_HEADER_TIMEOUT", &t.ResponseHeader)
+	envTimeout("MCP_UPSTREAM_IDLE_TIMEOUT", &t.Idle)
+	if doc != nil && doc.Extensions[upstreamTimeoutsExtension] != nil {
+		phases, ok := doc.Extensions[upstreamTimeoutsExtension].(map[string]any)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "[WARN] Ignoring %s: expected an object of durations\n", upstreamTimeoutsExtension)
+		}
+		for phase, v := range phases {
+			var target *time.Duration
+			switch strings.ToLower(phase) {
+			case "connect":
+				target = &t.Connect
+			case "tls", "tls-handshake":
+				target = &t.TLSHandshake
+			case "response-header":
+				target = &t.ResponseHeader
+			case "idle":
+				target = &t.Idle
+			default:
+				fmt.Fprintf(os.Stderr, "[WARN] Ignoring unknown phase %q in %s\n", phase, upstreamTimeoutsExtension)
+				continue
+			}
+			s, _ := v.(string)
+			if d, err := time.ParseDuration(strings.TrimSpace(s)); err == nil && d >= 0 {
+				*target = d
+			} else {
+				fmt.Fprintf(os.Stderr, "[WARN] Ignoring invalid %s %s %v\n", upstreamTimeoutsExtension, phase, v)
+			}
+		}
+	}
+	if opts != nil && opts.UpstreamTimeouts != nil {
+		o := opts.UpstreamTimeouts
+		for _, p := range []struct{ from, to *time.Duration }{
+			{&o.Connect, &t.Connect}, {&o.TLSHandshake, &t.TLSHandshake},
+			{&o.ResponseHeader, &t.ResponseHeader}, {&o.Idle, &t.Idle},
+		} {
+			if *p.from > 0 {
+				*p.to = *p.from
+			}
+		}
+	}
+	return t
+}
+
+// envTimeout sets *d from the duration in the name environment variable, when it is valid.
+func envTimeout(name string, d *time.Duration) {
+	v := strings.TrimSpace(os.Getenv(name))
+	if v == "" {
+		return
+	}
+	parsed, err := time.ParseDuration(v)
+	if err != nil || parsed < 0 {
+		fmt.Fprintf(os.Stderr, "[WARN] Ignoring invalid %s=%q\n", name, v)
+		return
+	}
+	*d = parsed
+}
+
+// upstreamBaseTransport returns a copy of the default transport with the phase timeouts t
+// whose TLS connections present serverName, when not empty.
+func upstreamBaseTransport(t UpstreamTimeouts, serverName string) *http.Transport {
+	transport, ok := sniTransport(serverName).(*http.Transport)
+	if !ok {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	dialer := &net.Dialer{Timeout: t.Connect, KeepAlive: 30 * time.Second}
+	transport.DialContext = dialer.DialContext
+	transport.TLSHandshakeTimeout = t.TLSHandshake
+	transport.ResponseHeaderTimeout = t.ResponseHeader
+	transport.IdleConnTimeout = t.Idle
+	return transport
+}
@@ -0,0 +1,65 @@
+package openapi2mcp
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+func TestSpecUpstreamTimeouts(t *testing.T) {
+	t.Setenv("MCP_UPSTREAM_CONNECT_TIMEOUT", "3s")
+	t.Setenv("MCP_UPSTREAM_IDLE_TIMEOUT", "0")
+	spec := strings.Replace(mockUpstreamSpec, "openapi: 3.0.0\n", "openapi: 3.0.0\nx-mcp-upstream-timeouts: {connect: 5s, response-header: 1m}\n", 1)
+	doc, err := LoadOpenAPISpecFromString(spec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+
+	got := specUpstreamTimeouts(doc, &ToolGenOptions{UpstreamTimeouts: &UpstreamTimeouts{ResponseHeader: 30 * time.Second}})
+	want := UpstreamTimeouts{
+		Connect:        5 * time.Second, // the extension over the environment
+		TLSHandshake:   DefaultUpstreamTimeouts.TLSHandshake,
+		ResponseHeader: 30 * time.Second, // the options over the extension
+		Idle:           0,                // turned off by the environment
+	}
+	if got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	t.Setenv("MCP_SERVER_READ_HEADER_TIMEOUT", "2s")
+	srv := NewHTTPServer(":0", nil)
+	if srv.ReadHeaderTimeout != 2*time.Second || srv.WriteTimeout != DefaultServerTimeouts.Write || srv.IdleTimeout != DefaultServerTimeouts.Idle {
+		t.Errorf("unexpected server timeouts: header %s, write %s, idle %s", srv.ReadHeaderTimeout, srv.WriteTimeout, srv.IdleTimeout)
+	}
+}
+
+func TestUpstreamResponseHeaderTimeout(t *testing.T) {
+	release := make(chan struct{})
+	opts := &ToolGenOptions{UpstreamTimeouts: &UpstreamTimeouts{ResponseHeader: 50 * time.Millisecond}}
+	server := newTestServer(t, mockUpstreamSpec, opts, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(`[]`))
+	})
+	defer close(release)
+
+	start := time.Now()
+	resp := server.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"listPets","arguments":{}}}`))
+	failed := false
+	switch r := resp.(type) {
+	case mcp.JSONRPCError:
+		failed = true
+	case mcp.JSONRPCResponse:
+		result, _ := r.Result.(mcp.CallToolResult)
+		failed = result.IsError
+	}
+	if !failed {
+		t.Errorf("expected the call to fail when the upstream sends no headers in time, got %+v", resp)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the response-header timeout to end the call, took %s", elapsed)
+	}
+}
@@ -0,0 +1,17 @@
+// tool_errors.go
+package openapi2mcp
+
+import (
+	"github.com/ubermorgenland/openapi-mcp/pkg/apierrors"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/mcp"
+)
+
+// withErrorMeta classifies a tool error result in the shared apierrors taxonomy,
+// so clients can branch on _meta.error.type the same way they do on HTTP and JSON-RPC errors.
+func withErrorMeta(res *mcp.CallToolResult, errType apierrors.Type, details string) *mcp.CallToolResult {
+	if res.Meta == nil {
+		res.Meta = map[string]any{}
+	}
+	res.Meta["error"] = apierrors.Details{Type: errType, Details: details}
+	return res
+}
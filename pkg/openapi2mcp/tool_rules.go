@@ -0,0 +1,201 @@
+// tool_rules.go
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+)
+
+// toolRulesExtension is the root-level spec extension selecting the operations that become
+// tools, for specs loaded from files. Database specs use the tool_rules column.
+const toolRulesExtension = "x-mcp-tool-rules"
+
+// ToolRule matches operations by tag, operationId or method and path pattern, and HTTP
+// method. Every criterion that is set must match; an empty rule matches nothing.
+type ToolRule struct {
+	Tags       []string `json:"tags,omitempty"`       // operation tags, ignoring case; one must match
+	Operations []string `json:"operations,omitempty"` // operationId or "METHOD /path" patterns, as for x-mcp-exclude-operations
+	Methods    []string `json:"methods,omitempty"`    // HTTP methods, e.g. GET
+}
+
+// ToolRules select the operations of a spec that become tools. With include rules, only
+// operations matching one of them are exposed; operations matching an exclude rule never
+// are. For example, the read-only pet operations without the admin ones:
+//
+//	{"include": [{"tags": ["pets"], "methods": ["GET"]}], "exclude": [{"operations": ["* /admin/*"]}]}
+type ToolRules struct {
+	Include []ToolRule `json:"include,omitempty"`
+	Exclude []ToolRule `json:"exclude,omitempty"`
+}
+
+// ParseToolRules parses and validates the JSON stored in the tool_rules column. Empty or
+// null means no rules.
+func ParseToolRules(data string) (*ToolRules, error) {
+	if strings.TrimSpace(data) == "" || strings.TrimSpace(data) == "null" {
+		return nil, nil
+	}
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.DisallowUnknownFields()
+	var rules ToolRules
+	if err := dec.Decode(&rules); err != nil {
+		return nil, fmt.Errorf("tool rules must be a JSON object with include and exclude lists of {tags, operations, methods} rules: %v", err)
+	}
+	if _, err := rules.compile(); err != nil {
+		return nil, err
+	}
+	return &rules, nil
+}
+
+// compiledToolRule is a ToolRule with its operation patterns compiled.
+type compiledToolRule struct {
+	tags       []string
+	operations []*regexp.Regexp
+	methods    []string
+}
+
+// toolRuleSet is the compiled form of ToolRules.
+type toolRuleSet struct {
+	include []compiledToolRule
+	exclude []compiledToolRule
+}
+
+func (r *ToolRules) compile() (*toolRuleSet, error) {
+	if r == nil || (len(r.Include) == 0 && len(r.Exclude) == 0) {
+		return nil, nil
+	}
+	compileList := func(kind string, rules []ToolRule) ([]compiledToolRule, error) {
+		compiled := make([]compiledToolRule, 0, len(rules))
+		for i, rule := range rules {
+			if len(rule.Tags) == 0 && len(rule.Operations) == 0 && len(rule.Methods) == 0 {
+				return nil, fmt.Errorf("%s rule %d: set at least one of tags, operations and methods", kind, i+1)
+			}
+			patterns, err := compileOperationPatterns(rule.Operations)
+			if err != nil {
+				return nil, fmt.Errorf("%s rule %d: %v", kind, i+1, err)
+			}
+			methods := make([]string, 0, len(rule.Methods))
+			for _, m := range rule.Methods {
+				m = strings.ToUpper(strings.TrimSpace(m))
+				if !knownHTTPMethod(m) {
+					return nil, fmt.Errorf("%s rule %d: unknown HTTP method %q", kind, i+1, m)
+				}
+				methods = append(methods, m)
+			}
+			compiled = append(compiled, compiledToolRule{tags: rule.Tags, operations: patterns, methods: methods})
+		}
+		return compiled, nil
+	}
+	include, err := compileList("include", r.Include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compileList("exclude", r.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	return &toolRuleSet{include: include, exclude: exclude}, nil
+}
+
+// knownHTTPMethod reports whether m is a method OpenAPI operations can have.
+func knownHTTPMethod(m string) bool {
+	switch m {
+	case "GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH", "TRACE":
+		return true
+	}
+	return false
+}
+
+func (r compiledToolRule) matches(op OpenAPIOperation) bool {
+	if len(r.tags) > 0 && !hasAnyTag(op.Tags, r.tags) {
+		return false
+	}
+	if len(r.operations) > 0 && !operationMatches(r.operations, op.OperationID, strings.ToUpper(op.Method)+" "+op.Path) {
+		return false
+	}
+	if len(r.methods) > 0 {
+		found := false
+		for _, m := range r.methods {
+			if strings.EqualFold(m, op.Method) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func hasAnyTag(tags, want []string) bool {
+	for _, tag := range tags {
+		for _, w := range want {
+			if strings.EqualFold(strings.TrimSpace(w), tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// exposes reports whether op becomes a tool under the rules. A nil set exposes everything.
+func (s *toolRuleSet) exposes(op OpenAPIOperation) bool {
+	if s == nil {
+		return true
+	}
+	for _, rule := range s.exclude {
+		if rule.matches(op) {
+			return false
+		}
+	}
+	if len(s.include) == 0 {
+		return true
+	}
+	for _, rule := range s.include {
+		if rule.matches(op) {
+			return true
+		}
+	}
+	return false
+}
+
+// specToolRules returns the compiled tool rules of a spec: opts, then the database row's
+// tool_rules, then the x-mcp-tool-rules extension. Invalid rules are logged and ignored.
+func specToolRules(doc *openapi3.T, opts *ToolGenOptions, dbSpec *models.OpenAPISpec) *toolRuleSet {
+	var rules *ToolRules
+	var source string
+	switch {
+	case opts != nil && opts.ToolRules != nil:
+		rules, source = opts.ToolRules, "ToolGenOptions.ToolRules"
+	case dbSpec != nil && dbSpec.ToolRules != nil:
+		parsed, err := ParseToolRules(*dbSpec.ToolRules)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] Ignoring tool rules of spec %s: %v\n", dbSpec.Name, err)
+			return nil
+		}
+		rules, source = parsed, "tool_rules of spec "+dbSpec.Name
+	case doc != nil && doc.Extensions[toolRulesExtension] != nil:
+		// Round-trip through JSON to validate the extension like the column
+		data, err := json.Marshal(doc.Extensions[toolRulesExtension])
+		if err == nil {
+			rules, err = ParseToolRules(string(data))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] Ignoring %s: %v\n", toolRulesExtension, err)
+			return nil
+		}
+		source = toolRulesExtension
+	}
+	set, err := rules.compile()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] Ignoring %s: %v\n", source, err)
+		return nil
+	}
+	return set
+}
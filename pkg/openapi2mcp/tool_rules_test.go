@@ -0,0 +1,87 @@
+package openapi2mcp
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+)
+
+const toolRulesSpec = `
+openapi: 3.0.0
+info: {title: Shop, version: "1.0"}
+paths:
+  /pets:
+    get: {operationId: listPets, tags: [pets], responses: {'200': {description: ok}}}
+    post: {operationId: createPet, tags: [pets], responses: {'200': {description: ok}}}
+  /pets/{id}:
+    delete:
+      operationId: deletePet
+      tags: [pets]
+      parameters: [{name: id, in: path, required: true, schema: {type: string}}]
+      responses: {'200': {description: ok}}
+  /orders:
+    get: {operationId: listOrders, tags: [store], responses: {'200': {description: ok}}}
+  /admin/users:
+    get: {operationId: adminListUsers, tags: [pets], responses: {'200': {description: ok}}}
+`
+
+func toolRulesToolNames(t *testing.T, spec string, opts *ToolGenOptions, dbSpec *models.OpenAPISpec) string {
+	t.Helper()
+	doc, err := LoadOpenAPISpecFromString(spec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	server := mcpserver.NewMCPServer("test", "0.0.1")
+	RegisterOpenAPITools(server, ExtractOpenAPIOperations(doc), doc, opts, dbSpec)
+	var names []string
+	for _, tool := range server.ListTools() {
+		if tool.Name != "info" && tool.Name != "describe" {
+			names = append(names, tool.Name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func TestToolRules(t *testing.T) {
+	rules := &ToolRules{
+		Include: []ToolRule{{Tags: []string{"PETS"}}, {Operations: []string{"listOrders"}}},
+		Exclude: []ToolRule{{Methods: []string{"delete"}}, {Operations: []string{"GET /admin/*"}}},
+	}
+	if got := toolRulesToolNames(t, toolRulesSpec, &ToolGenOptions{ToolRules: rules}, nil); got != "createPet,listOrders,listPets" {
+		t.Errorf("expected the included operations without the excluded ones, got %s", got)
+	}
+
+	// Every criterion of a rule must match
+	dbRules := `{"include": [{"tags": ["pets"], "methods": ["GET"]}]}`
+	if got := toolRulesToolNames(t, toolRulesSpec, nil, &models.OpenAPISpec{Name: "shop", ToolRules: &dbRules}); got != "adminListUsers,listPets" {
+		t.Errorf("expected the GET pet operations from the tool_rules column, got %s", got)
+	}
+
+	spec := strings.Replace(toolRulesSpec, "openapi: 3.0.0\n", "openapi: 3.0.0\nx-mcp-tool-rules: {exclude: [{tags: [pets]}]}\n", 1)
+	if got := toolRulesToolNames(t, spec, nil, nil); got != "listOrders" {
+		t.Errorf("expected the extension to exclude the pet operations, got %s", got)
+	}
+}
+
+func TestParseToolRules(t *testing.T) {
+	for _, data := range []string{"", "null"} {
+		if rules, err := ParseToolRules(data); err != nil || rules != nil {
+			t.Errorf("expected no rules for %q, got %+v, %v", data, rules, err)
+		}
+	}
+	for _, data := range []string{
+		`[]`,
+		`{"include": [{}]}`,
+		`{"exclude": [{"methods": ["FETCH"]}]}`,
+		`{"include": [{"operations": ["re:("]}]}`,
+		`{"include": [{"tag": ["pets"]}]}`,
+	} {
+		if _, err := ParseToolRules(data); err == nil {
+			t.Errorf("expected %s to be rejected", data)
+		}
+	}
+}
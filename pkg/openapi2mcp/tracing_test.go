@@ -0,0 +1,110 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/tracing"
+)
+
+func TestTracingAcrossToolCall(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	var mu sync.Mutex
+	var upstreamTraceparent string
+	var spans []map[string]any
+
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []map[string]any `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("invalid OTLP request: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}))
+	defer collector.Close()
+	tracer := tracing.New(tracing.Config{Exporter: tracing.ExporterOTLP, Endpoint: collector.URL, SampleRatio: 1, ParentBased: true})
+	tracing.SetDefault(tracer)
+	defer tracing.SetDefault(nil)
+
+	server := newTestServer(t, mockUpstreamSpec, &ToolGenOptions{}, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		upstreamTraceparent = r.Header.Get("traceparent")
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 1, "name": "Rex"}`))
+	})
+	ts := httptest.NewServer(HandlerForStreamableHTTP(server, "/mcp"))
+	defer ts.Close()
+
+	post := func(sessionID, body string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/mcp", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+		if sessionID != "" {
+			req.Header.Set("Mcp-Session-Id", sessionID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	resp := post("", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+	post(sessionID, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"getPet","arguments":{"id":1}}}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	tracer.Shutdown(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !strings.HasPrefix(upstreamTraceparent, "00-"+traceID+"-") {
+		t.Errorf("expected the upstream call to continue the client's trace, got traceparent %q", upstreamTraceparent)
+	}
+	byName := map[string]map[string]any{}
+	for _, span := range spans {
+		if span["traceId"] != traceID {
+			t.Errorf("expected every span in the client's trace, got %v", span)
+		}
+		byName[span["name"].(string)] = span
+	}
+	call, upstreamSpan := byName["tools/call getPet"], byName["GET"]
+	if call == nil || upstreamSpan == nil {
+		t.Fatalf("expected tool call and upstream spans, got %v", spans)
+	}
+	if upstreamSpan["parentSpanId"] != call["spanId"] {
+		t.Errorf("expected the upstream span to be a child of the tool call span")
+	}
+	if !strings.HasSuffix(upstreamTraceparent, "-"+upstreamSpan["spanId"].(string)+"-01") {
+		t.Errorf("expected the upstream span in the traceparent header, got %q", upstreamTraceparent)
+	}
+	var httpSpan map[string]any
+	for _, span := range spans {
+		if span["spanId"] == call["parentSpanId"] {
+			httpSpan = span
+		}
+	}
+	if httpSpan == nil || httpSpan["name"] != "POST /mcp" || httpSpan["parentSpanId"] != "00f067aa0ba902b7" {
+		t.Errorf("expected the tool call span under the HTTP server span of the client's request, got %v", httpSpan)
+	}
+}
@@ -0,0 +1,51 @@
+// upstream_host.go
+package openapi2mcp
+
+import (
+	"crypto/tls"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const (
+	// upstreamHostExtension is the root-level spec extension that sets the Host header of
+	// upstream requests, for upstreams behind shared load balancers that route on it.
+	upstreamHostExtension = "x-mcp-upstream-host"
+	// upstreamSNIExtension is the root-level spec extension that sets the TLS server name
+	// upstream connections present and verify the certificate against.
+	upstreamSNIExtension = "x-mcp-upstream-sni"
+)
+
+// specUpstreamHost returns the Host header and TLS server name overrides of a spec: opts,
+// then the x-mcp-upstream-host and x-mcp-upstream-sni extensions. Empty means the host of
+// the request URL, as usual.
+func specUpstreamHost(doc *openapi3.T, opts *ToolGenOptions) (host, sni string) {
+	if opts != nil {
+		host, sni = opts.UpstreamHost, opts.UpstreamSNI
+	}
+	if doc != nil {
+		if v, ok := doc.Extensions[upstreamHostExtension].(string); ok && host == "" {
+			host = strings.TrimSpace(v)
+		}
+		if v, ok := doc.Extensions[upstreamSNIExtension].(string); ok && sni == "" {
+			sni = strings.TrimSpace(v)
+		}
+	}
+	return host, sni
+}
+
+// sniTransport returns a copy of the default transport whose TLS connections present
+// serverName, or nil when there is no override.
+func sniTransport(serverName string) http.RoundTripper {
+	if serverName == "" {
+		return nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	transport.TLSClientConfig.ServerName = serverName
+	return transport
+}
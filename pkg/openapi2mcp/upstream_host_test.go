@@ -0,0 +1,47 @@
+package openapi2mcp
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestUpstreamHostOverride(t *testing.T) {
+	var gotHost string
+	upstream := func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.Host
+		w.Write([]byte(`[]`))
+	}
+
+	spec := strings.Replace(mockUpstreamSpec, "openapi: 3.0.0\n", "openapi: 3.0.0\nx-mcp-upstream-host: pets.internal.example.com\n", 1)
+	server := newTestServer(t, spec, nil, upstream)
+	callToolForTest(t, server, "listPets", map[string]any{})
+	if gotHost != "pets.internal.example.com" {
+		t.Errorf("expected the Host header override, got %q", gotHost)
+	}
+
+	// Options take precedence over the extension
+	server = newTestServer(t, spec, &ToolGenOptions{UpstreamHost: "api.example.com"}, upstream)
+	callToolForTest(t, server, "listPets", map[string]any{})
+	if gotHost != "api.example.com" {
+		t.Errorf("expected the Host header from the options, got %q", gotHost)
+	}
+}
+
+func TestSpecUpstreamSNI(t *testing.T) {
+	doc, _ := LoadOpenAPISpecFromString(strings.Replace(mockUpstreamSpec, "openapi: 3.0.0\n", "openapi: 3.0.0\nx-mcp-upstream-sni: edge.example.com\n", 1))
+	host, sni := specUpstreamHost(doc, nil)
+	if host != "" || sni != "edge.example.com" {
+		t.Fatalf("expected only the SNI override, got host %q and SNI %q", host, sni)
+	}
+	transport, ok := sniTransport(sni).(*http.Transport)
+	if !ok || transport.TLSClientConfig.ServerName != "edge.example.com" {
+		t.Errorf("expected a transport presenting edge.example.com, got %+v", transport)
+	}
+	if sniTransport("") != nil {
+		t.Error("expected the default transport without an override")
+	}
+	if http.DefaultTransport.(*http.Transport).TLSClientConfig != nil && http.DefaultTransport.(*http.Transport).TLSClientConfig.ServerName != "" {
+		t.Error("expected the default transport to be left alone")
+	}
+}
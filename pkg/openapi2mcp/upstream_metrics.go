@@ -0,0 +1,256 @@
+// upstream_metrics.go
+package openapi2mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/metrics"
+	"github.com/ubermorgenland/openapi-mcp/pkg/tracing"
+)
+
+const (
+	// slowCallExtension is the root-level spec extension that sets the slow upstream call threshold.
+	slowCallExtension = "x-mcp-slow-call-threshold"
+	// DefaultSlowCallThreshold applies when neither opts, the spec nor MCP_SLOW_CALL_THRESHOLD set one.
+	DefaultSlowCallThreshold = 5 * time.Second
+)
+
+// UpstreamLatencyBuckets are the upper bounds of the upstream call duration histogram.
+var UpstreamLatencyBuckets = []time.Duration{
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+}
+
+// HistogramBucket is one cumulative bucket of a duration histogram.
+type HistogramBucket struct {
+	LeMs  int64 `json:"le_ms"` // upper bound in milliseconds; -1 for +Inf
+	Count int64 `json:"count"`
+}
+
+// UpstreamCallStats are the upstream HTTP client metrics of one spec and tool.
+type UpstreamCallStats struct {
+	Endpoint      string            `json:"endpoint"`
+	Tool          string            `json:"tool"`
+	Calls         int64             `json:"calls"`
+	Errors        int64             `json:"errors"` // transport errors and 5xx responses
+	SlowCalls     int64             `json:"slow_calls"`
+	SlowThreshold string            `json:"slow_threshold"`
+	SumMs         int64             `json:"sum_ms"`
+	Buckets       []HistogramBucket `json:"buckets"`
+	StatusCodes   map[string]int64  `json:"status_codes"` // "0" counts transport errors
+}
+
+type upstreamCall struct {
+	calls         int64
+	errors        int64
+	slow          int64
+	slowThreshold time.Duration
+	sum           time.Duration
+	buckets       []int64 // per bucket, not cumulative; the last one is +Inf
+	statusCodes   map[int]int64
+}
+
+// UpstreamMetrics counts upstream HTTP calls per spec endpoint and tool, with a duration
+// histogram and the number of calls over the spec's slow call threshold.
+type UpstreamMetrics struct {
+	mu    sync.Mutex
+	calls map[string]*upstreamCall // endpoint + "/" + tool
+}
+
+// NewUpstreamMetrics creates an empty metrics registry.
+func NewUpstreamMetrics() *UpstreamMetrics {
+	return &UpstreamMetrics{calls: make(map[string]*upstreamCall)}
+}
+
+var (
+	defaultUpstreamMetrics     *UpstreamMetrics
+	defaultUpstreamMetricsOnce sync.Once
+)
+
+// DefaultUpstreamMetrics returns the process-wide upstream metrics, shared by all specs.
+func DefaultUpstreamMetrics() *UpstreamMetrics {
+	defaultUpstreamMetricsOnce.Do(func() {
+		defaultUpstreamMetrics = NewUpstreamMetrics()
+	})
+	return defaultUpstreamMetrics
+}
+
+// Observe records one upstream call. status is 0 when the call failed before a response.
+func (m *UpstreamMetrics) Observe(endpoint, tool string, status int, d, slowThreshold time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := latencyKey(endpoint, tool)
+	c := m.calls[key]
+	if c == nil {
+		c = &upstreamCall{buckets: make([]int64, len(UpstreamLatencyBuckets)+1), statusCodes: map[int]int64{}}
+		m.calls[key] = c
+	}
+	c.calls++
+	c.sum += d
+	c.statusCodes[status]++
+	if status == 0 || status >= 500 {
+		c.errors++
+	}
+	c.slowThreshold = slowThreshold
+	if slowThreshold > 0 && d >= slowThreshold {
+		c.slow++
+	}
+	i := sort.Search(len(UpstreamLatencyBuckets), func(i int) bool { return d <= UpstreamLatencyBuckets[i] })
+	c.buckets[i]++
+}
+
+// All returns the metrics of every spec and tool, sorted by endpoint and tool.
+func (m *UpstreamMetrics) All() []UpstreamCallStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	all := make([]UpstreamCallStats, 0, len(m.calls))
+	for key, c := range m.calls {
+		endpoint, tool := splitLatencyKey(key)
+		stats := UpstreamCallStats{
+			Endpoint:      endpoint,
+			Tool:          tool,
+			Calls:         c.calls,
+			Errors:        c.errors,
+			SlowCalls:     c.slow,
+			SlowThreshold: c.slowThreshold.String(),
+			SumMs:         c.sum.Milliseconds(),
+			StatusCodes:   make(map[string]int64, len(c.statusCodes)),
+		}
+		var cumulative int64
+		for i, n := range c.buckets {
+			cumulative += n
+			le := int64(-1)
+			if i < len(UpstreamLatencyBuckets) {
+				le = UpstreamLatencyBuckets[i].Milliseconds()
+			}
+			stats.Buckets = append(stats.Buckets, HistogramBucket{LeMs: le, Count: cumulative})
+		}
+		for status, n := range c.statusCodes {
+			stats.StatusCodes[strconv.Itoa(status)] = n
+		}
+		all = append(all, stats)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return latencyKey(all[i].Endpoint, all[i].Tool) < latencyKey(all[j].Endpoint, all[j].Tool)
+	})
+	return all
+}
+
+// specSlowCallThreshold returns the duration above which upstream calls of a spec are logged as
+// slow: opts, then the x-mcp-slow-call-threshold extension, then MCP_SLOW_CALL_THRESHOLD, then 5s.
+func specSlowCallThreshold(doc *openapi3.T, opts *ToolGenOptions) time.Duration {
+	if opts != nil && opts.SlowCallThreshold > 0 {
+		return opts.SlowCallThreshold
+	}
+	if doc != nil {
+		if v, ok := doc.Extensions[slowCallExtension].(string); ok {
+			if d, err := time.ParseDuration(strings.TrimSpace(v)); err == nil && d > 0 {
+				return d
+			}
+			fmt.Fprintf(os.Stderr, "[WARN] Ignoring invalid %s %q\n", slowCallExtension, v)
+		}
+	}
+	if v := os.Getenv("MCP_SLOW_CALL_THRESHOLD"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		fmt.Fprintf(os.Stderr, "[WARN] Ignoring invalid MCP_SLOW_CALL_THRESHOLD=%q\n", v)
+	}
+	return DefaultSlowCallThreshold
+}
+
+type upstreamToolKey struct{}
+
+// withUpstreamTool labels the upstream requests made with ctx with the calling tool.
+func withUpstreamTool(ctx context.Context, tool string) context.Context {
+	return context.WithValue(ctx, upstreamToolKey{}, tool)
+}
+
+// upstreamTransport measures every upstream call of one spec, logs the slow ones and
+// tracks the spec's health. It also applies the spec's Host header override.
+type upstreamTransport struct {
+	base          http.RoundTripper // nil uses http.DefaultTransport
+	host          string            // Host header override, if any
+	endpoint      string
+	metrics       *UpstreamMetrics
+	health        *SpecHealth
+	bandwidth     *BandwidthTracker
+	slowThreshold time.Duration
+}
+
+func (t *upstreamTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if t.host != "" {
+		req = req.Clone(req.Context())
+		req.Host = t.host
+	}
+	req, span := tracing.StartClient(req)
+	tool, _ := req.Context().Value(upstreamToolKey{}).(string)
+	span.SetAttribute("gen_ai.tool.name", tool)
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	d := time.Since(start)
+	tracing.EndClient(span, resp, err)
+
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	t.metrics.Observe(t.endpoint, tool, status, d, t.slowThreshold)
+	t.observeBandwidth(req, resp)
+	recordUpstreamStatus(req.Context(), status)
+	metrics.ObserveUpstreamResponse(t.endpoint, status)
+	if t.health != nil {
+		t.health.Observe(t.endpoint, status, err)
+	}
+	if t.slowThreshold > 0 && d >= t.slowThreshold {
+		// Only the path is logged; query strings may carry credentials
+		fmt.Fprintf(os.Stderr, "[WARN] Slow upstream call: spec=%s tool=%s %s %s%s status=%d duration=%s threshold=%s\n",
+			t.endpoint, tool, req.Method, req.URL.Host, req.URL.Path, status, d.Round(time.Millisecond), t.slowThreshold)
+	}
+	return resp, err
+}
+
+// newUpstreamClient returns the HTTP client dedicated to one spec's upstream calls, with the
+// phase timeouts and the overall timeout, retries and circuit breaker of config. host and sni
+// override the Host header and the TLS server name of its requests when not empty.
+func newUpstreamClient(endpoint string, metrics *UpstreamMetrics, health *SpecHealth, bandwidth *BandwidthTracker, slowThreshold time.Duration, timeouts UpstreamTimeouts, config UpstreamPolicy, breakers *CircuitBreakers, host, sni string) *http.Client {
+	// Every attempt of a retried call is measured
+	measured := &upstreamTransport{
+		base:          upstreamBaseTransport(timeouts, sni),
+		host:          host,
+		endpoint:      endpoint,
+		metrics:       metrics,
+		health:        health,
+		bandwidth:     bandwidth,
+		slowThreshold: slowThreshold,
+	}
+	return &http.Client{
+		Timeout: config.Timeout,
+		Transport: &retryTransport{
+			next:     measured,
+			endpoint: endpoint,
+			config:   config,
+			breakers: breakers,
+		},
+	}
+}
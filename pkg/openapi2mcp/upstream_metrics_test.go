@@ -0,0 +1,76 @@
+package openapi2mcp
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestUpstreamMetricsHistogram(t *testing.T) {
+	m := NewUpstreamMetrics()
+	m.Observe("pets", "getPet", 200, 40*time.Millisecond, time.Second)
+	m.Observe("pets", "getPet", 200, 300*time.Millisecond, time.Second)
+	m.Observe("pets", "getPet", 503, 2*time.Second, time.Second)
+	m.Observe("pets", "getPet", 0, time.Minute, time.Second)
+
+	all := m.All()
+	if len(all) != 1 {
+		t.Fatalf("expected one tool, got %+v", all)
+	}
+	stats := all[0]
+	if stats.Calls != 4 || stats.Errors != 2 || stats.SlowCalls != 2 || stats.SlowThreshold != "1s" {
+		t.Errorf("unexpected counters: %+v", stats)
+	}
+	if stats.StatusCodes["200"] != 2 || stats.StatusCodes["503"] != 1 || stats.StatusCodes["0"] != 1 {
+		t.Errorf("unexpected status codes: %v", stats.StatusCodes)
+	}
+	want := map[int64]int64{50: 1, 250: 1, 500: 2, 1000: 2, 2500: 3, 30000: 3, -1: 4}
+	for _, b := range stats.Buckets {
+		if n, ok := want[b.LeMs]; ok && b.Count != n {
+			t.Errorf("bucket le=%dms: expected %d, got %d", b.LeMs, n, b.Count)
+		}
+	}
+	if last := stats.Buckets[len(stats.Buckets)-1]; last.LeMs != -1 || last.Count != stats.Calls {
+		t.Errorf("expected the +Inf bucket to count every call, got %+v", last)
+	}
+}
+
+func TestUpstreamClientRecordsCalls(t *testing.T) {
+	metrics := NewUpstreamMetrics()
+	opts := &ToolGenOptions{UpstreamMetrics: metrics, SlowCallThreshold: 10 * time.Millisecond}
+	server := newTestServer(t, mockUpstreamSpec, opts, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	})
+
+	callToolForTest(t, server, "listPets", map[string]any{})
+	all := metrics.All()
+	if len(all) != 1 || all[0].Endpoint != "pets" || all[0].Tool != "listPets" {
+		t.Fatalf("expected metrics labeled with spec and tool, got %+v", all)
+	}
+	if all[0].Calls != 1 || all[0].SlowCalls != 1 || all[0].StatusCodes["200"] != 1 {
+		t.Errorf("expected one slow successful call, got %+v", all[0])
+	}
+}
+
+func TestSpecSlowCallThreshold(t *testing.T) {
+	if got := specSlowCallThreshold(nil, nil); got != DefaultSlowCallThreshold {
+		t.Errorf("expected the default threshold, got %s", got)
+	}
+	t.Setenv("MCP_SLOW_CALL_THRESHOLD", "3s")
+	if got := specSlowCallThreshold(nil, nil); got != 3*time.Second {
+		t.Errorf("expected the environment threshold, got %s", got)
+	}
+	doc, err := LoadOpenAPISpecFromString(mockUpstreamSpec)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	doc.Extensions = map[string]any{slowCallExtension: "750ms"}
+	if got := specSlowCallThreshold(doc, nil); got != 750*time.Millisecond {
+		t.Errorf("expected the extension threshold, got %s", got)
+	}
+	if got := specSlowCallThreshold(doc, &ToolGenOptions{SlowCallThreshold: time.Second}); got != time.Second {
+		t.Errorf("expected the option threshold, got %s", got)
+	}
+}
@@ -0,0 +1,427 @@
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+)
+
+// upstreamPolicyExtension is the root-level spec extension setting the overall timeout, retry
+// policy and circuit breaker of upstream calls, e.g. {timeout: 60s, max_retries: 3}.
+const upstreamPolicyExtension = "x-mcp-upstream-policy"
+
+// UpstreamPolicy is the overall timeout, retry policy and circuit breaker of a spec's
+// upstream calls. Zero fields in ToolGenOptions fall back to the spec, the environment and
+// the defaults; negative ones turn the timeout, the retries or the circuit breaker off.
+type UpstreamPolicy struct {
+	Timeout          time.Duration // a whole call, retries included
+	MaxRetries       int           // retries after a 429, a 5xx or a connection error
+	RetryBackoff     time.Duration // wait before the first retry, doubled for each retry
+	MaxRetryBackoff  time.Duration // longest wait between retries; a longer Retry-After is not waited for
+	BreakerThreshold int           // consecutive failures of a host that open its circuit
+	BreakerCooldown  time.Duration // how long an open circuit fails calls before letting one through
+}
+
+// DefaultUpstreamPolicy applies when neither opts, the spec nor the MCP_UPSTREAM_*
+// variables set a field: no overall timeout besides the phase timeouts, 2 retries starting
+// at 250ms, and a circuit opening for 30s after 5 consecutive failures of a host.
+var DefaultUpstreamPolicy = UpstreamPolicy{
+	MaxRetries:       2,
+	RetryBackoff:     250 * time.Millisecond,
+	MaxRetryBackoff:  10 * time.Second,
+	BreakerThreshold: 5,
+	BreakerCooldown:  30 * time.Second,
+}
+
+// upstreamPolicyJSON is the JSON form of UpstreamPolicy in the upstream_policy column
+// and the x-mcp-upstream-policy extension. Absent fields fall back; zero turns a field off.
+type upstreamPolicyJSON struct {
+	Timeout          *string `json:"timeout"`
+	MaxRetries       *int    `json:"max_retries"`
+	RetryBackoff     *string `json:"retry_backoff"`
+	MaxRetryBackoff  *string `json:"max_retry_backoff"`
+	BreakerThreshold *int    `json:"breaker_threshold"`
+	BreakerCooldown  *string `json:"breaker_cooldown"`
+}
+
+// ParseUpstreamPolicy parses the JSON upstream policy of a spec, e.g.
+// {"timeout": "60s", "max_retries": 3, "breaker_threshold": 0}. Absent fields are zero, so
+// they fall back; fields set to 0 are negative, so they turn the setting off. An empty
+// string returns nil.
+func ParseUpstreamPolicy(s string) (*UpstreamPolicy, error) {
+	if strings.TrimSpace(s) == "" || strings.TrimSpace(s) == "null" {
+		return nil, nil
+	}
+	var raw upstreamPolicyJSON
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid upstream policy: %v", err)
+	}
+	c := &UpstreamPolicy{}
+	for _, d := range []struct {
+		name string
+		from *string
+		to   *time.Duration
+	}{
+		{"timeout", raw.Timeout, &c.Timeout},
+		{"retry_backoff", raw.RetryBackoff, &c.RetryBackoff},
+		{"max_retry_backoff", raw.MaxRetryBackoff, &c.MaxRetryBackoff},
+		{"breaker_cooldown", raw.BreakerCooldown, &c.BreakerCooldown},
+	} {
+		if d.from == nil {
+			continue
+		}
+		parsed, err := time.ParseDuration(strings.TrimSpace(*d.from))
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("invalid %s %q: expected a Go duration such as 30s", d.name, *d.from)
+		}
+		*d.to = offIfZero(parsed)
+	}
+	for _, n := range []struct {
+		name string
+		from *int
+		to   *int
+	}{
+		{"max_retries", raw.MaxRetries, &c.MaxRetries},
+		{"breaker_threshold", raw.BreakerThreshold, &c.BreakerThreshold},
+	} {
+		if n.from == nil {
+			continue
+		}
+		if *n.from < 0 {
+			return nil, fmt.Errorf("invalid %s %d: expected 0 or more", n.name, *n.from)
+		}
+		*n.to = offIfZero(*n.from)
+	}
+	return c, nil
+}
+
+// offIfZero returns -1 for an explicit zero, which turns a setting off rather than falling back.
+func offIfZero[T int | time.Duration](v T) T {
+	if v == 0 {
+		return -1
+	}
+	return v
+}
+
+// overlay sets the fields of c that o sets.
+func (c *UpstreamPolicy) overlay(o *UpstreamPolicy) {
+	if o == nil {
+		return
+	}
+	for _, d := range []struct{ from, to *time.Duration }{
+		{&o.Timeout, &c.Timeout}, {&o.RetryBackoff, &c.RetryBackoff},
+		{&o.MaxRetryBackoff, &c.MaxRetryBackoff}, {&o.BreakerCooldown, &c.BreakerCooldown},
+	} {
+		if *d.from != 0 {
+			*d.to = *d.from
+		}
+	}
+	if o.MaxRetries != 0 {
+		c.MaxRetries = o.MaxRetries
+	}
+	if o.BreakerThreshold != 0 {
+		c.BreakerThreshold = o.BreakerThreshold
+	}
+}
+
+// specUpstreamPolicy returns the upstream policy of a spec, field by field: opts,
+// then the upstream_policy column, then the x-mcp-upstream-policy extension, then
+// MCP_UPSTREAM_TIMEOUT, MCP_UPSTREAM_MAX_RETRIES, MCP_UPSTREAM_RETRY_BACKOFF,
+// MCP_UPSTREAM_MAX_RETRY_BACKOFF, MCP_UPSTREAM_BREAKER_THRESHOLD and
+// MCP_UPSTREAM_BREAKER_COOLDOWN, then DefaultUpstreamPolicy. Settings turned off are zero.
+func specUpstreamPolicy(doc *openapi3.T, opts *ToolGenOptions, dbSpec *models.OpenAPISpec) UpstreamPolicy {
+	c := DefaultUpstreamPolicy
+	env := UpstreamPolicy{}
+	envUpstreamDuration("MCP_UPSTREAM_TIMEOUT", &env.Timeout)
+	envUpstreamDuration("MCP_UPSTREAM_RETRY_BACKOFF", &env.RetryBackoff)
+	envUpstreamDuration("MCP_UPSTREAM_MAX_RETRY_BACKOFF", &env.MaxRetryBackoff)
+	envUpstreamDuration("MCP_UPSTREAM_BREAKER_COOLDOWN", &env.BreakerCooldown)
+	envUpstreamCount("MCP_UPSTREAM_MAX_RETRIES", &env.MaxRetries)
+	envUpstreamCount("MCP_UPSTREAM_BREAKER_THRESHOLD", &env.BreakerThreshold)
+	c.overlay(&env)
+	if doc != nil && doc.Extensions[upstreamPolicyExtension] != nil {
+		// Round-trip through JSON to parse the extension like the column
+		data, err := json.Marshal(doc.Extensions[upstreamPolicyExtension])
+		var ext *UpstreamPolicy
+		if err == nil {
+			ext, err = ParseUpstreamPolicy(string(data))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] Ignoring %s: %v\n", upstreamPolicyExtension, err)
+		}
+		c.overlay(ext)
+	}
+	if dbSpec != nil && dbSpec.UpstreamPolicy != nil {
+		column, err := ParseUpstreamPolicy(*dbSpec.UpstreamPolicy)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] Ignoring upstream policy of spec %s: %v\n", dbSpec.Name, err)
+		}
+		c.overlay(column)
+	}
+	if opts != nil {
+		c.overlay(opts.UpstreamPolicy)
+	}
+	for _, d := range []*time.Duration{&c.Timeout, &c.RetryBackoff, &c.MaxRetryBackoff, &c.BreakerCooldown} {
+		*d = max(*d, 0)
+	}
+	c.MaxRetries, c.BreakerThreshold = max(c.MaxRetries, 0), max(c.BreakerThreshold, 0)
+	return c
+}
+
+// envUpstreamDuration sets *d from the duration in the name environment variable, when it is
+// valid; "0" turns the setting off.
+func envUpstreamDuration(name string, d *time.Duration) {
+	parsed := time.Duration(-1)
+	envTimeout(name, &parsed)
+	if parsed >= 0 {
+		*d = offIfZero(parsed)
+	}
+}
+
+// envUpstreamCount sets *n from the count in the name environment variable, when it is
+// valid; "0" turns the setting off.
+func envUpstreamCount(name string, n *int) {
+	v := strings.TrimSpace(os.Getenv(name))
+	if v == "" {
+		return
+	}
+	parsed, err := strconv.Atoi(v)
+	if err != nil || parsed < 0 {
+		fmt.Fprintf(os.Stderr, "[WARN] Ignoring invalid %s=%q\n", name, v)
+		return
+	}
+	*n = offIfZero(parsed)
+}
+
+// CircuitOpenError is returned for upstream calls to a host whose circuit is open.
+type CircuitOpenError struct {
+	Host       string
+	RetryAfter time.Duration // until the circuit lets a trial call through
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker open for %s after repeated failures; retry in %s", e.Host, e.RetryAfter.Round(time.Second))
+}
+
+type hostCircuit struct {
+	failures  int       // consecutive failures
+	openUntil time.Time // zero while the circuit is closed
+	trial     bool      // a trial call of the half-open circuit is in flight
+}
+
+// CircuitBreakers tracks the consecutive failures of upstream hosts, and fails calls to a
+// host fast while its circuit is open. After the cooldown, one trial call is let through:
+// its success closes the circuit, its failure opens it again.
+type CircuitBreakers struct {
+	mu    sync.Mutex
+	hosts map[string]*hostCircuit
+}
+
+// NewCircuitBreakers creates circuit breakers with every circuit closed.
+func NewCircuitBreakers() *CircuitBreakers {
+	return &CircuitBreakers{hosts: make(map[string]*hostCircuit)}
+}
+
+var (
+	defaultCircuitBreakers     *CircuitBreakers
+	defaultCircuitBreakersOnce sync.Once
+)
+
+// DefaultCircuitBreakers returns the process-wide circuit breakers, shared by all specs, so
+// specs calling the same host share its circuit.
+func DefaultCircuitBreakers() *CircuitBreakers {
+	defaultCircuitBreakersOnce.Do(func() {
+		defaultCircuitBreakers = NewCircuitBreakers()
+	})
+	return defaultCircuitBreakers
+}
+
+// allow returns a *CircuitOpenError when the circuit of host is open, and otherwise lets
+// the call through, as the trial call when the cooldown is over.
+func (b *CircuitBreakers) allow(host string, now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.hosts[host]
+	if c == nil || c.openUntil.IsZero() {
+		return nil
+	}
+	if now.Before(c.openUntil) {
+		return &CircuitOpenError{Host: host, RetryAfter: c.openUntil.Sub(now)}
+	}
+	if c.trial {
+		// Only one trial call at a time
+		return &CircuitOpenError{Host: host}
+	}
+	c.trial = true
+	return nil
+}
+
+// record counts the outcome of a call to host, opening its circuit for cooldown after
+// threshold consecutive failures, or after a failed trial call.
+func (b *CircuitBreakers) record(host string, failed bool, threshold int, cooldown time.Duration, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.hosts[host]
+	if !failed {
+		if c != nil && !c.openUntil.IsZero() {
+			fmt.Fprintf(os.Stderr, "[INFO] Circuit breaker closed for %s\n", host)
+		}
+		delete(b.hosts, host)
+		return
+	}
+	if c == nil {
+		c = &hostCircuit{}
+		b.hosts[host] = c
+	}
+	c.failures++
+	if c.trial || (c.openUntil.IsZero() && c.failures >= threshold) {
+		fmt.Fprintf(os.Stderr, "[WARN] Circuit breaker open for %s after %d consecutive failures; failing calls for %s\n", host, c.failures, cooldown)
+		c.openUntil = now.Add(cooldown)
+		c.trial = false
+	}
+}
+
+// abandon lets another trial call through when the trial call to host was canceled.
+func (b *CircuitBreakers) abandon(host string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c := b.hosts[host]; c != nil {
+		c.trial = false
+	}
+}
+
+// State returns whether the circuit of host is open, and until when.
+func (b *CircuitBreakers) State(host string) (open bool, until time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c := b.hosts[host]; c != nil && !c.openUntil.IsZero() {
+		return true, c.openUntil
+	}
+	return false, time.Time{}
+}
+
+// retryTransport retries the upstream calls of one spec after 429 and 5xx responses and
+// connection errors, with exponential backoff honoring Retry-After, and applies the circuit
+// breaker of the upstream host to every attempt.
+type retryTransport struct {
+	next     http.RoundTripper
+	endpoint string
+	config   UpstreamPolicy
+	breakers *CircuitBreakers
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+		if t.config.BreakerThreshold > 0 {
+			if err := t.breakers.allow(host, time.Now()); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := t.next.RoundTrip(req)
+		if t.config.BreakerThreshold > 0 {
+			if req.Context().Err() != nil {
+				// Canceled calls say nothing about the host
+				t.breakers.abandon(host)
+			} else {
+				failed := err != nil || resp.StatusCode >= 500
+				t.breakers.record(host, failed, t.config.BreakerThreshold, t.config.BreakerCooldown, time.Now())
+			}
+		}
+		wait, retry := t.retryAfter(req, resp, err, attempt)
+		if !retry {
+			return resp, err
+		}
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+			// Drain the body so the connection can be reused
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		tool, _ := req.Context().Value(upstreamToolKey{}).(string)
+		fmt.Fprintf(os.Stderr, "[INFO] Retrying upstream call: spec=%s tool=%s %s %s%s status=%d retry=%d/%d in %s\n",
+			t.endpoint, tool, req.Method, req.URL.Host, req.URL.Path, status, attempt+1, t.config.MaxRetries, wait.Round(time.Millisecond))
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryAfter returns how long to wait before retrying a call, and whether to retry it at all.
+// 429 and 503 responses are retried for every method, since the upstream did not process
+// the request; other 5xx responses and connection errors only for idempotent methods.
+func (t *retryTransport) retryAfter(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= t.config.MaxRetries || req.Context().Err() != nil {
+		return 0, false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// The body cannot be sent again
+		return 0, false
+	}
+	idempotent := !isWriteMethod(req.Method) || req.Method == http.MethodPut || req.Method == http.MethodDelete
+	switch {
+	case err != nil:
+		var open *CircuitOpenError
+		if errors.As(err, &open) || !idempotent {
+			return 0, false
+		}
+	case resp.StatusCode == http.StatusTooManyRequests && req.Context().Value(keyFailoverKey{}) != nil:
+		// The call is sent again with the next API key of the spec
+		return 0, false
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable:
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			// Don't hold the call for longer than the longest backoff
+			return wait, t.config.MaxRetryBackoff == 0 || wait <= t.config.MaxRetryBackoff
+		}
+	case resp.StatusCode >= 500 && idempotent:
+	default:
+		return 0, false
+	}
+	backoff := t.config.RetryBackoff << min(attempt, 20)
+	if t.config.MaxRetryBackoff > 0 && backoff > t.config.MaxRetryBackoff {
+		backoff = t.config.MaxRetryBackoff
+	}
+	// Jitter spreads the retries of concurrent calls
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1)), true
+}
+
+// parseRetryAfter parses a Retry-After header, in seconds or as an HTTP date.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,145 @@
+package openapi2mcp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+)
+
+func testUpstreamClient(policy UpstreamPolicy, breakers *CircuitBreakers) *http.Client {
+	return newUpstreamClient("test", NewUpstreamMetrics(), nil, nil, 0, DefaultUpstreamTimeouts, policy, breakers, "", "")
+}
+
+func TestUpstreamRetries(t *testing.T) {
+	var calls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer upstream.Close()
+
+	policy := UpstreamPolicy{MaxRetries: 2, RetryBackoff: time.Millisecond, MaxRetryBackoff: 10 * time.Millisecond}
+	client := testUpstreamClient(policy, NewCircuitBreakers())
+	resp, err := client.Post(upstream.URL, "application/json", strings.NewReader(`{"a":1}`))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	// The 502 is not retried: the POST may have been processed
+	if resp.StatusCode != http.StatusBadGateway || calls.Load() != 2 {
+		t.Errorf("expected a POST to be retried after 429 only, got status %d after %d calls", resp.StatusCode, calls.Load())
+	}
+
+	calls.Store(0)
+	resp, err = client.Get(upstream.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls.Load() != 3 {
+		t.Errorf("expected a GET to be retried after 429 and 502, got status %d after %d calls", resp.StatusCode, calls.Load())
+	}
+
+	// A Retry-After above the longest backoff is returned to the caller
+	long := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer long.Close()
+	calls.Store(0)
+	resp, err = client.Get(long.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if calls.Load() != 1 {
+		t.Errorf("expected no retry after Retry-After: 120, got %d calls", calls.Load())
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	var healthy atomic.Bool
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer upstream.Close()
+
+	breakers := NewCircuitBreakers()
+	policy := UpstreamPolicy{BreakerThreshold: 3, BreakerCooldown: 50 * time.Millisecond}
+	client := testUpstreamClient(policy, breakers)
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(upstream.URL)
+		if err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+		resp.Body.Close()
+	}
+	_, err := client.Get(upstream.URL)
+	var open *CircuitOpenError
+	if !errors.As(err, &open) || calls.Load() != 3 {
+		t.Fatalf("expected the circuit to open after 3 failures, got %v after %d calls", err, calls.Load())
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	healthy.Store(true)
+	resp, err := client.Get(upstream.URL)
+	if err != nil {
+		t.Fatalf("expected a trial call after the cooldown, got %v", err)
+	}
+	resp.Body.Close()
+	if isOpen, _ := breakers.State(strings.TrimPrefix(upstream.URL, "http://")); isOpen {
+		t.Error("expected a successful trial call to close the circuit")
+	}
+}
+
+func TestSpecUpstreamPolicy(t *testing.T) {
+	t.Setenv("MCP_UPSTREAM_MAX_RETRIES", "4")
+	t.Setenv("MCP_UPSTREAM_TIMEOUT", "90s")
+	doc, err := LoadOpenAPISpecFromString(`
+openapi: 3.0.0
+info: {title: T, version: "1"}
+x-mcp-upstream-policy: {max_retries: 1, breaker_cooldown: 1m}
+paths: {}
+`)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	column := `{"breaker_threshold": 0, "retry_backoff": "1s"}`
+	p := specUpstreamPolicy(doc, &ToolGenOptions{UpstreamPolicy: &UpstreamPolicy{MaxRetryBackoff: time.Minute}}, &models.OpenAPISpec{UpstreamPolicy: &column})
+	want := UpstreamPolicy{
+		Timeout:          90 * time.Second,
+		MaxRetries:       1,
+		RetryBackoff:     time.Second,
+		MaxRetryBackoff:  time.Minute,
+		BreakerThreshold: 0, // turned off by the column
+		BreakerCooldown:  time.Minute,
+	}
+	if p != want {
+		t.Errorf("expected %+v, got %+v", want, p)
+	}
+
+	if _, err := ParseUpstreamPolicy(`{"max_retries": -1}`); err == nil {
+		t.Error("expected a negative max_retries to be rejected")
+	}
+	if _, err := ParseUpstreamPolicy(`{"retries": 3}`); err == nil {
+		t.Error("expected an unknown field to be rejected")
+	}
+}
@@ -0,0 +1,90 @@
+// user_agent.go
+package openapi2mcp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
+	mcpserver "github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+const (
+	// userAgentExtension is the root-level spec extension that overrides the upstream User-Agent.
+	userAgentExtension = "x-mcp-user-agent"
+	// attributionExtension is the root-level spec extension that enables attribution headers for one spec.
+	attributionExtension = "x-mcp-attribution-headers"
+	// sessionAttributionHeader carries the MCP session that originated an upstream call.
+	sessionAttributionHeader = "X-MCP-Session-Id"
+)
+
+// UserAgentVersion is the version reported in the default upstream User-Agent.
+// The server binary sets it from its build metadata.
+var UserAgentVersion = "dev"
+
+// defaultUserAgent identifies upstream traffic, e.g. "openapi-mcp/v1.2.3 (+petstore)".
+func defaultUserAgent(endpoint string) string {
+	ua := "openapi-mcp/" + UserAgentVersion
+	if endpoint != "" {
+		ua += " (+" + endpoint + ")"
+	}
+	return ua
+}
+
+// specUserAgent returns the User-Agent for a spec: opts, then the x-mcp-user-agent extension,
+// then the default.
+func specUserAgent(doc *openapi3.T, opts *ToolGenOptions, endpoint string) string {
+	if opts != nil && opts.UserAgent != "" {
+		return opts.UserAgent
+	}
+	if doc != nil {
+		if v, ok := doc.Extensions[userAgentExtension].(string); ok && strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return defaultUserAgent(endpoint)
+}
+
+// specAttributionHeaders reports whether X-Forwarded-For and X-MCP-Session-Id are sent upstream.
+// They are off unless enabled by opts, the x-mcp-attribution-headers extension or MCP_ATTRIBUTION_HEADERS.
+func specAttributionHeaders(doc *openapi3.T, opts *ToolGenOptions) bool {
+	if opts != nil && opts.AttributionHeaders {
+		return true
+	}
+	if doc != nil {
+		if v, ok := doc.Extensions[attributionExtension].(bool); ok {
+			return v
+		}
+	}
+	enabled, _ := strconv.ParseBool(os.Getenv("MCP_ATTRIBUTION_HEADERS"))
+	return enabled
+}
+
+// setAttributionHeaders adds the client address and the originating session to an upstream request.
+func setAttributionHeaders(ctx context.Context, req *http.Request) {
+	if forwardedFor := clientForwardedFor(ctx); forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	if session := mcpserver.ClientSessionFromContext(ctx); session != nil && session.SessionID() != "" {
+		req.Header.Set(sessionAttributionHeader, session.SessionID())
+	}
+}
+
+// clientForwardedFor extends the X-Forwarded-For chain of the incoming MCP request with its remote address.
+func clientForwardedFor(ctx context.Context) string {
+	chain := sessionHeaders(ctx).Get("X-Forwarded-For")
+	if authCtx, ok := auth.FromContext(ctx); ok && authCtx != nil && authCtx.OriginalRequest != nil {
+		if host, _, err := net.SplitHostPort(authCtx.OriginalRequest.RemoteAddr); err == nil && host != "" {
+			if chain == "" {
+				return host
+			}
+			return chain + ", " + host
+		}
+	}
+	return chain
+}
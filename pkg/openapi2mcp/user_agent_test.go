@@ -0,0 +1,55 @@
+package openapi2mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
+)
+
+func TestUpstreamUserAgent(t *testing.T) {
+	var got http.Header
+	upstream := func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}
+
+	tests := []struct {
+		name      string
+		extension string
+		want      string
+	}{
+		{name: "default", want: "openapi-mcp/" + UserAgentVersion + " (+pets)"},
+		{name: "spec override", extension: "x-mcp-user-agent: petstore-bot/2.0\n", want: "petstore-bot/2.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := strings.Replace(mockUpstreamSpec, "openapi: 3.0.0\n", "openapi: 3.0.0\n"+tt.extension, 1)
+			server := newTestServer(t, spec, nil, upstream)
+			callToolForTest(t, server, "listPets", map[string]any{})
+			if ua := got.Get("User-Agent"); ua != tt.want {
+				t.Errorf("expected User-Agent %q, got %q", tt.want, ua)
+			}
+			if got.Get("X-Forwarded-For") != "" || got.Get(sessionAttributionHeader) != "" {
+				t.Errorf("attribution headers must be opt-in, got %v", got)
+			}
+		})
+	}
+}
+
+func TestSetAttributionHeaders(t *testing.T) {
+	incoming := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	incoming.RemoteAddr = "10.0.0.5:51234"
+	incoming.Header.Set("X-Forwarded-For", "203.0.113.7")
+	ctx := auth.WithAuthContext(context.Background(), &auth.AuthContext{OriginalRequest: incoming})
+
+	req := httptest.NewRequest(http.MethodGet, "http://upstream/pets", nil)
+	setAttributionHeaders(ctx, req)
+	if got := req.Header.Get("X-Forwarded-For"); got != "203.0.113.7, 10.0.0.5" {
+		t.Errorf("unexpected X-Forwarded-For %q", got)
+	}
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+)
+
+// AuditLogRepository handles database operations for the tool call audit log
+type AuditLogRepository struct {
+	db *sql.DB
+}
+
+// NewAuditLogRepository creates a new repository instance
+func NewAuditLogRepository(db *sql.DB) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+const auditColumns = `id, called_at, endpoint, tool, session_id, arg_names, args_hash, status, upstream_status, error, duration_ms, host`
+
+// AuditFilter selects audit records; empty fields match every record
+type AuditFilter struct {
+	Endpoint  string
+	Tool      string
+	SessionID string
+	Since     time.Time
+}
+
+// Insert writes a batch of records in one statement, so a failed batch is never partly written
+func (r *AuditLogRepository) Insert(records []*models.AuditRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	const columns = 11
+	var query strings.Builder
+	query.WriteString(`INSERT INTO tool_call_audit (called_at, endpoint, tool, session_id, arg_names, args_hash, status, upstream_status, error, duration_ms, host) VALUES `)
+	args := make([]any, 0, len(records)*columns)
+	for i, rec := range records {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(")
+		for c := 1; c <= columns; c++ {
+			if c > 1 {
+				query.WriteString(", ")
+			}
+			fmt.Fprintf(&query, "$%d", i*columns+c)
+		}
+		query.WriteString(")")
+		args = append(args, rec.CalledAt, rec.Endpoint, rec.Tool, rec.SessionID, rec.ArgNames, rec.ArgsHash,
+			rec.Status, rec.UpstreamStatus, rec.Error, rec.DurationMs, rec.Host)
+	}
+	err := withRetry(r.db, "Insert", func() error {
+		_, err := r.db.Exec(query.String(), args...)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write audit records: %v", err)
+	}
+	return nil
+}
+
+// List returns the most recent records matching the filter
+func (r *AuditLogRepository) List(filter AuditFilter, limit int) ([]*models.AuditRecord, error) {
+	query := `
+		SELECT ` + auditColumns + `
+		FROM tool_call_audit
+		WHERE ($1 = '' OR endpoint = $1) AND ($2 = '' OR tool = $2) AND ($3 = '' OR session_id = $3)
+			AND ($4::timestamp IS NULL OR called_at >= $4)
+		ORDER BY called_at DESC
+		LIMIT $5
+	`
+	var since *time.Time
+	if !filter.Since.IsZero() {
+		since = &filter.Since
+	}
+	var records []*models.AuditRecord
+	err := withRetry(r.db, "List", func() error {
+		rows, err := r.db.Query(query, filter.Endpoint, filter.Tool, filter.SessionID, since, limit)
+		if err != nil {
+			return err
+		}
+		records, err = scanAuditRecords(rows)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit records: %v", err)
+	}
+	return records, nil
+}
+
+func scanAuditRecords(rows *sql.Rows) ([]*models.AuditRecord, error) {
+	defer rows.Close()
+	var records []*models.AuditRecord
+	for rows.Next() {
+		rec := &models.AuditRecord{}
+		if err := rows.Scan(
+			&rec.ID,
+			&rec.CalledAt,
+			&rec.Endpoint,
+			&rec.Tool,
+			&rec.SessionID,
+			&rec.ArgNames,
+			&rec.ArgsHash,
+			&rec.Status,
+			&rec.UpstreamStatus,
+			&rec.Error,
+			&rec.DurationMs,
+			&rec.Host,
+		); err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
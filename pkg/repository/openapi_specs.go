@@ -3,13 +3,16 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"log"
+	"time"
 
 	"github.com/ubermorgenland/openapi-mcp/pkg/models"
 )
 
 // OpenAPISpecRepository handles database operations for OpenAPI specs
 type OpenAPISpecRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	replica *sql.DB // optional read-only replica for list queries
 }
 
 // NewOpenAPISpecRepository creates a new repository instance
@@ -17,57 +20,123 @@ func NewOpenAPISpecRepository(db *sql.DB) *OpenAPISpecRepository {
 	return &OpenAPISpecRepository{db: db}
 }
 
-// Create inserts a new OpenAPI spec into the database
+// WithReplica routes GetAll and GetActive to a read-only replica, falling back to the
+// primary when the replica fails. A nil replica reads from the primary.
+func (r *OpenAPISpecRepository) WithReplica(replica *sql.DB) *OpenAPISpecRepository {
+	r.replica = replica
+	return r
+}
+
+// begin opens a transaction on the primary, retrying transient connection errors
+func (r *OpenAPISpecRepository) begin() (*sql.Tx, error) {
+	var tx *sql.Tx
+	err := withRetry(r.db, "begin", func() error {
+		var err error
+		tx, err = r.db.Begin()
+		return err
+	})
+	return tx, err
+}
+
+// Create inserts a new OpenAPI spec into the database. The spec content is stored
+// once per distinct content in spec_blobs and referenced by hash.
 func (r *OpenAPISpecRepository) Create(spec *models.OpenAPISpec) (*models.OpenAPISpec, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create openapi spec: %v", err)
+	}
+	defer tx.Rollback()
+
+	// A deleted spec still holds its name and endpoint; creating a spec that reuses them
+	// purges it
+	if _, err := purgeSpecs(tx, `(name = $1 OR endpoint_path = $2)`, spec.Name, spec.EndpointPath); err != nil {
+		return nil, fmt.Errorf("failed to create openapi spec: %v", err)
+	}
+
+	hash, err := acquireBlob(tx, spec.SpecContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create openapi spec: %v", err)
+	}
+
+	// The ID is taken first, as the token is encrypted bound to it
+	if err := tx.QueryRow(`SELECT nextval(pg_get_serial_sequence('openapi_specs', 'id'))`).Scan(&spec.ID); err != nil {
+		return nil, fmt.Errorf("failed to create openapi spec: %v", err)
+	}
+
 	query := `
-		INSERT INTO openapi_specs (name, title, version, spec_content, endpoint_path, file_format, file_size, api_key_token, is_active)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		RETURNING id, created_at, updated_at
+		INSERT INTO openapi_specs (name, title, version, content_hash, endpoint_path, file_format, file_size, api_key_token, is_active, feature_flags, aliases, require_token_to_activate, rate_limit, tool_rules, upstream_policy, credential_strategy, id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		RETURNING created_at, updated_at
 	`
 
-	err := r.db.QueryRow(
+	err = tx.QueryRow(
 		query,
 		spec.Name,
 		spec.Title,
 		spec.Version,
-		spec.SpecContent,
+		hash,
 		spec.EndpointPath,
 		spec.FileFormat,
 		spec.FileSize,
-		spec.ApiKeyToken,
+		sealedToken{spec.ApiKeyToken, specTokenColumn, spec.ID},
 		spec.IsActive,
-	).Scan(&spec.ID, &spec.CreatedAt, &spec.UpdatedAt)
+		spec.FeatureFlags,
+		spec.Aliases,
+		spec.RequireToken,
+		spec.RateLimit,
+		spec.ToolRules,
+		spec.UpstreamPolicy,
+		spec.CredentialStrategy,
+		spec.ID,
+	).Scan(&spec.CreatedAt, &spec.UpdatedAt)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create openapi spec: %v", err)
 	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to create openapi spec: %v", err)
+	}
 
+	spec.ContentHash = &hash
 	return spec, nil
 }
 
 // GetByID retrieves an OpenAPI spec by its ID
 func (r *OpenAPISpecRepository) GetByID(id int) (*models.OpenAPISpec, error) {
 	query := `
-		SELECT id, name, title, version, spec_content, endpoint_path, file_format, file_size, api_key_token, is_active, created_at, updated_at
-		FROM openapi_specs
-		WHERE id = $1
+		SELECT s.id, s.name, s.title, s.version, COALESCE(b.content, s.spec_content, ''), s.endpoint_path, s.file_format, s.file_size,
+		       s.api_key_token, s.is_active, s.created_at, s.updated_at, s.content_hash, s.feature_flags, s.aliases, s.deleted_at, s.require_token_to_activate, s.rate_limit, s.tool_rules, s.upstream_policy, s.credential_strategy
+		FROM openapi_specs s
+		LEFT JOIN spec_blobs b ON b.hash = s.content_hash
+		WHERE s.id = $1 AND s.deleted_at IS NULL
 	`
 
 	spec := &models.OpenAPISpec{}
-	err := r.db.QueryRow(query, id).Scan(
-		&spec.ID,
-		&spec.Name,
-		&spec.Title,
-		&spec.Version,
-		&spec.SpecContent,
-		&spec.EndpointPath,
-		&spec.FileFormat,
-		&spec.FileSize,
-		&spec.ApiKeyToken,
-		&spec.IsActive,
-		&spec.CreatedAt,
-		&spec.UpdatedAt,
-	)
+	err := withRetry(r.db, "GetByID", func() error {
+		return r.db.QueryRow(query, id).Scan(
+			&spec.ID,
+			&spec.Name,
+			&spec.Title,
+			&spec.Version,
+			&spec.SpecContent,
+			&spec.EndpointPath,
+			&spec.FileFormat,
+			&spec.FileSize,
+			openedToken{&spec.ApiKeyToken, specTokenColumn, &spec.ID},
+			&spec.IsActive,
+			&spec.CreatedAt,
+			&spec.UpdatedAt,
+			&spec.ContentHash,
+			&spec.FeatureFlags,
+			&spec.Aliases,
+			&spec.DeletedAt,
+			&spec.RequireToken,
+			&spec.RateLimit,
+			&spec.ToolRules,
+			&spec.UpstreamPolicy,
+			&spec.CredentialStrategy,
+		)
+	})
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -82,26 +151,39 @@ func (r *OpenAPISpecRepository) GetByID(id int) (*models.OpenAPISpec, error) {
 // GetByName retrieves an OpenAPI spec by its name
 func (r *OpenAPISpecRepository) GetByName(name string) (*models.OpenAPISpec, error) {
 	query := `
-		SELECT id, name, title, version, spec_content, endpoint_path, file_format, file_size, api_key_token, is_active, created_at, updated_at
-		FROM openapi_specs
-		WHERE name = $1
+		SELECT s.id, s.name, s.title, s.version, COALESCE(b.content, s.spec_content, ''), s.endpoint_path, s.file_format, s.file_size,
+		       s.api_key_token, s.is_active, s.created_at, s.updated_at, s.content_hash, s.feature_flags, s.aliases, s.deleted_at, s.require_token_to_activate, s.rate_limit, s.tool_rules, s.upstream_policy, s.credential_strategy
+		FROM openapi_specs s
+		LEFT JOIN spec_blobs b ON b.hash = s.content_hash
+		WHERE s.name = $1 AND s.deleted_at IS NULL
 	`
 
 	spec := &models.OpenAPISpec{}
-	err := r.db.QueryRow(query, name).Scan(
-		&spec.ID,
-		&spec.Name,
-		&spec.Title,
-		&spec.Version,
-		&spec.SpecContent,
-		&spec.EndpointPath,
-		&spec.FileFormat,
-		&spec.FileSize,
-		&spec.ApiKeyToken,
-		&spec.IsActive,
-		&spec.CreatedAt,
-		&spec.UpdatedAt,
-	)
+	err := withRetry(r.db, "GetByName", func() error {
+		return r.db.QueryRow(query, name).Scan(
+			&spec.ID,
+			&spec.Name,
+			&spec.Title,
+			&spec.Version,
+			&spec.SpecContent,
+			&spec.EndpointPath,
+			&spec.FileFormat,
+			&spec.FileSize,
+			openedToken{&spec.ApiKeyToken, specTokenColumn, &spec.ID},
+			&spec.IsActive,
+			&spec.CreatedAt,
+			&spec.UpdatedAt,
+			&spec.ContentHash,
+			&spec.FeatureFlags,
+			&spec.Aliases,
+			&spec.DeletedAt,
+			&spec.RequireToken,
+			&spec.RateLimit,
+			&spec.ToolRules,
+			&spec.UpstreamPolicy,
+			&spec.CredentialStrategy,
+		)
+	})
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -116,26 +198,39 @@ func (r *OpenAPISpecRepository) GetByName(name string) (*models.OpenAPISpec, err
 // GetByEndpointPath retrieves an OpenAPI spec by its endpoint path
 func (r *OpenAPISpecRepository) GetByEndpointPath(path string) (*models.OpenAPISpec, error) {
 	query := `
-		SELECT id, name, title, version, spec_content, endpoint_path, file_format, file_size, api_key_token, is_active, created_at, updated_at
-		FROM openapi_specs
-		WHERE endpoint_path = $1
+		SELECT s.id, s.name, s.title, s.version, COALESCE(b.content, s.spec_content, ''), s.endpoint_path, s.file_format, s.file_size,
+		       s.api_key_token, s.is_active, s.created_at, s.updated_at, s.content_hash, s.feature_flags, s.aliases, s.deleted_at, s.require_token_to_activate, s.rate_limit, s.tool_rules, s.upstream_policy, s.credential_strategy
+		FROM openapi_specs s
+		LEFT JOIN spec_blobs b ON b.hash = s.content_hash
+		WHERE s.endpoint_path = $1 AND s.deleted_at IS NULL
 	`
 
 	spec := &models.OpenAPISpec{}
-	err := r.db.QueryRow(query, path).Scan(
-		&spec.ID,
-		&spec.Name,
-		&spec.Title,
-		&spec.Version,
-		&spec.SpecContent,
-		&spec.EndpointPath,
-		&spec.FileFormat,
-		&spec.FileSize,
-		&spec.ApiKeyToken,
-		&spec.IsActive,
-		&spec.CreatedAt,
-		&spec.UpdatedAt,
-	)
+	err := withRetry(r.db, "GetByEndpointPath", func() error {
+		return r.db.QueryRow(query, path).Scan(
+			&spec.ID,
+			&spec.Name,
+			&spec.Title,
+			&spec.Version,
+			&spec.SpecContent,
+			&spec.EndpointPath,
+			&spec.FileFormat,
+			&spec.FileSize,
+			openedToken{&spec.ApiKeyToken, specTokenColumn, &spec.ID},
+			&spec.IsActive,
+			&spec.CreatedAt,
+			&spec.UpdatedAt,
+			&spec.ContentHash,
+			&spec.FeatureFlags,
+			&spec.Aliases,
+			&spec.DeletedAt,
+			&spec.RequireToken,
+			&spec.RateLimit,
+			&spec.ToolRules,
+			&spec.UpstreamPolicy,
+			&spec.CredentialStrategy,
+		)
+	})
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -150,105 +245,83 @@ func (r *OpenAPISpecRepository) GetByEndpointPath(path string) (*models.OpenAPIS
 // GetAll retrieves all OpenAPI specs
 func (r *OpenAPISpecRepository) GetAll() ([]*models.OpenAPISpec, error) {
 	query := `
-		SELECT id, name, title, version, spec_content, endpoint_path, file_format, file_size, api_key_token, is_active, created_at, updated_at
-		FROM openapi_specs
-		ORDER BY created_at DESC
+		SELECT s.id, s.name, s.title, s.version, COALESCE(b.content, s.spec_content, ''), s.endpoint_path, s.file_format, s.file_size,
+		       s.api_key_token, s.is_active, s.created_at, s.updated_at, s.content_hash, s.feature_flags, s.aliases, s.deleted_at, s.require_token_to_activate, s.rate_limit, s.tool_rules, s.upstream_policy, s.credential_strategy
+		FROM openapi_specs s
+		LEFT JOIN spec_blobs b ON b.hash = s.content_hash
+		WHERE s.deleted_at IS NULL
+		ORDER BY s.created_at DESC
 	`
 
-	rows, err := r.db.Query(query)
+	specs, err := r.listSpecs("GetAll", query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all openapi specs: %v", err)
 	}
-	defer rows.Close()
-
-	var specs []*models.OpenAPISpec
-	for rows.Next() {
-		spec := &models.OpenAPISpec{}
-		err := rows.Scan(
-			&spec.ID,
-			&spec.Name,
-			&spec.Title,
-			&spec.Version,
-			&spec.SpecContent,
-			&spec.EndpointPath,
-			&spec.FileFormat,
-			&spec.FileSize,
-			&spec.ApiKeyToken,
-			&spec.IsActive,
-			&spec.CreatedAt,
-			&spec.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan openapi spec: %v", err)
-		}
-		specs = append(specs, spec)
-	}
-
 	return specs, nil
 }
 
 // GetActive retrieves all active OpenAPI specs
 func (r *OpenAPISpecRepository) GetActive() ([]*models.OpenAPISpec, error) {
 	query := `
-		SELECT id, name, title, version, spec_content, endpoint_path, file_format, file_size, api_key_token, is_active, created_at, updated_at
-		FROM openapi_specs
-		WHERE is_active = true
-		ORDER BY created_at DESC
+		SELECT s.id, s.name, s.title, s.version, COALESCE(b.content, s.spec_content, ''), s.endpoint_path, s.file_format, s.file_size,
+		       s.api_key_token, s.is_active, s.created_at, s.updated_at, s.content_hash, s.feature_flags, s.aliases, s.deleted_at, s.require_token_to_activate, s.rate_limit, s.tool_rules, s.upstream_policy, s.credential_strategy
+		FROM openapi_specs s
+		LEFT JOIN spec_blobs b ON b.hash = s.content_hash
+		WHERE s.is_active = true AND s.deleted_at IS NULL
+		ORDER BY s.created_at DESC
 	`
 
-	rows, err := r.db.Query(query)
+	specs, err := r.listSpecs("GetActive", query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get active openapi specs: %v", err)
 	}
-	defer rows.Close()
+	return specs, nil
+}
 
-	var specs []*models.OpenAPISpec
-	for rows.Next() {
-		spec := &models.OpenAPISpec{}
-		err := rows.Scan(
-			&spec.ID,
-			&spec.Name,
-			&spec.Title,
-			&spec.Version,
-			&spec.SpecContent,
-			&spec.EndpointPath,
-			&spec.FileFormat,
-			&spec.FileSize,
-			&spec.ApiKeyToken,
-			&spec.IsActive,
-			&spec.CreatedAt,
-			&spec.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan openapi spec: %v", err)
+// Update modifies an existing OpenAPI spec. Changed content moves the spec to the
+// blob for the new content and releases the old one.
+func (r *OpenAPISpecRepository) Update(spec *models.OpenAPISpec) (*models.OpenAPISpec, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to update openapi spec: %v", err)
+	}
+	defer tx.Rollback()
+
+	var oldHash sql.NullString
+	err = tx.QueryRow(`SELECT content_hash FROM openapi_specs WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, spec.ID).Scan(&oldHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("openapi spec with id %d not found", spec.ID)
 		}
-		specs = append(specs, spec)
+		return nil, fmt.Errorf("failed to update openapi spec: %v", err)
 	}
 
-	return specs, nil
-}
+	hash := ContentHash(spec.SpecContent)
+	if !oldHash.Valid || oldHash.String != hash {
+		if _, err := acquireBlob(tx, spec.SpecContent); err != nil {
+			return nil, fmt.Errorf("failed to update openapi spec: %v", err)
+		}
+	}
 
-// Update modifies an existing OpenAPI spec
-func (r *OpenAPISpecRepository) Update(spec *models.OpenAPISpec) (*models.OpenAPISpec, error) {
 	query := `
 		UPDATE openapi_specs
-		SET name = $2, title = $3, version = $4, spec_content = $5, endpoint_path = $6, 
+		SET name = $2, title = $3, version = $4, content_hash = $5, spec_content = NULL, endpoint_path = $6, 
 		    file_format = $7, file_size = $8, api_key_token = $9, is_active = $10, updated_at = NOW()
 		WHERE id = $1
 		RETURNING updated_at
 	`
 
-	err := r.db.QueryRow(
+	err = tx.QueryRow(
 		query,
 		spec.ID,
 		spec.Name,
 		spec.Title,
 		spec.Version,
-		spec.SpecContent,
+		hash,
 		spec.EndpointPath,
 		spec.FileFormat,
 		spec.FileSize,
-		spec.ApiKeyToken,
+		sealedToken{spec.ApiKeyToken, specTokenColumn, spec.ID},
 		spec.IsActive,
 	).Scan(&spec.UpdatedAt)
 
@@ -256,14 +329,30 @@ func (r *OpenAPISpecRepository) Update(spec *models.OpenAPISpec) (*models.OpenAP
 		return nil, fmt.Errorf("failed to update openapi spec: %v", err)
 	}
 
+	if oldHash.Valid && oldHash.String != hash {
+		if err := releaseBlob(tx, oldHash.String); err != nil {
+			return nil, fmt.Errorf("failed to update openapi spec: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to update openapi spec: %v", err)
+	}
+
+	spec.ContentHash = &hash
 	return spec, nil
 }
 
-// Delete removes an OpenAPI spec from the database
+// Delete soft-deletes an OpenAPI spec: it is hidden from every query, but kept with its
+// content until it is restored or purged
 func (r *OpenAPISpecRepository) Delete(id int) error {
-	query := `DELETE FROM openapi_specs WHERE id = $1`
-
-	result, err := r.db.Exec(query, id)
+	query := `UPDATE openapi_specs SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+
+	var result sql.Result
+	err := withRetry(r.db, "Delete", func() error {
+		var err error
+		result, err = r.db.Exec(query, id)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to delete openapi spec: %v", err)
 	}
@@ -280,11 +369,112 @@ func (r *OpenAPISpecRepository) Delete(id int) error {
 	return nil
 }
 
+// Restore undoes the soft deletion of an OpenAPI spec
+func (r *OpenAPISpecRepository) Restore(id int) error {
+	query := `UPDATE openapi_specs SET deleted_at = NULL, updated_at = NOW() WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	var result sql.Result
+	err := withRetry(r.db, "Restore", func() error {
+		var err error
+		result, err = r.db.Exec(query, id)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore openapi spec: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("deleted openapi spec with id %d not found", id)
+	}
+
+	return nil
+}
+
+// GetDeleted retrieves the soft-deleted OpenAPI specs, most recently deleted first
+func (r *OpenAPISpecRepository) GetDeleted() ([]*models.OpenAPISpec, error) {
+	query := `
+		SELECT s.id, s.name, s.title, s.version, COALESCE(b.content, s.spec_content, ''), s.endpoint_path, s.file_format, s.file_size,
+		       s.api_key_token, s.is_active, s.created_at, s.updated_at, s.content_hash, s.feature_flags, s.aliases, s.deleted_at, s.require_token_to_activate, s.rate_limit, s.tool_rules, s.upstream_policy, s.credential_strategy
+		FROM openapi_specs s
+		LEFT JOIN spec_blobs b ON b.hash = s.content_hash
+		WHERE s.deleted_at IS NOT NULL
+		ORDER BY s.deleted_at DESC
+	`
+
+	specs, err := r.listSpecs("GetDeleted", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted openapi specs: %v", err)
+	}
+	return specs, nil
+}
+
+// PurgeDeleted permanently removes the specs soft-deleted before cutoff and releases their
+// content blobs. It returns the number of specs removed.
+func (r *OpenAPISpecRepository) PurgeDeleted(cutoff time.Time) (int, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted openapi specs: %v", err)
+	}
+	defer tx.Rollback()
+
+	purged, err := purgeSpecs(tx, `deleted_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted openapi specs: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to purge deleted openapi specs: %v", err)
+	}
+	return purged, nil
+}
+
+// purgeSpecs permanently removes the soft-deleted specs matching a condition and releases
+// their content blobs
+func purgeSpecs(tx *sql.Tx, condition string, args ...any) (int, error) {
+	rows, err := tx.Query(`DELETE FROM openapi_specs WHERE deleted_at IS NOT NULL AND `+condition+` RETURNING content_hash`, args...)
+	if err != nil {
+		return 0, err
+	}
+	var hashes []string
+	purged := 0
+	for rows.Next() {
+		var hash sql.NullString
+		if err := rows.Scan(&hash); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		purged++
+		if hash.Valid {
+			hashes = append(hashes, hash.String)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, hash := range hashes {
+		if err := releaseBlob(tx, hash); err != nil {
+			return 0, err
+		}
+	}
+	return purged, nil
+}
+
 // SetActive sets the is_active status of an OpenAPI spec
 func (r *OpenAPISpecRepository) SetActive(id int, active bool) error {
-	query := `UPDATE openapi_specs SET is_active = $2, updated_at = NOW() WHERE id = $1`
-
-	result, err := r.db.Exec(query, id, active)
+	query := `UPDATE openapi_specs SET is_active = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+
+	var result sql.Result
+	err := withRetry(r.db, "SetActive", func() error {
+		var err error
+		result, err = r.db.Exec(query, id, active)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to set active status: %v", err)
 	}
@@ -301,11 +491,202 @@ func (r *OpenAPISpecRepository) SetActive(id int, active bool) error {
 	return nil
 }
 
+// UpdateFeatureFlags sets the feature flags of an OpenAPI spec; nil clears them
+func (r *OpenAPISpecRepository) UpdateFeatureFlags(id int, featureFlags *string) error {
+	query := `UPDATE openapi_specs SET feature_flags = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+
+	var result sql.Result
+	err := withRetry(r.db, "UpdateFeatureFlags", func() error {
+		var err error
+		result, err = r.db.Exec(query, id, featureFlags)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update feature flags: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("openapi spec with id %d not found", id)
+	}
+
+	return nil
+}
+
+// UpdateAliases sets the endpoint aliases of an OpenAPI spec; nil clears them
+func (r *OpenAPISpecRepository) UpdateAliases(id int, aliases *string) error {
+	query := `UPDATE openapi_specs SET aliases = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+
+	var result sql.Result
+	err := withRetry(r.db, "UpdateAliases", func() error {
+		var err error
+		result, err = r.db.Exec(query, id, aliases)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update aliases: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("openapi spec with id %d not found", id)
+	}
+
+	return nil
+}
+
+// UpdateRateLimit sets the rate limit of an OpenAPI spec's endpoint; nil uses the default
+func (r *OpenAPISpecRepository) UpdateRateLimit(id int, rateLimit *string) error {
+	query := `UPDATE openapi_specs SET rate_limit = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+
+	var result sql.Result
+	err := withRetry(r.db, "UpdateRateLimit", func() error {
+		var err error
+		result, err = r.db.Exec(query, id, rateLimit)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update rate_limit: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("openapi spec with id %d not found", id)
+	}
+
+	return nil
+}
+
+// UpdateToolRules sets the include/exclude rules selecting the operations of an OpenAPI spec
+// that become tools; nil exposes all of them
+func (r *OpenAPISpecRepository) UpdateToolRules(id int, toolRules *string) error {
+	query := `UPDATE openapi_specs SET tool_rules = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+
+	var result sql.Result
+	err := withRetry(r.db, "UpdateToolRules", func() error {
+		var err error
+		result, err = r.db.Exec(query, id, toolRules)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update tool_rules: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("openapi spec with id %d not found", id)
+	}
+
+	return nil
+}
+
+// UpdateUpstreamPolicy sets the timeout, retry and circuit breaker settings of the upstream
+// calls of an OpenAPI spec; nil uses the defaults
+func (r *OpenAPISpecRepository) UpdateUpstreamPolicy(id int, upstreamPolicy *string) error {
+	query := `UPDATE openapi_specs SET upstream_policy = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+
+	var result sql.Result
+	err := withRetry(r.db, "UpdateUpstreamPolicy", func() error {
+		var err error
+		result, err = r.db.Exec(query, id, upstreamPolicy)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update upstream_policy: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("openapi spec with id %d not found", id)
+	}
+
+	return nil
+}
+
+// UpdateCredentialStrategy sets how the calls of an OpenAPI spec spread over its API keys;
+// nil uses round-robin
+func (r *OpenAPISpecRepository) UpdateCredentialStrategy(id int, credentialStrategy *string) error {
+	query := `UPDATE openapi_specs SET credential_strategy = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+
+	var result sql.Result
+	err := withRetry(r.db, "UpdateCredentialStrategy", func() error {
+		var err error
+		result, err = r.db.Exec(query, id, credentialStrategy)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update credential_strategy: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("openapi spec with id %d not found", id)
+	}
+
+	return nil
+}
+
+// UpdateRequireToken sets whether an OpenAPI spec is mounted without tools while it has no
+// credentials
+func (r *OpenAPISpecRepository) UpdateRequireToken(id int, require bool) error {
+	query := `UPDATE openapi_specs SET require_token_to_activate = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+
+	var result sql.Result
+	err := withRetry(r.db, "UpdateRequireToken", func() error {
+		var err error
+		result, err = r.db.Exec(query, id, require)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update require_token_to_activate: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("openapi spec with id %d not found", id)
+	}
+
+	return nil
+}
+
 // UpdateApiKeyToken updates the API key token for an OpenAPI spec
 func (r *OpenAPISpecRepository) UpdateApiKeyToken(id int, apiKeyToken *string) error {
-	query := `UPDATE openapi_specs SET api_key_token = $2, updated_at = NOW() WHERE id = $1`
-
-	result, err := r.db.Exec(query, id, apiKeyToken)
+	query := `UPDATE openapi_specs SET api_key_token = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+
+	var result sql.Result
+	err := withRetry(r.db, "UpdateApiKeyToken", func() error {
+		var err error
+		result, err = r.db.Exec(query, id, sealedToken{apiKeyToken, specTokenColumn, id})
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to update API key token: %v", err)
 	}
@@ -321,3 +702,70 @@ func (r *OpenAPISpecRepository) UpdateApiKeyToken(id int, apiKeyToken *string) e
 
 	return nil
 }
+
+// listSpecs runs a query returning specs on the replica, if any, and on the primary
+// when the replica fails
+func (r *OpenAPISpecRepository) listSpecs(op, query string) ([]*models.OpenAPISpec, error) {
+	if r.replica != nil {
+		var specs []*models.OpenAPISpec
+		err := withRetry(r.replica, op+" (replica)", func() error {
+			var err error
+			specs, err = querySpecs(r.replica, query)
+			return err
+		})
+		if err == nil {
+			return specs, nil
+		}
+		log.Printf("[WARN] %s failed on the read replica, falling back to primary: %v", op, err)
+	}
+
+	var specs []*models.OpenAPISpec
+	err := withRetry(r.db, op, func() error {
+		var err error
+		specs, err = querySpecs(r.db, query)
+		return err
+	})
+	return specs, err
+}
+
+// querySpecs runs a query returning specs and scans all rows
+func querySpecs(db *sql.DB, query string) ([]*models.OpenAPISpec, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var specs []*models.OpenAPISpec
+	for rows.Next() {
+		spec := &models.OpenAPISpec{}
+		err := rows.Scan(
+			&spec.ID,
+			&spec.Name,
+			&spec.Title,
+			&spec.Version,
+			&spec.SpecContent,
+			&spec.EndpointPath,
+			&spec.FileFormat,
+			&spec.FileSize,
+			openedToken{&spec.ApiKeyToken, specTokenColumn, &spec.ID},
+			&spec.IsActive,
+			&spec.CreatedAt,
+			&spec.UpdatedAt,
+			&spec.ContentHash,
+			&spec.FeatureFlags,
+			&spec.Aliases,
+			&spec.DeletedAt,
+			&spec.RequireToken,
+			&spec.RateLimit,
+			&spec.ToolRules,
+			&spec.UpstreamPolicy,
+			&spec.CredentialStrategy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan openapi spec: %w", err)
+		}
+		specs = append(specs, spec)
+	}
+	return specs, rows.Err()
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/ubermorgenland/openapi-mcp/pkg/database"
+)
+
+// Retry policy for transient connection errors, e.g. during a Postgres failover.
+// DB_RETRY_ATTEMPTS overrides the number of attempts (1 disables retries).
+const (
+	defaultRetryAttempts = 3
+	retryBaseDelay       = 100 * time.Millisecond
+	retryMaxDelay        = 2 * time.Second
+)
+
+// retryAttempts returns the configured number of attempts per statement
+func retryAttempts() int {
+	if v := os.Getenv("DB_RETRY_ATTEMPTS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 1 {
+			return n
+		}
+	}
+	return defaultRetryAttempts
+}
+
+// withRetry runs fn until it succeeds, fails with a non-transient error, or runs out of
+// attempts, backing off exponentially between attempts. On the first connection error, the
+// idle connections of db are dropped and fn is retried at once: after an idle period they
+// have often all been closed by the server or a firewall, and would each fail an attempt.
+// Only use it for statements that are safe to run again: reads, idempotent updates and
+// opening transactions.
+func withRetry(db *sql.DB, op string, fn func() error) error {
+	attempts := retryAttempts()
+	delay := retryBaseDelay
+	reconnected := false
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = fn(); err == nil || !isTransientError(err) {
+			return err
+		}
+		if !reconnected && db != nil {
+			reconnected = true
+			log.Printf("[WARN] Database connection error in %s, reconnecting: %v", op, err)
+			database.ResetIdleConnections(db)
+			if err = fn(); err == nil || !isTransientError(err) {
+				return err
+			}
+		}
+		if attempt < attempts {
+			log.Printf("[WARN] Transient database error in %s (attempt %d/%d), retrying in %v: %v", op, attempt, attempts, delay, err)
+			time.Sleep(delay)
+			delay *= 2
+			if delay > retryMaxDelay {
+				delay = retryMaxDelay
+			}
+		}
+	}
+	return err
+}
+
+// isTransientError reports whether err is a connection-level failure that may succeed on retry
+func isTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// Class 08 is connection exceptions; 57P01-57P03 are shutdowns and startups during failover
+		return pqErr.Code.Class() == "08" || pqErr.Code == "57P01" || pqErr.Code == "57P02" || pqErr.Code == "57P03"
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "connection refused") || strings.Contains(msg, "connection reset") ||
+		strings.Contains(msg, "broken pipe") || strings.Contains(msg, "bad connection")
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+)
+
+// ContentHash returns the spec_blobs key for spec content: the hex SHA-256 of its bytes
+func ContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// acquireBlob stores content in spec_blobs, or takes another reference to the
+// existing blob with the same content, and returns its hash
+func acquireBlob(tx *sql.Tx, content string) (string, error) {
+	hash := ContentHash(content)
+	query := `
+		INSERT INTO spec_blobs (hash, content, size, ref_count)
+		VALUES ($1, $2, $3, 1)
+		ON CONFLICT (hash) DO UPDATE SET ref_count = spec_blobs.ref_count + 1
+	`
+	if _, err := tx.Exec(query, hash, content, len(content)); err != nil {
+		return "", fmt.Errorf("failed to store spec content: %v", err)
+	}
+	return hash, nil
+}
+
+// releaseBlob drops a reference to a blob and deletes it when it is no longer referenced
+func releaseBlob(tx *sql.Tx, hash string) error {
+	if _, err := tx.Exec(`UPDATE spec_blobs SET ref_count = ref_count - 1 WHERE hash = $1`, hash); err != nil {
+		return fmt.Errorf("failed to release spec content: %v", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM spec_blobs WHERE hash = $1 AND ref_count <= 0`, hash); err != nil {
+		return fmt.Errorf("failed to delete spec content: %v", err)
+	}
+	return nil
+}
+
+// BlobStats summarizes content deduplication
+type BlobStats struct {
+	Blobs      int   `json:"blobs"`
+	Specs      int   `json:"specs"`
+	StoredSize int64 `json:"stored_bytes"`
+	SavedSize  int64 `json:"saved_bytes"`
+}
+
+// BlobStats reports how many distinct contents are stored and how many bytes deduplication saves
+func (r *OpenAPISpecRepository) BlobStats() (*BlobStats, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(ref_count), 0), COALESCE(SUM(size), 0), COALESCE(SUM(size::BIGINT * (ref_count - 1)), 0)
+		FROM spec_blobs
+	`
+	stats := &BlobStats{}
+	err := withRetry(r.db, "BlobStats", func() error {
+		return r.db.QueryRow(query).Scan(&stats.Blobs, &stats.Specs, &stats.StoredSize, &stats.SavedSize)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spec blob stats: %v", err)
+	}
+	return stats, nil
+}
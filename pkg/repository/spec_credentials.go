@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+)
+
+// SpecCredentialRepository handles database operations for the API keys of specs
+type SpecCredentialRepository struct {
+	db *sql.DB
+}
+
+// NewSpecCredentialRepository creates a new repository instance
+func NewSpecCredentialRepository(db *sql.DB) *SpecCredentialRepository {
+	return &SpecCredentialRepository{db: db}
+}
+
+const specCredentialColumns = `id, spec_id, label, token, created_at`
+
+// Create adds an API key to a spec that is not deleted
+func (r *SpecCredentialRepository) Create(cred *models.SpecCredential) (*models.SpecCredential, error) {
+	// The ID is taken first, as the token is encrypted bound to it
+	var id int
+	if err := r.db.QueryRow(`SELECT nextval(pg_get_serial_sequence('spec_credentials', 'id'))`).Scan(&id); err != nil {
+		return nil, fmt.Errorf("failed to create spec credential: %v", err)
+	}
+	query := `
+		INSERT INTO spec_credentials (id, spec_id, label, token)
+		SELECT $4, id, $2, $3 FROM openapi_specs WHERE id = $1 AND deleted_at IS NULL
+		RETURNING created_at
+	`
+	// Not retried: the insert is not idempotent
+	err := r.db.QueryRow(query, cred.SpecID, cred.Label, sealedToken{&cred.Token, credentialTokenColumn, id}, id).Scan(&cred.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("openapi spec with id %d not found", cred.SpecID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spec credential: %v", err)
+	}
+	cred.ID = id
+	return cred, nil
+}
+
+// ListBySpec returns the API keys of a spec in the order they were added
+func (r *SpecCredentialRepository) ListBySpec(specID int) ([]*models.SpecCredential, error) {
+	query := `SELECT ` + specCredentialColumns + ` FROM spec_credentials WHERE spec_id = $1 ORDER BY id`
+	var creds []*models.SpecCredential
+	err := withRetry(r.db, "ListBySpec", func() error {
+		rows, err := r.db.Query(query, specID)
+		if err != nil {
+			return err
+		}
+		creds, err = scanSpecCredentials(rows)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list spec credentials: %v", err)
+	}
+	return creds, nil
+}
+
+// GetAll returns the API keys of all specs, grouped by spec ID, in the order they were added
+func (r *SpecCredentialRepository) GetAll() (map[int][]*models.SpecCredential, error) {
+	query := `SELECT ` + specCredentialColumns + ` FROM spec_credentials ORDER BY spec_id, id`
+	var creds []*models.SpecCredential
+	err := withRetry(r.db, "GetAll", func() error {
+		rows, err := r.db.Query(query)
+		if err != nil {
+			return err
+		}
+		creds, err = scanSpecCredentials(rows)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spec credentials: %v", err)
+	}
+	bySpec := map[int][]*models.SpecCredential{}
+	for _, cred := range creds {
+		bySpec[cred.SpecID] = append(bySpec[cred.SpecID], cred)
+	}
+	return bySpec, nil
+}
+
+// Delete removes an API key by ID
+func (r *SpecCredentialRepository) Delete(id int) error {
+	var result sql.Result
+	err := withRetry(r.db, "Delete", func() error {
+		var err error
+		result, err = r.db.Exec(`DELETE FROM spec_credentials WHERE id = $1`, id)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete spec credential: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("spec credential with id %d not found", id)
+	}
+
+	return nil
+}
+
+func scanSpecCredentials(rows *sql.Rows) ([]*models.SpecCredential, error) {
+	defer rows.Close()
+	var creds []*models.SpecCredential
+	for rows.Next() {
+		cred := &models.SpecCredential{}
+		if err := rows.Scan(
+			&cred.ID,
+			&cred.SpecID,
+			&cred.Label,
+			openedToken{&cred.Token, credentialTokenColumn, &cred.ID},
+			&cred.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		creds = append(creds, cred)
+	}
+	return creds, rows.Err()
+}
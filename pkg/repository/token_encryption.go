@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/secrets"
+)
+
+// The token columns, which with the row ID make the associated data tokens are encrypted
+// with: an encrypted token copied to another row or column no longer decrypts.
+const (
+	specTokenColumn       = "openapi_specs.api_key_token"
+	credentialTokenColumn = "spec_credentials.token"
+)
+
+// tokenAAD returns the associated data binding a token to its row
+func tokenAAD(column string, id int) string {
+	return column + ":" + strconv.Itoa(id)
+}
+
+// sealedToken writes a token encrypted with MCP_TOKEN_ENCRYPTION_KEY, or in plaintext when
+// no key is set. A key that is set but invalid fails the write rather than storing plaintext.
+type sealedToken struct {
+	token  *string
+	column string
+	id     int
+}
+
+// Value implements driver.Valuer
+func (t sealedToken) Value() (driver.Value, error) {
+	if t.token == nil {
+		return nil, nil
+	}
+	cipher, err := secrets.DefaultTokenCipher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt token: %v", err)
+	}
+	return cipher.Encrypt(*t.token, tokenAAD(t.column, t.id))
+}
+
+// openedToken reads a token into a *string or a **string, decrypting it when it is encrypted.
+// id points to the row ID, which the row scans before the token.
+type openedToken struct {
+	dest   any
+	column string
+	id     *int
+}
+
+// Scan implements sql.Scanner
+func (t openedToken) Scan(src any) error {
+	var stored sql.NullString
+	if err := stored.Scan(src); err != nil {
+		return err
+	}
+	token := stored.String
+	if secrets.IsEncrypted(token) {
+		cipher, err := secrets.DefaultTokenCipher()
+		if err == nil {
+			token, err = cipher.Decrypt(token, tokenAAD(t.column, *t.id))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to decrypt token: %v", err)
+		}
+	}
+	switch dest := t.dest.(type) {
+	case **string:
+		if !stored.Valid {
+			*dest = nil
+			return nil
+		}
+		*dest = &token
+	case *string:
+		*dest = token
+	default:
+		return fmt.Errorf("unsupported token destination %T", t.dest)
+	}
+	return nil
+}
+
+// TokenEncryptionResult counts the tokens EncryptTokens encrypted
+type TokenEncryptionResult struct {
+	SpecTokens       int // api_key_token values of openapi_specs
+	CredentialTokens int // token values of spec_credentials
+}
+
+// EncryptTokens encrypts the tokens stored in plaintext or with a previous key with the
+// current MCP_TOKEN_ENCRYPTION_KEY, in openapi_specs (deleted specs included) and
+// spec_credentials, in one transaction. With dryRun it only counts them.
+func (r *OpenAPISpecRepository) EncryptTokens(dryRun bool) (*TokenEncryptionResult, error) {
+	cipher, err := secrets.DefaultTokenCipher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt tokens: %v", err)
+	}
+	if cipher == nil {
+		return nil, fmt.Errorf("failed to encrypt tokens: MCP_TOKEN_ENCRYPTION_KEY is not set")
+	}
+
+	tx, err := r.begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt tokens: %v", err)
+	}
+	defer tx.Rollback()
+
+	result := &TokenEncryptionResult{}
+	result.SpecTokens, err = encryptColumn(tx, cipher, specTokenColumn, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	result.CredentialTokens, err = encryptColumn(tx, cipher, credentialTokenColumn, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return result, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit token encryption: %v", err)
+	}
+	return result, nil
+}
+
+// encryptColumn encrypts the token column (table.column) of a table where it is not
+// encrypted with the current key, and returns the number of rows that needed it
+func encryptColumn(tx *sql.Tx, cipher *secrets.TokenCipher, tokenColumn string, dryRun bool) (int, error) {
+	table, column, _ := strings.Cut(tokenColumn, ".")
+	rows, err := tx.Query(`SELECT id, ` + column + ` FROM ` + table + ` WHERE ` + column + ` IS NOT NULL AND ` + column + ` <> '' ORDER BY id FOR UPDATE`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %v", tokenColumn, err)
+	}
+	stale := map[int]string{}
+	var ids []int
+	for rows.Next() {
+		var id int
+		var stored string
+		if err := rows.Scan(&id, &stored); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to read %s: %v", tokenColumn, err)
+		}
+		if !cipher.Current(stored) {
+			stale[id] = stored
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read %s: %v", tokenColumn, err)
+	}
+
+	for _, id := range ids {
+		sealed, err := reencryptToken(cipher, stale[id], tokenAAD(tokenColumn, id))
+		if err != nil {
+			return 0, fmt.Errorf("%s %d: %v", table, id, err)
+		}
+		if dryRun {
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE `+table+` SET `+column+` = $2 WHERE id = $1`, id, sealed); err != nil {
+			return 0, fmt.Errorf("failed to update %s %d: %v", table, id, err)
+		}
+	}
+	return len(ids), nil
+}
+
+// reencryptToken returns a stored token, plaintext or encrypted with a previous key, encrypted
+// with the current key of cipher
+func reencryptToken(cipher *secrets.TokenCipher, stored, aad string) (string, error) {
+	token, err := cipher.Decrypt(stored, aad)
+	if err != nil {
+		return "", err
+	}
+	return cipher.Encrypt(token, aad)
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/secrets"
+)
+
+func TestReencryptToken(t *testing.T) {
+	old, _ := secrets.NewTokenCipher(bytes.Repeat([]byte{1}, 32))
+	current, err := secrets.NewTokenCipher(bytes.Repeat([]byte{2}, 32), bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatalf("NewTokenCipher: %v", err)
+	}
+	aad := tokenAAD(specTokenColumn, 3)
+	if aad != "openapi_specs.api_key_token:3" {
+		t.Errorf("unexpected associated data %q", aad)
+	}
+
+	previous, _ := old.Encrypt("secret-token", aad)
+	for _, stored := range []string{"secret-token", previous} {
+		sealed, err := reencryptToken(current, stored, aad)
+		if err != nil {
+			t.Fatalf("reencryptToken(%q): %v", stored, err)
+		}
+		if !current.Current(sealed) {
+			t.Errorf("expected %q to be encrypted with the current key, got %q", stored, sealed)
+		}
+		if token, err := current.Decrypt(sealed, aad); err != nil || token != "secret-token" {
+			t.Errorf("expected the token back, got %q, %v", token, err)
+		}
+	}
+
+	if _, err := reencryptToken(current, previous, tokenAAD(specTokenColumn, 4)); err == nil {
+		t.Errorf("expected a token moved to another row not to be re-encrypted")
+	}
+	unknown, _ := secrets.NewTokenCipher(bytes.Repeat([]byte{3}, 32))
+	foreign, _ := unknown.Encrypt("secret-token", aad)
+	if _, err := reencryptToken(current, foreign, aad); err == nil {
+		t.Errorf("expected a token under an unknown key to fail")
+	}
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+)
+
+// ToolCallRepository handles database operations for the tool call journal
+type ToolCallRepository struct {
+	db *sql.DB
+}
+
+// NewToolCallRepository creates a new repository instance
+func NewToolCallRepository(db *sql.DB) *ToolCallRepository {
+	return &ToolCallRepository{db: db}
+}
+
+const toolCallColumns = `id, endpoint, tool, session_id, arg_names, status, error, host, pid, accepted_at, finished_at`
+
+// Accept records a call as accepted and returns its ID. It is not retried, as a retried
+// insert could journal the call twice.
+func (r *ToolCallRepository) Accept(call *models.ToolCall) (int64, error) {
+	query := `
+		INSERT INTO tool_call_journal (endpoint, tool, session_id, arg_names, status, host, pid)
+		VALUES ($1, $2, $3, $4, 'accepted', $5, $6)
+		RETURNING id, accepted_at
+	`
+	err := r.db.QueryRow(query, call.Endpoint, call.Tool, call.SessionID, call.ArgNames, call.Host, call.Pid).Scan(&call.ID, &call.AcceptedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to journal tool call: %v", err)
+	}
+	call.Status = "accepted"
+	return call.ID, nil
+}
+
+// Finish records the outcome of an accepted call
+func (r *ToolCallRepository) Finish(id int64, status string, errMsg *string) error {
+	query := `UPDATE tool_call_journal SET status = $2, error = $3, finished_at = NOW() WHERE id = $1 AND status = 'accepted'`
+	err := withRetry(r.db, "Finish", func() error {
+		_, err := r.db.Exec(query, id, status, errMsg)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to journal tool call outcome: %v", err)
+	}
+	return nil
+}
+
+// Interrupt marks the unfinished calls of a server instance as interrupted and returns them.
+// With pid 0, the calls of every earlier process on the host are marked (after a restart);
+// otherwise only those of that process (at shutdown). Calls accepted before staleBefore are
+// marked whatever instance accepted them, as that instance is gone for good.
+func (r *ToolCallRepository) Interrupt(host string, pid int, staleBefore time.Time, reason string) ([]*models.ToolCall, error) {
+	query := `
+		UPDATE tool_call_journal SET status = 'interrupted', error = $4, finished_at = NOW()
+		WHERE status = 'accepted' AND ((host = $1 AND ($2 = 0 OR pid = $2)) OR accepted_at < $3)
+		RETURNING ` + toolCallColumns
+	var calls []*models.ToolCall
+	err := withRetry(r.db, "Interrupt", func() error {
+		rows, err := r.db.Query(query, host, pid, staleBefore, reason)
+		if err != nil {
+			return err
+		}
+		calls, err = scanToolCalls(rows)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark interrupted tool calls: %v", err)
+	}
+	return calls, nil
+}
+
+// List returns the most recent journaled calls, optionally only those with a status
+func (r *ToolCallRepository) List(status string, limit int) ([]*models.ToolCall, error) {
+	query := `
+		SELECT ` + toolCallColumns + `
+		FROM tool_call_journal
+		WHERE $1 = '' OR status = $1
+		ORDER BY accepted_at DESC
+		LIMIT $2
+	`
+	var calls []*models.ToolCall
+	err := withRetry(r.db, "List", func() error {
+		rows, err := r.db.Query(query, status, limit)
+		if err != nil {
+			return err
+		}
+		calls, err = scanToolCalls(rows)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tool calls: %v", err)
+	}
+	return calls, nil
+}
+
+// Prune deletes finished calls accepted before the given time
+func (r *ToolCallRepository) Prune(before time.Time) (int64, error) {
+	var result sql.Result
+	err := withRetry(r.db, "Prune", func() error {
+		var err error
+		result, err = r.db.Exec(`DELETE FROM tool_call_journal WHERE status <> 'accepted' AND accepted_at < $1`, before)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune tool call journal: %v", err)
+	}
+	return result.RowsAffected()
+}
+
+func scanToolCalls(rows *sql.Rows) ([]*models.ToolCall, error) {
+	defer rows.Close()
+	var calls []*models.ToolCall
+	for rows.Next() {
+		call := &models.ToolCall{}
+		if err := rows.Scan(
+			&call.ID,
+			&call.Endpoint,
+			&call.Tool,
+			&call.SessionID,
+			&call.ArgNames,
+			&call.Status,
+			&call.Error,
+			&call.Host,
+			&call.Pid,
+			&call.AcceptedAt,
+			&call.FinishedAt,
+		); err != nil {
+			return nil, err
+		}
+		calls = append(calls, call)
+	}
+	return calls, rows.Err()
+}
@@ -0,0 +1,99 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/awssig"
+)
+
+// AWSSecretsManagerProvider reads secrets from AWS Secrets Manager with GetSecretValue.
+// References look like aws-sm://prod/petstore or aws-sm://prod/petstore#api_key, where the
+// key selects a field of a JSON secret. Credentials come from the standard AWS_* variables.
+type AWSSecretsManagerProvider struct {
+	Region          string // AWS_REGION or AWS_DEFAULT_REGION
+	AccessKeyID     string // AWS_ACCESS_KEY_ID
+	SecretAccessKey string // AWS_SECRET_ACCESS_KEY
+	SessionToken    string // AWS_SESSION_TOKEN, optional
+	Endpoint        string // AWS_SECRETSMANAGER_ENDPOINT, optional (e.g. LocalStack)
+	Client          *http.Client
+}
+
+// NewAWSSecretsManagerProvider configures the provider from the environment.
+func NewAWSSecretsManagerProvider() *AWSSecretsManagerProvider {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	return &AWSSecretsManagerProvider{
+		Region:          region,
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		Endpoint:        os.Getenv("AWS_SECRETSMANAGER_ENDPOINT"),
+		Client:          &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Fetch reads a secret string from Secrets Manager.
+func (p *AWSSecretsManagerProvider) Fetch(ctx context.Context, ref Reference) (string, error) {
+	if p.Region == "" || p.AccessKeyID == "" || p.SecretAccessKey == "" {
+		return "", fmt.Errorf("aws secrets manager is not configured: set AWS_REGION, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + p.Region + ".amazonaws.com"
+	}
+	payload, _ := json.Marshal(map[string]string{"SecretId": ref.Path})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	p.sign(req, "secretsmanager", payload, time.Now().UTC())
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		var awsErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(body, &awsErr)
+		return "", fmt.Errorf("secrets manager returned HTTP %d %s %s", resp.StatusCode, awsErr.Type, awsErr.Message)
+	}
+	var out struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return "", fmt.Errorf("invalid secrets manager response: %w", err)
+	}
+	if out.SecretString == "" {
+		return "", fmt.Errorf("secret %s has no SecretString (binary secrets are not supported)", ref.Path)
+	}
+	return selectKey(out.SecretString, ref.Key)
+}
+
+// sign adds an AWS Signature Version 4 Authorization header for an AWS service, e.g. secretsmanager.
+func (p *AWSSecretsManagerProvider) sign(req *http.Request, service string, payload []byte, now time.Time) {
+	awssig.Signer{
+		AccessKeyID:     p.AccessKeyID,
+		SecretAccessKey: p.SecretAccessKey,
+		SessionToken:    p.SessionToken,
+		Region:          p.Region,
+		Service:         service,
+		ContentSHA256:   true,
+	}.Sign(req, payload, now)
+}
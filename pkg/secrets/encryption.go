@@ -0,0 +1,200 @@
+package secrets
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// encryptedTokenPrefix starts tokens encrypted at rest: enc:v1:<key ID>:<base64 nonce and
+// ciphertext>. Stored values without it are plaintext.
+const encryptedTokenPrefix = "enc:v1:"
+
+// TokenCipher encrypts the tokens stored in the database with AES-256-GCM, so the database
+// never holds them in plaintext. It decrypts tokens encrypted with its current key or one of
+// its previous keys, which lets keys be rotated.
+type TokenCipher struct {
+	keyID string
+	aead  cipher.AEAD
+	keys  map[string]cipher.AEAD // key ID -> AEAD of the current and previous keys
+}
+
+// NewTokenCipher creates a cipher encrypting with key and decrypting with key and the
+// previous keys. Keys are 32 bytes.
+func NewTokenCipher(key []byte, previous ...[]byte) (*TokenCipher, error) {
+	c := &TokenCipher{keys: map[string]cipher.AEAD{}}
+	for i, k := range append([][]byte{key}, previous...) {
+		if len(k) != 32 {
+			return nil, fmt.Errorf("token encryption keys are 32 bytes, got %d", len(k))
+		}
+		block, err := aes.NewCipher(k)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		id := keyID(k)
+		if i == 0 {
+			c.keyID, c.aead = id, aead
+		}
+		if _, ok := c.keys[id]; !ok {
+			c.keys[id] = aead
+		}
+	}
+	return c, nil
+}
+
+// keyID identifies a key in the tokens it encrypted without revealing it.
+func keyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4])
+}
+
+// IsEncrypted reports whether a stored token is encrypted.
+func IsEncrypted(stored string) bool {
+	return strings.HasPrefix(stored, encryptedTokenPrefix)
+}
+
+// Encrypt returns the stored form of a token, encrypted with the current key. aad names where
+// the token is stored, e.g. the table, column and row ID: the token only decrypts with the
+// same aad, so a stored token copied to another row is rejected. A nil cipher, used when no
+// key is configured, returns the token unchanged, unless it would read as encrypted. Empty
+// tokens are returned as they are.
+func (c *TokenCipher) Encrypt(token, aad string) (string, error) {
+	if token == "" {
+		return token, nil
+	}
+	if c == nil {
+		if IsEncrypted(token) {
+			return "", fmt.Errorf("tokens starting with %q can only be stored with MCP_TOKEN_ENCRYPTION_KEY set", encryptedTokenPrefix)
+		}
+		return token, nil
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to encrypt token: %v", err)
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(token), []byte(aad))
+	return encryptedTokenPrefix + c.keyID + ":" + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns the token a stored value holds: the decrypted token when it is encrypted
+// with the same aad, and the value itself when it is plaintext.
+func (c *TokenCipher) Decrypt(stored, aad string) (string, error) {
+	if !IsEncrypted(stored) {
+		return stored, nil
+	}
+	if c == nil {
+		return "", fmt.Errorf("token is encrypted, but MCP_TOKEN_ENCRYPTION_KEY is not set")
+	}
+	id, data, ok := strings.Cut(strings.TrimPrefix(stored, encryptedTokenPrefix), ":")
+	if !ok {
+		return "", fmt.Errorf("malformed encrypted token")
+	}
+	aead := c.keys[id]
+	if aead == nil {
+		return "", fmt.Errorf("token is encrypted with key %s, which is neither MCP_TOKEN_ENCRYPTION_KEY nor one of MCP_TOKEN_ENCRYPTION_PREVIOUS_KEYS", id)
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted token")
+	}
+	token, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(aad))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt token with key %s: %v", id, err)
+	}
+	return string(token), nil
+}
+
+// Current reports whether a stored token is encrypted with the current key, so it does not
+// need to be encrypted again.
+func (c *TokenCipher) Current(stored string) bool {
+	if c == nil {
+		return !IsEncrypted(stored)
+	}
+	return strings.HasPrefix(stored, encryptedTokenPrefix+c.keyID+":")
+}
+
+// ParseEncryptionKey reads a token encryption key: 32 bytes in base64 or hex, e.g. from
+// openssl rand -base64 32, or 32 raw bytes, e.g. a data key decrypted by aws-kms://.
+func ParseEncryptionKey(value string) ([]byte, error) {
+	if len(value) == 32 {
+		return []byte(value), nil
+	}
+	value = strings.TrimSpace(value)
+	if key, err := hex.DecodeString(value); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if key, err := enc.DecodeString(value); err == nil && len(key) == 32 {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("expected 32 bytes in base64 or hex, e.g. from openssl rand -base64 32")
+}
+
+// TokenCipherFromEnv creates the cipher of MCP_TOKEN_ENCRYPTION_KEY, which also decrypts
+// with the comma-separated MCP_TOKEN_ENCRYPTION_PREVIOUS_KEYS. Keys may be secret references
+// such as aws-kms://<ciphertext> or vault://secret/data/mcp#token_key. It returns nil
+// without a key, in which case tokens are stored in plaintext.
+func TokenCipherFromEnv(ctx context.Context) (*TokenCipher, error) {
+	value := strings.TrimSpace(os.Getenv("MCP_TOKEN_ENCRYPTION_KEY"))
+	if value == "" {
+		return nil, nil
+	}
+	key, err := resolveEncryptionKey(ctx, value)
+	if err != nil {
+		return nil, fmt.Errorf("MCP_TOKEN_ENCRYPTION_KEY: %v", err)
+	}
+	var previous [][]byte
+	for _, v := range strings.Split(os.Getenv("MCP_TOKEN_ENCRYPTION_PREVIOUS_KEYS"), ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		k, err := resolveEncryptionKey(ctx, v)
+		if err != nil {
+			return nil, fmt.Errorf("MCP_TOKEN_ENCRYPTION_PREVIOUS_KEYS: %v", err)
+		}
+		previous = append(previous, k)
+	}
+	return NewTokenCipher(key, previous...)
+}
+
+// resolveEncryptionKey parses a key, fetching it from the secret manager when it is a reference.
+func resolveEncryptionKey(ctx context.Context, value string) ([]byte, error) {
+	if Default().IsReference(value) {
+		resolved, err := Default().Resolve(ctx, value)
+		if err != nil {
+			return nil, err
+		}
+		value = resolved
+	}
+	return ParseEncryptionKey(value)
+}
+
+var (
+	defaultTokenCipher     *TokenCipher
+	defaultTokenCipherErr  error
+	defaultTokenCipherOnce sync.Once
+)
+
+// DefaultTokenCipher returns the process-wide cipher of TokenCipherFromEnv, created on first use.
+func DefaultTokenCipher() (*TokenCipher, error) {
+	defaultTokenCipherOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		defaultTokenCipher, defaultTokenCipherErr = TokenCipherFromEnv(ctx)
+	})
+	return defaultTokenCipher, defaultTokenCipherErr
+}
@@ -0,0 +1,134 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func TestTokenCipher_RoundTrip(t *testing.T) {
+	c, err := NewTokenCipher(testKey(1))
+	if err != nil {
+		t.Fatalf("NewTokenCipher: %v", err)
+	}
+	const aad = "openapi_specs.api_key_token:1"
+	sealed, err := c.Encrypt("secret-token", aad)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if !IsEncrypted(sealed) || strings.Contains(sealed, "secret-token") || !c.Current(sealed) {
+		t.Fatalf("expected an encrypted token under the current key, got %q", sealed)
+	}
+	if again, _ := c.Encrypt("secret-token", aad); again == sealed {
+		t.Errorf("expected a fresh nonce for every encryption")
+	}
+	if token, err := c.Decrypt(sealed, aad); err != nil || token != "secret-token" {
+		t.Errorf("expected the token back, got %q, %v", token, err)
+	}
+	if token, err := c.Decrypt("plain-token", aad); err != nil || token != "plain-token" {
+		t.Errorf("expected a plaintext token to be read as it is, got %q, %v", token, err)
+	}
+	if sealed, err := c.Encrypt("", aad); err != nil || sealed != "" {
+		t.Errorf("expected an empty token to stay empty, got %q, %v", sealed, err)
+	}
+}
+
+func TestTokenCipher_WrongKey(t *testing.T) {
+	c, _ := NewTokenCipher(testKey(1))
+	other, _ := NewTokenCipher(testKey(2))
+	sealed, _ := c.Encrypt("secret-token", "row:1")
+	if _, err := other.Decrypt(sealed, "row:1"); err == nil {
+		t.Errorf("expected a token encrypted with an unknown key not to decrypt")
+	}
+	var none *TokenCipher
+	if _, err := none.Decrypt(sealed, "row:1"); err == nil {
+		t.Errorf("expected an encrypted token not to decrypt without a key")
+	}
+}
+
+func TestTokenCipher_Rotation(t *testing.T) {
+	old, _ := NewTokenCipher(testKey(1))
+	sealed, _ := old.Encrypt("secret-token", "row:1")
+
+	rotated, err := NewTokenCipher(testKey(2), testKey(1))
+	if err != nil {
+		t.Fatalf("NewTokenCipher: %v", err)
+	}
+	if rotated.Current(sealed) {
+		t.Errorf("expected a token under the previous key not to be current")
+	}
+	if token, err := rotated.Decrypt(sealed, "row:1"); err != nil || token != "secret-token" {
+		t.Fatalf("expected the previous key to decrypt, got %q, %v", token, err)
+	}
+	resealed, _ := rotated.Encrypt("secret-token", "row:1")
+	if !rotated.Current(resealed) || old.Current(resealed) {
+		t.Errorf("expected a new token to be encrypted with the new key, got %q", resealed)
+	}
+	if _, err := old.Decrypt(resealed, "row:1"); err == nil {
+		t.Errorf("expected the old key alone not to decrypt a token under the new key")
+	}
+}
+
+func TestTokenCipher_Tamper(t *testing.T) {
+	c, _ := NewTokenCipher(testKey(1))
+	sealed, _ := c.Encrypt("secret-token", "openapi_specs.api_key_token:1")
+
+	prefix, data, _ := strings.Cut(strings.TrimPrefix(sealed, encryptedTokenPrefix), ":")
+	raw, _ := base64.RawURLEncoding.DecodeString(data)
+	raw[len(raw)-1] ^= 1
+	tampered := encryptedTokenPrefix + prefix + ":" + base64.RawURLEncoding.EncodeToString(raw)
+	if _, err := c.Decrypt(tampered, "openapi_specs.api_key_token:1"); err == nil {
+		t.Errorf("expected a modified ciphertext not to decrypt")
+	}
+	if _, err := c.Decrypt(encryptedTokenPrefix+prefix+":!!", "openapi_specs.api_key_token:1"); err == nil {
+		t.Errorf("expected a malformed token not to decrypt")
+	}
+	// A token copied to another row or column does not decrypt either
+	for _, aad := range []string{"openapi_specs.api_key_token:2", "spec_credentials.token:1"} {
+		if _, err := c.Decrypt(sealed, aad); err == nil {
+			t.Errorf("expected the token not to decrypt as %s", aad)
+		}
+	}
+}
+
+func TestTokenCipher_EncryptsPrefixedPlaintext(t *testing.T) {
+	c, _ := NewTokenCipher(testKey(1))
+	sealed, err := c.Encrypt("enc:v1:looks-encrypted", "row:1")
+	if err != nil || sealed == "enc:v1:looks-encrypted" {
+		t.Fatalf("expected a token with the encrypted prefix to be encrypted, got %q, %v", sealed, err)
+	}
+	if token, err := c.Decrypt(sealed, "row:1"); err != nil || token != "enc:v1:looks-encrypted" {
+		t.Errorf("expected the token back, got %q, %v", token, err)
+	}
+
+	var none *TokenCipher
+	if _, err := none.Encrypt("enc:v1:looks-encrypted", "row:1"); err == nil {
+		t.Errorf("expected a token with the encrypted prefix not to be stored in plaintext")
+	}
+	if token, err := none.Encrypt("plain-token", "row:1"); err != nil || token != "plain-token" {
+		t.Errorf("expected a plaintext token without a key, got %q, %v", token, err)
+	}
+}
+
+func TestParseEncryptionKey(t *testing.T) {
+	key := testKey(7)
+	for _, value := range []string{
+		base64.StdEncoding.EncodeToString(key),
+		base64.RawURLEncoding.EncodeToString(key),
+		hex.EncodeToString(key),
+		string(key),
+	} {
+		if got, err := ParseEncryptionKey(value); err != nil || !bytes.Equal(got, key) {
+			t.Errorf("ParseEncryptionKey(%q) = %x, %v", value, got, err)
+		}
+	}
+	if _, err := ParseEncryptionKey("too-short"); err == nil {
+		t.Errorf("expected a short key to be rejected")
+	}
+}
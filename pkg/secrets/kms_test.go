@@ -0,0 +1,37 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAWSKMSProvider_PathPrefixedEndpoint(t *testing.T) {
+	aws := &AWSSecretsManagerProvider{
+		Region:          "eu-west-1",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		Client:          http.DefaultClient,
+	}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/aws/kms/" || r.Header.Get("X-Amz-Target") != "TrentService.Decrypt" {
+			http.Error(w, "unexpected request "+r.URL.Path, http.StatusBadRequest)
+			return
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") || !strings.Contains(r.Header.Get("Authorization"), "/eu-west-1/kms/aws4_request") {
+			http.Error(w, `{"__type":"InvalidSignatureException"}`, http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"Plaintext":"` + base64.StdEncoding.EncodeToString([]byte("data-key")) + `"}`))
+	}))
+	defer upstream.Close()
+
+	p := &AWSKMSProvider{AWS: aws, Endpoint: upstream.URL + "/aws/kms"}
+	got, err := p.Fetch(context.Background(), Reference{Scheme: "aws-kms", Path: "Y2lwaGVydGV4dA=="})
+	if err != nil || got != "data-key" {
+		t.Fatalf("expected the data key from a path-prefixed endpoint, got %q, %v", got, err)
+	}
+}
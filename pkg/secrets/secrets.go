@@ -0,0 +1,184 @@
+// Package secrets resolves references to secrets kept in an external secret manager,
+// such as vault://secret/data/petstore#api_key or aws-sm://prod/petstore, so that only
+// the reference is stored in the database and the credential itself is fetched on use.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL is how long a fetched secret is reused when SECRETS_CACHE_TTL is not set.
+const DefaultCacheTTL = 5 * time.Minute
+
+// Reference is a parsed secret reference: <scheme>://<path>[#<key>].
+type Reference struct {
+	Scheme string // e.g. "vault", "aws-sm"
+	Path   string // secret path or name in the secret manager
+	Key    string // field within the secret; empty for the whole value
+}
+
+// String returns the reference in its <scheme>://<path>[#<key>] form.
+func (r Reference) String() string {
+	s := r.Scheme + "://" + r.Path
+	if r.Key != "" {
+		s += "#" + r.Key
+	}
+	return s
+}
+
+// Provider fetches secrets from one secret manager.
+type Provider interface {
+	Fetch(ctx context.Context, ref Reference) (string, error)
+}
+
+// ProviderFunc adapts a function to the Provider interface.
+type ProviderFunc func(ctx context.Context, ref Reference) (string, error)
+
+// Fetch calls f.
+func (f ProviderFunc) Fetch(ctx context.Context, ref Reference) (string, error) {
+	return f(ctx, ref)
+}
+
+type cachedSecret struct {
+	value     string
+	expiresAt time.Time
+}
+
+// Resolver turns secret references into secret values, caching them for a TTL.
+// Values that are not references are returned unchanged.
+type Resolver struct {
+	ttl time.Duration
+
+	mu        sync.RWMutex
+	providers map[string]Provider
+	cache     map[string]cachedSecret
+}
+
+// NewResolver creates a resolver with the given cache TTL and no providers.
+func NewResolver(ttl time.Duration) *Resolver {
+	return &Resolver{ttl: ttl, providers: map[string]Provider{}, cache: map[string]cachedSecret{}}
+}
+
+var (
+	defaultResolver     *Resolver
+	defaultResolverOnce sync.Once
+)
+
+// Default returns the process-wide resolver with the built-in providers: vault, aws-sm,
+// aws-kms, env and file. Its cache TTL comes from SECRETS_CACHE_TTL (a Go duration, "0" disables caching).
+func Default() *Resolver {
+	defaultResolverOnce.Do(func() {
+		ttl := DefaultCacheTTL
+		if v := os.Getenv("SECRETS_CACHE_TTL"); v != "" {
+			if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+				ttl = d
+			}
+		}
+		defaultResolver = NewResolver(ttl)
+		defaultResolver.Register("vault", NewVaultProvider())
+		defaultResolver.Register("aws-sm", NewAWSSecretsManagerProvider())
+		defaultResolver.Register("aws-kms", NewAWSKMSProvider())
+		defaultResolver.Register("env", ProviderFunc(fetchEnv))
+		defaultResolver.Register("file", ProviderFunc(fetchFile))
+	})
+	return defaultResolver
+}
+
+// Register adds or replaces the provider for a reference scheme.
+func (r *Resolver) Register(scheme string, p Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[strings.ToLower(scheme)] = p
+}
+
+// Parse parses value as a reference to one of the registered schemes.
+func (r *Resolver) Parse(value string) (Reference, bool) {
+	scheme, rest, found := strings.Cut(strings.TrimSpace(value), "://")
+	if !found || rest == "" {
+		return Reference{}, false
+	}
+	scheme = strings.ToLower(scheme)
+	r.mu.RLock()
+	_, known := r.providers[scheme]
+	r.mu.RUnlock()
+	if !known {
+		return Reference{}, false
+	}
+	path, key, _ := strings.Cut(rest, "#")
+	return Reference{Scheme: scheme, Path: path, Key: key}, true
+}
+
+// IsReference reports whether value is a secret reference rather than a raw secret.
+func (r *Resolver) IsReference(value string) bool {
+	_, ok := r.Parse(value)
+	return ok
+}
+
+// Resolve returns the secret a reference points at, from the cache while it is fresh.
+// A value that is not a reference is returned as is.
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	ref, ok := r.Parse(value)
+	if !ok {
+		return value, nil
+	}
+	cacheKey := ref.String()
+	r.mu.RLock()
+	cached, hit := r.cache[cacheKey]
+	provider := r.providers[ref.Scheme]
+	r.mu.RUnlock()
+	if hit && time.Now().Before(cached.expiresAt) {
+		return cached.value, nil
+	}
+
+	secret, err := provider.Fetch(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", cacheKey, err)
+	}
+	if r.ttl > 0 {
+		r.mu.Lock()
+		r.cache[cacheKey] = cachedSecret{value: secret, expiresAt: time.Now().Add(r.ttl)}
+		r.mu.Unlock()
+	}
+	return secret, nil
+}
+
+// Forget drops a cached secret, so the next Resolve fetches it again (e.g. after rotation).
+func (r *Resolver) Forget(value string) {
+	if ref, ok := r.Parse(value); ok {
+		r.mu.Lock()
+		delete(r.cache, ref.String())
+		r.mu.Unlock()
+	}
+}
+
+// Describe returns a log-safe description of a stored token: the reference itself, which
+// holds no secret, or the length of a raw token.
+func Describe(value string) string {
+	if ref, ok := Default().Parse(value); ok {
+		return "secret reference " + ref.String()
+	}
+	return fmt.Sprintf("raw token (length: %d)", len(value))
+}
+
+// fetchEnv resolves env://NAME from the server's environment.
+func fetchEnv(_ context.Context, ref Reference) (string, error) {
+	value, ok := os.LookupEnv(ref.Path)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", ref.Path)
+	}
+	return selectKey(value, ref.Key)
+}
+
+// fetchFile resolves file:///path, e.g. a secret mounted by Kubernetes or Docker.
+func fetchFile(_ context.Context, ref Reference) (string, error) {
+	data, err := os.ReadFile(ref.Path)
+	if err != nil {
+		return "", err
+	}
+	return selectKey(strings.TrimRight(string(data), "\r\n"), ref.Key)
+}
@@ -0,0 +1,111 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// VaultProvider reads secrets from HashiCorp Vault over its HTTP API. References look like
+// vault://secret/data/petstore#api_key: the path is read with GET /v1/<path> and the key
+// selects a field. KV version 2 responses (data.data) and version 1 responses (data) both work.
+type VaultProvider struct {
+	Addr      string // VAULT_ADDR, e.g. https://vault.internal:8200
+	Token     string // VAULT_TOKEN
+	Namespace string // VAULT_NAMESPACE (Vault Enterprise), optional
+	Client    *http.Client
+}
+
+// NewVaultProvider configures a Vault provider from VAULT_ADDR, VAULT_TOKEN and VAULT_NAMESPACE.
+func NewVaultProvider() *VaultProvider {
+	return &VaultProvider{
+		Addr:      os.Getenv("VAULT_ADDR"),
+		Token:     os.Getenv("VAULT_TOKEN"),
+		Namespace: os.Getenv("VAULT_NAMESPACE"),
+		Client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Fetch reads one secret field from Vault.
+func (p *VaultProvider) Fetch(ctx context.Context, ref Reference) (string, error) {
+	if p.Addr == "" || p.Token == "" {
+		return "", fmt.Errorf("vault is not configured: set VAULT_ADDR and VAULT_TOKEN")
+	}
+	url := strings.TrimRight(p.Addr, "/") + "/v1/" + strings.TrimLeft(ref.Path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", p.Token)
+	if p.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.Namespace)
+	}
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned HTTP %d for %s", resp.StatusCode, ref.Path)
+	}
+
+	var payload struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", fmt.Errorf("invalid vault response: %w", err)
+	}
+	fields := payload.Data
+	// KV v2 nests the secret under data.data, next to data.metadata
+	if inner, ok := fields["data"].(map[string]any); ok {
+		if _, hasMetadata := fields["metadata"]; hasMetadata {
+			fields = inner
+		}
+	}
+	return selectField(fields, ref.Key)
+}
+
+// selectField returns the field named key, or the only field when key is empty.
+func selectField(fields map[string]any, key string) (string, error) {
+	if key == "" {
+		if len(fields) != 1 {
+			names := make([]string, 0, len(fields))
+			for name := range fields {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return "", fmt.Errorf("secret has fields %v; select one with #<key>", names)
+		}
+		for name := range fields {
+			key = name
+		}
+	}
+	value, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("secret has no field %q", key)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(value)
+	return string(data), err
+}
+
+// selectKey returns value itself, or the field named key when value is a JSON object.
+func selectKey(value, key string) (string, error) {
+	if key == "" {
+		return value, nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object, cannot select #%s", key)
+	}
+	return selectField(fields, key)
+}
@@ -3,12 +3,12 @@ package server
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
 	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/apierrors"
 	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
 	"github.com/ubermorgenland/openapi-mcp/pkg/models"
 )
@@ -52,7 +52,7 @@ func SecureAuthContextFunc(authStateManager *auth.StateManager) HTTPContextFunc
 func HandleReload(reloadFunc func() ([]string, error)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			apierrors.WriteStatus(w, http.StatusMethodNotAllowed, "Method not allowed")
 			return
 		}
 
@@ -102,7 +102,7 @@ func HandleAPIList(listFunc func() ([]map[string]interface{}, error)) http.Handl
 		apis, err := listFunc()
 		if err != nil {
 			log.Printf("Failed to list APIs: %v", err)
-			http.Error(w, fmt.Sprintf("Failed to list APIs: %v", err), http.StatusInternalServerError)
+			apierrors.Write(w, Wrap(err, GetType(err), "Failed to list APIs"))
 			return
 		}
 
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
+	"github.com/ubermorgenland/openapi-mcp/pkg/secrets"
+)
+
+// requireTokenExtension is the root OpenAPI extension that makes a spec file require
+// credentials to activate (`x-mcp-require-token-to-activate: true`), like the
+// require_token_to_activate column does for database specs
+const requireTokenExtension = "x-mcp-require-token-to-activate"
+
+// RequiresToken reports whether a spec is mounted without tools while it has no credentials
+func RequiresToken(loaded *LoadedSpec) bool {
+	if loaded.Spec != nil && loaded.Spec.RequireToken {
+		return true
+	}
+	if loaded.Doc == nil {
+		return false
+	}
+	v, _ := loaded.Doc.Extensions[requireTokenExtension].(bool)
+	return v
+}
+
+// CredentialSource returns where the credentials of a spec with a security scheme come from:
+// "database", or the environment variable that supplies them. It returns "" when the spec has
+// none, and an error when its database token is a secret reference that does not resolve.
+func CredentialSource(ctx context.Context, loaded *LoadedSpec) (string, error) {
+	envVar, _ := RequiredEnvVar(loaded)
+	if envVar == "" {
+		return "", nil
+	}
+	if creds := auth.SpecCredentials(loaded.Spec); len(creds) > 0 {
+		if _, err := secrets.Default().Resolve(ctx, creds[0].Token); err != nil {
+			return "", err
+		}
+		return "database", nil
+	}
+	for _, v := range credentialEnvVars(loaded, envVar) {
+		if os.Getenv(v) != "" {
+			return v, nil
+		}
+	}
+	return "", nil
+}
+
+// credentialEnvVars lists the environment variables that can supply a spec's credentials:
+// its own, the GENERAL_ default, then the generic ones the auth layer falls back to
+func credentialEnvVars(loaded *LoadedSpec, envVar string) []string {
+	vars := []string{envVar, "GENERAL_" + strings.TrimPrefix(envVar, strings.ToUpper(loaded.Endpoint)+"_")}
+	switch loaded.AuthType {
+	case "apiKey":
+		vars = append(vars, "API_KEY", "X_API_KEY")
+	case "bearer":
+		vars = append(vars, "BEARER_TOKEN", "API_KEY")
+	case "basic":
+		vars = append(vars, "BASIC_AUTH")
+	}
+	return vars
+}
+
+// InactiveReason returns why a spec that requires a token to activate is mounted without
+// tools, so agents do not discover tools that could only fail with 401. It returns "" when
+// the spec is active: it does not require a token, needs no credentials, or has them.
+func InactiveReason(ctx context.Context, loaded *LoadedSpec) string {
+	if !RequiresToken(loaded) {
+		return ""
+	}
+	envVar, _ := RequiredEnvVar(loaded)
+	if envVar == "" {
+		return ""
+	}
+	source, err := CredentialSource(ctx, loaded)
+	if err != nil {
+		return fmt.Sprintf("requires a token to activate, and its database token does not resolve: %v", err)
+	}
+	if source != "" {
+		return ""
+	}
+	return fmt.Sprintf("requires a token to activate: set a database token or %s", envVar)
+}
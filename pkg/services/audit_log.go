@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/export"
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+	"github.com/ubermorgenland/openapi-mcp/pkg/openapi2mcp"
+	"github.com/ubermorgenland/openapi-mcp/pkg/repository"
+)
+
+// AuditLogService writes audit events to the tool_call_audit table. It implements export.Sink.
+type AuditLogService struct {
+	repo *repository.AuditLogRepository
+}
+
+// NewAuditLogService creates an audit log sink writing to the database.
+func NewAuditLogService(db *sql.DB) *AuditLogService {
+	return &AuditLogService{repo: repository.NewAuditLogRepository(db)}
+}
+
+// Send writes a batch of audit events, whose data are openapi2mcp.CallEvent values.
+func (s *AuditLogService) Send(ctx context.Context, events []export.Event) error {
+	records := make([]*models.AuditRecord, 0, len(events))
+	for _, e := range events {
+		call, ok := e.Data.(openapi2mcp.CallEvent)
+		if !ok {
+			return export.Permanent(fmt.Errorf("unexpected audit event data %T", e.Data))
+		}
+		records = append(records, &models.AuditRecord{
+			CalledAt:       call.Time,
+			Endpoint:       call.Endpoint,
+			Tool:           call.Tool,
+			SessionID:      optionalString(call.SessionID),
+			ArgNames:       optionalString(strings.Join(call.ArgNames, ",")),
+			ArgsHash:       optionalString(call.ArgsHash),
+			Status:         call.Status,
+			UpstreamStatus: optionalInt(call.UpstreamStatus),
+			Error:          optionalString(call.Error),
+			DurationMs:     call.DurationMs,
+			Host:           e.Host,
+		})
+	}
+	return s.repo.Insert(records)
+}
+
+// List returns the most recent audit records matching the filter.
+func (s *AuditLogService) List(filter repository.AuditFilter, limit int) ([]*models.AuditRecord, error) {
+	return s.repo.List(filter, limit)
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func optionalInt(n int) *int {
+	if n == 0 {
+		return nil
+	}
+	return &n
+}
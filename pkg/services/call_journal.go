@@ -0,0 +1,100 @@
+package services
+
+import (
+	"database/sql"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+	"github.com/ubermorgenland/openapi-mcp/pkg/openapi2mcp"
+	"github.com/ubermorgenland/openapi-mcp/pkg/repository"
+)
+
+// DefaultJournalStaleAfter is how old an unfinished call must be before any instance
+// reports it as interrupted, when MCP_CALL_JOURNAL_STALE_AFTER is not set.
+const DefaultJournalStaleAfter = time.Hour
+
+// DefaultJournalRetention is how long finished calls are kept, when MCP_CALL_JOURNAL_RETENTION is not set.
+const DefaultJournalRetention = 7 * 24 * time.Hour
+
+// CallJournalService journals tool calls in the database. It implements openapi2mcp.CallJournal.
+type CallJournalService struct {
+	repo       *repository.ToolCallRepository
+	host       string
+	pid        int
+	staleAfter time.Duration
+	retention  time.Duration
+}
+
+// NewCallJournalService creates a journal for this server instance (host name and process ID).
+func NewCallJournalService(db *sql.DB) *CallJournalService {
+	host, _ := os.Hostname()
+	return &CallJournalService{
+		repo:       repository.NewToolCallRepository(db),
+		host:       host,
+		pid:        os.Getpid(),
+		staleAfter: durationFromEnv("MCP_CALL_JOURNAL_STALE_AFTER", DefaultJournalStaleAfter),
+		retention:  durationFromEnv("MCP_CALL_JOURNAL_RETENTION", DefaultJournalRetention),
+	}
+}
+
+// Accept records a call before it runs.
+func (s *CallJournalService) Accept(entry openapi2mcp.JournalEntry) (int64, error) {
+	call := &models.ToolCall{
+		Endpoint: entry.Endpoint,
+		Tool:     entry.Tool,
+		Host:     s.host,
+		Pid:      s.pid,
+	}
+	if entry.SessionID != "" {
+		call.SessionID = &entry.SessionID
+	}
+	if len(entry.ArgNames) > 0 {
+		names := strings.Join(entry.ArgNames, ",")
+		call.ArgNames = &names
+	}
+	return s.repo.Accept(call)
+}
+
+// Finish records the outcome of an accepted call.
+func (s *CallJournalService) Finish(id int64, status, errMsg string) error {
+	var msg *string
+	if errMsg != "" {
+		msg = &errMsg
+	}
+	return s.repo.Finish(id, status, msg)
+}
+
+// Recover marks the calls left unfinished by earlier processes on this host, and by
+// instances gone for longer than MCP_CALL_JOURNAL_STALE_AFTER, as interrupted and returns them.
+// Call it at startup, before tools are served.
+func (s *CallJournalService) Recover() ([]*models.ToolCall, error) {
+	return s.repo.Interrupt(s.host, 0, time.Now().Add(-s.staleAfter), "server restarted before the call finished")
+}
+
+// InterruptInFlight marks this process's unfinished calls as interrupted and returns them.
+// Call it at shutdown, after in-flight requests had their chance to finish.
+func (s *CallJournalService) InterruptInFlight() ([]*models.ToolCall, error) {
+	return s.repo.Interrupt(s.host, s.pid, time.Now().Add(-s.staleAfter), "server shut down before the call finished")
+}
+
+// List returns the most recent journaled calls, optionally only those with a status.
+func (s *CallJournalService) List(status string, limit int) ([]*models.ToolCall, error) {
+	return s.repo.List(status, limit)
+}
+
+// Prune deletes finished calls older than MCP_CALL_JOURNAL_RETENTION.
+func (s *CallJournalService) Prune() (int64, error) {
+	return s.repo.Prune(time.Now().Add(-s.retention))
+}
+
+// durationFromEnv parses a positive Go duration from an environment variable
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	if v := os.Getenv(name); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return fallback
+}
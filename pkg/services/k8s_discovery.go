@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/k8s"
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+	"github.com/ubermorgenland/openapi-mcp/pkg/repository"
+)
+
+// KubernetesSpecPrefix starts the names of specs imported from Kubernetes. Discovery only
+// updates and deactivates specs with this prefix, never ones imported by other means.
+const KubernetesSpecPrefix = "k8s-"
+
+// KubernetesSyncResult summarizes one discovery pass.
+type KubernetesSyncResult struct {
+	Created     []string
+	Updated     []string
+	Deactivated []string
+	Failed      map[string]string // target or spec name -> error
+}
+
+// Changed reports whether the pass changed any spec in the database.
+func (r *KubernetesSyncResult) Changed() bool {
+	return len(r.Created)+len(r.Updated)+len(r.Deactivated) > 0
+}
+
+// KubernetesDiscoveryService imports the specs announced by annotated Services and Ingresses
+// into the database, keeps them up to date and deactivates them when the annotation goes away.
+type KubernetesDiscoveryService struct {
+	loader   *SpecLoaderService
+	client   *k8s.Client
+	interval time.Duration
+	onChange func(*KubernetesSyncResult)
+
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewKubernetesDiscoveryService creates a discovery service that syncs every interval and
+// calls onChange (which may be nil) after a pass that changed the database.
+func NewKubernetesDiscoveryService(loader *SpecLoaderService, client *k8s.Client, interval time.Duration, onChange func(*KubernetesSyncResult)) *KubernetesDiscoveryService {
+	return &KubernetesDiscoveryService{loader: loader, client: client, interval: interval, onChange: onChange}
+}
+
+// Sync runs one discovery pass.
+func (s *KubernetesDiscoveryService) Sync(ctx context.Context) (*KubernetesSyncResult, error) {
+	targets, skipped, err := s.client.Targets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &KubernetesSyncResult{Failed: map[string]string{}}
+	for _, err := range skipped {
+		log.Printf("[WARN] Kubernetes discovery: %v", err)
+	}
+
+	seen := map[string]bool{}
+	for _, t := range targets {
+		name := KubernetesSpecPrefix + t.Namespace + "-" + t.Name
+		if t.Kind == "Ingress" {
+			name += "-ingress"
+		}
+		seen[name] = true
+		created, updated, err := s.importTarget(ctx, name, t)
+		switch {
+		case err != nil:
+			result.Failed[t.ID()] = err.Error()
+		case created:
+			result.Created = append(result.Created, name)
+		case updated:
+			result.Updated = append(result.Updated, name)
+		}
+	}
+
+	specs, err := s.loader.GetAllSpecs()
+	if err != nil {
+		return result, fmt.Errorf("failed to list specs: %v", err)
+	}
+	for _, spec := range specs {
+		if !strings.HasPrefix(spec.Name, KubernetesSpecPrefix) || seen[spec.Name] || spec.IsActive == nil || !*spec.IsActive {
+			continue
+		}
+		if err := s.loader.DeactivateSpec(spec.ID); err != nil {
+			result.Failed[spec.Name] = err.Error()
+			continue
+		}
+		result.Deactivated = append(result.Deactivated, spec.Name)
+	}
+	return result, nil
+}
+
+// importTarget fetches the spec of a target and creates or updates the spec named name.
+func (s *KubernetesDiscoveryService) importTarget(ctx context.Context, name string, t k8s.Target) (created, updated bool, err error) {
+	data, err := fetchSpec(ctx, t.SpecURL)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to fetch %s: %v", t.SpecURL, err)
+	}
+	content, format, err := withServerURL(data, t.ServerURL, t.BaseURL)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to parse spec from %s: %v", t.SpecURL, err)
+	}
+	var token *string
+	if t.APIKeyToken != "" {
+		token = &t.APIKeyToken
+	}
+
+	existing, err := s.loader.specRepo.GetByName(name)
+	if err != nil {
+		if !strings.Contains(err.Error(), "not found") {
+			return false, false, err
+		}
+		spec := models.NewOpenAPISpec(name, content, t.Endpoint)
+		spec.FileFormat = &format
+		spec.ApiKeyToken = token
+		spec.IsActive = &t.Enabled
+		fileSize := len(content)
+		spec.FileSize = &fileSize
+		if err := s.loader.applySpecMetadata(spec); err != nil {
+			return false, false, err
+		}
+		if _, err := s.loader.specRepo.Create(spec); err != nil {
+			return false, false, fmt.Errorf("failed to save spec to database: %v", err)
+		}
+		return true, false, nil
+	}
+
+	unchanged := existing.ContentHash != nil && *existing.ContentHash == repository.ContentHash(content) &&
+		existing.EndpointPath == t.Endpoint &&
+		stringValue(existing.ApiKeyToken) == t.APIKeyToken &&
+		existing.IsActive != nil && *existing.IsActive == t.Enabled
+	if unchanged {
+		return false, false, nil
+	}
+	existing.SpecContent = content
+	existing.EndpointPath = t.Endpoint
+	existing.FileFormat = &format
+	existing.ApiKeyToken = token
+	existing.IsActive = &t.Enabled
+	fileSize := len(content)
+	existing.FileSize = &fileSize
+	if err := s.loader.applySpecMetadata(existing); err != nil {
+		return false, false, err
+	}
+	if _, err := s.loader.specRepo.Update(existing); err != nil {
+		return false, false, err
+	}
+	return false, true, nil
+}
+
+// withServerURL points the spec's servers at serverURL when it is set, or at baseURL when
+// the spec has no absolute server URL. The spec is returned unchanged, or re-encoded as
+// JSON when its servers were replaced.
+func withServerURL(content []byte, serverURL, baseURL string) (string, string, error) {
+	format := "yaml"
+	if trimmed := strings.TrimSpace(string(content)); strings.HasPrefix(trimmed, "{") {
+		format = "json"
+	}
+	doc, err := openapi3.NewLoader().LoadFromData(content)
+	if err != nil {
+		return "", "", err
+	}
+	if serverURL == "" {
+		for _, srv := range doc.Servers {
+			if srv != nil && (strings.HasPrefix(srv.URL, "http://") || strings.HasPrefix(srv.URL, "https://")) {
+				return string(content), format, nil
+			}
+		}
+		if baseURL == "" {
+			return string(content), format, nil
+		}
+		serverURL = baseURL
+	}
+	doc.Servers = openapi3.Servers{{URL: serverURL}}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return "", "", err
+	}
+	return string(data), "json", nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// Start syncs in the background every interval until Stop is called.
+func (s *KubernetesDiscoveryService) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+	s.stop, s.done = make(chan struct{}), make(chan struct{})
+	go s.run(s.stop, s.done)
+}
+
+// Stop ends background syncing and waits for a running pass to finish.
+func (s *KubernetesDiscoveryService) Stop() {
+	s.mu.Lock()
+	stop, done := s.stop, s.done
+	s.stop, s.done = nil, nil
+	s.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+func (s *KubernetesDiscoveryService) run(stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), s.interval)
+			result, err := s.Sync(ctx)
+			cancel()
+			s.report(result, err)
+		}
+	}
+}
+
+// report logs a pass and calls onChange when it changed the database.
+func (s *KubernetesDiscoveryService) report(result *KubernetesSyncResult, err error) {
+	if err != nil {
+		log.Printf("Kubernetes discovery failed: %v", err)
+	}
+	if result == nil {
+		return
+	}
+	for target, msg := range result.Failed {
+		log.Printf("[WARN] Kubernetes discovery: %s: %s", target, msg)
+	}
+	if !result.Changed() {
+		return
+	}
+	log.Printf("Kubernetes discovery: created %v, updated %v, deactivated %v", result.Created, result.Updated, result.Deactivated)
+	if s.onChange != nil {
+		s.onChange(result)
+	}
+}
+
+// SyncAndReport runs one pass, logging its outcome like the background passes do.
+func (s *KubernetesDiscoveryService) SyncAndReport(ctx context.Context) {
+	result, err := s.Sync(ctx)
+	s.report(result, err)
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// MCP clients whose configuration MCPClientConfig prints
+const (
+	MCPClientClaude = "claude"
+	MCPClientVSCode = "vscode"
+	MCPClientCursor = "cursor"
+)
+
+// MCPConfigOptions describes the MCP server entry to add to a client's configuration
+type MCPConfigOptions struct {
+	Client    string            // claude, vscode or cursor
+	Name      string            // key of the server in the client's configuration
+	URL       string            // URL of the mounted endpoint, without the /sse suffix
+	Transport string            // "streamable" (default) or "sse", as the server's --http-transport
+	Headers   map[string]string // headers the client sends with every request
+}
+
+// MCPClientConfig returns the configuration block pointing an MCP client at a mounted endpoint:
+// the .mcp.json of Claude Code, the .vscode/mcp.json of VS Code or the mcp.json of Cursor.
+func MCPClientConfig(opts MCPConfigOptions) (map[string]any, error) {
+	url := strings.TrimRight(opts.URL, "/")
+	transportType := "http"
+	switch opts.Transport {
+	case "", "streamable":
+	case "sse":
+		url += "/sse"
+		transportType = "sse"
+	default:
+		return nil, fmt.Errorf("unknown transport %q: expected streamable or sse", opts.Transport)
+	}
+
+	server := map[string]any{"url": url}
+	if len(opts.Headers) > 0 {
+		server["headers"] = opts.Headers
+	}
+	switch strings.ToLower(opts.Client) {
+	case MCPClientClaude:
+		server["type"] = transportType
+		return map[string]any{"mcpServers": map[string]any{opts.Name: server}}, nil
+	case MCPClientVSCode:
+		server["type"] = transportType
+		return map[string]any{"servers": map[string]any{opts.Name: server}}, nil
+	case MCPClientCursor:
+		// Cursor picks the transport from the server's responses
+		return map[string]any{"mcpServers": map[string]any{opts.Name: server}}, nil
+	}
+	return nil, fmt.Errorf("unknown client %q: expected claude, vscode or cursor", opts.Client)
+}
+
+// CredentialHeaderPlaceholders returns the headers a client must send to authenticate to the
+// API of a spec without a stored token, one per kind of security scheme, with placeholder values.
+func CredentialHeaderPlaceholders(doc *openapi3.T) map[string]string {
+	headers := make(map[string]string)
+	if doc == nil || doc.Components == nil {
+		return headers
+	}
+	names := make([]string, 0, len(doc.Components.SecuritySchemes))
+	for name := range doc.Components.SecuritySchemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ref := doc.Components.SecuritySchemes[name]
+		if ref == nil || ref.Value == nil {
+			continue
+		}
+		scheme := ref.Value
+		switch {
+		case scheme.Type == "apiKey":
+			headers["X-API-Key"] = "<YOUR_API_KEY>"
+		case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic"):
+			if _, ok := headers["Authorization"]; !ok {
+				headers["Authorization"] = "Basic <BASE64_USER_PASSWORD>"
+			}
+		case scheme.Type == "http" || scheme.Type == "oauth2" || scheme.Type == "openIdConnect":
+			headers["Authorization"] = "Bearer <YOUR_TOKEN>"
+		}
+	}
+	return headers
+}
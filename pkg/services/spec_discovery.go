@@ -0,0 +1,241 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxSpecBytes limits the size of a fetched spec or docs page.
+const maxSpecBytes = 64 << 20
+
+// Patterns that reference a spec from a docs page or a Swagger UI config, in order of preference.
+var specURLPatterns = []*regexp.Regexp{
+	// <redoc spec-url="...">, <rapi-doc spec-url="...">, <elements-api apiDescriptionUrl="...">
+	regexp.MustCompile(`(?i)\b(?:spec-url|apiDescriptionUrl)\s*=\s*["']([^"']+)["']`),
+	// Redoc.init("...")
+	regexp.MustCompile(`Redoc\.init\(\s*["']([^"']+)["']`),
+	// SwaggerUIBundle({url: "..."}), and "url": "..." in JSON configs; the first of urls: [...] too
+	regexp.MustCompile(`["']?\burl["']?\s*:\s*["']([^"']+)["']`),
+}
+
+// configURLPattern finds the swagger-config URL of Swagger UI set up with configUrl (springdoc).
+var configURLPattern = regexp.MustCompile(`["']?\bconfigUrl["']?\s*:\s*["']([^"']+)["']`)
+
+// scriptSrcPattern finds the scripts of a page; Swagger UI 4.9+ keeps its config in swagger-initializer.js.
+var scriptSrcPattern = regexp.MustCompile(`(?i)<script[^>]+src\s*=\s*["']([^"']+)["']`)
+
+// wellKnownSpecPaths are tried, relative to the docs page and to the site root, when the
+// page references no spec.
+var wellKnownSpecPaths = []string{
+	"openapi.json", "openapi.yaml", "swagger.json", "swagger.yaml",
+	"v3/api-docs", "v2/api-docs", "swagger/v1/swagger.json", "api-docs", "docs/openapi.json",
+}
+
+// fetchSpec downloads spec content from a URL. When the URL is an HTML docs page, such as
+// Swagger UI, Redoc or RapiDoc, the spec it displays is discovered and downloaded instead.
+func fetchSpec(ctx context.Context, url string) ([]byte, error) {
+	content, contentType, err := fetchURL(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	if !isHTML(contentType, content) {
+		return content, nil
+	}
+	specURL, spec, err := discoverSpec(ctx, url, content)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Found spec %s on docs page %s", specURL, url)
+	return spec, nil
+}
+
+func fetchURL(ctx context.Context, url string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept", "application/json, application/yaml, text/yaml, text/html;q=0.5, */*;q=0.1")
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("HTTP %d when fetching spec", resp.StatusCode)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxSpecBytes))
+	return content, resp.Header.Get("Content-Type"), err
+}
+
+// discoverSpec finds the spec shown by a docs page: the URLs the page (or its Swagger UI
+// config and initializer scripts) references, then well-known spec locations.
+func discoverSpec(ctx context.Context, pageURL string, page []byte) (string, []byte, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return "", nil, err
+	}
+	candidates := specURLCandidates(ctx, base, string(page))
+	tried := map[string]bool{}
+	for _, candidate := range candidates {
+		if tried[candidate] {
+			continue
+		}
+		tried[candidate] = true
+		if content, _, err := fetchURL(ctx, candidate); err == nil && looksLikeSpec(content) {
+			return candidate, content, nil
+		}
+	}
+	return "", nil, fmt.Errorf("%s is an HTML page and no OpenAPI spec was found on it; pass the URL of the spec itself (tried %d locations)", pageURL, len(tried))
+}
+
+// specURLCandidates lists the spec URLs a page may point at, most likely first.
+func specURLCandidates(ctx context.Context, base *url.URL, page string) []string {
+	var candidates []string
+	add := func(ref *url.URL, raw string) {
+		if u, ok := resolveSpecURL(ref, raw); ok {
+			candidates = append(candidates, u)
+		}
+	}
+
+	// Relative URLs in the initializer scripts resolve against the page, not the script
+	sources := []string{page}
+	// Swagger UI 4.9+ moved its config from index.html to swagger-initializer.js
+	for _, m := range scriptSrcPattern.FindAllStringSubmatch(page, -1) {
+		src := strings.ToLower(m[1])
+		if !strings.Contains(src, "init") && !strings.Contains(src, "config") {
+			continue
+		}
+		if u, ok := resolveScriptURL(base, m[1]); ok {
+			if script, _, err := fetchURL(ctx, u); err == nil {
+				sources = append(sources, string(script))
+			}
+		}
+	}
+
+	for _, text := range sources {
+		// configUrl points at a JSON config with url or urls (springdoc's /v3/api-docs/swagger-config)
+		for _, m := range configURLPattern.FindAllStringSubmatch(text, -1) {
+			if u, ok := resolveSpecURL(base, m[1]); ok {
+				if config, _, err := fetchURL(ctx, u); err == nil {
+					for _, raw := range swaggerConfigURLs(config) {
+						add(base, raw)
+					}
+				}
+			}
+		}
+		for _, pattern := range specURLPatterns {
+			for _, m := range pattern.FindAllStringSubmatch(text, -1) {
+				add(base, m[1])
+			}
+		}
+	}
+
+	root := &url.URL{Scheme: base.Scheme, Host: base.Host, Path: "/"}
+	for _, p := range wellKnownSpecPaths {
+		add(base, p)
+		add(root, p)
+	}
+	return candidates
+}
+
+// swaggerConfigURLs returns the spec URLs of a Swagger UI JSON config: url, then those in urls.
+func swaggerConfigURLs(config []byte) []string {
+	var cfg struct {
+		URL  string `json:"url"`
+		URLs []struct {
+			URL string `json:"url"`
+		} `json:"urls"`
+	}
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return nil
+	}
+	var urls []string
+	if cfg.URL != "" {
+		urls = append(urls, cfg.URL)
+	}
+	for _, u := range cfg.URLs {
+		if u.URL != "" {
+			urls = append(urls, u.URL)
+		}
+	}
+	return urls
+}
+
+// resolveSpecURL resolves a URL found on a page against base. Only http(s) URLs that can be
+// a spec are kept, not scripts, stylesheets or images.
+func resolveSpecURL(base *url.URL, raw string) (string, bool) {
+	raw = strings.TrimSpace(strings.ReplaceAll(html.UnescapeString(raw), `\/`, "/"))
+	if raw == "" || strings.HasPrefix(raw, "#") || strings.Contains(raw, "${") || strings.Contains(raw, "{{") {
+		return "", false
+	}
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	u := base.ResolveReference(ref)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", false
+	}
+	switch strings.ToLower(pathExt(u.Path)) {
+	case ".js", ".css", ".png", ".svg", ".ico", ".html", ".htm":
+		return "", false
+	}
+	return u.String(), true
+}
+
+// resolveScriptURL resolves the src of a script tag against the page's URL.
+func resolveScriptURL(base *url.URL, src string) (string, bool) {
+	ref, err := url.Parse(strings.TrimSpace(html.UnescapeString(src)))
+	if err != nil {
+		return "", false
+	}
+	u := base.ResolveReference(ref)
+	return u.String(), u.Scheme == "http" || u.Scheme == "https"
+}
+
+func pathExt(p string) string {
+	if i := strings.LastIndex(p, "."); i > strings.LastIndex(p, "/") {
+		return p[i:]
+	}
+	return ""
+}
+
+// isHTML reports whether a response is an HTML page rather than a spec.
+func isHTML(contentType string, content []byte) bool {
+	if strings.Contains(strings.ToLower(contentType), "text/html") {
+		return true
+	}
+	head := bytes.ToLower(bytes.TrimSpace(content[:min(len(content), 512)]))
+	return bytes.HasPrefix(head, []byte("<!doctype html")) || bytes.HasPrefix(head, []byte("<html"))
+}
+
+// looksLikeSpec reports whether content is a JSON or YAML OpenAPI or Swagger document.
+func looksLikeSpec(content []byte) bool {
+	trimmed := bytes.TrimSpace(content)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		var doc map[string]any
+		if json.Unmarshal(trimmed, &doc) != nil {
+			return false
+		}
+		_, openapi := doc["openapi"]
+		_, swagger := doc["swagger"]
+		return openapi || swagger
+	}
+	for _, line := range strings.SplitN(string(trimmed), "\n", 50) {
+		if strings.HasPrefix(line, "openapi:") || strings.HasPrefix(line, "swagger:") {
+			return true
+		}
+	}
+	return false
+}
@@ -1,32 +1,50 @@
 package services
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
 	"github.com/ubermorgenland/openapi-mcp/pkg/database"
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
 	"github.com/ubermorgenland/openapi-mcp/pkg/models"
 	"github.com/ubermorgenland/openapi-mcp/pkg/openapi2mcp"
+	"github.com/ubermorgenland/openapi-mcp/pkg/openapi2mcp/convert"
 	"github.com/ubermorgenland/openapi-mcp/pkg/repository"
 )
 
 // SpecLoaderService handles loading OpenAPI specs from database or files
 type SpecLoaderService struct {
-	specRepo *repository.OpenAPISpecRepository
-	db       *sql.DB
+	specRepo       *repository.OpenAPISpecRepository
+	credentialRepo *repository.SpecCredentialRepository
+	db             *sql.DB
+	pipeline       *SpecPipeline
 }
 
 // NewSpecLoaderService creates a new spec loader service
 func NewSpecLoaderService(db *sql.DB) *SpecLoaderService {
 	return &SpecLoaderService{
-		specRepo: repository.NewOpenAPISpecRepository(db),
-		db:       db,
+		specRepo:       repository.NewOpenAPISpecRepository(db).WithReplica(database.ReplicaDB),
+		credentialRepo: repository.NewSpecCredentialRepository(db),
+		db:             db,
+		pipeline:       NewSpecPipeline(false),
 	}
 }
 
+// Pipeline returns the pipeline every spec loaded by this service goes through.
+// Register hooks on it to extend loading for the server, the management API and the CLIs at once.
+func (s *SpecLoaderService) Pipeline() *SpecPipeline {
+	return s.pipeline
+}
+
 // LoadFromDatabase loads all active OpenAPI specs from the database
 func (s *SpecLoaderService) LoadFromDatabase() ([]openapi2mcp.OpenAPIOperation, []*openapi3.T, error) {
 	specs, err := s.specRepo.GetActive()
@@ -42,50 +60,21 @@ func (s *SpecLoaderService) LoadFromDatabase() ([]openapi2mcp.OpenAPIOperation,
 	var allDocs []*openapi3.T
 
 	for _, spec := range specs {
-		doc, err := s.parseSpecContent(spec)
+		loaded, err := s.pipeline.ProcessDBSpec(context.Background(), spec)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: Failed to parse spec '%s': %v\n", spec.Name, err)
 			continue
 		}
 
-		ops := openapi2mcp.ExtractOpenAPIOperations(doc)
-		allOps = append(allOps, ops...)
-		allDocs = append(allDocs, doc)
+		allOps = append(allOps, loaded.Operations...)
+		allDocs = append(allDocs, loaded.Doc)
 
-		fmt.Fprintf(os.Stderr, "Loaded spec '%s' with %d operations from database\n", spec.Name, len(ops))
+		fmt.Fprintf(os.Stderr, "Loaded spec '%s' with %d operations from database\n", spec.Name, len(loaded.Operations))
 	}
 
 	return allOps, allDocs, nil
 }
 
-// parseSpecContent parses the spec content based on its format
-func (s *SpecLoaderService) parseSpecContent(spec *models.OpenAPISpec) (*openapi3.T, error) {
-	loader := openapi3.NewLoader()
-
-	// Determine if content is JSON or YAML based on format or content
-	format := "yaml"
-	if spec.FileFormat != nil {
-		format = *spec.FileFormat
-	}
-
-	var doc *openapi3.T
-	var err error
-
-	if format == "json" || strings.HasPrefix(strings.TrimSpace(spec.SpecContent), "{") {
-		// Parse as JSON
-		doc, err = loader.LoadFromData([]byte(spec.SpecContent))
-	} else {
-		// Parse as YAML
-		doc, err = loader.LoadFromData([]byte(spec.SpecContent))
-	}
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse OpenAPI spec content: %v", err)
-	}
-
-	return doc, nil
-}
-
 // LoadSpecByName loads a specific spec by name from the database
 func (s *SpecLoaderService) LoadSpecByName(name string) ([]openapi2mcp.OpenAPIOperation, *openapi3.T, error) {
 	spec, err := s.specRepo.GetByName(name)
@@ -97,13 +86,12 @@ func (s *SpecLoaderService) LoadSpecByName(name string) ([]openapi2mcp.OpenAPIOp
 		return nil, nil, fmt.Errorf("spec '%s' is not active", name)
 	}
 
-	doc, err := s.parseSpecContent(spec)
+	loaded, err := s.pipeline.ProcessDBSpec(context.Background(), spec)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to parse spec content: %v", err)
 	}
 
-	ops := openapi2mcp.ExtractOpenAPIOperations(doc)
-	return ops, doc, nil
+	return loaded.Operations, loaded.Doc, nil
 }
 
 // LoadSpecByEndpoint loads a specific spec by endpoint path from the database
@@ -117,13 +105,12 @@ func (s *SpecLoaderService) LoadSpecByEndpoint(endpointPath string) ([]openapi2m
 		return nil, nil, fmt.Errorf("spec at endpoint '%s' is not active", endpointPath)
 	}
 
-	doc, err := s.parseSpecContent(spec)
+	loaded, err := s.pipeline.ProcessDBSpec(context.Background(), spec)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to parse spec content: %v", err)
 	}
 
-	ops := openapi2mcp.ExtractOpenAPIOperations(doc)
-	return ops, doc, nil
+	return loaded.Operations, loaded.Doc, nil
 }
 
 // ImportSpecFromFile imports a spec from a file into the database
@@ -131,51 +118,61 @@ func (s *SpecLoaderService) ImportSpecFromFile(filePath, name, endpointPath stri
 	return s.ImportSpecFromFileWithToken(filePath, name, endpointPath, nil)
 }
 
-// ImportSpecFromFileWithToken imports a spec from a file into the database with an API key token
+// ImportOptions controls how a spec is imported into the database
+type ImportOptions struct {
+	ApiKeyToken       *string  // API key token of the spec
+	ExcludeOperations []string // patterns of operations stripped before the spec is stored, see openapi2mcp.ExcludeOperations
+}
+
+// ImportSpecFromFileWithToken imports a spec from a file or an http(s) URL into the database with an API key token
 func (s *SpecLoaderService) ImportSpecFromFileWithToken(filePath, name, endpointPath string, apiKeyToken *string) error {
+	return s.ImportSpecFromFileWithOptions(filePath, name, endpointPath, ImportOptions{ApiKeyToken: apiKeyToken})
+}
+
+// ImportSpecFromFileWithOptions imports a spec from a file or an http(s) URL into the database
+func (s *SpecLoaderService) ImportSpecFromFileWithOptions(filePath, name, endpointPath string, opts ImportOptions) error {
 	// Check if database is connected
 	if database.DB == nil {
 		return fmt.Errorf("database connection not initialized")
 	}
 
-	// Read file content
-	content, err := os.ReadFile(filePath)
+	// Read file content; URLs may also point at a Swagger UI or other docs page showing the spec
+	var content []byte
+	var err error
+	isURL := strings.HasPrefix(filePath, "http://") || strings.HasPrefix(filePath, "https://")
+	if isURL {
+		content, err = fetchSpec(context.Background(), filePath)
+	} else {
+		content, err = os.ReadFile(filePath)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to read spec file: %v", err)
 	}
 
 	// Determine file format
 	format := "yaml"
-	if strings.HasSuffix(strings.ToLower(filePath), ".json") {
+	if strings.HasSuffix(strings.ToLower(filePath), ".json") || (isURL && bytes.HasPrefix(bytes.TrimSpace(content), []byte("{"))) {
 		format = "json"
 	}
-
-	// Parse the spec to extract title and version
-	loader := openapi3.NewLoader()
-	doc, err := loader.LoadFromData(content)
-	if err != nil {
-		return fmt.Errorf("failed to parse OpenAPI spec: %v", err)
+	if content, format, err = upgradeSwagger2(name, content, format); err != nil {
+		return err
 	}
-
-	var title, version *string
-	if doc.Info != nil {
-		if doc.Info.Title != "" {
-			title = &doc.Info.Title
-		}
-		if doc.Info.Version != "" {
-			version = &doc.Info.Version
-		}
+	if content, format, err = excludeOperations(name, content, format, opts.ExcludeOperations); err != nil {
+		return err
 	}
 
 	// Create new spec model
 	spec := models.NewOpenAPISpec(name, string(content), endpointPath)
-	spec.Title = title
-	spec.Version = version
 	spec.FileFormat = &format
-	spec.ApiKeyToken = apiKeyToken
+	spec.ApiKeyToken = opts.ApiKeyToken
 	fileSize := len(content)
 	spec.FileSize = &fileSize
 
+	// Run the spec through the loading pipeline to reject unparseable specs and extract title and version
+	if err := s.applySpecMetadata(spec); err != nil {
+		return err
+	}
+
 	// Save to database
 	_, err = s.specRepo.Create(spec)
 	if err != nil {
@@ -186,6 +183,63 @@ func (s *SpecLoaderService) ImportSpecFromFileWithToken(filePath, name, endpoint
 	return nil
 }
 
+// SplitImportResult describes one spec created by ImportSplitSpecFromFile
+type SplitImportResult struct {
+	Name         string
+	EndpointPath string
+	Tag          string
+	Operations   int
+}
+
+// ImportSplitSpecFromFile splits a large spec by tag and imports each part as its own spec,
+// named <name>-<tag> and mounted at <endpoint>-<tag>. Excluded operations are stripped before
+// the spec is split. Parts imported before an error are kept.
+func (s *SpecLoaderService) ImportSplitSpecFromFile(filePath, name, endpointPath string, opts ImportOptions) ([]SplitImportResult, error) {
+	if database.DB == nil {
+		return nil, fmt.Errorf("database connection not initialized")
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec file: %v", err)
+	}
+	if content, _, err = upgradeSwagger2(name, content, ""); err != nil {
+		return nil, err
+	}
+	doc, err := openapi2mcp.LoadOpenAPISpecFromBytes(content)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := openapi2mcp.ExcludeOperations(doc, opts.ExcludeOperations); err != nil {
+		return nil, err
+	} else if n > 0 {
+		fmt.Fprintf(os.Stderr, "Excluded %d operations from spec '%s'\n", n, name)
+	}
+	parts, err := openapi2mcp.SplitSpecByTag(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var imported []SplitImportResult
+	for _, part := range parts {
+		content, err := part.JSON()
+		if err != nil {
+			return imported, fmt.Errorf("failed to encode spec part '%s': %v", part.Tag, err)
+		}
+		result := SplitImportResult{
+			Name:         name + "-" + part.Slug,
+			EndpointPath: strings.TrimRight(endpointPath, "/") + "-" + part.Slug,
+			Tag:          part.Tag,
+			Operations:   part.Operations,
+		}
+		if err := s.CreateSpecFromContent(result.Name, result.EndpointPath, string(content), "json", opts.ApiKeyToken); err != nil {
+			return imported, fmt.Errorf("failed to import spec part '%s': %v", part.Tag, err)
+		}
+		imported = append(imported, result)
+	}
+	return imported, nil
+}
+
 // GetAllSpecs returns all specs from the database
 func (s *SpecLoaderService) GetAllSpecs() ([]*models.OpenAPISpec, error) {
 	return s.specRepo.GetAll()
@@ -193,7 +247,20 @@ func (s *SpecLoaderService) GetAllSpecs() ([]*models.OpenAPISpec, error) {
 
 // GetActiveSpecs returns all active specs from the database
 func (s *SpecLoaderService) GetActiveSpecs() ([]*models.OpenAPISpec, error) {
-	return s.specRepo.GetActive()
+	specs, err := s.specRepo.GetActive()
+	if err != nil {
+		return nil, err
+	}
+	// Specs with several API keys spread their calls over them. Without the keys, e.g.
+	// before the migration adding them ran, specs are served with their api_key_token.
+	creds, err := s.credentialRepo.GetAll()
+	if err != nil {
+		log.Printf("[WARN] Serving specs with their api_key_token only: %v", err)
+	}
+	for _, spec := range specs {
+		spec.Credentials = creds[spec.ID]
+	}
+	return specs, nil
 }
 
 // ActivateSpec activates a spec by ID
@@ -206,49 +273,249 @@ func (s *SpecLoaderService) DeactivateSpec(id int) error {
 	return s.specRepo.SetActive(id, false)
 }
 
-// DeleteSpec deletes a spec by ID
+// DeleteSpec soft-deletes a spec by ID; it can be restored with RestoreSpec until it is purged
 func (s *SpecLoaderService) DeleteSpec(id int) error {
 	return s.specRepo.Delete(id)
 }
 
+// RestoreSpec restores a soft-deleted spec by ID, unless another spec has taken one of its
+// aliases in the meantime
+func (s *SpecLoaderService) RestoreSpec(id int) error {
+	deleted, err := s.specRepo.GetDeleted()
+	if err != nil {
+		return err
+	}
+	var spec *models.OpenAPISpec
+	for _, d := range deleted {
+		if d.ID == id {
+			spec = d
+			break
+		}
+	}
+	if spec == nil {
+		return fmt.Errorf("deleted openapi spec with id %d not found", id)
+	}
+	if aliases := spec.AliasPaths(); len(aliases) > 0 {
+		specs, err := s.specRepo.GetAll()
+		if err != nil {
+			return err
+		}
+		for _, other := range specs {
+			taken := append([]string{strings.Trim(other.EndpointPath, "/")}, other.AliasPaths()...)
+			for _, alias := range aliases {
+				for _, t := range taken {
+					if strings.EqualFold(t, alias) {
+						return fmt.Errorf("alias /%s is now used by spec '%s'; clear it before restoring", alias, other.Name)
+					}
+				}
+			}
+		}
+	}
+	return s.specRepo.Restore(id)
+}
+
+// GetDeletedSpecs returns the soft-deleted specs, most recently deleted first
+func (s *SpecLoaderService) GetDeletedSpecs() ([]*models.OpenAPISpec, error) {
+	return s.specRepo.GetDeleted()
+}
+
+// PurgeDeletedSpecs permanently removes the specs deleted longer than retention ago and
+// returns how many were removed
+func (s *SpecLoaderService) PurgeDeletedSpecs(retention time.Duration) (int, error) {
+	return s.specRepo.PurgeDeleted(time.Now().Add(-retention))
+}
+
 // UpdateApiKeyToken updates the API key token for a spec by ID
 func (s *SpecLoaderService) UpdateApiKeyToken(id int, apiKeyToken *string) error {
 	return s.specRepo.UpdateApiKeyToken(id, apiKeyToken)
 }
 
+// UpdateRequireToken sets whether a spec by ID is mounted without tools while it has no
+// credentials, see InactiveReason
+func (s *SpecLoaderService) UpdateRequireToken(id int, require bool) error {
+	return s.specRepo.UpdateRequireToken(id, require)
+}
+
+// UpdateRateLimit validates and sets the rate limit of a spec's endpoint by ID, such as
+// "1000/m, client=60/m" (see server.ParseRateLimit); an empty string uses the default
+func (s *SpecLoaderService) UpdateRateLimit(id int, rateLimit string) error {
+	if strings.TrimSpace(rateLimit) == "" {
+		return s.specRepo.UpdateRateLimit(id, nil)
+	}
+	if _, err := server.ParseRateLimit(rateLimit); err != nil {
+		return err
+	}
+	return s.specRepo.UpdateRateLimit(id, &rateLimit)
+}
+
+// UpdateToolRules validates and sets the include/exclude rules selecting the operations of a
+// spec that become tools (see openapi2mcp.ParseToolRules); an empty string exposes all of them
+func (s *SpecLoaderService) UpdateToolRules(id int, toolRules string) error {
+	rules, err := openapi2mcp.ParseToolRules(toolRules)
+	if err != nil {
+		return err
+	}
+	if rules == nil {
+		return s.specRepo.UpdateToolRules(id, nil)
+	}
+	return s.specRepo.UpdateToolRules(id, &toolRules)
+}
+
+// UpdateUpstreamPolicy validates and sets the timeout, retry and circuit breaker settings of
+// the upstream calls of a spec (see openapi2mcp.ParseUpstreamPolicy); an empty string uses
+// the defaults
+func (s *SpecLoaderService) UpdateUpstreamPolicy(id int, upstreamPolicy string) error {
+	policy, err := openapi2mcp.ParseUpstreamPolicy(upstreamPolicy)
+	if err != nil {
+		return err
+	}
+	if policy == nil {
+		return s.specRepo.UpdateUpstreamPolicy(id, nil)
+	}
+	return s.specRepo.UpdateUpstreamPolicy(id, &upstreamPolicy)
+}
+
+// UpdateCredentialStrategy validates and sets how the calls of a spec spread over its API
+// keys, round-robin or failover (see auth.ParseCredentialStrategy); an empty string uses
+// round-robin
+func (s *SpecLoaderService) UpdateCredentialStrategy(id int, strategy string) error {
+	strategy, err := auth.ParseCredentialStrategy(strategy)
+	if err != nil {
+		return err
+	}
+	if strategy == "" {
+		return s.specRepo.UpdateCredentialStrategy(id, nil)
+	}
+	return s.specRepo.UpdateCredentialStrategy(id, &strategy)
+}
+
+// AddCredential adds an API key to a spec by ID, used in turn with its api_key_token; the
+// label names the key in listings, e.g. after the account it belongs to
+func (s *SpecLoaderService) AddCredential(specID int, token, label string) (*models.SpecCredential, error) {
+	if strings.TrimSpace(token) == "" {
+		return nil, fmt.Errorf("the token is empty")
+	}
+	cred := &models.SpecCredential{SpecID: specID, Token: token}
+	if label != "" {
+		cred.Label = &label
+	}
+	return s.credentialRepo.Create(cred)
+}
+
+// ListCredentials returns the API keys added to a spec by ID, besides its api_key_token
+func (s *SpecLoaderService) ListCredentials(specID int) ([]*models.SpecCredential, error) {
+	return s.credentialRepo.ListBySpec(specID)
+}
+
+// RemoveCredential removes an API key by its credential ID
+func (s *SpecLoaderService) RemoveCredential(id int) error {
+	return s.credentialRepo.Delete(id)
+}
+
+// EncryptTokens encrypts the stored API key tokens that are in plaintext or encrypted with a
+// previous key with MCP_TOKEN_ENCRYPTION_KEY; with dryRun it only counts them
+func (s *SpecLoaderService) EncryptTokens(dryRun bool) (*repository.TokenEncryptionResult, error) {
+	return s.specRepo.EncryptTokens(dryRun)
+}
+
+// UpdateFeatureFlags validates and sets the feature flags of a spec by ID; an empty string clears them
+func (s *SpecLoaderService) UpdateFeatureFlags(id int, featureFlags string) error {
+	if strings.TrimSpace(featureFlags) == "" {
+		return s.specRepo.UpdateFeatureFlags(id, nil)
+	}
+	if _, err := openapi2mcp.ParseFeatureFlags(featureFlags); err != nil {
+		return err
+	}
+	return s.specRepo.UpdateFeatureFlags(id, &featureFlags)
+}
+
+// UpdateAliases validates and sets the endpoint aliases of a spec by ID, a comma-separated
+// list of paths such as "/wx, /weather-v1"; an empty string clears them. An alias may not
+// be the endpoint or alias of another spec.
+func (s *SpecLoaderService) UpdateAliases(id int, aliases string) error {
+	var paths []string
+	for _, alias := range strings.Split(aliases, ",") {
+		alias = strings.Trim(strings.TrimSpace(alias), "/")
+		if alias == "" {
+			continue
+		}
+		if !validAlias(alias) {
+			return fmt.Errorf("invalid alias %q: use letters, digits, '-', '_' and '.' only", alias)
+		}
+		paths = append(paths, "/"+alias)
+	}
+	if len(paths) == 0 {
+		return s.specRepo.UpdateAliases(id, nil)
+	}
+
+	specs, err := s.specRepo.GetAll()
+	if err != nil {
+		return err
+	}
+	for _, spec := range specs {
+		taken := append([]string{strings.Trim(spec.EndpointPath, "/")}, spec.AliasPaths()...)
+		for _, path := range paths {
+			for _, t := range taken {
+				if !strings.EqualFold(t, strings.Trim(path, "/")) {
+					continue
+				}
+				if spec.ID == id {
+					if t == strings.Trim(spec.EndpointPath, "/") {
+						return fmt.Errorf("alias %s is the spec's own endpoint", path)
+					}
+					continue
+				}
+				return fmt.Errorf("alias %s is already used by spec '%s'", path, spec.Name)
+			}
+		}
+	}
+	joined := strings.Join(paths, ",")
+	return s.specRepo.UpdateAliases(id, &joined)
+}
+
+// validAlias reports whether an alias is a single path segment of safe characters
+func validAlias(alias string) bool {
+	for _, r := range alias {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return alias != "." && alias != ".."
+}
+
 // CreateSpecFromContent creates a new spec directly from content
 func (s *SpecLoaderService) CreateSpecFromContent(name, endpointPath, specContent, fileFormat string, apiKeyToken *string) error {
+	return s.CreateSpecFromContentWithOptions(name, endpointPath, specContent, fileFormat, ImportOptions{ApiKeyToken: apiKeyToken})
+}
+
+// CreateSpecFromContentWithOptions creates a new spec directly from content
+func (s *SpecLoaderService) CreateSpecFromContentWithOptions(name, endpointPath, specContent, fileFormat string, opts ImportOptions) error {
 	// Check if database is connected
 	if database.DB == nil {
 		return fmt.Errorf("database connection not initialized")
 	}
 
-	// Parse the spec to extract title and version
-	loader := openapi3.NewLoader()
-	doc, err := loader.LoadFromData([]byte(specContent))
+	content, fileFormat, err := upgradeSwagger2(name, []byte(specContent), fileFormat)
 	if err != nil {
-		return fmt.Errorf("failed to parse OpenAPI spec: %v", err)
+		return err
 	}
-
-	var title, version *string
-	if doc.Info != nil {
-		if doc.Info.Title != "" {
-			title = &doc.Info.Title
-		}
-		if doc.Info.Version != "" {
-			version = &doc.Info.Version
-		}
+	if content, fileFormat, err = excludeOperations(name, content, fileFormat, opts.ExcludeOperations); err != nil {
+		return err
 	}
+	specContent = string(content)
 
 	// Create new spec model
 	spec := models.NewOpenAPISpec(name, specContent, endpointPath)
-	spec.Title = title
-	spec.Version = version
 	spec.FileFormat = &fileFormat
-	spec.ApiKeyToken = apiKeyToken
+	spec.ApiKeyToken = opts.ApiKeyToken
 	fileSize := len(specContent)
 	spec.FileSize = &fileSize
 
+	// Run the spec through the loading pipeline to reject unparseable specs and extract title and version
+	if err := s.applySpecMetadata(spec); err != nil {
+		return err
+	}
+
 	// Save to database
 	_, err = s.specRepo.Create(spec)
 	if err != nil {
@@ -257,3 +524,57 @@ func (s *SpecLoaderService) CreateSpecFromContent(name, endpointPath, specConten
 
 	return nil
 }
+
+// upgradeSwagger2 converts Swagger 2.0 content to OpenAPI 3, stored as JSON. Other content
+// is returned unchanged, with its format.
+func upgradeSwagger2(name string, content []byte, format string) ([]byte, string, error) {
+	if !convert.IsSwagger2(content) {
+		return content, format, nil
+	}
+	converted, err := convert.Swagger2ToOpenAPI3(content)
+	if err != nil {
+		return nil, "", err
+	}
+	fmt.Fprintf(os.Stderr, "Converted Swagger 2.0 spec '%s' to OpenAPI 3\n", name)
+	return converted, "json", nil
+}
+
+// excludeOperations strips the operations matching patterns from spec content before it is
+// stored; the stripped spec is stored as JSON. Content is returned unchanged when no
+// operation matches.
+func excludeOperations(name string, content []byte, format string, patterns []string) ([]byte, string, error) {
+	if len(patterns) == 0 {
+		return content, format, nil
+	}
+	doc, err := openapi2mcp.LoadOpenAPISpecFromBytes(content)
+	if err != nil {
+		return nil, "", err
+	}
+	n, err := openapi2mcp.ExcludeOperations(doc, patterns)
+	if err != nil || n == 0 {
+		return content, format, err
+	}
+	stripped, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode spec '%s': %v", name, err)
+	}
+	fmt.Fprintf(os.Stderr, "Excluded %d operations from spec '%s'\n", n, name)
+	return stripped, "json", nil
+}
+
+// applySpecMetadata runs a spec through the loading pipeline and copies its title and version onto the model
+func (s *SpecLoaderService) applySpecMetadata(spec *models.OpenAPISpec) error {
+	loaded, err := s.pipeline.ProcessDBSpec(context.Background(), spec)
+	if err != nil {
+		return fmt.Errorf("failed to parse OpenAPI spec: %v", err)
+	}
+	if info := loaded.Doc.Info; info != nil {
+		if info.Title != "" {
+			spec.Title = &info.Title
+		}
+		if info.Version != "" {
+			spec.Version = &info.Version
+		}
+	}
+	return nil
+}
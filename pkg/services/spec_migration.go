@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/database"
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+	"github.com/ubermorgenland/openapi-mcp/pkg/openapi2mcp"
+)
+
+// Statuses of a spec file migrated by MigrateFromFiles
+const (
+	MigrationImported = "imported"
+	MigrationExisting = "existing" // a spec with the file's endpoint was already in the database
+	MigrationDryRun   = "dry-run"
+	MigrationFailed   = "failed"
+)
+
+// MigrationOptions controls MigrateFromFiles
+type MigrationOptions struct {
+	WithTokens bool // store the token of each spec's environment variable (e.g. WEATHER_API_KEY) in the database
+	DryRun     bool // check the files without writing to the database
+}
+
+// MigrationResult describes the migration of one spec file
+type MigrationResult struct {
+	File     string
+	Name     string
+	Endpoint string
+	Status   string
+	Error    error
+
+	EnvVar      string // environment variable the spec's credentials come from in file mode
+	TokenCopied bool
+
+	// Tools mounted in file mode and, once imported, in database mode
+	FileTools     []string
+	DatabaseTools []string
+	MissingTools  []string // mounted in file mode but not in database mode
+	ExtraTools    []string // mounted in database mode but not in file mode
+}
+
+// Verified reports whether the spec mounts the same tools in database mode as in file mode
+func (r *MigrationResult) Verified() bool {
+	return r.Error == nil && r.DatabaseTools != nil && len(r.MissingTools) == 0 && len(r.ExtraTools) == 0
+}
+
+// MigrateFromFiles imports the spec files of a file-mode specs directory into the database.
+// Each spec keeps the endpoint file mode serves it at and is named after it. Specs whose
+// endpoint is already in the database are not imported again, so a migration can be rerun.
+// The tools of every imported spec are compared with the tools file mode mounts.
+func (s *SpecLoaderService) MigrateFromFiles(ctx context.Context, dir string, opts MigrationOptions) ([]*MigrationResult, error) {
+	if database.DB == nil && !opts.DryRun {
+		return nil, fmt.Errorf("database connection not initialized")
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read specs directory: %v", err)
+	}
+
+	existing := map[string]*models.OpenAPISpec{}
+	if !opts.DryRun {
+		specs, err := s.specRepo.GetAll()
+		if err != nil {
+			return nil, err
+		}
+		for _, spec := range specs {
+			existing[strings.Trim(spec.EndpointPath, "/")] = spec
+		}
+	}
+
+	// File specs are validated strictly, like the server does in file mode
+	filePipeline := NewSpecPipeline(true)
+	var results []*MigrationResult
+	for _, file := range files {
+		if info, err := os.Stat(file); err != nil || info.IsDir() {
+			continue
+		}
+		result := &MigrationResult{File: file, Endpoint: EndpointFromPath(file)}
+		result.Name = result.Endpoint
+		results = append(results, result)
+		if err := s.migrateFile(ctx, filePipeline, result, existing[result.Endpoint], opts); err != nil {
+			result.Status = MigrationFailed
+			result.Error = err
+		}
+	}
+	return results, nil
+}
+
+// migrateFile imports one spec file unless spec, the database spec at its endpoint, exists,
+// and compares the tools of both modes
+func (s *SpecLoaderService) migrateFile(ctx context.Context, filePipeline *SpecPipeline, result *MigrationResult, spec *models.OpenAPISpec, opts MigrationOptions) error {
+	loaded, err := filePipeline.ProcessFile(ctx, result.File)
+	if err != nil {
+		return err
+	}
+	result.FileTools = loadedToolNames(loaded)
+	result.EnvVar, _ = RequiredEnvVar(loaded)
+
+	var token *string
+	if opts.WithTokens && result.EnvVar != "" {
+		if value := os.Getenv(result.EnvVar); value != "" {
+			token = &value
+		}
+	}
+
+	switch {
+	case opts.DryRun:
+		result.Status = MigrationDryRun
+		result.TokenCopied = token != nil
+		return nil
+	case spec != nil:
+		result.Status = MigrationExisting
+		result.Name = spec.Name
+	default:
+		format := "yaml"
+		if strings.EqualFold(filepath.Ext(result.File), ".json") {
+			format = "json"
+		}
+		if err := s.CreateSpecFromContent(result.Name, "/"+result.Endpoint, string(loaded.Content), format, token); err != nil {
+			return err
+		}
+		result.Status = MigrationImported
+		result.TokenCopied = token != nil
+		if spec, err = s.specRepo.GetByEndpointPath("/" + result.Endpoint); err != nil {
+			return err
+		}
+	}
+
+	dbLoaded, err := s.pipeline.ProcessDBSpec(ctx, spec)
+	if err != nil {
+		return fmt.Errorf("failed to load the database spec: %v", err)
+	}
+	result.DatabaseTools = loadedToolNames(dbLoaded)
+	result.MissingTools = subtractNames(result.FileTools, result.DatabaseTools)
+	result.ExtraTools = subtractNames(result.DatabaseTools, result.FileTools)
+	return nil
+}
+
+// loadedToolNames returns the sorted names of the tools the server mounts for a spec
+func loadedToolNames(loaded *LoadedSpec) []string {
+	srv := openapi2mcp.NewServerWithDatabase(loaded.Doc.Info.Title, loaded.Doc.Info.Version, loaded.Doc, loaded.Spec)
+	names := []string{}
+	for _, tool := range srv.ListTools() {
+		names = append(names, tool.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// subtractNames returns the names in a that are not in b
+func subtractNames(a, b []string) []string {
+	in := make(map[string]bool, len(b))
+	for _, name := range b {
+		in[name] = true
+	}
+	var diff []string
+	for _, name := range a {
+		if !in[name] {
+			diff = append(diff, name)
+		}
+	}
+	return diff
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
+	"github.com/ubermorgenland/openapi-mcp/pkg/memory"
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+	"github.com/ubermorgenland/openapi-mcp/pkg/openapi2mcp"
+	"github.com/ubermorgenland/openapi-mcp/pkg/openapi2mcp/convert"
+)
+
+// PipelineStage identifies a step of the spec loading pipeline
+type PipelineStage string
+
+const (
+	// StageParse turns raw spec content into an OpenAPI document
+	StageParse PipelineStage = "parse"
+	// StageValidate checks the parsed document against the OpenAPI schema
+	StageValidate PipelineStage = "validate"
+	// StageOptimize reduces the memory footprint of the document
+	StageOptimize PipelineStage = "optimize"
+	// StageMount derives the metadata needed to mount the spec (endpoint, operations, auth)
+	StageMount PipelineStage = "mount"
+)
+
+// SpecHook is run after the built-in work of a pipeline stage.
+// Returning an error rejects the spec.
+type SpecHook func(ctx context.Context, spec *LoadedSpec) error
+
+// LoadedSpec is an OpenAPI spec that went through the loading pipeline
+type LoadedSpec struct {
+	Endpoint   string              // endpoint path without leading slash
+	Doc        *openapi3.T         // parsed document
+	Spec       *models.OpenAPISpec // database record, or a synthetic one for file-based specs
+	Content    []byte              // raw spec content
+	Operations []openapi2mcp.OpenAPIOperation
+
+	AuthSchemeName string
+	AuthType       string
+	AuthPath       string
+
+	// ValidationErr holds the validation error of a spec accepted in lenient mode
+	ValidationErr error
+	LoadedAt      time.Time
+}
+
+// Title returns the API title, or the endpoint if the spec has none
+func (ls *LoadedSpec) Title() string {
+	if ls.Doc != nil && ls.Doc.Info != nil && ls.Doc.Info.Title != "" {
+		return ls.Doc.Info.Title
+	}
+	return ls.Endpoint
+}
+
+// SpecPipeline is the single path every spec takes before being served:
+// parse → validate → optimize → mount metadata. Extra behaviour is added
+// with Use instead of forking the loading code.
+type SpecPipeline struct {
+	strictValidation bool
+	hooks            map[PipelineStage][]SpecHook
+}
+
+// NewSpecPipeline creates a spec loading pipeline. With strictValidation, specs
+// failing OpenAPI validation are rejected; otherwise they are loaded with a warning.
+func NewSpecPipeline(strictValidation bool) *SpecPipeline {
+	return &SpecPipeline{
+		strictValidation: strictValidation,
+		hooks:            make(map[PipelineStage][]SpecHook),
+	}
+}
+
+// Use registers a hook that runs after the given stage. Hooks run in registration order.
+func (p *SpecPipeline) Use(stage PipelineStage, hook SpecHook) *SpecPipeline {
+	p.hooks[stage] = append(p.hooks[stage], hook)
+	return p
+}
+
+// Process runs raw spec content through the pipeline. spec may be nil for specs
+// that don't come from the database; a synthetic record is created so that
+// downstream code can rely on the raw content for header casing.
+func (p *SpecPipeline) Process(ctx context.Context, endpoint string, content []byte, spec *models.OpenAPISpec) (*LoadedSpec, error) {
+	// Swagger 2.0 files are served too; imports store them already converted
+	if convert.IsSwagger2(content) {
+		converted, err := convert.Swagger2ToOpenAPI3(content)
+		if err != nil {
+			return nil, err
+		}
+		content = converted
+	}
+	ls := &LoadedSpec{
+		Endpoint: strings.Trim(endpoint, "/"),
+		Spec:     spec,
+		Content:  content,
+		LoadedAt: time.Now(),
+	}
+
+	// Parse
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %v", err)
+	}
+	ls.Doc = doc
+	// Specs can drop operations themselves, e.g. the admin endpoints of a third-party API
+	if n, err := openapi2mcp.ExcludeOperations(doc, openapi2mcp.SpecExcludedOperations(doc)); err != nil {
+		return nil, err
+	} else if n > 0 {
+		fmt.Fprintf(os.Stderr, "Excluded %d operations from spec for endpoint '%s'\n", n, ls.Endpoint)
+	}
+	if err := p.runHooks(ctx, StageParse, ls); err != nil {
+		return nil, err
+	}
+
+	// Validate
+	if err := doc.Validate(loader.Context); err != nil {
+		if p.strictValidation {
+			return nil, fmt.Errorf("OpenAPI spec validation failed: %v", err)
+		}
+		ls.ValidationErr = err
+		fmt.Fprintf(os.Stderr, "Warning: spec for endpoint '%s' failed validation, loading anyway: %v\n", ls.Endpoint, err)
+	}
+	if err := p.runHooks(ctx, StageValidate, ls); err != nil {
+		return nil, err
+	}
+
+	// Optimize: nothing by default, as examples and descriptions feed tool
+	// documentation and the mock upstream. See OptimizeHook.
+	if err := p.runHooks(ctx, StageOptimize, ls); err != nil {
+		return nil, err
+	}
+
+	// Mount metadata
+	if ls.Spec == nil {
+		ls.Spec = &models.OpenAPISpec{
+			Name:         ls.Endpoint,
+			SpecContent:  string(content),
+			EndpointPath: "/" + ls.Endpoint,
+		}
+	}
+	ls.Operations = openapi2mcp.ExtractOpenAPIOperations(doc)
+	ls.AuthSchemeName, ls.AuthType, ls.AuthPath = auth.ExtractAuthSchemeFromSpecWithContent(doc, string(content))
+	if err := p.runHooks(ctx, StageMount, ls); err != nil {
+		return nil, err
+	}
+
+	return ls, nil
+}
+
+// ProcessDBSpec runs a database spec through the pipeline
+func (p *SpecPipeline) ProcessDBSpec(ctx context.Context, spec *models.OpenAPISpec) (*LoadedSpec, error) {
+	return p.Process(ctx, spec.EndpointPath, []byte(spec.SpecContent), spec)
+}
+
+// ProcessFile reads a spec from a local file or an http(s) URL and runs it through
+// the pipeline. The endpoint is derived from the file name.
+func (p *SpecPipeline) ProcessFile(ctx context.Context, path string) (*LoadedSpec, error) {
+	var content []byte
+	var err error
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		content, err = fetchSpec(ctx, path)
+	} else {
+		content, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec %s: %v", path, err)
+	}
+	return p.Process(ctx, EndpointFromPath(path), content, nil)
+}
+
+func (p *SpecPipeline) runHooks(ctx context.Context, stage PipelineStage, ls *LoadedSpec) error {
+	for _, hook := range p.hooks[stage] {
+		if err := hook(ctx, ls); err != nil {
+			return fmt.Errorf("%s hook rejected spec for endpoint '%s': %v", stage, ls.Endpoint, err)
+		}
+	}
+	return nil
+}
+
+// EndpointFromPath derives an endpoint name from a spec file path or URL:
+// the base name without extension, with underscores replaced by hyphens.
+func EndpointFromPath(path string) string {
+	if idx := strings.Index(path, "?"); idx != -1 {
+		path = path[:idx]
+	}
+	name := filepath.Base(path)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	return strings.ReplaceAll(name, "_", "-")
+}
+
+// OptimizeHook returns a StageOptimize hook that strips examples and other fields
+// not needed to serve the spec, to reduce memory usage. It is opt-in because tool
+// documentation and the mock upstream lose information.
+func OptimizeHook(maxMemoryMB int64) SpecHook {
+	optimizer := memory.NewMemoryEfficientSpecLoader(maxMemoryMB, 0)
+	return func(ctx context.Context, spec *LoadedSpec) error {
+		return optimizer.OptimizeSpec(spec.Doc)
+	}
+}
+
+// RequiredEnvVar returns the environment variable that supplies credentials for a
+// file-based spec, with a description, or "" if the spec needs none.
+func RequiredEnvVar(spec *LoadedSpec) (string, string) {
+	if spec.AuthPath == "" {
+		return "", ""
+	}
+	prefix := strings.ToUpper(spec.Endpoint)
+	switch spec.AuthType {
+	case "apiKey":
+		return prefix + "_API_KEY", "API key for " + spec.Title()
+	case "bearer":
+		return prefix + "_BEARER_TOKEN", "Bearer token for " + spec.Title()
+	case "basic":
+		return prefix + "_BASIC_AUTH", "Basic auth for " + spec.Title()
+	}
+	return "", ""
+}
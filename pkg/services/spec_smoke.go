@@ -0,0 +1,34 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
+	"github.com/ubermorgenland/openapi-mcp/pkg/openapi2mcp"
+)
+
+// SmokeTestSpec builds the tools of a spec the way the server mounts them and calls its
+// GET tools against the real API with the spec's stored token (see openapi2mcp.SmokeTest).
+// It works for inactive specs too, so a spec and its token can be checked before activation.
+func (s *SpecLoaderService) SmokeTestSpec(ctx context.Context, id int, timeout time.Duration) (*LoadedSpec, []openapi2mcp.SmokeResult, error) {
+	spec, err := s.specRepo.GetByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	loaded, err := s.Pipeline().ProcessDBSpec(ctx, spec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load spec %s: %v", spec.Name, err)
+	}
+	srv := openapi2mcp.NewServerWithDatabase(loaded.Doc.Info.Title, loaded.Doc.Info.Version, loaded.Doc, loaded.Spec)
+
+	// Authenticate like a client of the mounted endpoint that sends no credentials of its own
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/"+loaded.Endpoint, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx = auth.WithAuthContext(ctx, auth.CreateAuthContext(req, loaded.Doc, loaded.Spec))
+	return loaded, openapi2mcp.SmokeTest(ctx, srv, loaded.Operations, timeout), nil
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/auth"
+	"github.com/ubermorgenland/openapi-mcp/pkg/database"
+	"github.com/ubermorgenland/openapi-mcp/pkg/secrets"
+)
+
+// Statuses of a startup check
+const (
+	CheckOK      = "ok"
+	CheckWarning = "warn" // the server starts, but something is likely misconfigured
+	CheckFailed  = "fail" // the server would not start, or not serve a spec
+	CheckSkipped = "skip"
+)
+
+// CheckResult is the outcome of one startup check
+type CheckResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// CheckReport collects the results of the startup checks
+type CheckReport struct {
+	Results []CheckResult `json:"results"`
+}
+
+// Add records the result of a check
+func (r *CheckReport) Add(name, status, detail string) {
+	r.Results = append(r.Results, CheckResult{Name: name, Status: status, Detail: detail})
+}
+
+// Failed reports whether any check failed
+func (r *CheckReport) Failed() bool {
+	for _, res := range r.Results {
+		if res.Status == CheckFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// Print writes the report as a table, followed by a summary line
+func (r *CheckReport) Print(w io.Writer) {
+	counts := map[string]int{}
+	for _, res := range r.Results {
+		counts[res.Status]++
+		fmt.Fprintf(w, "%-5s %-28s %s\n", strings.ToUpper(res.Status), res.Name, res.Detail)
+	}
+	fmt.Fprintf(w, "\n%d ok, %d warnings, %d failed, %d skipped\n",
+		counts[CheckOK], counts[CheckWarning], counts[CheckFailed], counts[CheckSkipped])
+}
+
+// CheckOptions controls RunStartupChecks
+type CheckOptions struct {
+	SpecsDir string // specs directory of file mode (default ./specs)
+	Addr     string // address the server listens on; empty skips the port check
+	Embedded int    // number of specs built into the binary, served when SpecsDir has none
+}
+
+// RunStartupChecks validates what the server needs to start, without serving or changing
+// anything: the DATABASE_URL connection, pending migrations, the specs the server would
+// mount, the credentials of those specs, and whether the listen address is free. It is
+// meant as a pre-deploy gate or init container; migrations are only reported, not run.
+func RunStartupChecks(ctx context.Context, opts CheckOptions) *CheckReport {
+	if opts.SpecsDir == "" {
+		opts.SpecsDir = "./specs"
+	}
+	report := &CheckReport{}
+
+	fileMode := true
+	if os.Getenv("DATABASE_URL") == "" {
+		report.Add("database", CheckSkipped, "DATABASE_URL not set; specs are loaded from "+opts.SpecsDir)
+	} else if db, err := database.Connect(); err != nil {
+		report.Add("database", CheckFailed, err.Error())
+	} else {
+		defer database.Close()
+		report.Add("database", CheckOK, "connected")
+		fileMode = !checkDatabaseSpecs(ctx, report, NewSpecLoaderService(db), opts.SpecsDir)
+	}
+	if fileMode {
+		checkFileSpecs(ctx, report, opts.SpecsDir, opts.Embedded)
+	}
+
+	if opts.Addr != "" {
+		if ln, err := net.Listen("tcp", opts.Addr); err != nil {
+			report.Add("port", CheckFailed, fmt.Sprintf("cannot listen on %s: %v", opts.Addr, err))
+		} else {
+			ln.Close()
+			report.Add("port", CheckOK, opts.Addr+" is available")
+		}
+	}
+	return report
+}
+
+// checkDatabaseSpecs checks the migrations and the active database specs, and reports
+// whether the server would serve them rather than fall back to the specs directory
+func checkDatabaseSpecs(ctx context.Context, report *CheckReport, specLoader *SpecLoaderService, specsDir string) bool {
+	pending, err := database.PendingMigrations(database.DB)
+	switch {
+	case err != nil:
+		report.Add("migrations", CheckFailed, err.Error())
+		return false
+	case len(pending) > 0:
+		// The server runs them on start
+		report.Add("migrations", CheckWarning, "pending, applied on start: "+strings.Join(pending, ", "))
+	default:
+		report.Add("migrations", CheckOK, "schema is up to date")
+	}
+
+	specs, err := specLoader.GetActiveSpecs()
+	if err != nil {
+		if len(pending) > 0 {
+			report.Add("specs", CheckSkipped, "the schema is not migrated yet")
+			return true
+		}
+		report.Add("specs", CheckFailed, err.Error())
+		return false
+	}
+	if len(specs) == 0 {
+		report.Add("specs", CheckWarning, "no active specs in the database; the server falls back to "+specsDir)
+		return false
+	}
+	for _, spec := range specs {
+		name := "spec " + spec.EndpointPath
+		loaded, err := specLoader.Pipeline().ProcessDBSpec(ctx, spec)
+		if err != nil {
+			report.Add(name, CheckFailed, err.Error())
+			continue
+		}
+		report.Add(name, CheckOK, fmt.Sprintf("%s, %d operations", loaded.Title(), len(loaded.Operations)))
+		checkSpecAuth(ctx, report, loaded)
+	}
+	return true
+}
+
+// checkFileSpecs checks the spec files the server mounts in file mode
+func checkFileSpecs(ctx context.Context, report *CheckReport, specsDir string, embedded int) {
+	files, err := filepath.Glob(filepath.Join(specsDir, "*"))
+	if err != nil {
+		report.Add("specs", CheckFailed, fmt.Sprintf("failed to read specs directory: %v", err))
+		return
+	}
+	// File specs are validated strictly, like the server does in file mode
+	pipeline := NewSpecPipeline(true)
+	found := 0
+	for _, file := range files {
+		if info, err := os.Stat(file); err != nil || info.IsDir() {
+			continue
+		}
+		found++
+		name := "spec /" + EndpointFromPath(file)
+		loaded, err := pipeline.ProcessFile(ctx, file)
+		if err != nil {
+			report.Add(name, CheckFailed, err.Error())
+			continue
+		}
+		report.Add(name, CheckOK, fmt.Sprintf("%s, %d operations", loaded.Title(), len(loaded.Operations)))
+		checkSpecAuth(ctx, report, loaded)
+	}
+	if found == 0 && embedded > 0 {
+		report.Add("specs", CheckOK, fmt.Sprintf("no spec files in %s; %d embedded specs are served", specsDir, embedded))
+	} else if found == 0 {
+		report.Add("specs", CheckFailed, "no spec files found in "+specsDir)
+	}
+}
+
+// checkSpecAuth checks that a spec with a security scheme has credentials: a database token,
+// whose secret reference resolves, or its environment variable. Without them the spec is
+// still served, but every call needs credentials from the client, or it is served without
+// tools when it requires a token to activate.
+func checkSpecAuth(ctx context.Context, report *CheckReport, loaded *LoadedSpec) {
+	envVar, _ := RequiredEnvVar(loaded)
+	if envVar == "" {
+		return
+	}
+	name := "auth /" + loaded.Endpoint
+	source, err := CredentialSource(ctx, loaded)
+	switch {
+	case err != nil:
+		report.Add(name, CheckFailed, fmt.Sprintf("failed to resolve the database token: %v", err))
+	case source == "database":
+		creds := auth.SpecCredentials(loaded.Spec)
+		detail := secrets.Describe(creds[0].Token)
+		if len(creds) > 1 {
+			detail = fmt.Sprintf("%d keys", len(creds))
+		}
+		report.Add(name, CheckOK, fmt.Sprintf("%s from the database (%s)", loaded.AuthType, detail))
+	case source != "":
+		report.Add(name, CheckOK, fmt.Sprintf("%s from %s", loaded.AuthType, source))
+	case RequiresToken(loaded):
+		report.Add(name, CheckWarning, fmt.Sprintf("no database token and %s is not set; the spec requires a token to activate and is served without tools", envVar))
+	default:
+		report.Add(name, CheckWarning, fmt.Sprintf("no database token and %s is not set; clients must send credentials", envVar))
+	}
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/database"
+	"github.com/ubermorgenland/openapi-mcp/pkg/models"
+)
+
+// Statuses of a token imported by ImportTokens
+const (
+	TokenSet       = "set"
+	TokenUnchanged = "unchanged" // the spec already has the token
+	TokenDryRun    = "dry-run"
+	TokenSkipped   = "skipped" // the entry has no token
+	TokenUnknown   = "unknown" // no spec has the entry's endpoint
+	TokenFailed    = "failed"
+)
+
+// envVarSuffixes are the suffixes of the environment variables file mode reads a spec's
+// credentials from, see RequiredEnvVar
+var envVarSuffixes = []string{"_API_KEY", "_BEARER_TOKEN", "_BASIC_AUTH"}
+
+// TokenEntry is one line of a token file
+type TokenEntry struct {
+	Line  int
+	Key   string // endpoint, e.g. weather or /weather, or environment variable, e.g. WEATHER_API_KEY
+	Token string
+}
+
+// TokenImportResult describes the import of one token file entry
+type TokenImportResult struct {
+	Entry    TokenEntry
+	SpecID   int
+	Endpoint string
+	Status   string
+	Error    error
+}
+
+// ParseTokenFile reads the entries of a token file. A .csv file has an endpoint and a token
+// per row, with an optional endpoint,token header; any other file is read as a .env file of
+// KEY=token lines, where KEY is an endpoint or an environment variable like WEATHER_API_KEY.
+func ParseTokenFile(path string) ([]TokenEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open token file: %v", err)
+	}
+	defer f.Close()
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return parseTokenCSV(f)
+	}
+	return parseTokenEnv(f)
+}
+
+func parseTokenCSV(r io.Reader) ([]TokenEntry, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	var entries []TokenEntry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV token file: %v", err)
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) != 2 {
+			return nil, fmt.Errorf("line %d: expected an endpoint and a token, got %d fields", line, len(record))
+		}
+		key := strings.TrimSpace(record[0])
+		if len(entries) == 0 && strings.EqualFold(key, "endpoint") {
+			continue // header
+		}
+		entries = append(entries, TokenEntry{Line: line, Key: key, Token: strings.TrimSpace(record[1])})
+	}
+}
+
+func parseTokenEnv(r io.Reader) ([]TokenEntry, error) {
+	var entries []TokenEntry
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(text, "export "), "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=token", line)
+		}
+		token, err := envValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		entries = append(entries, TokenEntry{Line: line, Key: strings.TrimSpace(key), Token: token})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read token file: %v", err)
+	}
+	return entries, nil
+}
+
+// envValue unquotes a .env value: double quotes take Go escapes, single quotes are literal,
+// and a # after whitespace starts a comment in unquoted values
+func envValue(v string) (string, error) {
+	switch {
+	case strings.HasPrefix(v, `"`):
+		end := strings.LastIndex(v, `"`)
+		if end == 0 {
+			return "", fmt.Errorf("unterminated quoted value")
+		}
+		return strconv.Unquote(v[:end+1])
+	case strings.HasPrefix(v, "'"):
+		end := strings.LastIndex(v, "'")
+		if end == 0 {
+			return "", fmt.Errorf("unterminated quoted value")
+		}
+		return v[1:end], nil
+	}
+	if i := strings.Index(v, " #"); i >= 0 {
+		v = v[:i]
+	}
+	return strings.TrimSpace(v), nil
+}
+
+// ImportTokens sets the API key token of the spec each entry names, by endpoint or by the
+// environment variable file mode reads its credentials from. Entries without a token are
+// skipped rather than clearing the spec's token, and entries naming a spec another entry
+// already set fail. With dryRun, the entries are matched without writing to the database.
+func (s *SpecLoaderService) ImportTokens(entries []TokenEntry, dryRun bool) ([]*TokenImportResult, error) {
+	if database.DB == nil {
+		return nil, fmt.Errorf("database connection not initialized")
+	}
+	specs, err := s.specRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	setBy := map[int]int{} // spec ID -> line of the entry setting its token
+	results := make([]*TokenImportResult, 0, len(entries))
+	for _, entry := range entries {
+		result := &TokenImportResult{Entry: entry}
+		results = append(results, result)
+		spec := specForTokenKey(specs, entry.Key)
+		if spec == nil {
+			result.Status = TokenUnknown
+			continue
+		}
+		result.SpecID, result.Endpoint = spec.ID, spec.EndpointPath
+		line, dup := setBy[spec.ID]
+		switch {
+		case entry.Token == "":
+			result.Status = TokenSkipped
+		case dup:
+			result.Status = TokenFailed
+			result.Error = fmt.Errorf("the token of this spec is already set on line %d", line)
+		case spec.ApiKeyToken != nil && *spec.ApiKeyToken == entry.Token:
+			result.Status = TokenUnchanged
+		case dryRun:
+			result.Status = TokenDryRun
+		default:
+			token := entry.Token
+			if err := s.specRepo.UpdateApiKeyToken(spec.ID, &token); err != nil {
+				result.Status = TokenFailed
+				result.Error = err
+			} else {
+				result.Status = TokenSet
+			}
+		}
+		if entry.Token != "" && !dup {
+			setBy[spec.ID] = entry.Line
+		}
+	}
+	return results, nil
+}
+
+// specForTokenKey returns the spec a token file key names: its endpoint, with or without
+// slashes, or an environment variable like WEATHER_API_KEY
+func specForTokenKey(specs []*models.OpenAPISpec, key string) *models.OpenAPISpec {
+	key = strings.Trim(key, "/")
+	for _, spec := range specs {
+		endpoint := strings.Trim(spec.EndpointPath, "/")
+		if key == endpoint {
+			return spec
+		}
+		for _, suffix := range envVarSuffixes {
+			if key == strings.ToUpper(endpoint)+suffix {
+				return spec
+			}
+		}
+	}
+	return nil
+}
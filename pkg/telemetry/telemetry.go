@@ -0,0 +1,277 @@
+// Package telemetry reports anonymous usage counts, so maintainers can tell which features
+// are used. It is off unless the operator opts in, and TELEMETRY=off (or DO_NOT_TRACK) turns
+// it off whatever else is configured.
+//
+// A Report is the complete payload: counts and the build and platform, with a random
+// instance ID generated at each start so reports of one process can be told apart. Spec
+// names, endpoints, URLs, host names, IP addresses, tool names, arguments and credentials are
+// never sent.
+//
+//	reporter := telemetry.New(telemetry.ConfigFromEnv("1.2.0"), collect)
+//	reporter.Start()
+//	defer reporter.Stop()
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultInterval is how often a report is sent when Config.Interval is not set.
+const DefaultInterval = 24 * time.Hour
+
+// firstReportDelay is how long after Start the first report is sent, so short-lived
+// processes such as --check runs report nothing.
+const firstReportDelay = 10 * time.Minute
+
+// Report is the payload of a telemetry report, sent as a JSON POST.
+type Report struct {
+	InstanceID  string         `json:"instance_id"` // random, generated at each start
+	Version     string         `json:"version"`
+	GoVersion   string         `json:"go_version"`
+	OS          string         `json:"os"`
+	Arch        string         `json:"arch"`
+	UptimeHours float64        `json:"uptime_hours"`
+	Specs       int            `json:"specs"`      // mounted specs
+	ToolCalls   int64          `json:"tool_calls"` // since the previous report
+	Transports  map[string]int `json:"transports"` // sessions in progress by transport, e.g. "streamable_http"
+	Database    bool           `json:"database"`   // specs are loaded from a database rather than files
+}
+
+// Counts are the usage counts a Collector returns. ToolCalls is a running total; reports carry
+// the calls since the previous report.
+type Counts struct {
+	Specs      int
+	ToolCalls  int64
+	Transports map[string]int
+	Database   bool
+}
+
+// Collector returns the current usage counts.
+type Collector func() Counts
+
+// Mode is whether and how reports are made.
+type Mode string
+
+const (
+	ModeOff Mode = "off" // no reports; the default
+	ModeOn  Mode = "on"  // reports are sent to Config.URL
+	ModeLog Mode = "log" // reports are only logged, to see what would be sent
+)
+
+// envForce turns telemetry off when set to off, whatever MCP_TELEMETRY is.
+const envForce = "TELEMETRY"
+
+// Config configures a Reporter.
+type Config struct {
+	Mode     Mode
+	URL      string        // where reports are POSTed in ModeOn
+	Interval time.Duration // 0 uses DefaultInterval
+	Version  string        // build version reported
+}
+
+// ConfigFromEnv reads the telemetry settings: MCP_TELEMETRY=on sends reports to
+// MCP_TELEMETRY_URL every MCP_TELEMETRY_INTERVAL (default 24h), MCP_TELEMETRY=log only logs
+// them. TELEMETRY=off or DO_NOT_TRACK=1 turn telemetry off whatever else is set.
+func ConfigFromEnv(version string) Config {
+	cfg := Config{Mode: ModeOff, URL: os.Getenv("MCP_TELEMETRY_URL"), Version: version}
+	if forcedOff() {
+		return cfg
+	}
+	switch v := strings.ToLower(strings.TrimSpace(os.Getenv("MCP_TELEMETRY"))); v {
+	case "", "off", "false", "0", "no":
+	case "on", "true", "1", "yes":
+		cfg.Mode = ModeOn
+		if cfg.URL == "" {
+			log.Printf("MCP_TELEMETRY is on but MCP_TELEMETRY_URL is not set; telemetry reports are only logged")
+			cfg.Mode = ModeLog
+		}
+	case "log":
+		cfg.Mode = ModeLog
+	default:
+		log.Printf("Invalid MCP_TELEMETRY %q, telemetry is off", v)
+	}
+	if v := os.Getenv("MCP_TELEMETRY_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < time.Minute {
+			log.Printf("Invalid MCP_TELEMETRY_INTERVAL %q, using %s", v, DefaultInterval)
+		} else {
+			cfg.Interval = d
+		}
+	}
+	return cfg
+}
+
+// forcedOff reports whether TELEMETRY=off or DO_NOT_TRACK is set.
+func forcedOff() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(envForce))) {
+	case "off", "false", "0", "no":
+		return true
+	}
+	if v := os.Getenv("DO_NOT_TRACK"); v != "" {
+		off, err := strconv.ParseBool(v)
+		return err != nil || off
+	}
+	return false
+}
+
+// Reporter sends a report at every interval while it runs. It is safe for concurrent use.
+type Reporter struct {
+	cfg        Config
+	collect    Collector
+	client     *http.Client
+	instanceID string
+	started    time.Time
+
+	mu        sync.Mutex
+	lastCalls int64
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// New creates a stopped reporter; see Start. A reporter of a config in ModeOff does nothing.
+func New(cfg Config, collect Collector) *Reporter {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	id := make([]byte, 16)
+	rand.Read(id)
+	return &Reporter{
+		cfg:        cfg,
+		collect:    collect,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		instanceID: hex.EncodeToString(id),
+		started:    time.Now(),
+	}
+}
+
+// Enabled reports whether the reporter makes reports.
+func (r *Reporter) Enabled() bool {
+	return r != nil && (r.cfg.Mode == ModeOn || r.cfg.Mode == ModeLog)
+}
+
+// Start sends the first report after a few minutes, then one at every interval, until Stop.
+func (r *Reporter) Start() {
+	if !r.Enabled() {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stop != nil {
+		return
+	}
+	// Counts kept across restarts, such as persisted latency stats, are not reported again
+	r.lastCalls = r.collect().ToolCalls
+	r.stop, r.done = make(chan struct{}), make(chan struct{})
+	go r.run(r.stop, r.done)
+	if r.cfg.Mode == ModeOn {
+		log.Printf("Anonymous usage telemetry is on: a report is sent every %s (TELEMETRY=off turns it off)", r.cfg.Interval)
+	} else {
+		log.Printf("Anonymous usage telemetry reports are logged every %s and not sent", r.cfg.Interval)
+	}
+}
+
+// Stop stops sending reports.
+func (r *Reporter) Stop() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	stop, done := r.stop, r.done
+	r.stop, r.done = nil, nil
+	r.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+func (r *Reporter) run(stop, done chan struct{}) {
+	defer close(done)
+	timer := time.NewTimer(min(firstReportDelay, r.cfg.Interval))
+	defer timer.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-timer.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := r.Send(ctx); err != nil {
+				log.Printf("Failed to send the telemetry report: %v", err)
+			}
+			cancel()
+			timer.Reset(r.cfg.Interval)
+		}
+	}
+}
+
+// Build returns the report of the current counts; the calls since the previous report are
+// counted as reported.
+func (r *Reporter) Build() Report {
+	counts := r.collect()
+	r.mu.Lock()
+	calls := counts.ToolCalls - r.lastCalls
+	if calls < 0 {
+		calls = counts.ToolCalls
+	}
+	r.lastCalls = counts.ToolCalls
+	r.mu.Unlock()
+	transports := counts.Transports
+	if transports == nil {
+		transports = map[string]int{}
+	}
+	return Report{
+		InstanceID:  r.instanceID,
+		Version:     r.cfg.Version,
+		GoVersion:   runtime.Version(),
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		UptimeHours: float64(time.Since(r.started).Round(time.Minute)) / float64(time.Hour),
+		Specs:       counts.Specs,
+		ToolCalls:   calls,
+		Transports:  transports,
+		Database:    counts.Database,
+	}
+}
+
+// Send builds a report and sends it, or logs it in ModeLog. TELEMETRY=off is checked again,
+// so it stops reports of a running process whose environment changed.
+func (r *Reporter) Send(ctx context.Context) error {
+	if !r.Enabled() || forcedOff() {
+		return nil
+	}
+	body, err := json.Marshal(r.Build())
+	if err != nil {
+		return err
+	}
+	if r.cfg.Mode == ModeLog {
+		log.Printf("Telemetry report (not sent): %s", body)
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("telemetry endpoint returned %s", resp.Status)
+	}
+	return nil
+}
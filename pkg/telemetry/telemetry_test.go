@@ -0,0 +1,42 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		wantMode     Mode
+		wantInterval time.Duration
+	}{
+		{name: "off by default", wantMode: ModeOff},
+		{name: "explicitly off", env: map[string]string{"MCP_TELEMETRY": "off"}, wantMode: ModeOff},
+		{name: "opt in", env: map[string]string{"MCP_TELEMETRY": "on", "MCP_TELEMETRY_URL": "https://t.example.com"}, wantMode: ModeOn},
+		{name: "opt in is case-insensitive", env: map[string]string{"MCP_TELEMETRY": " True ", "MCP_TELEMETRY_URL": "https://t.example.com"}, wantMode: ModeOn},
+		{name: "opt in without a URL only logs", env: map[string]string{"MCP_TELEMETRY": "on"}, wantMode: ModeLog},
+		{name: "log only", env: map[string]string{"MCP_TELEMETRY": "log"}, wantMode: ModeLog},
+		{name: "invalid mode", env: map[string]string{"MCP_TELEMETRY": "sometimes", "MCP_TELEMETRY_URL": "https://t.example.com"}, wantMode: ModeOff},
+		{name: "TELEMETRY=off overrides opt in", env: map[string]string{"MCP_TELEMETRY": "on", "MCP_TELEMETRY_URL": "https://t.example.com", "TELEMETRY": "off"}, wantMode: ModeOff},
+		{name: "TELEMETRY=on does not opt in", env: map[string]string{"TELEMETRY": "on"}, wantMode: ModeOff},
+		{name: "DO_NOT_TRACK overrides opt in", env: map[string]string{"MCP_TELEMETRY": "log", "DO_NOT_TRACK": "1"}, wantMode: ModeOff},
+		{name: "DO_NOT_TRACK=0 allows opt in", env: map[string]string{"MCP_TELEMETRY": "log", "DO_NOT_TRACK": "0"}, wantMode: ModeLog},
+		{name: "invalid DO_NOT_TRACK turns it off", env: map[string]string{"MCP_TELEMETRY": "log", "DO_NOT_TRACK": "please"}, wantMode: ModeOff},
+		{name: "interval", env: map[string]string{"MCP_TELEMETRY": "log", "MCP_TELEMETRY_INTERVAL": "6h"}, wantMode: ModeLog, wantInterval: 6 * time.Hour},
+		{name: "invalid interval", env: map[string]string{"MCP_TELEMETRY": "log", "MCP_TELEMETRY_INTERVAL": "daily"}, wantMode: ModeLog},
+		{name: "interval below a minute", env: map[string]string{"MCP_TELEMETRY": "log", "MCP_TELEMETRY_INTERVAL": "30s"}, wantMode: ModeLog},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"MCP_TELEMETRY", "MCP_TELEMETRY_URL", "MCP_TELEMETRY_INTERVAL", envForce, "DO_NOT_TRACK"} {
+				t.Setenv(name, tt.env[name])
+			}
+			cfg := ConfigFromEnv("1.2.0")
+			if cfg.Mode != tt.wantMode || cfg.Interval != tt.wantInterval || cfg.Version != "1.2.0" {
+				t.Errorf("expected mode %q and interval %s, got %+v", tt.wantMode, tt.wantInterval, cfg)
+			}
+		})
+	}
+}
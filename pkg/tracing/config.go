@@ -0,0 +1,212 @@
+package tracing
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Exporters.
+const (
+	ExporterNone    = "none"
+	ExporterOTLP    = "otlp"    // OTLP over HTTP with JSON encoding
+	ExporterConsole = "console" // one JSON line per span on stderr
+)
+
+// Config configures a Tracer.
+type Config struct {
+	ServiceName        string
+	Exporter           string            // ExporterOTLP, ExporterConsole or ExporterNone
+	Endpoint           string            // OTLP traces URL, e.g. http://localhost:4318/v1/traces
+	Headers            map[string]string // sent with every OTLP request, e.g. for authentication
+	Timeout            time.Duration     // per export; default 10s
+	ResourceAttributes map[string]string
+	SampleRatio        float64 // share of new traces recorded, 0 to 1
+	ParentBased        bool    // follow the sampling decision of the parent span when there is one
+	BatchTimeout       time.Duration
+	MaxBatchSize       int
+	MaxQueueSize       int
+}
+
+func (c Config) withDefaults() Config {
+	if c.ServiceName == "" {
+		c.ServiceName = "openapi-mcp"
+	}
+	if c.Endpoint == "" {
+		c.Endpoint = "http://localhost:4318/v1/traces"
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.BatchTimeout <= 0 {
+		c.BatchTimeout = 5 * time.Second
+	}
+	if c.MaxBatchSize <= 0 {
+		c.MaxBatchSize = 512
+	}
+	if c.MaxQueueSize <= 0 {
+		c.MaxQueueSize = 2048
+	}
+	return c
+}
+
+// sample decides whether a new span of the trace is recorded.
+func (c Config) sample(parent SpanContext, id TraceID) bool {
+	if c.ParentBased && parent.IsValid() {
+		return parent.Sampled
+	}
+	if c.SampleRatio >= 1 {
+		return true
+	}
+	return traceIDRatio(id) < c.SampleRatio
+}
+
+// ConfigFromEnv reads the standard OpenTelemetry environment variables:
+//
+//	OTEL_SDK_DISABLED                   true turns tracing off
+//	OTEL_TRACES_EXPORTER                otlp, console or none; otlp when an OTLP endpoint is set, none otherwise
+//	OTEL_EXPORTER_OTLP_TRACES_ENDPOINT  OTLP traces URL, used as is
+//	OTEL_EXPORTER_OTLP_ENDPOINT         OTLP base URL, /v1/traces is appended (default http://localhost:4318)
+//	OTEL_EXPORTER_OTLP_[TRACES_]HEADERS key=value pairs separated by commas, values URL-encoded
+//	OTEL_EXPORTER_OTLP_[TRACES_]TIMEOUT export timeout in milliseconds (default 10000)
+//	OTEL_EXPORTER_OTLP_[TRACES_]PROTOCOL only http/json is supported
+//	OTEL_SERVICE_NAME                   service name (default serviceName)
+//	OTEL_RESOURCE_ATTRIBUTES            key=value pairs separated by commas
+//	OTEL_TRACES_SAMPLER                 always_on, always_off, traceidratio or their parentbased_ variants (default parentbased_always_on)
+//	OTEL_TRACES_SAMPLER_ARG             ratio of the traceidratio samplers (default 1)
+//	OTEL_BSP_SCHEDULE_DELAY             export interval in milliseconds (default 5000)
+//	OTEL_BSP_MAX_EXPORT_BATCH_SIZE      spans per export (default 512)
+//	OTEL_BSP_MAX_QUEUE_SIZE             spans buffered before new ones are dropped (default 2048)
+//
+// Invalid values are logged and replaced by their default.
+func ConfigFromEnv(serviceName string) Config {
+	cfg := Config{ServiceName: serviceName, SampleRatio: 1, ParentBased: true}
+	if b, _ := strconv.ParseBool(os.Getenv("OTEL_SDK_DISABLED")); b {
+		cfg.Exporter = ExporterNone
+		return cfg
+	}
+
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" {
+		if base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); base != "" {
+			endpoint = strings.TrimRight(base, "/") + "/v1/traces"
+		}
+	}
+	cfg.Endpoint = endpoint
+
+	switch exporter := strings.ToLower(strings.TrimSpace(os.Getenv("OTEL_TRACES_EXPORTER"))); exporter {
+	case "":
+		cfg.Exporter = ExporterNone
+		if endpoint != "" {
+			cfg.Exporter = ExporterOTLP
+		}
+	case ExporterOTLP, ExporterConsole, ExporterNone:
+		cfg.Exporter = exporter
+	case "logging":
+		cfg.Exporter = ExporterConsole
+	default:
+		fmt.Fprintf(os.Stderr, "[WARN] Unsupported OTEL_TRACES_EXPORTER %q: use otlp, console or none; tracing is off\n", exporter)
+		cfg.Exporter = ExporterNone
+	}
+
+	if protocol := envFirst("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", "OTEL_EXPORTER_OTLP_PROTOCOL"); protocol != "" && protocol != "http/json" && cfg.Exporter == ExporterOTLP {
+		fmt.Fprintf(os.Stderr, "[WARN] OTLP protocol %q is not supported, exporting with http/json\n", protocol)
+	}
+	if headers := envFirst("OTEL_EXPORTER_OTLP_TRACES_HEADERS", "OTEL_EXPORTER_OTLP_HEADERS"); headers != "" {
+		cfg.Headers = parseKeyValues(headers)
+	}
+	if ms := envMillis(envFirstName("OTEL_EXPORTER_OTLP_TRACES_TIMEOUT", "OTEL_EXPORTER_OTLP_TIMEOUT")); ms > 0 {
+		cfg.Timeout = ms
+	}
+	cfg.BatchTimeout = envMillis("OTEL_BSP_SCHEDULE_DELAY")
+	cfg.MaxBatchSize = envInt("OTEL_BSP_MAX_EXPORT_BATCH_SIZE")
+	cfg.MaxQueueSize = envInt("OTEL_BSP_MAX_QUEUE_SIZE")
+
+	if attrs := os.Getenv("OTEL_RESOURCE_ATTRIBUTES"); attrs != "" {
+		cfg.ResourceAttributes = parseKeyValues(attrs)
+		if name := cfg.ResourceAttributes["service.name"]; name != "" {
+			cfg.ServiceName = name
+		}
+	}
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		cfg.ServiceName = name
+	}
+
+	arg := 1.0
+	if v := os.Getenv("OTEL_TRACES_SAMPLER_ARG"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || f > 1 {
+			fmt.Fprintf(os.Stderr, "[WARN] Invalid OTEL_TRACES_SAMPLER_ARG %q: expected a ratio from 0 to 1, using 1\n", v)
+		} else {
+			arg = f
+		}
+	}
+	switch sampler := strings.ToLower(strings.TrimSpace(os.Getenv("OTEL_TRACES_SAMPLER"))); sampler {
+	case "", "parentbased_always_on":
+	case "always_on":
+		cfg.ParentBased = false
+	case "always_off":
+		cfg.ParentBased, cfg.SampleRatio = false, 0
+	case "parentbased_always_off":
+		cfg.SampleRatio = 0
+	case "traceidratio":
+		cfg.ParentBased, cfg.SampleRatio = false, arg
+	case "parentbased_traceidratio":
+		cfg.SampleRatio = arg
+	default:
+		fmt.Fprintf(os.Stderr, "[WARN] Unsupported OTEL_TRACES_SAMPLER %q, using parentbased_always_on\n", sampler)
+	}
+	return cfg
+}
+
+// envFirst returns the first set variable of names.
+func envFirst(names ...string) string {
+	return os.Getenv(envFirstName(names...))
+}
+
+func envFirstName(names ...string) string {
+	for _, name := range names {
+		if os.Getenv(name) != "" {
+			return name
+		}
+	}
+	return names[len(names)-1]
+}
+
+func envMillis(name string) time.Duration {
+	return time.Duration(envInt(name)) * time.Millisecond
+}
+
+func envInt(name string) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		fmt.Fprintf(os.Stderr, "[WARN] Invalid %s %q: expected a positive integer, using the default\n", name, v)
+		return 0
+	}
+	return n
+}
+
+// parseKeyValues reads "key1=value1,key2=value2" with URL-encoded values, as used by
+// OTEL_EXPORTER_OTLP_HEADERS and OTEL_RESOURCE_ATTRIBUTES.
+func parseKeyValues(s string) map[string]string {
+	values := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			continue
+		}
+		if decoded, err := url.PathUnescape(strings.TrimSpace(value)); err == nil {
+			value = decoded
+		}
+		values[key] = strings.TrimSpace(value)
+	}
+	return values
+}
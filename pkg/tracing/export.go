@@ -0,0 +1,187 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// exporter sends a batch of ended spans.
+type exporter interface {
+	export(ctx context.Context, spans []*Span) error
+}
+
+// scopeName is the instrumentation scope of the spans.
+const scopeName = "github.com/ubermorgenland/openapi-mcp/pkg/tracing"
+
+// OTLP JSON encoding of spans, see
+// https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		TraceState        string          `json:"traceState,omitempty"`
+		Name              string          `json:"name"`
+		Kind              SpanKind        `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            otlpStatus      `json:"status"`
+	}
+	otlpAttribute struct {
+		Key   string         `json:"key"`
+		Value map[string]any `json:"value"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code,omitempty"` // 2 is an error; unset otherwise
+		Message string `json:"message,omitempty"`
+	}
+)
+
+func otlpValue(v any) map[string]any {
+	switch v := v.(type) {
+	case string:
+		return map[string]any{"stringValue": v}
+	case bool:
+		return map[string]any{"boolValue": v}
+	case int:
+		return map[string]any{"intValue": strconv.Itoa(v)}
+	case int64:
+		return map[string]any{"intValue": strconv.FormatInt(v, 10)}
+	case float64:
+		return map[string]any{"doubleValue": v}
+	}
+	return map[string]any{"stringValue": fmt.Sprint(v)}
+}
+
+func otlpAttributes(attrs map[string]string) []otlpAttribute {
+	var out []otlpAttribute
+	for k, v := range attrs {
+		out = append(out, otlpAttribute{Key: k, Value: otlpValue(v)})
+	}
+	return out
+}
+
+func (s *Span) otlp() otlpSpan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	span := otlpSpan{
+		TraceID:           s.sc.TraceID.String(),
+		SpanID:            s.sc.SpanID.String(),
+		TraceState:        s.sc.TraceState,
+		Name:              s.name,
+		Kind:              s.kind,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+	}
+	if s.parent.IsValid() {
+		span.ParentSpanID = s.parent.String()
+	}
+	for _, a := range s.attrs {
+		span.Attributes = append(span.Attributes, otlpAttribute{Key: a.key, Value: otlpValue(a.value)})
+	}
+	if s.failed {
+		span.Status = otlpStatus{Code: 2, Message: s.status}
+	}
+	return span
+}
+
+// resourceAttributes returns the resource attributes of cfg, with service.name set.
+func resourceAttributes(cfg Config) map[string]string {
+	attrs := map[string]string{"telemetry.sdk.name": "openapi-mcp", "telemetry.sdk.language": "go"}
+	for k, v := range cfg.ResourceAttributes {
+		attrs[k] = v
+	}
+	attrs["service.name"] = cfg.ServiceName
+	return attrs
+}
+
+// otlpExporter posts batches to an OTLP/HTTP endpoint with JSON encoding.
+type otlpExporter struct {
+	cfg      Config
+	client   *http.Client
+	resource []otlpAttribute
+}
+
+func newOTLPExporter(cfg Config) *otlpExporter {
+	return &otlpExporter{cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}, resource: otlpAttributes(resourceAttributes(cfg))}
+}
+
+func (e *otlpExporter) export(ctx context.Context, spans []*Span) error {
+	scope := otlpScopeSpans{Scope: otlpScope{Name: scopeName}}
+	for _, s := range spans {
+		scope.Spans = append(scope.Spans, s.otlp())
+	}
+	body, err := json.Marshal(otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: e.resource},
+		ScopeSpans: []otlpScopeSpans{scope},
+	}}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned HTTP %d", e.cfg.Endpoint, resp.StatusCode)
+	}
+	return nil
+}
+
+// consoleExporter writes one JSON line per span, for debugging without a collector. It
+// writes to stderr, as stdout carries the MCP protocol in stdio mode.
+type consoleExporter struct {
+	cfg Config
+	mu  sync.Mutex
+	w   io.Writer
+}
+
+func (e *consoleExporter) export(ctx context.Context, spans []*Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	enc := json.NewEncoder(e.w)
+	for _, s := range spans {
+		line := struct {
+			Service string `json:"service"`
+			otlpSpan
+		}{e.cfg.ServiceName, s.otlp()}
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,399 @@
+// Package tracing records OpenTelemetry spans of the request path: the HTTP request of an
+// MCP transport, the MCP message it carries and the upstream API calls of tool handlers.
+// Trace context is read from and written to W3C traceparent headers, so a trace started by
+// an MCP client continues through the server to the upstream API. Spans are exported with
+// OTLP over HTTP (JSON encoding) or printed to stderr, as configured by the standard OTEL_*
+// environment variables; see ConfigFromEnv.
+//
+// Tracing is off unless configured. Library users get the same configuration through
+// Default, or set their own tracer:
+//
+//	tracing.SetDefault(tracing.New(tracing.Config{ServiceName: "petstore-mcp", Exporter: tracing.ExporterOTLP, Endpoint: "http://collector:4318/v1/traces"}))
+//	defer tracing.Shutdown(ctx)
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+func (s SpanID) String() string  { return hex.EncodeToString(s[:]) }
+
+// IsValid reports whether the ID is not all zeros.
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+// IsValid reports whether the ID is not all zeros.
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+// SpanContext is the part of a span propagated to other processes.
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Sampled    bool
+	TraceState string // opaque vendor data of the tracestate header, passed on as is
+	Remote     bool   // read from an incoming request
+}
+
+// IsValid reports whether the span context has a trace and span ID.
+func (sc SpanContext) IsValid() bool { return sc.TraceID.IsValid() && sc.SpanID.IsValid() }
+
+// SpanKind is the OTLP kind of a span.
+type SpanKind int
+
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+// Span is an operation of a trace. A nil *Span is valid and records nothing, so callers
+// never need to check whether tracing is on.
+type Span struct {
+	tracer    *Tracer
+	name      string
+	kind      SpanKind
+	sc        SpanContext
+	parent    SpanID
+	start     time.Time
+	recording bool
+
+	mu     sync.Mutex
+	end    time.Time
+	attrs  []attribute
+	failed bool
+	status string
+	ended  bool
+}
+
+type attribute struct {
+	key   string
+	value any
+}
+
+// SpanContext returns the span context, which is invalid for a nil span.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// IsRecording reports whether the span will be exported.
+func (s *Span) IsRecording() bool {
+	return s != nil && s.recording
+}
+
+// SetAttribute sets an attribute; values are strings, bools, integers or floats, anything
+// else is recorded as its fmt representation.
+func (s *Span) SetAttribute(key string, value any) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.attrs {
+		if s.attrs[i].key == key {
+			s.attrs[i].value = value
+			return
+		}
+	}
+	s.attrs = append(s.attrs, attribute{key, value})
+}
+
+// SetError marks the span as failed with the error message; a nil error does nothing.
+func (s *Span) SetError(err error) {
+	if err == nil {
+		return
+	}
+	s.Fail(err.Error())
+}
+
+// Fail marks the span as failed with a status message.
+func (s *Span) Fail(message string) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed, s.status = true, message
+}
+
+// End ends the span and queues it for export. Calls after the first do nothing.
+func (s *Span) End() {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended, s.end = true, time.Now()
+	s.mu.Unlock()
+	s.tracer.enqueue(s)
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns ctx carrying span as the parent of spans started from it.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the current span of ctx, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+type remoteKey struct{}
+
+// ContextWithRemoteSpanContext returns ctx carrying sc, read from another process, as the
+// parent of spans started from it.
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	sc.Remote = true
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context of the current span of ctx, or the
+// remote one it carries.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if s := SpanFromContext(ctx); s != nil {
+		return s.sc
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
+
+// Tracer starts spans and exports the ended ones in batches. A nil *Tracer is valid and
+// starts no spans.
+type Tracer struct {
+	cfg      Config
+	exporter exporter
+	queue    chan *Span
+	flush    chan chan struct{}
+	done     chan struct{}
+	wg       sync.WaitGroup
+	closed   atomic.Bool
+	dropped  atomic.Uint64
+	failures atomic.Uint64
+}
+
+// New starts a tracer, or returns nil when cfg.Exporter is ExporterNone or empty.
+func New(cfg Config) *Tracer {
+	cfg = cfg.withDefaults()
+	var exp exporter
+	switch cfg.Exporter {
+	case ExporterOTLP:
+		exp = newOTLPExporter(cfg)
+	case ExporterConsole:
+		exp = &consoleExporter{cfg: cfg, w: os.Stderr}
+	default:
+		return nil
+	}
+	t := &Tracer{
+		cfg:      cfg,
+		exporter: exp,
+		queue:    make(chan *Span, cfg.MaxQueueSize),
+		flush:    make(chan chan struct{}),
+		done:     make(chan struct{}),
+	}
+	t.wg.Add(1)
+	go t.run()
+	return t
+}
+
+// Start starts a span named name as a child of the current span of ctx, and returns ctx
+// carrying the new span. The span must be ended with End.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if t == nil || t.closed.Load() {
+		return ctx, nil
+	}
+	parent := SpanContextFromContext(ctx)
+	s := &Span{tracer: t, name: name, kind: kind, start: time.Now()}
+	if parent.IsValid() {
+		s.sc.TraceID, s.parent, s.sc.TraceState = parent.TraceID, parent.SpanID, parent.TraceState
+	} else {
+		rand.Read(s.sc.TraceID[:])
+	}
+	rand.Read(s.sc.SpanID[:])
+	s.sc.Sampled = t.cfg.sample(parent, s.sc.TraceID)
+	s.recording = s.sc.Sampled
+	return ContextWithSpan(ctx, s), s
+}
+
+// Stats returns the number of spans dropped because the export queue was full, and of
+// failed exports.
+func (t *Tracer) Stats() (dropped, failedExports uint64) {
+	if t == nil {
+		return 0, 0
+	}
+	return t.dropped.Load(), t.failures.Load()
+}
+
+func (t *Tracer) enqueue(s *Span) {
+	if t.closed.Load() {
+		return
+	}
+	select {
+	case t.queue <- s:
+	default:
+		// Requests never wait for the exporter
+		t.dropped.Add(1)
+	}
+}
+
+func (t *Tracer) run() {
+	defer t.wg.Done()
+	ticker := time.NewTicker(t.cfg.BatchTimeout)
+	defer ticker.Stop()
+	batch := make([]*Span, 0, t.cfg.MaxBatchSize)
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), t.cfg.Timeout)
+		if err := t.exporter.export(ctx, batch); err != nil {
+			if t.failures.Add(1) == 1 {
+				fmt.Fprintf(os.Stderr, "[WARN] Failed to export trace spans: %v\n", err)
+			}
+		}
+		cancel()
+		batch = make([]*Span, 0, t.cfg.MaxBatchSize)
+	}
+	drain := func() {
+		for {
+			select {
+			case s := <-t.queue:
+				batch = append(batch, s)
+				if len(batch) >= t.cfg.MaxBatchSize {
+					send()
+				}
+			default:
+				send()
+				return
+			}
+		}
+	}
+	for {
+		select {
+		case s := <-t.queue:
+			batch = append(batch, s)
+			if len(batch) >= t.cfg.MaxBatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case ack := <-t.flush:
+			drain()
+			close(ack)
+		case <-t.done:
+			drain()
+			return
+		}
+	}
+}
+
+// ForceFlush exports the ended spans now.
+func (t *Tracer) ForceFlush(ctx context.Context) {
+	if t == nil || t.closed.Load() {
+		return
+	}
+	ack := make(chan struct{})
+	select {
+	case t.flush <- ack:
+	case <-ctx.Done():
+		return
+	}
+	select {
+	case <-ack:
+	case <-ctx.Done():
+	}
+}
+
+// Shutdown exports the ended spans and stops the tracer; spans started afterwards are not
+// recorded. It returns when the export is done or ctx ends.
+func (t *Tracer) Shutdown(ctx context.Context) {
+	if t == nil || !t.closed.CompareAndSwap(false, true) {
+		return
+	}
+	close(t.done)
+	finished := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-ctx.Done():
+	}
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultTracer *Tracer
+	defaultOnce   sync.Once
+)
+
+// Default returns the process-wide tracer, started on first use from the OTEL_* environment
+// variables with ConfigFromEnv("openapi-mcp"), or nil when tracing is off.
+func Default() *Tracer {
+	defaultOnce.Do(func() {
+		t := New(ConfigFromEnv("openapi-mcp"))
+		if t != nil {
+			fmt.Fprintf(os.Stderr, "[INFO] Exporting trace spans of service %q with the %s exporter\n", t.cfg.ServiceName, t.cfg.Exporter)
+		}
+		defaultMu.Lock()
+		defaultTracer = t
+		defaultMu.Unlock()
+	})
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultTracer
+}
+
+// SetDefault replaces the process-wide tracer; nil turns tracing off. The previous tracer is
+// not shut down.
+func SetDefault(t *Tracer) {
+	defaultOnce.Do(func() {})
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultTracer = t
+}
+
+// Enabled reports whether the process-wide tracer records spans.
+func Enabled() bool {
+	return Default() != nil
+}
+
+// Start starts a span with the process-wide tracer; see Tracer.Start.
+func Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	return Default().Start(ctx, name, kind)
+}
+
+// Shutdown exports the ended spans of the process-wide tracer and stops it.
+func Shutdown(ctx context.Context) {
+	defaultMu.RLock()
+	t := defaultTracer
+	defaultMu.RUnlock()
+	t.Shutdown(ctx)
+}
+
+// traceIDRatio maps a trace ID to [0, 1) for ratio sampling, using its random low 8 bytes.
+func traceIDRatio(id TraceID) float64 {
+	return float64(binary.BigEndian.Uint64(id[8:])>>11) / (1 << 53)
+}
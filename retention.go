@@ -0,0 +1,51 @@
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/services"
+)
+
+// defaultDeletedSpecRetention is how long a deleted spec can be restored by default
+const defaultDeletedSpecRetention = 7 * 24 * time.Hour
+
+// deletedSpecRetention reads how long deleted specs can be restored from
+// MCP_DELETED_SPEC_RETENTION, e.g. "72h". 0 keeps them until they are restored.
+func deletedSpecRetention() time.Duration {
+	v := os.Getenv("MCP_DELETED_SPEC_RETENTION")
+	if v == "" {
+		return defaultDeletedSpecRetention
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		log.Printf("Invalid MCP_DELETED_SPEC_RETENTION %q, using the default of %s", v, defaultDeletedSpecRetention)
+		return defaultDeletedSpecRetention
+	}
+	return d
+}
+
+// startSpecPurge permanently removes the specs deleted longer than the retention period
+// ago, now and then every hour
+func startSpecPurge(specLoader *services.SpecLoaderService) {
+	retention := deletedSpecRetention()
+	if retention == 0 {
+		log.Printf("Deleted specs are kept until restored (MCP_DELETED_SPEC_RETENTION=0)")
+		return
+	}
+	purge := func() {
+		purged, err := specLoader.PurgeDeletedSpecs(retention)
+		if err != nil {
+			log.Printf("Failed to purge deleted specs: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d specs deleted more than %s ago", purged, retention)
+		}
+	}
+	purge()
+	go func() {
+		for range time.Tick(time.Hour) {
+			purge()
+		}
+	}()
+}
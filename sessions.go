@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/mcp/server"
+)
+
+// AdminSession is one session in the GET /sessions response
+type AdminSession struct {
+	Endpoint string `json:"endpoint"`
+	server.SessionSummary
+}
+
+// SessionsResponse is the response body of GET /sessions
+type SessionsResponse struct {
+	Count    int            `json:"count"`
+	Sessions []AdminSession `json:"sessions"`
+}
+
+// sessionEndpoints returns a snapshot of the mounted endpoints that track sessions
+func sessionEndpoints() []mountedEndpoint {
+	var endpoints []mountedEndpoint
+	for _, e := range mountedEndpoints() {
+		if e.sessions != nil {
+			endpoints = append(endpoints, e)
+		}
+	}
+	return endpoints
+}
+
+// handleListSessions serves GET /sessions, optionally filtered with ?endpoint=/name
+func handleListSessions(w http.ResponseWriter, r *http.Request) {
+	filter := r.URL.Query().Get("endpoint")
+	if filter != "" && !strings.HasPrefix(filter, "/") {
+		filter = "/" + filter
+	}
+
+	response := SessionsResponse{Sessions: []AdminSession{}}
+	for _, e := range sessionEndpoints() {
+		if filter != "" && e.Path != filter {
+			continue
+		}
+		for _, summary := range e.sessions.Sessions() {
+			response.Sessions = append(response.Sessions, AdminSession{Endpoint: e.Path, SessionSummary: summary})
+		}
+	}
+	response.Count = len(response.Sessions)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleTerminateSession serves DELETE /sessions/{ref}: the session is terminated on
+// whichever endpoint knows it, its open streams are closed and its ID is rejected afterwards.
+// The session is named by the ref GET /sessions lists, or by its ID
+func handleTerminateSession(w http.ResponseWriter, r *http.Request, ref string) {
+	for _, e := range sessionEndpoints() {
+		sessionID := ""
+		for _, summary := range e.sessions.Sessions() {
+			if summary.Ref == ref || summary.ID == ref {
+				sessionID = summary.ID
+				break
+			}
+		}
+		if sessionID == "" {
+			continue
+		}
+		err := e.sessions.TerminateSession(context.Background(), sessionID)
+		if errors.Is(err, server.ErrSessionNotFound) {
+			continue
+		}
+		if err != nil {
+			writeErrorResponse(w, "Failed to terminate session: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Session %s on %s terminated via admin API", server.SessionRef(sessionID), e.Path)
+		writeSuccessResponse(w, "Session terminated", map[string]string{"ref": server.SessionRef(sessionID), "endpoint": e.Path})
+		return
+	}
+	writeErrorResponse(w, "Session not found", http.StatusNotFound)
+}
+
+// sessionsHandler routes /sessions and /sessions/{ref}
+func sessionsHandler(w http.ResponseWriter, r *http.Request) {
+	ref := strings.Trim(strings.TrimPrefix(r.URL.Path, "/sessions"), "/")
+	switch {
+	case ref == "" && r.Method == http.MethodGet:
+		handleListSessions(w, r)
+	case ref != "" && r.Method == http.MethodDelete:
+		handleTerminateSession(w, r, ref)
+	default:
+		writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/dynamicserver"
+	"github.com/ubermorgenland/openapi-mcp/pkg/services"
+)
+
+// StartupEndpoint is one mounted spec in the startup report
+type StartupEndpoint struct {
+	Path       string   `json:"path"`
+	Title      string   `json:"title"`
+	Aliases    []string `json:"aliases,omitempty"`
+	Tools      int      `json:"tools"`
+	Operations int      `json:"operations"`
+	AuthType   string   `json:"auth_type,omitempty"`
+	AuthEnvVar string   `json:"auth_env_var,omitempty"` // environment variable supplying the spec's credentials
+	AuthSet    bool     `json:"auth_set"`               // whether the spec has credentials: a database token or its environment variable
+	Disabled   string   `json:"disabled,omitempty"`     // why the spec is mounted without tools until it has credentials
+}
+
+// StartupReport is the machine-readable summary of what the server came up with, written to
+// STARTUP_REPORT_PATH so deployment pipelines can assert the expected specs are served
+type StartupReport struct {
+	Version     string            `json:"version"`
+	GitCommit   string            `json:"git_commit"`
+	Mode        string            `json:"mode"` // "database" or "file"
+	Addr        string            `json:"addr"`
+	GeneratedAt time.Time         `json:"generated_at"`
+	Endpoints   []StartupEndpoint `json:"endpoints"`
+	Warnings    []string          `json:"warnings"`
+}
+
+// startupWarnings collects the problems found while mounting the specs, for the startup report
+var startupWarnings []string
+
+// addStartupWarning logs a startup problem and records it for the startup report
+func addStartupWarning(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	log.Printf("%s", msg)
+	startupWarnings = append(startupWarnings, msg)
+}
+
+// buildStartupReport describes the specs mounted on the gateway
+func buildStartupReport(addr string) StartupReport {
+	report := StartupReport{
+		Version:     version,
+		GitCommit:   gitCommit,
+		Mode:        "file",
+		Addr:        addr,
+		GeneratedAt: time.Now().UTC(),
+		Endpoints:   []StartupEndpoint{},
+		Warnings:    append([]string{}, startupWarnings...),
+	}
+	if databaseMode {
+		report.Mode = "database"
+	}
+	if gateway == nil {
+		return report
+	}
+	for _, m := range gateway.Mounts() {
+		endpoint := StartupEndpoint{Path: m.Path(), Title: m.Title, Aliases: m.Aliases, AuthType: m.AuthType, AuthSet: true}
+		if m.MCP != nil {
+			endpoint.Tools = len(m.MCP.ListTools())
+		}
+		if m.Loaded != nil {
+			endpoint.Operations = len(m.Loaded.Operations)
+			if m.Loaded.ValidationErr != nil {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("%s failed validation and was loaded anyway: %v", m.Path(), m.Loaded.ValidationErr))
+			}
+			checkStartupAuth(&report, &endpoint, m)
+		}
+		if m.Disabled != "" {
+			endpoint.Disabled = m.Disabled
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s is disabled: it %s", m.Path(), m.Disabled))
+		}
+		report.Endpoints = append(report.Endpoints, endpoint)
+	}
+	return report
+}
+
+// checkStartupAuth fills in the credentials a mounted spec needs, and warns when it has none;
+// disabled specs get their own warning
+func checkStartupAuth(report *StartupReport, endpoint *StartupEndpoint, m *dynamicserver.Mount) {
+	envVar, _ := services.RequiredEnvVar(m.Loaded)
+	if envVar == "" {
+		return
+	}
+	endpoint.AuthEnvVar = envVar
+	if source, _ := services.CredentialSource(context.Background(), m.Loaded); source != "" {
+		return
+	}
+	endpoint.AuthSet = false
+	if m.Disabled != "" {
+		return
+	}
+	report.Warnings = append(report.Warnings, fmt.Sprintf("%s has no database token and %s is not set; clients must send credentials", m.Path(), envVar))
+}
+
+// writeStartupReport writes the startup report as JSON to STARTUP_REPORT_PATH, or to stdout
+// when it is "-". Nothing is written when it is not set.
+func writeStartupReport(addr string) {
+	path := os.Getenv("STARTUP_REPORT_PATH")
+	if path == "" {
+		return
+	}
+	data, err := json.MarshalIndent(buildStartupReport(addr), "", "  ")
+	if err != nil {
+		log.Printf("Failed to encode startup report: %v", err)
+		return
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		log.Printf("Failed to write startup report to %s: %v", path, err)
+		return
+	}
+	log.Printf("Startup report written to %s", path)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"github.com/ubermorgenland/openapi-mcp/pkg/database"
+	"github.com/ubermorgenland/openapi-mcp/pkg/openapi2mcp"
+	"github.com/ubermorgenland/openapi-mcp/pkg/telemetry"
+)
+
+// telemetryReporter sends the anonymous usage reports operators opted in to; nil until started
+var telemetryReporter *telemetry.Reporter
+
+// startTelemetry starts the usage reports configured by MCP_TELEMETRY, once the specs are mounted
+func startTelemetry() {
+	telemetryReporter = telemetry.New(telemetry.ConfigFromEnv(version), collectTelemetry)
+	telemetryReporter.Start()
+}
+
+// stopTelemetry stops the usage reports
+func stopTelemetry() {
+	telemetryReporter.Stop()
+}
+
+// collectTelemetry counts the mounted specs, tool calls and sessions by transport
+func collectTelemetry() telemetry.Counts {
+	counts := telemetry.Counts{
+		Transports: sessionsByTransport(),
+		Database:   database.DB != nil,
+	}
+	for _, stats := range openapi2mcp.DefaultLatencyTracker().All() {
+		counts.ToolCalls += stats.Calls
+	}
+	if gateway != nil {
+		counts.Specs = len(gateway.Mounts())
+	}
+	return counts
+}
+
+// sessionsByTransport counts the active sessions of the mounted specs by transport
+func sessionsByTransport() map[string]int {
+	sessions := map[string]int{"streamable_http": 0, "sse": 0}
+	if gateway == nil {
+		return sessions
+	}
+	for _, m := range gateway.Mounts() {
+		if m.Sessions != nil {
+			sessions["streamable_http"] += len(m.Sessions.Sessions())
+		}
+		if m.SSE != nil {
+			sessions["sse"] += m.SSE.SessionCount()
+		}
+	}
+	return sessions
+}
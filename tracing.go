@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/ubermorgenland/openapi-mcp/pkg/tracing"
+)
+
+// startTracing starts the trace exporter configured by the OTEL_* environment variables, so
+// configuration errors are logged at startup rather than on the first request
+func startTracing() {
+	tracing.Default()
+}
+
+// stopTracing exports the spans still buffered
+func stopTracing() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	tracing.Shutdown(ctx)
+}